@@ -0,0 +1,269 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/config"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/keyring"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+)
+
+// Authentication modes selected with GRAFANA_AUTH_MODE
+const (
+	// authAuto picks OAuth when GRAFANA_OAUTH_TOKEN_URL is set, else token
+	// auth when a token is available, else basic auth when GRAFANA_USERNAME
+	// is set, else anonymous access
+	authAuto = "auto"
+	// authToken sends a service account token or API key as a bearer token
+	authToken = "token"
+	// authBasic sends GRAFANA_USERNAME and GRAFANA_PASSWORD
+	authBasic = "basic"
+	// authOAuth sends access tokens from an OAuth 2.0 provider, obtained
+	// with the client credentials grant
+	authOAuth = "oauth"
+	// authNone sends no credentials, for anonymous access
+	authNone = "none"
+)
+
+// authModeAliases maps the other accepted names to a mode
+var authModeAliases = map[string]string{
+	"":                authAuto,
+	"service_account": authToken,
+	"api_key":         authToken,
+	"bearer":          authToken,
+	"oidc":            authOAuth,
+}
+
+// newGrafanaClient builds the Grafana client with the credentials the
+// environment selects and the given TLS setup
+func newGrafanaClient(grafanaURL string, tlsCfg grafana.TLSConfig) (*grafana.Client, error) {
+	client, err := authenticatedClient(grafanaURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.ConfigureTLS(tlsCfg); err != nil {
+		return nil, err
+	}
+	if tlsCfg.InsecureSkipVerify {
+		log.Println("Warning: TLS certificate verification of Grafana is disabled")
+	}
+	return client, nil
+}
+
+func authenticatedClient(grafanaURL string) (*grafana.Client, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("GRAFANA_AUTH_MODE")))
+	if alias, ok := authModeAliases[mode]; ok {
+		mode = alias
+	}
+	username, password := os.Getenv("GRAFANA_USERNAME"), os.Getenv("GRAFANA_PASSWORD")
+
+	switch mode {
+	case authAuto:
+		if os.Getenv("GRAFANA_OAUTH_TOKEN_URL") != "" {
+			return oauthClient(grafanaURL)
+		}
+		if os.Getenv("GRAFANA_API_KEY") == "" && username != "" {
+			return basicAuthClient(grafanaURL, username, password), nil
+		}
+		return grafana.NewClient(grafanaURL, resolveAPIKey(grafanaURL)), nil
+	case authToken:
+		return grafana.NewClient(grafanaURL, resolveAPIKey(grafanaURL)), nil
+	case authBasic:
+		if username == "" {
+			return nil, errors.New("GRAFANA_AUTH_MODE=basic needs GRAFANA_USERNAME")
+		}
+		return basicAuthClient(grafanaURL, username, password), nil
+	case authOAuth:
+		return oauthClient(grafanaURL)
+	case authNone:
+		log.Println("Connecting to Grafana without credentials")
+		return grafana.NewClient(grafanaURL, ""), nil
+	}
+	return nil, fmt.Errorf("unknown GRAFANA_AUTH_MODE %q: use auto, token, basic, oauth, or none", mode)
+}
+
+func basicAuthClient(grafanaURL, username, password string) *grafana.Client {
+	if password == "" {
+		log.Printf("Warning: GRAFANA_PASSWORD not set for basic auth as %s", username)
+	}
+	log.Printf("Authenticating to Grafana as %s with basic auth", username)
+	return grafana.NewBasicAuthClient(grafanaURL, username, password)
+}
+
+// grafanaTLS returns the TLS setup of cfg with the GRAFANA_TLS_* variables
+// applied over it
+func grafanaTLS(cfg *config.ToolsConfig) grafana.TLSConfig {
+	c := cfg.TLS()
+	t := grafana.TLSConfig{
+		CertFile:           c.CertFile,
+		KeyFile:            c.KeyFile,
+		CAFile:             c.CAFile,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+	if v := os.Getenv("GRAFANA_TLS_CERT"); v != "" {
+		t.CertFile = v
+	}
+	if v := os.Getenv("GRAFANA_TLS_KEY"); v != "" {
+		t.KeyFile = v
+	}
+	if v := os.Getenv("GRAFANA_TLS_CA"); v != "" {
+		t.CAFile = v
+	}
+	if v, err := strconv.ParseBool(os.Getenv("GRAFANA_TLS_INSECURE_SKIP_VERIFY")); err == nil {
+		t.InsecureSkipVerify = v
+	}
+	return t
+}
+
+// retryPolicy returns the default retry policy with cfg's settings applied
+func retryPolicy(cfg *config.ToolsConfig) grafana.RetryPolicy {
+	p := grafana.DefaultRetryPolicy()
+	if n := cfg.RetryMaxAttempts(); n > 0 {
+		p.MaxAttempts = n
+	}
+	initial, max := cfg.RetryBackoff()
+	if initial > 0 {
+		p.InitialBackoff = initial
+	}
+	if max > 0 {
+		p.MaxBackoff = max
+	}
+	if jitter, ok := cfg.RetryJitter(); ok {
+		p.Jitter = jitter
+	}
+	return p
+}
+
+// responseCache returns the response cache settings, with configured TTLs
+// replacing the defaults
+func responseCache(cfg *config.ToolsConfig) grafana.ResponseCacheSettings {
+	s := grafana.DefaultResponseCacheSettings()
+	for family, ttl := range map[string]*time.Duration{
+		"folders":     &s.Folders,
+		"datasources": &s.Datasources,
+		"dashboards":  &s.Dashboards,
+	} {
+		if v, ok := cfg.ResponseCacheTTL(family); ok {
+			*ttl = v
+		}
+	}
+	if n := cfg.ResponseCacheSize(); n > 0 {
+		s.Size = n
+	}
+	return s
+}
+
+// oauthClient reads the OAuth client from GRAFANA_OAUTH_* variables. When
+// the access token goes in a header other than Authorization, for a proxy
+// in front of Grafana, the API token still authenticates to Grafana.
+func oauthClient(grafanaURL string) (*grafana.Client, error) {
+	cfg := grafana.OAuthConfig{
+		TokenURL:     os.Getenv("GRAFANA_OAUTH_TOKEN_URL"),
+		ClientID:     os.Getenv("GRAFANA_OAUTH_CLIENT_ID"),
+		ClientSecret: os.Getenv("GRAFANA_OAUTH_CLIENT_SECRET"),
+		Scopes:       strings.FieldsFunc(os.Getenv("GRAFANA_OAUTH_SCOPES"), func(r rune) bool { return r == ',' || r == ' ' }),
+		Audience:     os.Getenv("GRAFANA_OAUTH_AUDIENCE"),
+		Header:       os.Getenv("GRAFANA_OAUTH_HEADER"),
+	}
+	if cfg.TokenURL == "" || cfg.ClientID == "" {
+		return nil, errors.New("OAuth authentication needs GRAFANA_OAUTH_TOKEN_URL and GRAFANA_OAUTH_CLIENT_ID")
+	}
+	if cfg.Header != "" && !strings.EqualFold(cfg.Header, "Authorization") {
+		log.Printf("Authenticating to the proxy with OAuth client %s in %s", cfg.ClientID, cfg.Header)
+		return grafana.NewOAuthClientWithAPIKey(grafanaURL, resolveAPIKey(grafanaURL), cfg), nil
+	}
+	log.Printf("Authenticating to Grafana with OAuth client %s", cfg.ClientID)
+	return grafana.NewOAuthClient(grafanaURL, cfg), nil
+}
+
+// tokenExpirySettings reads the token section of the config. Rotated tokens
+// are saved only when the token came from the OS keyring: one passed in
+// GRAFANA_API_KEY would be revoked and still used after a restart.
+func tokenExpirySettings(cfg *config.ToolsConfig, grafanaURL string, tlsCfg grafana.TLSConfig) tools.TokenExpirySettings {
+	s := tools.DefaultTokenExpirySettings()
+	if warn, ok := cfg.TokenExpiryWarning(); ok {
+		s.Warning = warn
+	}
+	if every, ok := cfg.TokenCheckInterval(); ok {
+		s.Interval = every
+	}
+	rotate, ttl, ok := cfg.TokenRotation()
+	s.Rotate = rotate
+	if ok {
+		s.RotationTTL = ttl
+	}
+	if key := cfg.TokenAdminToken(); key != "" {
+		s.Admin = grafana.NewClient(grafanaURL, key)
+		if err := s.Admin.ConfigureTLS(tlsCfg); err != nil {
+			log.Printf("Warning: token.admin_token not used: %v", err)
+			s.Admin = nil
+		}
+	}
+	if s.Rotate && os.Getenv("GRAFANA_API_KEY") == "" {
+		account := keyringAccount(grafanaURL)
+		if _, err := keyring.Get(keyring.Service, account); err == nil {
+			s.Save = func(key string) error {
+				return keyring.Set(keyring.Service, account, key)
+			}
+		}
+	}
+	return s
+}
+
+// instanceClient builds the client of a further Grafana instance from its
+// config entry, with the retry and response cache settings of cfg. Without
+// a tls entry of its own the instance uses the tls section, but not the
+// GRAFANA_TLS_* variables, which belong to GRAFANA_URL.
+func instanceClient(inst config.InstanceConfig, cfg *config.ToolsConfig) (*grafana.Client, error) {
+	var client *grafana.Client
+	switch {
+	case inst.APIKey != "":
+		client = grafana.NewClient(inst.URL, inst.APIKey)
+	case inst.Username != "":
+		client = grafana.NewBasicAuthClient(inst.URL, inst.Username, inst.Password)
+	default:
+		log.Printf("Connecting to instance %s without credentials", inst.Name)
+		client = grafana.NewClient(inst.URL, "")
+	}
+	t := inst.TLS
+	if t == (config.TLSConfig{}) {
+		t = cfg.TLS()
+	}
+	tlsCfg := grafana.TLSConfig{
+		CertFile:           t.CertFile,
+		KeyFile:            t.KeyFile,
+		CAFile:             t.CAFile,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+	if err := client.ConfigureTLS(tlsCfg); err != nil {
+		return nil, err
+	}
+	if tlsCfg.InsecureSkipVerify {
+		log.Printf("Warning: TLS certificate verification of instance %s is disabled", inst.Name)
+	}
+	client.SetRetryPolicy(retryPolicy(cfg))
+	client.SetResponseCache(responseCache(cfg))
+	client.SetOrgID(inst.OrgID)
+	return client, nil
+}
+
+// grafanaOrgID returns the organization GRAFANA_ORG_ID selects, or 0 for the
+// user's current organization
+func grafanaOrgID() (int64, error) {
+	v := strings.TrimSpace(os.Getenv("GRAFANA_ORG_ID"))
+	if v == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("GRAFANA_ORG_ID must be a positive organization ID, got %q", v)
+	}
+	return id, nil
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+)
+
+// The HTTP transport implements MCP's Streamable HTTP transport on a single
+// endpoint. Clients POST JSON-RPC messages and receive the responses in the
+// HTTP response; a GET opens a server-sent event stream for messages that
+// answer no request. Sessions start with initialize, are named by the
+// Mcp-Session-Id header, and end with a DELETE or after idling.
+const (
+	httpPath = "/mcp"
+
+	// httpMaxBody bounds a POSTed message, which may carry a whole
+	// dashboard or manifest
+	httpMaxBody = 32 << 20
+
+	// httpStreamBuffer bounds the messages queued for a slow event stream;
+	// further messages are dropped
+	httpStreamBuffer = 100
+)
+
+// httpTransport serves the MCP endpoint and tracks its sessions
+type httpTransport struct {
+	listen   listenConfig
+	registry *tools.Registry
+	settings sessionSettings
+
+	mu       sync.Mutex
+	sessions map[string]*httpSession
+}
+
+func (t *httpTransport) Serve(registry *tools.Registry) error {
+	t.registry = registry
+	go t.expireSessions()
+
+	mux := http.NewServeMux()
+	mux.Handle(httpPath, t)
+	log.Printf("Listening for MCP over %s on %s%s", t.listen.scheme("HTTP", "HTTPS"), t.listen.Addr, httpPath)
+	return t.listen.serve(mux)
+}
+
+func (t *httpTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !checkOrigin(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		t.handlePost(w, r)
+	case http.MethodGet:
+		t.handleStream(w, r)
+	case http.MethodDelete:
+		t.handleDelete(w, r)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handlePost dispatches a message or batch of messages. Requests are
+// answered in the response body, as one object or, for a batch, an array;
+// notifications and client responses are acknowledged with 202.
+func (t *httpTransport) handlePost(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, httpMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	msgs, batch, err := splitBatch(body)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, mcp.ParseError, "Parse error", err.Error())
+		return
+	}
+
+	var ids []string
+	var calls [][]byte
+	initialize := false
+	for _, data := range msgs {
+		var msg struct {
+			Method string `json:"method"`
+		}
+		if json.Unmarshal(data, &msg) != nil || msg.Method == "" {
+			// A response to a server request; the server sends none
+			continue
+		}
+		calls = append(calls, data)
+		if id := messageID(data); id != "" {
+			ids = append(ids, id)
+		}
+		initialize = initialize || msg.Method == "initialize"
+	}
+
+	sess, status, reason := t.session(r, initialize)
+	if sess == nil {
+		http.Error(w, reason, status)
+		return
+	}
+	if initialize {
+		w.Header().Set(sessionHeader, sess.id)
+	}
+
+	waits, err := sess.expect(ids)
+	if err != nil {
+		writeHTTPError(w, http.StatusConflict, mcp.InvalidRequest, "Invalid request", err.Error())
+		return
+	}
+	for _, data := range calls {
+		sess.server.handleMessage(data)
+	}
+	if len(ids) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	replies := make([][]byte, 0, len(ids))
+	for _, id := range ids {
+		select {
+		case data, ok := <-waits[id]:
+			if !ok {
+				http.Error(w, "session closed", http.StatusNotFound)
+				return
+			}
+			replies = append(replies, data)
+		case <-r.Context().Done():
+			// The client gave up, so its calls are cancelled
+			sess.forget(ids)
+			for _, id := range ids {
+				sess.server.cancel(id, "client disconnected")
+			}
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		fmt.Fprintf(w, "[%s]", bytes.Join(replies, []byte(",")))
+		return
+	}
+	w.Write(replies[0])
+}
+
+// handleStream holds a server-sent event stream open for messages that
+// answer no request. A session has at most one stream; a new GET replaces
+// the previous one.
+func (t *httpTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		http.Error(w, "GET opens an event stream and must accept text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	sess, status, reason := t.session(r, false)
+	if sess == nil {
+		http.Error(w, reason, status)
+		return
+	}
+
+	stream := sess.openStream()
+	defer sess.closeStream(stream)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	// Comments keep proxies from timing out a quiet stream
+	var keepalive <-chan time.Time
+	if every := t.settings.PingInterval; every > 0 {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		keepalive = ticker.C
+	}
+	for {
+		select {
+		case data, ok := <-stream:
+			if !ok {
+				return
+			}
+			if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		case <-keepalive:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleDelete ends a session at the client's request
+func (t *httpTransport) handleDelete(w http.ResponseWriter, r *http.Request) {
+	sess, status, reason := t.session(r, false)
+	if sess == nil {
+		http.Error(w, reason, status)
+		return
+	}
+	t.mu.Lock()
+	delete(t.sessions, sess.id)
+	release := len(t.sessions) == 0
+	t.mu.Unlock()
+	sess.close()
+	log.Printf("HTTP session %s closed by the client", sess.id)
+	if release {
+		t.registry.ReleaseIdle()
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// session returns the session named by the Mcp-Session-Id header, or
+// starts one for an initialize request. Otherwise it returns the status
+// and reason to refuse the request with.
+func (t *httpTransport) session(r *http.Request, initialize bool) (*httpSession, int, string) {
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		if !initialize {
+			return nil, http.StatusBadRequest, sessionHeader + " header is required; start a session with initialize"
+		}
+		sess, err := t.newSession()
+		if err != nil {
+			return nil, http.StatusInternalServerError, err.Error()
+		}
+		t.mu.Lock()
+		t.sessions[sess.id] = sess
+		t.mu.Unlock()
+		log.Printf("HTTP session %s started from %s", sess.id, r.RemoteAddr)
+		return sess, 0, ""
+	}
+	t.mu.Lock()
+	sess := t.sessions[id]
+	t.mu.Unlock()
+	if sess == nil {
+		return nil, http.StatusNotFound, "unknown or expired session"
+	}
+	sess.touch()
+	return sess, 0, ""
+}
+
+func (t *httpTransport) newSession() (*httpSession, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	sess := &httpSession{
+		id:         hex.EncodeToString(b),
+		lastActive: time.Now(),
+		waiting:    make(map[string]chan []byte),
+	}
+	sess.server = &Server{registry: t.registry, writer: sess, session: sess.id}
+	return sess, nil
+}
+
+// expireSessions closes sessions idle for longer than the idle timeout.
+// HTTP clients often go away without a DELETE, so with no idle timeout
+// sessions live until the server stops. Once none are left, the registry's
+// caches and Grafana connections are released.
+func (t *httpTransport) expireSessions() {
+	idle := t.settings.IdleTimeout
+	if idle <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.settings.sweepInterval())
+	defer ticker.Stop()
+	for now := range ticker.C {
+		t.mu.Lock()
+		closed := 0
+		for id, sess := range t.sessions {
+			if sess.idleSince(now) >= idle {
+				delete(t.sessions, id)
+				sess.close()
+				closed++
+				log.Printf("HTTP session %s closed: idle for %s", id, idle)
+			}
+		}
+		release := closed > 0 && len(t.sessions) == 0
+		t.mu.Unlock()
+		if release {
+			t.registry.ReleaseIdle()
+		}
+	}
+}
+
+// httpSession is an MCP session spread over many HTTP requests. Responses
+// go to the POST waiting for their id; anything else goes to the event
+// stream when one is open.
+type httpSession struct {
+	id     string
+	server *Server
+
+	mu         sync.Mutex
+	closed     bool
+	lastActive time.Time
+	waiting    map[string]chan []byte
+	stream     chan []byte
+}
+
+func (s *httpSession) touch() {
+	s.mu.Lock()
+	s.lastActive = time.Now()
+	s.mu.Unlock()
+}
+
+// idleSince returns how long the session has had no requests and no
+// running calls
+func (s *httpSession) idleSince(now time.Time) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.waiting) > 0 || s.stream != nil {
+		return 0
+	}
+	return now.Sub(s.lastActive)
+}
+
+// expect registers the request ids a POST waits for. An id already
+// waiting in the session is refused, as its response could not be told
+// apart.
+func (s *httpSession) expect(ids []string) (map[string]chan []byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	waits := make(map[string]chan []byte, len(ids))
+	for _, id := range ids {
+		if _, dup := s.waiting[id]; dup || waits[id] != nil {
+			return nil, fmt.Errorf("request id %s is already in use", id)
+		}
+		waits[id] = make(chan []byte, 1)
+	}
+	for id, ch := range waits {
+		s.waiting[id] = ch
+	}
+	return waits, nil
+}
+
+// forget drops the waits of a POST whose client disconnected
+func (s *httpSession) forget(ids []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		delete(s.waiting, id)
+	}
+	s.lastActive = time.Now()
+}
+
+func (s *httpSession) openStream() chan []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream != nil {
+		close(s.stream)
+	}
+	s.stream = make(chan []byte, httpStreamBuffer)
+	return s.stream
+}
+
+func (s *httpSession) closeStream(stream chan []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stream == stream {
+		close(s.stream)
+		s.stream = nil
+		s.lastActive = time.Now()
+	}
+}
+
+// close ends the session, cancels calls still running, and ends its
+// resource subscriptions
+func (s *httpSession) close() {
+	s.server.cancelAll()
+	s.server.unsubscribeAll()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	if s.stream != nil {
+		close(s.stream)
+		s.stream = nil
+	}
+	for id, ch := range s.waiting {
+		close(ch)
+		delete(s.waiting, id)
+	}
+}
+
+// WriteMessage routes a message to the POST waiting for its id, or to the
+// event stream
+func (s *httpSession) WriteMessage(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if id := messageID(data); id != "" {
+		if ch, ok := s.waiting[id]; ok {
+			delete(s.waiting, id)
+			s.lastActive = time.Now()
+			ch <- data
+			return nil
+		}
+	}
+	if s.stream == nil {
+		return nil
+	}
+	select {
+	case s.stream <- data:
+	default:
+		log.Printf("HTTP session %s: event stream is full, dropping a message", s.id)
+	}
+	return nil
+}
+
+// checkOrigin refuses requests from web pages of other sites. Browsers send
+// Origin, so this protects servers reachable only from localhost against
+// DNS rebinding.
+func checkOrigin(w http.ResponseWriter, r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || u.Host != r.Host {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return false
+		}
+	}
+	return true
+}
+
+// splitBatch returns the messages of a POST body, which holds one JSON-RPC
+// message or an array of them
+func splitBatch(body []byte) ([][]byte, bool, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var raw []json.RawMessage
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, false, err
+		}
+		if len(raw) == 0 {
+			return nil, false, fmt.Errorf("empty batch")
+		}
+		msgs := make([][]byte, len(raw))
+		for i, m := range raw {
+			msgs[i] = m
+		}
+		return msgs, true, nil
+	}
+	if !json.Valid(body) {
+		return nil, false, fmt.Errorf("body is not valid JSON")
+	}
+	return [][]byte{body}, false, nil
+}
+
+// writeHTTPError answers a POST that cannot be dispatched with a JSON-RPC
+// error without an id
+func writeHTTPError(w http.ResponseWriter, status, code int, message, details string) {
+	data, _ := json.Marshal(mcp.Response{
+		JSONRPC: "2.0",
+		Error: &mcp.Error{
+			Code:    code,
+			Message: message,
+			Data:    &mcp.ErrorData{Details: details},
+		},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultListenAddr is where network transports listen when no address is
+// given: the loopback interface only
+const defaultListenAddr = "127.0.0.1:8080"
+
+// Connection timeouts for network transports. There is no read or write
+// timeout on whole requests: SSE streams and WebSocket sessions stay open
+// for as long as the client is connected.
+const (
+	readHeaderTimeout = 10 * time.Second
+	idleTimeout       = 2 * time.Minute
+)
+
+// listenConfig is where a network transport listens and how it
+// authenticates clients. Every tool runs with the server's Grafana
+// credentials, so clients must present the bearer token, a client
+// certificate signed by ClientCAFile, or both when both are set.
+type listenConfig struct {
+	Addr string
+	// Token is the bearer token clients send in the Authorization header
+	Token string
+	// CertFile and KeyFile serve TLS
+	CertFile string
+	KeyFile  string
+	// ClientCAFile holds the PEM CA certificates client certificates must
+	// be signed by (mutual TLS)
+	ClientCAFile string
+}
+
+// listenConfigFromEnv returns the listen configuration for addr with the
+// MCP_AUTH_TOKEN and MCP_TLS_* variables applied
+func listenConfigFromEnv(addr string) listenConfig {
+	return listenConfig{
+		Addr:         addr,
+		Token:        strings.TrimSpace(os.Getenv("MCP_AUTH_TOKEN")),
+		CertFile:     os.Getenv("MCP_TLS_CERT"),
+		KeyFile:      os.Getenv("MCP_TLS_KEY"),
+		ClientCAFile: os.Getenv("MCP_TLS_CLIENT_CA"),
+	}
+}
+
+// check reports a configuration that would leave the transport open to
+// anyone who can reach it
+func (c listenConfig) check() error {
+	if (c.CertFile == "") != (c.KeyFile == "") {
+		return errors.New("MCP_TLS_CERT and MCP_TLS_KEY must be set together")
+	}
+	if c.ClientCAFile != "" && c.CertFile == "" {
+		return errors.New("MCP_TLS_CLIENT_CA needs MCP_TLS_CERT and MCP_TLS_KEY to serve TLS")
+	}
+	if c.Token == "" && c.ClientCAFile == "" {
+		return errors.New("network transports require client authentication: set MCP_AUTH_TOKEN to a bearer token clients must send, or MCP_TLS_CLIENT_CA for mutual TLS")
+	}
+	return nil
+}
+
+// server returns the HTTP server for handler, refusing requests that fail
+// authentication before they reach it. Clients that are slow to send
+// request headers or leave keep-alive connections idle are disconnected.
+func (c listenConfig) server(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              c.Addr,
+		Handler:           c.authenticate(handler),
+		ReadHeaderTimeout: readHeaderTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+}
+
+// serve listens on the configured address
+func (c listenConfig) serve(handler http.Handler) error {
+	srv := c.server(handler)
+	if c.CertFile == "" {
+		return srv.ListenAndServe()
+	}
+	srv.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.ClientCAFile != "" {
+		pem, err := os.ReadFile(c.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("reading MCP_TLS_CLIENT_CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("MCP_TLS_CLIENT_CA %s holds no PEM certificates", c.ClientCAFile)
+		}
+		srv.TLSConfig.ClientCAs = pool
+		srv.TLSConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return srv.ListenAndServeTLS(c.CertFile, c.KeyFile)
+}
+
+// scheme is the URL scheme clients reach the transport with
+func (c listenConfig) scheme(plain, secure string) string {
+	if c.CertFile != "" {
+		return secure
+	}
+	return plain
+}
+
+// authenticate refuses requests without the bearer token with 401. Client
+// certificates are verified in the TLS handshake, before any request.
+func (c listenConfig) authenticate(next http.Handler) http.Handler {
+	if c.Token == "" {
+		return next
+	}
+	want := []byte(c.Token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), want) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="grafana-mcp"`)
+			http.Error(w, "missing or invalid bearer token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestListenConfigCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     listenConfig
+		wantErr string
+	}{
+		{"token", listenConfig{Token: "s3cret"}, ""},
+		{"mutual TLS", listenConfig{CertFile: "c.pem", KeyFile: "k.pem", ClientCAFile: "ca.pem"}, ""},
+		{"token over TLS", listenConfig{Token: "s3cret", CertFile: "c.pem", KeyFile: "k.pem"}, ""},
+		{"no authentication", listenConfig{}, "require client authentication"},
+		{"TLS without client auth", listenConfig{CertFile: "c.pem", KeyFile: "k.pem"}, "require client authentication"},
+		{"cert without key", listenConfig{Token: "s3cret", CertFile: "c.pem"}, "set together"},
+		{"client CA without TLS", listenConfig{ClientCAFile: "ca.pem"}, "to serve TLS"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.check()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("check() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("check() = %v, want an error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	reached := false
+	h := listenConfig{Token: "s3cret"}.authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	tests := []struct {
+		header string
+		want   int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Basic s3cret", http.StatusUnauthorized},
+		{"Bearer s3cret", http.StatusOK},
+		{"bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		reached = false
+		req := httptest.NewRequest(http.MethodPost, httpPath, strings.NewReader(`{}`))
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Authorization %q: status %d, want %d", tt.header, rec.Code, tt.want)
+		}
+		if reached != (tt.want == http.StatusOK) {
+			t.Errorf("Authorization %q: handler reached = %v", tt.header, reached)
+		}
+		if tt.want == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("Authorization %q: no WWW-Authenticate challenge", tt.header)
+		}
+	}
+}
+
+// TestHTTPTransportRequiresToken checks a session cannot be started on the
+// Streamable HTTP endpoint without the bearer token
+func TestHTTPTransportRequiresToken(t *testing.T) {
+	tr := &httpTransport{listen: listenConfig{Token: "s3cret"}, sessions: map[string]*httpSession{}}
+	srv := httptest.NewServer(tr.listen.authenticate(tr))
+	defer srv.Close()
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`
+	resp, err := http.Post(srv.URL+httpPath, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", resp.StatusCode)
+	}
+	if len(tr.sessions) != 0 {
+		t.Fatalf("%d sessions started by an unauthenticated request", len(tr.sessions))
+	}
+}
+
+// TestSSETransportRequiresToken checks neither the event stream nor the
+// message endpoint of the SSE transport is reachable without the token
+func TestSSETransportRequiresToken(t *testing.T) {
+	tr := &sseTransport{listen: listenConfig{Token: "s3cret"}, sessions: map[string]*sseSession{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc(ssePath, tr.handleStream)
+	mux.HandleFunc(sseMessages, tr.handleMessage)
+	srv := httptest.NewServer(tr.listen.authenticate(mux))
+	defer srv.Close()
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest(http.MethodGet, srv.URL+ssePath, nil),
+		httptest.NewRequest(http.MethodPost, srv.URL+sseMessages+"?sessionId=x", strings.NewReader(`{}`)),
+	} {
+		req.RequestURI = ""
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Errorf("%s %s: status %d, want 401", req.Method, req.URL.Path, resp.StatusCode)
+		}
+	}
+	if len(tr.sessions) != 0 {
+		t.Fatalf("%d sessions started by unauthenticated requests", len(tr.sessions))
+	}
+}
+
+// TestWebSocketTransportRequiresToken checks the upgrade is refused before
+// a session starts when the handshake lacks the token
+func TestWebSocketTransportRequiresToken(t *testing.T) {
+	tr := &wsTransport{listen: listenConfig{Token: "s3cret"}, sessions: map[string]*wsSession{}}
+	srv := httptest.NewServer(tr.listen.authenticate(tr))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+wsPath, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401", resp.StatusCode)
+	}
+	if len(tr.sessions) != 0 {
+		t.Fatalf("%d sessions started by an unauthenticated handshake", len(tr.sessions))
+	}
+}
+
+// TestServerTimeouts checks clients cannot hold connections open by
+// trickling request headers or idling between requests
+func TestServerTimeouts(t *testing.T) {
+	srv := listenConfig{Addr: "127.0.0.1:0", Token: "s3cret"}.server(http.NotFoundHandler())
+	if srv.ReadHeaderTimeout <= 0 || srv.IdleTimeout <= 0 {
+		t.Fatalf("ReadHeaderTimeout %v, IdleTimeout %v, want both set", srv.ReadHeaderTimeout, srv.IdleTimeout)
+	}
+	if srv.ReadTimeout != 0 || srv.WriteTimeout != 0 {
+		t.Fatalf("ReadTimeout %v, WriteTimeout %v, want none so streams stay open", srv.ReadTimeout, srv.WriteTimeout)
+	}
+	rec := httptest.NewRecorder()
+	srv.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, httpPath, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated request got %d, want 401", rec.Code)
+	}
+}
+
+// TestWebSocketTransportChecksOrigin checks a page on another site cannot
+// open a session, even with valid credentials
+func TestWebSocketTransportChecksOrigin(t *testing.T) {
+	tr := &wsTransport{listen: listenConfig{Token: "s3cret"}, sessions: map[string]*wsSession{}}
+	srv := httptest.NewServer(tr.listen.authenticate(tr))
+	defer srv.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL+wsPath, nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	req.Header.Set("Origin", "https://attacker.example")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status %d, want 403", resp.StatusCode)
+	}
+	if len(tr.sessions) != 0 {
+		t.Fatalf("%d sessions started by a cross-origin handshake", len(tr.sessions))
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/config"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/keyring"
+)
+
+// The login and logout commands keep the API token in the OS keyring,
+// keyed by Grafana URL, so MCP client configurations need only GRAFANA_URL.
+
+// defaultGrafanaURL is used when GRAFANA_URL is unset
+const defaultGrafanaURL = "http://localhost:3000"
+
+// grafanaURLFromEnv returns GRAFANA_URL or the default
+func grafanaURLFromEnv() string {
+	if u := os.Getenv("GRAFANA_URL"); u != "" {
+		return u
+	}
+	return defaultGrafanaURL
+}
+
+// keyringAccount is the keyring account a Grafana URL's token is stored
+// under
+func keyringAccount(grafanaURL string) string {
+	return strings.TrimSuffix(grafanaURL, "/")
+}
+
+// resolveAPIKey returns GRAFANA_API_KEY, else the token stored by login
+func resolveAPIKey(grafanaURL string) string {
+	if key := os.Getenv("GRAFANA_API_KEY"); key != "" {
+		return key
+	}
+	key, err := keyring.Get(keyring.Service, keyringAccount(grafanaURL))
+	switch {
+	case err == nil:
+		log.Printf("Using the API token stored in the OS keyring for %s", keyringAccount(grafanaURL))
+		return key
+	case errors.Is(err, keyring.ErrNotFound):
+		log.Printf("Warning: GRAFANA_API_KEY not set and no token stored for %s; run `grafana-mcp login`, some operations may fail", keyringAccount(grafanaURL))
+	case errors.Is(err, keyring.ErrUnavailable):
+		log.Println("Warning: GRAFANA_API_KEY not set, some operations may fail")
+	default:
+		log.Printf("Warning: GRAFANA_API_KEY not set and the OS keyring could not be read (%v), some operations may fail", err)
+	}
+	return ""
+}
+
+// runLogin prompts for an API token, checks it against Grafana, and stores
+// it in the OS keyring
+func runLogin(args []string) int {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	grafanaURL := fs.String("url", grafanaURLFromEnv(), "Grafana URL the token belongs to (default $GRAFANA_URL)")
+	noVerify := fs.Bool("no-verify", false, "store the token without checking it against Grafana")
+	fs.Parse(args)
+	account := keyringAccount(*grafanaURL)
+
+	token, err := readToken(fmt.Sprintf("API token for %s: ", account))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not read the token: %v\n", err)
+		return 1
+	}
+	if token == "" {
+		fmt.Fprintln(os.Stderr, "No token given")
+		return 1
+	}
+
+	if !*noVerify {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+			return 1
+		}
+		client := grafana.NewClient(account, token)
+		if err := client.ConfigureTLS(grafanaTLS(cfg)); err != nil {
+			fmt.Fprintf(os.Stderr, "Configuration error: %v\n", err)
+			return 1
+		}
+		org, err := client.GetCurrentOrg(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Grafana rejected the token: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Token works for organization %q\n", org.Name)
+	}
+	if err := keyring.Set(keyring.Service, account, token); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not store the token: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Stored the token for %s in the OS keyring; GRAFANA_API_KEY can be removed from client configs\n", account)
+	return 0
+}
+
+// runLogout removes a stored token
+func runLogout(args []string) int {
+	fs := flag.NewFlagSet("logout", flag.ExitOnError)
+	grafanaURL := fs.String("url", grafanaURLFromEnv(), "Grafana URL whose token to remove (default $GRAFANA_URL)")
+	fs.Parse(args)
+	account := keyringAccount(*grafanaURL)
+
+	err := keyring.Delete(keyring.Service, account)
+	if errors.Is(err, keyring.ErrNotFound) {
+		fmt.Fprintf(os.Stderr, "No token stored for %s\n", account)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not remove the token: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "Removed the token for %s from the OS keyring\n", account)
+	return 0
+}
+
+// readToken reads one line from stdin, turning off echo when stdin is a
+// terminal that supports it. Piped input is read as is.
+func readToken(prompt string) (string, error) {
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(os.Stderr, prompt)
+		if runtime.GOOS != "windows" && stty("-echo") == nil {
+			defer func() {
+				stty("echo")
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func stty(mode string) error {
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
@@ -2,15 +2,27 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"regexp"
+	"sync"
+	_ "time/tzdata" // output.timezone must resolve in images without zoneinfo
 
 	"github.com/npcomplete777/grafana-mcp/internal/config"
 	"github.com/npcomplete777/grafana-mcp/internal/grafana"
 	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/notify"
+	"github.com/npcomplete777/grafana-mcp/internal/scheduler"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
 	"github.com/npcomplete777/grafana-mcp/internal/tools"
 )
 
@@ -20,24 +32,48 @@ const (
 	protocolVersion = "2024-11-05"
 )
 
-// Server handles MCP protocol communication
+// Server handles the MCP protocol for one session. Transports create one
+// per client session and feed it messages; the registry is shared.
 type Server struct {
 	registry *tools.Registry
 	reader   *bufio.Reader
-	writer   io.Writer
+	writer   messageWriter
+	writeMu  sync.Mutex
+	calls    sync.WaitGroup
+
+	// inflight cancels running tool calls by request ID
+	inflightMu sync.Mutex
+	inflight   map[string]context.CancelFunc
+
+	// subscriptions ends the session's resource subscriptions by URI
+	subscriptionsMu sync.Mutex
+	subscriptions   map[string]func()
+
+	// orgs holds the organizations grafana_switch_org moved this session
+	// to, created on the first tracked request
+	orgs *grafana.SessionOrgs
+
+	// session identifies the session to the registry, which keeps state
+	// such as its chosen Grafana instance under it
+	session string
 }
 
 func main() {
-	// Get configuration from environment
-	grafanaURL := os.Getenv("GRAFANA_URL")
-	if grafanaURL == "" {
-		grafanaURL = "http://localhost:3000"
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "login":
+			os.Exit(runLogin(os.Args[2:]))
+		case "logout":
+			os.Exit(runLogout(os.Args[2:]))
+		}
 	}
 
-	apiKey := os.Getenv("GRAFANA_API_KEY")
-	if apiKey == "" {
-		log.Println("Warning: GRAFANA_API_KEY not set, some operations may fail")
-	}
+	transport := flag.String("transport", os.Getenv("MCP_TRANSPORT"), "MCP transport: stdio, websocket, http, or sse (default $MCP_TRANSPORT, else stdio)")
+	listen := flag.String("listen", os.Getenv("MCP_LISTEN_ADDR"), "listen address for network transports (default $MCP_LISTEN_ADDR, else 127.0.0.1:8080)")
+	flag.Parse()
+
+	// Get configuration from environment
+	grafanaURL := grafanaURLFromEnv()
 
 	// Load tool enable/disable config (config.yaml or GRAFANA_CONFIG_FILE)
 	toolCfg, err := config.Load()
@@ -46,66 +82,353 @@ func main() {
 	}
 
 	// Create Grafana client
-	client := grafana.NewClient(grafanaURL, apiKey)
+	tlsCfg := grafanaTLS(toolCfg)
+	client, err := newGrafanaClient(grafanaURL, tlsCfg)
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+	client.SetRetryPolicy(retryPolicy(toolCfg))
+	client.SetResponseCache(responseCache(toolCfg))
+	orgID, err := grafanaOrgID()
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+	client.SetOrgID(orgID)
+
+	opts := []tools.Option{tools.WithConcurrencyLimits(tools.ConcurrencyLimits{
+		MaxCalls:             toolCfg.MaxConcurrentCalls(),
+		PerTool:              toolCfg.MaxConcurrent,
+		SerializeWritesByUID: toolCfg.SerializeWritesByUID(),
+	})}
+
+	render := tools.DefaultRenderSettings()
+	if ttl, ok := toolCfg.RenderCacheTTL(); ok {
+		render.CacheTTL = ttl
+	}
+	if n := toolCfg.RenderCacheSize(); n > 0 {
+		render.CacheSize = n
+	}
+	if n := toolCfg.MaxConcurrentRenders(); n > 0 {
+		render.MaxConcurrent = n
+	}
+	opts = append(opts, tools.WithRenderSettings(render))
+
+	queryCache := tools.DefaultQueryCacheSettings()
+	if ttl, ok := toolCfg.QueryCacheTTL(); ok {
+		queryCache.TTL = ttl
+	}
+	if n := toolCfg.QueryCacheSize(); n > 0 {
+		queryCache.Size = n
+	}
+	if step := toolCfg.QueryCacheMinStep(); step > 0 {
+		queryCache.MinStep = step
+	}
+	opts = append(opts, tools.WithQueryCache(queryCache))
+
+	if toolCfg.InventoryPrefetch() {
+		inventory := tools.DefaultInventorySettings()
+		if every, ok := toolCfg.InventoryInterval(); ok {
+			inventory.Interval = every
+		}
+		opts = append(opts, tools.WithInventoryPrefetch(inventory))
+	}
+	opts = append(opts, tools.WithFiringAlertsInterval(toolCfg.FiringAlertsInterval()))
+
+	if g, ok := toolCfg.Guardrails(); ok {
+		maxRange, maxWindow := toolCfg.GuardrailRanges()
+		opts = append(opts, tools.WithQueryGuardrails(tools.QueryGuardrails{
+			Refuse:           g.Action == "refuse",
+			MaxTimeRange:     maxRange,
+			MaxRangeSelector: maxWindow,
+			MaxSeries:        g.MaxSeries,
+			MaxLogBytes:      g.MaxLogBytes,
+			MaxLogStreams:    g.MaxLogStreams,
+		}))
+	}
+
+	if dir := toolCfg.ExportDir(); dir != "" {
+		opts = append(opts, tools.WithExportDir(dir))
+	}
+	if dir := toolCfg.JsonnetDir(); dir != "" {
+		opts = append(opts, tools.WithJsonnetDir(dir))
+	}
+	if d := toolCfg.JsonnetTimeout(); d > 0 {
+		opts = append(opts, tools.WithJsonnetTimeout(d))
+	}
+
+	if ttl, ok := toolCfg.Confirmation(); ok {
+		opts = append(opts, tools.WithConfirmation(ttl))
+	}
+
+	loc, layout := toolCfg.OutputTimeFormat()
+	opts = append(opts, tools.WithTimeFormat(tools.TimeFormat{Location: loc, Layout: layout}))
+
+	if m, ok := toolCfg.Mimir(); ok {
+		opts = append(opts, tools.WithMimir(grafana.NewRuler(grafana.RulerConfig{
+			URL:      m.URL,
+			Tenant:   m.Tenant,
+			Username: m.Username,
+			Password: m.Password,
+			Token:    m.Token,
+			Headers:  m.Headers,
+		})))
+	}
+
+	if a, ok := toolCfg.DatasourceAccess(); ok {
+		access := tools.DatasourceAccess{
+			AllowUIDs:  a.AllowDatasources,
+			AllowTypes: a.AllowTypes,
+			DenyUIDs:   a.DenyDatasources,
+			DenyTypes:  a.DenyTypes,
+		}
+		for _, rule := range a.DenyExpressions {
+			access.DenyExpressions = append(access.DenyExpressions, tools.ExpressionRule{
+				Pattern: regexp.MustCompile(rule.Pattern),
+				UIDs:    rule.Datasources,
+				Types:   rule.Types,
+			})
+		}
+		opts = append(opts, tools.WithDatasourceAccess(access))
+	}
+
+	// Options only the GRAFANA_URL instance takes: other instances have
+	// their own credentials, no state directory, and no local provisioning
+	primaryOpts := []tools.Option{tools.WithInstanceName(toolCfg.DefaultInstance())}
+	if path := toolCfg.ProvisioningDashboardsPath(); path != "" {
+		primaryOpts = append(primaryOpts, tools.WithProvisioningPath(path))
+	}
+	if tokenCfg := tokenExpirySettings(toolCfg, grafanaURL, tlsCfg); tokenCfg.Warning > 0 {
+		primaryOpts = append(primaryOpts, tools.WithTokenExpiry(tokenCfg))
+	}
+
+	var urlPolicy *tools.URLPolicy
+	if p, ok := toolCfg.URLPolicy(); ok {
+		policy := tools.URLPolicy{
+			AllowHosts:  p.AllowHosts,
+			DenyHosts:   p.DenyHosts,
+			DenyPrivate: p.DenyPrivate,
+		}
+		for _, cidr := range p.AllowCIDRs {
+			_, n, _ := net.ParseCIDR(cidr)
+			policy.AllowCIDRs = append(policy.AllowCIDRs, n)
+		}
+		for _, cidr := range p.DenyCIDRs {
+			_, n, _ := net.ParseCIDR(cidr)
+			policy.DenyCIDRs = append(policy.DenyCIDRs, n)
+		}
+		opts = append(opts, tools.WithURLPolicy(policy))
+		urlPolicy = &policy
+	}
+
+	primaryOpts = append(primaryOpts, detectGrafana(client, "")...)
 
-	// Create tool registry
-	registry := tools.NewRegistry(client, toolCfg.IsEnabled)
+	// Persist the render cache and job history across restarts when STATE_DIR is set
+	var store *state.Store
+	if dir := os.Getenv("STATE_DIR"); dir != "" {
+		if store, err = state.Open(dir); err != nil {
+			log.Fatalf("Configuration error: %v", err)
+		}
+		primaryOpts = append(primaryOpts, tools.WithStateStore(store))
+		log.Printf("State directory: %s", dir)
+	}
 
-	// Create server
-	server := &Server{
-		registry: registry,
-		reader:   bufio.NewReader(os.Stdin),
-		writer:   os.Stdout,
+	var notifier *notify.Notifier
+	if hooks := toolCfg.Webhooks(); len(hooks) > 0 {
+		webhooks := make([]notify.Webhook, 0, len(hooks))
+		for _, h := range hooks {
+			webhooks = append(webhooks, notify.Webhook{
+				Name:        h.Name,
+				URL:         h.URL,
+				Format:      h.Format,
+				On:          h.On,
+				Template:    h.Template,
+				Headers:     h.Headers,
+				IncludeArgs: h.IncludeArgs,
+			})
+		}
+		checkURL := func(url string) error { return urlPolicy.Check(context.Background(), url) }
+		if notifier, err = notify.New(webhooks, notify.WithURLCheck(checkURL)); err != nil {
+			log.Fatalf("Configuration error: webhooks: %v", err)
+		}
+		defer notifier.Close()
+		opts = append(opts, tools.WithNotifier(notifier))
+	}
+
+	// Jobs call tools through the registry, which is created below
+	var registry *tools.Registry
+	var sched *scheduler.Scheduler
+	if schedCfg, ok := toolCfg.Scheduler(); ok {
+		sched = scheduler.New(func(tool string, args map[string]interface{}) (string, error) {
+			return registry.RunTool(tool, args)
+		}, schedCfg.History)
+		var saved []scheduler.Run
+		if _, err := store.Get(state.BucketJobs, "history", &saved); err != nil {
+			log.Printf("Warning: could not restore job history: %v", err)
+		}
+		keep := schedCfg.History
+		if keep <= 0 {
+			keep = 100
+		}
+		// Runs finishing together call back concurrently
+		var savedMu sync.Mutex
+		sched.OnRun(func(run scheduler.Run) {
+			if store != nil {
+				savedMu.Lock()
+				saved = append(saved, run)
+				if len(saved) > keep {
+					saved = saved[len(saved)-keep:]
+				}
+				if err := store.Put(state.BucketJobs, "history", saved, 0); err != nil {
+					log.Printf("Warning: could not save job history: %v", err)
+				}
+				savedMu.Unlock()
+			}
+			notifier.Notify(notify.Event{
+				Kind:     "job",
+				Tool:     run.Tool,
+				Job:      run.Job,
+				Status:   run.Status,
+				Error:    run.Error,
+				Output:   run.Output,
+				Time:     run.Started.UTC(),
+				Duration: run.Duration,
+			})
+		})
+		for _, job := range schedCfg.Jobs {
+			if err := sched.Add(job.Name, job.Schedule, job.Tool, job.Args); err != nil {
+				log.Fatalf("Configuration error: scheduler: %v", err)
+			}
+		}
+		sched.Restore(saved)
+		opts = append(opts, tools.WithScheduler(sched))
+	}
+
+	// Create a tool registry per Grafana instance
+	registry = tools.NewRegistry(client, toolCfg.IsEnabled, append(primaryOpts, opts...)...)
+	registries := []*tools.Registry{registry}
+	for _, inst := range toolCfg.Instances() {
+		instClient, err := instanceClient(inst, toolCfg)
+		if err != nil {
+			log.Fatalf("Configuration error: instance %s: %v", inst.Name, err)
+		}
+		instOpts := append([]tools.Option{tools.WithInstanceName(inst.Name)}, detectGrafana(instClient, inst.Name)...)
+		registries = append(registries, tools.NewRegistry(instClient, toolCfg.IsEnabled, append(instOpts, opts...)...))
+		log.Printf("Instance %s: %s", inst.Name, inst.URL)
+	}
+	if err := tools.ConnectInstances(registries...); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+	for _, reg := range registries {
+		reg.StartPrefetch()
+		defer reg.StopPrefetch()
+	}
+	registry.StartTokenMonitor()
+	defer registry.StopTokenMonitor()
+	if sched != nil {
+		sched.Start()
+		defer sched.Stop()
+		log.Printf("Scheduler started with %d jobs", len(sched.Jobs()))
 	}
 
 	log.SetOutput(os.Stderr)
 	log.Printf("Starting %s v%s", serverName, serverVersion)
 	log.Printf("Grafana URL: %s", grafanaURL)
 
-	// Run the server
-	if err := server.Run(); err != nil {
+	// Run the server on the selected transport
+	addr := *listen
+	if addr == "" {
+		addr = defaultListenAddr
+	}
+	t, err := newTransport(*transport, listenConfigFromEnv(addr), sessionSettingsFrom(toolCfg))
+	if err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+	if err := t.Serve(registry); err != nil {
 		log.Fatalf("Server error: %v", err)
 	}
 }
 
-// Run starts the main server loop
+// Run starts the main server loop. Tool calls run concurrently, subject to
+// the registry's concurrency limits; other requests are answered in order.
+//
+// Messages are read as a stream of JSON values rather than lines, so a
+// message may be any size, several may share a line, and CRLF line endings
+// are accepted. Input that is not valid JSON is skipped up to the next
+// newline.
 func (s *Server) Run() error {
+	defer s.calls.Wait()
+	defer s.unsubscribeAll()
+	dec := json.NewDecoder(s.reader)
 	for {
-		line, err := s.reader.ReadBytes('\n')
-		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
-			return fmt.Errorf("read error: %w", err)
-		}
-
-		if len(line) == 0 {
+		var msg json.RawMessage
+		err := dec.Decode(&msg)
+		if err == nil {
+			s.handleMessage(msg)
 			continue
 		}
-
-		var request mcp.Request
-		if err := json.Unmarshal(line, &request); err != nil {
-			// Log parse error but don't send response with null ID
-			// Claude Desktop's Zod schema rejects null IDs
-			log.Printf("Parse error: %v", err)
-			continue
+		if err == io.EOF {
+			return nil
+		}
+		if err == io.ErrUnexpectedEOF {
+			log.Printf("Parse error: input ended inside a message")
+			return nil
+		}
+		var syntaxErr *json.SyntaxError
+		if !errors.As(err, &syntaxErr) {
+			return fmt.Errorf("read error: %w", err)
 		}
 
-		// Check if this is a notification (no ID field or explicitly null)
-		// Per JSON-RPC 2.0: notifications have no "id" member
-		isNotification := len(request.ID) == 0 || string(request.ID) == "null"
+		// Log parse error but don't send response with null ID
+		// Claude Desktop's Zod schema rejects null IDs
+		log.Printf("Parse error: %v", err)
 
-		if isNotification {
-			// Handle notification methods silently (no response)
-			switch request.Method {
-			case "initialized", "notifications/cancelled":
-				// Known notifications - no action needed
+		// The decoder cannot continue past a syntax error: drop the rest
+		// of the offending line, which may start after the whitespace the
+		// decoder stopped at, and start a new decoder after it
+		s.reader = bufio.NewReader(io.MultiReader(dec.Buffered(), s.reader))
+		for {
+			line, err := s.reader.ReadBytes('\n')
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("read error: %w", err)
+			}
+			if len(bytes.TrimSpace(line)) > 0 {
+				break
 			}
-			continue
 		}
+		dec = json.NewDecoder(s.reader)
+	}
+}
 
-		s.handleRequest(&request)
+// handleMessage dispatches one JSON-RPC message, whichever transport it
+// arrived on
+func (s *Server) handleMessage(data []byte) {
+	var request mcp.Request
+	if err := json.Unmarshal(data, &request); err != nil {
+		// Log parse error but don't send response with null ID
+		// Claude Desktop's Zod schema rejects null IDs
+		log.Printf("Parse error: %v", err)
+		return
 	}
+
+	// Check if this is a notification (no ID field or explicitly null)
+	// Per JSON-RPC 2.0: notifications have no "id" member
+	isNotification := len(request.ID) == 0 || string(request.ID) == "null"
+
+	if isNotification {
+		// Handle notification methods silently (no response)
+		switch request.Method {
+		case "notifications/cancelled":
+			s.handleCancelled(&request)
+		case "initialized":
+			// Known notifications - no action needed
+		}
+		return
+	}
+
+	s.handleRequest(&request)
 }
 
 func (s *Server) handleRequest(req *mcp.Request) {
@@ -115,7 +438,31 @@ func (s *Server) handleRequest(req *mcp.Request) {
 	case "tools/list":
 		s.handleListTools(req)
 	case "tools/call":
-		s.handleCallTool(req)
+		s.calls.Add(1)
+		go func() {
+			defer s.calls.Done()
+			s.handleCallTool(req)
+		}()
+	case "resources/list":
+		s.calls.Add(1)
+		go func() {
+			defer s.calls.Done()
+			s.handleListResources(req)
+		}()
+	case "resources/templates/list":
+		s.sendResult(req.ID, mcp.ListResourceTemplatesResult{
+			ResourceTemplates: s.registry.GetResourceTemplates(),
+		})
+	case "resources/read":
+		s.calls.Add(1)
+		go func() {
+			defer s.calls.Done()
+			s.handleReadResource(req)
+		}()
+	case "resources/subscribe":
+		s.handleSubscribe(req)
+	case "resources/unsubscribe":
+		s.handleUnsubscribe(req)
 	case "ping":
 		s.sendResult(req.ID, map[string]string{})
 	default:
@@ -130,6 +477,9 @@ func (s *Server) handleInitialize(req *mcp.Request) {
 			Tools: &mcp.ToolsCapability{
 				ListChanged: false,
 			},
+			Resources: &mcp.ResourcesCapability{
+				Subscribe: true,
+			},
 		},
 		ServerInfo: mcp.ServerInfo{
 			Name:    serverName,
@@ -160,15 +510,256 @@ func (s *Server) handleCallTool(req *mcp.Request) {
 		return
 	}
 
-	result, err := s.registry.CallTool(params.Name, params.Arguments)
+	ctx, done := s.track(req.ID)
+	defer done()
+	result, err := s.registry.CallTool(ctx, params.Name, params.Arguments)
+	if ctx.Err() != nil {
+		// The client gave up on this request and expects no response
+		log.Printf("Tool call %s cancelled", params.Name)
+		return
+	}
 	if err != nil {
-		s.sendError(req.ID, mcp.InternalError, "Tool execution failed", err.Error())
+		s.sendErrorData(req.ID, mcp.InternalError, "Tool execution failed", tools.ErrorData(err))
 		return
 	}
 
 	s.sendResult(req.ID, result)
 }
 
+// track registers a cancellable context for an in-flight request. done
+// must be called once the request finishes.
+func (s *Server) track(id json.RawMessage) (context.Context, func()) {
+	key := string(id)
+	s.inflightMu.Lock()
+	if s.inflight == nil {
+		s.inflight = map[string]context.CancelFunc{}
+	}
+	if s.orgs == nil {
+		s.orgs = grafana.NewSessionOrgs()
+	}
+	ctx := tools.WithSession(grafana.WithSessionOrgs(context.Background(), s.orgs), s.session)
+	ctx, cancel := context.WithCancel(ctx)
+	s.inflight[key] = cancel
+	s.inflightMu.Unlock()
+	return ctx, func() {
+		s.inflightMu.Lock()
+		delete(s.inflight, key)
+		s.inflightMu.Unlock()
+		cancel()
+	}
+}
+
+// handleCancelled aborts the request a notifications/cancelled names.
+// Requests that already finished, or were never seen, are ignored.
+func (s *Server) handleCancelled(req *mcp.Request) {
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		return
+	}
+	var params mcp.CancelledParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil || len(params.RequestID) == 0 {
+		return
+	}
+	s.cancel(string(params.RequestID), params.Reason)
+}
+
+// cancel aborts an in-flight request by ID, reporting whether one was
+// running
+func (s *Server) cancel(id, reason string) bool {
+	s.inflightMu.Lock()
+	cancel := s.inflight[id]
+	s.inflightMu.Unlock()
+	if cancel == nil {
+		return false
+	}
+	if reason != "" {
+		log.Printf("Cancelling request %s: %s", id, reason)
+	} else {
+		log.Printf("Cancelling request %s", id)
+	}
+	cancel()
+	return true
+}
+
+// cancelAll aborts every in-flight request, for sessions that end while
+// calls are still running
+func (s *Server) cancelAll() {
+	s.inflightMu.Lock()
+	defer s.inflightMu.Unlock()
+	for _, cancel := range s.inflight {
+		cancel()
+	}
+}
+
+func (s *Server) handleListResources(req *mcp.Request) {
+	ctx, done := s.track(req.ID)
+	defer done()
+	resources, err := s.registry.GetResources(ctx)
+	if ctx.Err() != nil {
+		return
+	}
+	if err != nil {
+		s.sendError(req.ID, mcp.InternalError, "Resource list failed", err.Error())
+		return
+	}
+	s.sendResult(req.ID, mcp.ListResourcesResult{Resources: resources})
+}
+
+func (s *Server) handleReadResource(req *mcp.Request) {
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendError(req.ID, mcp.InvalidParams, "Invalid params", err.Error())
+		return
+	}
+
+	var params mcp.ReadResourceParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil || params.URI == "" {
+		details := "uri is required"
+		if err != nil {
+			details = err.Error()
+		}
+		s.sendError(req.ID, mcp.InvalidParams, "Invalid params", details)
+		return
+	}
+
+	ctx, done := s.track(req.ID)
+	defer done()
+	result, err := s.registry.ReadResource(ctx, params.URI)
+	if ctx.Err() != nil {
+		log.Printf("Resource read %s cancelled", params.URI)
+		return
+	}
+	if errors.Is(err, tools.ErrResourceNotFound) {
+		s.sendError(req.ID, mcp.ResourceNotFound, "Resource not found", params.URI)
+		return
+	}
+	if grafana.StatusCode(err) == http.StatusNotFound {
+		s.sendErrorData(req.ID, mcp.ResourceNotFound, "Resource not found", tools.ErrorData(err))
+		return
+	}
+	if err != nil {
+		s.sendErrorData(req.ID, mcp.InternalError, "Resource read failed", tools.ErrorData(err))
+		return
+	}
+
+	s.sendResult(req.ID, result)
+}
+
+// handleSubscribe subscribes the session to updates of a resource.
+// Subscribing again to the same resource keeps the one subscription.
+func (s *Server) handleSubscribe(req *mcp.Request) {
+	uri, ok := s.subscribeURI(req)
+	if !ok {
+		return
+	}
+	s.subscriptionsMu.Lock()
+	defer s.subscriptionsMu.Unlock()
+	if _, subscribed := s.subscriptions[uri]; !subscribed {
+		unsubscribe, err := s.registry.SubscribeResource(uri, s.sendResourceUpdated)
+		if errors.Is(err, tools.ErrResourceNotFound) {
+			s.sendError(req.ID, mcp.ResourceNotFound, "Resource not found", uri)
+			return
+		}
+		if err != nil {
+			s.sendError(req.ID, mcp.InvalidParams, "Invalid params", err.Error())
+			return
+		}
+		if s.subscriptions == nil {
+			s.subscriptions = map[string]func(){}
+		}
+		s.subscriptions[uri] = unsubscribe
+	}
+	s.sendResult(req.ID, map[string]string{})
+}
+
+// handleUnsubscribe ends the session's subscription to a resource, if any
+func (s *Server) handleUnsubscribe(req *mcp.Request) {
+	uri, ok := s.subscribeURI(req)
+	if !ok {
+		return
+	}
+	s.subscriptionsMu.Lock()
+	unsubscribe := s.subscriptions[uri]
+	delete(s.subscriptions, uri)
+	s.subscriptionsMu.Unlock()
+	if unsubscribe != nil {
+		unsubscribe()
+	}
+	s.sendResult(req.ID, map[string]string{})
+}
+
+// subscribeURI reads the URI of a subscribe or unsubscribe request,
+// answering the request with an error when it has none
+func (s *Server) subscribeURI(req *mcp.Request) (string, bool) {
+	paramsJSON, err := json.Marshal(req.Params)
+	if err != nil {
+		s.sendError(req.ID, mcp.InvalidParams, "Invalid params", err.Error())
+		return "", false
+	}
+	var params mcp.SubscribeParams
+	if err := json.Unmarshal(paramsJSON, &params); err != nil || params.URI == "" {
+		details := "uri is required"
+		if err != nil {
+			details = err.Error()
+		}
+		s.sendError(req.ID, mcp.InvalidParams, "Invalid params", details)
+		return "", false
+	}
+	return params.URI, true
+}
+
+// unsubscribeAll ends every resource subscription of a session that ended
+func (s *Server) unsubscribeAll() {
+	s.subscriptionsMu.Lock()
+	subs := s.subscriptions
+	s.subscriptions = nil
+	s.subscriptionsMu.Unlock()
+	for _, unsubscribe := range subs {
+		unsubscribe()
+	}
+}
+
+// sendResourceUpdated tells the client a subscribed resource changed
+func (s *Server) sendResourceUpdated(uri string) {
+	data, err := json.Marshal(mcp.Notification{
+		JSONRPC: "2.0",
+		Method:  "notifications/resources/updated",
+		Params:  mcp.ResourceUpdatedParams{URI: uri},
+	})
+	if err != nil {
+		log.Printf("Failed to marshal notification: %v", err)
+		return
+	}
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.writer.WriteMessage(data); err != nil {
+		log.Printf("Failed to write notification: %v", err)
+	}
+}
+
+// detectGrafana returns the options describing the Grafana version and
+// features behind client, so unsupported tools can be hidden. instance
+// names a further instance in log messages.
+func detectGrafana(client *grafana.Client, instance string) []tools.Option {
+	prefix := ""
+	if instance != "" {
+		prefix = fmt.Sprintf("instance %s: ", instance)
+	}
+	var opts []tools.Option
+	if version, err := client.GetVersion(context.Background()); err != nil {
+		log.Printf("Warning: %scould not detect Grafana version, all tools stay enabled: %v", prefix, err)
+	} else {
+		log.Printf("%sGrafana version: %s", prefix, version)
+		opts = append(opts, tools.WithGrafanaVersion(version))
+	}
+	if settings, err := client.GetFrontendSettings(context.Background()); err != nil {
+		log.Printf("Warning: %scould not read Grafana feature toggles, feature-gated tools stay enabled: %v", prefix, err)
+	} else {
+		opts = append(opts, tools.WithFeatures(settings.Features()))
+	}
+	return opts
+}
+
 func (s *Server) sendResult(id json.RawMessage, result interface{}) {
 	response := mcp.Response{
 		JSONRPC: "2.0",
@@ -179,13 +770,17 @@ func (s *Server) sendResult(id json.RawMessage, result interface{}) {
 }
 
 func (s *Server) sendError(id json.RawMessage, code int, message, details string) {
+	s.sendErrorData(id, code, message, &mcp.ErrorData{Details: details})
+}
+
+func (s *Server) sendErrorData(id json.RawMessage, code int, message string, data *mcp.ErrorData) {
 	response := mcp.Response{
 		JSONRPC: "2.0",
 		ID:      id,
 		Error: &mcp.Error{
 			Code:    code,
 			Message: message,
-			Data:    &mcp.ErrorData{Details: details},
+			Data:    data,
 		},
 	}
 	s.send(response)
@@ -197,5 +792,9 @@ func (s *Server) send(response mcp.Response) {
 		log.Printf("Failed to marshal response: %v", err)
 		return
 	}
-	fmt.Fprintf(s.writer, "%s\n", data)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	if err := s.writer.WriteMessage(data); err != nil {
+		log.Printf("Failed to write response: %v", err)
+	}
 }
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/config"
+)
+
+// sessionSettings are the keepalive and expiry timings of sessions on
+// network transports
+type sessionSettings struct {
+	// PingInterval is how often connected clients are pinged; 0 disables
+	// pings and the silent-connection check that relies on them
+	PingInterval time.Duration
+	// IdleTimeout closes a session with no requests and no running calls
+	// for this long; 0 keeps idle sessions open
+	IdleTimeout time.Duration
+	// ResumeWindow is how long a session outlives its last connection
+	ResumeWindow time.Duration
+}
+
+func defaultSessionSettings() sessionSettings {
+	return sessionSettings{
+		PingInterval: 30 * time.Second,
+		IdleTimeout:  30 * time.Minute,
+		ResumeWindow: 5 * time.Minute,
+	}
+}
+
+// sessionSettingsFrom applies the sessions section of the config file to
+// the defaults
+func sessionSettingsFrom(cfg *config.ToolsConfig) sessionSettings {
+	s := defaultSessionSettings()
+	if d, ok := cfg.SessionPingInterval(); ok {
+		s.PingInterval = d
+	}
+	if d, ok := cfg.SessionIdleTimeout(); ok {
+		s.IdleTimeout = d
+	}
+	if d, ok := cfg.SessionResumeWindow(); ok {
+		s.ResumeWindow = d
+	}
+	return s
+}
+
+// sweepInterval is how often sessions are checked for expiry: once a
+// minute, or often enough to honour shorter timeouts
+func (s sessionSettings) sweepInterval() time.Duration {
+	every := time.Minute
+	for _, d := range []time.Duration{s.IdleTimeout, s.ResumeWindow} {
+		if d > 0 && d/2 < every {
+			every = d / 2
+		}
+	}
+	if every < time.Second {
+		every = time.Second
+	}
+	return every
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+)
+
+// The SSE transport is MCP's original HTTP transport, still the one many
+// remote clients speak. A client opens an event stream with GET /sse; the
+// first event names the endpoint it POSTs messages to, and every response
+// arrives on the stream. A session lasts as long as its stream.
+const (
+	ssePath     = "/sse"
+	sseMessages = "/messages"
+)
+
+// sseTransport serves event streams and the message endpoint
+type sseTransport struct {
+	listen   listenConfig
+	registry *tools.Registry
+	settings sessionSettings
+
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+}
+
+func (t *sseTransport) Serve(registry *tools.Registry) error {
+	t.registry = registry
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(ssePath, t.handleStream)
+	mux.HandleFunc(sseMessages, t.handleMessage)
+	log.Printf("Listening for MCP over SSE on %s://%s%s", t.listen.scheme("http", "https"), t.listen.Addr, ssePath)
+	return t.listen.serve(mux)
+}
+
+// handleStream starts a session and streams its messages until the client
+// disconnects
+func (t *sseTransport) handleStream(w http.ResponseWriter, r *http.Request) {
+	if !checkOrigin(w, r) {
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", "GET")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sess := &sseSession{id: hex.EncodeToString(b), ready: make(chan struct{}, 1)}
+	sess.server = &Server{registry: t.registry, writer: sess, session: sess.id}
+	t.mu.Lock()
+	t.sessions[sess.id] = sess
+	t.mu.Unlock()
+	log.Printf("SSE session %s started from %s", sess.id, r.RemoteAddr)
+	defer t.endSession(sess)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "event: endpoint\ndata: %s?sessionId=%s\n\n", sseMessages, sess.id); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	var keepalive <-chan time.Time
+	if every := t.settings.PingInterval; every > 0 {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		keepalive = ticker.C
+	}
+	for {
+		select {
+		case <-sess.ready:
+			for _, data := range sess.take() {
+				if _, err := fmt.Fprintf(w, "event: message\ndata: %s\n\n", data); err != nil {
+					return
+				}
+			}
+		case <-keepalive:
+			if _, err := io.WriteString(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// handleMessage dispatches a POSTed message to its session. The response,
+// if any, is sent on the session's stream.
+func (t *sseTransport) handleMessage(w http.ResponseWriter, r *http.Request) {
+	if !checkOrigin(w, r) {
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", "POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	t.mu.Lock()
+	sess := t.sessions[r.URL.Query().Get("sessionId")]
+	t.mu.Unlock()
+	if sess == nil {
+		http.Error(w, "unknown or closed session", http.StatusNotFound)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, httpMaxBody))
+	if err != nil {
+		http.Error(w, "request body too large or unreadable", http.StatusRequestEntityTooLarge)
+		return
+	}
+	msgs, _, err := splitBatch(body)
+	if err != nil {
+		writeHTTPError(w, http.StatusBadRequest, mcp.ParseError, "Parse error", err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	for _, data := range msgs {
+		sess.server.handleMessage(data)
+	}
+}
+
+// endSession forgets a session whose stream closed, cancels its calls
+// still running, and ends its resource subscriptions. Once no sessions are
+// left, the registry's caches and Grafana connections are released.
+func (t *sseTransport) endSession(sess *sseSession) {
+	sess.server.cancelAll()
+	sess.server.unsubscribeAll()
+	sess.close()
+	t.mu.Lock()
+	delete(t.sessions, sess.id)
+	release := len(t.sessions) == 0
+	t.mu.Unlock()
+	log.Printf("SSE session %s closed", sess.id)
+	if release {
+		t.registry.ReleaseIdle()
+	}
+}
+
+// sseSession is an MCP session bound to one event stream
+type sseSession struct {
+	id     string
+	server *Server
+	ready  chan struct{} // signalled when messages are queued
+
+	mu      sync.Mutex
+	closed  bool
+	pending [][]byte
+}
+
+// WriteMessage queues a message for the stream. A stream that falls
+// behind by more than wsMaxPending messages loses the oldest.
+func (s *sseSession) WriteMessage(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if len(s.pending) >= wsMaxPending {
+		s.pending = s.pending[1:]
+		log.Printf("SSE session %s is not reading its stream, dropping a message", s.id)
+	}
+	s.pending = append(s.pending, data)
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (s *sseSession) take() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := s.pending
+	s.pending = nil
+	return out
+}
+
+func (s *sseSession) close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	s.pending = nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+)
+
+// transport carries MCP sessions between clients and the registry. Each
+// session gets its own Server, so per-client state such as pending
+// responses stays with the session while tools and caches are shared.
+type transport interface {
+	// Serve runs until the transport fails or, for stdio, its input ends
+	Serve(registry *tools.Registry) error
+}
+
+// newTransport returns the transport selected by name. Network transports
+// listen as listen configures.
+func newTransport(name string, listen listenConfig, settings sessionSettings) (transport, error) {
+	switch name {
+	case "", "stdio":
+		return stdioTransport{in: os.Stdin, out: os.Stdout}, nil
+	case "websocket":
+		if err := listen.check(); err != nil {
+			return nil, err
+		}
+		return &wsTransport{listen: listen, settings: settings, sessions: make(map[string]*wsSession)}, nil
+	case "http":
+		if err := listen.check(); err != nil {
+			return nil, err
+		}
+		return &httpTransport{listen: listen, settings: settings, sessions: make(map[string]*httpSession)}, nil
+	case "sse":
+		if err := listen.check(); err != nil {
+			return nil, err
+		}
+		return &sseTransport{listen: listen, settings: settings, sessions: make(map[string]*sseSession)}, nil
+	}
+	return nil, fmt.Errorf("unknown transport %q (want stdio, websocket, http, or sse)", name)
+}
+
+// stdioSession is the session id of the stdio transport. The process
+// serves one client, so a choice it persists, such as its Grafana
+// instance, carries over to the next run.
+const stdioSession = "stdio"
+
+// stdioTransport serves a single session over the process's stdin and stdout
+type stdioTransport struct {
+	in  io.Reader
+	out io.Writer
+}
+
+func (t stdioTransport) Serve(registry *tools.Registry) error {
+	server := &Server{
+		registry: registry,
+		reader:   bufio.NewReader(t.in),
+		writer:   lineWriter{w: t.out},
+		session:  stdioSession,
+	}
+	return server.Run()
+}
+
+// messageWriter delivers one serialized JSON-RPC message to the client
+type messageWriter interface {
+	WriteMessage(data []byte) error
+}
+
+// lineWriter frames messages as newline-delimited JSON for the stdio transport
+type lineWriter struct {
+	w io.Writer
+}
+
+func (l lineWriter) WriteMessage(data []byte) error {
+	_, err := fmt.Fprintf(l.w, "%s\n", data)
+	return err
+}
@@ -0,0 +1,455 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/internal/websocket"
+)
+
+// The WebSocket transport carries the same JSON-RPC messages as stdio, one
+// per text frame. Each connection belongs to a session that outlives it: a
+// client that reconnects with the session id gets the responses produced
+// while it was away, and requests it re-sends are answered from the session
+// rather than run twice.
+const (
+	wsPath = "/mcp/ws"
+
+	// sessionHeader carries the session id in the handshake response, and
+	// in the request of a client resuming a session
+	sessionHeader = "Mcp-Session-Id"
+
+	// wsMaxPending bounds the messages queued for a disconnected client;
+	// the oldest are dropped first
+	wsMaxPending = 1000
+
+	// wsReplySize is how many recent responses a session keeps for
+	// re-sent requests
+	wsReplySize = 100
+
+	// wsWriteTimeout bounds each frame written to a client; a client that
+	// stops reading for longer is disconnected and its messages queued
+	wsWriteTimeout = 10 * time.Second
+)
+
+// wsTransport accepts WebSocket connections and tracks their sessions
+type wsTransport struct {
+	listen   listenConfig
+	registry *tools.Registry
+	settings sessionSettings
+
+	mu       sync.Mutex
+	sessions map[string]*wsSession
+}
+
+func (t *wsTransport) Serve(registry *tools.Registry) error {
+	t.registry = registry
+	go t.expireSessions()
+
+	mux := http.NewServeMux()
+	mux.Handle(wsPath, t)
+	log.Printf("Listening for WebSocket connections on %s://%s%s", t.listen.scheme("ws", "wss"), t.listen.Addr, wsPath)
+	return t.listen.serve(mux)
+}
+
+// ServeHTTP upgrades a request to a WebSocket connection, resuming the
+// session named by the Mcp-Session-Id header or session query parameter.
+// Browsers send client certificates to any site's page that connects, so
+// handshakes from other origins are refused.
+func (t *wsTransport) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !checkOrigin(w, r) {
+		return
+	}
+	id := r.Header.Get(sessionHeader)
+	if id == "" {
+		id = r.URL.Query().Get("session")
+	}
+
+	var sess *wsSession
+	if id != "" {
+		t.mu.Lock()
+		sess = t.sessions[id]
+		t.mu.Unlock()
+		if sess == nil {
+			http.Error(w, "unknown or expired session", http.StatusNotFound)
+			return
+		}
+	} else {
+		var err error
+		if sess, err = t.newSession(); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	conn, err := websocket.Upgrade(w, r, http.Header{sessionHeader: {sess.id}})
+	if err != nil {
+		log.Printf("WebSocket upgrade from %s: %v", r.RemoteAddr, err)
+		return
+	}
+	if id == "" {
+		t.mu.Lock()
+		t.sessions[sess.id] = sess
+		t.mu.Unlock()
+		log.Printf("WebSocket session %s started from %s", sess.id, r.RemoteAddr)
+	} else {
+		log.Printf("WebSocket session %s resumed from %s", sess.id, r.RemoteAddr)
+	}
+	sess.serve(conn)
+}
+
+func (t *wsTransport) newSession() (*wsSession, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	sess := &wsSession{
+		id:         hex.EncodeToString(b),
+		settings:   t.settings,
+		lastActive: time.Now(),
+		inflight:   make(map[string]bool),
+		replies:    make(map[string][]byte),
+	}
+	sess.server = &Server{registry: t.registry, writer: sess, session: sess.id}
+	return sess, nil
+}
+
+// expireSessions closes sessions that have been idle or disconnected too
+// long. Once none are left, the registry's caches and Grafana connections
+// are released.
+func (t *wsTransport) expireSessions() {
+	ticker := time.NewTicker(t.settings.sweepInterval())
+	defer ticker.Stop()
+	for now := range ticker.C {
+		// Sessions are closed after t.mu is released, so closing one never
+		// holds up handshakes
+		t.mu.Lock()
+		expired := map[*wsSession]string{}
+		for id, sess := range t.sessions {
+			if reason := sess.expiry(now); reason != "" {
+				delete(t.sessions, id)
+				expired[sess] = reason
+			}
+		}
+		release := len(expired) > 0 && len(t.sessions) == 0
+		t.mu.Unlock()
+
+		for sess, reason := range expired {
+			sess.close()
+			log.Printf("WebSocket session %s closed: %s", sess.id, reason)
+		}
+		if release {
+			t.registry.ReleaseIdle()
+		}
+	}
+}
+
+// wsSession is an MCP session served over one WebSocket connection at a
+// time. Tool calls keep running while the client is disconnected.
+type wsSession struct {
+	id       string
+	server   *Server
+	settings sessionSettings
+
+	mu         sync.Mutex
+	conn       *wsConn
+	gen        int // incremented per connection, so a stale reader cannot detach its successor
+	closed     bool
+	detachedAt time.Time
+	lastActive time.Time // last message from the client
+	pending    [][]byte
+	inflight   map[string]bool
+	replies    map[string][]byte
+	replyOrder []string
+}
+
+// wsConn is a session's connection and the goroutine writing to it. Messages
+// are handed to the writer under the session lock and written outside it,
+// so a client that stops reading blocks only its own writer.
+type wsConn struct {
+	ws   *websocket.Conn
+	out  chan []byte
+	stop chan struct{}
+}
+
+// write sends messages from out until stop is closed or a write fails
+func (c *wsConn) write(s *wsSession) {
+	for {
+		select {
+		case <-c.stop:
+			return
+		case data := <-c.out:
+			if err := c.ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				log.Printf("WebSocket session %s disconnected: %v", s.id, err)
+				s.writeFailed(c, data)
+				return
+			}
+		}
+	}
+}
+
+// serve attaches conn to the session, delivers queued messages, and reads
+// requests until the connection fails or goes quiet
+func (s *wsSession) serve(conn *websocket.Conn) {
+	conn.SetWriteTimeout(wsWriteTimeout)
+	c := &wsConn{ws: conn, out: make(chan []byte, wsMaxPending), stop: make(chan struct{})}
+	s.mu.Lock()
+	if s.closed {
+		// The session expired while the client was reconnecting
+		s.mu.Unlock()
+		conn.CloseGracefully()
+		return
+	}
+	// The client may have reconnected before the old connection timed out
+	s.dropConn()
+	s.conn = c
+	s.lastActive = time.Now()
+	s.gen++
+	gen := s.gen
+	pending := s.pending
+	s.pending = nil
+	for _, data := range pending {
+		s.deliver(data)
+	}
+	s.mu.Unlock()
+	go c.write(s)
+
+	// With pings enabled, a connection that answers nothing for two
+	// intervals is considered dead
+	extend := func() {}
+	if every := s.settings.PingInterval; every > 0 {
+		extend = func() { conn.SetReadDeadline(time.Now().Add(2 * every)) }
+		conn.SetPongHandler(extend)
+		extend()
+
+		done := make(chan struct{})
+		go keepalive(conn, every, done)
+		defer close(done)
+	}
+
+	for {
+		op, data, err := conn.ReadMessage()
+		if err != nil {
+			if err != websocket.ErrClosed {
+				log.Printf("WebSocket session %s disconnected: %v", s.id, err)
+			}
+			break
+		}
+		extend()
+		if op == websocket.TextMessage {
+			s.receive(data)
+		}
+	}
+	s.detach(conn, gen)
+}
+
+// keepalive pings conn every interval until done is closed
+func keepalive(conn *websocket.Conn, every time.Duration, done <-chan struct{}) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if err := conn.Ping(); err != nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func (s *wsSession) detach(conn *websocket.Conn, gen int) {
+	conn.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.gen == gen {
+		s.dropConn()
+	}
+}
+
+// dropConn detaches the current connection and stops its writer, queueing
+// the messages it had not sent ahead of any already pending. The caller
+// holds s.mu.
+func (s *wsSession) dropConn() {
+	c := s.conn
+	if c == nil {
+		return
+	}
+	s.conn = nil
+	s.detachedAt = time.Now()
+	close(c.stop)
+	c.ws.Close()
+	var unsent [][]byte
+	for len(c.out) > 0 {
+		unsent = append(unsent, <-c.out)
+	}
+	s.queue(append(unsent, s.pending...))
+}
+
+// writeFailed requeues the message c's writer could not send and detaches c
+// if it is still the session's connection
+func (s *wsSession) writeFailed(c *wsConn, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	if s.conn == c {
+		s.dropConn()
+	}
+	if s.conn != nil {
+		s.deliver(data)
+		return
+	}
+	s.queue(append([][]byte{data}, s.pending...))
+}
+
+// queue replaces the pending messages, keeping the newest wsMaxPending.
+// The caller holds s.mu.
+func (s *wsSession) queue(msgs [][]byte) {
+	if len(msgs) > wsMaxPending {
+		msgs = msgs[len(msgs)-wsMaxPending:]
+	}
+	s.pending = msgs
+}
+
+// expiry returns why the session should be closed at now, or ""
+func (s *wsSession) expiry(now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil && now.Sub(s.detachedAt) >= s.settings.ResumeWindow {
+		return "not resumed within " + s.settings.ResumeWindow.String()
+	}
+	if idle := s.settings.IdleTimeout; idle > 0 && len(s.inflight) == 0 && now.Sub(s.lastActive) >= idle {
+		return "idle for " + idle.String()
+	}
+	return ""
+}
+
+// close ends the session, drops its queued messages and reply cache,
+// cancels calls still running, and ends its resource subscriptions
+func (s *wsSession) close() {
+	s.server.cancelAll()
+	s.server.unsubscribeAll()
+	s.mu.Lock()
+	s.closed = true
+	var conn *websocket.Conn
+	if c := s.conn; c != nil {
+		s.conn = nil
+		close(c.stop)
+		conn = c.ws
+	}
+	s.pending, s.replies, s.replyOrder, s.inflight = nil, nil, nil, nil
+	s.mu.Unlock()
+	if conn != nil {
+		conn.CloseGracefully()
+	}
+}
+
+// receive handles a message from the client. A request whose id was seen
+// before is not run again: a finished one is answered from the reply cache,
+// and a running one will be answered when it completes.
+func (s *wsSession) receive(data []byte) {
+	s.mu.Lock()
+	s.lastActive = time.Now()
+	closed := s.closed
+	s.mu.Unlock()
+	if closed {
+		return
+	}
+	if id := messageID(data); id != "" {
+		s.mu.Lock()
+		reply, done := s.replies[id]
+		running := s.inflight[id]
+		if done {
+			s.deliver(reply)
+		} else if !running {
+			s.inflight[id] = true
+		}
+		s.mu.Unlock()
+		if done || running {
+			return
+		}
+	} else if id := cancelledID(data); id != "" {
+		// A cancelled call sends no reply, so it stops counting as running
+		s.mu.Lock()
+		delete(s.inflight, id)
+		s.mu.Unlock()
+	}
+	s.server.handleMessage(data)
+}
+
+// cancelledID returns the request a notifications/cancelled names, or ""
+// for any other message
+func cancelledID(data []byte) string {
+	var msg struct {
+		Method string              `json:"method"`
+		Params mcp.CancelledParams `json:"params"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Method != "notifications/cancelled" {
+		return ""
+	}
+	return string(msg.Params.RequestID)
+}
+
+// WriteMessage sends a message to the client, or queues it until the client
+// reconnects
+func (s *wsSession) WriteMessage(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	if id := messageID(data); id != "" {
+		delete(s.inflight, id)
+		if _, ok := s.replies[id]; !ok {
+			s.replyOrder = append(s.replyOrder, id)
+			if len(s.replyOrder) > wsReplySize {
+				delete(s.replies, s.replyOrder[0])
+				s.replyOrder = s.replyOrder[1:]
+			}
+		}
+		s.replies[id] = data
+	}
+	s.deliver(data)
+	return nil
+}
+
+// deliver hands data to the current connection's writer, falling back to
+// the pending queue. A connection whose writer has fallen wsMaxPending
+// messages behind is dropped. The caller holds s.mu.
+func (s *wsSession) deliver(data []byte) {
+	if s.conn != nil {
+		select {
+		case s.conn.out <- data:
+			return
+		default:
+			log.Printf("WebSocket session %s disconnected: client is not reading", s.id)
+			s.dropConn()
+		}
+	}
+	if len(s.pending) >= wsMaxPending {
+		s.pending = s.pending[1:]
+	}
+	s.pending = append(s.pending, data)
+}
+
+// messageID returns the JSON-RPC id of a message, or "" for notifications
+func messageID(data []byte) string {
+	var msg struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if json.Unmarshal(data, &msg) != nil || len(msg.ID) == 0 || string(msg.ID) == "null" {
+		return ""
+	}
+	return string(msg.ID)
+}
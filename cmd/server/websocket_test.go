@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/websocket"
+)
+
+// TestWebSocketSlowClient checks a client that stops reading holds up
+// neither the calls writing to its session nor the session sweeper
+func TestWebSocketSlowClient(t *testing.T) {
+	tr := &wsTransport{settings: sessionSettings{ResumeWindow: time.Minute}, sessions: map[string]*wsSession{}}
+	sess, err := tr.newSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		sess.serve(conn)
+	}))
+	defer srv.Close()
+	defer sess.close()
+
+	client, err := websocket.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil, nil, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	// Far more than the socket buffers hold, so the writer blocks
+	msg := []byte(`{"jsonrpc":"2.0","method":"notifications/message","params":"` + string(bytes.Repeat([]byte("x"), 64<<10)) + `"}`)
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 500; i++ {
+			sess.WriteMessage(msg)
+		}
+		sess.expiry(time.Now())
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("writing to a session whose client stopped reading blocked")
+	}
+}
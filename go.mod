@@ -2,4 +2,12 @@ module github.com/npcomplete777/grafana-mcp
 
 go 1.22
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	github.com/google/go-jsonnet v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	gopkg.in/yaml.v2 v2.2.7 // indirect
+	sigs.k8s.io/yaml v1.1.0 // indirect
+)
@@ -0,0 +1,52 @@
+package analysis
+
+import "math"
+
+// maxDeviationScore caps scores against flat baselines, where any change is
+// infinitely many standard deviations away
+const maxDeviationScore = 100
+
+// Deviation measures how far the recent part of a series departs from the
+// baseline formed by the points before it
+type Deviation struct {
+	Recent    float64 `json:"recent"`
+	Baseline  Stats   `json:"baseline"`
+	Score     float64 `json:"score"`
+	Direction string  `json:"direction"`
+}
+
+// RecentDeviation compares the mean of the points at or after since against
+// the statistics of the points before it. It reports false when either side
+// has too few points to compare.
+func RecentDeviation(times []int64, values []float64, since int64) (Deviation, bool) {
+	split := len(times)
+	for i, t := range times {
+		if t >= since {
+			split = i
+			break
+		}
+	}
+	if split < 3 || split >= len(values) {
+		return Deviation{}, false
+	}
+
+	d := Deviation{Baseline: Summarize(values[:split]), Recent: mean(values[split:])}
+	sd := d.Baseline.StdDev
+	if sd == 0 {
+		sd = math.Abs(d.Baseline.Mean) * 0.01
+	}
+	diff := d.Recent - d.Baseline.Mean
+	switch {
+	case diff == 0:
+		return d, true
+	case sd == 0:
+		d.Score = maxDeviationScore
+	default:
+		d.Score = math.Min(math.Abs(diff)/sd, maxDeviationScore)
+	}
+	d.Direction = "up"
+	if diff < 0 {
+		d.Direction = "down"
+	}
+	return d, true
+}
@@ -0,0 +1,120 @@
+package analysis
+
+import "math"
+
+// ChangePoint marks a point where a series shifted away from its baseline
+type ChangePoint struct {
+	Index     int     `json:"index"`
+	Time      int64   `json:"time"`
+	Value     float64 `json:"value"`
+	Baseline  float64 `json:"baseline"`
+	Score     float64 `json:"score"`
+	Direction string  `json:"direction"`
+}
+
+// ZScoreChangePoints flags points whose z-score against the preceding window
+// exceeds threshold. Consecutive flagged points collapse into the first one.
+func ZScoreChangePoints(times []int64, values []float64, window int, threshold float64) []ChangePoint {
+	if window < 2 {
+		window = 2
+	}
+	var out []ChangePoint
+	inChange := false
+	for i := window; i < len(values); i++ {
+		base := values[i-window : i]
+		m := mean(base)
+		sd := stddev(base, m)
+		if sd == 0 {
+			sd = math.Abs(m) * 0.01
+		}
+		if sd == 0 {
+			inChange = false
+			continue
+		}
+		z := (values[i] - m) / sd
+		if math.Abs(z) < threshold {
+			inChange = false
+			continue
+		}
+		if inChange {
+			continue
+		}
+		inChange = true
+		out = append(out, ChangePoint{
+			Index:     i,
+			Time:      times[i],
+			Value:     values[i],
+			Baseline:  m,
+			Score:     z,
+			Direction: direction(z),
+		})
+	}
+	return out
+}
+
+// CUSUMChangePoints runs a two-sided CUSUM over the series standardized by
+// the mean and deviation of its first warmup points. threshold is the
+// decision interval h in standard deviations; drift k is fixed at 0.5.
+func CUSUMChangePoints(times []int64, values []float64, warmup int, threshold float64) []ChangePoint {
+	if len(values) < 3 {
+		return nil
+	}
+	if warmup < 2 || warmup > len(values) {
+		warmup = len(values) / 4
+		if warmup < 2 {
+			warmup = 2
+		}
+	}
+	m := mean(values[:warmup])
+	sd := stddev(values[:warmup], m)
+	if sd == 0 {
+		sd = stddev(values, mean(values))
+	}
+	if sd == 0 {
+		return nil
+	}
+
+	const k = 0.5
+	var out []ChangePoint
+	pos, neg := 0.0, 0.0
+	for i := warmup; i < len(values); i++ {
+		x := (values[i] - m) / sd
+		pos = math.Max(0, pos+x-k)
+		neg = math.Max(0, neg-x-k)
+		if pos <= threshold && neg <= threshold {
+			continue
+		}
+		score := pos
+		if neg > pos {
+			score = -neg
+		}
+		out = append(out, ChangePoint{
+			Index:     i,
+			Time:      times[i],
+			Value:     values[i],
+			Baseline:  m,
+			Score:     score,
+			Direction: direction(score),
+		})
+		// Re-baseline on the level after the change
+		end := i + warmup
+		if end > len(values) {
+			end = len(values)
+		}
+		if end-i >= 2 {
+			m = mean(values[i:end])
+			if s := stddev(values[i:end], m); s > 0 {
+				sd = s
+			}
+		}
+		pos, neg = 0, 0
+	}
+	return out
+}
+
+func direction(score float64) string {
+	if score < 0 {
+		return "down"
+	}
+	return "up"
+}
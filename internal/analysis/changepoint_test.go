@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+)
+
+// step returns n points alternating between 10 and 11, then each level in
+// levels repeated n times
+func step(n int, levels ...float64) []float64 {
+	var out []float64
+	for i := 0; i < n; i++ {
+		out = append(out, 10+float64(i%2))
+	}
+	for _, l := range levels {
+		for i := 0; i < n; i++ {
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+func times(n int) []int64 {
+	out := make([]int64, n)
+	for i := range out {
+		out[i] = int64(i) * 60000
+	}
+	return out
+}
+
+// change is a change point without its statistics, for comparing results
+type change struct {
+	Index     int
+	Direction string
+}
+
+func changes(points []ChangePoint) []change {
+	out := []change{}
+	for _, p := range points {
+		out = append(out, change{p.Index, p.Direction})
+	}
+	return out
+}
+
+func TestZScoreChangePoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		window int
+		want   []change
+	}{
+		{"flat", []float64{5, 5, 5, 5, 5, 5, 5, 5}, 4, []change{}},
+		{"all zero", []float64{0, 0, 0, 0, 0, 0}, 4, []change{}},
+		{"shorter than the window", []float64{1, 100}, 4, []change{}},
+		{"noise below threshold", step(12), 4, []change{}},
+		{"step up", step(8, 30), 4, []change{{8, "up"}}},
+		{"step down", step(8, 0), 4, []change{{8, "down"}}},
+		{"consecutive outliers collapse", append(step(10), 50, 100), 10, []change{{10, "up"}}},
+		{"window below 2 is raised to 2", []float64{10, 11, 10, 11, 40}, 0, []change{{4, "up"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changes(ZScoreChangePoints(times(len(tt.values)), tt.values, tt.window, 3))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("change points = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestZScoreChangePointFields(t *testing.T) {
+	values := step(4, 30)
+	points := ZScoreChangePoints(times(len(values)), values, 4, 3)
+	if len(points) != 1 {
+		t.Fatalf("change points = %+v, want one", points)
+	}
+	p := points[0]
+	if p.Time != 4*60000 || p.Value != 30 || p.Baseline != 10.5 || p.Score < 3 {
+		t.Fatalf("change point = %+v, want time 240000, value 30, baseline 10.5, score above 3", p)
+	}
+}
+
+func TestCUSUMChangePoints(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		warmup int
+		want   []change
+	}{
+		{"too short", []float64{1, 100}, 0, []change{}},
+		{"constant", []float64{5, 5, 5, 5, 5, 5, 5, 5}, 4, []change{}},
+		{"noise below threshold", step(16), 8, []change{}},
+		{"step up", step(8, 20), 8, []change{{8, "up"}}},
+		{"step down", step(8, 0), 8, []change{{8, "down"}}},
+		{"re-baselines after each change", step(8, 20, 10), 8, []change{{8, "up"}, {16, "down"}}},
+		{"default warmup is a quarter of the series", step(8, 20, 20, 20), 0, []change{{8, "up"}}},
+		// With a flat warmup the whole series sets the scale, so the shift
+		// accumulates over several points before crossing the threshold
+		{"flat warmup", []float64{10, 10, 10, 10, 20, 20, 20, 20}, 4, []change{{7, "up"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := changes(CUSUMChangePoints(times(len(tt.values)), tt.values, tt.warmup, 5))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("change points = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
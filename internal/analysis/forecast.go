@@ -0,0 +1,166 @@
+package analysis
+
+import (
+	"errors"
+	"sort"
+)
+
+// Forecast is a fitted trend that can project a series forward
+type Forecast struct {
+	Method string `json:"method"`
+	// Slope is the trend per second at the end of the series
+	Slope float64 `json:"slope_per_second"`
+	// R2 is the coefficient of determination of a linear fit, when applicable
+	R2 float64 `json:"r2,omitempty"`
+	// Last is the fitted value at the last observed point
+	Last float64 `json:"last_fitted"`
+
+	lastTime int64
+	step     int64
+	season   []float64
+	seasonAt int
+}
+
+// At returns the projected value at t (epoch milliseconds)
+func (f *Forecast) At(t int64) float64 {
+	dt := float64(t-f.lastTime) / 1000
+	v := f.Last + f.Slope*dt
+	if len(f.season) > 0 && f.step > 0 {
+		h := int((t - f.lastTime) / f.step)
+		v += f.season[(f.seasonAt+h)%len(f.season)]
+	}
+	return v
+}
+
+// Crossing returns the first time after the series ends, up to horizon
+// (epoch milliseconds), at which the projection reaches threshold from the
+// side the series ends on. It reports false if it never does.
+func (f *Forecast) Crossing(threshold float64, horizon int64) (int64, bool) {
+	above := f.At(f.lastTime) >= threshold
+	if len(f.season) == 0 {
+		if f.Slope == 0 || (f.Slope > 0) == above {
+			return 0, false
+		}
+		t := f.lastTime + int64((threshold-f.Last)/f.Slope*1000)
+		if t > horizon {
+			return 0, false
+		}
+		return t, true
+	}
+	for t := f.lastTime + f.step; t <= horizon; t += f.step {
+		if (f.At(t) >= threshold) != above {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// LinearForecast fits a least-squares line through the series
+func LinearForecast(times []int64, values []float64) (*Forecast, error) {
+	if len(values) < 3 {
+		return nil, errors.New("at least 3 points are needed to fit a trend")
+	}
+	t0 := times[0]
+	xs := make([]float64, len(times))
+	for i, t := range times {
+		xs[i] = float64(t-t0) / 1000
+	}
+	mx, my := mean(xs), mean(values)
+	var sxy, sxx, syy float64
+	for i := range xs {
+		dx, dy := xs[i]-mx, values[i]-my
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 {
+		return nil, errors.New("series has no time span")
+	}
+	slope := sxy / sxx
+	f := &Forecast{
+		Method:   "linear",
+		Slope:    slope,
+		Last:     my + slope*(xs[len(xs)-1]-mx),
+		lastTime: times[len(times)-1],
+	}
+	if syy > 0 {
+		f.R2 = sxy * sxy / (sxx * syy)
+	}
+	return f, nil
+}
+
+// HoltWintersForecast fits Holt's linear trend by double exponential
+// smoothing, adding an additive seasonal component when seasonPoints > 1.
+// Points are assumed to be evenly spaced; seasonal fits need two full seasons.
+func HoltWintersForecast(times []int64, values []float64, seasonPoints int, alpha, beta, gamma float64) (*Forecast, error) {
+	n := len(values)
+	if n < 3 {
+		return nil, errors.New("at least 3 points are needed to fit a trend")
+	}
+	step := medianStep(times)
+	if step <= 0 {
+		return nil, errors.New("series has no time span")
+	}
+
+	m := seasonPoints
+	if m <= 1 {
+		m = 0
+	}
+	if m > 0 && n < 2*m {
+		return nil, errors.New("seasonal forecasts need at least two full seasons of data")
+	}
+
+	level, trend := values[0], values[1]-values[0]
+	season := make([]float64, m)
+	start := 1
+	if m > 0 {
+		// Start from the first season: its mean sits mid-season, so the
+		// seasonal offsets are taken against the trend line through it
+		first, second := mean(values[:m]), mean(values[m:2*m])
+		trend = (second - first) / float64(m)
+		mid := float64(m-1) / 2
+		for i := 0; i < m; i++ {
+			season[i] = values[i] - (first + trend*(float64(i)-mid))
+		}
+		level = first + trend*mid
+		start = m
+	}
+	for i := start; i < n; i++ {
+		s := 0.0
+		if m > 0 {
+			s = season[i%m]
+		}
+		prev := level
+		level = alpha*(values[i]-s) + (1-alpha)*(level+trend)
+		trend = beta*(level-prev) + (1-beta)*trend
+		if m > 0 {
+			season[i%m] = gamma*(values[i]-level) + (1-gamma)*s
+		}
+	}
+
+	f := &Forecast{
+		Method:   "holt",
+		Slope:    trend / (float64(step) / 1000),
+		Last:     level,
+		lastTime: times[n-1],
+		step:     step,
+	}
+	if m > 0 {
+		f.Method = "holt_winters"
+		f.season = season
+		f.seasonAt = (n - 1) % m
+	}
+	return f, nil
+}
+
+func medianStep(times []int64) int64 {
+	if len(times) < 2 {
+		return 0
+	}
+	steps := make([]int64, 0, len(times)-1)
+	for i := 1; i < len(times); i++ {
+		steps = append(steps, times[i]-times[i-1])
+	}
+	sort.Slice(steps, func(i, j int) bool { return steps[i] < steps[j] })
+	return steps[len(steps)/2]
+}
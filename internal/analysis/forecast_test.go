@@ -0,0 +1,186 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+const minute = int64(60000)
+
+// linear returns n points a minute apart on the line start + perMinute*i
+func linear(n int, start, perMinute float64) ([]int64, []float64) {
+	ts := make([]int64, n)
+	vs := make([]float64, n)
+	for i := range ts {
+		ts[i] = int64(i) * minute
+		vs[i] = start + perMinute*float64(i)
+	}
+	return ts, vs
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6*math.Max(1, math.Abs(b))
+}
+
+func TestLinearForecast(t *testing.T) {
+	ts, vs := linear(10, 50, 6)
+	noisy := append([]float64(nil), vs...)
+	noisy[3] += 5
+	noisy[6] -= 5
+
+	tests := []struct {
+		name     string
+		times    []int64
+		values   []float64
+		slope    float64
+		last     float64
+		exactFit bool
+	}{
+		{"exact line", ts, vs, 0.1, 104, true},
+		{"noise averages out", ts, noisy, 0.1 - 1.0/330, 104 - 9.0/11, false},
+		{"flat", ts[:4], []float64{7, 7, 7, 7}, 0, 7, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := LinearForecast(tt.times, tt.values)
+			if err != nil {
+				t.Fatalf("LinearForecast: %v", err)
+			}
+			if !near(f.Slope, tt.slope) || !near(f.Last, tt.last) {
+				t.Fatalf("slope %v, last %v, want %v, %v", f.Slope, f.Last, tt.slope, tt.last)
+			}
+			if tt.exactFit != near(f.R2, 1) {
+				t.Fatalf("r2 = %v, exact fit %v", f.R2, tt.exactFit)
+			}
+			if got := f.At(ts[len(tt.times)-1] + 10*minute); !near(got, tt.last+tt.slope*600) {
+				t.Fatalf("At 10 minutes on = %v, want %v", got, tt.last+tt.slope*600)
+			}
+		})
+	}
+}
+
+func TestForecastRejectsUnusableSeries(t *testing.T) {
+	ts, vs := linear(10, 0, 1)
+	tests := []struct {
+		name string
+		fit  func() (*Forecast, error)
+		want string
+	}{
+		{"linear too short", func() (*Forecast, error) { return LinearForecast(ts[:2], vs[:2]) }, "at least 3 points"},
+		{"linear no span", func() (*Forecast, error) { return LinearForecast([]int64{5, 5, 5}, vs[:3]) }, "no time span"},
+		{"holt too short", func() (*Forecast, error) { return HoltWintersForecast(ts[:2], vs[:2], 0, 0.5, 0.1, 0) }, "at least 3 points"},
+		{"holt no span", func() (*Forecast, error) { return HoltWintersForecast([]int64{5, 5, 5}, vs[:3], 0, 0.5, 0.1, 0) }, "no time span"},
+		{"one season", func() (*Forecast, error) { return HoltWintersForecast(ts, vs, 6, 0.5, 0.1, 0.1) }, "two full seasons"},
+	}
+	for _, tt := range tests {
+		if _, err := tt.fit(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestHoltWintersForecast(t *testing.T) {
+	t.Run("holt follows an exact line", func(t *testing.T) {
+		ts, vs := linear(20, 10, 3)
+		f, err := HoltWintersForecast(ts, vs, 0, 0.5, 0.1, 0)
+		if err != nil {
+			t.Fatalf("HoltWintersForecast: %v", err)
+		}
+		if f.Method != "holt" || !near(f.Slope, 0.05) || !near(f.Last, 67) {
+			t.Fatalf("forecast = %+v, want holt with slope 0.05/s ending at 67", f)
+		}
+	})
+
+	t.Run("seasonal pattern repeats ahead", func(t *testing.T) {
+		pattern := []float64{0, 10, 0, -10}
+		ts, vs := linear(16, 100, 1)
+		for i := range vs {
+			vs[i] += pattern[i%4]
+		}
+		f, err := HoltWintersForecast(ts, vs, 4, 0.5, 0.1, 0.1)
+		if err != nil {
+			t.Fatalf("HoltWintersForecast: %v", err)
+		}
+		if f.Method != "holt_winters" {
+			t.Fatalf("method = %s, want holt_winters", f.Method)
+		}
+		for h := int64(1); h <= 8; h++ {
+			i := 15 + h
+			want := 100 + float64(i) + pattern[i%4]
+			if got := f.At(ts[15] + h*minute); !near(got, want) {
+				t.Errorf("At %d steps ahead = %v, want %v", h, got, want)
+			}
+		}
+	})
+}
+
+func TestCrossing(t *testing.T) {
+	const horizon = 1000 * minute
+	tests := []struct {
+		name      string
+		perMinute float64
+		threshold float64
+		want      int64
+		wantOK    bool
+	}{
+		{"rising reaches the threshold", 2, 100, 20 * minute, true},
+		{"falling reaches the threshold", -2, 40, 30 * minute, true},
+		{"rising away from a lower threshold", 2, 0, 0, false},
+		{"falling away from a higher threshold", -2, 100, 0, false},
+		{"flat never crosses", 0, 100, 0, false},
+		{"beyond the horizon", 0.01, 100, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Ends at 80 at minute 10
+			ts, vs := linear(11, 80-tt.perMinute*10, tt.perMinute)
+			f, err := LinearForecast(ts, vs)
+			if err != nil {
+				t.Fatalf("LinearForecast: %v", err)
+			}
+			got, ok := f.Crossing(tt.threshold, horizon)
+			if ok != tt.wantOK || (ok && math.Abs(float64(got-tt.want)) > 1) {
+				t.Fatalf("Crossing(%v) = %d, %v, want %d, %v", tt.threshold, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	t.Run("seasonal crossing is found by stepping", func(t *testing.T) {
+		pattern := []float64{0, 10, 0, -10}
+		ts, vs := linear(16, 0, 0)
+		for i := range vs {
+			vs[i] += pattern[i%4]
+		}
+		f, err := HoltWintersForecast(ts, vs, 4, 0.5, 0.1, 0.1)
+		if err != nil {
+			t.Fatalf("HoltWintersForecast: %v", err)
+		}
+		// The series ends on -10 and peaks at 10 two steps later
+		got, ok := f.Crossing(5, horizon)
+		if !ok || got != ts[15]+2*minute {
+			t.Fatalf("Crossing(5) = %d, %v, want %d", got, ok, ts[15]+2*minute)
+		}
+		if _, ok := f.Crossing(20, horizon); ok {
+			t.Fatal("a flat seasonal series crosses a threshold above its peak")
+		}
+	})
+}
+
+func TestMedianStep(t *testing.T) {
+	tests := []struct {
+		times []int64
+		want  int64
+	}{
+		{nil, 0},
+		{[]int64{5}, 0},
+		{[]int64{0, 60, 120, 180}, 60},
+		{[]int64{0, 60, 120, 600, 660}, 60},
+		{[]int64{0, 30, 90, 150}, 60},
+	}
+	for _, tt := range tests {
+		if got := medianStep(tt.times); got != tt.want {
+			t.Errorf("medianStep(%v) = %d, want %d", tt.times, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Histogram is the quantiles of one histogram over time
+type Histogram struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Quantiles []QuantileSeries  `json:"quantiles"`
+}
+
+// QuantileSeries is one quantile of a histogram at each timestamp
+type QuantileSeries struct {
+	Quantile float64   `json:"quantile"`
+	Stats    Stats     `json:"stats"`
+	Times    []int64   `json:"-"`
+	Values   []float64 `json:"-"`
+}
+
+// bucket is one histogram bucket at one timestamp. For classic histograms
+// count is cumulative up to upper; for native ones it covers (lower, upper].
+type bucket struct {
+	lower, upper, count float64
+}
+
+// HistogramQuantiles turns histogram frames into quantiles over time.
+// Classic histograms are series with an le label, grouped by their other
+// labels; native histograms are Grafana's heatmap-cells frames with yMin,
+// yMax, and count fields. Quantiles are interpolated linearly within the
+// bucket they fall in, as PromQL's histogram_quantile does. ok is false
+// when the frames hold no histogram.
+func HistogramQuantiles(frames []grafana.DataFrame, quantiles []float64) ([]Histogram, bool) {
+	type group struct {
+		name   string
+		labels map[string]string
+		byTime map[int64][]bucket
+		cumul  bool
+	}
+	groups := map[string]*group{}
+	var order []string
+	add := func(labels map[string]string, name string, cumulative bool) *group {
+		key := name + "\x00" + labelKey(labels)
+		g := groups[key]
+		if g == nil {
+			g = &group{name: name, labels: labels, byTime: map[int64][]bucket{}, cumul: cumulative}
+			groups[key] = g
+			order = append(order, key)
+		}
+		return g
+	}
+
+	for _, f := range frames {
+		if cells := heatmapCells(f); cells != nil {
+			var labels map[string]string
+			name := f.Schema.Name
+			for _, field := range f.Schema.Fields {
+				if field.Name == "count" {
+					labels = field.Labels
+				}
+			}
+			if name == "" {
+				name = seriesName("", grafana.FieldSchema{Name: "histogram", Labels: labels})
+			}
+			g := add(labels, name, false)
+			for t, bs := range cells {
+				g.byTime[t] = append(g.byTime[t], bs...)
+			}
+		}
+	}
+	for _, s := range FramesToSeries(frames) {
+		le, ok := s.Labels["le"]
+		if !ok {
+			continue
+		}
+		upper, err := strconv.ParseFloat(le, 64)
+		if err != nil {
+			continue
+		}
+		labels := make(map[string]string, len(s.Labels)-1)
+		for k, v := range s.Labels {
+			if k != "le" {
+				labels[k] = v
+			}
+		}
+		g := add(labels, seriesName("", grafana.FieldSchema{Name: "histogram", Labels: labels}), true)
+		for i, t := range s.Times {
+			g.byTime[t] = append(g.byTime[t], bucket{upper: upper, count: s.Values[i]})
+		}
+	}
+	if len(order) == 0 {
+		return nil, false
+	}
+
+	out := make([]Histogram, 0, len(order))
+	for _, key := range order {
+		g := groups[key]
+		times := make([]int64, 0, len(g.byTime))
+		for t := range g.byTime {
+			times = append(times, t)
+		}
+		sort.Slice(times, func(i, j int) bool { return times[i] < times[j] })
+
+		h := Histogram{Name: g.name, Labels: g.labels}
+		for _, q := range quantiles {
+			qs := QuantileSeries{Quantile: q}
+			for _, t := range times {
+				var v float64
+				if g.cumul {
+					v = classicQuantile(q, g.byTime[t])
+				} else {
+					v = cellQuantile(q, g.byTime[t])
+				}
+				if math.IsNaN(v) {
+					continue
+				}
+				qs.Times = append(qs.Times, t)
+				qs.Values = append(qs.Values, v)
+			}
+			qs.Stats = Summarize(qs.Values)
+			h.Quantiles = append(h.Quantiles, qs)
+		}
+		out = append(out, h)
+	}
+	return out, true
+}
+
+// classicQuantile computes quantile q from cumulative buckets. It follows
+// histogram_quantile: NaN without a +Inf bucket or observations, the
+// highest finite bound when q falls in +Inf, and linear interpolation
+// otherwise, with 0 as the lower bound of the first bucket if it is
+// positive.
+func classicQuantile(q float64, buckets []bucket) float64 {
+	if q < 0 || q > 1 || len(buckets) < 2 {
+		return math.NaN()
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].upper < buckets[j].upper })
+	if !math.IsInf(buckets[len(buckets)-1].upper, 1) {
+		return math.NaN()
+	}
+	// Counts may dip across buckets when series are scraped at slightly
+	// different times; cumulative counts never decrease
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i].count < buckets[i-1].count {
+			buckets[i].count = buckets[i-1].count
+		}
+	}
+	total := buckets[len(buckets)-1].count
+	if total <= 0 {
+		return math.NaN()
+	}
+	rank := q * total
+	i := sort.Search(len(buckets)-1, func(i int) bool { return buckets[i].count >= rank })
+	if i == len(buckets)-1 {
+		return buckets[len(buckets)-2].upper
+	}
+	if i == 0 && buckets[0].upper <= 0 {
+		return buckets[0].upper
+	}
+	lower, below := 0.0, 0.0
+	if i > 0 {
+		lower, below = buckets[i-1].upper, buckets[i-1].count
+	}
+	inBucket := buckets[i].count - below
+	if inBucket <= 0 {
+		return buckets[i].upper
+	}
+	return lower + (buckets[i].upper-lower)*(rank-below)/inBucket
+}
+
+// cellQuantile computes quantile q from non-cumulative buckets with their
+// own bounds
+func cellQuantile(q float64, buckets []bucket) float64 {
+	if q < 0 || q > 1 || len(buckets) == 0 {
+		return math.NaN()
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].upper < buckets[j].upper })
+	total := 0.0
+	for _, b := range buckets {
+		total += b.count
+	}
+	if total <= 0 {
+		return math.NaN()
+	}
+	rank, seen := q*total, 0.0
+	for _, b := range buckets {
+		if b.count > 0 && seen+b.count >= rank {
+			return b.lower + (b.upper-b.lower)*(rank-seen)/b.count
+		}
+		seen += b.count
+	}
+	return buckets[len(buckets)-1].upper
+}
+
+// heatmapCells reads a native histogram frame as buckets by timestamp, or
+// returns nil for any other frame
+func heatmapCells(f grafana.DataFrame) map[int64][]bucket {
+	idx := map[string]int{}
+	for i, field := range f.Schema.Fields {
+		idx[field.Name] = i
+	}
+	tIdx, ok1 := idx["xMax"]
+	if !ok1 {
+		tIdx, ok1 = idx["xMin"]
+	}
+	lo, ok2 := idx["yMin"]
+	hi, ok3 := idx["yMax"]
+	n, ok4 := idx["count"]
+	if !ok1 || !ok2 || !ok3 || !ok4 {
+		return nil
+	}
+	vals := f.Data.Values
+	if tIdx >= len(vals) || lo >= len(vals) || hi >= len(vals) || n >= len(vals) {
+		return nil
+	}
+	out := map[int64][]bucket{}
+	for j := range vals[tIdx] {
+		if j >= len(vals[lo]) || j >= len(vals[hi]) || j >= len(vals[n]) {
+			break
+		}
+		t, ok1 := toFloat(vals[tIdx][j])
+		l, ok2 := toFloat(vals[lo][j])
+		u, ok3 := toFloat(vals[hi][j])
+		c, ok4 := toFloat(vals[n][j])
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			continue
+		}
+		out[int64(t)] = append(out[int64(t)], bucket{lower: l, upper: u, count: c})
+	}
+	return out
+}
+
+func labelKey(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	key := ""
+	for _, k := range keys {
+		key += k + "=" + labels[k] + ","
+	}
+	return key
+}
@@ -0,0 +1,179 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+func TestClassicQuantile(t *testing.T) {
+	inf := math.Inf(1)
+	latency := []bucket{{upper: 0.1, count: 10}, {upper: 0.5, count: 60}, {upper: 1, count: 90}, {upper: inf, count: 100}}
+	tests := []struct {
+		name    string
+		q       float64
+		buckets []bucket
+		want    float64
+	}{
+		{"median interpolates within its bucket", 0.5, latency, 0.42},
+		{"first bucket starts at zero", 0.05, latency, 0.05},
+		{"zero quantile", 0, latency, 0},
+		{"upper bound of a bucket", 0.9, latency, 1},
+		{"+Inf bucket gives the highest finite bound", 0.95, latency, 1},
+		{"buckets in any order", 0.5, []bucket{latency[3], latency[1], latency[0], latency[2]}, 0.42},
+		{"dips in cumulative counts are flattened", 0.5, []bucket{{upper: 0.1, count: 10}, {upper: 0.5, count: 8}, {upper: 1, count: 20}, {upper: inf, count: 20}}, 0.1},
+		{"non-positive first bound is returned as is", 0.25, []bucket{{upper: -1, count: 10}, {upper: 0, count: 20}, {upper: inf, count: 20}}, -1},
+		{"empty bucket returns its upper bound", 0, []bucket{{upper: 0.1, count: 0}, {upper: 0.5, count: 10}, {upper: inf, count: 10}}, 0.1},
+		{"no +Inf bucket", 0.5, []bucket{{upper: 0.1, count: 10}, {upper: 0.5, count: 20}}, math.NaN()},
+		{"single bucket", 0.5, []bucket{{upper: inf, count: 10}}, math.NaN()},
+		{"no observations", 0.5, []bucket{{upper: 0.1, count: 0}, {upper: inf, count: 0}}, math.NaN()},
+		{"quantile above 1", 1.5, latency, math.NaN()},
+		{"negative quantile", -0.1, latency, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := append([]bucket(nil), tt.buckets...)
+			if got := classicQuantile(tt.q, buckets); !sameFloat(got, tt.want) {
+				t.Fatalf("classicQuantile(%v) = %v, want %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCellQuantile(t *testing.T) {
+	cells := []bucket{{0, 1, 10}, {1, 2, 30}, {2, 4, 60}}
+	tests := []struct {
+		name    string
+		q       float64
+		buckets []bucket
+		want    float64
+	}{
+		{"median", 0.5, cells, 2 + 2*10.0/60},
+		{"bucket boundary", 0.1, cells, 1},
+		{"maximum", 1, cells, 4},
+		{"cells in any order", 0.5, []bucket{cells[2], cells[0], cells[1]}, 2 + 2*10.0/60},
+		{"zero quantile skips empty cells", 0, []bucket{{0, 1, 0}, {1, 2, 10}}, 1},
+		{"no cells", 0.5, nil, math.NaN()},
+		{"no observations", 0.5, []bucket{{0, 1, 0}}, math.NaN()},
+		{"quantile above 1", 2, cells, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buckets := append([]bucket(nil), tt.buckets...)
+			if got := cellQuantile(tt.q, buckets); !sameFloat(got, tt.want) {
+				t.Fatalf("cellQuantile(%v) = %v, want %v", tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+// bucketFrame is one classic histogram bucket series as Prometheus returns it
+func bucketFrame(labels map[string]string, times []interface{}, counts []interface{}) grafana.DataFrame {
+	return frame("", []grafana.FieldSchema{
+		{Name: "Time", Type: "time"},
+		{Name: "Value", Type: "number", Labels: labels},
+	}, times, counts)
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	ts := []interface{}{1000.0, 2000.0}
+	classic := func(job string) []grafana.DataFrame {
+		return []grafana.DataFrame{
+			bucketFrame(map[string]string{"job": job, "le": "+Inf"}, ts, []interface{}{100.0, 40.0}),
+			bucketFrame(map[string]string{"job": job, "le": "0.1"}, ts, []interface{}{10.0, 40.0}),
+			bucketFrame(map[string]string{"job": job, "le": "0.5"}, ts, []interface{}{60.0, 40.0}),
+			bucketFrame(map[string]string{"job": job, "le": "1"}, ts, []interface{}{90.0, 40.0}),
+		}
+	}
+	native := frame("latency", []grafana.FieldSchema{
+		{Name: "xMax", Type: "time"}, {Name: "yMin", Type: "number"}, {Name: "yMax", Type: "number"},
+		{Name: "count", Type: "number", Labels: map[string]string{"job": "api"}},
+	},
+		[]interface{}{1000.0, 1000.0, 2000.0},
+		[]interface{}{0.0, 1.0, 0.0},
+		[]interface{}{1.0, 2.0, 1.0},
+		[]interface{}{10.0, 10.0, 0.0},
+	)
+
+	type quantile struct {
+		Name     string
+		Quantile float64
+		Times    []int64
+		Values   []float64
+	}
+	flatten := func(hs []Histogram) []quantile {
+		out := []quantile{}
+		for _, h := range hs {
+			for _, q := range h.Quantiles {
+				out = append(out, quantile{h.Name, q.Quantile, q.Times, q.Values})
+			}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name   string
+		frames []grafana.DataFrame
+		want   []quantile
+		wantOK bool
+	}{
+		{
+			name:   "classic buckets grouped by their other labels",
+			frames: append(classic("api"), classic("web")...),
+			want: []quantile{
+				{"histogram{job=api}", 0.5, []int64{1000, 2000}, []float64{0.42, 0.05}},
+				{"histogram{job=api}", 0.99, []int64{1000, 2000}, []float64{1, 0.099}},
+				{"histogram{job=web}", 0.5, []int64{1000, 2000}, []float64{0.42, 0.05}},
+				{"histogram{job=web}", 0.99, []int64{1000, 2000}, []float64{1, 0.099}},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "native heatmap cells, empty timestamps dropped",
+			frames: []grafana.DataFrame{native},
+			want: []quantile{
+				{"latency", 0.5, []int64{1000}, []float64{1}},
+				{"latency", 0.99, []int64{1000}, []float64{1.98}},
+			},
+			wantOK: true,
+		},
+		{
+			name: "series without le are not histograms",
+			frames: []grafana.DataFrame{bucketFrame(map[string]string{"job": "api"}, ts, []interface{}{1.0, 2.0}),
+				bucketFrame(map[string]string{"le": "fast"}, ts, []interface{}{1.0, 2.0})},
+			want: []quantile{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hs, ok := HistogramQuantiles(tt.frames, []float64{0.5, 0.99})
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			got := flatten(hs)
+			if len(got) != len(tt.want) {
+				t.Fatalf("quantiles = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				g, w := got[i], tt.want[i]
+				if g.Name != w.Name || g.Quantile != w.Quantile || !reflect.DeepEqual(g.Times, w.Times) || len(g.Values) != len(w.Values) {
+					t.Fatalf("quantile %d = %+v, want %+v", i, g, w)
+				}
+				for j := range g.Values {
+					if !sameFloat(g.Values[j], w.Values[j]) {
+						t.Fatalf("quantile %d = %+v, want %+v", i, g, w)
+					}
+				}
+			}
+		})
+	}
+}
+
+func sameFloat(a, b float64) bool {
+	if math.IsNaN(a) || math.IsNaN(b) {
+		return math.IsNaN(a) && math.IsNaN(b)
+	}
+	return math.Abs(a-b) < 1e-9
+}
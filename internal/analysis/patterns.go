@@ -0,0 +1,189 @@
+package analysis
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Wildcard replaces the variable parts of a log pattern
+const Wildcard = "<*>"
+
+// LogLine is a single log entry extracted from a data frame
+type LogLine struct {
+	Time int64
+	Line string
+}
+
+// FramesToLogLines extracts log lines from Loki-style frames. The line field
+// is the string field named Line, line, or body, falling back to the first
+// string field that is not an ID or label column.
+func FramesToLogLines(frames []grafana.DataFrame) []LogLine {
+	var out []LogLine
+	for _, f := range frames {
+		timeIdx, lineIdx := -1, -1
+		for i, field := range f.Schema.Fields {
+			switch {
+			case field.Type == "time" && timeIdx < 0:
+				timeIdx = i
+			case field.Type == "string" && (field.Name == "Line" || field.Name == "line" || field.Name == "body"):
+				lineIdx = i
+			case field.Type == "string" && lineIdx < 0 && field.Name != "id" && field.Name != "tsNs" && field.Name != "labels":
+				lineIdx = i
+			}
+		}
+		if lineIdx < 0 || lineIdx >= len(f.Data.Values) {
+			continue
+		}
+		col := f.Data.Values[lineIdx]
+		for j, v := range col {
+			s, ok := v.(string)
+			if !ok {
+				continue
+			}
+			l := LogLine{Line: s}
+			if timeIdx >= 0 && timeIdx < len(f.Data.Values) && j < len(f.Data.Values[timeIdx]) {
+				if t, ok := toFloat(f.Data.Values[timeIdx][j]); ok {
+					l.Time = int64(t)
+				}
+			}
+			out = append(out, l)
+		}
+	}
+	return out
+}
+
+// Pattern is a log template shared by a cluster of similar lines
+type Pattern struct {
+	Template  string   `json:"template"`
+	Count     int      `json:"count"`
+	FirstSeen int64    `json:"first_seen,omitempty"`
+	LastSeen  int64    `json:"last_seen,omitempty"`
+	Examples  []string `json:"examples"`
+
+	tokens []string
+}
+
+// PatternMiner clusters log lines into templates using the Drain approach:
+// lines are grouped by token count and leading token, then merged into the
+// most similar existing template when at least the similarity threshold of
+// their tokens agree. Tokens that differ become wildcards.
+type PatternMiner struct {
+	similarity  float64
+	maxExamples int
+	groups      map[string][]*Pattern
+	order       []*Pattern
+}
+
+// NewPatternMiner creates a miner that merges lines whose token similarity to
+// a template is at least similarity (0-1) and keeps up to maxExamples sample
+// lines per pattern
+func NewPatternMiner(similarity float64, maxExamples int) *PatternMiner {
+	if similarity <= 0 || similarity > 1 {
+		similarity = 0.5
+	}
+	return &PatternMiner{
+		similarity:  similarity,
+		maxExamples: maxExamples,
+		groups:      make(map[string][]*Pattern),
+	}
+}
+
+// Add assigns a line to its closest pattern, creating one if none is close enough
+func (m *PatternMiner) Add(line string, t int64) {
+	tokens := tokenize(line)
+	if len(tokens) == 0 {
+		return
+	}
+	key := groupKey(tokens)
+
+	var best *Pattern
+	bestScore := -1.0
+	for _, p := range m.groups[key] {
+		if score := similarity(p.tokens, tokens); score >= m.similarity && score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if best == nil {
+		best = &Pattern{tokens: tokens, FirstSeen: t, LastSeen: t, Examples: []string{}}
+		m.groups[key] = append(m.groups[key], best)
+		m.order = append(m.order, best)
+	} else {
+		for i, tok := range tokens {
+			if best.tokens[i] != tok {
+				best.tokens[i] = Wildcard
+			}
+		}
+	}
+
+	best.Count++
+	if t != 0 && (best.FirstSeen == 0 || t < best.FirstSeen) {
+		best.FirstSeen = t
+	}
+	if t > best.LastSeen {
+		best.LastSeen = t
+	}
+	if len(best.Examples) < m.maxExamples {
+		best.Examples = append(best.Examples, line)
+	}
+}
+
+// Patterns returns every pattern, most frequent first
+func (m *PatternMiner) Patterns() []Pattern {
+	out := make([]Pattern, 0, len(m.order))
+	for _, p := range m.order {
+		c := *p
+		c.Template = strings.Join(p.tokens, " ")
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Count > out[j].Count })
+	return out
+}
+
+// tokenize splits a line on whitespace and masks tokens that look variable:
+// anything containing a digit, and the values of key=value pairs that do
+func tokenize(line string) []string {
+	tokens := strings.Fields(line)
+	for i, tok := range tokens {
+		if k, v, ok := strings.Cut(tok, "="); ok && k != "" {
+			if hasDigit(v) {
+				tokens[i] = k + "=" + Wildcard
+			}
+			continue
+		}
+		if hasDigit(tok) {
+			tokens[i] = Wildcard
+		}
+	}
+	return tokens
+}
+
+// groupKey buckets lines by length and leading token so only plausible
+// candidates are compared
+func groupKey(tokens []string) string {
+	return strconv.Itoa(len(tokens)) + "|" + tokens[0]
+}
+
+// similarity is the fraction of positions where the template matches the
+// line exactly or holds a wildcard
+func similarity(template, tokens []string) float64 {
+	same := 0
+	for i, tok := range tokens {
+		if template[i] == tok || template[i] == Wildcard {
+			same++
+		}
+	}
+	return float64(same) / float64(len(tokens))
+}
+
+func hasDigit(s string) bool {
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", []string{}},
+		{"  server started  ", []string{"server", "started"}},
+		{"GET /api/users/42 200 13ms", []string{"GET", "<*>", "<*>", "<*>"}},
+		{"user=alice status=500 retry=true", []string{"user=alice", "status=<*>", "retry=true"}},
+		{"=5 a==1", []string{"<*>", "a=<*>"}},
+		{"took ٣ms", []string{"took", "<*>"}},
+	}
+	for _, tt := range tests {
+		if got := tokenize(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tokenize(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		template, tokens []string
+		want             float64
+	}{
+		{[]string{"a", "b", "c", "d"}, []string{"a", "b", "c", "d"}, 1},
+		{[]string{"a", "b", "c", "d"}, []string{"a", "x", "c", "y"}, 0.5},
+		{[]string{"a", Wildcard, "c", "d"}, []string{"a", "x", "c", "y"}, 0.75},
+		{[]string{"a", "b"}, []string{"x", "y"}, 0},
+	}
+	for _, tt := range tests {
+		if got := similarity(tt.template, tt.tokens); got != tt.want {
+			t.Errorf("similarity(%q, %q) = %v, want %v", tt.template, tt.tokens, got, tt.want)
+		}
+	}
+}
+
+func TestPatternMiner(t *testing.T) {
+	type pattern struct {
+		Template string
+		Count    int
+	}
+	tests := []struct {
+		name       string
+		similarity float64
+		lines      []string
+		want       []pattern
+	}{
+		{
+			name:       "variable values become wildcards",
+			similarity: 0.5,
+			lines: []string{
+				"connection from 10.0.0.1 accepted",
+				"connection from 10.0.0.2 accepted",
+				"connection from 10.0.0.3 refused",
+			},
+			want: []pattern{{"connection from <*> <*>", 3}},
+		},
+		{
+			name:       "most frequent first, ties in arrival order",
+			similarity: 0.5,
+			lines: []string{
+				"cache miss",
+				"user alice logged in",
+				"user bob logged in",
+				"user carol logged out",
+				"shutting down",
+			},
+			want: []pattern{{"user <*> logged <*>", 3}, {"cache miss", 1}, {"shutting down", 1}},
+		},
+		{
+			name:       "different lengths never merge",
+			similarity: 0.1,
+			lines:      []string{"job done", "job done now"},
+			want:       []pattern{{"job done", 1}, {"job done now", 1}},
+		},
+		{
+			name:       "different leading tokens never merge",
+			similarity: 0.1,
+			lines:      []string{"alpha done", "beta done"},
+			want:       []pattern{{"alpha done", 1}, {"beta done", 1}},
+		},
+		{
+			name:       "below the similarity threshold starts a new pattern",
+			similarity: 0.9,
+			lines:      []string{"user alice logged in", "user bob logged in"},
+			want:       []pattern{{"user alice logged in", 1}, {"user bob logged in", 1}},
+		},
+		{
+			name:       "out of range similarity defaults to one half",
+			similarity: 2,
+			lines:      []string{"user alice logged in", "user bob logged out"},
+			want:       []pattern{{"user <*> logged <*>", 2}},
+		},
+		{
+			name:       "blank lines are ignored",
+			similarity: 0.5,
+			lines:      []string{"", "   ", "ready"},
+			want:       []pattern{{"ready", 1}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewPatternMiner(tt.similarity, 2)
+			for i, line := range tt.lines {
+				m.Add(line, int64(i+1))
+			}
+			got := []pattern{}
+			for _, p := range m.Patterns() {
+				got = append(got, pattern{p.Template, p.Count})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("patterns = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternMinerTracksTimesAndExamples(t *testing.T) {
+	m := NewPatternMiner(0.5, 2)
+	m.Add("request 1 ok", 300)
+	m.Add("request 2 ok", 100)
+	m.Add("request 3 ok", 0)
+	m.Add("request 4 ok", 200)
+
+	patterns := m.Patterns()
+	if len(patterns) != 1 {
+		t.Fatalf("patterns = %+v, want one", patterns)
+	}
+	p := patterns[0]
+	if p.FirstSeen != 100 || p.LastSeen != 300 {
+		t.Errorf("seen %d-%d, want 100-300 ignoring the untimed line", p.FirstSeen, p.LastSeen)
+	}
+	if want := []string{"request 1 ok", "request 2 ok"}; !reflect.DeepEqual(p.Examples, want) {
+		t.Errorf("examples = %q, want the first %d lines", p.Examples, len(want))
+	}
+}
+
+func TestFramesToLogLines(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames []grafana.DataFrame
+		want   []LogLine
+	}{
+		{
+			name: "Loki frame with labels and id columns",
+			frames: []grafana.DataFrame{frame("", []grafana.FieldSchema{
+				{Name: "labels", Type: "other"}, {Name: "Time", Type: "time"}, {Name: "Line", Type: "string"},
+				{Name: "tsNs", Type: "string"}, {Name: "id", Type: "string"},
+			},
+				[]interface{}{nil, nil}, []interface{}{1000.0, 2000.0}, []interface{}{"first", "second"},
+				[]interface{}{"1000000000", "2000000000"}, []interface{}{"a", "b"},
+			)},
+			want: []LogLine{{1000, "first"}, {2000, "second"}},
+		},
+		{
+			name: "a named line field wins over earlier strings",
+			frames: []grafana.DataFrame{frame("", []grafana.FieldSchema{
+				{Name: "level", Type: "string"}, {Name: "body", Type: "string"},
+			}, []interface{}{"info"}, []interface{}{"hello"})},
+			want: []LogLine{{0, "hello"}},
+		},
+		{
+			name: "falls back to the first plain string field",
+			frames: []grafana.DataFrame{frame("", []grafana.FieldSchema{
+				{Name: "id", Type: "string"}, {Name: "message", Type: "string"}, {Name: "host", Type: "string"},
+			}, []interface{}{"x"}, []interface{}{"hello"}, []interface{}{"web-1"})},
+			want: []LogLine{{0, "hello"}},
+		},
+		{
+			name: "non-string values and frames without lines are skipped",
+			frames: []grafana.DataFrame{
+				frame("", []grafana.FieldSchema{{Name: "Time", Type: "time"}, {Name: "Value", Type: "number"}},
+					[]interface{}{1000.0}, []interface{}{1.0}),
+				frame("", []grafana.FieldSchema{{Name: "Line", Type: "string"}}, []interface{}{nil, "kept"}),
+			},
+			want: []LogLine{{0, "kept"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FramesToLogLines(tt.frames); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("lines = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
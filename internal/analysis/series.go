@@ -0,0 +1,145 @@
+// Package analysis implements the local statistics used by the investigation
+// tools: frame-to-series conversion, change point detection, summaries, and
+// log pattern mining.
+package analysis
+
+import (
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Series is a single numeric time series extracted from a data frame
+type Series struct {
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Times  []int64           `json:"-"`
+	Values []float64         `json:"-"`
+}
+
+// FramesToSeries converts query result frames into numeric series. Every
+// number field in a frame with a time field becomes one series; points with
+// null values are dropped.
+func FramesToSeries(frames []grafana.DataFrame) []Series {
+	var out []Series
+	for _, f := range frames {
+		timeIdx := -1
+		for i, field := range f.Schema.Fields {
+			if field.Type == "time" {
+				timeIdx = i
+				break
+			}
+		}
+		if timeIdx < 0 || timeIdx >= len(f.Data.Values) {
+			continue
+		}
+		times := f.Data.Values[timeIdx]
+
+		for i, field := range f.Schema.Fields {
+			if field.Type != "number" || i >= len(f.Data.Values) {
+				continue
+			}
+			s := Series{Name: seriesName(f.Schema.Name, field), Labels: field.Labels}
+			col := f.Data.Values[i]
+			for j := 0; j < len(col) && j < len(times); j++ {
+				t, tok := toFloat(times[j])
+				v, vok := toFloat(col[j])
+				if !tok || !vok || math.IsNaN(v) {
+					continue
+				}
+				s.Times = append(s.Times, int64(t))
+				s.Values = append(s.Values, v)
+			}
+			if len(s.Values) > 0 {
+				out = append(out, s)
+			}
+		}
+	}
+	return out
+}
+
+func seriesName(frameName string, field grafana.FieldSchema) string {
+	if len(field.Labels) == 0 {
+		if frameName != "" {
+			return frameName
+		}
+		return field.Name
+	}
+	keys := make([]string, 0, len(field.Labels))
+	for k := range field.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+"="+field.Labels[k])
+	}
+	return field.Name + "{" + strings.Join(parts, ", ") + "}"
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+// Stats summarizes a slice of values
+type Stats struct {
+	Count  int     `json:"count"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Last   float64 `json:"last"`
+}
+
+// Summarize computes basic statistics over values
+func Summarize(values []float64) Stats {
+	if len(values) == 0 {
+		return Stats{}
+	}
+	s := Stats{Count: len(values), Min: values[0], Max: values[0], Last: values[len(values)-1]}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+		if v < s.Min {
+			s.Min = v
+		}
+		if v > s.Max {
+			s.Max = v
+		}
+	}
+	s.Mean = sum / float64(len(values))
+	s.StdDev = stddev(values, s.Mean)
+	return s
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func stddev(values []float64, m float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - m) * (v - m)
+	}
+	return math.Sqrt(ss / float64(len(values)-1))
+}
@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+func frame(name string, fields []grafana.FieldSchema, values ...[]interface{}) grafana.DataFrame {
+	return grafana.DataFrame{
+		Schema: grafana.FrameSchema{Name: name, Fields: fields},
+		Data:   grafana.FrameData{Values: values},
+	}
+}
+
+func TestFramesToSeries(t *testing.T) {
+	timeField := grafana.FieldSchema{Name: "Time", Type: "time"}
+	tests := []struct {
+		name   string
+		frames []grafana.DataFrame
+		want   []Series
+	}{
+		{
+			name: "labels name the series in key order",
+			frames: []grafana.DataFrame{frame("up", []grafana.FieldSchema{
+				timeField,
+				{Name: "Value", Type: "number", Labels: map[string]string{"job": "api", "instance": "a:9090"}},
+			}, []interface{}{1000.0, 2000.0}, []interface{}{1.0, 0.0})},
+			want: []Series{{
+				Name:   "Value{instance=a:9090, job=api}",
+				Labels: map[string]string{"job": "api", "instance": "a:9090"},
+				Times:  []int64{1000, 2000},
+				Values: []float64{1, 0},
+			}},
+		},
+		{
+			name: "unlabelled fields take the frame name, then the field name",
+			frames: []grafana.DataFrame{
+				frame("requests", []grafana.FieldSchema{timeField, {Name: "Value", Type: "number"}},
+					[]interface{}{1000.0}, []interface{}{5.0}),
+				frame("", []grafana.FieldSchema{timeField, {Name: "errors", Type: "number"}},
+					[]interface{}{1000.0}, []interface{}{int64(2)}),
+			},
+			want: []Series{
+				{Name: "requests", Times: []int64{1000}, Values: []float64{5}},
+				{Name: "errors", Times: []int64{1000}, Values: []float64{2}},
+			},
+		},
+		{
+			name: "nulls and NaN are dropped",
+			frames: []grafana.DataFrame{frame("cpu", []grafana.FieldSchema{timeField, {Name: "Value", Type: "number"}},
+				[]interface{}{1000.0, 2000.0, 3000.0, nil}, []interface{}{1.0, nil, math.NaN(), 4.0})},
+			want: []Series{{Name: "cpu", Times: []int64{1000}, Values: []float64{1}}},
+		},
+		{
+			name: "each number field is a series; strings are skipped",
+			frames: []grafana.DataFrame{frame("", []grafana.FieldSchema{
+				timeField, {Name: "host", Type: "string"}, {Name: "min", Type: "number"}, {Name: "max", Type: "number"},
+			}, []interface{}{1000.0}, []interface{}{"a"}, []interface{}{1.0}, []interface{}{9.0})},
+			want: []Series{
+				{Name: "min", Times: []int64{1000}, Values: []float64{1}},
+				{Name: "max", Times: []int64{1000}, Values: []float64{9}},
+			},
+		},
+		{
+			name: "frames without a time field or values are skipped",
+			frames: []grafana.DataFrame{
+				frame("table", []grafana.FieldSchema{{Name: "Value", Type: "number"}}, []interface{}{1.0}),
+				frame("empty", []grafana.FieldSchema{timeField, {Name: "Value", Type: "number"}}),
+				frame("all null", []grafana.FieldSchema{timeField, {Name: "Value", Type: "number"}},
+					[]interface{}{1000.0}, []interface{}{nil}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FramesToSeries(tt.frames)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("series = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		want   Stats
+	}{
+		{"empty", nil, Stats{}},
+		{"single value", []float64{3}, Stats{Count: 1, Min: 3, Max: 3, Mean: 3, Last: 3}},
+		{"sample deviation", []float64{2, 4, 4, 4, 5, 5, 7, 9}, Stats{Count: 8, Min: 2, Max: 9, Mean: 5, StdDev: math.Sqrt(32.0 / 7), Last: 9}},
+		{"last is the final value, not the max", []float64{-2, 10, 4}, Stats{Count: 3, Min: -2, Max: 10, Mean: 4, StdDev: 6, Last: 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.values); got != tt.want {
+				t.Fatalf("Summarize(%v) = %+v, want %+v", tt.values, got, tt.want)
+			}
+		})
+	}
+}
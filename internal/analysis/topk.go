@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// TopKFrames keeps the k series (number fields) that rank highest, or lowest
+// when bottom is set, by the given aggregate of their values: "max"
+// (default), "avg", or "last". Frames left without a number field are
+// dropped. It returns the kept frames and the number of series before filtering.
+func TopKFrames(frames []grafana.DataFrame, k int, by string, bottom bool) ([]grafana.DataFrame, int) {
+	type ref struct {
+		frame, field int
+		score        float64
+	}
+	var refs []ref
+	for fi, f := range frames {
+		for i, field := range f.Schema.Fields {
+			if field.Type != "number" || i >= len(f.Data.Values) {
+				continue
+			}
+			refs = append(refs, ref{frame: fi, field: i, score: aggregate(f.Data.Values[i], by)})
+		}
+	}
+	total := len(refs)
+	if k <= 0 || total <= k {
+		return frames, total
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		a, b := refs[i].score, refs[j].score
+		// Series without values rank last either way
+		if math.IsNaN(a) != math.IsNaN(b) {
+			return !math.IsNaN(a)
+		}
+		if bottom {
+			return a < b
+		}
+		return a > b
+	})
+	keep := map[[2]int]bool{}
+	for _, r := range refs[:k] {
+		keep[[2]int{r.frame, r.field}] = true
+	}
+
+	var out []grafana.DataFrame
+	for fi, f := range frames {
+		kept := grafana.DataFrame{Schema: grafana.FrameSchema{Name: f.Schema.Name}}
+		hasSeries := false
+		for i, field := range f.Schema.Fields {
+			if field.Type == "number" && !keep[[2]int{fi, i}] {
+				continue
+			}
+			hasSeries = hasSeries || field.Type == "number"
+			kept.Schema.Fields = append(kept.Schema.Fields, field)
+			if i < len(f.Data.Values) {
+				kept.Data.Values = append(kept.Data.Values, f.Data.Values[i])
+			}
+		}
+		if hasSeries {
+			out = append(out, kept)
+		}
+	}
+	return out, total
+}
+
+// aggregate reduces a column to one value, or NaN if it has no numbers
+func aggregate(col []interface{}, by string) float64 {
+	var vals []float64
+	for _, v := range col {
+		if f, ok := toFloat(v); ok && !math.IsNaN(f) {
+			vals = append(vals, f)
+		}
+	}
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	switch by {
+	case "avg":
+		return mean(vals)
+	case "last":
+		return vals[len(vals)-1]
+	}
+	return Summarize(vals).Max
+}
@@ -0,0 +1,114 @@
+// Package cache provides a small in-memory LRU cache with per-entry
+// expiry, shared by tools that memoize slow Grafana calls.
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache is a concurrency-safe LRU cache whose entries expire after a TTL.
+// The zero value is not usable; create one with New.
+type Cache[V any] struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	max    int
+	order  *list.List
+	items  map[string]*list.Element
+	hits   int64
+	misses int64
+}
+
+type entry[V any] struct {
+	key     string
+	value   V
+	expires time.Time
+}
+
+// Stats reports cache usage counters
+type Stats struct {
+	Entries int   `json:"entries"`
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+}
+
+// New creates a cache holding at most max entries for ttl each. A max of 0
+// or less means unbounded; a ttl of 0 or less means entries never expire.
+func New[V any](max int, ttl time.Duration) *Cache[V] {
+	return &Cache[V]{
+		ttl:   ttl,
+		max:   max,
+		order: list.New(),
+		items: make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached value for key if present and not expired
+func (c *Cache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var zero V
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return zero, false
+	}
+	e := el.Value.(*entry[V])
+	if c.ttl > 0 && time.Now().After(e.expires) {
+		c.removeElement(el)
+		c.misses++
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	c.hits++
+	return e.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when full
+func (c *Cache[V]) Set(key string, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expires := time.Now().Add(c.ttl)
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry[V]{key: key, value: value, expires: expires})
+	for c.max > 0 && c.order.Len() > c.max {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// Delete removes key from the cache
+func (c *Cache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.removeElement(el)
+	}
+}
+
+// Purge removes every entry
+func (c *Cache[V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// Stats returns the current entry count and hit/miss counters
+func (c *Cache[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{Entries: c.order.Len(), Hits: c.hits, Misses: c.misses}
+}
+
+func (c *Cache[V]) removeElement(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.items, el.Value.(*entry[V]).key)
+}
@@ -3,24 +3,398 @@ package config
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-// ToolConfig controls whether an individual MCP tool is enabled.
+// ToolConfig controls whether an individual MCP tool is enabled and how many
+// calls to it may run at once.
 type ToolConfig struct {
-	Enabled *bool `yaml:"enabled"`
+	Enabled       *bool `yaml:"enabled"`
+	MaxConcurrent int   `yaml:"max_concurrent"`
+}
+
+// LimitsConfig bounds concurrent tool execution across all tools.
+type LimitsConfig struct {
+	// MaxConcurrentCalls caps in-flight tool calls; 0 means unlimited.
+	MaxConcurrentCalls int `yaml:"max_concurrent_calls"`
+	// SerializeWritesByUID runs write tools targeting the same uid one at a
+	// time, avoiding version conflicts on concurrent saves. Defaults to true.
+	SerializeWritesByUID *bool `yaml:"serialize_writes_by_uid"`
+}
+
+// RenderConfig tunes the panel render cache and renderer load.
+type RenderConfig struct {
+	// CacheTTL is how long rendered images are reused, e.g. "5m"; "0"
+	// disables the cache. Empty uses the default.
+	CacheTTL string `yaml:"cache_ttl"`
+	// CacheSize is the maximum number of cached images.
+	CacheSize int `yaml:"cache_size"`
+	// MaxConcurrent caps renders in flight across all calls.
+	MaxConcurrent int `yaml:"max_concurrent"`
+}
+
+// QueryCacheConfig tunes the cache of datasource query results.
+type QueryCacheConfig struct {
+	// TTL is how long results are reused, e.g. "30s"; "0" disables the
+	// cache. Empty uses the default.
+	TTL string `yaml:"ttl"`
+	// Size is the maximum number of cached results.
+	Size int `yaml:"size"`
+	// MinStep is the smallest bucket relative time ranges are rounded to,
+	// e.g. "10s".
+	MinStep string `yaml:"min_step"`
+}
+
+// ResponseCacheConfig sets how long cached Grafana responses are reused,
+// per endpoint family.
+type ResponseCacheConfig struct {
+	// Folders, Datasources, and Dashboards are TTLs such as "30s"; "0"
+	// stops caching that family. Empty uses the default.
+	Folders     string `yaml:"folders"`
+	Datasources string `yaml:"datasources"`
+	Dashboards  string `yaml:"dashboards"`
+	// Size is the most responses kept per family.
+	Size int `yaml:"size"`
+}
+
+// InventoryConfig controls the background prefetch of folders,
+// datasources, and dashboard metadata.
+type InventoryConfig struct {
+	// Prefetch enables the prefetch. Defaults to true.
+	Prefetch *bool `yaml:"prefetch"`
+	// Interval is how often the inventory is refreshed, e.g. "5m"; "0"
+	// fetches it once at startup. Empty uses the default.
+	Interval string `yaml:"interval"`
+}
+
+// ResourcesConfig tunes the MCP resources clients can subscribe to.
+type ResourcesConfig struct {
+	// FiringAlertsInterval is how often firing alerts are polled while a
+	// client is subscribed to grafana://alerts/firing, e.g. "30s". Empty
+	// uses the default.
+	FiringAlertsInterval string `yaml:"firing_alerts_interval"`
+}
+
+// JobConfig is a tool call run on a cron schedule.
+type JobConfig struct {
+	Name     string                 `yaml:"name"`
+	Schedule string                 `yaml:"schedule"`
+	Tool     string                 `yaml:"tool"`
+	Args     map[string]interface{} `yaml:"args"`
+}
+
+// SchedulerConfig enables the built-in scheduler and lists its jobs.
+type SchedulerConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// History is the number of runs kept across all jobs (default 100).
+	History int         `yaml:"history"`
+	Jobs    []JobConfig `yaml:"jobs"`
+}
+
+// WebhookConfig is an outbound notification target for tool calls and jobs.
+type WebhookConfig struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// Format is "generic" (default) or "slack".
+	Format string `yaml:"format"`
+	// On lists triggering events: destructive, write, error, job,
+	// job_failed, tool:<glob>, or job:<glob>.
+	On []string `yaml:"on"`
+	// Template is a Go text/template over the event.
+	Template    string            `yaml:"template"`
+	Headers     map[string]string `yaml:"headers"`
+	IncludeArgs bool              `yaml:"include_args"`
+}
+
+// GuardrailsConfig bounds what a query may scan before it is sent to a
+// Prometheus or Loki datasource.
+type GuardrailsConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Action is "warn" (default) to run the query and attach the estimate,
+	// or "refuse" to reject queries over a limit.
+	Action string `yaml:"action"`
+	// MaxTimeRange is the longest from/to span, e.g. "31d".
+	MaxTimeRange string `yaml:"max_time_range"`
+	// MaxRangeSelector is the longest range selector or subquery window,
+	// e.g. "1d" rejects rate(x[30d]).
+	MaxRangeSelector string `yaml:"max_range_selector"`
+	// MaxSeries caps the Prometheus series a query's selectors may match.
+	MaxSeries int `yaml:"max_series"`
+	// MaxLogBytes caps the bytes Loki's index reports for a query's stream
+	// selectors over the time range.
+	MaxLogBytes int64 `yaml:"max_log_bytes"`
+	// MaxLogStreams caps the Loki streams a query's selectors may match.
+	MaxLogStreams int64 `yaml:"max_log_streams"`
+}
+
+// ExpressionRuleConfig blocks queries whose text matches Pattern, a Go
+// regular expression, on the listed datasources (all when both are empty).
+type ExpressionRuleConfig struct {
+	Pattern     string   `yaml:"pattern"`
+	Datasources []string `yaml:"datasources"`
+	Types       []string `yaml:"types"`
+}
+
+// DatasourceAccessConfig restricts which datasources the query tools may
+// target. Deny rules win; empty allow lists allow everything.
+type DatasourceAccessConfig struct {
+	AllowDatasources []string               `yaml:"allow_datasources"`
+	AllowTypes       []string               `yaml:"allow_types"`
+	DenyDatasources  []string               `yaml:"deny_datasources"`
+	DenyTypes        []string               `yaml:"deny_types"`
+	DenyExpressions  []ExpressionRuleConfig `yaml:"deny_expressions"`
+}
+
+// URLPolicyConfig restricts the hosts URLs given to tools may point at.
+// Deny rules win; with no allow rules every host not denied is allowed.
+type URLPolicyConfig struct {
+	// AllowHosts and DenyHosts are host names; * matches any characters.
+	AllowHosts []string `yaml:"allow_hosts"`
+	DenyHosts  []string `yaml:"deny_hosts"`
+	// AllowCIDRs and DenyCIDRs match the addresses hosts resolve to.
+	AllowCIDRs []string `yaml:"allow_cidrs"`
+	DenyCIDRs  []string `yaml:"deny_cidrs"`
+	// DenyPrivate refuses loopback, private, and link-local addresses
+	// outside AllowCIDRs.
+	DenyPrivate bool `yaml:"deny_private"`
+}
+
+// ConfirmationConfig makes destructive tools ask before they run: the first
+// call returns a token, and only the same call repeated with it goes ahead.
+type ConfirmationConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// TTL is how long a token stays valid, e.g. "5m". Empty uses the
+	// default.
+	TTL string `yaml:"ttl"`
+}
+
+// SessionsConfig tunes keepalive and expiry of sessions on network
+// transports. Durations are strings such as "30s"; "0" disables the ping
+// or idle timeout, and empty uses the default.
+type SessionsConfig struct {
+	// PingInterval is how often the server pings a connected client. A
+	// connection silent for two intervals is dropped.
+	PingInterval string `yaml:"ping_interval"`
+	// IdleTimeout closes a session that has sent no requests and has no
+	// calls running for this long, releasing its resources.
+	IdleTimeout string `yaml:"idle_timeout"`
+	// ResumeWindow is how long a session can be resumed after its
+	// connection drops.
+	ResumeWindow string `yaml:"resume_window"`
+}
+
+// OutputConfig sets how timestamps in tool output are rendered.
+type OutputConfig struct {
+	// Timezone is an IANA zone name such as "Europe/Berlin", or "UTC" or
+	// "Local". Empty uses the server's local zone.
+	Timezone string `yaml:"timezone"`
+	// TimeFormat is "rfc3339" (default), "rfc1123", "datetime", or a Go
+	// time layout such as "2006-01-02 15:04 MST".
+	TimeFormat string `yaml:"time_format"`
+}
+
+// ProvisioningConfig points at dashboards Grafana provisions from files, for
+// servers running next to Grafana.
+type ProvisioningConfig struct {
+	// DashboardsPath is a directory of dashboard JSON files, or of provider
+	// YAML files such as /etc/grafana/provisioning/dashboards.
+	DashboardsPath string `yaml:"dashboards_path"`
+}
+
+// FilesConfig confines the local files tools read from and write to the
+// server's disk.
+type FilesConfig struct {
+	// ExportDir is the directory tools write files into; their output_dir
+	// arguments resolve within it. Unset, export tools cannot write files
+	// and renders go to the temp dir.
+	ExportDir string `yaml:"export_dir"`
+	// JsonnetDir is the directory Jsonnet files and libraries are read from.
+	// Unset, only inline Jsonnet without imports is evaluated.
+	JsonnetDir string `yaml:"jsonnet_dir"`
+	// JsonnetTimeout bounds how long a Jsonnet program may evaluate, e.g.
+	// "5s". Empty uses the default of 10s.
+	JsonnetTimeout string `yaml:"jsonnet_timeout"`
+}
+
+// MimirConfig points the ruler tools at a Mimir or Cortex cluster reached
+// directly, for stacks with no Grafana datasource for it. Password, Token,
+// and header values may reference environment variables as ${VAR}.
+type MimirConfig struct {
+	URL string `yaml:"url"`
+	// Tenant is sent as X-Scope-OrgID.
+	Tenant   string            `yaml:"tenant"`
+	Username string            `yaml:"username"`
+	Password string            `yaml:"password"`
+	Token    string            `yaml:"token"`
+	Headers  map[string]string `yaml:"headers"`
+}
+
+// TokenConfig controls the check of the service account token's expiry and
+// its rotation. AdminToken may reference environment variables as ${VAR}.
+type TokenConfig struct {
+	// ExpiryWarning is how long before expiry to warn, e.g. "14d"; "0" turns
+	// the background check off. Empty uses the default.
+	ExpiryWarning string `yaml:"expiry_warning"`
+	// CheckInterval is how often the token is checked, e.g. "12h"; "0"
+	// checks it once at startup. Empty uses the default.
+	CheckInterval string `yaml:"check_interval"`
+	// Rotate replaces an expiring token stored with `grafana-mcp login`.
+	Rotate bool `yaml:"rotate"`
+	// RotationTTL is the lifetime of rotated tokens, e.g. "90d"; "0" never
+	// expires them. Empty uses the default.
+	RotationTTL string `yaml:"rotation_ttl"`
+	// AdminToken reads and rotates the service account's tokens when the
+	// token itself may not.
+	AdminToken string `yaml:"admin_token"`
+}
+
+// RetryConfig controls retries of requests to Grafana that failed
+// transiently.
+type RetryConfig struct {
+	// MaxAttempts is the most attempts per request; 1 disables retries.
+	// 0 uses the default.
+	MaxAttempts int `yaml:"max_attempts"`
+	// InitialBackoff is the first wait, e.g. "250ms", doubled per retry.
+	InitialBackoff string `yaml:"initial_backoff"`
+	// MaxBackoff caps each wait; a longer Retry-After ends the retries.
+	MaxBackoff string `yaml:"max_backoff"`
+	// Jitter is the fraction of each wait randomly taken off it, 0 to 1.
+	Jitter *float64 `yaml:"jitter"`
+}
+
+// TLSConfig is the TLS setup of connections to Grafana. The GRAFANA_TLS_*
+// environment variables override it.
+type TLSConfig struct {
+	// CertFile and KeyFile are a PEM client certificate and key, for
+	// Grafana behind mutual TLS.
+	CertFile string `yaml:"cert_file"`
+	KeyFile  string `yaml:"key_file"`
+	// CAFile holds PEM CA certificates trusted besides the system's.
+	CAFile string `yaml:"ca_file"`
+	// InsecureSkipVerify accepts any server certificate, for development.
+	InsecureSkipVerify bool `yaml:"insecure_skip_verify"`
+}
+
+// InstanceConfig is a further Grafana instance, such as dev or stage, that
+// tools reach through their instance argument. APIKey and Password may
+// reference environment variables as ${VAR}.
+type InstanceConfig struct {
+	// Name is what the instance argument calls it: letters, digits, - and _.
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+	// APIKey is a service account token or API key. Without one, Username
+	// and Password are sent with basic auth; without either, no credentials.
+	APIKey   string `yaml:"api_key"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+	// TLS replaces the tls section for this instance when set.
+	TLS TLSConfig `yaml:"tls"`
+	// OrgID is the organization requests go to; 0 uses the user's current
+	// organization.
+	OrgID int64 `yaml:"org_id"`
+}
+
+// instanceName is the form of instance names
+var instanceName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]*$`)
+
+// namedTimeFormats are the layouts TimeFormat accepts by name
+var namedTimeFormats = map[string]string{
+	"rfc3339":  time.RFC3339,
+	"rfc1123":  time.RFC1123,
+	"datetime": "2006-01-02 15:04:05 MST",
 }
 
 // yamlConfig is the raw YAML file structure.
 type yamlConfig struct {
-	Tools map[string]ToolConfig `yaml:"tools"`
+	Tools        map[string]ToolConfig  `yaml:"tools"`
+	Limits       LimitsConfig           `yaml:"limits"`
+	Render       RenderConfig           `yaml:"render"`
+	QueryCache   QueryCacheConfig       `yaml:"query_cache"`
+	Responses    ResponseCacheConfig    `yaml:"response_cache"`
+	Inventory    InventoryConfig        `yaml:"inventory"`
+	Resources    ResourcesConfig        `yaml:"resources"`
+	Scheduler    SchedulerConfig        `yaml:"scheduler"`
+	Webhooks     []WebhookConfig        `yaml:"webhooks"`
+	Guardrails   GuardrailsConfig       `yaml:"guardrails"`
+	Access       DatasourceAccessConfig `yaml:"datasource_access"`
+	URLPolicy    URLPolicyConfig        `yaml:"url_policy"`
+	Sessions     SessionsConfig         `yaml:"sessions"`
+	Confirmation ConfirmationConfig     `yaml:"confirmation"`
+	Output       OutputConfig           `yaml:"output"`
+	Provisioning ProvisioningConfig     `yaml:"provisioning"`
+	Files        FilesConfig            `yaml:"files"`
+	Mimir        MimirConfig            `yaml:"mimir"`
+	Token        TokenConfig            `yaml:"token"`
+	TLS          TLSConfig              `yaml:"tls"`
+	Retry        RetryConfig            `yaml:"retry"`
+
+	DefaultInstance string           `yaml:"default_instance"`
+	Instances       []InstanceConfig `yaml:"instances"`
 }
 
-// ToolsConfig holds per-tool enable/disable settings loaded from a YAML file.
+// ToolsConfig holds per-tool settings and execution limits loaded from a YAML file.
 type ToolsConfig struct {
-	tools map[string]ToolConfig
+	tools     map[string]ToolConfig
+	limits    LimitsConfig
+	render    RenderConfig
+	cacheTTL  *time.Duration
+	queryTTL  *time.Duration
+	queryStep time.Duration
+	querySize int
+	inventory InventoryConfig
+	invEvery  *time.Duration
+	scheduler SchedulerConfig
+	webhooks  []WebhookConfig
+
+	// firingEvery is the poll interval of subscribed firing alerts
+	firingEvery time.Duration
+
+	// responseTTLs holds the response cache TTLs set, by family
+	responseTTLs map[string]time.Duration
+	responseSize int
+
+	guardrails       GuardrailsConfig
+	maxTimeRange     time.Duration
+	maxRangeSelector time.Duration
+	access           DatasourceAccessConfig
+	urlPolicy        URLPolicyConfig
+
+	pingInterval *time.Duration
+	idleTimeout  *time.Duration
+	resumeWindow *time.Duration
+
+	confirmation ConfirmationConfig
+	confirmTTL   time.Duration
+
+	location   *time.Location
+	timeLayout string
+
+	provisioning   ProvisioningConfig
+	files          FilesConfig
+	jsonnetTimeout time.Duration
+	mimir          MimirConfig
+
+	token        TokenConfig
+	tokenWarning *time.Duration
+	tokenEvery   *time.Duration
+	tokenTTL     *time.Duration
+
+	tls TLSConfig
+
+	retry        RetryConfig
+	retryInitial time.Duration
+	retryMax     time.Duration
+
+	defaultInstance string
+	instances       []InstanceConfig
 }
 
 // Load reads tool configuration from the file pointed to by GRAFANA_CONFIG_FILE,
@@ -49,6 +423,236 @@ func Load() (*ToolsConfig, error) {
 	if y.Tools != nil {
 		cfg.tools = y.Tools
 	}
+	if y.Limits.MaxConcurrentCalls < 0 {
+		return nil, fmt.Errorf("parsing config file %q: limits.max_concurrent_calls must not be negative", path)
+	}
+	for name, tc := range cfg.tools {
+		if tc.MaxConcurrent < 0 {
+			return nil, fmt.Errorf("parsing config file %q: tools.%s.max_concurrent must not be negative", path, name)
+		}
+	}
+	cfg.limits = y.Limits
+	if y.Render.CacheTTL != "" {
+		ttl, err := time.ParseDuration(y.Render.CacheTTL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config file %q: render.cache_ttl: %w", path, err)
+		}
+		cfg.cacheTTL = &ttl
+	}
+	cfg.render = y.Render
+	if y.QueryCache.TTL != "" {
+		ttl, err := time.ParseDuration(y.QueryCache.TTL)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config file %q: query_cache.ttl: %w", path, err)
+		}
+		cfg.queryTTL = &ttl
+	}
+	if y.QueryCache.MinStep != "" {
+		if cfg.queryStep, err = time.ParseDuration(y.QueryCache.MinStep); err != nil {
+			return nil, fmt.Errorf("parsing config file %q: query_cache.min_step: %w", path, err)
+		}
+	}
+	cfg.querySize = y.QueryCache.Size
+	cfg.responseTTLs = map[string]time.Duration{}
+	for family, v := range map[string]string{
+		"folders":     y.Responses.Folders,
+		"datasources": y.Responses.Datasources,
+		"dashboards":  y.Responses.Dashboards,
+	} {
+		if v == "" {
+			continue
+		}
+		ttl, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config file %q: response_cache.%s: %w", path, family, err)
+		}
+		cfg.responseTTLs[family] = ttl
+	}
+	cfg.responseSize = y.Responses.Size
+	if y.Inventory.Interval != "" {
+		every, err := time.ParseDuration(y.Inventory.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("parsing config file %q: inventory.interval: %w", path, err)
+		}
+		cfg.invEvery = &every
+	}
+	cfg.inventory = y.Inventory
+	if v := y.Resources.FiringAlertsInterval; v != "" {
+		if cfg.firingEvery, err = time.ParseDuration(v); err != nil || cfg.firingEvery <= 0 {
+			return nil, fmt.Errorf("parsing config file %q: resources.firing_alerts_interval must be a positive duration", path)
+		}
+	}
+	for i, job := range y.Scheduler.Jobs {
+		if job.Name == "" || job.Schedule == "" || job.Tool == "" {
+			return nil, fmt.Errorf("parsing config file %q: scheduler.jobs[%d] needs name, schedule, and tool", path, i)
+		}
+	}
+	cfg.scheduler = y.Scheduler
+	cfg.webhooks = y.Webhooks
+
+	g := y.Guardrails
+	if g.Action != "" && g.Action != "warn" && g.Action != "refuse" {
+		return nil, fmt.Errorf("parsing config file %q: guardrails.action must be warn or refuse", path)
+	}
+	if g.MaxSeries < 0 || g.MaxLogBytes < 0 || g.MaxLogStreams < 0 {
+		return nil, fmt.Errorf("parsing config file %q: guardrails limits must not be negative", path)
+	}
+	if g.MaxTimeRange != "" {
+		if cfg.maxTimeRange, err = parseDuration(g.MaxTimeRange); err != nil {
+			return nil, fmt.Errorf("parsing config file %q: guardrails.max_time_range: %w", path, err)
+		}
+	}
+	if g.MaxRangeSelector != "" {
+		if cfg.maxRangeSelector, err = parseDuration(g.MaxRangeSelector); err != nil {
+			return nil, fmt.Errorf("parsing config file %q: guardrails.max_range_selector: %w", path, err)
+		}
+	}
+	cfg.guardrails = g
+
+	for i, rule := range y.Access.DenyExpressions {
+		if _, err := regexp.Compile(rule.Pattern); err != nil || rule.Pattern == "" {
+			return nil, fmt.Errorf("parsing config file %q: datasource_access.deny_expressions[%d] needs a valid pattern", path, i)
+		}
+	}
+	cfg.access = y.Access
+
+	for _, cidrs := range [][]string{y.URLPolicy.AllowCIDRs, y.URLPolicy.DenyCIDRs} {
+		for _, cidr := range cidrs {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return nil, fmt.Errorf("parsing config file %q: url_policy: %q is not a CIDR range", path, cidr)
+			}
+		}
+	}
+	cfg.urlPolicy = y.URLPolicy
+
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   **time.Duration
+	}{
+		{"ping_interval", y.Sessions.PingInterval, &cfg.pingInterval},
+		{"idle_timeout", y.Sessions.IdleTimeout, &cfg.idleTimeout},
+		{"resume_window", y.Sessions.ResumeWindow, &cfg.resumeWindow},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(d.value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("parsing config file %q: sessions.%s must be a non-negative duration", path, d.name)
+		}
+		*d.dst = &v
+	}
+
+	if ttl := y.Confirmation.TTL; ttl != "" {
+		if cfg.confirmTTL, err = parseDuration(ttl); err != nil || cfg.confirmTTL <= 0 {
+			return nil, fmt.Errorf("parsing config file %q: confirmation.ttl must be a positive duration", path)
+		}
+	}
+	cfg.confirmation = y.Confirmation
+
+	if tz := y.Output.Timezone; tz != "" {
+		if cfg.location, err = time.LoadLocation(tz); err != nil {
+			return nil, fmt.Errorf("parsing config file %q: output.timezone: %w", path, err)
+		}
+	}
+	if f := y.Output.TimeFormat; f != "" {
+		cfg.timeLayout = f
+		if layout, ok := namedTimeFormats[strings.ToLower(f)]; ok {
+			cfg.timeLayout = layout
+		}
+	}
+	cfg.provisioning = y.Provisioning
+	cfg.files = y.Files
+	if v := y.Files.JsonnetTimeout; v != "" {
+		if cfg.jsonnetTimeout, err = time.ParseDuration(v); err != nil || cfg.jsonnetTimeout <= 0 {
+			return nil, fmt.Errorf("parsing config file %q: files.jsonnet_timeout must be a positive duration", path)
+		}
+	}
+
+	if m := y.Mimir; m.URL != "" {
+		u, err := url.Parse(m.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("parsing config file %q: mimir.url must be an http or https URL", path)
+		}
+		m.Password, m.Token = os.ExpandEnv(m.Password), os.ExpandEnv(m.Token)
+		for k, v := range m.Headers {
+			m.Headers[k] = os.ExpandEnv(v)
+		}
+		cfg.mimir = m
+	}
+
+	for _, d := range []struct {
+		name  string
+		value string
+		dst   **time.Duration
+	}{
+		{"expiry_warning", y.Token.ExpiryWarning, &cfg.tokenWarning},
+		{"check_interval", y.Token.CheckInterval, &cfg.tokenEvery},
+		{"rotation_ttl", y.Token.RotationTTL, &cfg.tokenTTL},
+	} {
+		if d.value == "" {
+			continue
+		}
+		v, err := parseDuration(d.value)
+		if err != nil || v < 0 {
+			return nil, fmt.Errorf("parsing config file %q: token.%s must be a non-negative duration", path, d.name)
+		}
+		*d.dst = &v
+	}
+	y.Token.AdminToken = os.ExpandEnv(y.Token.AdminToken)
+	cfg.token = y.Token
+
+	if (y.TLS.CertFile == "") != (y.TLS.KeyFile == "") {
+		return nil, fmt.Errorf("parsing config file %q: tls.cert_file and tls.key_file must be set together", path)
+	}
+	cfg.tls = y.TLS
+
+	r := y.Retry
+	if r.MaxAttempts < 0 {
+		return nil, fmt.Errorf("parsing config file %q: retry.max_attempts must not be negative", path)
+	}
+	if r.Jitter != nil && (*r.Jitter < 0 || *r.Jitter > 1) {
+		return nil, fmt.Errorf("parsing config file %q: retry.jitter must be between 0 and 1", path)
+	}
+	if r.InitialBackoff != "" {
+		if cfg.retryInitial, err = time.ParseDuration(r.InitialBackoff); err != nil || cfg.retryInitial < 0 {
+			return nil, fmt.Errorf("parsing config file %q: retry.initial_backoff must be a non-negative duration", path)
+		}
+	}
+	if r.MaxBackoff != "" {
+		if cfg.retryMax, err = time.ParseDuration(r.MaxBackoff); err != nil || cfg.retryMax < 0 {
+			return nil, fmt.Errorf("parsing config file %q: retry.max_backoff must be a non-negative duration", path)
+		}
+	}
+	cfg.retry = r
+
+	if name := y.DefaultInstance; name != "" && !instanceName.MatchString(name) {
+		return nil, fmt.Errorf("parsing config file %q: default_instance %q may only use letters, digits, - and _", path, name)
+	}
+	cfg.defaultInstance = y.DefaultInstance
+	seen := map[string]bool{cfg.DefaultInstance(): true}
+	for i, inst := range y.Instances {
+		switch {
+		case !instanceName.MatchString(inst.Name):
+			return nil, fmt.Errorf("parsing config file %q: instances[%d].name %q may only use letters, digits, - and _", path, i, inst.Name)
+		case seen[inst.Name]:
+			return nil, fmt.Errorf("parsing config file %q: instances[%d].name %q is used twice (the GRAFANA_URL instance is %q)", path, i, inst.Name, cfg.DefaultInstance())
+		}
+		seen[inst.Name] = true
+		u, err := url.Parse(inst.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("parsing config file %q: instances[%d].url must be an http or https URL", path, i)
+		}
+		if (inst.TLS.CertFile == "") != (inst.TLS.KeyFile == "") {
+			return nil, fmt.Errorf("parsing config file %q: instances[%d].tls.cert_file and key_file must be set together", path, i)
+		}
+		if inst.OrgID < 0 {
+			return nil, fmt.Errorf("parsing config file %q: instances[%d].org_id must be a positive organization ID", path, i)
+		}
+		inst.APIKey, inst.Password = os.ExpandEnv(inst.APIKey), os.ExpandEnv(inst.Password)
+		cfg.instances = append(cfg.instances, inst)
+	}
 	return cfg, nil
 }
 
@@ -64,3 +668,284 @@ func (c *ToolsConfig) IsEnabled(name string) bool {
 	}
 	return *tc.Enabled
 }
+
+// MaxConcurrent returns the per-tool concurrency limit, or 0 for unlimited.
+func (c *ToolsConfig) MaxConcurrent(name string) int {
+	return c.tools[name].MaxConcurrent
+}
+
+// MaxConcurrentCalls returns the limit on in-flight tool calls across all
+// tools, or 0 for unlimited.
+func (c *ToolsConfig) MaxConcurrentCalls() int {
+	return c.limits.MaxConcurrentCalls
+}
+
+// SerializeWritesByUID reports whether write tools targeting the same uid
+// should run one at a time.
+func (c *ToolsConfig) SerializeWritesByUID() bool {
+	if c.limits.SerializeWritesByUID == nil {
+		return true
+	}
+	return *c.limits.SerializeWritesByUID
+}
+
+// RenderCacheTTL returns the configured render cache TTL and whether one
+// was set.
+func (c *ToolsConfig) RenderCacheTTL() (time.Duration, bool) {
+	if c.cacheTTL == nil {
+		return 0, false
+	}
+	return *c.cacheTTL, true
+}
+
+// RenderCacheSize returns the configured render cache size, or 0 for the default.
+func (c *ToolsConfig) RenderCacheSize() int {
+	return c.render.CacheSize
+}
+
+// MaxConcurrentRenders returns the configured render concurrency, or 0 for the default.
+func (c *ToolsConfig) MaxConcurrentRenders() int {
+	return c.render.MaxConcurrent
+}
+
+// QueryCacheTTL returns the configured query cache TTL and whether one was set.
+func (c *ToolsConfig) QueryCacheTTL() (time.Duration, bool) {
+	if c.queryTTL == nil {
+		return 0, false
+	}
+	return *c.queryTTL, true
+}
+
+// QueryCacheSize returns the configured query cache size, or 0 for the default.
+func (c *ToolsConfig) QueryCacheSize() int {
+	return c.querySize
+}
+
+// QueryCacheMinStep returns the configured time bucket, or 0 for the default.
+func (c *ToolsConfig) QueryCacheMinStep() time.Duration {
+	return c.queryStep
+}
+
+// ResponseCacheTTL returns the configured TTL of cached responses of family
+// (folders, datasources, or dashboards) and whether one was set.
+func (c *ToolsConfig) ResponseCacheTTL(family string) (time.Duration, bool) {
+	ttl, ok := c.responseTTLs[family]
+	return ttl, ok
+}
+
+// ResponseCacheSize returns the configured number of cached responses per
+// family, or 0 for the default.
+func (c *ToolsConfig) ResponseCacheSize() int {
+	return c.responseSize
+}
+
+// InventoryPrefetch reports whether folders, datasources, and dashboard
+// metadata should be prefetched in the background.
+func (c *ToolsConfig) InventoryPrefetch() bool {
+	if c.inventory.Prefetch == nil {
+		return true
+	}
+	return *c.inventory.Prefetch
+}
+
+// InventoryInterval returns the configured refresh interval and whether one
+// was set.
+func (c *ToolsConfig) InventoryInterval() (time.Duration, bool) {
+	if c.invEvery == nil {
+		return 0, false
+	}
+	return *c.invEvery, true
+}
+
+// FiringAlertsInterval returns how often subscribed firing alerts are
+// polled, or 0 when not configured.
+func (c *ToolsConfig) FiringAlertsInterval() time.Duration {
+	return c.firingEvery
+}
+
+// Scheduler returns the scheduler settings and whether the scheduler is enabled.
+func (c *ToolsConfig) Scheduler() (SchedulerConfig, bool) {
+	return c.scheduler, c.scheduler.Enabled
+}
+
+// Webhooks returns the configured outbound notification targets.
+func (c *ToolsConfig) Webhooks() []WebhookConfig {
+	return c.webhooks
+}
+
+// Guardrails returns the query guardrail settings and whether they are enabled.
+func (c *ToolsConfig) Guardrails() (GuardrailsConfig, bool) {
+	return c.guardrails, c.guardrails.Enabled
+}
+
+// GuardrailRanges returns the parsed guardrail durations; 0 means unlimited.
+func (c *ToolsConfig) GuardrailRanges() (maxTimeRange, maxRangeSelector time.Duration) {
+	return c.maxTimeRange, c.maxRangeSelector
+}
+
+// DatasourceAccess returns the datasource access rules and whether any are set.
+func (c *ToolsConfig) DatasourceAccess() (DatasourceAccessConfig, bool) {
+	a := c.access
+	set := len(a.AllowDatasources)+len(a.AllowTypes)+len(a.DenyDatasources)+len(a.DenyTypes)+len(a.DenyExpressions) > 0
+	return a, set
+}
+
+// URLPolicy returns the restrictions on URLs given to tools and whether
+// any are configured.
+func (c *ToolsConfig) URLPolicy() (URLPolicyConfig, bool) {
+	p := c.urlPolicy
+	return p, p.DenyPrivate || len(p.AllowHosts)+len(p.DenyHosts)+len(p.AllowCIDRs)+len(p.DenyCIDRs) > 0
+}
+
+// SessionPingInterval returns the configured ping interval and whether one
+// was set.
+func (c *ToolsConfig) SessionPingInterval() (time.Duration, bool) {
+	if c.pingInterval == nil {
+		return 0, false
+	}
+	return *c.pingInterval, true
+}
+
+// SessionIdleTimeout returns the configured idle timeout and whether one
+// was set.
+func (c *ToolsConfig) SessionIdleTimeout() (time.Duration, bool) {
+	if c.idleTimeout == nil {
+		return 0, false
+	}
+	return *c.idleTimeout, true
+}
+
+// SessionResumeWindow returns the configured resume window and whether one
+// was set.
+func (c *ToolsConfig) SessionResumeWindow() (time.Duration, bool) {
+	if c.resumeWindow == nil {
+		return 0, false
+	}
+	return *c.resumeWindow, true
+}
+
+// Confirmation returns how long confirmation tokens of destructive tools
+// stay valid, 0 for the default, and whether confirmation is enabled.
+func (c *ToolsConfig) Confirmation() (time.Duration, bool) {
+	return c.confirmTTL, c.confirmation.Enabled
+}
+
+// OutputTimeFormat returns the timezone and Go layout for timestamps in
+// tool output; nil and "" mean the defaults.
+func (c *ToolsConfig) OutputTimeFormat() (*time.Location, string) {
+	return c.location, c.timeLayout
+}
+
+// ProvisioningDashboardsPath returns the configured provisioned dashboards
+// path, or "" when none is set.
+func (c *ToolsConfig) ProvisioningDashboardsPath() string {
+	return c.provisioning.DashboardsPath
+}
+
+// ExportDir returns the directory export tools write into, or "" when
+// they may not write files.
+func (c *ToolsConfig) ExportDir() string {
+	return c.files.ExportDir
+}
+
+// JsonnetDir returns the directory Jsonnet files and libraries are read
+// from, or "" when none may be.
+func (c *ToolsConfig) JsonnetDir() string {
+	return c.files.JsonnetDir
+}
+
+// JsonnetTimeout returns how long a Jsonnet program may evaluate, or 0 for
+// the default.
+func (c *ToolsConfig) JsonnetTimeout() time.Duration {
+	return c.jsonnetTimeout
+}
+
+// Mimir returns the directly reached Mimir or Cortex cluster and whether
+// one is configured.
+func (c *ToolsConfig) Mimir() (MimirConfig, bool) {
+	return c.mimir, c.mimir.URL != ""
+}
+
+// TokenExpiryWarning returns how long before expiry the token is reported
+// as expiring and whether it was set.
+func (c *ToolsConfig) TokenExpiryWarning() (time.Duration, bool) {
+	if c.tokenWarning == nil {
+		return 0, false
+	}
+	return *c.tokenWarning, true
+}
+
+// TokenCheckInterval returns how often the token is checked and whether it
+// was set.
+func (c *ToolsConfig) TokenCheckInterval() (time.Duration, bool) {
+	if c.tokenEvery == nil {
+		return 0, false
+	}
+	return *c.tokenEvery, true
+}
+
+// TokenRotation reports whether expiring tokens are rotated, with the
+// lifetime of rotated tokens and whether it was set.
+func (c *ToolsConfig) TokenRotation() (rotate bool, ttl time.Duration, ttlSet bool) {
+	if c.tokenTTL != nil {
+		ttl, ttlSet = *c.tokenTTL, true
+	}
+	return c.token.Rotate, ttl, ttlSet
+}
+
+// TokenAdminToken returns the token that reads and rotates service account
+// tokens, or "" to use the server's own.
+func (c *ToolsConfig) TokenAdminToken() string {
+	return c.token.AdminToken
+}
+
+// RetryMaxAttempts returns the configured attempts per request, or 0 for
+// the default.
+func (c *ToolsConfig) RetryMaxAttempts() int {
+	return c.retry.MaxAttempts
+}
+
+// RetryBackoff returns the configured initial and maximum waits between
+// attempts, each 0 when not set.
+func (c *ToolsConfig) RetryBackoff() (initial, max time.Duration) {
+	return c.retryInitial, c.retryMax
+}
+
+// RetryJitter returns the configured jitter fraction and whether one was
+// set.
+func (c *ToolsConfig) RetryJitter() (float64, bool) {
+	if c.retry.Jitter == nil {
+		return 0, false
+	}
+	return *c.retry.Jitter, true
+}
+
+// TLS returns the TLS setup of connections to Grafana.
+func (c *ToolsConfig) TLS() TLSConfig {
+	return c.tls
+}
+
+// DefaultInstance returns the name of the instance GRAFANA_URL points at,
+// "default" unless configured.
+func (c *ToolsConfig) DefaultInstance() string {
+	if c.defaultInstance == "" {
+		return "default"
+	}
+	return c.defaultInstance
+}
+
+// Instances returns the further Grafana instances tools can reach.
+func (c *ToolsConfig) Instances() []InstanceConfig {
+	return c.instances
+}
+
+// parseDuration is time.ParseDuration plus the d and w units used in
+// PromQL and Grafana time ranges, e.g. "31d" or "2w".
+func parseDuration(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, suffix)); err == nil && strings.HasSuffix(s, suffix) {
+			return time.Duration(n) * unit, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,189 @@
+package dashboard
+
+// gridWidth is the number of columns in the dashboard grid
+const gridWidth = 24
+
+// MixedUID is the UID of Grafana's Mixed pseudo-datasource, which lets each
+// target of a panel query its own datasource
+const MixedUID = "-- Mixed --"
+
+// Builder assembles a dashboard model, assigning panel IDs and laying panels
+// out left to right on the 24-column grid.
+type Builder struct {
+	dash   map[string]interface{}
+	panels []interface{}
+	nextID int
+	x, y   int
+	rowH   int
+}
+
+// NewBuilder starts a dashboard with the given title
+func NewBuilder(title string) *Builder {
+	return &Builder{
+		dash: map[string]interface{}{
+			"title":         title,
+			"schemaVersion": TargetSchemaVersion,
+			"editable":      true,
+			"time":          map[string]interface{}{"from": "now-6h", "to": "now"},
+			"templating":    map[string]interface{}{"list": []interface{}{}},
+		},
+		nextID: 1,
+	}
+}
+
+// Set sets a top-level dashboard field such as tags, uid, or refresh
+func (b *Builder) Set(key string, value interface{}) *Builder {
+	b.dash[key] = value
+	return b
+}
+
+// Variable appends a template variable
+func (b *Builder) Variable(v map[string]interface{}) *Builder {
+	t := b.dash["templating"].(map[string]interface{})
+	t["list"] = append(t["list"].([]interface{}), v)
+	return b
+}
+
+// Row starts a new expanded row on its own line
+func (b *Builder) Row(title string) *Builder {
+	b.newline()
+	b.add(map[string]interface{}{
+		"type":      "row",
+		"title":     title,
+		"collapsed": false,
+		"panels":    []interface{}{},
+	}, gridWidth, 1)
+	b.newline()
+	return b
+}
+
+// Panel places a panel of width w and height h, wrapping to a new line when
+// it does not fit
+func (b *Builder) Panel(p map[string]interface{}, w, h int) *Builder {
+	if w <= 0 || w > gridWidth {
+		w = gridWidth
+	}
+	if b.x+w > gridWidth {
+		b.newline()
+	}
+	b.add(p, w, h)
+	return b
+}
+
+// Len returns the number of panels added, including rows
+func (b *Builder) Len() int {
+	return len(b.panels)
+}
+
+// Dashboard returns the assembled dashboard model
+func (b *Builder) Dashboard() map[string]interface{} {
+	b.dash["panels"] = b.panels
+	return b.dash
+}
+
+func (b *Builder) add(p map[string]interface{}, w, h int) {
+	p["id"] = b.nextID
+	p["gridPos"] = map[string]interface{}{"x": b.x, "y": b.y, "w": w, "h": h}
+	b.nextID++
+	b.panels = append(b.panels, p)
+	b.x += w
+	if h > b.rowH {
+		b.rowH = h
+	}
+}
+
+func (b *Builder) newline() {
+	if b.x == 0 {
+		return
+	}
+	b.y += b.rowH
+	b.x, b.rowH = 0, 0
+}
+
+// Ref builds a datasource reference
+func Ref(typ, uid string) map[string]interface{} {
+	return map[string]interface{}{"type": typ, "uid": uid}
+}
+
+// MixedRef builds the reference to the Mixed pseudo-datasource
+func MixedRef() map[string]interface{} {
+	return Ref("datasource", MixedUID)
+}
+
+// Target builds a query target with the expr/legendFormat fields used by
+// Prometheus and Loki
+func Target(refID string, ds map[string]interface{}, expr, legend string) map[string]interface{} {
+	t := map[string]interface{}{"refId": refID, "datasource": ds, "expr": expr}
+	if legend != "" {
+		t["legendFormat"] = legend
+	}
+	return t
+}
+
+// TimeseriesPanel builds a time series panel. The panel uses the Mixed
+// datasource when its targets query more than one datasource; a nil ds
+// takes the targets' datasource.
+func TimeseriesPanel(title string, ds map[string]interface{}, unit string, targets ...map[string]interface{}) map[string]interface{} {
+	return vizPanel("timeseries", title, ds, unit, targets)
+}
+
+// StatPanel builds a stat panel showing the last value
+func StatPanel(title string, ds map[string]interface{}, unit string, targets ...map[string]interface{}) map[string]interface{} {
+	p := vizPanel("stat", title, ds, unit, targets)
+	p["options"] = map[string]interface{}{
+		"reduceOptions": map[string]interface{}{"calcs": []interface{}{"lastNotNull"}, "fields": "", "values": false},
+		"colorMode":     "value",
+		"graphMode":     "area",
+	}
+	return p
+}
+
+func vizPanel(typ, title string, ds map[string]interface{}, unit string, targets []map[string]interface{}) map[string]interface{} {
+	defaults := map[string]interface{}{}
+	if unit != "" {
+		defaults["unit"] = unit
+	}
+	ts := make([]interface{}, len(targets))
+	for i, t := range targets {
+		ts[i] = t
+	}
+	return map[string]interface{}{
+		"type":        typ,
+		"title":       title,
+		"datasource":  panelDatasource(ds, targets),
+		"targets":     ts,
+		"fieldConfig": map[string]interface{}{"defaults": defaults, "overrides": []interface{}{}},
+	}
+}
+
+// panelDatasource returns ds, or the Mixed datasource when the targets
+// query another datasource as well. Server-side expressions run alongside
+// any datasource, so they do not count.
+func panelDatasource(ds map[string]interface{}, targets []map[string]interface{}) map[string]interface{} {
+	for _, t := range targets {
+		tds, _ := t["datasource"].(map[string]interface{})
+		if tds == nil || String(tds, "uid") == "__expr__" {
+			continue
+		}
+		switch {
+		case ds == nil:
+			ds = tds
+		case String(tds, "uid") != String(ds, "uid"):
+			return MixedRef()
+		}
+	}
+	return ds
+}
+
+// SetThreshold adds a red threshold step at value and draws it on the panel
+func SetThreshold(p map[string]interface{}, value float64) {
+	defaults := fieldDefaults(p)
+	defaults["thresholds"] = map[string]interface{}{
+		"mode": "absolute",
+		"steps": []interface{}{
+			map[string]interface{}{"color": "green", "value": nil},
+			map[string]interface{}{"color": "red", "value": value},
+		},
+	}
+	ensure(defaults, "custom")["thresholdsStyle"] = map[string]interface{}{"mode": "line"}
+}
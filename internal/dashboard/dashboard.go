@@ -0,0 +1,60 @@
+// Package dashboard provides helpers for working with raw Grafana dashboard JSON.
+package dashboard
+
+// Panels returns every panel in the dashboard in display order, including
+// panels nested inside collapsed rows. Row panels themselves are included so
+// callers can decide whether to skip them.
+func Panels(dash map[string]interface{}) []map[string]interface{} {
+	var out []map[string]interface{}
+	top, _ := dash["panels"].([]interface{})
+	for _, p := range top {
+		pm, ok := p.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		out = append(out, pm)
+		if nested, ok := pm["panels"].([]interface{}); ok {
+			for _, n := range nested {
+				if nm, ok := n.(map[string]interface{}); ok {
+					out = append(out, nm)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// IsRow reports whether the panel is a row container.
+func IsRow(panel map[string]interface{}) bool {
+	t, _ := panel["type"].(string)
+	return t == "row"
+}
+
+// PanelID returns the numeric ID of a panel, or 0 if it has none.
+func PanelID(panel map[string]interface{}) int64 {
+	switch v := panel["id"].(type) {
+	case float64:
+		return int64(v)
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+// FindPanel returns the panel with the given ID, or nil if it does not exist.
+func FindPanel(dash map[string]interface{}, id int64) map[string]interface{} {
+	for _, p := range Panels(dash) {
+		if PanelID(p) == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// String returns a string field from a JSON object, or "" if absent.
+func String(obj map[string]interface{}, key string) string {
+	s, _ := obj[key].(string)
+	return s
+}
@@ -0,0 +1,96 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// Change is a single difference between two JSON documents. Old is absent
+// for additions and New is absent for removals.
+type Change struct {
+	Path string      `json:"path"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Clone returns a deep copy of a JSON object.
+func Clone(obj map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// Diff returns the differences between two decoded JSON values, ordered by
+// path. Array elements are compared by index.
+func Diff(a, b interface{}) []Change {
+	var out []Change
+	diffValue("", a, b, &out)
+	return out
+}
+
+func diffValue(path string, a, b interface{}, out *[]Change) {
+	am, aIsMap := a.(map[string]interface{})
+	bm, bIsMap := b.(map[string]interface{})
+	if aIsMap && bIsMap {
+		keys := make([]string, 0, len(am)+len(bm))
+		for k := range am {
+			keys = append(keys, k)
+		}
+		for k := range bm {
+			if _, ok := am[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			av, aok := am[k]
+			bv, bok := bm[k]
+			switch {
+			case !aok:
+				*out = append(*out, Change{Path: p, New: bv})
+			case !bok:
+				*out = append(*out, Change{Path: p, Old: av})
+			default:
+				diffValue(p, av, bv, out)
+			}
+		}
+		return
+	}
+
+	as, aIsSlice := a.([]interface{})
+	bs, bIsSlice := b.([]interface{})
+	if aIsSlice && bIsSlice {
+		n := len(as)
+		if len(bs) > n {
+			n = len(bs)
+		}
+		for i := 0; i < n; i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(as):
+				*out = append(*out, Change{Path: p, New: bs[i]})
+			case i >= len(bs):
+				*out = append(*out, Change{Path: p, Old: as[i]})
+			default:
+				diffValue(p, as[i], bs[i], out)
+			}
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*out = append(*out, Change{Path: path, Old: a, New: b})
+	}
+}
@@ -0,0 +1,192 @@
+package dashboard
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/go-jsonnet"
+)
+
+// JsonnetSource describes a Jsonnet (typically grafonnet) dashboard program.
+// Exactly one of Snippet or File should be set.
+type JsonnetSource struct {
+	Snippet string
+	File    string
+	// JPath lists library search directories. The file's own directory and
+	// its vendor/ subdirectory are searched automatically.
+	JPath   []string
+	ExtVars map[string]string
+	TLAVars map[string]string
+	// Dir is where imports of a Snippet are resolved from, along with its
+	// vendor/ subdirectory
+	Dir string
+	// Resolve vets every file the program reads, including File, returning
+	// the path to read or an error to refuse it. When nil, a Snippet cannot
+	// import anything and File cannot be used.
+	Resolve func(path string) (string, error)
+	// Timeout bounds the evaluation; 0 or less uses DefaultJsonnetTimeout
+	Timeout time.Duration
+}
+
+const (
+	// DefaultJsonnetTimeout is how long a program may evaluate unless
+	// JsonnetSource.Timeout says otherwise
+	DefaultJsonnetTimeout = 10 * time.Second
+	// jsonnetMaxStack bounds the call depth of a program, so unbounded
+	// recursion fails fast instead of growing until the timeout
+	jsonnetMaxStack = 500
+	// maxJsonnetEvaluations caps evaluations running at once. go-jsonnet
+	// cannot be interrupted, so an evaluation abandoned at its deadline
+	// keeps its goroutine until it finishes; the cap stops runaway programs
+	// from piling up.
+	maxJsonnetEvaluations = 4
+)
+
+// errNoImports is returned for reads of a program without a Resolve
+var errNoImports = errors.New("imports are disabled: no jsonnet library directory is configured")
+
+// jsonnetSlots holds a token per running evaluation
+var jsonnetSlots = make(chan struct{}, maxJsonnetEvaluations)
+
+// EvaluateJsonnet evaluates a Jsonnet program and returns the dashboard model
+// it produces. Programs that wrap the model in a {"dashboard": ...} envelope,
+// as the save API does, are unwrapped. It gives up when ctx is done or the
+// source's timeout passes.
+func EvaluateJsonnet(ctx context.Context, src JsonnetSource) (map[string]interface{}, error) {
+	if (src.Snippet == "") == (src.File == "") {
+		return nil, fmt.Errorf("exactly one of snippet or file is required")
+	}
+
+	if src.File != "" && src.Resolve == nil {
+		return nil, errNoImports
+	}
+
+	jpath := append([]string{}, src.JPath...)
+	if src.File != "" {
+		dir := filepath.Dir(src.File)
+		jpath = append(jpath, dir, filepath.Join(dir, "vendor"))
+	} else if src.Dir != "" {
+		jpath = append(jpath, src.Dir, filepath.Join(src.Dir, "vendor"))
+	}
+
+	vm := jsonnet.MakeVM()
+	vm.MaxStack = jsonnetMaxStack
+	vm.Importer(&vettedImporter{jpath: jpath, resolve: src.Resolve, cache: map[string]jsonnet.Contents{}})
+	for k, v := range src.ExtVars {
+		vm.ExtVar(k, v)
+	}
+	for k, v := range src.TLAVars {
+		vm.TLAVar(k, v)
+	}
+
+	timeout := src.Timeout
+	if timeout <= 0 {
+		timeout = DefaultJsonnetTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	out, err := evaluateWithin(ctx, func() (string, error) {
+		if src.File != "" {
+			path, err := src.Resolve(src.File)
+			if err != nil {
+				return "", err
+			}
+			return vm.EvaluateFile(path)
+		}
+		return vm.EvaluateAnonymousSnippet(filepath.Join(src.Dir, "dashboard.jsonnet"), src.Snippet)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var model map[string]interface{}
+	if err := json.Unmarshal([]byte(out), &model); err != nil {
+		return nil, fmt.Errorf("jsonnet output is not a JSON object: %w", err)
+	}
+	if inner, ok := model["dashboard"].(map[string]interface{}); ok {
+		if _, hasPanels := model["panels"]; !hasPanels {
+			model = inner
+		}
+	}
+	if String(model, "title") == "" {
+		return nil, fmt.Errorf("jsonnet output has no dashboard title")
+	}
+	return model, nil
+}
+
+// evaluateWithin runs eval in a goroutine of its own and returns its
+// result, or ctx's error once ctx is done
+func evaluateWithin(ctx context.Context, eval func() (string, error)) (string, error) {
+	select {
+	case jsonnetSlots <- struct{}{}:
+	case <-ctx.Done():
+		return "", fmt.Errorf("waiting for a jsonnet evaluation slot: %w", ctx.Err())
+	}
+	type result struct {
+		out string
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		defer func() { <-jsonnetSlots }()
+		out, err := eval()
+		done <- result{out, err}
+	}()
+	select {
+	case r := <-done:
+		return r.out, r.err
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("jsonnet evaluation did not finish in time: %w", ctx.Err())
+		}
+		return "", ctx.Err()
+	}
+}
+
+// vettedImporter resolves imports like jsonnet's FileImporter, relative to
+// the importing file and then in the library paths, but reads only files
+// resolve accepts
+type vettedImporter struct {
+	jpath   []string
+	resolve func(path string) (string, error)
+	cache   map[string]jsonnet.Contents
+}
+
+func (im *vettedImporter) Import(importedFrom, importedPath string) (jsonnet.Contents, string, error) {
+	if im.resolve == nil {
+		return jsonnet.Contents{}, "", errNoImports
+	}
+	dirs := []string{filepath.Dir(importedFrom)}
+	for i := len(im.jpath) - 1; i >= 0; i-- {
+		dirs = append(dirs, im.jpath[i])
+	}
+	for _, dir := range dirs {
+		path := importedPath
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		path, err := im.resolve(path)
+		if err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+		if c, ok := im.cache[path]; ok {
+			return c, path, nil
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return jsonnet.Contents{}, "", err
+		}
+		c := jsonnet.MakeContentsRaw(data)
+		im.cache[path] = c
+		return c, path, nil
+	}
+	return jsonnet.Contents{}, "", fmt.Errorf("couldn't open import %q: no match locally or in the Jsonnet library paths", importedPath)
+}
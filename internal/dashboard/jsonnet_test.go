@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEvaluateJsonnet(t *testing.T) {
+	model, err := EvaluateJsonnet(context.Background(), JsonnetSource{
+		Snippet: `{ dashboard: { title: std.extVar('env') + ' overview', uid: 'x' } }`,
+		ExtVars: map[string]string{"env": "prod"},
+	})
+	if err != nil {
+		t.Fatalf("EvaluateJsonnet: %v", err)
+	}
+	if model["title"] != "prod overview" {
+		t.Fatalf("model = %v, want the unwrapped dashboard", model)
+	}
+}
+
+func TestEvaluateJsonnetStopsRunawayPrograms(t *testing.T) {
+	// Counting to a few million takes seconds without growing the stack
+	const slow = `{ title: std.toString(std.foldl(function(acc, x) acc + 1, std.range(1, 1000000), 0)) }`
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		src     JsonnetSource
+		want    error
+		wantMsg string
+	}{
+		{
+			name:    "unbounded recursion",
+			ctx:     context.Background(),
+			src:     JsonnetSource{Snippet: `local f(x) = f(x + 1); f(0)`},
+			wantMsg: "max stack frames exceeded",
+		},
+		{
+			name: "timeout",
+			ctx:  context.Background(),
+			src:  JsonnetSource{Snippet: slow, Timeout: 50 * time.Millisecond},
+			want: context.DeadlineExceeded,
+		},
+		{
+			name: "cancelled call",
+			ctx:  cancelled,
+			src:  JsonnetSource{Snippet: slow},
+			want: context.Canceled,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := time.Now()
+			_, err := EvaluateJsonnet(tt.ctx, tt.src)
+			if err == nil {
+				t.Fatal("evaluated, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+			if tt.wantMsg != "" && !strings.Contains(err.Error(), tt.wantMsg) {
+				t.Fatalf("err = %v, want it to mention %q", err, tt.wantMsg)
+			}
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("returned after %v", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,819 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// TargetSchemaVersion is the dashboard schemaVersion written by Upgrade
+const TargetSchemaVersion = 39
+
+// DatasourceResolver maps a legacy datasource name to its UID and type
+type DatasourceResolver func(name string) (uid, typ string, ok bool)
+
+// PanelMigration describes how a single panel was upgraded
+type PanelMigration struct {
+	PanelID int64    `json:"panel_id"`
+	Title   string   `json:"title,omitempty"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Notes   []string `json:"notes,omitempty"`
+}
+
+// UpgradeResult summarizes the changes made by Upgrade
+type UpgradeResult struct {
+	FromSchemaVersion int              `json:"from_schema_version"`
+	ToSchemaVersion   int              `json:"to_schema_version"`
+	Panels            []PanelMigration `json:"panels,omitempty"`
+	Notes             []string         `json:"notes,omitempty"`
+}
+
+// Changed reports whether Upgrade modified the dashboard
+func (u UpgradeResult) Changed() bool {
+	return u.FromSchemaVersion != u.ToSchemaVersion || len(u.Panels) > 0
+}
+
+// Upgrade converts deprecated Angular panels (graph, singlestat, table-old)
+// to their React replacements, rewrites pre-7.0 threshold, field option and
+// legend formats, replaces datasource names with {uid, type} references when
+// resolve is non-nil, and sets schemaVersion to TargetSchemaVersion. The
+// dashboard is modified in place.
+//
+// Dashboards still using the pre-5.0 rows layout are left at their schema
+// version so that Grafana applies its own layout migration on load.
+func Upgrade(dash map[string]interface{}, resolve DatasourceResolver) UpgradeResult {
+	from, _ := number(dash["schemaVersion"])
+	res := UpgradeResult{FromSchemaVersion: int(from), ToSchemaVersion: int(from)}
+
+	for _, p := range Panels(dash) {
+		if IsRow(p) {
+			continue
+		}
+		m := PanelMigration{PanelID: PanelID(p), Title: String(p, "title"), From: String(p, "type")}
+		switch m.From {
+		case "graph":
+			m.Notes = migrateGraph(p)
+		case "singlestat", "grafana-singlestat-panel":
+			m.Notes = migrateSinglestat(p)
+		case "table-old":
+			m.Notes = migrateTableOld(p)
+		}
+		legacy := migrateFieldOptions(p)
+		legacy = migrateLegend(p) || legacy
+		refs := resolve != nil && migrateDatasourceRefs(p, resolve)
+		m.To = String(p, "type")
+		if m.To != m.From || legacy || refs {
+			if legacy {
+				m.Notes = append(m.Notes, "converted legacy field options, thresholds or legend format")
+			}
+			if refs {
+				m.Notes = append(m.Notes, "replaced datasource names with uid references")
+			}
+			res.Panels = append(res.Panels, m)
+		}
+	}
+
+	if resolve != nil {
+		if templating, ok := dash["templating"].(map[string]interface{}); ok {
+			vars, _ := templating["list"].([]interface{})
+			for _, v := range vars {
+				if vm, ok := v.(map[string]interface{}); ok {
+					if ref, ok := datasourceRef(vm["datasource"], resolve); ok {
+						vm["datasource"] = ref
+					}
+				}
+			}
+		}
+	}
+
+	if _, legacyRows := dash["rows"]; legacyRows {
+		res.Notes = append(res.Notes, "dashboard uses the legacy rows layout; schemaVersion left unchanged so Grafana migrates the layout on load")
+		return res
+	}
+	if res.FromSchemaVersion < TargetSchemaVersion {
+		dash["schemaVersion"] = TargetSchemaVersion
+		res.ToSchemaVersion = TargetSchemaVersion
+	}
+	return res
+}
+
+// ============== graph -> timeseries ==============
+
+var graphLegendCalcs = []struct{ legacy, calc string }{
+	{"min", "min"}, {"max", "max"}, {"avg", "mean"}, {"current", "lastNotNull"}, {"total", "sum"},
+}
+
+func migrateGraph(p map[string]interface{}) []string {
+	var notes []string
+	defaults := fieldDefaults(p)
+	custom := ensure(defaults, "custom")
+	options := map[string]interface{}{}
+
+	switch {
+	case p["bars"] == true:
+		custom["drawStyle"] = "bars"
+		custom["fillOpacity"] = 100
+	case p["lines"] == false && p["points"] == true:
+		custom["drawStyle"] = "points"
+	default:
+		custom["drawStyle"] = "line"
+		if fill, ok := number(p["fill"]); ok {
+			custom["fillOpacity"] = fill * 10
+		}
+	}
+	if lw, ok := number(p["linewidth"]); ok {
+		custom["lineWidth"] = lw
+	}
+	if p["points"] == true {
+		custom["showPoints"] = "always"
+		if r, ok := number(p["pointradius"]); ok {
+			custom["pointSize"] = r * 2
+		}
+	} else {
+		custom["showPoints"] = "never"
+	}
+	if p["steppedLine"] == true {
+		custom["lineInterpolation"] = "stepAfter"
+	}
+	if p["dashes"] == true {
+		custom["lineStyle"] = map[string]interface{}{"fill": "dash", "dash": []interface{}{10, 10}}
+	}
+	if p["stack"] == true {
+		mode := "normal"
+		if p["percentage"] == true {
+			mode = "percent"
+		}
+		custom["stacking"] = map[string]interface{}{"mode": mode, "group": "A"}
+	}
+	switch String(p, "nullPointMode") {
+	case "connected":
+		custom["spanNulls"] = true
+	case "null as zero":
+		notes = append(notes, "nullPointMode 'null as zero' has no direct equivalent; add a transformation or set spanNulls")
+	}
+	if d, ok := number(p["decimals"]); ok {
+		defaults["decimals"] = d
+	}
+
+	if yaxes, ok := p["yaxes"].([]interface{}); ok && len(yaxes) > 0 {
+		if y, ok := yaxes[0].(map[string]interface{}); ok {
+			if unit := String(y, "format"); unit != "" {
+				defaults["unit"] = unit
+			}
+			if d, ok := number(y["decimals"]); ok {
+				defaults["decimals"] = d
+			}
+			if v, ok := number(y["min"]); ok {
+				defaults["min"] = v
+			}
+			if v, ok := number(y["max"]); ok {
+				defaults["max"] = v
+			}
+			if label := String(y, "label"); label != "" {
+				custom["axisLabel"] = label
+			}
+			if base, ok := number(y["logBase"]); ok && base > 1 {
+				custom["scaleDistribution"] = map[string]interface{}{"type": "log", "log": base}
+			}
+			if y["show"] == false {
+				custom["axisPlacement"] = "hidden"
+			}
+		}
+	}
+
+	legend := map[string]interface{}{"showLegend": true, "displayMode": "list", "placement": "bottom", "calcs": []interface{}{}}
+	if l, ok := p["legend"].(map[string]interface{}); ok {
+		legend["showLegend"] = l["show"] != false
+		if l["alignAsTable"] == true {
+			legend["displayMode"] = "table"
+		}
+		if l["rightSide"] == true {
+			legend["placement"] = "right"
+		}
+		var calcs []interface{}
+		for _, c := range graphLegendCalcs {
+			if l[c.legacy] == true {
+				calcs = append(calcs, c.calc)
+			}
+		}
+		if calcs != nil {
+			legend["calcs"] = calcs
+		}
+	}
+	options["legend"] = legend
+
+	tooltip := map[string]interface{}{"mode": "single", "sort": "none"}
+	if t, ok := p["tooltip"].(map[string]interface{}); ok {
+		if t["shared"] == true {
+			tooltip["mode"] = "multi"
+		}
+		switch v, _ := number(t["sort"]); v {
+		case 1:
+			tooltip["sort"] = "asc"
+		case 2:
+			tooltip["sort"] = "desc"
+		}
+	}
+	options["tooltip"] = tooltip
+
+	if th, ok := p["thresholds"].([]interface{}); ok && len(th) > 0 {
+		steps := []interface{}{map[string]interface{}{"color": "green", "value": nil}}
+		style := "line"
+		for _, t := range th {
+			tm, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			v, ok := number(tm["value"])
+			if !ok {
+				continue
+			}
+			if String(tm, "op") == "lt" {
+				notes = append(notes, "'lt' graph thresholds were converted as 'gt'; review threshold colors")
+			}
+			if tm["fill"] == true {
+				style = "line+area"
+			}
+			steps = append(steps, map[string]interface{}{"color": thresholdColor(tm), "value": v})
+		}
+		defaults["thresholds"] = map[string]interface{}{"mode": "absolute", "steps": steps}
+		custom["thresholdsStyle"] = map[string]interface{}{"mode": style}
+	}
+
+	overrides := fieldOverrides(p)
+	if colors, ok := p["aliasColors"].(map[string]interface{}); ok {
+		for _, alias := range sortedKeys(colors) {
+			if s, ok := colors[alias].(string); ok {
+				overrides = append(overrides, override("byName", alias,
+					property("color", map[string]interface{}{"mode": "fixed", "fixedColor": s})))
+			}
+		}
+	}
+	if so, ok := p["seriesOverrides"].([]interface{}); ok {
+		for _, o := range so {
+			om, ok := o.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ov, skipped := seriesOverride(om)
+			if ov != nil {
+				overrides = append(overrides, ov)
+			}
+			if len(skipped) > 0 {
+				notes = append(notes, fmt.Sprintf("series override %q: unsupported properties %s dropped", String(om, "alias"), strings.Join(skipped, ", ")))
+			}
+		}
+	}
+	setOverrides(p, overrides)
+
+	if tr, ok := p["timeRegions"].([]interface{}); ok && len(tr) > 0 {
+		notes = append(notes, "time regions are not supported by the time series panel and were dropped")
+	}
+
+	for _, k := range []string{"bars", "lines", "points", "linewidth", "fill", "fillGradient", "pointradius",
+		"stack", "percentage", "steppedLine", "nullPointMode", "yaxes", "xaxis", "yaxis", "legend", "tooltip",
+		"thresholds", "aliasColors", "seriesOverrides", "dashes", "dashLength", "spaceLength", "renderer",
+		"hiddenSeries", "timeRegions", "decimals"} {
+		delete(p, k)
+	}
+	p["options"] = options
+	p["type"] = "timeseries"
+	return notes
+}
+
+// seriesOverride converts a graph seriesOverrides entry to a field override,
+// returning the names of properties that have no equivalent.
+func seriesOverride(o map[string]interface{}) (map[string]interface{}, []string) {
+	alias := String(o, "alias")
+	if alias == "" {
+		return nil, nil
+	}
+	var props []interface{}
+	var skipped []string
+	for _, k := range sortedKeys(o) {
+		v := o[k]
+		switch k {
+		case "alias", "$$hashKey":
+		case "yaxis":
+			if n, _ := number(v); n == 2 {
+				props = append(props, property("custom.axisPlacement", "right"))
+			}
+		case "color":
+			props = append(props, property("color", map[string]interface{}{"mode": "fixed", "fixedColor": v}))
+		case "bars":
+			if v == true {
+				props = append(props, property("custom.drawStyle", "bars"))
+			}
+		case "lines":
+			if v == false {
+				props = append(props, property("custom.lineWidth", 0))
+			}
+		case "linewidth":
+			props = append(props, property("custom.lineWidth", v))
+		case "fill":
+			if n, ok := number(v); ok {
+				props = append(props, property("custom.fillOpacity", n*10))
+			}
+		case "dashes":
+			if v == true {
+				props = append(props, property("custom.lineStyle", map[string]interface{}{"fill": "dash", "dash": []interface{}{10, 10}}))
+			}
+		case "stack":
+			if v == false {
+				props = append(props, property("custom.stacking", map[string]interface{}{"mode": "none"}))
+			}
+		case "legend":
+			if v == false {
+				props = append(props, property("custom.hideFrom", map[string]interface{}{"legend": true, "tooltip": false, "viz": false}))
+			}
+		default:
+			skipped = append(skipped, k)
+		}
+	}
+	if len(props) == 0 {
+		return nil, skipped
+	}
+	matcher := "byName"
+	if strings.HasPrefix(alias, "/") && strings.HasSuffix(alias, "/") && len(alias) > 1 {
+		matcher = "byRegexp"
+	}
+	return override(matcher, alias, props...), skipped
+}
+
+func thresholdColor(t map[string]interface{}) string {
+	switch String(t, "colorMode") {
+	case "critical":
+		return "red"
+	case "warning":
+		return "orange"
+	case "ok":
+		return "green"
+	}
+	if c := String(t, "fillColor"); c != "" {
+		return c
+	}
+	if c := String(t, "lineColor"); c != "" {
+		return c
+	}
+	return "red"
+}
+
+// ============== singlestat -> stat / gauge ==============
+
+var singlestatCalcs = map[string]string{
+	"avg": "mean", "current": "lastNotNull", "max": "max", "min": "min", "total": "sum",
+	"first": "firstNotNull", "delta": "delta", "diff": "diff", "range": "range", "last_time": "lastNotNull",
+}
+
+func migrateSinglestat(p map[string]interface{}) []string {
+	var notes []string
+	defaults := fieldDefaults(p)
+
+	unit := String(p, "format")
+	if unit == "none" {
+		unit = ""
+	}
+	prefix, postfix := String(p, "prefix"), String(p, "postfix")
+	switch {
+	case unit == "" && prefix != "" && postfix == "":
+		unit = "prefix:" + prefix
+	case unit == "" && postfix != "" && prefix == "":
+		unit = "suffix:" + postfix
+	case prefix != "" || postfix != "":
+		notes = append(notes, "prefix/postfix could not be combined with the unit and were dropped")
+	}
+	if unit != "" {
+		defaults["unit"] = unit
+	}
+	if d, ok := number(p["decimals"]); ok {
+		defaults["decimals"] = d
+	}
+	if text := String(p, "nullText"); text != "" {
+		defaults["noValue"] = text
+	}
+
+	colors, _ := p["colors"].([]interface{})
+	steps := []interface{}{map[string]interface{}{"color": colorAt(colors, 0, "green"), "value": nil}}
+	for i, t := range strings.Split(String(p, "thresholds"), ",") {
+		if v, err := strconv.ParseFloat(strings.TrimSpace(t), 64); err == nil {
+			steps = append(steps, map[string]interface{}{"color": colorAt(colors, i+1, "red"), "value": v})
+		}
+	}
+	defaults["thresholds"] = map[string]interface{}{"mode": "absolute", "steps": steps}
+
+	var mappings []interface{}
+	if vm, ok := p["valueMaps"].([]interface{}); ok {
+		for _, m := range vm {
+			if mm, ok := m.(map[string]interface{}); ok && String(mm, "op") == "=" {
+				mappings = append(mappings, map[string]interface{}{
+					"type":    "value",
+					"options": map[string]interface{}{String(mm, "value"): map[string]interface{}{"text": String(mm, "text")}},
+				})
+			}
+		}
+	}
+	if rm, ok := p["rangeMaps"].([]interface{}); ok {
+		for _, m := range rm {
+			mm, ok := m.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			from, _ := strconv.ParseFloat(String(mm, "from"), 64)
+			to, _ := strconv.ParseFloat(String(mm, "to"), 64)
+			mappings = append(mappings, map[string]interface{}{
+				"type": "range",
+				"options": map[string]interface{}{
+					"from": from, "to": to, "result": map[string]interface{}{"text": String(mm, "text")},
+				},
+			})
+		}
+	}
+	if mappings != nil {
+		defaults["mappings"] = mappings
+	}
+
+	calc := "mean"
+	if c, ok := singlestatCalcs[String(p, "valueName")]; ok {
+		calc = c
+	} else if v := String(p, "valueName"); v != "" && v != "avg" {
+		notes = append(notes, fmt.Sprintf("valueName %q has no equivalent; using mean", v))
+	}
+	options := map[string]interface{}{
+		"reduceOptions": map[string]interface{}{"calcs": []interface{}{calc}, "fields": "", "values": false},
+		"orientation":   "auto",
+	}
+	if col := String(p, "tableColumn"); col != "" {
+		options["reduceOptions"].(map[string]interface{})["fields"] = "/^" + col + "$/"
+	}
+
+	gauge, _ := p["gauge"].(map[string]interface{})
+	if gauge != nil && gauge["show"] == true {
+		if v, ok := number(gauge["minValue"]); ok {
+			defaults["min"] = v
+		}
+		if v, ok := number(gauge["maxValue"]); ok {
+			defaults["max"] = v
+		}
+		options["showThresholdMarkers"] = gauge["thresholdMarkers"] != false
+		options["showThresholdLabels"] = gauge["thresholdLabels"] == true
+		p["type"] = "gauge"
+	} else {
+		colorMode := "none"
+		switch {
+		case p["colorBackground"] == true:
+			colorMode = "background"
+		case p["colorValue"] == true:
+			colorMode = "value"
+		}
+		graphMode := "none"
+		if sl, ok := p["sparkline"].(map[string]interface{}); ok && sl["show"] == true {
+			graphMode = "area"
+		}
+		options["colorMode"] = colorMode
+		options["graphMode"] = graphMode
+		options["justifyMode"] = "auto"
+		options["textMode"] = "auto"
+		p["type"] = "stat"
+	}
+
+	for _, k := range []string{"format", "decimals", "prefix", "postfix", "prefixFontSize", "postfixFontSize",
+		"valueFontSize", "nullText", "nullPointMode", "thresholds", "colors", "colorBackground", "colorValue",
+		"colorPrefix", "colorPostfix", "valueName", "valueMaps", "rangeMaps", "mappingType", "mappingTypes",
+		"sparkline", "gauge", "tableColumn", "combine"} {
+		delete(p, k)
+	}
+	p["options"] = options
+	return notes
+}
+
+func colorAt(colors []interface{}, i int, fallback string) string {
+	if i < len(colors) {
+		if s, ok := colors[i].(string); ok && s != "" {
+			return s
+		}
+	}
+	return fallback
+}
+
+// ============== table-old -> table ==============
+
+var tableTransforms = map[string]string{
+	"timeseries_to_rows":      "seriesToRows",
+	"timeseries_to_columns":   "seriesToColumns",
+	"timeseries_aggregations": "reduce",
+	"table":                   "merge",
+}
+
+func migrateTableOld(p map[string]interface{}) []string {
+	var notes []string
+	defaults := fieldDefaults(p)
+	custom := ensure(defaults, "custom")
+	custom["align"] = "auto"
+	overrides := fieldOverrides(p)
+
+	if styles, ok := p["styles"].([]interface{}); ok {
+		for _, s := range styles {
+			sm, ok := s.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			props, note := tableStyleProperties(sm)
+			if note != "" {
+				notes = append(notes, note)
+			}
+			pattern := String(sm, "pattern")
+			if pattern == "/.*/" {
+				for _, pr := range props {
+					pm := pr.(map[string]interface{})
+					setPath(defaults, String(pm, "id"), pm["value"])
+				}
+				continue
+			}
+			if len(props) == 0 || pattern == "" {
+				continue
+			}
+			matcher := "byName"
+			if strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") && len(pattern) > 1 {
+				matcher = "byRegexp"
+			}
+			overrides = append(overrides, override(matcher, pattern, props...))
+		}
+	}
+	setOverrides(p, overrides)
+
+	if t := String(p, "transform"); t != "" {
+		id, ok := tableTransforms[t]
+		if !ok {
+			notes = append(notes, fmt.Sprintf("transform %q has no equivalent and was dropped", t))
+		} else {
+			tr := map[string]interface{}{"id": id, "options": map[string]interface{}{}}
+			if id == "reduce" {
+				var reducers []interface{}
+				cols, _ := p["columns"].([]interface{})
+				for _, c := range cols {
+					if cm, ok := c.(map[string]interface{}); ok {
+						if calc, ok := singlestatCalcs[String(cm, "value")]; ok {
+							reducers = append(reducers, calc)
+						}
+					}
+				}
+				tr["options"] = map[string]interface{}{"reducers": reducers}
+			}
+			existing, _ := p["transformations"].([]interface{})
+			p["transformations"] = append([]interface{}{tr}, existing...)
+		}
+	}
+	if _, ok := p["sort"].(map[string]interface{}); ok {
+		notes = append(notes, "column sort was index based and was dropped; set sortBy in the table options")
+	}
+
+	options := map[string]interface{}{"showHeader": p["showHeader"] != false}
+	for _, k := range []string{"styles", "transform", "columns", "sort", "pageSize", "fontSize", "scroll", "showHeader"} {
+		delete(p, k)
+	}
+	p["options"] = options
+	p["type"] = "table"
+	return notes
+}
+
+func tableStyleProperties(s map[string]interface{}) ([]interface{}, string) {
+	var props []interface{}
+	var note string
+	switch String(s, "type") {
+	case "hidden":
+		props = append(props, property("custom.hidden", true))
+	case "date":
+		unit := "dateTimeAsIso"
+		if f := String(s, "dateFormat"); f != "" && f != "YYYY-MM-DD HH:mm:ss" {
+			unit = "time:" + f
+		}
+		props = append(props, property("unit", unit))
+	case "number":
+		if u := String(s, "unit"); u != "" && u != "short" {
+			props = append(props, property("unit", u))
+		}
+		if d, ok := number(s["decimals"]); ok {
+			props = append(props, property("decimals", d))
+		}
+	}
+	if alias := String(s, "alias"); alias != "" {
+		props = append(props, property("displayName", alias))
+	}
+	if a := String(s, "align"); a != "" && a != "auto" {
+		props = append(props, property("custom.align", a))
+	}
+	if s["link"] == true {
+		props = append(props, property("links", []interface{}{map[string]interface{}{
+			"title":       String(s, "linkTooltip"),
+			"url":         String(s, "linkUrl"),
+			"targetBlank": s["linkTargetBlank"] == true,
+		}}))
+	}
+	if mode := String(s, "colorMode"); mode != "" {
+		colors, _ := s["colors"].([]interface{})
+		steps := []interface{}{map[string]interface{}{"color": colorAt(colors, 0, "green"), "value": nil}}
+		th, _ := s["thresholds"].([]interface{})
+		for i, t := range th {
+			if v, ok := number(t); ok {
+				steps = append(steps, map[string]interface{}{"color": colorAt(colors, i+1, "red"), "value": v})
+			}
+		}
+		props = append(props, property("thresholds", map[string]interface{}{"mode": "absolute", "steps": steps}))
+		switch mode {
+		case "cell":
+			props = append(props, property("custom.cellOptions", map[string]interface{}{"type": "color-background"}))
+		case "value":
+			props = append(props, property("custom.cellOptions", map[string]interface{}{"type": "color-text"}))
+		case "row":
+			props = append(props, property("custom.cellOptions", map[string]interface{}{"type": "color-background"}))
+			note = fmt.Sprintf("style %q colored whole rows; converted to cell coloring", String(s, "pattern"))
+		}
+	}
+	return props, note
+}
+
+// ============== legacy option formats ==============
+
+// migrateFieldOptions moves pre-7.0 options.fieldOptions into fieldConfig and
+// wraps bare threshold arrays in the {mode, steps} form.
+func migrateFieldOptions(p map[string]interface{}) bool {
+	changed := false
+	options, _ := p["options"].(map[string]interface{})
+	if fo, ok := options["fieldOptions"].(map[string]interface{}); ok {
+		defaults := fieldDefaults(p)
+		if legacy, ok := fo["defaults"].(map[string]interface{}); ok {
+			for k, v := range legacy {
+				if k == "title" {
+					k = "displayName"
+				}
+				if _, exists := defaults[k]; !exists {
+					defaults[k] = v
+				}
+			}
+		}
+		if ov, ok := fo["overrides"].([]interface{}); ok && len(ov) > 0 {
+			setOverrides(p, append(fieldOverrides(p), ov...))
+		}
+		reduce := map[string]interface{}{"calcs": fo["calcs"], "fields": fo["fields"], "values": fo["values"] == true}
+		if fo["calcs"] == nil {
+			reduce["calcs"] = []interface{}{"mean"}
+		}
+		if reduce["fields"] == nil {
+			reduce["fields"] = ""
+		}
+		if limit, ok := fo["limit"]; ok {
+			reduce["limit"] = limit
+		}
+		options["reduceOptions"] = reduce
+		delete(options, "fieldOptions")
+		changed = true
+	}
+
+	if fc, ok := p["fieldConfig"].(map[string]interface{}); ok {
+		if defaults, ok := fc["defaults"].(map[string]interface{}); ok {
+			if steps, ok := defaults["thresholds"].([]interface{}); ok {
+				for _, s := range steps {
+					// Legacy steps used -Infinity for the base value
+					if sm, ok := s.(map[string]interface{}); ok {
+						if v, isNum := number(sm["value"]); !isNum || math.IsInf(v, 0) {
+							sm["value"] = nil
+						}
+					}
+				}
+				defaults["thresholds"] = map[string]interface{}{"mode": "absolute", "steps": steps}
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// migrateLegend converts options.legend from the displayMode "hidden" form
+// to the showLegend flag introduced in schema 37.
+func migrateLegend(p map[string]interface{}) bool {
+	options, _ := p["options"].(map[string]interface{})
+	legend, ok := options["legend"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	if _, ok := legend["showLegend"]; ok {
+		return false
+	}
+	if String(legend, "displayMode") == "hidden" {
+		legend["showLegend"] = false
+		legend["displayMode"] = "list"
+	} else {
+		legend["showLegend"] = true
+	}
+	return true
+}
+
+// migrateDatasourceRefs replaces datasource names on the panel and its
+// targets with {uid, type} references.
+func migrateDatasourceRefs(p map[string]interface{}, resolve DatasourceResolver) bool {
+	changed := false
+	if ref, ok := datasourceRef(p["datasource"], resolve); ok {
+		p["datasource"] = ref
+		changed = true
+	}
+	targets, _ := p["targets"].([]interface{})
+	for _, t := range targets {
+		if tm, ok := t.(map[string]interface{}); ok {
+			if ref, ok := datasourceRef(tm["datasource"], resolve); ok {
+				tm["datasource"] = ref
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+func datasourceRef(v interface{}, resolve DatasourceResolver) (map[string]interface{}, bool) {
+	name, ok := v.(string)
+	if !ok || name == "" || strings.HasPrefix(name, "$") {
+		return nil, false
+	}
+	uid, typ, ok := resolve(name)
+	if !ok {
+		return nil, false
+	}
+	return map[string]interface{}{"uid": uid, "type": typ}, true
+}
+
+// ============== helpers ==============
+
+func ensure(m map[string]interface{}, key string) map[string]interface{} {
+	if v, ok := m[key].(map[string]interface{}); ok {
+		return v
+	}
+	v := map[string]interface{}{}
+	m[key] = v
+	return v
+}
+
+func fieldDefaults(p map[string]interface{}) map[string]interface{} {
+	return ensure(ensure(p, "fieldConfig"), "defaults")
+}
+
+func fieldOverrides(p map[string]interface{}) []interface{} {
+	ov, _ := ensure(p, "fieldConfig")["overrides"].([]interface{})
+	return ov
+}
+
+func setOverrides(p map[string]interface{}, overrides []interface{}) {
+	if overrides == nil {
+		overrides = []interface{}{}
+	}
+	ensure(p, "fieldConfig")["overrides"] = overrides
+}
+
+func override(matcher, options string, props ...interface{}) map[string]interface{} {
+	if matcher == "byRegexp" {
+		options = strings.TrimSuffix(strings.TrimPrefix(options, "/"), "/")
+	}
+	return map[string]interface{}{
+		"matcher":    map[string]interface{}{"id": matcher, "options": options},
+		"properties": props,
+	}
+}
+
+func property(id string, value interface{}) interface{} {
+	return map[string]interface{}{"id": id, "value": value}
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// setPath assigns a dotted field config path such as custom.align
+func setPath(m map[string]interface{}, path string, value interface{}) {
+	parts := strings.Split(path, ".")
+	for _, p := range parts[:len(parts)-1] {
+		m = ensure(m, p)
+	}
+	m[parts[len(parts)-1]] = value
+}
+
+// number converts JSON numbers and numeric strings to float64
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
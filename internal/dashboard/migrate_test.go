@@ -0,0 +1,279 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decodeJSON parses a model written as JSON, so before and after states
+// compare with the number types Grafana's JSON would have
+func decodeJSON(t *testing.T, s string) map[string]interface{} {
+	t.Helper()
+	var m map[string]interface{}
+	if err := json.Unmarshal([]byte(s), &m); err != nil {
+		t.Fatalf("bad test JSON: %v\n%s", err, s)
+	}
+	return m
+}
+
+// roundTrip normalizes values set by Go code, such as ints, to their JSON
+// form
+func roundTrip(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("encoding the migrated model: %v", err)
+	}
+	return decodeJSON(t, string(data))
+}
+
+func testResolver(name string) (string, string, bool) {
+	if name == "Prometheus" {
+		return "prom", "prometheus", true
+	}
+	return "", "", false
+}
+
+func TestUpgradePanels(t *testing.T) {
+	tests := []struct {
+		name      string
+		before    string
+		after     string
+		wantNotes []string
+	}{
+		{
+			name: "graph to timeseries",
+			before: `{"id": 1, "type": "graph", "title": "Requests",
+				"fill": 1, "linewidth": 2, "points": false, "stack": true, "nullPointMode": "connected",
+				"yaxes": [{"format": "reqps", "min": 0, "logBase": 1, "show": true}, {"format": "short"}],
+				"legend": {"show": true, "alignAsTable": true, "rightSide": true, "avg": true, "max": true},
+				"tooltip": {"shared": true, "sort": 2},
+				"aliasColors": {"errors": "red"},
+				"seriesOverrides": [{"alias": "/5xx/", "yaxis": 2, "zindex": 3}],
+				"targets": [{"refId": "A", "expr": "rate(http_requests_total[5m])"}]}`,
+			after: `{"id": 1, "type": "timeseries", "title": "Requests",
+				"fieldConfig": {
+					"defaults": {"unit": "reqps", "min": 0, "custom": {
+						"drawStyle": "line", "fillOpacity": 10, "lineWidth": 2, "showPoints": "never",
+						"stacking": {"mode": "normal", "group": "A"}, "spanNulls": true}},
+					"overrides": [
+						{"matcher": {"id": "byName", "options": "errors"}, "properties": [{"id": "color", "value": {"mode": "fixed", "fixedColor": "red"}}]},
+						{"matcher": {"id": "byRegexp", "options": "5xx"}, "properties": [{"id": "custom.axisPlacement", "value": "right"}]}
+					]},
+				"options": {
+					"legend": {"showLegend": true, "displayMode": "table", "placement": "right", "calcs": ["max", "mean"]},
+					"tooltip": {"mode": "multi", "sort": "desc"}},
+				"targets": [{"refId": "A", "expr": "rate(http_requests_total[5m])"}]}`,
+			wantNotes: []string{`series override "/5xx/": unsupported properties zindex dropped`},
+		},
+		{
+			name: "graph bars with thresholds",
+			before: `{"id": 1, "type": "graph", "bars": true, "lines": false,
+				"thresholds": [{"value": 80, "colorMode": "warning", "op": "gt", "fill": true}]}`,
+			after: `{"id": 1, "type": "timeseries",
+				"fieldConfig": {
+					"defaults": {
+						"custom": {"drawStyle": "bars", "fillOpacity": 100, "showPoints": "never", "thresholdsStyle": {"mode": "line+area"}},
+						"thresholds": {"mode": "absolute", "steps": [{"color": "green", "value": null}, {"color": "orange", "value": 80}]}},
+					"overrides": []},
+				"options": {
+					"legend": {"showLegend": true, "displayMode": "list", "placement": "bottom", "calcs": []},
+					"tooltip": {"mode": "single", "sort": "none"}}}`,
+		},
+		{
+			name: "singlestat to stat",
+			before: `{"id": 2, "type": "singlestat", "title": "Uptime",
+				"format": "percent", "decimals": 2, "thresholds": "90,99", "colors": ["red", "orange", "green"],
+				"colorBackground": true, "valueName": "current", "sparkline": {"show": true},
+				"valueMaps": [{"op": "=", "value": "null", "text": "N/A"}]}`,
+			after: `{"id": 2, "type": "stat", "title": "Uptime",
+				"fieldConfig": {"defaults": {
+					"unit": "percent", "decimals": 2,
+					"thresholds": {"mode": "absolute", "steps": [{"color": "red", "value": null}, {"color": "orange", "value": 90}, {"color": "green", "value": 99}]},
+					"mappings": [{"type": "value", "options": {"null": {"text": "N/A"}}}]}},
+				"options": {
+					"reduceOptions": {"calcs": ["lastNotNull"], "fields": "", "values": false},
+					"orientation": "auto", "colorMode": "background", "graphMode": "area", "justifyMode": "auto", "textMode": "auto"}}`,
+		},
+		{
+			name: "singlestat gauge to gauge",
+			before: `{"id": 3, "type": "singlestat", "title": "CPU", "format": "none", "prefix": "~", "thresholds": "80",
+				"gauge": {"show": true, "minValue": 0, "maxValue": 100, "thresholdMarkers": true}}`,
+			after: `{"id": 3, "type": "gauge", "title": "CPU",
+				"fieldConfig": {"defaults": {
+					"unit": "prefix:~", "min": 0, "max": 100,
+					"thresholds": {"mode": "absolute", "steps": [{"color": "green", "value": null}, {"color": "red", "value": 80}]}}},
+				"options": {
+					"reduceOptions": {"calcs": ["mean"], "fields": "", "values": false},
+					"orientation": "auto", "showThresholdMarkers": true, "showThresholdLabels": false}}`,
+		},
+		{
+			name: "table-old to table",
+			before: `{"id": 4, "type": "table-old", "title": "Hosts",
+				"transform": "timeseries_aggregations", "columns": [{"text": "Avg", "value": "avg"}, {"text": "Max", "value": "max"}],
+				"styles": [
+					{"pattern": "Time", "type": "date"},
+					{"pattern": "/.*/", "type": "number", "unit": "bytes", "decimals": 1},
+					{"pattern": "host", "type": "string", "alias": "Host", "colorMode": "row", "colors": ["green", "red"], "thresholds": [5]}
+				],
+				"sort": {"col": 0, "desc": true}, "showHeader": true}`,
+			after: `{"id": 4, "type": "table", "title": "Hosts",
+				"fieldConfig": {
+					"defaults": {"custom": {"align": "auto"}, "unit": "bytes", "decimals": 1},
+					"overrides": [
+						{"matcher": {"id": "byName", "options": "Time"}, "properties": [{"id": "unit", "value": "dateTimeAsIso"}]},
+						{"matcher": {"id": "byName", "options": "host"}, "properties": [
+							{"id": "displayName", "value": "Host"},
+							{"id": "thresholds", "value": {"mode": "absolute", "steps": [{"color": "green", "value": null}, {"color": "red", "value": 5}]}},
+							{"id": "custom.cellOptions", "value": {"type": "color-background"}}
+						]}
+					]},
+				"transformations": [{"id": "reduce", "options": {"reducers": ["mean", "max"]}}],
+				"options": {"showHeader": true}}`,
+			wantNotes: []string{
+				`style "host" colored whole rows; converted to cell coloring`,
+				"column sort was index based and was dropped; set sortBy in the table options",
+			},
+		},
+		{
+			name: "legacy field options and bare thresholds",
+			before: `{"id": 5, "type": "gauge", "title": "Memory",
+				"options": {"fieldOptions": {"calcs": ["last"], "values": false, "defaults": {
+					"title": "Memory", "unit": "bytes",
+					"thresholds": [{"color": "green", "value": null}, {"color": "red", "value": 80}]}}}}`,
+			after: `{"id": 5, "type": "gauge", "title": "Memory",
+				"fieldConfig": {"defaults": {
+					"displayName": "Memory", "unit": "bytes",
+					"thresholds": {"mode": "absolute", "steps": [{"color": "green", "value": null}, {"color": "red", "value": 80}]}}},
+				"options": {"reduceOptions": {"calcs": ["last"], "fields": "", "values": false}}}`,
+			wantNotes: []string{"converted legacy field options, thresholds or legend format"},
+		},
+		{
+			name: "infinite base threshold",
+			before: `{"id": 5, "type": "stat",
+				"fieldConfig": {"defaults": {"thresholds": [{"color": "green", "value": "-Infinity"}, {"color": "red", "value": 80}]}}}`,
+			after: `{"id": 5, "type": "stat",
+				"fieldConfig": {"defaults": {"thresholds": {"mode": "absolute", "steps": [{"color": "green", "value": null}, {"color": "red", "value": 80}]}}}}`,
+			wantNotes: []string{"converted legacy field options, thresholds or legend format"},
+		},
+		{
+			name:      "hidden legend",
+			before:    `{"id": 6, "type": "timeseries", "options": {"legend": {"displayMode": "hidden", "placement": "bottom"}}}`,
+			after:     `{"id": 6, "type": "timeseries", "options": {"legend": {"showLegend": false, "displayMode": "list", "placement": "bottom"}}}`,
+			wantNotes: []string{"converted legacy field options, thresholds or legend format"},
+		},
+		{
+			name:      "shown legend",
+			before:    `{"id": 6, "type": "timeseries", "options": {"legend": {"displayMode": "table"}}}`,
+			after:     `{"id": 6, "type": "timeseries", "options": {"legend": {"showLegend": true, "displayMode": "table"}}}`,
+			wantNotes: []string{"converted legacy field options, thresholds or legend format"},
+		},
+		{
+			name: "datasource names",
+			before: `{"id": 7, "type": "timeseries", "datasource": "Prometheus",
+				"targets": [{"refId": "A", "datasource": "Prometheus"}, {"refId": "B", "datasource": "$ds"}, {"refId": "C", "datasource": "Unknown"}]}`,
+			after: `{"id": 7, "type": "timeseries", "datasource": {"uid": "prom", "type": "prometheus"},
+				"targets": [{"refId": "A", "datasource": {"uid": "prom", "type": "prometheus"}}, {"refId": "B", "datasource": "$ds"}, {"refId": "C", "datasource": "Unknown"}]}`,
+			wantNotes: []string{"replaced datasource names with uid references"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			panel := decodeJSON(t, tt.before)
+			from := String(panel, "type")
+			dash := map[string]interface{}{"schemaVersion": 27.0, "panels": []interface{}{panel}}
+
+			res := Upgrade(dash, testResolver)
+
+			if got, want := roundTrip(t, panel), decodeJSON(t, tt.after); !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				wantJSON, _ := json.MarshalIndent(want, "", "  ")
+				t.Fatalf("migrated panel:\n%s\nwant:\n%s", gotJSON, wantJSON)
+			}
+			if len(res.Panels) != 1 {
+				t.Fatalf("result lists %d panels, want 1: %+v", len(res.Panels), res.Panels)
+			}
+			m := res.Panels[0]
+			if m.From != from || m.To != String(panel, "type") {
+				t.Errorf("migration %s -> %s, want %s -> %s", m.From, m.To, from, String(panel, "type"))
+			}
+			if !reflect.DeepEqual(m.Notes, tt.wantNotes) {
+				t.Errorf("notes = %q, want %q", m.Notes, tt.wantNotes)
+			}
+		})
+	}
+}
+
+func TestUpgradeDashboard(t *testing.T) {
+	tests := []struct {
+		name        string
+		before      string
+		after       string
+		wantFrom    int
+		wantTo      int
+		wantChanged bool
+	}{
+		{
+			name:        "schema version raised",
+			before:      `{"schemaVersion": 16, "panels": []}`,
+			after:       `{"schemaVersion": 39, "panels": []}`,
+			wantFrom:    16,
+			wantTo:      TargetSchemaVersion,
+			wantChanged: true,
+		},
+		{
+			name:        "template datasource names",
+			before:      `{"schemaVersion": 39, "templating": {"list": [{"name": "job", "datasource": "Prometheus"}, {"name": "ds", "type": "datasource"}]}}`,
+			after:       `{"schemaVersion": 39, "templating": {"list": [{"name": "job", "datasource": {"uid": "prom", "type": "prometheus"}}, {"name": "ds", "type": "datasource"}]}}`,
+			wantFrom:    39,
+			wantTo:      39,
+			wantChanged: false,
+		},
+		{
+			name:        "legacy rows layout keeps its version",
+			before:      `{"schemaVersion": 14, "rows": [{"panels": []}]}`,
+			after:       `{"schemaVersion": 14, "rows": [{"panels": []}]}`,
+			wantFrom:    14,
+			wantTo:      14,
+			wantChanged: false,
+		},
+		{
+			name: "current model unchanged",
+			before: `{"schemaVersion": 39, "title": "Current", "panels": [
+				{"id": 1, "type": "row", "title": "Overview", "panels": []},
+				{"id": 2, "type": "timeseries", "title": "Latency",
+					"datasource": {"uid": "prom", "type": "prometheus"},
+					"fieldConfig": {"defaults": {"unit": "s", "thresholds": {"mode": "absolute", "steps": [{"color": "green", "value": null}]}}, "overrides": []},
+					"options": {"legend": {"showLegend": true, "displayMode": "list", "placement": "bottom", "calcs": []}},
+					"targets": [{"refId": "A", "datasource": {"uid": "prom", "type": "prometheus"}, "expr": "up"}]},
+				{"id": 3, "type": "stat", "title": "Up", "datasource": "$ds",
+					"options": {"reduceOptions": {"calcs": ["lastNotNull"], "fields": "", "values": false}}}
+			]}`,
+			wantFrom:    39,
+			wantTo:      39,
+			wantChanged: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dash := decodeJSON(t, tt.before)
+			res := Upgrade(dash, testResolver)
+
+			after := tt.after
+			if after == "" {
+				after = tt.before
+			}
+			if got, want := roundTrip(t, dash), decodeJSON(t, after); !reflect.DeepEqual(got, want) {
+				gotJSON, _ := json.MarshalIndent(got, "", "  ")
+				t.Fatalf("migrated dashboard:\n%s\nwant:\n%s", gotJSON, after)
+			}
+			if res.FromSchemaVersion != tt.wantFrom || res.ToSchemaVersion != tt.wantTo {
+				t.Errorf("schema %d -> %d, want %d -> %d", res.FromSchemaVersion, res.ToSchemaVersion, tt.wantFrom, tt.wantTo)
+			}
+			if res.Changed() != tt.wantChanged {
+				t.Errorf("Changed() = %v, want %v (%+v)", res.Changed(), tt.wantChanged, res)
+			}
+		})
+	}
+}
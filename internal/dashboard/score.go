@@ -0,0 +1,562 @@
+package dashboard
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/promql"
+)
+
+// Score categories and their weight in the overall score
+var scoreWeights = map[string]float64{
+	"panel_count":  15,
+	"descriptions": 20,
+	"units":        25,
+	"legends":      20,
+	"thresholds":   20,
+}
+
+const (
+	// comfortablePanels is the panel count above which a dashboard starts
+	// losing points; at overloadedPanels it scores zero
+	comfortablePanels = 20
+	overloadedPanels  = 60
+	// maxLegendTargets is the number of queries a panel can have before its
+	// legend is considered crowded
+	maxLegendTargets = 6
+)
+
+// unitPanels are the panel types whose values are meaningless without a unit
+var unitPanels = map[string]bool{
+	"timeseries": true, "stat": true, "gauge": true, "bargauge": true,
+	"barchart": true, "state-timeline": true, "histogram": true, "trend": true,
+}
+
+// legendPanels are the panel types with a series legend
+var legendPanels = map[string]bool{
+	"timeseries": true, "barchart": true, "piechart": true, "trend": true, "histogram": true,
+}
+
+// undescribedPanels never need a description
+var undescribedPanels = map[string]bool{"row": true, "text": true, "news": true, "dashlist": true}
+
+// legacyPanels are pre-8.0 panel types the scorer cannot inspect reliably
+var legacyPanels = map[string]bool{"graph": true, "singlestat": true, "table-old": true}
+
+// severityRank orders the standard threshold colors from healthy to critical
+var severityRank = map[string]int{"green": 0, "blue": 0, "yellow": 1, "orange": 2, "red": 3, "purple": 3}
+
+// ScoreCategory is the score of one readability dimension
+type ScoreCategory struct {
+	Score   int    `json:"score"`
+	Summary string `json:"summary"`
+}
+
+// Suggestion is one concrete improvement. When Path and Value are set the
+// fix is mechanical: assign Value at the dotted Path inside the panel JSON
+// (numeric path elements index arrays).
+type Suggestion struct {
+	Category   string      `json:"category"`
+	PanelID    int64       `json:"panel_id,omitempty"`
+	PanelTitle string      `json:"panel_title,omitempty"`
+	Message    string      `json:"message"`
+	Path       string      `json:"path,omitempty"`
+	Value      interface{} `json:"value,omitempty"`
+}
+
+// ScoreResult is the readability report for a dashboard
+type ScoreResult struct {
+	Score       int                      `json:"score"`
+	Grade       string                   `json:"grade"`
+	Panels      int                      `json:"panels"`
+	Categories  map[string]ScoreCategory `json:"categories"`
+	Suggestions []Suggestion             `json:"suggestions"`
+}
+
+// Score rates a dashboard on panel count, description coverage, unit
+// configuration, legend overload, and threshold color consistency. Each
+// category scores 0-100; the overall score is their weighted average.
+func Score(dash map[string]interface{}) ScoreResult {
+	var panels []map[string]interface{}
+	var legacy []string
+	for _, p := range Panels(dash) {
+		if IsRow(p) {
+			continue
+		}
+		panels = append(panels, p)
+		if legacyPanels[String(p, "type")] {
+			legacy = append(legacy, panelLabel(p))
+		}
+	}
+
+	s := &scorer{res: ScoreResult{Panels: len(panels), Categories: map[string]ScoreCategory{}}}
+	s.panelCount(panels)
+	s.descriptions(panels)
+	s.units(panels)
+	s.legends(panels)
+	s.thresholds(panels)
+	if len(legacy) > 0 {
+		s.res.Suggestions = append(s.res.Suggestions, Suggestion{
+			Category: "units",
+			Message:  fmt.Sprintf("%d legacy panel(s) (%s) were not inspected; upgrade them with grafana_upgrade_dashboard_schema and score again", len(legacy), strings.Join(legacy, ", ")),
+		})
+	}
+
+	var total, weights float64
+	for name, c := range s.res.Categories {
+		total += float64(c.Score) * scoreWeights[name]
+		weights += scoreWeights[name]
+	}
+	if weights > 0 {
+		s.res.Score = int(math.Round(total / weights))
+	}
+	s.res.Grade = grade(s.res.Score)
+	if s.res.Suggestions == nil {
+		s.res.Suggestions = []Suggestion{}
+	}
+	return s.res
+}
+
+type scorer struct {
+	res ScoreResult
+}
+
+func (s *scorer) category(name string, ok, total int, summary string) {
+	score := 100
+	if total > 0 {
+		score = int(math.Round(100 * float64(ok) / float64(total)))
+	}
+	s.res.Categories[name] = ScoreCategory{Score: score, Summary: summary}
+}
+
+func (s *scorer) suggest(category string, p map[string]interface{}, path string, value interface{}, format string, args ...interface{}) {
+	sg := Suggestion{Category: category, Message: fmt.Sprintf(format, args...), Path: path, Value: value}
+	if p != nil {
+		sg.PanelID = PanelID(p)
+		sg.PanelTitle = String(p, "title")
+	}
+	s.res.Suggestions = append(s.res.Suggestions, sg)
+}
+
+func (s *scorer) panelCount(panels []map[string]interface{}) {
+	n := len(panels)
+	score := 100
+	if n > comfortablePanels {
+		over := float64(n-comfortablePanels) / float64(overloadedPanels-comfortablePanels)
+		score = int(math.Round(100 * math.Max(0, 1-over)))
+	}
+	s.res.Categories["panel_count"] = ScoreCategory{Score: score, Summary: fmt.Sprintf("%d panels", n)}
+	if n > comfortablePanels {
+		s.suggest("panel_count", nil, "", nil,
+			"%d panels is more than a reader can scan (aim for %d or fewer); move detail panels into collapsed rows or a linked drill-down dashboard", n, comfortablePanels)
+	}
+}
+
+func (s *scorer) descriptions(panels []map[string]interface{}) {
+	var ok, total int
+	for _, p := range panels {
+		if undescribedPanels[String(p, "type")] {
+			continue
+		}
+		total++
+		if strings.TrimSpace(String(p, "description")) != "" {
+			ok++
+			continue
+		}
+		s.suggest("descriptions", p, "description", nil,
+			"add a description saying what the panel shows and what a bad value looks like")
+	}
+	s.category("descriptions", ok, total, fmt.Sprintf("%d of %d panels described", ok, total))
+}
+
+func (s *scorer) units(panels []map[string]interface{}) {
+	var ok, total int
+	for _, p := range panels {
+		if !unitPanels[String(p, "type")] {
+			continue
+		}
+		total++
+		defaults, _ := mapAt(p, "fieldConfig", "defaults")
+		if String(defaults, "unit") != "" {
+			ok++
+			continue
+		}
+		if unit := inferUnit(p); unit != "" {
+			s.suggest("units", p, "fieldConfig.defaults.unit", unit, "no unit set; the queries suggest %q", unit)
+		} else {
+			s.suggest("units", p, "fieldConfig.defaults.unit", nil, "no unit set; values render as bare numbers")
+		}
+	}
+	s.category("units", ok, total, fmt.Sprintf("%d of %d panels have a unit", ok, total))
+}
+
+func (s *scorer) legends(panels []map[string]interface{}) {
+	var ok, total int
+	for _, p := range panels {
+		if !legendPanels[String(p, "type")] {
+			continue
+		}
+		legend, _ := mapAt(p, "options", "legend")
+		if show, set := legend["showLegend"].(bool); (set && !show) || String(legend, "displayMode") == "hidden" {
+			continue
+		}
+		total++
+		targets := visibleTargets(p)
+		crowded := false
+		if len(targets) > maxLegendTargets && String(legend, "displayMode") != "table" {
+			crowded = true
+			s.suggest("legends", p, "options.legend.displayMode", "table",
+				"%d queries share one list legend; a table legend (optionally placed right) stays readable", len(targets))
+		}
+		for _, t := range targets {
+			if strings.TrimSpace(String(t.target, "legendFormat")) != "" {
+				continue
+			}
+			expr := String(t.target, "expr")
+			if expr == "" {
+				continue
+			}
+			grouping, bounded := seriesLabels(expr)
+			if bounded && len(grouping) == 0 {
+				continue
+			}
+			crowded = true
+			path := fmt.Sprintf("targets.%d.legendFormat", t.index)
+			if len(grouping) > 0 {
+				s.suggest("legends", p, path, legendFormat(grouping),
+					"query %s has no legend format, so every series shows its full label set", String(t.target, "refId"))
+			} else {
+				s.suggest("legends", p, path, nil,
+					"query %s has no legend format and is not aggregated, so the legend lists every raw series with all labels", String(t.target, "refId"))
+			}
+		}
+		if !crowded {
+			ok++
+		}
+	}
+	s.category("legends", ok, total, fmt.Sprintf("%d of %d legends readable", ok, total))
+}
+
+func (s *scorer) thresholds(panels []map[string]interface{}) {
+	type thresholded struct {
+		panel map[string]interface{}
+		steps []interface{}
+		key   string
+	}
+	var found []thresholded
+	groups := map[string][]int{}
+	bad := map[int]bool{}
+	for _, p := range panels {
+		defaults, _ := mapAt(p, "fieldConfig", "defaults")
+		th, _ := defaults["thresholds"].(map[string]interface{})
+		steps, _ := th["steps"].([]interface{})
+		if len(steps) < 2 {
+			continue
+		}
+		i := len(found)
+		found = append(found, thresholded{panel: p, steps: steps, key: stepsKey(steps)})
+		if !monotonic(steps) {
+			bad[i] = true
+			s.suggest("thresholds", p, "", nil,
+				"threshold colors go back and forth in severity (%s); order them so colors only get worse, or only get better, as values rise", stepsKey(steps))
+		}
+		if metric := firstMetric(p); metric != "" {
+			group := String(defaults, "unit") + "|" + metric
+			groups[group] = append(groups[group], i)
+		}
+	}
+
+	for _, group := range sortedGroupKeys(groups) {
+		members := groups[group]
+		counts := map[string]int{}
+		for _, i := range members {
+			counts[found[i].key]++
+		}
+		if len(counts) < 2 {
+			continue
+		}
+		// The most common step set wins; ties go to the first panel's
+		majority := found[members[0]].key
+		for _, i := range members {
+			if counts[found[i].key] > counts[majority] {
+				majority = found[i].key
+			}
+		}
+		var canonical []interface{}
+		for _, i := range members {
+			if found[i].key == majority {
+				canonical = found[i].steps
+				break
+			}
+		}
+		metric := group[strings.IndexByte(group, '|')+1:]
+		for _, i := range members {
+			if found[i].key == majority {
+				continue
+			}
+			bad[i] = true
+			s.suggest("thresholds", found[i].panel, "fieldConfig.defaults.thresholds.steps", canonical,
+				"thresholds (%s) differ from the other %s panels (%s); one metric should turn the same color at the same value everywhere", found[i].key, metric, majority)
+		}
+	}
+	s.category("thresholds", len(found)-len(bad), len(found), fmt.Sprintf("%d of %d thresholded panels consistent", len(found)-len(bad), len(found)))
+}
+
+type indexedTarget struct {
+	index  int
+	target map[string]interface{}
+}
+
+func visibleTargets(p map[string]interface{}) []indexedTarget {
+	var out []indexedTarget
+	targets, _ := p["targets"].([]interface{})
+	for i, t := range targets {
+		tm, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if hide, _ := tm["hide"].(bool); hide {
+			continue
+		}
+		out = append(out, indexedTarget{index: i, target: tm})
+	}
+	return out
+}
+
+// seriesLabels reports the labels distinguishing the series a PromQL query
+// returns. bounded is false when the outermost expression keeps every input
+// label (no aggregation), so the series count is unknown.
+func seriesLabels(expr string) (labels []string, bounded bool) {
+	e, err := promql.Parse(expr)
+	if err != nil {
+		return nil, true
+	}
+	return seriesLabelsOf(e)
+}
+
+func seriesLabelsOf(e promql.Expr) ([]string, bool) {
+	for {
+		switch n := e.(type) {
+		case *promql.Paren:
+			e = n.Expr
+			continue
+		case *promql.Binary:
+			if n.LHS.Type() == promql.ValueScalar {
+				e = n.RHS
+			} else {
+				e = n.LHS
+			}
+			continue
+		case *promql.Unary:
+			e = n.Expr
+			continue
+		case *promql.Modified:
+			e = n.Expr
+			continue
+		case *promql.Call:
+			// Most functions keep their input's labels; histogram_quantile
+			// consumes le
+			arg := vectorArg(n)
+			if arg == nil {
+				return nil, true
+			}
+			if n.Func == "histogram_quantile" {
+				inner, ok := seriesLabelsOf(arg)
+				var kept []string
+				for _, l := range inner {
+					if l != "le" {
+						kept = append(kept, l)
+					}
+				}
+				return kept, ok
+			}
+			e = arg
+			continue
+		case *promql.Aggregate:
+			if n.Without {
+				return nil, false
+			}
+			return n.Grouping, true
+		case *promql.NumberLiteral, *promql.StringLiteral:
+			return nil, true
+		}
+		return nil, false
+	}
+}
+
+// vectorArg returns the first vector or range-vector argument of a call
+func vectorArg(c *promql.Call) promql.Expr {
+	for _, a := range c.Args {
+		if t := a.Type(); t == promql.ValueVector || t == promql.ValueMatrix {
+			return a
+		}
+	}
+	return nil
+}
+
+func legendFormat(labels []string) string {
+	parts := make([]string, len(labels))
+	for i, l := range labels {
+		parts[i] = "{{" + l + "}}"
+	}
+	return strings.Join(parts, " ")
+}
+
+// unitSuffixes maps metric name suffixes to Grafana units, for instant and
+// rate() queries
+var unitSuffixes = []struct{ suffix, unit, rateUnit string }{
+	{"_seconds", "s", "s"},
+	{"_milliseconds", "ms", "ms"},
+	{"_bytes", "bytes", "Bps"},
+	{"_ratio", "percentunit", "percentunit"},
+	{"_percent", "percent", "percent"},
+	{"_celsius", "celsius", "celsius"},
+	{"_total", "short", "ops"},
+}
+
+// inferUnit guesses a unit from the metric names of a panel's PromQL
+// queries, following Prometheus naming conventions
+func inferUnit(p map[string]interface{}) string {
+	for _, t := range visibleTargets(p) {
+		e, err := promql.Parse(String(t.target, "expr"))
+		if err != nil {
+			continue
+		}
+		var metric string
+		var rated bool
+		promql.Inspect(e, func(n promql.Expr, ancestors []promql.Expr) bool {
+			vs, ok := n.(*promql.VectorSelector)
+			if !ok || metric != "" {
+				return metric == ""
+			}
+			metric = vs.Name
+			for _, a := range ancestors {
+				if c, ok := a.(*promql.Call); ok && (c.Func == "rate" || c.Func == "irate") {
+					rated = true
+				}
+			}
+			return false
+		})
+		for _, s := range []string{"_sum", "_count", "_bucket"} {
+			metric = strings.TrimSuffix(metric, s)
+		}
+		for _, u := range unitSuffixes {
+			if strings.HasSuffix(metric, u.suffix) || strings.HasSuffix(metric, u.suffix+"_total") {
+				if rated {
+					return u.rateUnit
+				}
+				return u.unit
+			}
+		}
+	}
+	return ""
+}
+
+// firstMetric returns the first metric name in a panel's PromQL queries
+func firstMetric(p map[string]interface{}) string {
+	for _, t := range visibleTargets(p) {
+		e, err := promql.Parse(String(t.target, "expr"))
+		if err != nil {
+			continue
+		}
+		var metric string
+		promql.Inspect(e, func(n promql.Expr, _ []promql.Expr) bool {
+			if vs, ok := n.(*promql.VectorSelector); ok && metric == "" {
+				metric = vs.Name
+			}
+			return metric == ""
+		})
+		if metric != "" {
+			return metric
+		}
+	}
+	return ""
+}
+
+// monotonic reports whether threshold colors only get worse or only get
+// better as the value rises. Colors outside the standard palette are ignored.
+func monotonic(steps []interface{}) bool {
+	var ranks []int
+	for _, st := range steps {
+		sm, _ := st.(map[string]interface{})
+		if r, ok := severityRank[baseColor(String(sm, "color"))]; ok {
+			ranks = append(ranks, r)
+		}
+	}
+	up, down := false, false
+	for i := 1; i < len(ranks); i++ {
+		switch {
+		case ranks[i] > ranks[i-1]:
+			up = true
+		case ranks[i] < ranks[i-1]:
+			down = true
+		}
+	}
+	return !(up && down)
+}
+
+// baseColor strips Grafana's shade prefixes, e.g. dark-red -> red
+func baseColor(c string) string {
+	for _, prefix := range []string{"super-light-", "light-", "semi-dark-", "dark-"} {
+		c = strings.TrimPrefix(c, prefix)
+	}
+	return c
+}
+
+// stepsKey renders threshold steps as "green, 80: yellow, 90: red"
+func stepsKey(steps []interface{}) string {
+	parts := make([]string, 0, len(steps))
+	for _, st := range steps {
+		sm, _ := st.(map[string]interface{})
+		color := baseColor(String(sm, "color"))
+		if v, ok := number(sm["value"]); ok {
+			parts = append(parts, fmt.Sprintf("%g: %s", v, color))
+		} else {
+			parts = append(parts, color)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func mapAt(m map[string]interface{}, keys ...string) (map[string]interface{}, bool) {
+	for _, k := range keys {
+		next, ok := m[k].(map[string]interface{})
+		if !ok {
+			return map[string]interface{}{}, false
+		}
+		m = next
+	}
+	return m, true
+}
+
+func panelLabel(p map[string]interface{}) string {
+	if t := String(p, "title"); t != "" {
+		return fmt.Sprintf("%d %q", PanelID(p), t)
+	}
+	return fmt.Sprintf("%d", PanelID(p))
+}
+
+func sortedGroupKeys(m map[string][]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	}
+	return "F"
+}
@@ -0,0 +1,245 @@
+package dashboard
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// DefaultTemplateLabels are the labels extracted when none are requested,
+// in variable order (each variable's options are filtered by the previous)
+var DefaultTemplateLabels = []string{"cluster", "environment", "env", "namespace", "job", "service", "app"}
+
+// queryFields are the target fields holding PromQL/LogQL-style selectors
+var queryFields = []string{"expr", "query"}
+
+// literalValue matches label values that are safe to treat as a single
+// literal even when used with =~
+var literalValue = regexp.MustCompile(`^[\w.\-:/]+$`)
+
+// TemplatizeOptions controls which hard-coded values are extracted
+type TemplatizeOptions struct {
+	// Labels to turn into variables, in dependency order
+	Labels []string
+	// DatasourceVariable adds a ${datasource} variable for the dominant
+	// datasource type and points matching panels and targets at it
+	DatasourceVariable bool
+}
+
+// TemplatizeResult describes the variables created by Templatize
+type TemplatizeResult struct {
+	Variables    []string            `json:"variables"`
+	Values       map[string][]string `json:"values"`
+	Replacements int                 `json:"replacements"`
+	Panels       []int64             `json:"panels"`
+	Skipped      []string            `json:"skipped,omitempty"`
+}
+
+// Templatize generalizes a dashboard in place: positive label matchers with
+// literal values in panel queries (e.g. namespace="checkout") are rewritten
+// to reference template variables (namespace=~"$namespace"), and a
+// query-backed variable is added for each label found. The variables default
+// to the values that were hard-coded so the dashboard renders as before.
+func Templatize(dash map[string]interface{}, opts TemplatizeOptions) TemplatizeResult {
+	labels := opts.Labels
+	if len(labels) == 0 {
+		labels = DefaultTemplateLabels
+	}
+	res := TemplatizeResult{Values: map[string][]string{}}
+
+	existing := map[string]bool{}
+	templating := ensure(dash, "templating")
+	vars, _ := templating["list"].([]interface{})
+	for _, v := range vars {
+		if vm, ok := v.(map[string]interface{}); ok {
+			existing[String(vm, "name")] = true
+		}
+	}
+
+	var dsVar bool
+	var dsType, dsUID string
+	if opts.DatasourceVariable && !existing["datasource"] {
+		dsType, dsUID = dominantDatasource(dash)
+		dsVar = dsType != ""
+	}
+
+	seen := map[string]map[string]bool{}
+	varDatasource := map[string]interface{}{}
+	for _, p := range Panels(dash) {
+		if IsRow(p) {
+			continue
+		}
+		touched := false
+		panelDS := p["datasource"]
+		targets, _ := p["targets"].([]interface{})
+		for _, t := range targets {
+			tm, ok := t.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			ds := tm["datasource"]
+			if ds == nil {
+				ds = panelDS
+			}
+			for _, field := range queryFields {
+				q, ok := tm[field].(string)
+				if !ok || q == "" {
+					continue
+				}
+				out, found := rewriteMatchers(q, labels)
+				if len(found) == 0 {
+					continue
+				}
+				tm[field] = out
+				touched = true
+				for label, values := range found {
+					if seen[label] == nil {
+						seen[label] = map[string]bool{}
+					}
+					for _, v := range values {
+						seen[label][v] = true
+						res.Replacements++
+					}
+					if _, ok := varDatasource[label]; !ok {
+						varDatasource[label] = ds
+					}
+				}
+			}
+			if dsVar && isRef(tm["datasource"], dsType, dsUID) {
+				tm["datasource"] = map[string]interface{}{"type": dsType, "uid": "${datasource}"}
+				touched = true
+			}
+		}
+		if dsVar && isRef(panelDS, dsType, dsUID) {
+			p["datasource"] = map[string]interface{}{"type": dsType, "uid": "${datasource}"}
+			touched = true
+		}
+		if touched {
+			res.Panels = append(res.Panels, PanelID(p))
+		}
+	}
+
+	var newVars []interface{}
+	if dsVar {
+		newVars = append(newVars, map[string]interface{}{
+			"type":    "datasource",
+			"name":    "datasource",
+			"label":   "Data source",
+			"query":   dsType,
+			"current": map[string]interface{}{"value": dsUID},
+			"hide":    0,
+			"refresh": 1,
+		})
+		res.Variables = append(res.Variables, "datasource")
+	}
+
+	var chain []string
+	for _, label := range labels {
+		values := seen[label]
+		if len(values) == 0 {
+			continue
+		}
+		sorted := make([]string, 0, len(values))
+		for v := range values {
+			sorted = append(sorted, v)
+		}
+		sort.Strings(sorted)
+		res.Values[label] = sorted
+
+		if existing[label] {
+			res.Skipped = append(res.Skipped, fmt.Sprintf("variable %q already exists; queries now reference it", label))
+			chain = append(chain, label)
+			continue
+		}
+
+		ds := varDatasource[label]
+		if dsVar && isRef(ds, dsType, dsUID) {
+			ds = map[string]interface{}{"type": dsType, "uid": "${datasource}"}
+		}
+		query := labelValuesQuery(label, chain)
+		current := map[string]interface{}{"text": sorted, "value": sorted}
+		if len(sorted) == 1 {
+			current = map[string]interface{}{"text": sorted[0], "value": sorted[0]}
+		}
+		newVars = append(newVars, map[string]interface{}{
+			"type":       "query",
+			"name":       label,
+			"label":      label,
+			"datasource": ds,
+			"query":      query,
+			"definition": query,
+			"current":    current,
+			"multi":      true,
+			"includeAll": true,
+			"refresh":    2,
+			"sort":       1,
+			"hide":       0,
+		})
+		res.Variables = append(res.Variables, label)
+		chain = append(chain, label)
+	}
+
+	if len(newVars) > 0 {
+		templating["list"] = append(newVars, vars...)
+	}
+	return res
+}
+
+// rewriteMatchers replaces literal positive matchers for the given labels
+// with variable references and returns the values that were replaced.
+func rewriteMatchers(q string, labels []string) (string, map[string][]string) {
+	found := map[string][]string{}
+	for _, label := range labels {
+		re := regexp.MustCompile(`(^|[{,\s])(` + regexp.QuoteMeta(label) + `)\s*(=~|=)\s*"((?:[^"\\]|\\.)*)"`)
+		q = re.ReplaceAllStringFunc(q, func(m string) string {
+			sub := re.FindStringSubmatch(m)
+			op, value := sub[3], sub[4]
+			if strings.Contains(value, "$") || (op == "=~" && !literalValue.MatchString(value)) {
+				return m
+			}
+			found[label] = append(found[label], value)
+			return fmt.Sprintf(`%s%s=~"$%s"`, sub[1], sub[2], label)
+		})
+	}
+	return q, found
+}
+
+func labelValuesQuery(label string, chain []string) string {
+	if len(chain) == 0 {
+		return fmt.Sprintf("label_values(%s)", label)
+	}
+	matchers := make([]string, len(chain))
+	for i, c := range chain {
+		matchers[i] = fmt.Sprintf(`%s=~"$%s"`, c, c)
+	}
+	return fmt.Sprintf("label_values({%s}, %s)", strings.Join(matchers, ", "), label)
+}
+
+// dominantDatasource returns the most used concrete datasource among panels
+func dominantDatasource(dash map[string]interface{}) (typ, uid string) {
+	counts := map[[2]string]int{}
+	for _, p := range Panels(dash) {
+		ref, ok := p["datasource"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		t, u := String(ref, "type"), String(ref, "uid")
+		if t == "" || u == "" || t == "datasource" || strings.HasPrefix(u, "$") {
+			continue
+		}
+		counts[[2]string{t, u}]++
+	}
+	best := 0
+	for k, n := range counts {
+		if n > best || (n == best && k[1] < uid) {
+			best, typ, uid = n, k[0], k[1]
+		}
+	}
+	return typ, uid
+}
+
+func isRef(ds interface{}, typ, uid string) bool {
+	m, ok := ds.(map[string]interface{})
+	return ok && String(m, "type") == typ && String(m, "uid") == uid
+}
@@ -0,0 +1,74 @@
+package dashboard
+
+import (
+	"regexp"
+	"strings"
+)
+
+// allValue is the value Grafana stores for a variable set to "All"
+const allValue = "$__all"
+
+// CurrentValues returns the current value(s) of each template variable. A
+// variable set to "All" resolves to its custom all value, or ".*" when it has none.
+func CurrentValues(dash map[string]interface{}) map[string][]string {
+	out := map[string][]string{}
+	templating, _ := dash["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, v := range list {
+		vm, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		name := String(vm, "name")
+		current, _ := vm["current"].(map[string]interface{})
+		if name == "" || current == nil {
+			continue
+		}
+		var values []string
+		switch cv := current["value"].(type) {
+		case string:
+			values = []string{cv}
+		case []interface{}:
+			for _, x := range cv {
+				if s, ok := x.(string); ok {
+					values = append(values, s)
+				}
+			}
+		}
+		for _, val := range values {
+			if val == allValue {
+				all := String(vm, "allValue")
+				if all == "" {
+					all = ".*"
+				}
+				values = []string{all}
+				break
+			}
+		}
+		if len(values) > 0 {
+			out[name] = values
+		}
+	}
+	return out
+}
+
+var variableRef = regexp.MustCompile(`\$\{(\w+)(?::\w+)?\}|\[\[(\w+)(?::\w+)?\]\]|\$(\w+)`)
+
+// Interpolate replaces $var, ${var}, ${var:format}, and [[var]] references
+// with their values. Multiple values are joined as a regex alternation,
+// matching Grafana's default for Prometheus and Loki. Built-in variables
+// ($__interval, $__range, ...) and unknown names are left for the server.
+func Interpolate(s string, values map[string][]string) string {
+	return variableRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := variableRef.FindStringSubmatch(ref)
+		name := m[1] + m[2] + m[3]
+		vals, ok := values[name]
+		if !ok || strings.HasPrefix(name, "__") {
+			return ref
+		}
+		if len(vals) == 1 {
+			return vals[0]
+		}
+		return "(" + strings.Join(vals, "|") + ")"
+	})
+}
@@ -0,0 +1,297 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Resources is the set of Grafana objects to convert to infrastructure as code
+type Resources struct {
+	Folders     []grafana.Folder
+	Dashboards  []Dashboard
+	Datasources []grafana.Datasource
+	RuleGroups  []grafana.RuleGroup
+}
+
+// Terraform renders resources as grafana-provider HCL. When dashboardFiles is
+// true, dashboard models are returned as separate JSON files referenced with
+// file(); otherwise they are embedded as heredocs. The returned map is keyed
+// by relative file path and always contains main.tf.
+func Terraform(res Resources, dashboardFiles bool) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	names := newNamer()
+	folderRefs := map[string]string{}
+
+	var b strings.Builder
+	b.WriteString("# Generated by grafana-mcp. Secure datasource fields are not exported.\n")
+
+	for _, f := range res.Folders {
+		name := names.get("folder", f.Title)
+		folderRefs[f.UID] = "grafana_folder." + name + ".uid"
+		fmt.Fprintf(&b, "\nresource \"grafana_folder\" %q {\n", name)
+		fmt.Fprintf(&b, "  uid   = %s\n", hclString(f.UID))
+		fmt.Fprintf(&b, "  title = %s\n", hclString(f.Title))
+		b.WriteString("}\n")
+	}
+
+	for _, ds := range res.Datasources {
+		name := names.get("datasource", ds.Name)
+		fmt.Fprintf(&b, "\nresource \"grafana_data_source\" %q {\n", name)
+		fmt.Fprintf(&b, "  type = %s\n", hclString(ds.Type))
+		fmt.Fprintf(&b, "  name = %s\n", hclString(ds.Name))
+		if ds.UID != "" {
+			fmt.Fprintf(&b, "  uid  = %s\n", hclString(ds.UID))
+		}
+		if ds.URL != "" {
+			fmt.Fprintf(&b, "  url  = %s\n", hclString(ds.URL))
+		}
+		if ds.Access != "" {
+			fmt.Fprintf(&b, "  access_mode = %s\n", hclString(ds.Access))
+		}
+		if ds.IsDefault {
+			b.WriteString("  is_default = true\n")
+		}
+		if ds.BasicAuth {
+			b.WriteString("  basic_auth_enabled = true\n")
+		}
+		if ds.Database != "" {
+			fmt.Fprintf(&b, "  database_name = %s\n", hclString(ds.Database))
+		}
+		if ds.User != "" {
+			fmt.Fprintf(&b, "  username = %s\n", hclString(ds.User))
+		}
+		if len(ds.JSONData) > 0 {
+			data, err := json.Marshal(ds.JSONData)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&b, "  json_data_encoded = %s\n", hclString(string(data)))
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, d := range res.Dashboards {
+		name := names.get("dashboard", d.Title)
+		data, err := json.MarshalIndent(CleanModel(d.Model), "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&b, "\nresource \"grafana_dashboard\" %q {\n", name)
+		if d.FolderUID != "" {
+			fmt.Fprintf(&b, "  folder = %s\n", folderRef(folderRefs, d.FolderUID))
+		}
+		if dashboardFiles {
+			file := "dashboards/" + SafeName(d.UID) + ".json"
+			files[file] = append(data, '\n')
+			fmt.Fprintf(&b, "  config_json = file(\"${path.module}/%s\")\n", file)
+		} else {
+			fmt.Fprintf(&b, "  config_json = <<-EOT\n%s\n  EOT\n", hclEscapeTemplate(string(data)))
+		}
+		b.WriteString("}\n")
+	}
+
+	for _, g := range res.RuleGroups {
+		name := names.get("rule_group", g.FolderUID+"_"+g.Title)
+		interval := g.Interval
+		if interval <= 0 {
+			interval = 60
+		}
+		fmt.Fprintf(&b, "\nresource \"grafana_rule_group\" %q {\n", name)
+		fmt.Fprintf(&b, "  name             = %s\n", hclString(g.Title))
+		fmt.Fprintf(&b, "  folder_uid       = %s\n", folderRef(folderRefs, g.FolderUID))
+		fmt.Fprintf(&b, "  interval_seconds = %d\n", interval)
+		for _, rule := range g.Rules {
+			if err := writeRule(&b, rule); err != nil {
+				return nil, err
+			}
+		}
+		b.WriteString("}\n")
+	}
+
+	files["main.tf"] = []byte(b.String())
+	return files, nil
+}
+
+func writeRule(b *strings.Builder, rule grafana.AlertRule) error {
+	b.WriteString("\n  rule {\n")
+	fmt.Fprintf(b, "    name      = %s\n", hclString(rule.Title))
+	if rule.UID != "" {
+		fmt.Fprintf(b, "    uid       = %s\n", hclString(rule.UID))
+	}
+	fmt.Fprintf(b, "    condition = %s\n", hclString(rule.Condition))
+	if rule.For != "" {
+		fmt.Fprintf(b, "    for       = %s\n", hclString(rule.For))
+	}
+	if rule.NoDataState != "" {
+		fmt.Fprintf(b, "    no_data_state  = %s\n", hclString(rule.NoDataState))
+	}
+	if rule.ExecErrState != "" {
+		fmt.Fprintf(b, "    exec_err_state = %s\n", hclString(rule.ExecErrState))
+	}
+	if rule.IsPaused {
+		b.WriteString("    is_paused = true\n")
+	}
+	if len(rule.Labels) > 0 {
+		fmt.Fprintf(b, "    labels = %s\n", hclMap(rule.Labels, "    "))
+	}
+	if len(rule.Annotations) > 0 {
+		fmt.Fprintf(b, "    annotations = %s\n", hclMap(rule.Annotations, "    "))
+	}
+	if cp, ok := rule.NotificationSettings["receiver"].(string); ok && cp != "" {
+		fmt.Fprintf(b, "    notification_settings {\n      contact_point = %s\n    }\n", hclString(cp))
+	}
+	for _, q := range rule.Data {
+		model, err := json.Marshal(q.Model)
+		if err != nil {
+			return err
+		}
+		b.WriteString("    data {\n")
+		fmt.Fprintf(b, "      ref_id         = %s\n", hclString(q.RefID))
+		fmt.Fprintf(b, "      datasource_uid = %s\n", hclString(q.DatasourceUID))
+		if q.QueryType != "" {
+			fmt.Fprintf(b, "      query_type     = %s\n", hclString(q.QueryType))
+		}
+		fmt.Fprintf(b, "      relative_time_range {\n        from = %d\n        to   = %d\n      }\n", q.RelativeTimeRange.From, q.RelativeTimeRange.To)
+		fmt.Fprintf(b, "      model = %s\n", hclString(string(model)))
+		b.WriteString("    }\n")
+	}
+	b.WriteString("  }\n")
+	return nil
+}
+
+// Grizzly renders resources as Grizzly (grizzly.grafana.com/v1alpha1)
+// manifests, one file per resource keyed by relative path.
+func Grizzly(res Resources) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	add := func(kind, name string, metadata map[string]interface{}, spec interface{}) error {
+		metadata["name"] = name
+		doc := map[string]interface{}{
+			"apiVersion": "grizzly.grafana.com/v1alpha1",
+			"kind":       kind,
+			"metadata":   metadata,
+			"spec":       spec,
+		}
+		data, err := yaml.Marshal(doc)
+		if err != nil {
+			return fmt.Errorf("encoding %s %s: %w", kind, name, err)
+		}
+		files["resources/"+strings.ToLower(kind)+"-"+SafeName(name)+".yaml"] = data
+		return nil
+	}
+
+	for _, f := range res.Folders {
+		if err := add("DashboardFolder", f.UID, map[string]interface{}{}, map[string]interface{}{"uid": f.UID, "title": f.Title}); err != nil {
+			return nil, err
+		}
+	}
+	for _, ds := range res.Datasources {
+		spec, err := toGeneric(ds)
+		if err != nil {
+			return nil, err
+		}
+		delete(spec, "id")
+		delete(spec, "orgId")
+		delete(spec, "secureJsonData")
+		name := ds.UID
+		if name == "" {
+			name = ds.Name
+		}
+		if err := add("Datasource", name, map[string]interface{}{}, spec); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range res.Dashboards {
+		meta := map[string]interface{}{}
+		if d.FolderUID != "" {
+			meta["folder"] = d.FolderUID
+		}
+		if err := add("Dashboard", d.UID, meta, CleanModel(d.Model)); err != nil {
+			return nil, err
+		}
+	}
+	for _, g := range res.RuleGroups {
+		spec, err := toGeneric(g)
+		if err != nil {
+			return nil, err
+		}
+		if err := add("AlertRuleGroup", g.FolderUID+"."+g.Title, map[string]interface{}{"folder": g.FolderUID}, spec); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// toGeneric round-trips v through JSON so YAML output uses the API field names
+func toGeneric(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func folderRef(refs map[string]string, uid string) string {
+	if ref, ok := refs[uid]; ok {
+		return ref
+	}
+	return hclString(uid)
+}
+
+// hclString quotes s as an HCL string literal, escaping template sequences
+func hclString(s string) string {
+	return hclEscapeTemplate(strconv.Quote(s))
+}
+
+// hclEscapeTemplate escapes HCL interpolation and directive markers
+func hclEscapeTemplate(s string) string {
+	s = strings.ReplaceAll(s, "${", "$${")
+	return strings.ReplaceAll(s, "%{", "%%{")
+}
+
+func hclMap(m map[string]string, indent string) string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s  %s = %s\n", indent, hclString(k), hclString(m[k]))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// namer produces unique Terraform resource names
+type namer struct {
+	used map[string]int
+}
+
+func newNamer() *namer {
+	return &namer{used: map[string]int{}}
+}
+
+func (n *namer) get(kind, title string) string {
+	base := strings.ToLower(strings.NewReplacer(".", "_", "-", "_").Replace(SafeName(title)))
+	if base == "" || (base[0] >= '0' && base[0] <= '9') {
+		base = kind + "_" + base
+	}
+	key := kind + "/" + base
+	n.used[key]++
+	if c := n.used[key]; c > 1 {
+		return fmt.Sprintf("%s_%d", base, c)
+	}
+	return base
+}
@@ -0,0 +1,175 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProvisionedDashboard is a dashboard file Grafana loads through file
+// provisioning, with the folder its provider puts it in
+type ProvisionedDashboard struct {
+	Path     string `json:"path"`
+	Provider string `json:"provider,omitempty"`
+	UID      string `json:"uid,omitempty"`
+	Title    string `json:"title,omitempty"`
+	// Folder is the title of the folder the dashboard is provisioned into,
+	// "" for General
+	Folder         string                 `json:"folder,omitempty"`
+	FolderUID      string                 `json:"folder_uid,omitempty"`
+	AllowUIUpdates bool                   `json:"allow_ui_updates,omitempty"`
+	Model          map[string]interface{} `json:"-"`
+	// Error is why the file could not be read as a dashboard
+	Error string `json:"error,omitempty"`
+}
+
+// provisioningProvider is one entry of a dashboard provider file
+type provisioningProvider struct {
+	Name           string `yaml:"name"`
+	Type           string `yaml:"type"`
+	Folder         string `yaml:"folder"`
+	FolderUID      string `yaml:"folderUid"`
+	AllowUIUpdates bool   `yaml:"allowUiUpdates"`
+	Options        struct {
+		Path                      string `yaml:"path"`
+		FoldersFromFilesStructure bool   `yaml:"foldersFromFilesStructure"`
+	} `yaml:"options"`
+}
+
+// ReadProvisioned reads the dashboards provisioned from path. A directory
+// holding provider YAML files (such as /etc/grafana/provisioning/dashboards)
+// is followed to each file provider's options.path, resolved relative to
+// the provider file; any other directory is read as a provider with
+// foldersFromFilesStructure, so subdirectories name folders. Files that are
+// not valid dashboards are returned with Error set.
+func ReadProvisioned(path string) ([]ProvisionedDashboard, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []ProvisionedDashboard{readDashboardFile(path)}, nil
+	}
+
+	providers, err := readProviders(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(providers) == 0 {
+		p := provisioningProvider{}
+		p.Options.Path = path
+		p.Options.FoldersFromFilesStructure = true
+		providers = []provisioningProvider{p}
+	}
+
+	var out []ProvisionedDashboard
+	for _, p := range providers {
+		dashboards, err := readProvider(p)
+		if err != nil {
+			return out, fmt.Errorf("provider %q: %w", p.Name, err)
+		}
+		out = append(out, dashboards...)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	return out, nil
+}
+
+// readProviders returns the file providers declared by the YAML files
+// directly in dir
+func readProviders(dir string) ([]provisioningProvider, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var providers []provisioningProvider
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		file := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var doc struct {
+			Providers []provisioningProvider `yaml:"providers"`
+		}
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", file, err)
+		}
+		for _, p := range doc.Providers {
+			if p.Type != "" && p.Type != "file" {
+				continue
+			}
+			if p.Options.Path == "" {
+				continue
+			}
+			p.Options.Path = os.ExpandEnv(p.Options.Path)
+			if !filepath.IsAbs(p.Options.Path) {
+				p.Options.Path = filepath.Join(dir, p.Options.Path)
+			}
+			providers = append(providers, p)
+		}
+	}
+	return providers, nil
+}
+
+// readProvider reads the dashboard files under a provider's path
+func readProvider(p provisioningProvider) ([]ProvisionedDashboard, error) {
+	root := p.Options.Path
+	var out []ProvisionedDashboard
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".json") {
+			return nil
+		}
+		dash := readDashboardFile(path)
+		dash.Provider = p.Name
+		dash.AllowUIUpdates = p.AllowUIUpdates
+		dash.Folder, dash.FolderUID = p.Folder, p.FolderUID
+		if p.Options.FoldersFromFilesStructure {
+			// Grafana names the folder after the file's directory; files at
+			// the root go to General
+			dash.Folder, dash.FolderUID = "", ""
+			if dir := filepath.Dir(path); dir != filepath.Clean(root) {
+				dash.Folder = filepath.Base(dir)
+			}
+		}
+		out = append(out, dash)
+		return nil
+	})
+	return out, err
+}
+
+func readDashboardFile(path string) ProvisionedDashboard {
+	dash := ProvisionedDashboard{Path: path}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		dash.Error = err.Error()
+		return dash
+	}
+	var model map[string]interface{}
+	if err := json.Unmarshal(data, &model); err != nil {
+		dash.Error = fmt.Sprintf("invalid JSON: %v", err)
+		return dash
+	}
+	// Files saved from the UI's export may wrap the model
+	if inner, ok := model["dashboard"].(map[string]interface{}); ok {
+		model = inner
+	}
+	dash.UID, _ = model["uid"].(string)
+	dash.Title, _ = model["title"].(string)
+	if dash.Title == "" {
+		dash.Error = "no dashboard title"
+	}
+	dash.Model = model
+	return dash
+}
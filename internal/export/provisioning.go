@@ -0,0 +1,130 @@
+// Package export writes Grafana content to local files in formats other tools
+// consume: Grafana file provisioning, Terraform, and Grizzly. It also reads
+// back the dashboards Grafana provisions from files.
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Dashboard is a dashboard model together with the folder it lives in
+type Dashboard struct {
+	UID         string
+	Title       string
+	FolderUID   string
+	FolderTitle string
+	Model       map[string]interface{}
+}
+
+// ProviderOptions configures the generated dashboard provider file
+type ProviderOptions struct {
+	Name            string
+	OrgID           int64
+	DashboardsPath  string
+	AllowUIUpdates  bool
+	DisableDeletion bool
+}
+
+// WriteProvisioning writes dashboards to dir/dashboards/<folder>/<uid>.json
+// and a provider file to dir/provisioning/dashboards/<name>.yaml that loads
+// them with foldersFromFilesStructure. It returns the files written.
+func WriteProvisioning(dir string, dashboards []Dashboard, opts ProviderOptions) ([]string, error) {
+	if opts.Name == "" {
+		opts.Name = "grafana-mcp"
+	}
+	if opts.OrgID == 0 {
+		opts.OrgID = 1
+	}
+	if opts.DashboardsPath == "" {
+		opts.DashboardsPath = "/var/lib/grafana/dashboards"
+	}
+
+	var written []string
+	for _, d := range dashboards {
+		sub := filepath.Join(dir, "dashboards")
+		if d.FolderUID != "" && d.FolderTitle != "" {
+			sub = filepath.Join(sub, SafeName(d.FolderTitle))
+		}
+		if err := os.MkdirAll(sub, 0o755); err != nil {
+			return written, fmt.Errorf("creating %s: %w", sub, err)
+		}
+
+		data, err := json.MarshalIndent(CleanModel(d.Model), "", "  ")
+		if err != nil {
+			return written, fmt.Errorf("encoding dashboard %s: %w", d.UID, err)
+		}
+		path := filepath.Join(sub, SafeName(d.UID)+".json")
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return written, fmt.Errorf("writing %s: %w", path, err)
+		}
+		written = append(written, path)
+	}
+
+	provider := map[string]interface{}{
+		"apiVersion": 1,
+		"providers": []map[string]interface{}{{
+			"name":            opts.Name,
+			"orgId":           opts.OrgID,
+			"type":            "file",
+			"disableDeletion": opts.DisableDeletion,
+			"allowUiUpdates":  opts.AllowUIUpdates,
+			"options": map[string]interface{}{
+				"path":                      opts.DashboardsPath,
+				"foldersFromFilesStructure": true,
+			},
+		}},
+	}
+	data, err := yaml.Marshal(provider)
+	if err != nil {
+		return written, fmt.Errorf("encoding provider: %w", err)
+	}
+	provDir := filepath.Join(dir, "provisioning", "dashboards")
+	if err := os.MkdirAll(provDir, 0o755); err != nil {
+		return written, fmt.Errorf("creating %s: %w", provDir, err)
+	}
+	path := filepath.Join(provDir, SafeName(opts.Name)+".yaml")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return written, fmt.Errorf("writing %s: %w", path, err)
+	}
+	written = append(written, path)
+
+	sort.Strings(written)
+	return written, nil
+}
+
+// CleanModel returns a copy of a dashboard model without the instance-specific
+// numeric id, which must not be carried between Grafana instances.
+func CleanModel(model map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(model))
+	for k, v := range model {
+		if k == "id" {
+			continue
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// SafeName converts a title into a portable file or identifier name.
+func SafeName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			b.WriteRune(r)
+		case r == ' ' || r == '/':
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "unnamed"
+	}
+	return b.String()
+}
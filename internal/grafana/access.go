@@ -0,0 +1,34 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ============== Access Introspection ==============
+
+// GetUserPermissions returns the RBAC actions the authenticated identity
+// may perform, each with the scopes it applies to. Grafana versions without
+// role-based access control answer 404.
+func (c *Client) GetUserPermissions(ctx context.Context) (map[string][]string, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/access-control/user/permissions", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string][]string
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result, nil
+}
+
+// CheckAccess makes a GET request to path and discards the response. It
+// returns nil when the request succeeded; an *APIError carries the status
+// of a refusal.
+func (c *Client) CheckAccess(ctx context.Context, path string) error {
+	_, err := c.doRequest(ctx, "GET", path, nil)
+	return err
+}
@@ -2,20 +2,44 @@ package grafana
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
 // Client represents a Grafana API client
 type Client struct {
 	baseURL    string
+	// keyMu guards apiKey, which SetAPIKey replaces when the token is rotated
+	keyMu      sync.RWMutex
 	apiKey     string
+	// username and password, when username is set, authenticate with HTTP
+	// basic auth in place of apiKey
+	username   string
+	password   string
+	// oauth, when set, adds an access token from an OAuth 2.0 provider
+	oauth      *oauthSource
 	httpClient *http.Client
+	// tlsConfig, when set by ConfigureTLS, is used for WebSocket
+	// connections too
+	tlsConfig  *tls.Config
+	retry      RetryPolicy
+	// responses, when set by SetResponseCache, reuses recent GET responses
+	cacheMu    sync.RWMutex
+	responses  *responseCache
+	// orgID, when set by SetOrgID, is sent as X-Grafana-Org-Id
+	orgID      atomic.Int64
 }
 
 // NewClient creates a new Grafana client
@@ -26,11 +50,158 @@ func NewClient(baseURL, apiKey string) *Client {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		retry: DefaultRetryPolicy(),
 	}
 }
 
-// doRequest performs an HTTP request to the Grafana API
-func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error) {
+// NewBasicAuthClient creates a Grafana client that authenticates as a user
+// with HTTP basic auth, for instances where API keys and service account
+// tokens are disabled
+func NewBasicAuthClient(baseURL, username, password string) *Client {
+	c := NewClient(baseURL, "")
+	c.username = username
+	c.password = password
+	return c
+}
+
+// CloseIdleConnections closes kept-alive connections to Grafana that are
+// not in use
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// SetAPIKey replaces the token sent with later requests
+func (c *Client) SetAPIKey(apiKey string) {
+	c.keyMu.Lock()
+	defer c.keyMu.Unlock()
+	c.apiKey = apiKey
+}
+
+// SetOrgID directs later requests to the organization with the given ID
+// through the X-Grafana-Org-Id header. 0 sends requests to the user's
+// current organization, as Grafana picks without the header.
+func (c *Client) SetOrgID(id int64) {
+	c.orgID.Store(id)
+}
+
+// OrgID returns the organization set by SetOrgID, or 0
+func (c *Client) OrgID() int64 {
+	return c.orgID.Load()
+}
+
+// SessionOrgs holds the organization one MCP session has switched each
+// client to. Transports attach one per session to the context of its calls
+// with WithSessionOrgs, so a switch made by one session leaves the other
+// sessions, and the organization set by SetOrgID, alone.
+type SessionOrgs struct {
+	mu  sync.Mutex
+	ids map[*Client]int64
+}
+
+// NewSessionOrgs returns the organizations of a session that has switched
+// none yet
+func NewSessionOrgs() *SessionOrgs {
+	return &SessionOrgs{ids: map[*Client]int64{}}
+}
+
+type sessionOrgsKey struct{}
+
+// WithSessionOrgs returns ctx carrying a session's organizations
+func WithSessionOrgs(ctx context.Context, orgs *SessionOrgs) context.Context {
+	return context.WithValue(ctx, sessionOrgsKey{}, orgs)
+}
+
+func sessionOrgsFrom(ctx context.Context) *SessionOrgs {
+	if ctx == nil {
+		return nil
+	}
+	orgs, _ := ctx.Value(sessionOrgsKey{}).(*SessionOrgs)
+	return orgs
+}
+
+// SetSessionOrgID directs later requests of the session ctx carries to the
+// organization with the given ID; 0 returns them to the client's
+// organization. It reports false, changing nothing, when ctx carries no
+// session.
+func (c *Client) SetSessionOrgID(ctx context.Context, id int64) bool {
+	orgs := sessionOrgsFrom(ctx)
+	if orgs == nil {
+		return false
+	}
+	orgs.mu.Lock()
+	defer orgs.mu.Unlock()
+	if id > 0 {
+		orgs.ids[c] = id
+	} else {
+		delete(orgs.ids, c)
+	}
+	return true
+}
+
+// OrgIDFor returns the organization requests made with ctx go to: the one
+// its session switched to, else the one set by SetOrgID, else 0 for the
+// user's current organization
+func (c *Client) OrgIDFor(ctx context.Context) int64 {
+	if orgs := sessionOrgsFrom(ctx); orgs != nil {
+		orgs.mu.Lock()
+		id, ok := orgs.ids[c]
+		orgs.mu.Unlock()
+		if ok {
+			return id
+		}
+	}
+	return c.OrgID()
+}
+
+// setOrgHeader adds the organization requests made with ctx go to to header
+func (c *Client) setOrgHeader(ctx context.Context, header http.Header) {
+	if id := c.OrgIDFor(ctx); id > 0 {
+		header.Set("X-Grafana-Org-Id", strconv.FormatInt(id, 10))
+	}
+}
+
+// authorization returns the Authorization header value for API requests,
+// or "" for anonymous access
+func (c *Client) authorization() string {
+	if c.username != "" {
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(c.username+":"+c.password))
+	}
+	c.keyMu.RLock()
+	defer c.keyMu.RUnlock()
+	if c.apiKey != "" {
+		return "Bearer " + c.apiKey
+	}
+	return ""
+}
+
+// authenticate sets the credentials of a request on header, fetching an
+// OAuth access token when one is due
+func (c *Client) authenticate(ctx context.Context, header http.Header) error {
+	if auth := c.authorization(); auth != "" {
+		header.Set("Authorization", auth)
+	}
+	if c.oauth != nil {
+		token, err := c.oauth.current(ctx)
+		if err != nil {
+			return fmt.Errorf("cannot get an OAuth access token for Grafana: %w", err)
+		}
+		header.Set(c.oauth.cfg.Header, "Bearer "+token)
+	}
+	return nil
+}
+
+// doRequest performs an HTTP request to the Grafana API. It is abandoned
+// when ctx is done.
+func (c *Client) doRequest(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	req, err := c.newRequest(ctx, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	return c.execute(req)
+}
+
+// newRequest builds a JSON API request with body marshalled as JSON
+func (c *Client) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
 	var bodyReader io.Reader
 	if body != nil {
 		jsonBody, err := json.Marshal(body)
@@ -40,41 +211,168 @@ func (c *Client) doRequest(method, path string, body interface{}) ([]byte, error
 		bodyReader = bytes.NewReader(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+path, bodyReader)
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "application/json")
 
+	return req, nil
+}
+
+// sendAuthenticated authenticates and sends a request and returns the
+// response body, converting connection failures and error statuses into
+// readable errors. Transient failures are retried under the client's retry
+// policy.
+func (c *Client) sendAuthenticated(req *http.Request) ([]byte, error) {
+	if err := c.authenticate(req.Context(), req.Header); err != nil {
+		return nil, err
+	}
+	respBody, err := c.sendWithRetries(req)
+
+	// The provider may revoke an access token before it expires: fetch a
+	// new one and retry once
+	var apiErr *APIError
+	if c.oauth == nil || !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		return respBody, err
+	}
+	retry, ok := rewind(req)
+	if !ok {
+		return nil, err
+	}
+	c.oauth.invalidate(strings.TrimPrefix(req.Header.Get(c.oauth.cfg.Header), "Bearer "))
+	if err := c.authenticate(retry.Context(), retry.Header); err != nil {
+		return nil, err
+	}
+	return c.sendWithRetries(retry)
+}
+
+// send makes one attempt at an authenticated request. retryAfter is
+// negative when the failure is not worth retrying, else the least wait
+// Grafana asked for before another attempt.
+func (c *Client) send(req *http.Request) (respBody []byte, retryAfter time.Duration, err error) {
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, -1, fmt.Errorf("request to Grafana abandoned: %w", ctxErr)
+		}
 		// Provide user-friendly error for connection failures
 		if strings.Contains(err.Error(), "connection refused") {
-			return nil, fmt.Errorf("cannot connect to Grafana at %s: connection refused. Ensure Grafana is running and accessible", c.baseURL)
+			return nil, retryableMethod(req, 0), fmt.Errorf("cannot connect to Grafana at %s: connection refused. Ensure Grafana is running and accessible", c.baseURL)
 		}
 		if strings.Contains(err.Error(), "no such host") {
-			return nil, fmt.Errorf("cannot connect to Grafana at %s: host not found. Check GRAFANA_URL configuration", c.baseURL)
+			return nil, -1, fmt.Errorf("cannot connect to Grafana at %s: host not found. Check GRAFANA_URL configuration", c.baseURL)
 		}
 		if strings.Contains(err.Error(), "timeout") {
-			return nil, fmt.Errorf("connection to Grafana at %s timed out. Check network connectivity", c.baseURL)
+			return nil, -1, fmt.Errorf("connection to Grafana at %s timed out. Check network connectivity", c.baseURL)
 		}
-		return nil, fmt.Errorf("request to Grafana failed: %w", err)
+		return nil, retryableMethod(req, 0), fmt.Errorf("request to Grafana failed: %w", err)
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err = io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
+		return nil, retryableMethod(req, 0), fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
-	}
-
-	return respBody, nil
+		retryAfter = -1
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests:
+			// A rate-limited request was not processed, so any method may
+			// be retried
+			retryAfter = parseRetryAfter(resp.Header.Get("Retry-After"))
+		case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			retryAfter = retryableMethod(req, parseRetryAfter(resp.Header.Get("Retry-After")))
+		}
+		return nil, retryAfter, newAPIError(resp.StatusCode, respBody)
+	}
+
+	return respBody, -1, nil
+}
+
+// APIError is returned for Grafana responses with an error status. Body is
+// the raw response, capped in size, for matching on specific failures;
+// Message is the normalized text the error reports.
+type APIError struct {
+	StatusCode int
+	Body       string
+	// Message is Grafana's error message, or the response text with markup
+	// stripped, credentials redacted, and length capped
+	Message string
+	// MessageID is Grafana's machine-readable error id, when it sent one
+	MessageID string
+	// TraceID identifies the request in Grafana's traces and logs, when it
+	// sent one
+	TraceID string
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Message)
+	if e.MessageID != "" {
+		msg += fmt.Sprintf(" [%s]", e.MessageID)
+	}
+	if e.TraceID != "" {
+		msg += fmt.Sprintf(" (trace %s)", e.TraceID)
+	}
+	return msg
+}
+
+// Kind classifies the status for callers that branch on the sort of
+// failure rather than the exact code: bad_request, unauthorized, forbidden,
+// not_found, conflict, precondition_failed, too_many_requests, unavailable,
+// server_error, or client_error
+func (e *APIError) Kind() string {
+	switch e.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusPreconditionFailed:
+		return "precondition_failed"
+	case http.StatusTooManyRequests:
+		return "too_many_requests"
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return "unavailable"
+	}
+	if e.StatusCode >= 500 {
+		return "server_error"
+	}
+	return "client_error"
+}
+
+// StatusCode returns the HTTP status of a Grafana API error anywhere in
+// err's chain, or 0 when err is not one
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return 0
+	}
+	return apiErr.StatusCode
+}
+
+// IsVersionConflict reports whether err is Grafana rejecting a save because
+// the dashboard or folder was changed since it was read (412 version-mismatch
+// for dashboards, 409 for folders)
+func IsVersionConflict(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		return strings.Contains(apiErr.Body, "version-mismatch") ||
+			strings.Contains(apiErr.Body, "changed by someone else")
+	}
+	return false
 }
 
 // ============== Dashboard Operations ==============
@@ -186,25 +484,68 @@ type SearchDashboardsResponse struct {
 	FolderID    int64    `json:"folderId"`
 	FolderUID   string   `json:"folderUid"`
 	FolderTitle string   `json:"folderTitle"`
+	SortMeta     int64   `json:"sortMeta,omitempty"`
+	SortMetaName string  `json:"sortMetaName,omitempty"`
+}
+
+// SearchQuery holds the filters accepted by the search API
+type SearchQuery struct {
+	Query        string
+	Tags         []string
+	FolderIDs    []int64
+	FolderUIDs   []string
+	DashboardIDs []int64
+	Type         string
+	// Starred limits results to the authenticated user's starred dashboards
+	Starred    bool
+	Sort       string
+	Limit      int
+	Page       int
 }
 
 // SearchDashboards searches for dashboards
-func (c *Client) SearchDashboards(query string, tags []string, folderIDs []int64, dashboardType string, limit int) ([]SearchDashboardsResponse, error) {
+func (c *Client) SearchDashboards(ctx context.Context, query string, tags []string, folderIDs []int64, dashboardType string, limit int) ([]SearchDashboardsResponse, error) {
+	return c.Search(ctx, SearchQuery{
+		Query:     query,
+		Tags:      tags,
+		FolderIDs: folderIDs,
+		Type:      dashboardType,
+		Limit:     limit,
+	})
+}
+
+// Search runs a dashboard/folder search with the filters in q
+func (c *Client) Search(ctx context.Context, q SearchQuery) ([]SearchDashboardsResponse, error) {
 	params := url.Values{}
-	if query != "" {
-		params.Set("query", query)
+	if q.Query != "" {
+		params.Set("query", q.Query)
 	}
-	for _, tag := range tags {
+	for _, tag := range q.Tags {
 		params.Add("tag", tag)
 	}
-	for _, fid := range folderIDs {
+	for _, fid := range q.FolderIDs {
 		params.Add("folderIds", fmt.Sprintf("%d", fid))
 	}
-	if dashboardType != "" {
-		params.Set("type", dashboardType)
+	for _, fuid := range q.FolderUIDs {
+		params.Add("folderUIDs", fuid)
+	}
+	for _, id := range q.DashboardIDs {
+		params.Add("dashboardIds", fmt.Sprintf("%d", id))
+	}
+	if q.Type != "" {
+		params.Set("type", q.Type)
 	}
-	if limit > 0 {
-		params.Set("limit", fmt.Sprintf("%d", limit))
+	if q.Starred {
+		params.Set("starred", "true")
+	}
+	if q.Sort != "" {
+		params.Set("sort", q.Sort)
+	}
+	if q.Limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", q.Limit))
+	}
+	if q.Page > 0 {
+		params.Set("page", fmt.Sprintf("%d", q.Page))
 	}
 
 	path := "/api/search"
@@ -212,7 +553,7 @@ func (c *Client) SearchDashboards(query string, tags []string, folderIDs []int64
 		path += "?" + params.Encode()
 	}
 
-	resp, err := c.doRequest("GET", path, nil)
+	resp, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -225,9 +566,23 @@ func (c *Client) SearchDashboards(query string, tags []string, folderIDs []int64
 	return results, nil
 }
 
+// GetDashboardUIDByID finds the uid of a dashboard from its legacy numeric id
+func (c *Client) GetDashboardUIDByID(ctx context.Context, id int64) (string, error) {
+	hits, err := c.Search(ctx, SearchQuery{DashboardIDs: []int64{id}, Type: "dash-db"})
+	if err != nil {
+		return "", err
+	}
+	for _, h := range hits {
+		if h.ID == id {
+			return h.UID, nil
+		}
+	}
+	return "", &APIError{StatusCode: http.StatusNotFound, Message: fmt.Sprintf("dashboard with id %d not found", id)}
+}
+
 // GetDashboard retrieves a dashboard by UID
-func (c *Client) GetDashboard(uid string) (*Dashboard, error) {
-	resp, err := c.doRequest("GET", "/api/dashboards/uid/"+uid, nil)
+func (c *Client) GetDashboard(ctx context.Context, uid string) (*Dashboard, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/dashboards/uid/"+uid, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -247,8 +602,8 @@ func (c *Client) GetDashboard(uid string) (*Dashboard, error) {
 }
 
 // SaveDashboard creates or updates a dashboard
-func (c *Client) SaveDashboard(req SaveDashboardRequest) (*SaveDashboardResponse, error) {
-	resp, err := c.doRequest("POST", "/api/dashboards/db", req)
+func (c *Client) SaveDashboard(ctx context.Context, req SaveDashboardRequest) (*SaveDashboardResponse, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/dashboards/db", req)
 	if err != nil {
 		return nil, err
 	}
@@ -261,12 +616,99 @@ func (c *Client) SaveDashboard(req SaveDashboardRequest) (*SaveDashboardResponse
 	return &result, nil
 }
 
+// SearchSortOption is a sort order advertised by the search API
+type SearchSortOption struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+	Description string `json:"description"`
+	Meta        string `json:"meta"`
+}
+
+// GetSearchSorting lists the search sort options the instance supports.
+// Enterprise instances include usage-based options such as viewed-recently.
+func (c *Client) GetSearchSorting(ctx context.Context) ([]SearchSortOption, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/search/sorting", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []SearchSortOption
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return results, nil
+}
+
 // DeleteDashboard deletes a dashboard by UID
-func (c *Client) DeleteDashboard(uid string) error {
-	_, err := c.doRequest("DELETE", "/api/dashboards/uid/"+uid, nil)
+func (c *Client) DeleteDashboard(ctx context.Context, uid string) error {
+	_, err := c.doRequest(ctx, "DELETE", "/api/dashboards/uid/"+uid, nil)
 	return err
 }
 
+// DashboardMeta holds the metadata Grafana returns alongside a dashboard
+type DashboardMeta struct {
+	Slug        string `json:"slug,omitempty"`
+	URL         string `json:"url,omitempty"`
+	FolderID    int64  `json:"folderId,omitempty"`
+	FolderUID   string `json:"folderUid,omitempty"`
+	FolderTitle string `json:"folderTitle,omitempty"`
+	Created     string `json:"created,omitempty"`
+	Updated     string `json:"updated,omitempty"`
+	CreatedBy   string `json:"createdBy,omitempty"`
+	UpdatedBy   string `json:"updatedBy,omitempty"`
+	Version     int    `json:"version,omitempty"`
+	Provisioned bool   `json:"provisioned,omitempty"`
+}
+
+// DashboardJSON is a dashboard kept as raw JSON so that fields not modelled
+// by Dashboard survive a read-modify-write cycle
+type DashboardJSON struct {
+	Dashboard map[string]interface{} `json:"dashboard"`
+	Meta      DashboardMeta          `json:"meta"`
+}
+
+// GetDashboardJSON retrieves a dashboard by UID as raw JSON with its metadata
+func (c *Client) GetDashboardJSON(ctx context.Context, uid string) (*DashboardJSON, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/dashboards/uid/"+uid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result DashboardJSON
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// SaveDashboardJSON creates or updates a dashboard from raw JSON
+func (c *Client) SaveDashboardJSON(ctx context.Context, dashboard map[string]interface{}, folderUID, message string, overwrite bool) (*SaveDashboardResponse, error) {
+	body := map[string]interface{}{
+		"dashboard": dashboard,
+		"overwrite": overwrite,
+	}
+	if folderUID != "" {
+		body["folderUid"] = folderUID
+	}
+	if message != "" {
+		body["message"] = message
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/dashboards/db", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result SaveDashboardResponse
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // ============== Datasource Operations ==============
 
 // Datasource represents a Grafana datasource
@@ -285,12 +727,13 @@ type Datasource struct {
 	IsDefault bool                   `json:"isDefault,omitempty"`
 	JSONData  map[string]interface{} `json:"jsonData,omitempty"`
 	SecureJSONData map[string]string `json:"secureJsonData,omitempty"`
+	BasicAuthUser  string            `json:"basicAuthUser,omitempty"`
 	ReadOnly  bool                   `json:"readOnly,omitempty"`
 }
 
 // GetDatasources retrieves all datasources
-func (c *Client) GetDatasources() ([]Datasource, error) {
-	resp, err := c.doRequest("GET", "/api/datasources", nil)
+func (c *Client) GetDatasources(ctx context.Context) ([]Datasource, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/datasources", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -304,8 +747,8 @@ func (c *Client) GetDatasources() ([]Datasource, error) {
 }
 
 // GetDatasource retrieves a datasource by UID
-func (c *Client) GetDatasource(uid string) (*Datasource, error) {
-	resp, err := c.doRequest("GET", "/api/datasources/uid/"+uid, nil)
+func (c *Client) GetDatasource(ctx context.Context, uid string) (*Datasource, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/datasources/uid/"+uid, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -319,8 +762,8 @@ func (c *Client) GetDatasource(uid string) (*Datasource, error) {
 }
 
 // CreateDatasource creates a new datasource
-func (c *Client) CreateDatasource(ds Datasource) (*Datasource, error) {
-	resp, err := c.doRequest("POST", "/api/datasources", ds)
+func (c *Client) CreateDatasource(ctx context.Context, ds Datasource) (*Datasource, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/datasources", ds)
 	if err != nil {
 		return nil, err
 	}
@@ -336,8 +779,8 @@ func (c *Client) CreateDatasource(ds Datasource) (*Datasource, error) {
 }
 
 // UpdateDatasource updates an existing datasource
-func (c *Client) UpdateDatasource(uid string, ds Datasource) (*Datasource, error) {
-	resp, err := c.doRequest("PUT", "/api/datasources/uid/"+uid, ds)
+func (c *Client) UpdateDatasource(ctx context.Context, uid string, ds Datasource) (*Datasource, error) {
+	resp, err := c.doRequest(ctx, "PUT", "/api/datasources/uid/"+uid, ds)
 	if err != nil {
 		return nil, err
 	}
@@ -353,11 +796,21 @@ func (c *Client) UpdateDatasource(uid string, ds Datasource) (*Datasource, error
 }
 
 // DeleteDatasource deletes a datasource by UID
-func (c *Client) DeleteDatasource(uid string) error {
-	_, err := c.doRequest("DELETE", "/api/datasources/uid/"+uid, nil)
+func (c *Client) DeleteDatasource(ctx context.Context, uid string) error {
+	_, err := c.doRequest(ctx, "DELETE", "/api/datasources/uid/"+uid, nil)
 	return err
 }
 
+// DatasourceProxyGet issues a GET through the datasource proxy, forwarding
+// path (relative to the datasource URL) and params to the backend
+func (c *Client) DatasourceProxyGet(ctx context.Context, uid, path string, params url.Values) ([]byte, error) {
+	p := "/api/datasources/proxy/uid/" + url.PathEscape(uid) + "/" + strings.TrimPrefix(path, "/")
+	if len(params) > 0 {
+		p += "?" + params.Encode()
+	}
+	return c.doRequest(ctx, "GET", p, nil)
+}
+
 // ============== Folder Operations ==============
 
 // Folder represents a Grafana folder
@@ -378,8 +831,8 @@ type Folder struct {
 }
 
 // GetFolders retrieves all folders
-func (c *Client) GetFolders() ([]Folder, error) {
-	resp, err := c.doRequest("GET", "/api/folders", nil)
+func (c *Client) GetFolders(ctx context.Context) ([]Folder, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/folders", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -393,8 +846,8 @@ func (c *Client) GetFolders() ([]Folder, error) {
 }
 
 // GetFolder retrieves a folder by UID
-func (c *Client) GetFolder(uid string) (*Folder, error) {
-	resp, err := c.doRequest("GET", "/api/folders/"+uid, nil)
+func (c *Client) GetFolder(ctx context.Context, uid string) (*Folder, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/folders/"+uid, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -408,13 +861,13 @@ func (c *Client) GetFolder(uid string) (*Folder, error) {
 }
 
 // CreateFolder creates a new folder
-func (c *Client) CreateFolder(title, uid string) (*Folder, error) {
+func (c *Client) CreateFolder(ctx context.Context, title, uid string) (*Folder, error) {
 	body := map[string]string{"title": title}
 	if uid != "" {
 		body["uid"] = uid
 	}
 
-	resp, err := c.doRequest("POST", "/api/folders", body)
+	resp, err := c.doRequest(ctx, "POST", "/api/folders", body)
 	if err != nil {
 		return nil, err
 	}
@@ -427,14 +880,22 @@ func (c *Client) CreateFolder(title, uid string) (*Folder, error) {
 	return &result, nil
 }
 
+// SetFolderTeamPermission grants a team a permission (View, Edit, or Admin)
+// on a folder, leaving other permissions in place
+func (c *Client) SetFolderTeamPermission(ctx context.Context, folderUID string, teamID int64, permission string) error {
+	path := fmt.Sprintf("/api/access-control/folders/%s/teams/%d", url.PathEscape(folderUID), teamID)
+	_, err := c.doRequest(ctx, "POST", path, map[string]string{"permission": permission})
+	return err
+}
+
 // UpdateFolder updates a folder
-func (c *Client) UpdateFolder(uid, title string, version int) (*Folder, error) {
+func (c *Client) UpdateFolder(ctx context.Context, uid, title string, version int) (*Folder, error) {
 	body := map[string]interface{}{
 		"title":   title,
 		"version": version,
 	}
 
-	resp, err := c.doRequest("PUT", "/api/folders/"+uid, body)
+	resp, err := c.doRequest(ctx, "PUT", "/api/folders/"+uid, body)
 	if err != nil {
 		return nil, err
 	}
@@ -448,8 +909,8 @@ func (c *Client) UpdateFolder(uid, title string, version int) (*Folder, error) {
 }
 
 // DeleteFolder deletes a folder by UID
-func (c *Client) DeleteFolder(uid string) error {
-	_, err := c.doRequest("DELETE", "/api/folders/"+uid, nil)
+func (c *Client) DeleteFolder(ctx context.Context, uid string) error {
+	_, err := c.doRequest(ctx, "DELETE", "/api/folders/"+uid, nil)
 	return err
 }
 
@@ -471,6 +932,16 @@ type AlertRule struct {
 	Annotations  map[string]string      `json:"annotations,omitempty"`
 	Labels       map[string]string      `json:"labels,omitempty"`
 	IsPaused     bool                   `json:"isPaused,omitempty"`
+	Provenance   string                 `json:"provenance,omitempty"`
+	NotificationSettings map[string]interface{} `json:"notification_settings,omitempty"`
+}
+
+// RuleGroup is an alert rule group with its evaluation interval
+type RuleGroup struct {
+	Title     string      `json:"title"`
+	FolderUID string      `json:"folderUid"`
+	Interval  int64       `json:"interval"`
+	Rules     []AlertRule `json:"rules"`
 }
 
 type AlertQuery struct {
@@ -487,8 +958,8 @@ type RelativeTimeRange struct {
 }
 
 // GetAlertRules retrieves all alert rules
-func (c *Client) GetAlertRules() ([]AlertRule, error) {
-	resp, err := c.doRequest("GET", "/api/v1/provisioning/alert-rules", nil)
+func (c *Client) GetAlertRules(ctx context.Context) ([]AlertRule, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/provisioning/alert-rules", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -502,8 +973,8 @@ func (c *Client) GetAlertRules() ([]AlertRule, error) {
 }
 
 // GetAlertRule retrieves an alert rule by UID
-func (c *Client) GetAlertRule(uid string) (*AlertRule, error) {
-	resp, err := c.doRequest("GET", "/api/v1/provisioning/alert-rules/"+uid, nil)
+func (c *Client) GetAlertRule(ctx context.Context, uid string) (*AlertRule, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/provisioning/alert-rules/"+uid, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -517,8 +988,8 @@ func (c *Client) GetAlertRule(uid string) (*AlertRule, error) {
 }
 
 // CreateAlertRule creates a new alert rule
-func (c *Client) CreateAlertRule(rule AlertRule) (*AlertRule, error) {
-	resp, err := c.doRequest("POST", "/api/v1/provisioning/alert-rules", rule)
+func (c *Client) CreateAlertRule(ctx context.Context, rule AlertRule) (*AlertRule, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/v1/provisioning/alert-rules", rule)
 	if err != nil {
 		return nil, err
 	}
@@ -532,8 +1003,52 @@ func (c *Client) CreateAlertRule(rule AlertRule) (*AlertRule, error) {
 }
 
 // UpdateAlertRule updates an alert rule
-func (c *Client) UpdateAlertRule(uid string, rule AlertRule) (*AlertRule, error) {
-	resp, err := c.doRequest("PUT", "/api/v1/provisioning/alert-rules/"+uid, rule)
+func (c *Client) UpdateAlertRule(ctx context.Context, uid string, rule AlertRule) (*AlertRule, error) {
+	resp, err := c.doRequest(ctx, "PUT", "/api/v1/provisioning/alert-rules/"+uid, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	var result AlertRule
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// UpdateAlertRuleKeepEditable updates an alert rule without marking it as
+// provisioned, so rules created in the UI remain editable there
+func (c *Client) UpdateAlertRuleKeepEditable(ctx context.Context, uid string, rule AlertRule) (*AlertRule, error) {
+	req, err := c.newRequest(ctx, "PUT", "/api/v1/provisioning/alert-rules/"+uid, rule)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Disable-Provenance", "true")
+
+	resp, err := c.execute(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result AlertRule
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// CreateAlertRuleKeepEditable creates an alert rule without marking it as
+// provisioned, so it can still be edited in the UI
+func (c *Client) CreateAlertRuleKeepEditable(ctx context.Context, rule AlertRule) (*AlertRule, error) {
+	req, err := c.newRequest(ctx, "POST", "/api/v1/provisioning/alert-rules", rule)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Disable-Provenance", "true")
+
+	resp, err := c.execute(req)
 	if err != nil {
 		return nil, err
 	}
@@ -546,9 +1061,24 @@ func (c *Client) UpdateAlertRule(uid string, rule AlertRule) (*AlertRule, error)
 	return &result, nil
 }
 
+// GetRuleGroup retrieves an alert rule group, including its interval in seconds
+func (c *Client) GetRuleGroup(ctx context.Context, folderUID, group string) (*RuleGroup, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/provisioning/folder/"+url.PathEscape(folderUID)+"/rule-groups/"+url.PathEscape(group), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result RuleGroup
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
 // DeleteAlertRule deletes an alert rule by UID
-func (c *Client) DeleteAlertRule(uid string) error {
-	_, err := c.doRequest("DELETE", "/api/v1/provisioning/alert-rules/"+uid, nil)
+func (c *Client) DeleteAlertRule(ctx context.Context, uid string) error {
+	_, err := c.doRequest(ctx, "DELETE", "/api/v1/provisioning/alert-rules/"+uid, nil)
 	return err
 }
 
@@ -572,28 +1102,65 @@ type Annotation struct {
 	UserName    string   `json:"userName,omitempty"`
 	UserEmail   string   `json:"email,omitempty"`
 	UserAvatarURL string `json:"avatarUrl,omitempty"`
+	AlertName   string   `json:"alertName,omitempty"`
+	NewState    string   `json:"newState,omitempty"`
+	PrevState   string   `json:"prevState,omitempty"`
+}
+
+// AnnotationsQuery holds the filters accepted by the annotations API
+type AnnotationsQuery struct {
+	From         int64
+	To           int64
+	DashboardUID string
+	PanelID      int64
+	AlertID      int64
+	UserID       int64
+	Tags         []string
+	Type         string // "alert" or "annotation"; empty returns both
+	Limit        int
 }
 
 // GetAnnotations retrieves annotations with optional filters
-func (c *Client) GetAnnotations(from, to int64, dashboardUID string, panelID int64, tags []string, limit int) ([]Annotation, error) {
+func (c *Client) GetAnnotations(ctx context.Context, from, to int64, dashboardUID string, panelID int64, tags []string, limit int) ([]Annotation, error) {
+	return c.QueryAnnotations(ctx, AnnotationsQuery{
+		From:         from,
+		To:           to,
+		DashboardUID: dashboardUID,
+		PanelID:      panelID,
+		Tags:         tags,
+		Limit:        limit,
+	})
+}
+
+// QueryAnnotations retrieves annotations matching q
+func (c *Client) QueryAnnotations(ctx context.Context, q AnnotationsQuery) ([]Annotation, error) {
 	params := url.Values{}
-	if from > 0 {
-		params.Set("from", fmt.Sprintf("%d", from))
+	if q.From > 0 {
+		params.Set("from", fmt.Sprintf("%d", q.From))
+	}
+	if q.To > 0 {
+		params.Set("to", fmt.Sprintf("%d", q.To))
 	}
-	if to > 0 {
-		params.Set("to", fmt.Sprintf("%d", to))
+	if q.DashboardUID != "" {
+		params.Set("dashboardUID", q.DashboardUID)
 	}
-	if dashboardUID != "" {
-		params.Set("dashboardUID", dashboardUID)
+	if q.PanelID > 0 {
+		params.Set("panelId", fmt.Sprintf("%d", q.PanelID))
 	}
-	if panelID > 0 {
-		params.Set("panelId", fmt.Sprintf("%d", panelID))
+	if q.AlertID > 0 {
+		params.Set("alertId", fmt.Sprintf("%d", q.AlertID))
 	}
-	for _, tag := range tags {
+	if q.UserID > 0 {
+		params.Set("userId", fmt.Sprintf("%d", q.UserID))
+	}
+	for _, tag := range q.Tags {
 		params.Add("tags", tag)
 	}
-	if limit > 0 {
-		params.Set("limit", fmt.Sprintf("%d", limit))
+	if q.Type != "" {
+		params.Set("type", q.Type)
+	}
+	if q.Limit > 0 {
+		params.Set("limit", fmt.Sprintf("%d", q.Limit))
 	}
 
 	path := "/api/annotations"
@@ -601,7 +1168,7 @@ func (c *Client) GetAnnotations(from, to int64, dashboardUID string, panelID int
 		path += "?" + params.Encode()
 	}
 
-	resp, err := c.doRequest("GET", path, nil)
+	resp, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -615,8 +1182,8 @@ func (c *Client) GetAnnotations(from, to int64, dashboardUID string, panelID int
 }
 
 // CreateAnnotation creates a new annotation
-func (c *Client) CreateAnnotation(ann Annotation) (*Annotation, error) {
-	resp, err := c.doRequest("POST", "/api/annotations", ann)
+func (c *Client) CreateAnnotation(ctx context.Context, ann Annotation) (*Annotation, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/annotations", ann)
 	if err != nil {
 		return nil, err
 	}
@@ -634,14 +1201,14 @@ func (c *Client) CreateAnnotation(ann Annotation) (*Annotation, error) {
 }
 
 // UpdateAnnotation updates an annotation
-func (c *Client) UpdateAnnotation(id int64, ann Annotation) error {
-	_, err := c.doRequest("PUT", fmt.Sprintf("/api/annotations/%d", id), ann)
+func (c *Client) UpdateAnnotation(ctx context.Context, id int64, ann Annotation) error {
+	_, err := c.doRequest(ctx, "PUT", fmt.Sprintf("/api/annotations/%d", id), ann)
 	return err
 }
 
 // DeleteAnnotation deletes an annotation by ID
-func (c *Client) DeleteAnnotation(id int64) error {
-	_, err := c.doRequest("DELETE", fmt.Sprintf("/api/annotations/%d", id), nil)
+func (c *Client) DeleteAnnotation(ctx context.Context, id int64) error {
+	_, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/annotations/%d", id), nil)
 	return err
 }
 
@@ -664,8 +1231,8 @@ type Address struct {
 }
 
 // GetCurrentOrg retrieves the current organization
-func (c *Client) GetCurrentOrg() (*Organization, error) {
-	resp, err := c.doRequest("GET", "/api/org", nil)
+func (c *Client) GetCurrentOrg(ctx context.Context) (*Organization, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/org", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -678,11 +1245,51 @@ func (c *Client) GetCurrentOrg() (*Organization, error) {
 	return &result, nil
 }
 
+// UserOrg is an organization the current user belongs to, with their role
+type UserOrg struct {
+	OrgID int64  `json:"orgId"`
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+}
+
+// GetUserOrgs retrieves the organizations of the current user
+func (c *Client) GetUserOrgs(ctx context.Context) ([]UserOrg, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/user/orgs", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []UserOrg
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result, nil
+}
+
+// GetOrgs retrieves every organization of the instance. It requires a
+// Grafana server admin.
+func (c *Client) GetOrgs(ctx context.Context) ([]Organization, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/orgs?perpage=1000", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []Organization
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result, nil
+}
+
 // ============== User Operations ==============
 
 // User represents a Grafana user
 type User struct {
 	ID             int64  `json:"id,omitempty"`
+	// UserID is set instead of ID by the organization users API
+	UserID         int64  `json:"userId,omitempty"`
 	Email          string `json:"email"`
 	Name           string `json:"name"`
 	Login          string `json:"login"`
@@ -695,11 +1302,14 @@ type User struct {
 	UpdatedAt      string `json:"updatedAt,omitempty"`
 	CreatedAt      string `json:"createdAt,omitempty"`
 	AvatarURL      string `json:"avatarUrl,omitempty"`
+	// Role and LastSeenAt are set by the organization users API
+	Role           string `json:"role,omitempty"`
+	LastSeenAt     string `json:"lastSeenAt,omitempty"`
 }
 
 // GetCurrentUser retrieves the current user
-func (c *Client) GetCurrentUser() (*User, error) {
-	resp, err := c.doRequest("GET", "/api/user", nil)
+func (c *Client) GetCurrentUser(ctx context.Context) (*User, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/user", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -713,8 +1323,8 @@ func (c *Client) GetCurrentUser() (*User, error) {
 }
 
 // GetOrgUsers retrieves users in the current organization
-func (c *Client) GetOrgUsers() ([]User, error) {
-	resp, err := c.doRequest("GET", "/api/org/users", nil)
+func (c *Client) GetOrgUsers(ctx context.Context) ([]User, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/org/users", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -727,6 +1337,52 @@ func (c *Client) GetOrgUsers() ([]User, error) {
 	return results, nil
 }
 
+// OrgUserPage is one page of organization users with the total across all
+// pages
+type OrgUserPage struct {
+	OrgUsers   []User `json:"orgUsers"`
+	TotalCount int64  `json:"totalCount"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"perPage"`
+}
+
+// SearchOrgUsers retrieves a page of the users in the current organization,
+// matching query by login, email, or name when set
+func (c *Client) SearchOrgUsers(ctx context.Context, query string, page, perPage int) (*OrgUserPage, error) {
+	path := "/api/org/users/search"
+	if params := pageParams(query, page, perPage); len(params) > 0 {
+		path += "?" + params.Encode()
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result OrgUserPage
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// pageParams returns the query, page, and perpage parameters of Grafana's
+// paginated search endpoints, leaving out those not set
+func pageParams(query string, page, perPage int) url.Values {
+	params := url.Values{}
+	if query != "" {
+		params.Set("query", query)
+	}
+	if page > 0 {
+		params.Set("page", fmt.Sprintf("%d", page))
+	}
+	if perPage > 0 {
+		params.Set("perpage", fmt.Sprintf("%d", perPage))
+	}
+	return params
+}
+
 // ============== Query Operations ==============
 
 // QueryRequest represents a query request
@@ -744,9 +1400,28 @@ type QueryTarget struct {
 	Query         string                 `json:"expr,omitempty"`
 	RawQuery      string                 `json:"rawQuery,omitempty"`
 	QueryType     string                 `json:"queryType,omitempty"`
+	MaxLines      int                    `json:"maxLines,omitempty"`
 	Extra         map[string]interface{} `json:"-"`
 }
 
+// MarshalJSON merges Extra, such as the datasource-specific fields of a panel
+// target, with the typed fields; typed fields that are set take precedence
+func (q QueryTarget) MarshalJSON() ([]byte, error) {
+	type plain QueryTarget
+	typed, err := json.Marshal(plain(q))
+	if err != nil || len(q.Extra) == 0 {
+		return typed, err
+	}
+	merged := make(map[string]interface{}, len(q.Extra)+8)
+	for k, v := range q.Extra {
+		merged[k] = v
+	}
+	if err := json.Unmarshal(typed, &merged); err != nil {
+		return nil, err
+	}
+	return json.Marshal(merged)
+}
+
 // QueryResponse represents query results
 type QueryResponse struct {
 	Results map[string]QueryResult `json:"results"`
@@ -778,8 +1453,8 @@ type FrameData struct {
 }
 
 // Query executes a query against datasources
-func (c *Client) Query(req QueryRequest) (*QueryResponse, error) {
-	resp, err := c.doRequest("POST", "/api/ds/query", req)
+func (c *Client) Query(ctx context.Context, req QueryRequest) (*QueryResponse, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/ds/query", req)
 	if err != nil {
 		return nil, err
 	}
@@ -802,8 +1477,8 @@ type Health struct {
 }
 
 // GetHealth retrieves the health status
-func (c *Client) GetHealth() (*Health, error) {
-	resp, err := c.doRequest("GET", "/api/health", nil)
+func (c *Client) GetHealth(ctx context.Context) (*Health, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/health", nil)
 	if err != nil {
 		return nil, err
 	}
@@ -829,42 +1504,47 @@ type Team struct {
 	Permission  int    `json:"permission,omitempty"`
 }
 
-// GetTeams retrieves all teams
-func (c *Client) GetTeams(query string, page, perPage int) ([]Team, error) {
-	params := url.Values{}
-	if query != "" {
-		params.Set("query", query)
-	}
-	if page > 0 {
-		params.Set("page", fmt.Sprintf("%d", page))
-	}
-	if perPage > 0 {
-		params.Set("perpage", fmt.Sprintf("%d", perPage))
+// TeamPage is one page of teams with the total across all pages
+type TeamPage struct {
+	Teams      []Team `json:"teams"`
+	TotalCount int64  `json:"totalCount"`
+	Page       int    `json:"page"`
+	PerPage    int    `json:"perPage"`
+}
+
+// GetTeams retrieves one page of teams, matching query by name when set
+func (c *Client) GetTeams(ctx context.Context, query string, page, perPage int) ([]Team, error) {
+	result, err := c.SearchTeams(ctx, query, page, perPage)
+	if err != nil {
+		return nil, err
 	}
+	return result.Teams, nil
+}
 
+// SearchTeams retrieves one page of teams with the total count, matching
+// query by name when set
+func (c *Client) SearchTeams(ctx context.Context, query string, page, perPage int) (*TeamPage, error) {
 	path := "/api/teams/search"
-	if len(params) > 0 {
+	if params := pageParams(query, page, perPage); len(params) > 0 {
 		path += "?" + params.Encode()
 	}
 
-	resp, err := c.doRequest("GET", path, nil)
+	resp, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var result struct {
-		Teams []Team `json:"teams"`
-	}
+	var result TeamPage
 	if err := json.Unmarshal(resp, &result); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
 
-	return result.Teams, nil
+	return &result, nil
 }
 
 // GetTeam retrieves a team by ID
-func (c *Client) GetTeam(id int64) (*Team, error) {
-	resp, err := c.doRequest("GET", fmt.Sprintf("/api/teams/%d", id), nil)
+func (c *Client) GetTeam(ctx context.Context, id int64) (*Team, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/api/teams/%d", id), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -878,13 +1558,13 @@ func (c *Client) GetTeam(id int64) (*Team, error) {
 }
 
 // CreateTeam creates a new team
-func (c *Client) CreateTeam(name, email string) (*Team, error) {
+func (c *Client) CreateTeam(ctx context.Context, name, email string) (*Team, error) {
 	body := map[string]string{"name": name}
 	if email != "" {
 		body["email"] = email
 	}
 
-	resp, err := c.doRequest("POST", "/api/teams", body)
+	resp, err := c.doRequest(ctx, "POST", "/api/teams", body)
 	if err != nil {
 		return nil, err
 	}
@@ -901,7 +1581,7 @@ func (c *Client) CreateTeam(name, email string) (*Team, error) {
 }
 
 // DeleteTeam deletes a team by ID
-func (c *Client) DeleteTeam(id int64) error {
-	_, err := c.doRequest("DELETE", fmt.Sprintf("/api/teams/%d", id), nil)
+func (c *Client) DeleteTeam(ctx context.Context, id int64) error {
+	_, err := c.doRequest(ctx, "DELETE", fmt.Sprintf("/api/teams/%d", id), nil)
 	return err
 }
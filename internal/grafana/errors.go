@@ -0,0 +1,136 @@
+package grafana
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxErrorBody bounds the response body kept on an APIError
+	maxErrorBody = 64 << 10
+	// maxErrorDetail bounds the error text passed on to tool results
+	maxErrorDetail = 512
+)
+
+var (
+	htmlTitle  = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+	htmlBlocks = regexp.MustCompile(`(?is)<(script|style|head)[^>]*>.*?</(script|style|head)>`)
+	htmlTag    = regexp.MustCompile(`(?s)<[^>]*>`)
+	whitespace = regexp.MustCompile(`\s+`)
+	redactions = []struct {
+		pattern *regexp.Regexp
+		replace string
+	}{
+		// Authorization header values echoed by proxies
+		{regexp.MustCompile(`(?i)\b(bearer|basic)\s+[A-Za-z0-9._~+/=-]{8,}`), "$1 [REDACTED]"},
+		// Grafana service account tokens and API keys
+		{regexp.MustCompile(`\bglsa_[A-Za-z0-9_]+`), "[REDACTED]"},
+		{regexp.MustCompile(`\beyJr[A-Za-z0-9_=-]{20,}`), "[REDACTED]"},
+		// JWTs
+		{regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), "[REDACTED]"},
+		// key=value and "key": "value" pairs with secret-looking names
+		{regexp.MustCompile(`(?i)((?:api[_-]?key|access[_-]?token|token|password|passwd|secret|authorization)["']?\s*[:=]\s*["']?)[^\s"'&,;}]+`), "${1}[REDACTED]"},
+		// credentials in URLs
+		{regexp.MustCompile(`(://)[^/\s:@]+:[^/\s@]+@`), "${1}[REDACTED]@"},
+	}
+)
+
+// newAPIError builds the error for a failed response, keeping a bounded copy
+// of the raw body and a normalized message that is safe to show
+func newAPIError(status int, body []byte) *APIError {
+	e := &APIError{StatusCode: status}
+	if len(body) > maxErrorBody {
+		e.Body = string(body[:maxErrorBody])
+	} else {
+		e.Body = string(body)
+	}
+
+	var grafanaErr struct {
+		Message   string `json:"message"`
+		MessageID string `json:"messageId"`
+		Error     string `json:"error"`
+		TraceID   string `json:"traceID"`
+	}
+	if json.Unmarshal(body, &grafanaErr) == nil {
+		e.TraceID = grafanaErr.TraceID
+		msg := grafanaErr.Message
+		switch {
+		case msg == "":
+			msg = grafanaErr.Error
+		case grafanaErr.Error != "" && !strings.Contains(msg, grafanaErr.Error):
+			msg += ": " + grafanaErr.Error
+		}
+		if msg != "" {
+			e.Message = errorDetail([]byte(msg))
+			e.MessageID = grafanaErr.MessageID
+			return e
+		}
+	}
+	e.Message = errorDetail(body)
+	return e
+}
+
+// errorDetail turns a response body into a short single-line message: markup
+// and control characters are stripped, credentials are redacted, and the
+// result is capped at maxErrorDetail bytes
+func errorDetail(body []byte) string {
+	text := string(body)
+	if looksLikeHTML(text) {
+		text = htmlText(text)
+	}
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && !unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, text)
+	text = strings.TrimSpace(whitespace.ReplaceAllString(text, " "))
+	for _, r := range redactions {
+		text = r.pattern.ReplaceAllString(text, r.replace)
+	}
+	if len(text) > maxErrorDetail {
+		cut := maxErrorDetail
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		text = fmt.Sprintf("%s… (truncated, %d bytes)", text[:cut], len(body))
+	}
+	if text == "" {
+		return "empty response body"
+	}
+	return text
+}
+
+func looksLikeHTML(s string) bool {
+	head := strings.ToLower(strings.TrimSpace(s))
+	if len(head) > 512 {
+		head = head[:512]
+	}
+	return strings.HasPrefix(head, "<!doctype html") || strings.HasPrefix(head, "<html") ||
+		strings.Contains(head, "<head") || strings.Contains(head, "<body")
+}
+
+// htmlText reduces an HTML error page, such as one from a reverse proxy, to
+// its title and visible text
+func htmlText(s string) string {
+	title := ""
+	if m := htmlTitle.FindStringSubmatch(s); m != nil {
+		title = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(m[1], " ")))
+	}
+	text := htmlBlocks.ReplaceAllString(s, " ")
+	text = strings.TrimSpace(html.UnescapeString(htmlTag.ReplaceAllString(text, " ")))
+	text = whitespace.ReplaceAllString(text, " ")
+	switch {
+	case title == "":
+		return text
+	case text == "" || strings.HasPrefix(text, title):
+		return title
+	default:
+		return title + ": " + text
+	}
+}
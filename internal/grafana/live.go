@@ -0,0 +1,174 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/websocket"
+)
+
+// ============== Live Operations ==============
+
+// LiveMessage is a single publication received from a Grafana Live channel
+type LiveMessage struct {
+	Received time.Time       `json:"received"`
+	Channel  string          `json:"channel"`
+	Data     json.RawMessage `json:"data"`
+}
+
+// DialWebSocket opens an authenticated WebSocket connection to a Grafana path.
+// The caller closes the connection when ctx is done.
+func (c *Client) DialWebSocket(ctx context.Context, path string) (*websocket.Conn, error) {
+	wsURL := c.BaseURL() + path
+	switch {
+	case strings.HasPrefix(wsURL, "https://"):
+		wsURL = "wss://" + strings.TrimPrefix(wsURL, "https://")
+	case strings.HasPrefix(wsURL, "http://"):
+		wsURL = "ws://" + strings.TrimPrefix(wsURL, "http://")
+	}
+
+	header := http.Header{}
+	if err := c.authenticate(ctx, header); err != nil {
+		return nil, err
+	}
+	c.setOrgHeader(ctx, header)
+
+	conn, err := websocket.Dial(wsURL, header, c.tlsConfig, c.httpClient.Timeout)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open websocket to Grafana at %s: %w", c.baseURL, err)
+	}
+	// The handshake cannot be interrupted, so a context that ended during
+	// it is honoured once it completes
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		conn.Close()
+		return nil, fmt.Errorf("websocket to Grafana abandoned: %w", ctxErr)
+	}
+	return conn, nil
+}
+
+// centrifugeCommand is a command of the Centrifuge v2 JSON protocol; the
+// server reads several separated by newlines
+type centrifugeCommand struct {
+	ID        int                  `json:"id"`
+	Connect   *centrifugeConnect   `json:"connect,omitempty"`
+	Subscribe *centrifugeSubscribe `json:"subscribe,omitempty"`
+}
+
+type centrifugeConnect struct {
+	Name string `json:"name"`
+}
+
+type centrifugeSubscribe struct {
+	Channel string `json:"channel"`
+}
+
+// centrifugeReply is the subset of the Centrifuge v2 JSON protocol Grafana Live uses
+type centrifugeReply struct {
+	ID    int `json:"id"`
+	Error *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+	Push *struct {
+		Channel string `json:"channel"`
+		Pub     *struct {
+			Data json.RawMessage `json:"data"`
+		} `json:"pub"`
+	} `json:"push"`
+}
+
+// SubscribeLive subscribes to a Grafana Live channel and collects publications
+// until duration elapses or maxMessages have been received. Requires the
+// Centrifuge v2 protocol used by Grafana 10 and later.
+func (c *Client) SubscribeLive(ctx context.Context, channel string, duration time.Duration, maxMessages int) ([]LiveMessage, error) {
+	conn, err := c.DialWebSocket(ctx, "/api/live/ws?cf_protocol_version=v2")
+	if err != nil {
+		return nil, err
+	}
+	defer conn.CloseGracefully()
+	// Closing the connection unblocks the read loop below
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	var commands [][]byte
+	for _, cmd := range []centrifugeCommand{
+		{ID: 1, Connect: &centrifugeConnect{Name: "grafana-mcp"}},
+		{ID: 2, Subscribe: &centrifugeSubscribe{Channel: channel}},
+	} {
+		data, err := json.Marshal(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode subscribe command: %w", err)
+		}
+		commands = append(commands, data)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, bytes.Join(commands, []byte("\n"))); err != nil {
+		return nil, fmt.Errorf("failed to send subscribe command: %w", err)
+	}
+
+	deadline := time.Now().Add(duration)
+	conn.SetReadDeadline(deadline)
+
+	messages := []LiveMessage{}
+	for len(messages) < maxMessages {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return messages, fmt.Errorf("live subscription abandoned: %w", ctxErr)
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return messages, nil
+			}
+			if errors.Is(err, websocket.ErrClosed) {
+				return messages, nil
+			}
+			return messages, fmt.Errorf("live connection failed: %w", err)
+		}
+
+		// The server may batch several newline-delimited replies in one frame
+		for _, line := range bytes.Split(data, []byte("\n")) {
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+			if string(line) == "{}" {
+				// Server ping; an empty command is the pong
+				conn.WriteMessage(websocket.TextMessage, []byte("{}"))
+				continue
+			}
+
+			var reply centrifugeReply
+			if err := json.Unmarshal(line, &reply); err != nil {
+				continue
+			}
+			if reply.Error != nil {
+				return messages, fmt.Errorf("live %s failed: %s (code %d)", commandName(reply.ID), reply.Error.Message, reply.Error.Code)
+			}
+			if reply.Push != nil && reply.Push.Pub != nil {
+				messages = append(messages, LiveMessage{
+					Received: time.Now().UTC(),
+					Channel:  reply.Push.Channel,
+					Data:     reply.Push.Pub.Data,
+				})
+			}
+		}
+	}
+	return messages, nil
+}
+
+func commandName(id int) string {
+	switch id {
+	case 1:
+		return "connect"
+	case 2:
+		return "subscribe"
+	}
+	return "command"
+}
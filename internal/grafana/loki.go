@@ -0,0 +1,237 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/websocket"
+)
+
+// ============== Loki Operations ==============
+
+// LogLine is a single log entry received from Loki
+type LogLine struct {
+	Timestamp time.Time         `json:"timestamp"`
+	Labels    map[string]string `json:"labels"`
+	Line      string            `json:"line"`
+}
+
+// TailResult holds the lines collected by a bounded Loki tail
+type TailResult struct {
+	Lines []LogLine `json:"lines"`
+	// Dropped counts entries Loki could not deliver because the client fell behind
+	Dropped int `json:"dropped"`
+	// Truncated is set when collection stopped at the line limit
+	Truncated bool `json:"truncated"`
+}
+
+// lokiTailMessage is a frame from the Loki /loki/api/v1/tail websocket
+type lokiTailMessage struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+	DroppedEntries []json.RawMessage `json:"dropped_entries"`
+}
+
+// TailLoki streams lines matching a LogQL query from a Loki datasource through
+// the datasource proxy, starting at start, until duration elapses or maxLines
+// have been received
+func (c *Client) TailLoki(ctx context.Context, datasourceUID, query string, start time.Time, duration time.Duration, maxLines int) (*TailResult, error) {
+	params := url.Values{}
+	params.Set("query", query)
+	params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+	params.Set("limit", strconv.Itoa(maxLines))
+	path := "/api/datasources/proxy/uid/" + url.PathEscape(datasourceUID) + "/loki/api/v1/tail?" + params.Encode()
+
+	conn, err := c.DialWebSocket(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.CloseGracefully()
+	// Closing the connection unblocks the read loop below
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	conn.SetReadDeadline(time.Now().Add(duration))
+
+	result := &TailResult{Lines: []LogLine{}}
+	for len(result.Lines) < maxLines {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return result, fmt.Errorf("loki tail abandoned: %w", ctxErr)
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return result, nil
+			}
+			if errors.Is(err, websocket.ErrClosed) {
+				return result, nil
+			}
+			return result, fmt.Errorf("loki tail failed: %w", err)
+		}
+
+		var msg lokiTailMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return result, fmt.Errorf("loki tail failed: %s", errorDetail(data))
+		}
+		result.Dropped += len(msg.DroppedEntries)
+		for _, s := range msg.Streams {
+			for _, v := range s.Values {
+				if len(result.Lines) >= maxLines {
+					result.Truncated = true
+					break
+				}
+				ns, _ := strconv.ParseInt(v[0], 10, 64)
+				result.Lines = append(result.Lines, LogLine{
+					Timestamp: time.Unix(0, ns).UTC(),
+					Labels:    s.Stream,
+					Line:      v[1],
+				})
+			}
+		}
+	}
+	result.Truncated = true
+	return result, nil
+}
+
+// CheckLokiQuery asks Loki to parse a LogQL query, returning it in Loki's
+// canonical formatting. Loki versions without /loki/api/v1/format_query are
+// checked with a one-line query over the last minute instead, returning an
+// empty string. Parse errors are *APIError with status 400.
+func (c *Client) CheckLokiQuery(ctx context.Context, datasourceUID, query string) (string, error) {
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "loki/api/v1/format_query", url.Values{"query": {query}})
+	if err == nil {
+		var result struct {
+			Data string `json:"data"`
+		}
+		if err := json.Unmarshal(resp, &result); err != nil {
+			return "", fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+		return result.Data, nil
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = c.DatasourceProxyGet(ctx, datasourceUID, "loki/api/v1/query_range", url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(now.Add(-time.Minute).UnixNano(), 10)},
+		"end":   {strconv.FormatInt(now.UnixNano(), 10)},
+		"limit": {"1"},
+	})
+	return "", err
+}
+
+// LokiIndexStats is Loki's index-based size estimate for a stream selector
+type LokiIndexStats struct {
+	Streams int64 `json:"streams"`
+	Chunks  int64 `json:"chunks"`
+	Entries int64 `json:"entries"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// GetLokiIndexStats estimates how much data a stream selector covers between
+// start and end from Loki's index, without reading any chunks
+func (c *Client) GetLokiIndexStats(ctx context.Context, datasourceUID, selector string, start, end time.Time) (*LokiIndexStats, error) {
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "loki/api/v1/index/stats", url.Values{
+		"query": {selector},
+		"start": {strconv.FormatInt(start.UnixNano(), 10)},
+		"end":   {strconv.FormatInt(end.UnixNano(), 10)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var stats LokiIndexStats
+	if err := json.Unmarshal(resp, &stats); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return &stats, nil
+}
+
+// LokiVolume is the bytes ingested by the streams sharing a set of labels
+type LokiVolume struct {
+	Labels map[string]string `json:"labels"`
+	Bytes  int64             `json:"bytes"`
+}
+
+// GetLokiVolume returns the largest stream groups matching a selector
+// between start and end, by bytes ingested, from Loki's index. With
+// targetLabels the volume is aggregated by those labels; without, each
+// stream is reported separately. Requires Loki 2.9+ with volume enabled.
+func (c *Client) GetLokiVolume(ctx context.Context, datasourceUID, selector string, start, end time.Time, targetLabels []string, limit int) ([]LokiVolume, error) {
+	params := url.Values{
+		"query": {selector},
+		"start": {strconv.FormatInt(start.UnixNano(), 10)},
+		"end":   {strconv.FormatInt(end.UnixNano(), 10)},
+	}
+	if len(targetLabels) > 0 {
+		params.Set("targetLabels", strings.Join(targetLabels, ","))
+		params.Set("aggregateBy", "labels")
+	} else {
+		params.Set("aggregateBy", "series")
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "loki/api/v1/index/volume", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data struct {
+			Result []struct {
+				Metric map[string]string `json:"metric"`
+				Value  []interface{}     `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	volumes := make([]LokiVolume, 0, len(result.Data.Result))
+	for _, s := range result.Data.Result {
+		v := LokiVolume{Labels: s.Metric}
+		if len(s.Value) == 2 {
+			if str, ok := s.Value[1].(string); ok {
+				v.Bytes, _ = strconv.ParseInt(str, 10, 64)
+			}
+		}
+		volumes = append(volumes, v)
+	}
+	return volumes, nil
+}
+
+// GetLokiSeries lists the label sets of the streams matching a selector
+// between start and end
+func (c *Client) GetLokiSeries(ctx context.Context, datasourceUID, selector string, start, end time.Time) ([]map[string]string, error) {
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "loki/api/v1/series", url.Values{
+		"match[]": {selector},
+		"start":   {strconv.FormatInt(start.UnixNano(), 10)},
+		"end":     {strconv.FormatInt(end.UnixNano(), 10)},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Data []map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return result.Data, nil
+}
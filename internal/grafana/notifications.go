@@ -0,0 +1,287 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ============== Notification Operations ==============
+
+// Receiver is a contact point as stored in the Grafana Alertmanager
+// configuration, grouping one or more integrations under a name
+type Receiver struct {
+	Name         string        `json:"name"`
+	Integrations []Integration `json:"grafana_managed_receiver_configs"`
+}
+
+// Integration is a single notifier (Slack, PagerDuty, email, ...) within a
+// contact point. Secure settings are omitted on read; SecureFields records
+// which ones are set.
+type Integration struct {
+	UID                   string                 `json:"uid,omitempty"`
+	Name                  string                 `json:"name"`
+	Type                  string                 `json:"type"`
+	DisableResolveMessage bool                   `json:"disableResolveMessage"`
+	Settings              map[string]interface{} `json:"settings"`
+	SecureSettings        map[string]string      `json:"secureSettings,omitempty"`
+	SecureFields          map[string]bool        `json:"secureFields,omitempty"`
+}
+
+// TestAlert is the synthetic alert sent by a receiver test
+type TestAlert struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ReceiverTestResult is the per-integration outcome of a receiver test
+type ReceiverTestResult struct {
+	Alert      TestAlert `json:"alert"`
+	NotifiedAt string    `json:"notified_at"`
+	Receivers  []struct {
+		Name    string `json:"name"`
+		Results []struct {
+			UID    string `json:"uid"`
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Error  string `json:"error,omitempty"`
+		} `json:"grafana_managed_receiver_configs"`
+	} `json:"receivers"`
+}
+
+// GetReceivers retrieves the contact points from the Grafana Alertmanager configuration
+func (c *Client) GetReceivers(ctx context.Context) ([]Receiver, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/alertmanager/grafana/config/api/v1/alerts", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Config struct {
+			Receivers []Receiver `json:"receivers"`
+		} `json:"alertmanager_config"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result.Config.Receivers, nil
+}
+
+// TestReceivers sends a test notification through each integration of the
+// given receivers. Integrations with a UID reuse their stored secure settings.
+func (c *Client) TestReceivers(ctx context.Context, receivers []Receiver, alert *TestAlert) (*ReceiverTestResult, error) {
+	body := map[string]interface{}{"receivers": receivers}
+	if alert != nil {
+		body["alert"] = alert
+	}
+
+	resp, err := c.doRequest(ctx, "POST", "/api/alertmanager/grafana/config/api/v1/receivers/test", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ReceiverTestResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// Route is a node in the notification policy tree
+type Route struct {
+	Receiver            string            `json:"receiver,omitempty"`
+	GroupBy             []string          `json:"group_by,omitempty"`
+	ObjectMatchers      [][]string        `json:"object_matchers,omitempty"`
+	Matchers            []string          `json:"matchers,omitempty"`
+	Match               map[string]string `json:"match,omitempty"`
+	MatchRE             map[string]string `json:"match_re,omitempty"`
+	Continue            bool              `json:"continue,omitempty"`
+	GroupWait           string            `json:"group_wait,omitempty"`
+	GroupInterval       string            `json:"group_interval,omitempty"`
+	RepeatInterval      string            `json:"repeat_interval,omitempty"`
+	MuteTimeIntervals   []string          `json:"mute_time_intervals,omitempty"`
+	ActiveTimeIntervals []string          `json:"active_time_intervals,omitempty"`
+	Routes              []*Route          `json:"routes,omitempty"`
+	Provenance          string            `json:"provenance,omitempty"`
+}
+
+// MuteTiming is a named set of time intervals used to mute or activate policies
+type MuteTiming struct {
+	Name          string         `json:"name"`
+	TimeIntervals []TimeInterval `json:"time_intervals"`
+	Provenance    string         `json:"provenance,omitempty"`
+}
+
+// TimeInterval restricts a mute timing to times of day, weekdays, days of
+// month, months, and years. Ranges use "start:end" notation.
+type TimeInterval struct {
+	Times       []TimeOfDayRange `json:"times,omitempty"`
+	Weekdays    []string         `json:"weekdays,omitempty"`
+	DaysOfMonth []string         `json:"days_of_month,omitempty"`
+	Months      []string         `json:"months,omitempty"`
+	Years       []string         `json:"years,omitempty"`
+	Location    string           `json:"location,omitempty"`
+}
+
+// TimeOfDayRange is a time of day range in HH:MM format
+type TimeOfDayRange struct {
+	StartTime string `json:"start_time"`
+	EndTime   string `json:"end_time"`
+}
+
+// GetNotificationPolicyTree retrieves the root notification policy
+func (c *Client) GetNotificationPolicyTree(ctx context.Context) (*Route, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/provisioning/policies", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Route
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// UpdateNotificationPolicyTree replaces the notification policy tree. The
+// tree is not marked as provisioned, so it stays editable in the UI.
+func (c *Client) UpdateNotificationPolicyTree(ctx context.Context, root Route) error {
+	req, err := c.newRequest(ctx, "PUT", "/api/v1/provisioning/policies", root)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Disable-Provenance", "true")
+	_, err = c.execute(req)
+	return err
+}
+
+// GetMuteTimings retrieves all mute timings
+func (c *Client) GetMuteTimings(ctx context.Context) ([]MuteTiming, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/v1/provisioning/mute-timings", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []MuteTiming
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return results, nil
+}
+
+// ContactPoint is one integration of a contact point as the provisioning
+// API returns it. Integrations sharing a name form one contact point.
+// Secure settings are returned as "[REDACTED]"; sending that value back in
+// an update keeps the stored secret.
+type ContactPoint struct {
+	UID                   string                 `json:"uid,omitempty"`
+	Name                  string                 `json:"name"`
+	Type                  string                 `json:"type"`
+	Settings              map[string]interface{} `json:"settings"`
+	DisableResolveMessage bool                   `json:"disableResolveMessage"`
+	Provenance            string                 `json:"provenance,omitempty"`
+}
+
+// GetContactPoints retrieves the contact point integrations, only those of
+// the named contact point when name is set
+func (c *Client) GetContactPoints(ctx context.Context, name string) ([]ContactPoint, error) {
+	path := "/api/v1/provisioning/contact-points"
+	if name != "" {
+		path += "?name=" + url.QueryEscape(name)
+	}
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []ContactPoint
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return results, nil
+}
+
+// CreateContactPoint creates a contact point integration. It is not marked
+// as provisioned, so it stays editable in the UI.
+func (c *Client) CreateContactPoint(ctx context.Context, cp ContactPoint) (*ContactPoint, error) {
+	req, err := c.newRequest(ctx, "POST", "/api/v1/provisioning/contact-points", cp)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Disable-Provenance", "true")
+
+	resp, err := c.execute(req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result ContactPoint
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// UpdateContactPoint replaces a contact point integration, keeping it
+// editable in the UI
+func (c *Client) UpdateContactPoint(ctx context.Context, uid string, cp ContactPoint) error {
+	req, err := c.newRequest(ctx, "PUT", "/api/v1/provisioning/contact-points/"+url.PathEscape(uid), cp)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Disable-Provenance", "true")
+	_, err = c.execute(req)
+	return err
+}
+
+// DeleteContactPoint deletes a contact point integration. Grafana refuses
+// to delete the last integration of a contact point that notification
+// policies still route to.
+func (c *Client) DeleteContactPoint(ctx context.Context, uid string) error {
+	_, err := c.doRequest(ctx, "DELETE", "/api/v1/provisioning/contact-points/"+url.PathEscape(uid), nil)
+	return err
+}
+
+// AlertmanagerAlert is an alert instance as the Grafana Alertmanager holds
+// it. Alerts of Grafana-managed rules carry the rule's UID in the
+// __alert_rule_uid__ label.
+type AlertmanagerAlert struct {
+	Fingerprint  string            `json:"fingerprint"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+	GeneratorURL string            `json:"generatorURL,omitempty"`
+	Status       struct {
+		State       string   `json:"state"`
+		SilencedBy  []string `json:"silencedBy"`
+		InhibitedBy []string `json:"inhibitedBy"`
+	} `json:"status"`
+	Receivers []struct {
+		Name string `json:"name"`
+	} `json:"receivers"`
+}
+
+// GetFiringAlerts retrieves the alerts the Grafana Alertmanager is currently
+// firing, leaving out silenced and inhibited ones
+func (c *Client) GetFiringAlerts(ctx context.Context) ([]AlertmanagerAlert, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/alertmanager/grafana/api/v2/alerts?active=true&silenced=false&inhibited=false", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []AlertmanagerAlert
+	if err := json.Unmarshal(resp, &alerts); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return alerts, nil
+}
@@ -0,0 +1,202 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============== OAuth 2.0 Client Credentials ==============
+
+// oauthRefreshMargin is how long before expiry a token is replaced, so a
+// request rarely carries one that lapses in flight
+const oauthRefreshMargin = time.Minute
+
+// oauthDefaultLifetime is assumed for tokens issued without expires_in
+const oauthDefaultLifetime = 5 * time.Minute
+
+// OAuthConfig is an OAuth 2.0 client allowed the client credentials grant
+// by the identity provider in front of Grafana
+type OAuthConfig struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Audience is sent as the audience parameter some providers require
+	Audience string
+	// Header carries the access token, "Authorization" when empty. Set it
+	// to e.g. "Proxy-Authorization" when a proxy checks the OAuth token and
+	// Grafana still expects its own credentials in Authorization.
+	Header string
+}
+
+// oauthSource fetches access tokens and reuses each until shortly before
+// it expires
+type oauthSource struct {
+	cfg        OAuthConfig
+	httpClient *http.Client
+
+	mu    sync.Mutex
+	token string
+	// refreshAt is when the token is replaced, ahead of its expiry
+	refreshAt time.Time
+	// inBody is set once the provider refused credentials in the
+	// Authorization header and accepted them as form parameters
+	inBody bool
+}
+
+// NewOAuthClient creates a Grafana client that authenticates with access
+// tokens obtained through the OAuth 2.0 client credentials grant. Tokens are
+// refreshed before they expire, and a request refused with 401 is retried
+// once with a new token.
+func NewOAuthClient(baseURL string, cfg OAuthConfig) *Client {
+	return NewOAuthClientWithAPIKey(baseURL, "", cfg)
+}
+
+// NewOAuthClientWithAPIKey is NewOAuthClient for a proxy in front of
+// Grafana: the access token goes in cfg.Header and apiKey authenticates to
+// Grafana itself
+func NewOAuthClientWithAPIKey(baseURL, apiKey string, cfg OAuthConfig) *Client {
+	if cfg.Header == "" {
+		cfg.Header = "Authorization"
+	}
+	c := NewClient(baseURL, apiKey)
+	c.oauth = &oauthSource{cfg: cfg, httpClient: &http.Client{Timeout: 30 * time.Second}}
+	return c
+}
+
+// current returns the cached token, fetching a new one when it is due for
+// refresh
+func (s *oauthSource) current(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token != "" && time.Now().Before(s.refreshAt) {
+		return s.token, nil
+	}
+	token, lifetime, err := s.fetch(ctx)
+	if err != nil {
+		return "", err
+	}
+	// Short-lived tokens are kept for half their lifetime
+	keep := lifetime - oauthRefreshMargin
+	if keep < lifetime/2 {
+		keep = lifetime / 2
+	}
+	s.token, s.refreshAt = token, time.Now().Add(keep)
+	return token, nil
+}
+
+// invalidate drops token so the next request fetches a new one. A token
+// already replaced by a concurrent request is left alone.
+func (s *oauthSource) invalidate(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.token == token {
+		s.token = ""
+	}
+}
+
+// fetch requests a token, sending the client credentials in the
+// Authorization header as RFC 6749 recommends and falling back to form
+// parameters for providers that accept only those
+func (s *oauthSource) fetch(ctx context.Context) (string, time.Duration, error) {
+	if !s.inBody {
+		token, lifetime, err := s.request(ctx, false)
+		var oerr *oauthError
+		if !errors.As(err, &oerr) || oerr.Code != "invalid_client" && oerr.status != http.StatusUnauthorized {
+			return token, lifetime, err
+		}
+	}
+	token, lifetime, err := s.request(ctx, true)
+	if err == nil {
+		s.inBody = true
+	}
+	return token, lifetime, err
+}
+
+func (s *oauthSource) request(ctx context.Context, inBody bool) (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(s.cfg.Scopes) > 0 {
+		form.Set("scope", strings.Join(s.cfg.Scopes, " "))
+	}
+	if s.cfg.Audience != "" {
+		form.Set("audience", s.cfg.Audience)
+	}
+	if inBody {
+		form.Set("client_id", s.cfg.ClientID)
+		form.Set("client_secret", s.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to create token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if !inBody {
+		req.SetBasicAuth(url.QueryEscape(s.cfg.ClientID), url.QueryEscape(s.cfg.ClientSecret))
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", 0, fmt.Errorf("token request abandoned: %w", ctxErr)
+		}
+		return "", 0, fmt.Errorf("token request to %s failed: %w", s.cfg.TokenURL, err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to read token response: %w", err)
+	}
+
+	var result struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int64  `json:"expires_in"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil && resp.StatusCode < 400 {
+		return "", 0, fmt.Errorf("failed to unmarshal token response: %w", err)
+	}
+	if resp.StatusCode >= 400 || result.AccessToken == "" {
+		oerr := &oauthError{Code: result.Error, Description: result.ErrorDescription, status: resp.StatusCode}
+		if oerr.Code == "" && oerr.Description == "" {
+			oerr.Description = errorDetail(body)
+		} else {
+			oerr.Description = errorDetail([]byte(oerr.Description))
+		}
+		return "", 0, oerr
+	}
+
+	lifetime := oauthDefaultLifetime
+	if result.ExpiresIn > 0 {
+		lifetime = time.Duration(result.ExpiresIn) * time.Second
+	}
+	return result.AccessToken, lifetime, nil
+}
+
+// oauthError is an error response from the token endpoint
+type oauthError struct {
+	Code        string
+	Description string
+	status      int
+}
+
+func (e *oauthError) Error() string {
+	msg := fmt.Sprintf("token endpoint refused the client (status %d)", e.status)
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Description != "" {
+		msg += ": " + e.Description
+	}
+	return msg
+}
@@ -0,0 +1,50 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+)
+
+// ============== Plugin Operations ==============
+
+// Plugin is an installed plugin as listed by /api/plugins
+type Plugin struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Type      string     `json:"type"`
+	Category  string     `json:"category,omitempty"`
+	Enabled   bool       `json:"enabled"`
+	State     string     `json:"state,omitempty"`
+	Signature string     `json:"signature,omitempty"`
+	Info      PluginInfo `json:"info"`
+}
+
+// PluginInfo is the descriptive metadata of a plugin
+type PluginInfo struct {
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Author      struct {
+		Name string `json:"name,omitempty"`
+	} `json:"author"`
+}
+
+// ListPlugins returns the installed plugins of a type ("datasource", "panel",
+// "app"), or of every type when pluginType is empty
+func (c *Client) ListPlugins(ctx context.Context, pluginType string) ([]Plugin, error) {
+	path := "/api/plugins"
+	if pluginType != "" {
+		path += "?" + url.Values{"type": {pluginType}}.Encode()
+	}
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []Plugin
+	if err := json.Unmarshal(resp, &plugins); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return plugins, nil
+}
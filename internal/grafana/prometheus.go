@@ -0,0 +1,213 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// PrometheusRule is a recording or alerting rule as reported by the
+// Prometheus-compatible /api/v1/rules endpoint
+type PrometheusRule struct {
+	Name        string            `json:"name"`
+	Query       string            `json:"query"`
+	Type        string            `json:"type"`
+	Duration    float64           `json:"duration,omitempty"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Health      string            `json:"health,omitempty"`
+	State       string            `json:"state,omitempty"`
+	LastError   string            `json:"lastError,omitempty"`
+}
+
+// PrometheusRuleGroup is a rule group from a Prometheus rules file
+type PrometheusRuleGroup struct {
+	Name     string           `json:"name"`
+	File     string           `json:"file"`
+	Interval float64          `json:"interval,omitempty"`
+	Rules    []PrometheusRule `json:"rules"`
+}
+
+// GetPrometheusRules lists the rule groups loaded by a Prometheus-compatible
+// datasource (Prometheus, Mimir, Cortex, Thanos) through the datasource proxy
+func (c *Client) GetPrometheusRules(ctx context.Context, datasourceUID string) ([]PrometheusRuleGroup, error) {
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "api/v1/rules", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string `json:"status"`
+		Error  string `json:"error,omitempty"`
+		Data   struct {
+			Groups []PrometheusRuleGroup `json:"groups"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Status == "error" {
+		return nil, fmt.Errorf("prometheus error: %s", result.Error)
+	}
+
+	return result.Data.Groups, nil
+}
+
+// GetPrometheusLabelValues lists the values of a label, optionally limited to
+// series matching the given selectors, through the datasource proxy
+func (c *Client) GetPrometheusLabelValues(ctx context.Context, datasourceUID, label string, matches []string) ([]string, error) {
+	params := url.Values{}
+	for _, m := range matches {
+		params.Add("match[]", m)
+	}
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "api/v1/label/"+url.PathEscape(label)+"/values", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string   `json:"status"`
+		Error  string   `json:"error,omitempty"`
+		Data   []string `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Status == "error" {
+		return nil, fmt.Errorf("prometheus error: %s", result.Error)
+	}
+
+	return result.Data, nil
+}
+
+// CountPrometheusSeries counts the series matching a selector between start
+// and end through the datasource proxy. When limit is positive at most limit
+// series are requested; servers that honor the limit parameter (Prometheus
+// 2.49+) then stop early, so a count equal to limit means "at least".
+func (c *Client) CountPrometheusSeries(ctx context.Context, datasourceUID, selector string, start, end time.Time, limit int) (int, error) {
+	params := url.Values{}
+	params.Set("match[]", selector)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "api/v1/series", params)
+	if err != nil {
+		return 0, err
+	}
+
+	var result struct {
+		Status string            `json:"status"`
+		Error  string            `json:"error,omitempty"`
+		Data   []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Status == "error" {
+		return 0, fmt.Errorf("prometheus error: %s", result.Error)
+	}
+
+	n := len(result.Data)
+	if limit > 0 && n > limit {
+		n = limit
+	}
+	return n, nil
+}
+
+// PrometheusTarget is a scrape target as reported by /api/v1/targets
+type PrometheusTarget struct {
+	DiscoveredLabels   map[string]string `json:"discoveredLabels,omitempty"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	ScrapePool         string            `json:"scrapePool,omitempty"`
+	ScrapeURL          string            `json:"scrapeUrl,omitempty"`
+	GlobalURL          string            `json:"globalUrl,omitempty"`
+	LastError          string            `json:"lastError,omitempty"`
+	LastScrape         string            `json:"lastScrape,omitempty"`
+	LastScrapeDuration float64           `json:"lastScrapeDuration,omitempty"`
+	Health             string            `json:"health,omitempty"`
+	ScrapeInterval     string            `json:"scrapeInterval,omitempty"`
+	ScrapeTimeout      string            `json:"scrapeTimeout,omitempty"`
+}
+
+// PrometheusTargets are the active and dropped targets of a Prometheus
+type PrometheusTargets struct {
+	Active  []PrometheusTarget `json:"activeTargets"`
+	Dropped []PrometheusTarget `json:"droppedTargets"`
+}
+
+// GetPrometheusTargets lists the scrape targets of a Prometheus datasource
+// through the datasource proxy. state is "active", "dropped", or "" for both.
+// Backends that do not scrape, such as Mimir, Cortex, and Thanos Query,
+// answer 404.
+func (c *Client) GetPrometheusTargets(ctx context.Context, datasourceUID, state string) (*PrometheusTargets, error) {
+	params := url.Values{}
+	if state != "" {
+		params.Set("state", state)
+	}
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "api/v1/targets", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string            `json:"status"`
+		Error  string            `json:"error,omitempty"`
+		Data   PrometheusTargets `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Status == "error" {
+		return nil, fmt.Errorf("prometheus error: %s", result.Error)
+	}
+
+	return &result.Data, nil
+}
+
+// PrometheusTargetMetadata is the metadata a target reports for one metric
+type PrometheusTargetMetadata struct {
+	Target map[string]string `json:"target"`
+	Metric string            `json:"metric,omitempty"`
+	Type   string            `json:"type,omitempty"`
+	Help   string            `json:"help,omitempty"`
+	Unit   string            `json:"unit,omitempty"`
+}
+
+// GetPrometheusTargetMetadata lists the metric metadata scraped from the
+// targets matching matchTarget (a label selector, "" for all), optionally
+// for one metric, through the datasource proxy
+func (c *Client) GetPrometheusTargetMetadata(ctx context.Context, datasourceUID, matchTarget, metric string, limit int) ([]PrometheusTargetMetadata, error) {
+	params := url.Values{}
+	if matchTarget != "" {
+		params.Set("match_target", matchTarget)
+	}
+	if metric != "" {
+		params.Set("metric", metric)
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "api/v1/targets/metadata", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Status string                     `json:"status"`
+		Error  string                     `json:"error,omitempty"`
+		Data   []PrometheusTargetMetadata `json:"data"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Status == "error" {
+		return nil, fmt.Errorf("prometheus error: %s", result.Error)
+	}
+
+	return result.Data, nil
+}
@@ -0,0 +1,206 @@
+package grafana
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/cache"
+)
+
+// ============== Response Cache ==============
+
+// Cached endpoint families
+const (
+	cacheFolders     = "folders"
+	cacheDatasources = "datasources"
+	cacheDashboards  = "dashboards"
+)
+
+// ResponseCacheSettings sets how long GET responses of each endpoint family
+// are reused. A TTL of 0 leaves that family uncached. Any other request
+// through the client, such as a save or delete, drops every cached response.
+type ResponseCacheSettings struct {
+	// Folders covers folder listings, lookups, and permissions
+	Folders time.Duration
+	// Datasources covers datasource listings and lookups, not queries
+	Datasources time.Duration
+	// Dashboards covers searches and dashboards fetched by UID
+	Dashboards time.Duration
+	// Size is the most responses kept per family
+	Size int
+}
+
+// DefaultResponseCacheSettings returns the settings used when none are
+// configured
+func DefaultResponseCacheSettings() ResponseCacheSettings {
+	return ResponseCacheSettings{
+		Folders:     30 * time.Second,
+		Datasources: time.Minute,
+		Dashboards:  15 * time.Second,
+		Size:        256,
+	}
+}
+
+// responseCache holds GET responses by family
+type responseCache struct {
+	families map[string]*cache.Cache[[]byte]
+
+	mu sync.Mutex
+	// generation counts invalidations, so a read that raced a write does
+	// not store what it fetched before the write
+	generation int
+}
+
+type uncachedKey struct{}
+
+// Uncached returns a context whose requests bypass cached responses. The
+// fresh responses are still stored for later reads.
+func Uncached(ctx context.Context) context.Context {
+	return context.WithValue(ctx, uncachedKey{}, true)
+}
+
+// SetResponseCache enables reuse of recent GET responses for the endpoint
+// families s gives a TTL, replacing any earlier cache
+func (c *Client) SetResponseCache(s ResponseCacheSettings) {
+	rc := &responseCache{families: map[string]*cache.Cache[[]byte]{}}
+	for family, ttl := range map[string]time.Duration{
+		cacheFolders:     s.Folders,
+		cacheDatasources: s.Datasources,
+		cacheDashboards:  s.Dashboards,
+	} {
+		if ttl > 0 {
+			rc.families[family] = cache.New[[]byte](s.Size, ttl)
+		}
+	}
+	if len(rc.families) == 0 {
+		rc = nil
+	}
+	c.cacheMu.Lock()
+	c.responses = rc
+	c.cacheMu.Unlock()
+}
+
+// InvalidateResponseCache drops every cached response
+func (c *Client) InvalidateResponseCache() {
+	if rc := c.responseCache(); rc != nil {
+		rc.invalidate()
+	}
+}
+
+// ResponseCacheStats reports usage of each cached family
+func (c *Client) ResponseCacheStats() map[string]cache.Stats {
+	rc := c.responseCache()
+	if rc == nil {
+		return nil
+	}
+	out := make(map[string]cache.Stats, len(rc.families))
+	for family, fc := range rc.families {
+		out[family] = fc.Stats()
+	}
+	return out
+}
+
+func (c *Client) responseCache() *responseCache {
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+	return c.responses
+}
+
+// execute sends a request to the client's organization through the
+// response cache: GETs of cached families are answered from it, and any
+// other request drops it
+func (c *Client) execute(req *http.Request) ([]byte, error) {
+	c.setOrgHeader(req.Context(), req.Header)
+	rc := c.responseCache()
+	if rc == nil {
+		return c.sendAuthenticated(req)
+	}
+	// Grafana may be served under a sub path of the host
+	path := strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(c.basePath(), "/"))
+	if req.Method != http.MethodGet {
+		body, err := c.sendAuthenticated(req)
+		if !readOnlyPost(req.Method, path) {
+			rc.invalidate()
+		}
+		return body, err
+	}
+
+	fc := rc.families[cacheFamily(path)]
+	if fc == nil {
+		return c.sendAuthenticated(req)
+	}
+	key := req.URL.RequestURI() + "|" + req.Header.Get("X-Grafana-Org-Id")
+	if uncached, _ := req.Context().Value(uncachedKey{}).(bool); !uncached {
+		if body, ok := fc.Get(key); ok {
+			return body, nil
+		}
+	}
+	rc.mu.Lock()
+	gen := rc.generation
+	rc.mu.Unlock()
+	body, err := c.sendAuthenticated(req)
+	if err != nil {
+		return nil, err
+	}
+	rc.mu.Lock()
+	if rc.generation == gen {
+		fc.Set(key, body)
+	}
+	rc.mu.Unlock()
+	return body, nil
+}
+
+func (rc *responseCache) invalidate() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.generation++
+	for _, fc := range rc.families {
+		fc.Purge()
+	}
+}
+
+// cacheFamily returns the cached family a GET path belongs to, or "".
+// Datasource proxy and resource calls are queries, so they are not cached.
+func cacheFamily(path string) string {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "api" {
+		return ""
+	}
+	switch parts[1] {
+	case "folders":
+		return cacheFolders
+	case "search":
+		return cacheDashboards
+	case "dashboards":
+		// /api/dashboards/uid/<uid>
+		if len(parts) == 4 && parts[2] == "uid" {
+			return cacheDashboards
+		}
+	case "datasources":
+		// /api/datasources, /api/datasources/<id>, and
+		// /api/datasources/{uid,name}/<key>
+		if len(parts) <= 3 || len(parts) == 4 && (parts[2] == "uid" || parts[2] == "name") {
+			return cacheDatasources
+		}
+	}
+	return ""
+}
+
+// readOnlyPost reports whether a non-GET request only reads, so it leaves
+// cached responses valid
+func readOnlyPost(method, path string) bool {
+	return method == http.MethodPost && path == "/api/ds/query"
+}
+
+// basePath returns the path part of the client's base URL
+func (c *Client) basePath() string {
+	u, err := url.Parse(c.baseURL)
+	if err != nil {
+		return ""
+	}
+	return u.Path
+}
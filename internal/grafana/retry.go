@@ -0,0 +1,145 @@
+package grafana
+
+import (
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============== Retries ==============
+
+// RetryPolicy controls how requests that failed transiently are retried:
+// rate limiting (429) for any method, and gateway errors (502, 503, 504) and
+// dropped connections for the methods that are safe to repeat. Writes that
+// create resources are not repeated after a gateway error, as Grafana may
+// have applied them.
+type RetryPolicy struct {
+	// MaxAttempts is the most attempts per request; 1 disables retries
+	MaxAttempts int
+	// InitialBackoff is the wait before the first retry, doubled for each
+	// retry after it
+	InitialBackoff time.Duration
+	// MaxBackoff caps each wait. A Retry-After longer than it ends the
+	// retries, so callers learn of a long outage promptly.
+	MaxBackoff time.Duration
+	// Jitter is the fraction of each wait, from 0 to 1, randomly taken off
+	// it so clients do not retry in step
+	Jitter float64
+}
+
+// DefaultRetryPolicy returns the policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     10 * time.Second,
+		Jitter:         0.5,
+	}
+}
+
+// SetRetryPolicy replaces the client's retry policy
+func (c *Client) SetRetryPolicy(p RetryPolicy) {
+	if p.MaxAttempts < 1 {
+		p.MaxAttempts = 1
+	}
+	if p.Jitter < 0 {
+		p.Jitter = 0
+	}
+	if p.Jitter > 1 {
+		p.Jitter = 1
+	}
+	c.retry = p
+}
+
+// sendWithRetries sends req, retrying transient failures under the retry
+// policy. Waits end early when the request's context is done.
+func (c *Client) sendWithRetries(req *http.Request) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		respBody, retryAfter, err := c.send(req)
+		if err == nil || retryAfter < 0 || attempt >= c.retry.MaxAttempts {
+			return respBody, err
+		}
+		wait := c.retry.backoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		if wait > c.retry.MaxBackoff && c.retry.MaxBackoff > 0 {
+			return respBody, err
+		}
+		next, ok := rewind(req)
+		if !ok {
+			return respBody, err
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, fmt.Errorf("request to Grafana abandoned: %w", req.Context().Err())
+		case <-timer.C:
+		}
+		req = next
+	}
+}
+
+// backoff returns the wait before retry number attempt, with jitter
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || wait < p.MaxBackoff); i++ {
+		wait *= 2
+	}
+	if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+		wait = p.MaxBackoff
+	}
+	return wait - time.Duration(p.Jitter*rand.Float64()*float64(wait))
+}
+
+// rewind returns a copy of req ready to send again, or false when its body
+// cannot be read a second time
+func rewind(req *http.Request) (*http.Request, bool) {
+	next := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return next, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	next.Body = body
+	return next, true
+}
+
+// retryableMethod returns wait when req may be repeated after a failure
+// that Grafana may have acted on, else -1
+func retryableMethod(req *http.Request, wait time.Duration) time.Duration {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return wait
+	}
+	return -1
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date, returning 0 when it is absent or invalid
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,64 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ============== Settings Operations ==============
+
+// BuildInfo describes the running Grafana build
+type BuildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit"`
+	Edition string `json:"edition"`
+	Env     string `json:"env"`
+}
+
+// FrontendSettings is the subset of /api/frontend/settings used to detect
+// optional features. Settings missing from older versions decode as nil.
+type FrontendSettings struct {
+	BuildInfo               BuildInfo       `json:"buildInfo"`
+	FeatureToggles          map[string]bool `json:"featureToggles"`
+	RendererAvailable       *bool           `json:"rendererAvailable"`
+	UnifiedAlertingEnabled  *bool           `json:"unifiedAlertingEnabled"`
+	PublicDashboardsEnabled *bool           `json:"publicDashboardsEnabled"`
+	AnonymousEnabled        bool            `json:"anonymousEnabled"`
+	AppSubURL               string          `json:"appSubUrl"`
+}
+
+// Features flattens feature toggles and known boolean settings into one map.
+// Keys absent from the map are unknown rather than disabled.
+func (s *FrontendSettings) Features() map[string]bool {
+	features := make(map[string]bool, len(s.FeatureToggles)+3)
+	for k, v := range s.FeatureToggles {
+		features[k] = v
+	}
+	for name, v := range map[string]*bool{
+		"rendererAvailable":       s.RendererAvailable,
+		"unifiedAlertingEnabled":  s.UnifiedAlertingEnabled,
+		"publicDashboardsEnabled": s.PublicDashboardsEnabled,
+	} {
+		if v != nil {
+			features[name] = *v
+		}
+	}
+	return features
+}
+
+// GetFrontendSettings retrieves the settings Grafana exposes to its frontend,
+// including feature toggles and build information
+func (c *Client) GetFrontendSettings(ctx context.Context) (*FrontendSettings, error) {
+	resp, err := c.doRequest(ctx, "GET", "/api/frontend/settings", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var result FrontendSettings
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,42 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// ============== Snapshot Operations ==============
+
+// SnapshotRequest creates a dashboard snapshot from dashboard JSON that
+// already carries its data
+type SnapshotRequest struct {
+	Dashboard map[string]interface{} `json:"dashboard"`
+	Name      string                 `json:"name,omitempty"`
+	// Expires is the lifetime in seconds; 0 keeps the snapshot forever
+	Expires int64 `json:"expires,omitempty"`
+}
+
+// Snapshot is the result of creating a dashboard snapshot
+type Snapshot struct {
+	ID        int64  `json:"id"`
+	Key       string `json:"key"`
+	DeleteKey string `json:"deleteKey"`
+	URL       string `json:"url"`
+	DeleteURL string `json:"deleteUrl"`
+}
+
+// CreateSnapshot stores a local dashboard snapshot
+func (c *Client) CreateSnapshot(ctx context.Context, req SnapshotRequest) (*Snapshot, error) {
+	resp, err := c.doRequest(ctx, "POST", "/api/snapshots", req)
+	if err != nil {
+		return nil, err
+	}
+
+	var result Snapshot
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,46 @@
+package grafana
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ============== Tempo Operations ==============
+
+// TempoTrace is a trace summary returned by Tempo search
+type TempoTrace struct {
+	TraceID           string `json:"traceID"`
+	RootServiceName   string `json:"rootServiceName,omitempty"`
+	RootTraceName     string `json:"rootTraceName,omitempty"`
+	StartTimeUnixNano string `json:"startTimeUnixNano,omitempty"`
+	DurationMs        int64  `json:"durationMs,omitempty"`
+}
+
+// SearchTempo runs a TraceQL search through the datasource proxy. Invalid
+// queries fail with an *APIError with status 400 carrying Tempo's parse error.
+func (c *Client) SearchTempo(ctx context.Context, datasourceUID, query string, start, end time.Time, limit int) ([]TempoTrace, error) {
+	params := url.Values{
+		"q":     {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+	}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	resp, err := c.DatasourceProxyGet(ctx, datasourceUID, "api/search", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result struct {
+		Traces []TempoTrace `json:"traces"`
+	}
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return result.Traces, nil
+}
@@ -0,0 +1,68 @@
+package grafana
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ============== Version Operations ==============
+
+// Version is a Grafana release version. Pre-release and build suffixes
+// (e.g. 11.0.0-preview, 10.4.1+security-01) are ignored for comparison.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+	Raw   string
+}
+
+// ParseVersion parses a version such as "10.4.1", "v9.5" or "11.0.0-preview"
+func ParseVersion(s string) (Version, error) {
+	v := Version{Raw: s}
+	core := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexAny(core, "-+ "); i >= 0 {
+		core = core[:i]
+	}
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 || parts[0] == "" {
+		return v, fmt.Errorf("invalid Grafana version %q", s)
+	}
+	nums := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			return v, fmt.Errorf("invalid Grafana version %q", s)
+		}
+		*nums[i] = n
+	}
+	return v, nil
+}
+
+// AtLeast reports whether v is the same as or newer than min
+func (v Version) AtLeast(min Version) bool {
+	if v.Major != min.Major {
+		return v.Major > min.Major
+	}
+	if v.Minor != min.Minor {
+		return v.Minor > min.Minor
+	}
+	return v.Patch >= min.Patch
+}
+
+func (v Version) String() string {
+	if v.Raw != "" {
+		return v.Raw
+	}
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// GetVersion detects the Grafana version from the health endpoint
+func (c *Client) GetVersion(ctx context.Context) (Version, error) {
+	health, err := c.GetHealth(ctx)
+	if err != nil {
+		return Version{}, err
+	}
+	return ParseVersion(health.Version)
+}
@@ -0,0 +1,51 @@
+package grafana
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// ============== Dashboard Version Operations ==============
+
+// DashboardVersion is one saved revision of a dashboard
+type DashboardVersion struct {
+	ID            int64  `json:"id"`
+	Version       int    `json:"version"`
+	ParentVersion int    `json:"parentVersion,omitempty"`
+	Created       string `json:"created"`
+	CreatedBy     string `json:"createdBy"`
+	Message       string `json:"message,omitempty"`
+}
+
+// GetDashboardVersions lists a dashboard's revisions, newest first. limit 0
+// uses Grafana's default.
+func (c *Client) GetDashboardVersions(ctx context.Context, uid string, limit int) ([]DashboardVersion, error) {
+	path := "/api/dashboards/uid/" + url.PathEscape(uid) + "/versions"
+	if limit > 0 {
+		path += "?" + url.Values{"limit": {strconv.Itoa(limit)}}.Encode()
+	}
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Grafana 11 wraps the list in an object with a continue token
+	var versions []DashboardVersion
+	if trimmed := bytes.TrimSpace(resp); len(trimmed) > 0 && trimmed[0] == '{' {
+		var page struct {
+			Versions []DashboardVersion `json:"versions"`
+		}
+		err = json.Unmarshal(resp, &page)
+		versions = page.Versions
+	} else {
+		err = json.Unmarshal(resp, &versions)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return versions, nil
+}
@@ -0,0 +1,61 @@
+// Package keyring stores secrets in the operating system's credential
+// store: the login keychain on macOS, the Credential Manager on Windows,
+// and the Secret Service (GNOME Keyring, KWallet) elsewhere, so tokens
+// need not sit in plaintext configuration files. It uses only the
+// standard library, calling the platform's own tools or APIs.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Service is the service name the server's credentials are stored under
+const Service = "grafana-mcp"
+
+// ErrNotFound is returned when the keyring holds no secret for an account
+var ErrNotFound = errors.New("no secret in the keyring")
+
+// ErrUnavailable is returned when the platform has no keyring to use, such
+// as a container without a Secret Service
+var ErrUnavailable = errors.New("no OS keyring is available")
+
+// Get returns the secret stored for service and account
+func Get(service, account string) (string, error) {
+	if err := checkName(service, account); err != nil {
+		return "", err
+	}
+	return get(service, account)
+}
+
+// Set stores secret for service and account, replacing any previous one
+func Set(service, account, secret string) error {
+	if err := checkName(service, account); err != nil {
+		return err
+	}
+	if secret == "" {
+		return errors.New("refusing to store an empty secret")
+	}
+	return set(service, account, secret)
+}
+
+// Delete removes the secret stored for service and account. It returns
+// ErrNotFound when there is none.
+func Delete(service, account string) error {
+	if err := checkName(service, account); err != nil {
+		return err
+	}
+	return del(service, account)
+}
+
+// checkName rejects names the platform tools could not pass through
+// unchanged
+func checkName(service, account string) error {
+	for _, s := range []string{service, account} {
+		if s == "" || strings.ContainsAny(s, "\"\\\n\r\x00") {
+			return fmt.Errorf("invalid keyring name %q", s)
+		}
+	}
+	return nil
+}
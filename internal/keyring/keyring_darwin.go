@@ -0,0 +1,52 @@
+package keyring
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// errSecItemNotFound is the exit status of security(1) for a missing item
+const errSecItemNotFound = 44
+
+func get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// set passes the command on security's stdin, hex encoded, so the secret
+// never appears in a process listing
+func set(service, account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s \"%s\" -a \"%s\" -X \"%s\"\n", service, account, hex.EncodeToString([]byte(secret))))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("keychain: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+func del(service, account string) error {
+	if err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run(); err != nil {
+		return securityError(err)
+	}
+	return nil
+}
+
+func securityError(err error) error {
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if exitErr.ExitCode() == errSecItemNotFound {
+			return ErrNotFound
+		}
+		return fmt.Errorf("keychain: %s", strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return fmt.Errorf("keychain: %w", err)
+}
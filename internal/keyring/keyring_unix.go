@@ -0,0 +1,61 @@
+//go:build !darwin && !windows
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// The Secret Service is reached through secret-tool, from libsecret
+// (libsecret-tools on Debian and Ubuntu)
+
+func get(service, account string) (string, error) {
+	out, err := secretTool(nil, "lookup", "service", service, "account", account)
+	if err != nil {
+		return "", err
+	}
+	if len(out) == 0 {
+		return "", ErrNotFound
+	}
+	return string(out), nil
+}
+
+// set passes the secret on stdin, so it never appears in a process listing
+func set(service, account, secret string) error {
+	_, err := secretTool(strings.NewReader(secret), "store", "--label="+service+" "+account, "service", service, "account", account)
+	return err
+}
+
+func del(service, account string) error {
+	if _, err := get(service, account); err != nil {
+		return err
+	}
+	_, err := secretTool(nil, "clear", "service", service, "account", account)
+	return err
+}
+
+func secretTool(stdin *strings.Reader, args ...string) ([]byte, error) {
+	path, err := exec.LookPath("secret-tool")
+	if err != nil {
+		return nil, fmt.Errorf("%w: secret-tool not found; install libsecret-tools (or your distribution's libsecret package) and run a Secret Service such as GNOME Keyring or KWallet", ErrUnavailable)
+	}
+	cmd := exec.Command(path, args...)
+	if stdin != nil {
+		cmd.Stdin = stdin
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		// lookup exits 1 without output when nothing matches
+		if errors.As(err, &exitErr) && args[0] == "lookup" && stderr.Len() == 0 {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("secret service: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
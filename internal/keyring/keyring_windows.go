@@ -0,0 +1,103 @@
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"syscall"
+	"unsafe"
+)
+
+// Secrets are generic credentials in the Windows Credential Manager, named
+// service:account
+
+var (
+	advapi32        = syscall.NewLazyDLL("advapi32.dll")
+	procCredReadW   = advapi32.NewProc("CredReadW")
+	procCredWriteW  = advapi32.NewProc("CredWriteW")
+	procCredDeleteW = advapi32.NewProc("CredDeleteW")
+	procCredFree    = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+	errorNotFound           = syscall.Errno(1168)
+)
+
+// credential mirrors the Win32 CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        syscall.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func target(service, account string) (*uint16, error) {
+	return syscall.UTF16PtrFromString(service + ":" + account)
+}
+
+func get(service, account string) (string, error) {
+	name, err := target(service, account)
+	if err != nil {
+		return "", err
+	}
+	var cred *credential
+	ok, _, err := procCredReadW.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred)))
+	if ok == 0 {
+		return "", credError(err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func set(service, account, secret string) error {
+	name, err := target(service, account)
+	if err != nil {
+		return err
+	}
+	user, err := syscall.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+	blob := []byte(secret)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         name,
+		CredentialBlobSize: uint32(len(blob)),
+		CredentialBlob:     &blob[0],
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	ok, _, err := procCredWriteW.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if ok == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+func del(service, account string) error {
+	name, err := target(service, account)
+	if err != nil {
+		return err
+	}
+	ok, _, err := procCredDeleteW.Call(uintptr(unsafe.Pointer(name)), credTypeGeneric, 0)
+	if ok == 0 {
+		return credError(err)
+	}
+	return nil
+}
+
+func credError(err error) error {
+	if errors.Is(err, errorNotFound) {
+		return ErrNotFound
+	}
+	return fmt.Errorf("credential manager: %w", err)
+}
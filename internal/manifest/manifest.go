@@ -0,0 +1,137 @@
+// Package manifest parses desired-state documents for Grafana content and
+// plans the creates, updates, and deletes that reconcile an instance to them.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Manifest is the desired state of a set of Grafana objects. Every object is
+// identified by its uid, so the same document can be applied repeatedly.
+type Manifest struct {
+	Folders     []grafana.Folder     `json:"folders,omitempty"`
+	Datasources []grafana.Datasource `json:"datasources,omitempty"`
+	Dashboards  []Dashboard          `json:"dashboards,omitempty"`
+	AlertRules  []grafana.AlertRule  `json:"alert_rules,omitempty"`
+}
+
+// Dashboard is a dashboard model and the folder it belongs in
+type Dashboard struct {
+	FolderUID string                 `json:"folder_uid,omitempty"`
+	Dashboard map[string]interface{} `json:"dashboard"`
+}
+
+// UID returns the dashboard model's uid
+func (d Dashboard) UID() string {
+	uid, _ := d.Dashboard["uid"].(string)
+	return uid
+}
+
+// Title returns the dashboard model's title
+func (d Dashboard) Title() string {
+	title, _ := d.Dashboard["title"].(string)
+	return title
+}
+
+var sections = []string{"folders", "datasources", "dashboards", "alert_rules"}
+
+// Parse reads a manifest written as YAML or JSON and validates it
+func Parse(data []byte) (*Manifest, error) {
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	top, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("manifest must be a mapping with %s", strings.Join(sections, ", "))
+	}
+	for key := range top {
+		if !contains(sections, key) {
+			return nil, fmt.Errorf("unknown manifest section %q (expected %s)", key, strings.Join(sections, ", "))
+		}
+	}
+	// Round-trip through JSON so the grafana types' json tags apply
+	buf, err := json.Marshal(top)
+	if err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(buf, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Validate checks that every object has the fields needed to reconcile it
+// and that no uid is declared twice
+func (m *Manifest) Validate() error {
+	var problems []string
+	seen := map[string]bool{}
+	check := func(kind, uid, name string, required map[string]string) {
+		label := fmt.Sprintf("%s %q", kind, name)
+		if uid == "" {
+			problems = append(problems, label+": uid is required")
+		} else if seen[kind+"/"+uid] {
+			problems = append(problems, fmt.Sprintf("%s %q: declared more than once", kind, uid))
+		}
+		seen[kind+"/"+uid] = true
+		for _, field := range sortedKeys(required) {
+			if required[field] == "" {
+				problems = append(problems, label+": "+field+" is required")
+			}
+		}
+	}
+	for _, f := range m.Folders {
+		check("folder", f.UID, f.Title, map[string]string{"title": f.Title})
+	}
+	for _, ds := range m.Datasources {
+		check("datasource", ds.UID, ds.Name, map[string]string{"name": ds.Name, "type": ds.Type})
+	}
+	for _, d := range m.Dashboards {
+		if d.Dashboard == nil {
+			problems = append(problems, "dashboard entry without a dashboard model")
+			continue
+		}
+		check("dashboard", d.UID(), d.Title(), map[string]string{"dashboard.title": d.Title()})
+	}
+	for _, rule := range m.AlertRules {
+		check("alert_rule", rule.UID, rule.Title, map[string]string{
+			"title":     rule.Title,
+			"folderUID": rule.FolderUID,
+			"ruleGroup": rule.RuleGroup,
+			"condition": rule.Condition,
+		})
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid manifest: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,175 @@
+package manifest
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Operations in a plan
+const (
+	OpCreate = "create"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// Object kinds in a plan, in the order they are created
+const (
+	KindFolder     = "folder"
+	KindDatasource = "datasource"
+	KindDashboard  = "dashboard"
+	KindAlertRule  = "alert_rule"
+)
+
+// State is what the instance currently holds, keyed by uid. Dashboards and
+// alert rules need only cover the manifest's objects and, for pruning, the
+// contents of its folders.
+type State struct {
+	Folders     map[string]grafana.Folder
+	Datasources map[string]grafana.Datasource
+	Dashboards  map[string]Dashboard
+	AlertRules  map[string]grafana.AlertRule
+}
+
+// Action is one change the plan makes. Changes lists the fields an update
+// sets, comparing only what the manifest declares.
+type Action struct {
+	Kind    string             `json:"kind"`
+	UID     string             `json:"uid"`
+	Name    string             `json:"name"`
+	Op      string             `json:"op"`
+	Changes []dashboard.Change `json:"changes,omitempty"`
+}
+
+// Plan compares the manifest with the current state. With prune, dashboards
+// and alert rules in the manifest's folders that the manifest does not
+// declare are deleted; folders and datasources are never pruned. Creates
+// and updates come first, in dependency order, then deletes in reverse.
+func Plan(m *Manifest, current *State, prune bool) (actions []Action, unchanged int) {
+	add := func(kind, uid, name string, desired, actual interface{}, exists bool) {
+		if !exists {
+			actions = append(actions, Action{Kind: kind, UID: uid, Name: name, Op: OpCreate})
+			return
+		}
+		if changes := declaredDiff(actual, desired); len(changes) > 0 {
+			actions = append(actions, Action{Kind: kind, UID: uid, Name: name, Op: OpUpdate, Changes: changes})
+			return
+		}
+		unchanged++
+	}
+
+	for _, f := range m.Folders {
+		cur, ok := current.Folders[f.UID]
+		add(KindFolder, f.UID, f.Title, map[string]interface{}{"title": f.Title}, map[string]interface{}{"title": cur.Title}, ok)
+	}
+	for _, ds := range m.Datasources {
+		cur, ok := current.Datasources[ds.UID]
+		add(KindDatasource, ds.UID, ds.Name, datasourceFields(ds), datasourceFields(cur), ok)
+	}
+	for _, d := range m.Dashboards {
+		cur, ok := current.Dashboards[d.UID()]
+		add(KindDashboard, d.UID(), d.Title(), dashboardFields(d), dashboardFields(cur), ok)
+	}
+	for _, rule := range m.AlertRules {
+		cur, ok := current.AlertRules[rule.UID]
+		add(KindAlertRule, rule.UID, rule.Title, ruleFields(rule), ruleFields(cur), ok)
+	}
+
+	if !prune {
+		return actions, unchanged
+	}
+	owned := map[string]bool{}
+	for _, f := range m.Folders {
+		owned[f.UID] = true
+	}
+	declaredRules := map[string]bool{}
+	for _, rule := range m.AlertRules {
+		declaredRules[rule.UID] = true
+	}
+	for _, uid := range sortedUIDs(current.AlertRules) {
+		rule := current.AlertRules[uid]
+		if owned[rule.FolderUID] && !declaredRules[uid] {
+			actions = append(actions, Action{Kind: KindAlertRule, UID: uid, Name: rule.Title, Op: OpDelete})
+		}
+	}
+	declaredDashboards := map[string]bool{}
+	for _, d := range m.Dashboards {
+		declaredDashboards[d.UID()] = true
+	}
+	for _, uid := range sortedUIDs(current.Dashboards) {
+		d := current.Dashboards[uid]
+		if owned[d.FolderUID] && !declaredDashboards[uid] {
+			actions = append(actions, Action{Kind: KindDashboard, UID: uid, Name: d.Title(), Op: OpDelete})
+		}
+	}
+	return actions, unchanged
+}
+
+// declaredDiff lists differences between actual and desired, ignoring
+// fields the server adds that the manifest leaves out. Removed list
+// elements still count.
+func declaredDiff(actual, desired interface{}) []dashboard.Change {
+	var out []dashboard.Change
+	for _, c := range dashboard.Diff(toJSON(actual), toJSON(desired)) {
+		if c.New == nil && !strings.HasSuffix(c.Path, "]") {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// toJSON returns v as decoded JSON so typed and untyped objects compare alike
+func toJSON(v interface{}) interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var out interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil
+	}
+	return out
+}
+
+// datasourceFields drops fields that are assigned by Grafana or, like
+// secureJsonData, cannot be read back, and string fields left empty, which
+// Grafana fills with defaults
+func datasourceFields(ds grafana.Datasource) map[string]interface{} {
+	ds.ID, ds.OrgID, ds.TypeName, ds.SecureJSONData = 0, 0, "", nil
+	fields, _ := toJSON(ds).(map[string]interface{})
+	for k, v := range fields {
+		if v == "" {
+			delete(fields, k)
+		}
+	}
+	return fields
+}
+
+func dashboardFields(d Dashboard) map[string]interface{} {
+	model := make(map[string]interface{}, len(d.Dashboard)+1)
+	for k, v := range d.Dashboard {
+		if k != "id" && k != "version" {
+			model[k] = v
+		}
+	}
+	model["folder_uid"] = d.FolderUID
+	return model
+}
+
+func ruleFields(rule grafana.AlertRule) grafana.AlertRule {
+	rule.ID, rule.OrgID, rule.Provenance = 0, 0, ""
+	return rule
+}
+
+func sortedUIDs[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,208 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// op is an action without its changes, for comparing plans
+type op struct {
+	Kind, UID, Op string
+}
+
+func ops(actions []Action) []op {
+	out := []op{}
+	for _, a := range actions {
+		out = append(out, op{a.Kind, a.UID, a.Op})
+	}
+	return out
+}
+
+func emptyState() *State {
+	return &State{
+		Folders:     map[string]grafana.Folder{},
+		Datasources: map[string]grafana.Datasource{},
+		Dashboards:  map[string]Dashboard{},
+		AlertRules:  map[string]grafana.AlertRule{},
+	}
+}
+
+// testManifest declares one object of each kind, all in folder "team"
+func testManifest() *Manifest {
+	return &Manifest{
+		Folders:     []grafana.Folder{{UID: "team", Title: "Team"}},
+		Datasources: []grafana.Datasource{{UID: "prom", Name: "Prometheus", Type: "prometheus", URL: "http://prometheus:9090"}},
+		Dashboards: []Dashboard{{FolderUID: "team", Dashboard: map[string]interface{}{
+			"uid": "overview", "title": "Overview", "tags": []interface{}{"team"},
+		}}},
+		AlertRules: []grafana.AlertRule{{UID: "latency", Title: "High latency", FolderUID: "team", RuleGroup: "slo", Condition: "C"}},
+	}
+}
+
+// liveState is testManifest as Grafana returns it, with the fields the
+// server assigns
+func liveState() *State {
+	s := emptyState()
+	s.Folders["team"] = grafana.Folder{ID: 3, UID: "team", Title: "Team", URL: "/dashboards/f/team", Version: 2}
+	s.Datasources["prom"] = grafana.Datasource{ID: 1, OrgID: 1, UID: "prom", Name: "Prometheus", Type: "prometheus", TypeName: "Prometheus", URL: "http://prometheus:9090", Access: "proxy"}
+	s.Dashboards["overview"] = Dashboard{FolderUID: "team", Dashboard: map[string]interface{}{
+		"id": 12.0, "version": 4.0, "uid": "overview", "title": "Overview", "tags": []interface{}{"team"}, "schemaVersion": 39.0,
+	}}
+	s.AlertRules["latency"] = grafana.AlertRule{ID: 7, OrgID: 1, UID: "latency", Title: "High latency", FolderUID: "team", RuleGroup: "slo", Condition: "C", Provenance: "api"}
+	return s
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name          string
+		manifest      func() *Manifest
+		state         func() *State
+		prune         bool
+		want          []op
+		wantUnchanged int
+	}{
+		{
+			name:     "everything missing is created in dependency order",
+			manifest: testManifest,
+			state:    emptyState,
+			want: []op{
+				{KindFolder, "team", OpCreate},
+				{KindDatasource, "prom", OpCreate},
+				{KindDashboard, "overview", OpCreate},
+				{KindAlertRule, "latency", OpCreate},
+			},
+		},
+		{
+			name:          "server-assigned fields are not changes",
+			manifest:      testManifest,
+			state:         liveState,
+			want:          []op{},
+			wantUnchanged: 4,
+		},
+		{
+			name:     "declared fields that differ are updated",
+			manifest: testManifest,
+			state: func() *State {
+				s := liveState()
+				s.Folders["team"] = grafana.Folder{UID: "team", Title: "Old team"}
+				rule := s.AlertRules["latency"]
+				rule.RuleGroup = "other"
+				s.AlertRules["latency"] = rule
+				return s
+			},
+			want: []op{
+				{KindFolder, "team", OpUpdate},
+				{KindAlertRule, "latency", OpUpdate},
+			},
+			wantUnchanged: 2,
+		},
+		{
+			name:     "a removed list element is a change",
+			manifest: testManifest,
+			state: func() *State {
+				s := liveState()
+				s.Dashboards["overview"].Dashboard["tags"] = []interface{}{"team", "legacy"}
+				return s
+			},
+			want:          []op{{KindDashboard, "overview", OpUpdate}},
+			wantUnchanged: 3,
+		},
+		{
+			name:     "undeclared objects are kept without prune",
+			manifest: testManifest,
+			state: func() *State {
+				s := liveState()
+				s.Dashboards["stale"] = Dashboard{FolderUID: "team", Dashboard: map[string]interface{}{"uid": "stale", "title": "Stale"}}
+				s.AlertRules["old"] = grafana.AlertRule{UID: "old", Title: "Old", FolderUID: "team"}
+				return s
+			},
+			want:          []op{},
+			wantUnchanged: 4,
+		},
+		{
+			name:     "prune deletes undeclared objects in owned folders only",
+			manifest: testManifest,
+			state: func() *State {
+				s := liveState()
+				s.Folders["other"] = grafana.Folder{UID: "other", Title: "Other"}
+				s.Datasources["loki"] = grafana.Datasource{UID: "loki", Name: "Loki", Type: "loki"}
+				for _, uid := range []string{"stale-b", "stale-a"} {
+					s.Dashboards[uid] = Dashboard{FolderUID: "team", Dashboard: map[string]interface{}{"uid": uid, "title": uid}}
+				}
+				s.Dashboards["elsewhere"] = Dashboard{FolderUID: "other", Dashboard: map[string]interface{}{"uid": "elsewhere", "title": "Elsewhere"}}
+				s.Dashboards["general"] = Dashboard{Dashboard: map[string]interface{}{"uid": "general", "title": "In General"}}
+				s.AlertRules["old"] = grafana.AlertRule{UID: "old", Title: "Old", FolderUID: "team"}
+				s.AlertRules["theirs"] = grafana.AlertRule{UID: "theirs", Title: "Theirs", FolderUID: "other"}
+				return s
+			},
+			prune: true,
+			want: []op{
+				{KindAlertRule, "old", OpDelete},
+				{KindDashboard, "stale-a", OpDelete},
+				{KindDashboard, "stale-b", OpDelete},
+			},
+			wantUnchanged: 4,
+		},
+		{
+			name: "prune comes after creates and updates",
+			manifest: func() *Manifest {
+				m := testManifest()
+				m.Dashboards = nil
+				return m
+			},
+			state: func() *State {
+				s := liveState()
+				delete(s.Datasources, "prom")
+				return s
+			},
+			prune: true,
+			want: []op{
+				{KindDatasource, "prom", OpCreate},
+				{KindDashboard, "overview", OpDelete},
+			},
+			wantUnchanged: 2,
+		},
+		{
+			name: "prune without folders deletes nothing",
+			manifest: func() *Manifest {
+				m := testManifest()
+				m.Folders = nil
+				return m
+			},
+			state: func() *State {
+				s := liveState()
+				s.Dashboards["stale"] = Dashboard{FolderUID: "team", Dashboard: map[string]interface{}{"uid": "stale"}}
+				return s
+			},
+			prune:         true,
+			want:          []op{},
+			wantUnchanged: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actions, unchanged := Plan(tt.manifest(), tt.state(), tt.prune)
+			if got := ops(actions); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("plan = %+v, want %+v", got, tt.want)
+			}
+			if unchanged != tt.wantUnchanged {
+				t.Fatalf("unchanged = %d, want %d", unchanged, tt.wantUnchanged)
+			}
+		})
+	}
+}
+
+func TestPlanListsDeclaredChanges(t *testing.T) {
+	s := liveState()
+	s.Folders["team"] = grafana.Folder{UID: "team", Title: "Old team"}
+	actions, _ := Plan(testManifest(), s, false)
+	if len(actions) != 1 {
+		t.Fatalf("plan = %+v, want one update", actions)
+	}
+	changes := actions[0].Changes
+	if len(changes) != 1 || changes[0].Path != "title" || changes[0].Old != "Old team" || changes[0].New != "Team" {
+		t.Fatalf("changes = %+v, want title Old team -> Team", changes)
+	}
+}
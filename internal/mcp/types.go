@@ -10,6 +10,13 @@ type Request struct {
 	Params  interface{}     `json:"params,omitempty"`
 }
 
+// JSON-RPC Notification, sent by the server without an ID
+type Notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
 // JSON-RPC Response
 type Response struct {
 	JSONRPC string          `json:"jsonrpc"`
@@ -25,10 +32,20 @@ type Error struct {
 	Data    *ErrorData  `json:"data,omitempty"`
 }
 
-// ErrorData provides structured error details
+// ErrorData provides structured error details. The Grafana fields are set
+// when a Grafana API call failed, so clients can branch on the failure.
 type ErrorData struct {
 	Details string `json:"details,omitempty"`
 	Cause   string `json:"cause,omitempty"`
+
+	// Status is the HTTP status Grafana answered with
+	Status int `json:"status,omitempty"`
+	// Kind classifies Status, e.g. forbidden, not_found, or conflict
+	Kind string `json:"kind,omitempty"`
+	// MessageID is Grafana's machine-readable error id
+	MessageID string `json:"messageId,omitempty"`
+	// TraceID identifies the request in Grafana's traces and logs
+	TraceID string `json:"traceId,omitempty"`
 }
 
 // MCP Tool Definition
@@ -65,15 +82,19 @@ type CallToolParams struct {
 	Arguments map[string]interface{} `json:"arguments,omitempty"`
 }
 
-// Tool Call Response
+// Tool Call Response. StructuredContent carries a machine-readable copy of
+// the result for clients that support it; Content remains the text form.
 type CallToolResult struct {
-	Content []ContentBlock `json:"content"`
-	IsError bool           `json:"isError,omitempty"`
+	Content           []ContentBlock `json:"content"`
+	StructuredContent interface{}    `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
 }
 
 type ContentBlock struct {
-	Type string `json:"type"`
-	Text string `json:"text,omitempty"`
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
 }
 
 // Initialize Result
@@ -107,6 +128,65 @@ type ListToolsResult struct {
 	Tools []Tool `json:"tools"`
 }
 
+// MCP Resource Definition
+type Resource struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// List Resources Result
+type ListResourcesResult struct {
+	Resources []Resource `json:"resources"`
+}
+
+// ResourceTemplate describes a family of resources addressed by a URI
+// template (RFC 6570), such as grafana://dashboards/{uid}
+type ResourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+}
+
+// List Resource Templates Result
+type ListResourceTemplatesResult struct {
+	ResourceTemplates []ResourceTemplate `json:"resourceTemplates"`
+}
+
+// Resource Read Request
+type ReadResourceParams struct {
+	URI string `json:"uri"`
+}
+
+// Resource Subscribe and Unsubscribe Request
+type SubscribeParams struct {
+	URI string `json:"uri"`
+}
+
+// Resource Updated Notification
+type ResourceUpdatedParams struct {
+	URI string `json:"uri"`
+}
+
+// Cancellation Notification
+type CancelledParams struct {
+	RequestID json.RawMessage `json:"requestId"`
+	Reason    string          `json:"reason,omitempty"`
+}
+
+// Resource Read Response
+type ReadResourceResult struct {
+	Contents []ResourceContents `json:"contents"`
+}
+
+type ResourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+}
+
 // Standard error codes
 const (
 	ParseError     = -32700
@@ -114,4 +194,8 @@ const (
 	MethodNotFound = -32601
 	InvalidParams  = -32602
 	InternalError  = -32603
+
+	// ResourceNotFound is the MCP error for a resources/read of an
+	// unknown URI
+	ResourceNotFound = -32002
 )
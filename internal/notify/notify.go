@@ -0,0 +1,270 @@
+// Package notify delivers outbound webhook notifications about tool calls
+// and scheduled job runs, so changes made through the server are visible
+// outside the chat session.
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"path"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// queueSize bounds undelivered events; when full, new events are dropped
+const queueSize = 256
+
+// Event describes a completed tool call or scheduled job run
+type Event struct {
+	// Kind is "tool" for tool calls and "job" for scheduled job runs
+	Kind        string                 `json:"kind"`
+	Tool        string                 `json:"tool"`
+	Job         string                 `json:"job,omitempty"`
+	Status      string                 `json:"status"`
+	Error       string                 `json:"error,omitempty"`
+	ReadOnly    bool                   `json:"read_only"`
+	Destructive bool                   `json:"destructive"`
+	Args        map[string]interface{} `json:"args,omitempty"`
+	Output      string                 `json:"output,omitempty"`
+	Time        time.Time              `json:"time"`
+	Duration    string                 `json:"duration"`
+}
+
+// Webhook is one configured notification target
+type Webhook struct {
+	Name string
+	URL  string
+	// Format is "generic" (the event as JSON, or the rendered template) or
+	// "slack" (the rendered text as a Slack message)
+	Format string
+	// On lists the events that trigger the webhook: "destructive", "write",
+	// "error", "job", "job_failed", "tool:<glob>", or "job:<glob>"
+	On []string
+	// Template is a text/template over Event; empty uses a one-line summary
+	Template string
+	Headers  map[string]string
+	// IncludeArgs sends tool arguments, which may contain sensitive values
+	IncludeArgs bool
+
+	tmpl *template.Template
+}
+
+const defaultTemplate = `grafana-mcp: {{if eq .Kind "job"}}job {{.Job}} ({{.Tool}}){{else}}{{.Tool}}{{end}} {{.Status}}{{if .Error}}: {{.Error}}{{end}} in {{.Duration}}`
+
+// Notifier matches events against webhooks and delivers them in the background
+type Notifier struct {
+	hooks    []*Webhook
+	client   *http.Client
+	queue    chan delivery
+	done     chan struct{}
+	checkURL func(url string) error
+}
+
+// Option configures a Notifier
+type Option func(*Notifier)
+
+// WithURLCheck refuses webhook URLs check rejects, both when the notifier
+// is created and before each delivery, since a host may resolve elsewhere
+// later
+func WithURLCheck(check func(url string) error) Option {
+	return func(n *Notifier) {
+		n.checkURL = check
+	}
+}
+
+type delivery struct {
+	hook *Webhook
+	ev   Event
+}
+
+// New validates the webhooks and starts the delivery worker
+func New(hooks []Webhook, opts ...Option) (*Notifier, error) {
+	n := &Notifier{
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan delivery, queueSize),
+		done:   make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(n)
+	}
+	for i := range hooks {
+		h := hooks[i]
+		if h.URL == "" {
+			return nil, fmt.Errorf("webhook %q: url is required", h.Name)
+		}
+		if n.checkURL != nil {
+			if err := n.checkURL(h.URL); err != nil {
+				return nil, fmt.Errorf("webhook %q: %w", h.Name, err)
+			}
+		}
+		if h.Format == "" {
+			h.Format = "generic"
+		}
+		if h.Format != "generic" && h.Format != "slack" {
+			return nil, fmt.Errorf("webhook %q: format must be generic or slack", h.Name)
+		}
+		if len(h.On) == 0 {
+			return nil, fmt.Errorf("webhook %q: on must list at least one event", h.Name)
+		}
+		for _, on := range h.On {
+			if !validTrigger(on) {
+				return nil, fmt.Errorf("webhook %q: unknown event %q", h.Name, on)
+			}
+		}
+		src := h.Template
+		if src == "" && h.Format == "slack" {
+			src = defaultTemplate
+		}
+		if src != "" {
+			t, err := template.New(h.Name).Parse(src)
+			if err != nil {
+				return nil, fmt.Errorf("webhook %q: template: %w", h.Name, err)
+			}
+			h.tmpl = t
+		}
+		n.hooks = append(n.hooks, &h)
+	}
+	go n.worker()
+	return n, nil
+}
+
+func validTrigger(on string) bool {
+	switch on {
+	case "destructive", "write", "error", "job", "job_failed":
+		return true
+	}
+	kind, glob, ok := strings.Cut(on, ":")
+	if !ok || (kind != "tool" && kind != "job") {
+		return false
+	}
+	_, err := path.Match(glob, "")
+	return err == nil
+}
+
+// Matches reports whether the event triggers the webhook
+func (h *Webhook) Matches(ev Event) bool {
+	for _, on := range h.On {
+		switch on {
+		case "destructive":
+			if ev.Kind == "tool" && ev.Destructive {
+				return true
+			}
+		case "write":
+			if ev.Kind == "tool" && !ev.ReadOnly {
+				return true
+			}
+		case "error":
+			if ev.Status == "error" {
+				return true
+			}
+		case "job":
+			if ev.Kind == "job" {
+				return true
+			}
+		case "job_failed":
+			if ev.Kind == "job" && ev.Status == "error" {
+				return true
+			}
+		default:
+			kind, glob, _ := strings.Cut(on, ":")
+			name := ev.Tool
+			if kind == "job" {
+				name = ev.Job
+			}
+			if kind == ev.Kind {
+				if ok, _ := path.Match(glob, name); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// Notify queues the event for every matching webhook without blocking.
+// Events are dropped if the queue is full.
+func (n *Notifier) Notify(ev Event) {
+	if n == nil {
+		return
+	}
+	for _, h := range n.hooks {
+		if !h.Matches(ev) {
+			continue
+		}
+		select {
+		case n.queue <- delivery{hook: h, ev: ev}:
+		default:
+			log.Printf("Warning: webhook %q queue full, dropping %s event", h.Name, ev.Tool)
+		}
+	}
+}
+
+// Close delivers queued events and stops the worker
+func (n *Notifier) Close() {
+	if n == nil {
+		return
+	}
+	close(n.queue)
+	<-n.done
+}
+
+func (n *Notifier) worker() {
+	defer close(n.done)
+	for d := range n.queue {
+		if err := n.send(d.hook, d.ev); err != nil {
+			log.Printf("Warning: webhook %q failed: %v", d.hook.Name, err)
+		}
+	}
+}
+
+func (n *Notifier) send(h *Webhook, ev Event) error {
+	if !h.IncludeArgs {
+		ev.Args = nil
+	}
+	if n.checkURL != nil {
+		if err := n.checkURL(h.URL); err != nil {
+			return err
+		}
+	}
+	body, err := h.payload(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", h.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range h.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// payload renders the request body: Slack gets {"text": ...}; generic
+// webhooks get the rendered template verbatim, or the event as JSON
+func (h *Webhook) payload(ev Event) ([]byte, error) {
+	if h.tmpl == nil {
+		return json.Marshal(ev)
+	}
+	var buf bytes.Buffer
+	if err := h.tmpl.Execute(&buf, ev); err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+	if h.Format == "slack" {
+		return json.Marshal(map[string]string{"text": buf.String()})
+	}
+	return buf.Bytes(), nil
+}
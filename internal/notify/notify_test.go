@@ -0,0 +1,211 @@
+package notify
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the requests a webhook endpoint gets
+type receiver struct {
+	*httptest.Server
+	mu   sync.Mutex
+	reqs []received
+}
+
+type received struct {
+	header http.Header
+	body   string
+}
+
+func newReceiver(t *testing.T) *receiver {
+	r := &receiver{}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		r.reqs = append(r.reqs, received{header: req.Header, body: string(body)})
+		r.mu.Unlock()
+	}))
+	t.Cleanup(r.Close)
+	return r
+}
+
+func (r *receiver) received() []received {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]received(nil), r.reqs...)
+}
+
+var testEvent = Event{
+	Kind:        "tool",
+	Tool:        "grafana_delete_dashboard",
+	Status:      "error",
+	Error:       "not found",
+	Destructive: true,
+	Args:        map[string]interface{}{"uid": "abc"},
+	Time:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Duration:    "12ms",
+}
+
+func TestPayloads(t *testing.T) {
+	tests := []struct {
+		name  string
+		hook  Webhook
+		check func(t *testing.T, r received)
+	}{
+		{
+			name: "generic event JSON without args",
+			hook: Webhook{On: []string{"destructive"}, Headers: map[string]string{"Authorization": "Bearer secret"}},
+			check: func(t *testing.T, r received) {
+				var got map[string]interface{}
+				if err := json.Unmarshal([]byte(r.body), &got); err != nil {
+					t.Fatalf("body is not JSON: %v\n%s", err, r.body)
+				}
+				want := map[string]interface{}{
+					"kind": "tool", "tool": "grafana_delete_dashboard", "status": "error", "error": "not found",
+					"read_only": false, "destructive": true, "time": "2024-05-01T12:00:00Z", "duration": "12ms",
+				}
+				if len(got) != len(want) {
+					t.Fatalf("payload = %v, want %v", got, want)
+				}
+				for k, v := range want {
+					if got[k] != v {
+						t.Errorf("%s = %v, want %v", k, got[k], v)
+					}
+				}
+				if r.header.Get("Authorization") != "Bearer secret" || r.header.Get("Content-Type") != "application/json" {
+					t.Errorf("headers = %v", r.header)
+				}
+			},
+		},
+		{
+			name: "generic event JSON with args",
+			hook: Webhook{On: []string{"error"}, IncludeArgs: true},
+			check: func(t *testing.T, r received) {
+				var got Event
+				if err := json.Unmarshal([]byte(r.body), &got); err != nil {
+					t.Fatalf("body is not JSON: %v", err)
+				}
+				if got.Args["uid"] != "abc" {
+					t.Fatalf("args = %v, want the tool arguments", got.Args)
+				}
+			},
+		},
+		{
+			name: "generic template sent verbatim",
+			hook: Webhook{On: []string{"tool:grafana_delete_*"}, Template: `{"summary": "{{.Tool}} {{.Status}}"}`},
+			check: func(t *testing.T, r received) {
+				if r.body != `{"summary": "grafana_delete_dashboard error"}` {
+					t.Fatalf("body = %s", r.body)
+				}
+			},
+		},
+		{
+			name: "slack default text",
+			hook: Webhook{On: []string{"write"}, Format: "slack"},
+			check: func(t *testing.T, r received) {
+				var got map[string]string
+				if err := json.Unmarshal([]byte(r.body), &got); err != nil {
+					t.Fatalf("body is not JSON: %v", err)
+				}
+				want := "grafana-mcp: grafana_delete_dashboard error: not found in 12ms"
+				if len(got) != 1 || got["text"] != want {
+					t.Fatalf("payload = %v, want text %q", got, want)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recv := newReceiver(t)
+			tt.hook.Name, tt.hook.URL = tt.name, recv.URL
+			n, err := New([]Webhook{tt.hook})
+			if err != nil {
+				t.Fatalf("New: %v", err)
+			}
+			n.Notify(testEvent)
+			n.Close()
+			reqs := recv.received()
+			if len(reqs) != 1 {
+				t.Fatalf("%d deliveries, want 1", len(reqs))
+			}
+			tt.check(t, reqs[0])
+		})
+	}
+}
+
+func TestUnmatchedEventsAreNotSent(t *testing.T) {
+	recv := newReceiver(t)
+	n, err := New([]Webhook{{Name: "jobs", URL: recv.URL, On: []string{"job_failed", "tool:grafana_create_*"}}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	n.Notify(testEvent)
+	n.Notify(Event{Kind: "job", Job: "backup", Tool: "grafana_export_provisioning", Status: "ok"})
+	n.Close()
+	if reqs := recv.received(); len(reqs) != 0 {
+		t.Fatalf("%d deliveries, want none", len(reqs))
+	}
+}
+
+func TestURLPolicy(t *testing.T) {
+	recv := newReceiver(t)
+	errDenied := errors.New("url policy: denied by configuration")
+
+	deny := func(string) error { return errDenied }
+	_, err := New([]Webhook{{Name: "internal", URL: recv.URL, On: []string{"error"}}}, WithURLCheck(deny))
+	if err == nil || !strings.Contains(err.Error(), "denied by configuration") {
+		t.Fatalf("New with a denied URL = %v, want it refused", err)
+	}
+
+	// A host allowed at startup may resolve to a denied address later
+	var mu sync.Mutex
+	allowed := true
+	check := func(string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !allowed {
+			return errDenied
+		}
+		return nil
+	}
+	n, err := New([]Webhook{{Name: "later", URL: recv.URL, On: []string{"error"}}}, WithURLCheck(check))
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	mu.Lock()
+	allowed = false
+	mu.Unlock()
+	n.Notify(testEvent)
+	n.Close()
+	if reqs := recv.received(); len(reqs) != 0 {
+		t.Fatalf("%d deliveries to a denied URL, want none", len(reqs))
+	}
+}
+
+func TestNewRejectsInvalidWebhooks(t *testing.T) {
+	tests := []struct {
+		name string
+		hook Webhook
+		want string
+	}{
+		{"no url", Webhook{On: []string{"error"}}, "url is required"},
+		{"bad format", Webhook{URL: "http://hooks.example.com", Format: "teams", On: []string{"error"}}, "format must be"},
+		{"no events", Webhook{URL: "http://hooks.example.com"}, "at least one event"},
+		{"unknown event", Webhook{URL: "http://hooks.example.com", On: []string{"deploy"}}, "unknown event"},
+		{"bad glob", Webhook{URL: "http://hooks.example.com", On: []string{"tool:["}}, "unknown event"},
+		{"bad template", Webhook{URL: "http://hooks.example.com", On: []string{"error"}, Template: "{{.Tool"}, "template"},
+	}
+	for _, tt := range tests {
+		tt.hook.Name = tt.name
+		if _, err := New([]Webhook{tt.hook}); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: New = %v, want an error containing %q", tt.name, err, tt.want)
+		}
+	}
+}
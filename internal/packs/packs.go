@@ -0,0 +1,151 @@
+// Package packs holds curated dashboard and alert rule bundles embedded in
+// the binary, parameterized at install time for the target environment.
+package packs
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+)
+
+//go:embed kubernetes/*.json templates/*/*.json
+var files embed.FS
+
+// Placeholders in pack assets
+const (
+	clusterLabelToken    = "__CLUSTER_LABEL__"
+	clusterSelectorToken = "__CLUSTER_SELECTOR__"
+	// matchAll stands in for the cluster matcher on single-cluster setups;
+	// it is always true for a selector with a metric name
+	matchAll = `__name__=~".+"`
+)
+
+// Pack is a set of dashboards and alert rules for one kind of environment
+type Pack struct {
+	Name       string
+	Dashboards []Dashboard
+	Alerts     []Alert
+}
+
+// Dashboard is a dashboard model and the exporters its queries need
+type Dashboard struct {
+	File     string   `json:"file"`
+	Requires []string `json:"requires"`
+	Model    map[string]interface{}
+}
+
+// Alert is a recommended alert rule: it fires while Expr compares to
+// Threshold by Op (gt or lt) for the For duration. Datasource names the
+// template input the query runs against.
+type Alert struct {
+	Title      string   `json:"title"`
+	Requires   []string `json:"requires,omitempty"`
+	Datasource string   `json:"datasource,omitempty"`
+	Severity   string   `json:"severity"`
+	For        string   `json:"for"`
+	Expr       string   `json:"expr"`
+	Op         string   `json:"op"`
+	Threshold  float64  `json:"threshold"`
+	Summary    string   `json:"summary"`
+}
+
+// Params selects the cluster a pack is installed for. With no ClusterLabel
+// the series are assumed to come from a single cluster; with a label but no
+// Cluster, dashboards get a cluster picker and alerts cover every cluster.
+type Params struct {
+	ClusterLabel string
+	Cluster      string
+}
+
+// Kubernetes returns the Kubernetes pack: dashboards built on
+// kube-state-metrics, cAdvisor, and node-exporter, and matching alert rules
+func Kubernetes(p Params) (*Pack, error) {
+	return load("kubernetes", p)
+}
+
+func load(name string, p Params) (*Pack, error) {
+	data, err := files.ReadFile(path.Join(name, "pack.json"))
+	if err != nil {
+		return nil, err
+	}
+	var manifest struct {
+		Dashboards []Dashboard `json:"dashboards"`
+		Alerts     []Alert     `json:"alerts"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("pack %s: %w", name, err)
+	}
+
+	dashSel, alertSel := matchAll, matchAll
+	if p.ClusterLabel != "" {
+		dashSel = fmt.Sprintf(`%s=~"$cluster"`, p.ClusterLabel)
+		if p.Cluster != "" {
+			alertSel = fmt.Sprintf(`%s=%q`, p.ClusterLabel, p.Cluster)
+		}
+	}
+	dashRepl := strings.NewReplacer(clusterLabelToken, p.ClusterLabel, clusterSelectorToken, dashSel)
+	alertRepl := strings.NewReplacer(clusterSelectorToken, alertSel)
+
+	pack := &Pack{Name: name}
+	for _, d := range manifest.Dashboards {
+		data, err := files.ReadFile(path.Join(name, d.File))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &d.Model); err != nil {
+			return nil, fmt.Errorf("pack %s: %s: %w", name, d.File, err)
+		}
+		d.Model = replaceStrings(d.Model, dashRepl).(map[string]interface{})
+		setClusterVariable(d.Model, p)
+		pack.Dashboards = append(pack.Dashboards, d)
+	}
+	for _, a := range manifest.Alerts {
+		a.Expr = alertRepl.Replace(a.Expr)
+		pack.Alerts = append(pack.Alerts, a)
+	}
+	return pack, nil
+}
+
+// setClusterVariable drops the cluster variable when there is no cluster
+// label and preselects the requested cluster otherwise
+func setClusterVariable(model map[string]interface{}, p Params) {
+	templating, _ := model["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	kept := list[:0]
+	for _, v := range list {
+		m, _ := v.(map[string]interface{})
+		if m["name"] != "cluster" {
+			kept = append(kept, v)
+			continue
+		}
+		if p.ClusterLabel == "" {
+			continue
+		}
+		if p.Cluster != "" {
+			m["current"] = map[string]interface{}{"text": p.Cluster, "value": p.Cluster}
+		}
+		kept = append(kept, m)
+	}
+	if templating != nil {
+		templating["list"] = kept
+	}
+}
+
+// replaceStrings applies r to every string in a decoded JSON value
+func replaceStrings(v interface{}, r *strings.Replacer) interface{} {
+	switch v := v.(type) {
+	case string:
+		return r.Replace(v)
+	case map[string]interface{}:
+		for k, child := range v {
+			v[k] = replaceStrings(child, r)
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = replaceStrings(child, r)
+		}
+	}
+	return v
+}
@@ -0,0 +1,120 @@
+package packs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// selectorToken marks where template alert expressions take the matchers
+// that scope them to the monitored targets
+const selectorToken = "__SELECTOR__"
+
+// Template is a dashboard and alert rule baseline for one piece of
+// software, with datasource inputs mapped at install time
+type Template struct {
+	Name        string      `json:"name"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	Exporter    string      `json:"exporter"`
+	Inputs      []Input     `json:"inputs"`
+	Dashboards  []Dashboard `json:"-"`
+	Alerts      []Alert     `json:"alerts"`
+}
+
+// Input is a datasource a template's queries reference as ${Name}
+type Input struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+}
+
+// TemplateParams fills in a template: Datasources maps each input name to a
+// datasource UID, and Selector, when set, is a label matcher list such as
+// job="api" that scopes the alert rules
+type TemplateParams struct {
+	Datasources map[string]string
+	Selector    string
+}
+
+// Templates lists the embedded templates, with inputs left unmapped
+func Templates() ([]Template, error) {
+	entries, err := fs.ReadDir(files, "templates")
+	if err != nil {
+		return nil, err
+	}
+	var out []Template
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		t, err := readTemplate(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out, nil
+}
+
+// LoadTemplate returns the named template with its datasource inputs
+// replaced by the mapped UIDs. Every input must be mapped.
+func LoadTemplate(name string, p TemplateParams) (*Template, error) {
+	t, err := readTemplate(name)
+	if err != nil {
+		return nil, err
+	}
+	var pairs []string
+	for _, in := range t.Inputs {
+		uid := p.Datasources[in.Name]
+		if uid == "" {
+			return nil, fmt.Errorf("template %s: no datasource mapped for input %s", name, in.Name)
+		}
+		pairs = append(pairs, "${"+in.Name+"}", uid)
+	}
+	repl := strings.NewReplacer(pairs...)
+	for i := range t.Dashboards {
+		t.Dashboards[i].Model = replaceStrings(t.Dashboards[i].Model, repl).(map[string]interface{})
+	}
+
+	sel := matchAll
+	if p.Selector != "" {
+		sel = p.Selector
+	}
+	for i := range t.Alerts {
+		t.Alerts[i].Expr = strings.ReplaceAll(t.Alerts[i].Expr, selectorToken, sel)
+	}
+	return t, nil
+}
+
+func readTemplate(name string) (*Template, error) {
+	dir := path.Join("templates", name)
+	data, err := files.ReadFile(path.Join(dir, "template.json"))
+	if err != nil {
+		return nil, fmt.Errorf("unknown template %q", name)
+	}
+	var manifest struct {
+		Template
+		Dashboards []Dashboard `json:"dashboards"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("template %s: %w", name, err)
+	}
+	t := manifest.Template
+	t.Name = name
+	for _, d := range manifest.Dashboards {
+		data, err := files.ReadFile(path.Join(dir, d.File))
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &d.Model); err != nil {
+			return nil, fmt.Errorf("template %s: %s: %w", name, d.File, err)
+		}
+		t.Dashboards = append(t.Dashboards, d)
+	}
+	return &t, nil
+}
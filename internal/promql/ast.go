@@ -0,0 +1,228 @@
+// Package promql parses and lints PromQL expressions locally, without a
+// Prometheus server. It understands Grafana template variables such as
+// $__rate_interval so dashboard and alert queries can be checked as
+// written.
+//
+// The upstream parser, github.com/prometheus/prometheus/promql/parser, is
+// not used: it rejects $var, ${var}, and [[var]] wherever they appear in a
+// range, a label list, or a number, which is where dashboards put them, and
+// it would bring the Prometheus module and its dependencies into a binary
+// that otherwise needs two. Expressions without variables are meant to parse
+// as they do upstream; parse_test.go lists the valid and invalid cases.
+package promql
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValueType is the type an expression evaluates to
+type ValueType string
+
+const (
+	ValueScalar ValueType = "scalar"
+	ValueVector ValueType = "instant vector"
+	ValueMatrix ValueType = "range vector"
+	ValueString ValueType = "string"
+	// ValueAny is the type of a template variable, which could expand to anything
+	ValueAny ValueType = "any"
+)
+
+// Error is a syntax or type error at a position in the expression
+type Error struct {
+	Msg    string `json:"message"`
+	Offset int    `json:"offset"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+func newError(input string, pos int, format string, args ...interface{}) *Error {
+	if pos > len(input) {
+		pos = len(input)
+	}
+	line := 1 + strings.Count(input[:pos], "\n")
+	col := pos - strings.LastIndex(input[:pos], "\n")
+	return &Error{Msg: fmt.Sprintf(format, args...), Offset: pos, Line: line, Column: col}
+}
+
+// Expr is a node of a parsed expression
+type Expr interface {
+	Type() ValueType
+	Pos() int
+}
+
+// NumberLiteral is a scalar constant
+type NumberLiteral struct {
+	Val    string
+	Offset int
+}
+
+// StringLiteral is a quoted string
+type StringLiteral struct {
+	Val    string
+	Offset int
+}
+
+// Variable is a Grafana template variable standing in for an expression
+type Variable struct {
+	Name   string
+	Offset int
+}
+
+// Matcher is a label matcher inside a selector
+type Matcher struct {
+	Name   string
+	Op     string
+	Value  string
+	Offset int
+}
+
+// VectorSelector selects series by metric name and label matchers
+type VectorSelector struct {
+	Name     string
+	Matchers []Matcher
+	Offset   int
+}
+
+// MatrixSelector selects a range of samples, e.g. foo[5m]
+type MatrixSelector struct {
+	Vector *VectorSelector
+	Range  string
+	Offset int
+}
+
+// Subquery evaluates an instant expression over a range, e.g. expr[1h:1m]
+type Subquery struct {
+	Expr   Expr
+	Range  string
+	Step   string
+	Offset int
+}
+
+// Modified is an expression with an offset or @ modifier
+type Modified struct {
+	Expr     Expr
+	OffsetBy string
+	At       string
+	Offset   int
+}
+
+// Call is a function call
+type Call struct {
+	Func   string
+	Args   []Expr
+	Offset int
+	typ    ValueType
+}
+
+// Aggregate is an aggregation such as sum by (job) (...)
+type Aggregate struct {
+	Op       string
+	Param    Expr
+	Expr     Expr
+	Grouping []string
+	Without  bool
+	// HasGrouping is set when a by or without clause was given, even an empty one
+	HasGrouping bool
+	Offset      int
+}
+
+// Binary is a binary operation with optional vector matching
+type Binary struct {
+	Op       string
+	LHS, RHS Expr
+	Bool     bool
+	// On is set for on(...) matching and unset for ignoring(...)
+	On       bool
+	Matching []string
+	// Group is "group_left" or "group_right" for many-to-one matching
+	Group   string
+	Include []string
+	Offset  int
+	typ     ValueType
+}
+
+// Unary is a negated or explicitly positive expression
+type Unary struct {
+	Op     string
+	Expr   Expr
+	Offset int
+}
+
+// Paren is a parenthesized expression
+type Paren struct {
+	Expr   Expr
+	Offset int
+}
+
+func (e *NumberLiteral) Type() ValueType  { return ValueScalar }
+func (e *StringLiteral) Type() ValueType  { return ValueString }
+func (e *Variable) Type() ValueType       { return ValueAny }
+func (e *VectorSelector) Type() ValueType { return ValueVector }
+func (e *MatrixSelector) Type() ValueType { return ValueMatrix }
+func (e *Subquery) Type() ValueType       { return ValueMatrix }
+func (e *Modified) Type() ValueType       { return e.Expr.Type() }
+func (e *Call) Type() ValueType           { return e.typ }
+func (e *Aggregate) Type() ValueType      { return ValueVector }
+func (e *Binary) Type() ValueType         { return e.typ }
+func (e *Unary) Type() ValueType          { return e.Expr.Type() }
+func (e *Paren) Type() ValueType          { return e.Expr.Type() }
+
+func (e *NumberLiteral) Pos() int  { return e.Offset }
+func (e *StringLiteral) Pos() int  { return e.Offset }
+func (e *Variable) Pos() int       { return e.Offset }
+func (e *VectorSelector) Pos() int { return e.Offset }
+func (e *MatrixSelector) Pos() int { return e.Offset }
+func (e *Subquery) Pos() int       { return e.Offset }
+func (e *Modified) Pos() int       { return e.Offset }
+func (e *Call) Pos() int           { return e.Offset }
+func (e *Aggregate) Pos() int      { return e.Offset }
+func (e *Binary) Pos() int         { return e.Offset }
+func (e *Unary) Pos() int          { return e.Offset }
+func (e *Paren) Pos() int          { return e.Offset }
+
+// Children returns the direct subexpressions of e
+func Children(e Expr) []Expr {
+	switch n := e.(type) {
+	case *MatrixSelector:
+		return []Expr{n.Vector}
+	case *Subquery:
+		return []Expr{n.Expr}
+	case *Modified:
+		return []Expr{n.Expr}
+	case *Call:
+		return n.Args
+	case *Aggregate:
+		if n.Param != nil {
+			return []Expr{n.Param, n.Expr}
+		}
+		return []Expr{n.Expr}
+	case *Binary:
+		return []Expr{n.LHS, n.RHS}
+	case *Unary:
+		return []Expr{n.Expr}
+	case *Paren:
+		return []Expr{n.Expr}
+	}
+	return nil
+}
+
+// Inspect walks e depth first, calling fn with each node and its ancestors
+// (outermost first). Children are skipped when fn returns false.
+func Inspect(e Expr, fn func(e Expr, ancestors []Expr) bool) {
+	inspect(e, nil, fn)
+}
+
+func inspect(e Expr, ancestors []Expr, fn func(Expr, []Expr) bool) {
+	if !fn(e, ancestors) {
+		return
+	}
+	ancestors = append(ancestors, e)
+	for _, c := range Children(e) {
+		inspect(c, ancestors, fn)
+	}
+}
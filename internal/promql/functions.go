@@ -0,0 +1,118 @@
+package promql
+
+// function describes a PromQL function signature. The last optional args
+// may be omitted; a variadic function repeats its last argument type.
+type function struct {
+	args     []ValueType
+	optional int
+	variadic bool
+	ret      ValueType
+}
+
+// short names keep the signature table readable
+const (
+	scal = ValueScalar
+	vec  = ValueVector
+	mat  = ValueMatrix
+	str  = ValueString
+)
+
+var functions = map[string]function{
+	"abs":                          {args: []ValueType{vec}, ret: vec},
+	"absent":                       {args: []ValueType{vec}, ret: vec},
+	"absent_over_time":             {args: []ValueType{mat}, ret: vec},
+	"acos":                         {args: []ValueType{vec}, ret: vec},
+	"acosh":                        {args: []ValueType{vec}, ret: vec},
+	"asin":                         {args: []ValueType{vec}, ret: vec},
+	"asinh":                        {args: []ValueType{vec}, ret: vec},
+	"atan":                         {args: []ValueType{vec}, ret: vec},
+	"atanh":                        {args: []ValueType{vec}, ret: vec},
+	"avg_over_time":                {args: []ValueType{mat}, ret: vec},
+	"ceil":                         {args: []ValueType{vec}, ret: vec},
+	"changes":                      {args: []ValueType{mat}, ret: vec},
+	"clamp":                        {args: []ValueType{vec, scal, scal}, ret: vec},
+	"clamp_max":                    {args: []ValueType{vec, scal}, ret: vec},
+	"clamp_min":                    {args: []ValueType{vec, scal}, ret: vec},
+	"cos":                          {args: []ValueType{vec}, ret: vec},
+	"cosh":                         {args: []ValueType{vec}, ret: vec},
+	"count_over_time":              {args: []ValueType{mat}, ret: vec},
+	"day_of_month":                 {args: []ValueType{vec}, optional: 1, ret: vec},
+	"day_of_week":                  {args: []ValueType{vec}, optional: 1, ret: vec},
+	"day_of_year":                  {args: []ValueType{vec}, optional: 1, ret: vec},
+	"days_in_month":                {args: []ValueType{vec}, optional: 1, ret: vec},
+	"deg":                          {args: []ValueType{vec}, ret: vec},
+	"delta":                        {args: []ValueType{mat}, ret: vec},
+	"deriv":                        {args: []ValueType{mat}, ret: vec},
+	"double_exponential_smoothing": {args: []ValueType{mat, scal, scal}, ret: vec},
+	"exp":                          {args: []ValueType{vec}, ret: vec},
+	"floor":                        {args: []ValueType{vec}, ret: vec},
+	"histogram_avg":                {args: []ValueType{vec}, ret: vec},
+	"histogram_count":              {args: []ValueType{vec}, ret: vec},
+	"histogram_fraction":           {args: []ValueType{scal, scal, vec}, ret: vec},
+	"histogram_quantile":           {args: []ValueType{scal, vec}, ret: vec},
+	"histogram_stddev":             {args: []ValueType{vec}, ret: vec},
+	"histogram_stdvar":             {args: []ValueType{vec}, ret: vec},
+	"histogram_sum":                {args: []ValueType{vec}, ret: vec},
+	"holt_winters":                 {args: []ValueType{mat, scal, scal}, ret: vec},
+	"hour":                         {args: []ValueType{vec}, optional: 1, ret: vec},
+	"idelta":                       {args: []ValueType{mat}, ret: vec},
+	"increase":                     {args: []ValueType{mat}, ret: vec},
+	"info":                         {args: []ValueType{vec, vec}, optional: 1, ret: vec},
+	"irate":                        {args: []ValueType{mat}, ret: vec},
+	"label_join":                   {args: []ValueType{vec, str, str, str}, variadic: true, ret: vec},
+	"label_replace":                {args: []ValueType{vec, str, str, str, str}, ret: vec},
+	"last_over_time":               {args: []ValueType{mat}, ret: vec},
+	"ln":                           {args: []ValueType{vec}, ret: vec},
+	"log10":                        {args: []ValueType{vec}, ret: vec},
+	"log2":                         {args: []ValueType{vec}, ret: vec},
+	"mad_over_time":                {args: []ValueType{mat}, ret: vec},
+	"max_over_time":                {args: []ValueType{mat}, ret: vec},
+	"min_over_time":                {args: []ValueType{mat}, ret: vec},
+	"minute":                       {args: []ValueType{vec}, optional: 1, ret: vec},
+	"month":                        {args: []ValueType{vec}, optional: 1, ret: vec},
+	"pi":                           {ret: scal},
+	"predict_linear":               {args: []ValueType{mat, scal}, ret: vec},
+	"present_over_time":            {args: []ValueType{mat}, ret: vec},
+	"quantile_over_time":           {args: []ValueType{scal, mat}, ret: vec},
+	"rad":                          {args: []ValueType{vec}, ret: vec},
+	"rate":                         {args: []ValueType{mat}, ret: vec},
+	"resets":                       {args: []ValueType{mat}, ret: vec},
+	"round":                        {args: []ValueType{vec, scal}, optional: 1, ret: vec},
+	"scalar":                       {args: []ValueType{vec}, ret: scal},
+	"sgn":                          {args: []ValueType{vec}, ret: vec},
+	"sin":                          {args: []ValueType{vec}, ret: vec},
+	"sinh":                         {args: []ValueType{vec}, ret: vec},
+	"sort":                         {args: []ValueType{vec}, ret: vec},
+	"sort_by_label":                {args: []ValueType{vec, str}, variadic: true, ret: vec},
+	"sort_by_label_desc":           {args: []ValueType{vec, str}, variadic: true, ret: vec},
+	"sort_desc":                    {args: []ValueType{vec}, ret: vec},
+	"sqrt":                         {args: []ValueType{vec}, ret: vec},
+	"stddev_over_time":             {args: []ValueType{mat}, ret: vec},
+	"stdvar_over_time":             {args: []ValueType{mat}, ret: vec},
+	"sum_over_time":                {args: []ValueType{mat}, ret: vec},
+	"tan":                          {args: []ValueType{vec}, ret: vec},
+	"tanh":                         {args: []ValueType{vec}, ret: vec},
+	"time":                         {ret: scal},
+	"timestamp":                    {args: []ValueType{vec}, ret: vec},
+	"vector":                       {args: []ValueType{scal}, ret: vec},
+	"year":                         {args: []ValueType{vec}, optional: 1, ret: vec},
+}
+
+// aggregations maps each aggregation operator to the type of its
+// parameter, or "" if it takes none
+var aggregations = map[string]ValueType{
+	"avg":          "",
+	"bottomk":      ValueScalar,
+	"count":        "",
+	"count_values": ValueString,
+	"group":        "",
+	"limit_ratio":  ValueScalar,
+	"limitk":       ValueScalar,
+	"max":          "",
+	"min":          "",
+	"quantile":     ValueScalar,
+	"stddev":       "",
+	"stdvar":       "",
+	"sum":          "",
+	"topk":         ValueScalar,
+}
@@ -0,0 +1,271 @@
+package promql
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokDuration
+	tokString
+	tokVariable
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	val  string
+	pos  int
+}
+
+// operators and punctuation, longest first so "=~" wins over "="
+var punctuation = []string{
+	"==", "!=", ">=", "<=", "=~", "!~",
+	"+", "-", "*", "/", "%", "^", ">", "<", "=",
+	"(", ")", "{", "}", "[", "]", ",", ":", "@",
+}
+
+type lexer struct {
+	input string
+	pos   int
+	// brackets is the [ ] nesting depth; inside brackets ':' separates a
+	// subquery range from its step instead of starting a metric name
+	brackets int
+}
+
+// lex splits input into tokens, failing on the first malformed one
+func lex(input string) ([]token, error) {
+	l := &lexer{input: input}
+	var toks []token
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		toks = append(toks, t)
+		if t.kind == tokEOF {
+			return toks, nil
+		}
+	}
+}
+
+func (l *lexer) errorf(pos int, format string, args ...interface{}) error {
+	return newError(l.input, pos, format, args...)
+}
+
+func (l *lexer) next() (token, error) {
+	l.skipSpaceAndComments()
+	if l.pos >= len(l.input) {
+		return token{kind: tokEOF, pos: l.pos}, nil
+	}
+	start := l.pos
+	c := l.input[l.pos]
+	rest := l.input[l.pos:]
+
+	switch {
+	case c == '$':
+		return l.lexVariable()
+	case strings.HasPrefix(rest, "[["):
+		end := strings.Index(rest, "]]")
+		if end < 0 {
+			return token{}, l.errorf(start, "unterminated [[variable]]")
+		}
+		l.pos += end + 2
+		return token{kind: tokVariable, val: l.input[start:l.pos], pos: start}, nil
+	case c == '"' || c == '\'' || c == '`':
+		return l.lexString(c)
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.input) && isDigit(l.input[l.pos+1])):
+		return l.lexNumberOrDuration()
+	case isIdentStart(c) && !(c == ':' && l.brackets > 0):
+		for l.pos < len(l.input) && isIdentChar(l.input[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokIdent, val: l.input[start:l.pos], pos: start}, nil
+	}
+
+	for _, p := range punctuation {
+		if strings.HasPrefix(rest, p) {
+			l.pos += len(p)
+			switch p {
+			case "[":
+				l.brackets++
+			case "]":
+				l.brackets--
+			}
+			return token{kind: tokPunct, val: p, pos: start}, nil
+		}
+	}
+	r, _ := utf8.DecodeRuneInString(rest)
+	return token{}, l.errorf(start, "unexpected character %q", r)
+}
+
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.input) {
+		switch c := l.input[l.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			l.pos++
+		case c == '#':
+			for l.pos < len(l.input) && l.input[l.pos] != '\n' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// lexVariable reads a Grafana template variable: $name, ${name}, or
+// ${name:format}
+func (l *lexer) lexVariable() (token, error) {
+	start := l.pos
+	l.pos++
+	if l.pos < len(l.input) && l.input[l.pos] == '{' {
+		end := strings.IndexByte(l.input[l.pos:], '}')
+		if end < 0 {
+			return token{}, l.errorf(start, "unterminated ${variable}")
+		}
+		l.pos += end + 1
+	} else {
+		for l.pos < len(l.input) && isIdentChar(l.input[l.pos]) && l.input[l.pos] != ':' {
+			l.pos++
+		}
+		if l.pos == start+1 {
+			return token{}, l.errorf(start, "unexpected character '$'")
+		}
+	}
+	return token{kind: tokVariable, val: l.input[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) lexString(quote byte) (token, error) {
+	start := l.pos
+	l.pos++
+	var b strings.Builder
+	for {
+		if l.pos >= len(l.input) {
+			return token{}, l.errorf(start, "unterminated string")
+		}
+		c := l.input[l.pos]
+		switch {
+		case c == quote:
+			l.pos++
+			return token{kind: tokString, val: b.String(), pos: start}, nil
+		case c == '\n' && quote != '`':
+			return token{}, l.errorf(start, "unterminated string")
+		case c == '\\' && quote != '`':
+			if l.pos+1 >= len(l.input) {
+				return token{}, l.errorf(start, "unterminated string")
+			}
+			esc := l.input[l.pos+1]
+			switch esc {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case '\\', '"', '\'':
+				b.WriteByte(esc)
+			default:
+				// Unknown escapes are kept so regular expressions such as
+				// "\\d+" written as "\d+" still reach the regex compiler
+				b.WriteByte('\\')
+				b.WriteByte(esc)
+			}
+			l.pos += 2
+		default:
+			b.WriteByte(c)
+			l.pos++
+		}
+	}
+}
+
+// lexNumberOrDuration reads a float, hex integer, or duration such as 1h30m
+func (l *lexer) lexNumberOrDuration() (token, error) {
+	start := l.pos
+	if strings.HasPrefix(l.input[l.pos:], "0x") || strings.HasPrefix(l.input[l.pos:], "0X") {
+		l.pos += 2
+		for l.pos < len(l.input) && isHexDigit(l.input[l.pos]) {
+			l.pos++
+		}
+		return l.finishNumber(start)
+	}
+
+	l.digits()
+	if l.pos < len(l.input) && durationUnitAt(l.input[l.pos:]) > 0 {
+		for {
+			n := durationUnitAt(l.input[l.pos:])
+			if n == 0 {
+				return token{}, l.errorf(start, "invalid duration %q", l.input[start:l.pos])
+			}
+			l.pos += n
+			if l.pos >= len(l.input) || !isDigit(l.input[l.pos]) {
+				break
+			}
+			l.digits()
+		}
+		if l.pos < len(l.input) && isAlnum(l.input[l.pos]) {
+			return token{}, l.errorf(start, "invalid duration %q", l.input[start:l.pos+1])
+		}
+		return token{kind: tokDuration, val: l.input[start:l.pos], pos: start}, nil
+	}
+
+	if l.pos < len(l.input) && l.input[l.pos] == '.' {
+		l.pos++
+		l.digits()
+	}
+	if l.pos < len(l.input) && (l.input[l.pos] == 'e' || l.input[l.pos] == 'E') {
+		l.pos++
+		if l.pos < len(l.input) && (l.input[l.pos] == '+' || l.input[l.pos] == '-') {
+			l.pos++
+		}
+		if l.pos >= len(l.input) || !isDigit(l.input[l.pos]) {
+			return token{}, l.errorf(start, "invalid number %q", l.input[start:l.pos])
+		}
+		l.digits()
+	}
+	return l.finishNumber(start)
+}
+
+func (l *lexer) finishNumber(start int) (token, error) {
+	if l.pos < len(l.input) && isAlnum(l.input[l.pos]) {
+		return token{}, l.errorf(start, "invalid number %q", l.input[start:l.pos+1])
+	}
+	return token{kind: tokNumber, val: l.input[start:l.pos], pos: start}, nil
+}
+
+func (l *lexer) digits() {
+	for l.pos < len(l.input) && (isDigit(l.input[l.pos]) || l.input[l.pos] == '_') {
+		l.pos++
+	}
+}
+
+// durationUnitAt returns the length of the duration unit at the start of
+// s, or 0 if there is none
+func durationUnitAt(s string) int {
+	if strings.HasPrefix(s, "ms") {
+		return 2
+	}
+	if s != "" && strings.IndexByte("smhdwy", s[0]) >= 0 {
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentChar(c byte) bool { return isIdentStart(c) || isDigit(c) }
+
+func isAlnum(c byte) bool { return c != ':' && isIdentChar(c) }
@@ -0,0 +1,188 @@
+package promql
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Warning is a lint finding: valid PromQL that is probably not what was meant
+type Warning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	offset  int
+}
+
+var counterSuffixes = []string{"_total", "_count", "_sum", "_bucket"}
+
+// counterSafe are functions whose result is meaningful on a raw counter
+var counterSafe = map[string]bool{
+	"rate": true, "irate": true, "increase": true, "resets": true,
+	"absent": true, "absent_over_time": true, "present_over_time": true,
+	"count_over_time": true, "changes": true, "timestamp": true,
+}
+
+// rateFuncs compute a per-second or total rate of a counter
+var rateFuncs = map[string]bool{"rate": true, "irate": true, "increase": true}
+
+// Lint reports suspicious constructs in a parsed expression: counters used
+// without rate(), rates of aggregated series, histogram quantiles that drop
+// the le label, $__interval ranges in rates, and label matchers that are
+// redundant, contradictory, or not doing what they look like.
+func Lint(input string, expr Expr) []Warning {
+	l := &linter{input: input}
+	Inspect(expr, func(e Expr, ancestors []Expr) bool {
+		switch n := e.(type) {
+		case *VectorSelector:
+			l.counter(n, ancestors)
+			l.matchers(n)
+		case *Call:
+			l.call(n)
+		}
+		return true
+	})
+	sort.SliceStable(l.warnings, func(i, j int) bool { return l.warnings[i].offset < l.warnings[j].offset })
+	return l.warnings
+}
+
+type linter struct {
+	input    string
+	warnings []Warning
+}
+
+func (l *linter) warn(pos int, rule, format string, args ...interface{}) {
+	e := newError(l.input, pos, format, args...)
+	l.warnings = append(l.warnings, Warning{Rule: rule, Message: e.Msg, Line: e.Line, Column: e.Column, offset: pos})
+}
+
+func isCounterName(name string) bool {
+	for _, s := range counterSuffixes {
+		if strings.HasSuffix(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *linter) counter(vs *VectorSelector, ancestors []Expr) {
+	name := vs.Name
+	for _, mt := range vs.Matchers {
+		if mt.Name == "__name__" && mt.Op == "=" {
+			name = mt.Value
+		}
+	}
+	if !isCounterName(name) {
+		return
+	}
+	for _, a := range ancestors {
+		switch n := a.(type) {
+		case *Call:
+			if counterSafe[n.Func] {
+				return
+			}
+		case *Aggregate:
+			if n.Op == "count" || n.Op == "group" || n.Op == "count_values" {
+				return
+			}
+		}
+	}
+	l.warn(vs.Offset, "counter-without-rate",
+		"%s looks like a counter but is used without rate() or increase(); raw counter values only ever go up and reset on restarts", name)
+}
+
+func (l *linter) call(c *Call) {
+	if rateFuncs[c.Func] && len(c.Args) == 1 {
+		switch arg := unparen(c.Args[0]).(type) {
+		case *MatrixSelector:
+			if arg.Range == "$__interval" || arg.Range == "${__interval}" {
+				l.warn(c.Offset, "rate-interval",
+					"%s over $__interval can return no data when the interval is shorter than two scrapes; use $__rate_interval", c.Func)
+			}
+		case *Subquery:
+			if inner := unparen(arg.Expr); isAggregate(inner) {
+				l.warn(c.Offset, "rate-of-aggregate",
+					"%s over an aggregated subquery mishandles counter resets; aggregate after the rate instead, e.g. sum(%s(metric[5m]))", c.Func, c.Func)
+			}
+		}
+	}
+
+	if c.Func == "histogram_quantile" && len(c.Args) == 2 {
+		if agg, ok := unparen(c.Args[1]).(*Aggregate); ok && agg.Op != "topk" && agg.Op != "bottomk" {
+			keepsLe := (!agg.Without && contains(agg.Grouping, "le")) || (agg.Without && !contains(agg.Grouping, "le"))
+			if !keepsLe {
+				l.warn(agg.Offset, "histogram-quantile-le",
+					"%s drops the le label, so histogram_quantile has no buckets to work with; add le to the by clause", agg.Op)
+			}
+		}
+	}
+}
+
+func (l *linter) matchers(vs *VectorSelector) {
+	equal := map[string]string{}
+	for _, mt := range vs.Matchers {
+		if HasVariables(mt.Value) {
+			continue
+		}
+		switch mt.Op {
+		case "=":
+			if prev, ok := equal[mt.Name]; ok && prev != mt.Value {
+				l.warn(mt.Offset, "conflicting-matchers",
+					"%s cannot equal both %q and %q; this selector never matches", mt.Name, prev, mt.Value)
+			}
+			equal[mt.Name] = mt.Value
+			if mt.Value == "" {
+				l.warn(mt.Offset, "empty-matcher",
+					"%s=\"\" selects series without the %s label; use %s!=\"\" to require it", mt.Name, mt.Name, mt.Name)
+			}
+		case "=~", "!~":
+			l.regexMatcher(mt)
+		}
+	}
+}
+
+func (l *linter) regexMatcher(mt Matcher) {
+	switch {
+	case mt.Value == ".*" && mt.Op == "=~":
+		l.warn(mt.Offset, "match-all-regex",
+			"%s=~\".*\" matches every series, including those without %s; remove it or use %s=~\".+\"", mt.Name, mt.Name, mt.Name)
+	case mt.Value == ".*" && mt.Op == "!~":
+		l.warn(mt.Offset, "never-matches",
+			"%s!~\".*\" excludes every series; this selector never matches", mt.Name)
+	case strings.HasPrefix(mt.Value, "^") || (strings.HasSuffix(mt.Value, "$") && !strings.HasSuffix(mt.Value, `\$`)):
+		l.warn(mt.Offset, "anchored-regex",
+			"%s%s%q: label matcher regexes are always fully anchored, so ^ and $ are redundant", mt.Name, mt.Op, mt.Value)
+	case mt.Value != "" && regexp.QuoteMeta(mt.Value) == mt.Value:
+		op := "="
+		if mt.Op == "!~" {
+			op = "!="
+		}
+		l.warn(mt.Offset, "regex-without-metacharacters",
+			"%s%s%q has no regex metacharacters; %s%s%q is equivalent and cheaper", mt.Name, mt.Op, mt.Value, mt.Name, op, mt.Value)
+	}
+}
+
+func unparen(e Expr) Expr {
+	for {
+		p, ok := e.(*Paren)
+		if !ok {
+			return e
+		}
+		e = p.Expr
+	}
+}
+
+func isAggregate(e Expr) bool {
+	_, ok := e.(*Aggregate)
+	return ok
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
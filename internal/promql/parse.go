@@ -0,0 +1,553 @@
+package promql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// binaryPrec is the precedence of each binary operator; higher binds tighter
+var binaryPrec = map[string]int{
+	"or":  1,
+	"and": 2, "unless": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5, "atan2": 5,
+	"^": 6,
+}
+
+var (
+	setOps        = map[string]bool{"and": true, "or": true, "unless": true}
+	comparisonOps = map[string]bool{"==": true, "!=": true, "<": true, "<=": true, ">": true, ">=": true}
+	matchOps      = map[string]bool{"=": true, "!=": true, "=~": true, "!~": true}
+	// keywords that can never start an expression
+	reserved = map[string]bool{"and": true, "or": true, "unless": true, "atan2": true, "bool": true, "offset": true}
+)
+
+// grafanaVariable matches template variable references inside strings
+var grafanaVariable = regexp.MustCompile(`\$\w|\$\{|\[\[`)
+
+// HasVariables reports whether s references a Grafana template variable
+func HasVariables(s string) bool {
+	return grafanaVariable.MatchString(s)
+}
+
+type parser struct {
+	input string
+	toks  []token
+	pos   int
+}
+
+// Parse parses and type-checks a PromQL expression. The returned error is
+// an *Error locating the first problem.
+func Parse(input string) (expr Expr, err error) {
+	toks, lexErr := lex(input)
+	if lexErr != nil {
+		return nil, lexErr
+	}
+	p := &parser{input: input, toks: toks}
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				panic(r)
+			}
+			expr, err = nil, e
+		}
+	}()
+
+	if p.peek().kind == tokEOF {
+		p.fail(0, "empty expression")
+	}
+	expr = p.parseExpr(0)
+	if t := p.peek(); t.kind != tokEOF {
+		if t.kind == tokPunct && t.val == "=" {
+			p.fail(t.pos, "unexpected \"=\"; use \"==\" to compare")
+		}
+		p.fail(t.pos, "unexpected %s", describe(t))
+	}
+	return expr, nil
+}
+
+func (p *parser) fail(pos int, format string, args ...interface{}) {
+	panic(newError(p.input, pos, format, args...))
+}
+
+func (p *parser) peek() token { return p.toks[p.pos] }
+
+func (p *parser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) isPunct(val string) bool {
+	t := p.peek()
+	return t.kind == tokPunct && t.val == val
+}
+
+func (p *parser) isIdent(vals ...string) bool {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return false
+	}
+	for _, v := range vals {
+		if t.val == v {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(val string) token {
+	t := p.next()
+	if t.kind != tokPunct || t.val != val {
+		p.fail(t.pos, "expected %q, got %s", val, describe(t))
+	}
+	return t
+}
+
+func describe(t token) string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokString:
+		return fmt.Sprintf("string %q", t.val)
+	default:
+		return fmt.Sprintf("%q", t.val)
+	}
+}
+
+func binaryOp(t token) (string, int, bool) {
+	if t.kind != tokPunct && t.kind != tokIdent {
+		return "", 0, false
+	}
+	prec, ok := binaryPrec[t.val]
+	return t.val, prec, ok
+}
+
+func (p *parser) parseExpr(minPrec int) Expr {
+	lhs := p.parseUnary()
+	for {
+		t := p.peek()
+		op, prec, ok := binaryOp(t)
+		if !ok || prec < minPrec {
+			return lhs
+		}
+		p.next()
+		b := &Binary{Op: op, LHS: lhs, Offset: t.pos}
+		matching := p.parseBinaryModifiers(b)
+		next := prec + 1
+		if op == "^" {
+			next = prec
+		}
+		b.RHS = p.parseExpr(next)
+		p.checkBinary(b, matching)
+		lhs = b
+	}
+}
+
+// parseBinaryModifiers reads bool, on/ignoring, and group_left/group_right,
+// reporting whether vector matching was specified
+func (p *parser) parseBinaryModifiers(b *Binary) bool {
+	if p.isIdent("bool") {
+		t := p.next()
+		if !comparisonOps[b.Op] {
+			p.fail(t.pos, "bool modifier can only be used on comparison operators")
+		}
+		b.Bool = true
+	}
+	if !p.isIdent("on", "ignoring") {
+		if p.isIdent("group_left", "group_right") {
+			p.fail(p.peek().pos, "%s requires on(...) or ignoring(...)", p.peek().val)
+		}
+		return false
+	}
+	b.On = p.next().val == "on"
+	b.Matching = p.parseLabelList()
+	if p.isIdent("group_left", "group_right") {
+		t := p.next()
+		if setOps[b.Op] {
+			p.fail(t.pos, "no grouping allowed for %q operation", b.Op)
+		}
+		b.Group = t.val
+		if p.isPunct("(") {
+			b.Include = p.parseLabelList()
+		}
+	}
+	return true
+}
+
+func (p *parser) checkBinary(b *Binary, matching bool) {
+	l, r := b.LHS.Type(), b.RHS.Type()
+	for _, side := range []Expr{b.LHS, b.RHS} {
+		if typ := side.Type(); typ != ValueScalar && typ != ValueVector && typ != ValueAny {
+			p.fail(side.Pos(), "binary expression must contain only scalar and instant vector types, got %s", typ)
+		}
+	}
+	switch {
+	case setOps[b.Op] && (l == ValueScalar || r == ValueScalar):
+		p.fail(b.Offset, "set operator %q not allowed in binary scalar expression", b.Op)
+	case comparisonOps[b.Op] && l == ValueScalar && r == ValueScalar && !b.Bool:
+		p.fail(b.Offset, "comparisons between scalars must use the bool modifier")
+	case matching && (l == ValueScalar || r == ValueScalar):
+		p.fail(b.Offset, "vector matching only allowed between instant vectors")
+	}
+	switch {
+	case l == ValueVector || r == ValueVector:
+		b.typ = ValueVector
+	case l == ValueAny || r == ValueAny:
+		b.typ = ValueAny
+	default:
+		b.typ = ValueScalar
+	}
+}
+
+func (p *parser) parseUnary() Expr {
+	if p.isPunct("-") || p.isPunct("+") {
+		t := p.next()
+		e := p.parseExpr(binaryPrec["^"])
+		if typ := e.Type(); typ != ValueScalar && typ != ValueVector && typ != ValueAny {
+			p.fail(t.pos, "unary expression only allowed on expressions of type scalar or instant vector, got %s", typ)
+		}
+		return &Unary{Op: t.val, Expr: e, Offset: t.pos}
+	}
+	return p.parsePostfix()
+}
+
+// parsePostfix reads a primary expression followed by ranges, subqueries,
+// and offset or @ modifiers
+func (p *parser) parsePostfix() Expr {
+	e := p.parsePrimary()
+	for {
+		switch {
+		case p.isPunct("["):
+			e = p.parseRange(e)
+		case p.isIdent("offset"), p.isPunct("@"):
+			e = p.parseModifier(e)
+		default:
+			return e
+		}
+	}
+}
+
+func (p *parser) parseRange(e Expr) Expr {
+	open := p.expect("[")
+	rng := p.parseDuration("range")
+	if p.isPunct(":") {
+		p.next()
+		var step string
+		if !p.isPunct("]") {
+			step = p.parseDuration("subquery step")
+		}
+		p.expect("]")
+		if typ := e.Type(); typ != ValueVector && typ != ValueAny {
+			p.fail(open.pos, "subquery is only allowed on instant vector, got %s", typ)
+		}
+		return &Subquery{Expr: e, Range: rng, Step: step, Offset: e.Pos()}
+	}
+	p.expect("]")
+	vs, ok := e.(*VectorSelector)
+	if !ok {
+		if _, isVar := e.(*Variable); isVar {
+			return &Subquery{Expr: e, Range: rng, Offset: e.Pos()}
+		}
+		p.fail(open.pos, "ranges only allowed for vector selectors; use a subquery such as [%s:] for expressions", rng)
+	}
+	return &MatrixSelector{Vector: vs, Range: rng, Offset: vs.Offset}
+}
+
+func (p *parser) parseDuration(what string) string {
+	t := p.next()
+	if t.kind != tokDuration && t.kind != tokVariable {
+		if t.kind == tokNumber {
+			p.fail(t.pos, "%s %q needs a unit, e.g. %ss", what, t.val, t.val)
+		}
+		p.fail(t.pos, "expected %s duration, got %s", what, describe(t))
+	}
+	return t.val
+}
+
+func (p *parser) parseModifier(e Expr) Expr {
+	mod, ok := e.(*Modified)
+	if !ok {
+		mod = &Modified{Expr: e, Offset: e.Pos()}
+	}
+	switch e := mod.Expr.(type) {
+	case *VectorSelector, *MatrixSelector, *Subquery, *Variable:
+	default:
+		p.fail(p.peek().pos, "offset and @ modifiers must follow a selector or subquery, not a %s expression", describeNode(e))
+	}
+
+	t := p.next()
+	if t.val == "offset" {
+		if mod.OffsetBy != "" {
+			p.fail(t.pos, "offset may not be set multiple times")
+		}
+		sign := ""
+		if p.isPunct("-") {
+			p.next()
+			sign = "-"
+		}
+		mod.OffsetBy = sign + p.parseDuration("offset")
+		return mod
+	}
+
+	if mod.At != "" {
+		p.fail(t.pos, "@ may not be set multiple times")
+	}
+	switch at := p.next(); {
+	case at.kind == tokNumber, at.kind == tokVariable:
+		mod.At = at.val
+	case at.kind == tokIdent && (at.val == "start" || at.val == "end"):
+		p.expect("(")
+		p.expect(")")
+		mod.At = at.val + "()"
+	default:
+		p.fail(at.pos, "expected a timestamp, start(), or end() after @, got %s", describe(at))
+	}
+	return mod
+}
+
+func describeNode(e Expr) string {
+	switch n := e.(type) {
+	case *Call:
+		return n.Func + "()"
+	case *Aggregate:
+		return n.Op
+	case *Binary:
+		return "binary"
+	case *Paren:
+		return "parenthesized"
+	case *NumberLiteral:
+		return "number"
+	case *StringLiteral:
+		return "string"
+	}
+	return "compound"
+}
+
+func (p *parser) parsePrimary() Expr {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		return &NumberLiteral{Val: t.val, Offset: t.pos}
+	case tokString:
+		return &StringLiteral{Val: t.val, Offset: t.pos}
+	case tokDuration:
+		p.fail(t.pos, "unexpected duration %q outside a range or offset", t.val)
+	case tokVariable:
+		if p.isPunct("{") {
+			p.next()
+			return p.parseSelector(t.val, t.pos, true)
+		}
+		return &Variable{Name: t.val, Offset: t.pos}
+	case tokPunct:
+		switch t.val {
+		case "(":
+			e := p.parseExpr(0)
+			p.expect(")")
+			return &Paren{Expr: e, Offset: t.pos}
+		case "{":
+			return p.parseSelector("", t.pos, true)
+		}
+		p.fail(t.pos, "unexpected %s", describe(t))
+	case tokIdent:
+		lower := strings.ToLower(t.val)
+		if lower == "inf" || lower == "nan" {
+			return &NumberLiteral{Val: t.val, Offset: t.pos}
+		}
+		if reserved[t.val] {
+			p.fail(t.pos, "unexpected keyword %q", t.val)
+		}
+		if _, ok := aggregations[t.val]; ok && (p.isPunct("(") || p.isIdent("by", "without")) {
+			return p.parseAggregate(t)
+		}
+		if p.isPunct("(") {
+			return p.parseCall(t)
+		}
+		brace := p.isPunct("{")
+		if brace {
+			p.next()
+		}
+		return p.parseSelector(t.val, t.pos, brace)
+	}
+	p.fail(t.pos, "unexpected %s", describe(t))
+	return nil
+}
+
+// parseSelector reads the label matchers of a selector whose opening brace,
+// if any, has been consumed
+func (p *parser) parseSelector(name string, pos int, brace bool) Expr {
+	vs := &VectorSelector{Name: name, Offset: pos}
+	for brace && !p.isPunct("}") {
+		lt := p.next()
+		if lt.kind != tokIdent && lt.kind != tokString {
+			p.fail(lt.pos, "expected label name, got %s", describe(lt))
+		}
+		ot := p.next()
+		if ot.kind != tokPunct || !matchOps[ot.val] {
+			if ot.val == "==" {
+				p.fail(ot.pos, "unexpected \"==\" in label matcher; use \"=\"")
+			}
+			p.fail(ot.pos, "expected label matching operator, got %s", describe(ot))
+		}
+		vt := p.next()
+		if vt.kind != tokString {
+			if vt.kind == tokVariable {
+				p.fail(vt.pos, "label matcher values must be quoted, e.g. %s%q", ot.val, vt.val)
+			}
+			p.fail(vt.pos, "expected quoted label value, got %s", describe(vt))
+		}
+		mt := Matcher{Name: lt.val, Op: ot.val, Value: vt.val, Offset: lt.pos}
+		if (mt.Op == "=~" || mt.Op == "!~") && !HasVariables(mt.Value) {
+			if _, err := regexp.Compile(mt.Value); err != nil {
+				p.fail(vt.pos, "invalid regular expression in matcher %s%s%q: %v", mt.Name, mt.Op, mt.Value, err)
+			}
+		}
+		if mt.Name == "__name__" && name != "" {
+			p.fail(lt.pos, "metric name must not be set twice: %q and %q", name, mt.Value)
+		}
+		vs.Matchers = append(vs.Matchers, mt)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	if brace {
+		p.expect("}")
+	}
+
+	if name == "" {
+		nonEmpty := false
+		for _, mt := range vs.Matchers {
+			if !mt.MatchesEmpty() {
+				nonEmpty = true
+			}
+		}
+		if !nonEmpty {
+			p.fail(pos, "vector selector must contain at least one non-empty matcher")
+		}
+	}
+	return vs
+}
+
+// MatchesEmpty reports whether the matcher selects series that lack the
+// label. Values with template variables are assumed not to.
+func (mt Matcher) MatchesEmpty() bool {
+	if HasVariables(mt.Value) {
+		return false
+	}
+	switch mt.Op {
+	case "=":
+		return mt.Value == ""
+	case "!=":
+		return mt.Value != ""
+	}
+	re, err := regexp.Compile("^(?:" + mt.Value + ")$")
+	if err != nil {
+		return false
+	}
+	if mt.Op == "=~" {
+		return re.MatchString("")
+	}
+	return !re.MatchString("")
+}
+
+func (p *parser) parseArgs() []Expr {
+	p.expect("(")
+	var args []Expr
+	for !p.isPunct(")") {
+		args = append(args, p.parseExpr(0))
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	p.expect(")")
+	return args
+}
+
+func (p *parser) parseCall(name token) Expr {
+	fn, ok := functions[name.val]
+	if !ok {
+		p.fail(name.pos, "unknown function %q", name.val)
+	}
+	call := &Call{Func: name.val, Args: p.parseArgs(), Offset: name.pos, typ: fn.ret}
+
+	min, max := len(fn.args)-fn.optional, len(fn.args)
+	switch n := len(call.Args); {
+	case n < min:
+		p.fail(name.pos, "expected at least %d argument(s) in call to %q, got %d", min, name.val, n)
+	case n > max && !fn.variadic:
+		p.fail(name.pos, "expected at most %d argument(s) in call to %q, got %d", max, name.val, n)
+	}
+	for i, arg := range call.Args {
+		want := fn.args[len(fn.args)-1]
+		if i < len(fn.args) {
+			want = fn.args[i]
+		}
+		if got := arg.Type(); got != want && got != ValueAny {
+			p.fail(arg.Pos(), "expected type %s in call to %q, got %s", want, name.val, got)
+		}
+	}
+	return call
+}
+
+func (p *parser) parseAggregate(op token) Expr {
+	agg := &Aggregate{Op: op.val, Offset: op.pos}
+	if p.isIdent("by", "without") {
+		p.parseGrouping(agg)
+	}
+	args := p.parseArgs()
+	if !agg.HasGrouping && p.isIdent("by", "without") {
+		p.parseGrouping(agg)
+	}
+
+	paramType := aggregations[op.val]
+	want := 1
+	if paramType != "" {
+		want = 2
+	}
+	if len(args) != want {
+		p.fail(op.pos, "wrong number of arguments for %s, expected %d, got %d", op.val, want, len(args))
+	}
+	if paramType != "" {
+		agg.Param = args[0]
+		if got := agg.Param.Type(); got != paramType && got != ValueAny {
+			p.fail(agg.Param.Pos(), "expected type %s for %s parameter, got %s", paramType, op.val, got)
+		}
+	}
+	agg.Expr = args[len(args)-1]
+	if got := agg.Expr.Type(); got != ValueVector && got != ValueAny {
+		p.fail(agg.Expr.Pos(), "expected type instant vector in %s, got %s", op.val, got)
+	}
+	return agg
+}
+
+func (p *parser) parseGrouping(agg *Aggregate) {
+	agg.Without = p.next().val == "without"
+	agg.HasGrouping = true
+	agg.Grouping = p.parseLabelList()
+}
+
+// parseLabelList reads a parenthesized, comma-separated list of label names
+func (p *parser) parseLabelList() []string {
+	p.expect("(")
+	var labels []string
+	for !p.isPunct(")") {
+		t := p.next()
+		if t.kind != tokIdent && t.kind != tokString && t.kind != tokVariable {
+			p.fail(t.pos, "expected label name, got %s", describe(t))
+		}
+		labels = append(labels, t.val)
+		if !p.isPunct(",") {
+			break
+		}
+		p.next()
+	}
+	p.expect(")")
+	return labels
+}
@@ -0,0 +1,147 @@
+package promql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseValid(t *testing.T) {
+	tests := []struct {
+		expr string
+		typ  ValueType
+	}{
+		{`up`, ValueVector},
+		{`up{job="api"}`, ValueVector},
+		{`{__name__=~"http_.*", job!=""}`, ValueVector},
+		{`http_requests_total{code=~"5..",method!~"GET|HEAD"}`, ValueVector},
+		{`rate(http_requests_total[5m])`, ValueVector},
+		{`rate(http_requests_total{job="api"}[5m] offset 1h)`, ValueVector},
+		{`sum by (job) (rate(http_requests_total[5m]))`, ValueVector},
+		{`sum(rate(http_requests_total[5m])) by (job)`, ValueVector},
+		{`sum without (instance) (up)`, ValueVector},
+		{`topk(5, sum by (pod) (container_memory_working_set_bytes))`, ValueVector},
+		{`count_values("version", build_info)`, ValueVector},
+		{`quantile(0.9, http_request_duration_seconds)`, ValueVector},
+		{`histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket[5m])))`, ValueVector},
+		{`max_over_time(up[1h:5m])`, ValueVector},
+		{`rate(http_requests_total[5m])[30m:1m]`, ValueMatrix},
+		{`up[5m]`, ValueMatrix},
+		{`up @ 1609746000`, ValueVector},
+		{`up offset -5m`, ValueVector},
+		{`1 + 2 * 3`, ValueScalar},
+		{`-up`, ValueVector},
+		{`2 ^ 3 ^ 2`, ValueScalar},
+		{`up == bool 1`, ValueVector},
+		{`a / on (job) group_left (team) b`, ValueVector},
+		{`a * ignoring (instance) b`, ValueVector},
+		{`a and b or c unless d`, ValueVector},
+		{`time()`, ValueScalar},
+		{`vector(1)`, ValueVector},
+		{`scalar(up)`, ValueScalar},
+		{`label_replace(up, "host", "$1", "instance", "(.*):.*")`, ValueVector},
+		{`absent_over_time(up{job="x"}[10m])`, ValueVector},
+		{`0x1F + 1e3 + .5 + Inf`, ValueScalar},
+		{`"text"`, ValueString},
+		{`(up)`, ValueVector},
+		{"up # trailing comment", ValueVector},
+		{`rate(http_requests_total[$__rate_interval])`, ValueVector},
+		{`sum by ($group) (up{job=~"$job"})`, ValueVector},
+		{`rate(http_requests_total{instance="${instance}"}[$__interval])`, ValueVector},
+		{`up{job="[[job]]"}`, ValueVector},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.expr, err)
+			continue
+		}
+		if expr.Type() != tt.typ {
+			t.Errorf("Parse(%q) is a %s, want %s", tt.expr, expr.Type(), tt.typ)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		expr string
+		want string // part of the error message
+	}{
+		{``, ""},
+		{`sum(`, ""},
+		{`up{job="api"`, ""},
+		{`up{job=}`, ""},
+		{`up{job~"x"}`, ""},
+		{`rate(up)`, "range vector"},
+		{`rate(up[5m], 1)`, ""},
+		{`nosuchfunc(up)`, "nosuchfunc"},
+		{`up[5m][5m]`, ""},
+		{`sum by (job) (up) by (job)`, ""},
+		{`up +`, ""},
+		{`1 and 2`, ""},
+		{`up == bool`, ""},
+		{`"a" + 1`, ""},
+		{`up[5x]`, ""},
+		{`up offset`, ""},
+		{`{}`, ""},
+		{`{job=~""}`, ""},
+		{`topk(up)`, ""},
+		{`up )`, ""},
+		{`"unterminated`, ""},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.expr)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", tt.expr)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error mentioning %q", tt.expr, err, tt.want)
+		}
+	}
+}
+
+func TestParseErrorPosition(t *testing.T) {
+	_, err := Parse("sum(\n  rate(up)\n)")
+	perr, ok := err.(*Error)
+	if !ok {
+		t.Fatalf("Parse error %v is a %T, want *Error", err, err)
+	}
+	if perr.Line != 2 || perr.Column < 3 {
+		t.Fatalf("error at %d:%d, want line 2 from column 3", perr.Line, perr.Column)
+	}
+}
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string // rules reported, in order
+	}{
+		{`rate(http_requests_total[5m])`, nil},
+		{`http_requests_total`, []string{"counter-without-rate"}},
+		{`count(http_requests_total)`, nil},
+		{`rate(http_requests_total[$__interval])`, []string{"rate-interval"}},
+		{`rate(sum(http_requests_total)[5m:])`, []string{"rate-of-aggregate"}},
+		{`histogram_quantile(0.9, sum by (job) (rate(x_bucket[5m])))`, []string{"histogram-quantile-le"}},
+		{`histogram_quantile(0.9, sum by (le) (rate(x_bucket[5m])))`, nil},
+		{`up{job="a", job="b"}`, []string{"conflicting-matchers"}},
+		{`up{job=""}`, []string{"empty-matcher"}},
+		{`up{job=~".*"}`, []string{"match-all-regex"}},
+		{`up{job!~".*"}`, []string{"never-matches"}},
+		{`up{job=~"^api$"}`, []string{"anchored-regex"}},
+		{`up{job=~"api"}`, []string{"regex-without-metacharacters"}},
+		{`up{job=~"$job"}`, nil},
+	}
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q): %v", tt.expr, err)
+		}
+		var got []string
+		for _, w := range Lint(tt.expr, expr) {
+			got = append(got, w.Rule)
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("Lint(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
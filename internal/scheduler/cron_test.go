@@ -0,0 +1,101 @@
+package scheduler
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRejectsInvalidSchedules(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "expected 5 fields"},
+		{"* * * *", "expected 5 fields"},
+		{"* * * * * *", "expected 5 fields"},
+		{"60 * * * *", "out of range"},
+		{"* 24 * * *", "out of range"},
+		{"* * 0 * *", "out of range"},
+		{"* * * 13 *", "out of range"},
+		{"* * * * 8", "out of range"},
+		{"5-1 * * * *", "out of range"},
+		{"*/0 * * * *", "bad step"},
+		{"*/x * * * *", "bad step"},
+		{"a * * * *", "bad value"},
+		{"1-b * * * *", "bad value"},
+		{"@every 10ms", "at least 1s"},
+		{"@every soon", "invalid schedule"},
+		{"@fortnightly", "expected 5 fields"},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.spec)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%q) = %v, want an error containing %q", tt.spec, err, tt.want)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// A Wednesday, the last day of January in a leap year
+	from := time.Date(2024, time.January, 31, 10, 30, 15, 0, time.UTC)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		spec string
+		from time.Time
+		want time.Time
+	}{
+		{"step", "*/15 * * * *", from, at(time.January, 31, 10, 45)},
+		{"top of the hour", "0 * * * *", from, at(time.January, 31, 11, 0)},
+		{"list", "5,40 * * * *", from, at(time.January, 31, 10, 40)},
+		{"range with step", "30 10-14/2 * * *", from, at(time.January, 31, 12, 30)},
+		{"value with step", "0 20/2 * * *", from, at(time.January, 31, 20, 0)},
+		{"strictly after", "30 10 * * *", at(time.January, 31, 10, 30), at(time.February, 1, 10, 30)},
+		{"weekdays roll into the next month", "0 9 * * 1-5", from, at(time.February, 1, 9, 0)},
+		{"first of the month", "0 0 1 * *", from, at(time.February, 1, 0, 0)},
+		{"year rollover", "0 0 * * *", time.Date(2024, time.December, 31, 23, 59, 0, 0, time.UTC), time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", from, at(time.February, 29, 0, 0)},
+		{"next leap day", "0 0 29 2 *", at(time.March, 1, 0, 0), time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"impossible date", "0 0 30 2 *", from, time.Time{}},
+		{"sunday as 7", "0 12 * * 7", from, at(time.February, 4, 12, 0)},
+
+		// Both day fields restricted: either matches
+		{"day of month or weekday", "0 0 15 * 1", from, at(time.February, 5, 0, 0)},
+		{"friday or the 13th", "0 0 13 * 5", from, at(time.February, 2, 0, 0)},
+		{"the 1st or a sunday", "0 0 1 * 0", from, at(time.February, 1, 0, 0)},
+
+		// A day field starting with * or covering its range is unrestricted,
+		// so only the other one applies
+		{"day of month step wildcard", "0 0 */1 * 1", from, at(time.February, 5, 0, 0)},
+		{"day of month full range", "0 0 1-31 * 1", from, at(time.February, 5, 0, 0)},
+		{"weekday step wildcard", "0 0 15 * */1", from, at(time.February, 15, 0, 0)},
+		{"weekday full range", "0 0 15 * 0-6", from, at(time.February, 15, 0, 0)},
+		{"weekday full range with 7", "0 0 15 * 1-7", from, at(time.February, 15, 0, 0)},
+
+		// A starred step still restricts its own field
+		{"every other day of month", "0 0 */2 * *", at(time.February, 1, 0, 0), at(time.February, 3, 0, 0)},
+		{"every other day of month on mondays", "0 0 */2 * 1", from, at(time.February, 5, 0, 0)},
+		{"odd mondays skip even ones", "0 0 */2 * 1", at(time.February, 5, 0, 0), at(time.February, 19, 0, 0)},
+		{"every other weekday", "0 0 * * */2", at(time.February, 1, 0, 0), at(time.February, 3, 0, 0)},
+		{"every other weekday on the 12th", "0 0 12 * */2", from, at(time.March, 12, 0, 0)},
+
+		{"every", "@every 90s", from, at(time.January, 31, 10, 31).Add(45 * time.Second)},
+		{"hourly", "@hourly", from, at(time.January, 31, 11, 0)},
+		{"weekly", "@weekly", from, at(time.February, 4, 0, 0)},
+		{"yearly", "@yearly", from, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.spec, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%q) from %s = %s, want %s", tt.spec, tt.from, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+// Package scheduler runs configured tool calls on cron schedules and keeps a
+// bounded history of their outcomes.
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxOutput bounds the tool output kept per run
+const maxOutput = 2000
+
+// Runner executes a tool call and returns its text output. A non-nil error
+// marks the run as failed.
+type Runner func(tool string, args map[string]interface{}) (string, error)
+
+// Job is a tool call run on a schedule
+type Job struct {
+	Name     string
+	Spec     string
+	Tool     string
+	Args     map[string]interface{}
+	schedule Schedule
+	next     time.Time
+	running  bool
+	last     *Run
+}
+
+// JobStatus describes a job and its most recent run
+type JobStatus struct {
+	Name     string                 `json:"name"`
+	Schedule string                 `json:"schedule"`
+	Tool     string                 `json:"tool"`
+	Args     map[string]interface{} `json:"args,omitempty"`
+	NextRun  *time.Time             `json:"next_run,omitempty"`
+	Running  bool                   `json:"running"`
+	LastRun  *Run                   `json:"last_run,omitempty"`
+}
+
+// Run records one execution of a job
+type Run struct {
+	Job      string    `json:"job"`
+	Tool     string    `json:"tool"`
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	Status   string    `json:"status"`
+	Output   string    `json:"output,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Scheduler runs jobs when they are due. Runs of the same job never overlap;
+// a job still running when it comes due again is recorded as skipped.
+type Scheduler struct {
+	mu      sync.Mutex
+	run     Runner
+	jobs    []*Job
+	history []Run
+	max     int
+	wake    chan struct{}
+	stop    chan struct{}
+	wg      sync.WaitGroup
+	now     func() time.Time
+	onRun   func(Run)
+}
+
+// New creates a scheduler that runs jobs with run and keeps the latest
+// historySize runs (100 if historySize is 0 or less)
+func New(run Runner, historySize int) *Scheduler {
+	if historySize <= 0 {
+		historySize = 100
+	}
+	return &Scheduler{
+		run:  run,
+		max:  historySize,
+		wake: make(chan struct{}, 1),
+		now:  time.Now,
+	}
+}
+
+// OnRun registers a callback invoked after every run, including skipped
+// ones. It must be set before Start. The callback runs without the
+// scheduler's lock held, possibly for several runs at once, so slow work
+// such as persisting the run does not hold up other jobs.
+func (s *Scheduler) OnRun(fn func(Run)) {
+	s.onRun = fn
+}
+
+// Add registers a job. Names must be unique.
+func (s *Scheduler) Add(name, spec, tool string, args map[string]interface{}) error {
+	if name == "" || tool == "" {
+		return fmt.Errorf("job name and tool are required")
+	}
+	schedule, err := Parse(spec)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", name, err)
+	}
+	args, err = normalizeArgs(args)
+	if err != nil {
+		return fmt.Errorf("job %q: %w", name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, j := range s.jobs {
+		if j.Name == name {
+			return fmt.Errorf("duplicate job %q", name)
+		}
+	}
+	s.jobs = append(s.jobs, &Job{
+		Name:     name,
+		Spec:     spec,
+		Tool:     tool,
+		Args:     args,
+		schedule: schedule,
+		next:     schedule.Next(s.now()),
+	})
+	s.signal()
+	return nil
+}
+
+// normalizeArgs round-trips args through JSON so values decoded from YAML
+// have the same types as arguments sent by MCP clients
+func normalizeArgs(args map[string]interface{}) (map[string]interface{}, error) {
+	if len(args) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	data, err := json.Marshal(args)
+	if err != nil {
+		return nil, fmt.Errorf("invalid args: %w", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("invalid args: %w", err)
+	}
+	return out, nil
+}
+
+// Start begins running due jobs in the background
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	if s.stop != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stop = make(chan struct{})
+	s.mu.Unlock()
+
+	s.wg.Add(1)
+	go s.loop()
+}
+
+// Stop stops scheduling and waits for running jobs to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	if s.stop == nil {
+		s.mu.Unlock()
+		return
+	}
+	close(s.stop)
+	s.stop = nil
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Scheduler) loop() {
+	defer s.wg.Done()
+	s.mu.Lock()
+	stop := s.stop
+	s.mu.Unlock()
+
+	for {
+		timer := time.NewTimer(s.dispatch())
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-s.wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// dispatch starts every due job and returns how long to sleep until the next one
+func (s *Scheduler) dispatch() time.Duration {
+	var skipped []Run
+	defer func() {
+		for _, r := range skipped {
+			s.notify(r)
+		}
+	}()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	wait := time.Hour
+	for _, j := range s.jobs {
+		if j.next.IsZero() {
+			continue
+		}
+		if !j.next.After(now) {
+			if j.running {
+				r := Run{Job: j.Name, Tool: j.Tool, Started: now, Duration: "0s", Status: "skipped", Error: "previous run still in progress"}
+				s.record(j, r)
+				skipped = append(skipped, r)
+			} else {
+				j.running = true
+				s.wg.Add(1)
+				go s.execute(j)
+			}
+			j.next = j.schedule.Next(now)
+			if j.next.IsZero() {
+				continue
+			}
+		}
+		if d := j.next.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+func (s *Scheduler) execute(j *Job) {
+	defer s.wg.Done()
+	started := s.now()
+	output, err := s.run(j.Tool, j.Args)
+	r := Run{
+		Job:      j.Name,
+		Tool:     j.Tool,
+		Started:  started,
+		Duration: s.now().Sub(started).Round(time.Millisecond).String(),
+		Status:   "ok",
+		Output:   truncate(output),
+	}
+	if err != nil {
+		r.Status = "error"
+		r.Error = err.Error()
+		if r.Output == r.Error {
+			r.Output = ""
+		}
+	}
+
+	s.mu.Lock()
+	j.running = false
+	s.record(j, r)
+	s.mu.Unlock()
+	s.notify(r)
+}
+
+// record appends to the history ring; callers hold s.mu
+func (s *Scheduler) record(j *Job, r Run) {
+	j.last = &r
+	s.history = append(s.history, r)
+	if len(s.history) > s.max {
+		s.history = s.history[len(s.history)-s.max:]
+	}
+}
+
+// notify passes r to the OnRun callback; callers must not hold s.mu
+func (s *Scheduler) notify(r Run) {
+	if s.onRun != nil {
+		s.onRun(r)
+	}
+}
+
+func truncate(s string) string {
+	if len(s) <= maxOutput {
+		return s
+	}
+	return s[:maxOutput] + "... (truncated)"
+}
+
+// Jobs returns every job with its next run time and latest outcome
+func (s *Scheduler) Jobs() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]JobStatus, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		st := JobStatus{Name: j.Name, Schedule: j.Spec, Tool: j.Tool, Args: j.Args, Running: j.running, LastRun: j.last}
+		if !j.next.IsZero() {
+			next := j.next
+			st.NextRun = &next
+		}
+		out = append(out, st)
+	}
+	sort.Slice(out, func(i, k int) bool { return out[i].Name < out[k].Name })
+	return out
+}
+
+// Restore seeds the history with runs saved from a previous process,
+// oldest first, and sets the latest run of each added job. Call it after
+// Add and before Start.
+func (s *Scheduler) Restore(runs []Run) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.history = append(append([]Run(nil), runs...), s.history...)
+	if len(s.history) > s.max {
+		s.history = s.history[len(s.history)-s.max:]
+	}
+	for _, j := range s.jobs {
+		for i := len(s.history) - 1; i >= 0; i-- {
+			if s.history[i].Job == j.Name {
+				r := s.history[i]
+				j.last = &r
+				break
+			}
+		}
+	}
+}
+
+// History returns up to limit recent runs, newest first, optionally for one job
+func (s *Scheduler) History(job string, limit int) []Run {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []Run{}
+	for i := len(s.history) - 1; i >= 0; i-- {
+		if job != "" && s.history[i].Job != job {
+			continue
+		}
+		out = append(out, s.history[i])
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+	}
+	return out
+}
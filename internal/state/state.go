@@ -0,0 +1,367 @@
+// Package state persists server state across restarts in a directory of
+// JSON files, one per bucket, so features such as the render cache,
+// scheduled job history, the undo journal, confirmation tokens, and the
+// instance each session works on survive a restart. Large binary values
+// are kept in files of their own beside the bucket's.
+package state
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Well-known buckets
+const (
+	BucketRender    = "render"
+	BucketJobs      = "jobs"
+	BucketInstances = "instances"
+	BucketConfirm   = "confirm"
+	BucketUndo      = "undo"
+)
+
+// DefaultLimit is the number of entries a bucket keeps unless SetLimit
+// changes it
+const DefaultLimit = 1024
+
+var bucketName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// Store is a concurrency-safe key/value store of JSON values grouped into
+// buckets. Every write rewrites the bucket's file atomically, so the store
+// suits small, infrequently written state rather than high write rates;
+// PutBlob keeps large values out of that file. A bucket holds at most its
+// limit of entries, the least recently written being evicted first.
+// A nil *Store is valid and stores nothing.
+type Store struct {
+	mu      sync.Mutex
+	dir     string
+	buckets map[string]map[string]record
+	limits  map[string]int
+}
+
+type record struct {
+	Value json.RawMessage `json:"value,omitempty"`
+	// Blob names the file holding the value written by PutBlob, in the
+	// bucket's directory
+	Blob    string     `json:"blob,omitempty"`
+	Written time.Time  `json:"written"`
+	Expires *time.Time `json:"expires,omitempty"`
+}
+
+func (r record) expired(now time.Time) bool {
+	return r.Expires != nil && now.After(*r.Expires)
+}
+
+// Open uses dir for state, creating it if needed. Buckets are loaded
+// lazily on first use.
+func Open(dir string) (*Store, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("state directory is required")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("creating state directory: %w", err)
+	}
+	return &Store{dir: dir, buckets: make(map[string]map[string]record), limits: make(map[string]int)}, nil
+}
+
+// SetLimit sets the number of entries bucket keeps; 0 or less restores
+// DefaultLimit. Entries over the limit are evicted on the next write.
+func (s *Store) SetLimit(bucket string, entries int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entries <= 0 {
+		delete(s.limits, bucket)
+		return
+	}
+	s.limits[bucket] = entries
+}
+
+// Dir returns the state directory
+func (s *Store) Dir() string {
+	if s == nil {
+		return ""
+	}
+	return s.dir
+}
+
+// Get decodes the value stored under key into out, reporting whether an
+// unexpired value was found
+func (s *Store) Get(bucket, key string, out interface{}) (bool, error) {
+	if s == nil {
+		return false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.load(bucket)
+	if err != nil {
+		return false, err
+	}
+	rec, ok := b[key]
+	if !ok || rec.expired(time.Now()) || rec.Blob != "" {
+		return false, nil
+	}
+	if err := json.Unmarshal(rec.Value, out); err != nil {
+		return false, fmt.Errorf("decoding %s/%s: %w", bucket, key, err)
+	}
+	return true, nil
+}
+
+// Put stores value under key. A ttl of 0 or less keeps it until deleted.
+func (s *Store) Put(bucket, key string, value interface{}, ttl time.Duration) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("encoding %s/%s: %w", bucket, key, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.load(bucket)
+	if err != nil {
+		return err
+	}
+	s.drop(bucket, b, key)
+	rec := newRecord(ttl)
+	rec.Value = data
+	b[key] = rec
+	return s.save(bucket, b)
+}
+
+// PutBlob stores data under key in a file of its own, so large values such
+// as images do not make every write of the bucket rewrite them. A ttl of 0
+// or less keeps it until deleted.
+func (s *Store) PutBlob(bucket, key string, data []byte, ttl time.Duration) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.load(bucket)
+	if err != nil {
+		return err
+	}
+	s.drop(bucket, b, key)
+	rec := newRecord(ttl)
+	rec.Blob = blobName(key)
+	if err := os.MkdirAll(s.blobDir(bucket), 0o700); err != nil {
+		return fmt.Errorf("writing %s/%s: %w", bucket, key, err)
+	}
+	if err := writeFile(s.blobDir(bucket), rec.Blob, data); err != nil {
+		return fmt.Errorf("writing %s/%s: %w", bucket, key, err)
+	}
+	b[key] = rec
+	return s.save(bucket, b)
+}
+
+// GetBlob returns the data PutBlob stored under key, reporting whether an
+// unexpired value was found
+func (s *Store) GetBlob(bucket, key string) ([]byte, bool, error) {
+	if s == nil {
+		return nil, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.load(bucket)
+	if err != nil {
+		return nil, false, err
+	}
+	rec, ok := b[key]
+	if !ok || rec.expired(time.Now()) || rec.Blob == "" {
+		return nil, false, nil
+	}
+	data, err := os.ReadFile(filepath.Join(s.blobDir(bucket), rec.Blob))
+	if os.IsNotExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("reading %s/%s: %w", bucket, key, err)
+	}
+	return data, true, nil
+}
+
+func newRecord(ttl time.Duration) record {
+	now := time.Now().UTC()
+	rec := record{Written: now}
+	if ttl > 0 {
+		exp := now.Add(ttl)
+		rec.Expires = &exp
+	}
+	return rec
+}
+
+// Delete removes key from bucket
+func (s *Store) Delete(bucket, key string) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.load(bucket)
+	if err != nil {
+		return err
+	}
+	if _, ok := b[key]; !ok {
+		return nil
+	}
+	s.drop(bucket, b, key)
+	return s.save(bucket, b)
+}
+
+// Keys returns the unexpired keys in bucket, sorted
+func (s *Store) Keys(bucket string) ([]string, error) {
+	if s == nil {
+		return nil, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, err := s.load(bucket)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	keys := make([]string, 0, len(b))
+	for k, rec := range b {
+		if !rec.expired(now) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// load returns the bucket, reading its file on first use; callers hold s.mu
+func (s *Store) load(bucket string) (map[string]record, error) {
+	if !bucketName.MatchString(bucket) {
+		return nil, fmt.Errorf("invalid bucket name %q", bucket)
+	}
+	if b, ok := s.buckets[bucket]; ok {
+		return b, nil
+	}
+	b := make(map[string]record)
+	data, err := os.ReadFile(s.path(bucket))
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		return nil, fmt.Errorf("reading bucket %s: %w", bucket, err)
+	default:
+		if err := json.Unmarshal(data, &b); err != nil {
+			return nil, fmt.Errorf("reading bucket %s: %w", bucket, err)
+		}
+	}
+	now := time.Now()
+	for k, rec := range b {
+		if rec.expired(now) {
+			s.drop(bucket, b, k)
+		}
+	}
+	s.buckets[bucket] = b
+	return b, nil
+}
+
+// drop removes key from the bucket and its blob file, if any; callers
+// hold s.mu
+func (s *Store) drop(bucket string, b map[string]record, key string) {
+	rec, ok := b[key]
+	if !ok {
+		return
+	}
+	if rec.Blob != "" {
+		os.Remove(filepath.Join(s.blobDir(bucket), rec.Blob))
+	}
+	delete(b, key)
+}
+
+// evict drops the least recently written entries over the bucket's limit;
+// callers hold s.mu
+func (s *Store) evict(bucket string, b map[string]record) {
+	limit, ok := s.limits[bucket]
+	if !ok {
+		limit = DefaultLimit
+	}
+	if len(b) <= limit {
+		return
+	}
+	keys := make([]string, 0, len(b))
+	for k := range b {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if wi, wj := b[keys[i]].Written, b[keys[j]].Written; !wi.Equal(wj) {
+			return wi.Before(wj)
+		}
+		return keys[i] < keys[j]
+	})
+	for _, k := range keys[:len(keys)-limit] {
+		s.drop(bucket, b, k)
+	}
+}
+
+// save writes the bucket to a temporary file and renames it into place,
+// dropping expired records and those over the limit; callers hold s.mu
+func (s *Store) save(bucket string, b map[string]record) error {
+	now := time.Now()
+	for k, rec := range b {
+		if rec.expired(now) {
+			s.drop(bucket, b, k)
+		}
+	}
+	s.evict(bucket, b)
+	data, err := json.Marshal(b)
+	if err != nil {
+		return fmt.Errorf("encoding bucket %s: %w", bucket, err)
+	}
+	if err := writeFile(s.dir, bucket+".json", data); err != nil {
+		return fmt.Errorf("writing bucket %s: %w", bucket, err)
+	}
+	return nil
+}
+
+// writeFile writes data to a temporary file in dir and renames it to name
+func writeFile(dir, name string, data []byte) error {
+	tmp, err := os.CreateTemp(dir, name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(dir, name)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *Store) path(bucket string) string {
+	return filepath.Join(s.dir, bucket+".json")
+}
+
+// blobDir is where the blobs of bucket are kept
+func (s *Store) blobDir(bucket string) string {
+	return filepath.Join(s.dir, bucket)
+}
+
+// blobName is the file name of the blob stored under key
+func blobName(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
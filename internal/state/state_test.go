@@ -0,0 +1,167 @@
+package state
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPutGetDelete(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("jobs", "history", []string{"a", "b"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	if ok, err := s.Get("jobs", "history", &got); !ok || err != nil {
+		t.Fatalf("Get = %v, %v; want the stored value", ok, err)
+	}
+	if !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Fatalf("Get decoded %v", got)
+	}
+	if err := s.Delete("jobs", "history"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := s.Get("jobs", "history", &got); ok {
+		t.Fatal("value still there after Delete")
+	}
+}
+
+func TestPersistsAcrossOpen(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir)
+	if err := s.Put("jobs", "kept", 42, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("jobs", "forever", "x", 0); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, _ := Open(dir)
+	keys, err := reopened.Keys("jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(keys, []string{"forever", "kept"}) {
+		t.Fatalf("Keys after reopening = %v", keys)
+	}
+	var n int
+	if ok, _ := reopened.Get("jobs", "kept", &n); !ok || n != 42 {
+		t.Fatalf("Get after reopening = %v, %d", ok, n)
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir)
+	if err := s.Put("render", "gone", "x", time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Put("render", "live", "y", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	var v string
+	if ok, _ := s.Get("render", "gone", &v); ok {
+		t.Fatal("expired value returned")
+	}
+	if keys, _ := s.Keys("render"); !reflect.DeepEqual(keys, []string{"live"}) {
+		t.Fatalf("Keys = %v, want only the unexpired key", keys)
+	}
+
+	// Expired records are dropped from the file on the next write, and
+	// ignored when an older file is loaded
+	if err := s.Put("render", "other", "z", 0); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "render.json"))
+	reopened, _ := Open(dir)
+	if ok, _ := reopened.Get("render", "gone", &v); ok {
+		t.Fatalf("expired value survived reopening: %s", data)
+	}
+	if ok, _ := reopened.Get("render", "live", &v); !ok || v != "y" {
+		t.Fatalf("unexpired value lost on reopening: %s", data)
+	}
+}
+
+func TestLimitEvictsOldest(t *testing.T) {
+	s, _ := Open(t.TempDir())
+	s.SetLimit("render", 3)
+	for i := 0; i < 5; i++ {
+		if err := s.Put("render", fmt.Sprintf("k%d", i), i, 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keys, _ := s.Keys("render")
+	if !reflect.DeepEqual(keys, []string{"k2", "k3", "k4"}) {
+		t.Fatalf("Keys = %v, want the three most recently written", keys)
+	}
+
+	// Other buckets keep the default limit
+	for i := 0; i < 5; i++ {
+		s.Put("jobs", fmt.Sprintf("k%d", i), i, 0)
+	}
+	if keys, _ := s.Keys("jobs"); len(keys) != 5 {
+		t.Fatalf("jobs kept %d keys, want 5", len(keys))
+	}
+}
+
+func TestBlobs(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := Open(dir)
+	s.SetLimit("render", 2)
+	png := []byte("\x89PNG fake image")
+	if err := s.PutBlob("render", "a", png, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	got, ok, err := s.GetBlob("render", "a")
+	if !ok || err != nil || string(got) != string(png) {
+		t.Fatalf("GetBlob = %q, %v, %v", got, ok, err)
+	}
+	index, _ := os.ReadFile(filepath.Join(dir, "render.json"))
+	if len(index) > 200 {
+		t.Fatalf("the bucket file holds the blob: %s", index)
+	}
+
+	s.PutBlob("render", "b", png, 0)
+	s.PutBlob("render", "c", png, 0)
+	if _, ok, _ := s.GetBlob("render", "a"); ok {
+		t.Fatal("blob over the limit not evicted")
+	}
+	if err := s.Delete("render", "b"); err != nil {
+		t.Fatal(err)
+	}
+	files, _ := os.ReadDir(filepath.Join(dir, "render"))
+	if len(files) != 1 {
+		t.Fatalf("%d blob files left, want 1 (evicted and deleted blobs are removed)", len(files))
+	}
+
+	reopened, _ := Open(dir)
+	if got, ok, _ := reopened.GetBlob("render", "c"); !ok || string(got) != string(png) {
+		t.Fatal("blob lost on reopening")
+	}
+}
+
+func TestNilStoreAndBucketNames(t *testing.T) {
+	var s *Store
+	if err := s.Put("jobs", "k", 1, 0); err != nil {
+		t.Fatal(err)
+	}
+	var v int
+	if ok, err := s.Get("jobs", "k", &v); ok || err != nil {
+		t.Fatalf("nil store Get = %v, %v", ok, err)
+	}
+
+	s, _ = Open(t.TempDir())
+	for _, bucket := range []string{"", "../escape", "Upper", "a/b"} {
+		if err := s.Put(bucket, "k", 1, 0); err == nil {
+			t.Errorf("Put into bucket %q succeeded", bucket)
+		}
+	}
+}
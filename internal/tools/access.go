@@ -0,0 +1,177 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// expressionDatasource is the UID and type of Grafana server-side
+// expressions, which read other queries' results rather than a datasource
+const expressionDatasource = "__expr__"
+
+// DatasourceAccess restricts which datasources the query-running tools may
+// target. Deny rules win over allow rules; empty allow lists allow all.
+type DatasourceAccess struct {
+	AllowUIDs       []string
+	AllowTypes      []string
+	DenyUIDs        []string
+	DenyTypes       []string
+	DenyExpressions []ExpressionRule
+}
+
+// ExpressionRule blocks queries whose text matches Pattern on the listed
+// datasources; empty UIDs and Types apply the rule to every datasource
+type ExpressionRule struct {
+	Pattern *regexp.Regexp
+	UIDs    []string
+	Types   []string
+}
+
+// WithDatasourceAccess enforces a datasource allowlist/denylist on every tool
+// that sends queries
+func WithDatasourceAccess(a DatasourceAccess) Option {
+	return func(r *Registry) {
+		r.access = &datasourceAccess{
+			DatasourceAccess: a,
+			allowUIDs:        stringSet(a.AllowUIDs),
+			allowTypes:       stringSet(a.AllowTypes),
+			denyUIDs:         stringSet(a.DenyUIDs),
+			denyTypes:        stringSet(a.DenyTypes),
+			types:            map[string]string{},
+		}
+	}
+}
+
+type datasourceAccess struct {
+	DatasourceAccess
+	allowUIDs, allowTypes, denyUIDs, denyTypes map[string]bool
+
+	// types caches datasource types by UID; callers' type claims are not
+	// trusted because Grafana routes queries by UID alone
+	mu    sync.Mutex
+	types map[string]string
+}
+
+// needsType reports whether any rule depends on the datasource type
+func (a *datasourceAccess) needsType() bool {
+	if len(a.allowTypes) > 0 || len(a.denyTypes) > 0 {
+		return true
+	}
+	for _, rule := range a.DenyExpressions {
+		if len(rule.Types) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkQueryAccess returns an error when the configured datasource rules
+// forbid sending texts to ds
+func (r *Registry) checkQueryAccess(ds grafana.DatasourceRef, texts ...string) error {
+	a := r.access
+	if a == nil || ds.UID == expressionDatasource || ds.Type == expressionDatasource {
+		return nil
+	}
+
+	typ := ds.Type
+	if ds.UID != "" && a.needsType() {
+		var err error
+		if typ, err = r.datasourceType(ds.UID); err != nil {
+			return fmt.Errorf("datasource access: cannot verify the type of datasource %s: %v", ds.UID, err)
+		}
+	}
+	name := ds.UID
+	if name == "" {
+		name = "(default)"
+	}
+
+	switch {
+	case a.denyUIDs[ds.UID]:
+		return fmt.Errorf("datasource access: datasource %s is denied by configuration", name)
+	case typ != "" && a.denyTypes[typ]:
+		return fmt.Errorf("datasource access: %s datasources are denied by configuration", typ)
+	case len(a.allowUIDs) > 0 && !a.allowUIDs[ds.UID]:
+		return fmt.Errorf("datasource access: datasource %s is not in the allowed list (%s)", name, joinSet(a.allowUIDs))
+	case len(a.allowTypes) > 0 && !a.allowTypes[typ]:
+		return fmt.Errorf("datasource access: %s datasource %s is not an allowed type (%s)", typ, name, joinSet(a.allowTypes))
+	}
+
+	for _, rule := range a.DenyExpressions {
+		if (len(rule.UIDs) > 0 && !contains(rule.UIDs, ds.UID)) || (len(rule.Types) > 0 && !contains(rule.Types, typ)) {
+			continue
+		}
+		for _, text := range texts {
+			if text != "" && rule.Pattern.MatchString(text) {
+				return fmt.Errorf("datasource access: the query matches the denied expression %q", rule.Pattern.String())
+			}
+		}
+	}
+	return nil
+}
+
+// datasourceType looks up, and caches, the type of a datasource
+func (r *Registry) datasourceType(uid string) (string, error) {
+	key := fmt.Sprintf("%d/%s", r.orgID(), uid)
+	r.access.mu.Lock()
+	typ, ok := r.access.types[key]
+	r.access.mu.Unlock()
+	if ok {
+		return typ, nil
+	}
+	ds, err := r.client.GetDatasource(r.ctx, uid)
+	if err != nil {
+		return "", err
+	}
+	r.access.mu.Lock()
+	r.access.types[key] = ds.Type
+	r.access.mu.Unlock()
+	return ds.Type, nil
+}
+
+// queryTexts returns the query strings of a target, including
+// datasource-specific fields such as rawSql carried in Extra
+func queryTexts(q grafana.QueryTarget) []string {
+	texts := []string{q.Query, q.RawQuery}
+	keys := make([]string, 0, len(q.Extra))
+	for k := range q.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if s, ok := q.Extra[k].(string); ok {
+			texts = append(texts, s)
+		}
+	}
+	return texts
+}
+
+func stringSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
+
+func joinSet(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
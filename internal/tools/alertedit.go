@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaBulkEditAlertRulesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_bulk_edit_alert_rules",
+		Description: "Add or remove labels and annotations across alert rules selected by folder, rule group, UID, or label matchers (e.g. add team=payments to every rule in a folder). File-provisioned rules are skipped; rules created in the UI stay editable there",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"folder_uid":         {Type: "string", Description: "Only rules in this folder"},
+				"rule_group":         {Type: "string", Description: "Only rules in this rule group"},
+				"uids":               {Type: "array", Description: "Only these rule UIDs"},
+				"matchers":           {Type: "array", Description: "Label matchers the rule labels must satisfy, e.g. [\"severity=critical\", \"team=~pay.*\"]"},
+				"add_labels":         {Type: "object", Description: "Labels to set (overwrites existing values)"},
+				"remove_labels":      {Type: "array", Description: "Label names to remove"},
+				"add_annotations":    {Type: "object", Description: "Annotations to set (overwrites existing values)"},
+				"remove_annotations": {Type: "array", Description: "Annotation names to remove"},
+				"dry_run":            {Type: "boolean", Description: "Report what would change without saving"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// ruleEdit is a set of label and annotation changes
+type ruleEdit struct {
+	addLabels         map[string]string
+	removeLabels      []string
+	addAnnotations    map[string]string
+	removeAnnotations []string
+}
+
+func (e ruleEdit) empty() bool {
+	return len(e.addLabels) == 0 && len(e.removeLabels) == 0 && len(e.addAnnotations) == 0 && len(e.removeAnnotations) == 0
+}
+
+// apply edits the rule in place and reports whether anything changed
+func (e ruleEdit) apply(rule *grafana.AlertRule) bool {
+	labels, lc := editMap(rule.Labels, e.addLabels, e.removeLabels)
+	annotations, ac := editMap(rule.Annotations, e.addAnnotations, e.removeAnnotations)
+	rule.Labels, rule.Annotations = labels, annotations
+	return lc || ac
+}
+
+func editMap(m, add map[string]string, remove []string) (map[string]string, bool) {
+	changed := false
+	out := make(map[string]string, len(m)+len(add))
+	for k, v := range m {
+		out[k] = v
+	}
+	for _, k := range remove {
+		if _, ok := out[k]; ok {
+			delete(out, k)
+			changed = true
+		}
+	}
+	for k, v := range add {
+		if old, ok := out[k]; !ok || old != v {
+			out[k] = v
+			changed = true
+		}
+	}
+	return out, changed
+}
+
+func (r *Registry) handleBulkEditAlertRules(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	edit := ruleEdit{
+		addLabels:         getStringMap(args, "add_labels"),
+		removeLabels:      getStringSlice(args, "remove_labels"),
+		addAnnotations:    getStringMap(args, "add_annotations"),
+		removeAnnotations: getStringSlice(args, "remove_annotations"),
+	}
+	if edit.empty() {
+		return errorResult("at least one of add_labels, remove_labels, add_annotations, remove_annotations is required"), nil
+	}
+	folderUID := getString(args, "folder_uid")
+	group := getString(args, "rule_group")
+	uids := map[string]bool{}
+	for _, u := range getStringSlice(args, "uids") {
+		uids[u] = true
+	}
+	matchers, err := parseLabelMatchers(getStringSlice(args, "matchers"))
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if folderUID == "" && group == "" && len(uids) == 0 && len(matchers) == 0 {
+		return errorResult("one of folder_uid, rule_group, uids, or matchers is required"), nil
+	}
+	dryRun := getBool(args, "dry_run")
+
+	rules, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list alert rules", err), nil
+	}
+
+	result := newBulkResult(dryRun)
+	for _, rule := range rules {
+		if (folderUID != "" && rule.FolderUID != folderUID) ||
+			(group != "" && rule.RuleGroup != group) ||
+			(len(uids) > 0 && !uids[rule.UID]) ||
+			!matchAll(matchers, rule.Labels) {
+			continue
+		}
+
+		if rule.Provenance == "file" {
+			result.Skip(rule.UID, rule.Title, "rule is provisioned from a file", nil)
+			continue
+		}
+		if !edit.apply(&rule) {
+			result.Skip(rule.UID, rule.Title, "labels and annotations already up to date", nil)
+			continue
+		}
+		detail := map[string]interface{}{
+			"folder_uid":  rule.FolderUID,
+			"rule_group":  rule.RuleGroup,
+			"labels":      rule.Labels,
+			"annotations": rule.Annotations,
+		}
+		if dryRun {
+			result.Succeed(rule.UID, rule.Title, "would_change", detail)
+			continue
+		}
+
+		if rule.Provenance == "" {
+			_, err = r.client.UpdateAlertRuleKeepEditable(r.ctx, rule.UID, rule)
+		} else {
+			_, err = r.client.UpdateAlertRule(r.ctx, rule.UID, rule)
+		}
+		if err != nil {
+			result.Fail(rule.UID, rule.Title, err.Error(), detail)
+			continue
+		}
+		result.Succeed(rule.UID, rule.Title, "changed", detail)
+	}
+
+	return bulkResult(result)
+}
@@ -0,0 +1,354 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/promql"
+)
+
+func (r *Registry) grafanaLintAlertRulesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_lint_alert_rules",
+		Description: "Check alert rules for quality problems: missing summary or runbook annotations, missing severity label, no pending (for) duration, NoData/Error handling left at defaults, alerts that only reach the default notification policy or a contact point that does not exist, and invalid or suspicious PromQL. Returns findings per rule and a fix-list of tool calls (grafana_bulk_edit_alert_rules, grafana_update_alert_rule) to apply",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"folder_uid":           {Type: "string", Description: "Only rules in this folder"},
+				"rule_group":           {Type: "string", Description: "Only rules in this rule group"},
+				"uids":                 {Type: "array", Description: "Only these rule UIDs"},
+				"matchers":             {Type: "array", Description: "Label matchers the rule labels must satisfy, e.g. [\"team=payments\"]"},
+				"required_labels":      {Type: "array", Description: "Labels every rule must have (default [\"severity\"])"},
+				"required_annotations": {Type: "array", Description: "Annotations every rule must have (default [\"summary\", \"runbook_url\"])"},
+				"skip_checks":          {Type: "array", Description: "Checks to skip: missing-label, missing-annotation, no-for, default-nodata, default-error, default-route, unknown-contact-point, query"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+type lintFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type ruleLint struct {
+	UID       string        `json:"uid"`
+	Title     string        `json:"title"`
+	FolderUID string        `json:"folder_uid"`
+	RuleGroup string        `json:"rule_group"`
+	Findings  []lintFinding `json:"findings"`
+}
+
+// lintFix is a tool call that resolves findings on one or more rules.
+// Values in angle brackets are placeholders to fill in per rule.
+type lintFix struct {
+	Check string                 `json:"check"`
+	Tool  string                 `json:"tool"`
+	Args  map[string]interface{} `json:"args"`
+	Note  string                 `json:"note,omitempty"`
+}
+
+// alertLinter holds the instance-wide context the routing and query checks need
+type alertLinter struct {
+	tree      *grafana.Route
+	receivers map[string]bool
+	folders   map[string]string
+	dsTypes   map[string]string
+	skip      map[string]bool
+	labels    []string
+	annots    []string
+}
+
+func (r *Registry) handleLintAlertRules(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	folderUID := getString(args, "folder_uid")
+	group := getString(args, "rule_group")
+	uids := map[string]bool{}
+	for _, u := range getStringSlice(args, "uids") {
+		uids[u] = true
+	}
+	matchers, err := parseLabelMatchers(getStringSlice(args, "matchers"))
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	l := &alertLinter{
+		skip:   map[string]bool{},
+		labels: getStringSlice(args, "required_labels"),
+		annots: getStringSlice(args, "required_annotations"),
+	}
+	if _, ok := args["required_labels"]; !ok {
+		l.labels = []string{"severity"}
+	}
+	if _, ok := args["required_annotations"]; !ok {
+		l.annots = []string{"summary", "runbook_url"}
+	}
+	for _, c := range getStringSlice(args, "skip_checks") {
+		l.skip[c] = true
+	}
+
+	rules, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list alert rules", err), nil
+	}
+	if !l.skip["default-route"] || !l.skip["unknown-contact-point"] {
+		if l.tree, err = r.client.GetNotificationPolicyTree(r.ctx); err != nil {
+			return apiErrorResult("Failed to get notification policies", err), nil
+		}
+		receivers, err := r.client.GetReceivers(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to get contact points", err), nil
+		}
+		l.receivers = make(map[string]bool, len(receivers))
+		for _, rc := range receivers {
+			l.receivers[rc.Name] = true
+		}
+		// Folder titles feed the grafana_folder label policies often match on
+		l.folders = map[string]string{}
+		if folders, err := r.client.GetFolders(r.ctx); err == nil {
+			for _, f := range folders {
+				l.folders[f.UID] = f.Title
+			}
+		}
+	}
+	if !l.skip["query"] {
+		l.dsTypes = map[string]string{}
+		if datasources, err := r.client.GetDatasources(r.ctx); err == nil {
+			for _, ds := range datasources {
+				l.dsTypes[ds.UID] = ds.Type
+			}
+		}
+	}
+
+	var linted []ruleLint
+	counts := map[string]int{}
+	checked := 0
+	for _, rule := range rules {
+		if (folderUID != "" && rule.FolderUID != folderUID) ||
+			(group != "" && rule.RuleGroup != group) ||
+			(len(uids) > 0 && !uids[rule.UID]) ||
+			!matchAll(matchers, rule.Labels) {
+			continue
+		}
+		checked++
+		findings, err := l.lint(rule)
+		if err != nil {
+			return apiErrorResult("Failed to evaluate notification policies", err), nil
+		}
+		if len(findings) == 0 {
+			continue
+		}
+		for _, f := range findings {
+			counts[f.Check]++
+		}
+		linted = append(linted, ruleLint{UID: rule.UID, Title: rule.Title, FolderUID: rule.FolderUID, RuleGroup: rule.RuleGroup, Findings: findings})
+	}
+	sort.Slice(linted, func(i, j int) bool {
+		if len(linted[i].Findings) != len(linted[j].Findings) {
+			return len(linted[i].Findings) > len(linted[j].Findings)
+		}
+		return linted[i].Title < linted[j].Title
+	})
+
+	return jsonResult(map[string]interface{}{
+		"rules_checked":       checked,
+		"rules_with_findings": len(linted),
+		"findings_by_check":   counts,
+		"rules":               linted,
+		"fixes":               l.fixes(rules, linted),
+		"note":                "File-provisioned rules get findings but no fixes; change them in their provisioning files",
+	})
+}
+
+// lint runs every enabled check on one rule
+func (l *alertLinter) lint(rule grafana.AlertRule) ([]lintFinding, error) {
+	var out []lintFinding
+	add := func(check, severity, format string, args ...interface{}) {
+		if !l.skip[check] {
+			out = append(out, lintFinding{Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	for _, name := range l.labels {
+		if strings.TrimSpace(rule.Labels[name]) == "" {
+			if name == "severity" {
+				add("missing-label", "warning", "no severity label; routing and triage cannot tell how urgent this alert is")
+			} else {
+				add("missing-label", "warning", "no %s label", name)
+			}
+		}
+	}
+	for _, name := range l.annots {
+		if strings.TrimSpace(rule.Annotations[name]) == "" {
+			add("missing-annotation", "warning", "no %s annotation; responders get no context in the notification", name)
+		}
+	}
+	if forDuration := strings.TrimSpace(rule.For); forDuration == "" || forDuration == "0" || forDuration == "0s" {
+		add("no-for", "warning", "no pending period (for); a single bad evaluation fires the alert")
+	}
+	if rule.NoDataState == "" || rule.NoDataState == "NoData" {
+		add("default-nodata", "info", "no-data handling is the default (NoData); choose OK if data disappearing is normal, Alerting if it means an outage, or KeepLast")
+	}
+	if rule.ExecErrState == "" || rule.ExecErrState == "Error" {
+		add("default-error", "info", "query error handling is the default (Error); choose Alerting, OK, or KeepLast explicitly")
+	}
+
+	if l.tree != nil {
+		if err := l.lintRouting(rule, add); err != nil {
+			return nil, err
+		}
+	}
+	if l.dsTypes != nil {
+		l.lintQueries(rule, add)
+	}
+	return out, nil
+}
+
+func (l *alertLinter) lintRouting(rule grafana.AlertRule, add func(check, severity, format string, args ...interface{})) error {
+	if receiver, _ := rule.NotificationSettings["receiver"].(string); receiver != "" {
+		if !l.receivers[receiver] {
+			add("unknown-contact-point", "error", "the rule notifies contact point %q, which does not exist", receiver)
+		}
+		return nil
+	}
+
+	labels := map[string]string{"alertname": rule.Title, "grafana_folder": rule.FolderUID}
+	if title, ok := l.folders[rule.FolderUID]; ok {
+		labels["grafana_folder"] = title
+	}
+	for k, v := range rule.Labels {
+		labels[k] = v
+	}
+	root := routeOpts{GroupWait: defaultGroupWait, GroupInterval: defaultGroupInterval, RepeatInterval: defaultRepeatInterval}
+	matched, err := matchRoutes(l.tree, root, labels, nil)
+	if err != nil {
+		return err
+	}
+	for _, m := range matched {
+		if len(m.Path) == 1 {
+			add("default-route", "warning", "no notification policy matches the rule's labels; alerts fall through to the default policy (%s)", m.Receiver)
+		}
+		if m.Receiver != "" && !l.receivers[m.Receiver] {
+			add("unknown-contact-point", "error", "the matching policy %s sends to contact point %q, which does not exist", strings.Join(m.Path, " > "), m.Receiver)
+		}
+	}
+	return nil
+}
+
+func (l *alertLinter) lintQueries(rule grafana.AlertRule, add func(check, severity, format string, args ...interface{})) {
+	for _, q := range rule.Data {
+		if !promQLTypes[l.dsTypes[q.DatasourceUID]] {
+			continue
+		}
+		expr, _ := q.Model["expr"].(string)
+		if expr == "" {
+			continue
+		}
+		parsed, err := promql.Parse(expr)
+		if err != nil {
+			add("query", "error", "query %s does not parse: %v", q.RefID, err)
+			continue
+		}
+		for _, w := range promql.Lint(expr, parsed) {
+			add("query", "warning", "query %s: %s", q.RefID, w.Message)
+		}
+	}
+}
+
+// fixes turns findings into tool calls. Label and annotation fixes are
+// batched into one grafana_bulk_edit_alert_rules call per missing key.
+func (l *alertLinter) fixes(rules []grafana.AlertRule, linted []ruleLint) []lintFix {
+	byUID := make(map[string]grafana.AlertRule, len(rules))
+	for _, rule := range rules {
+		byUID[rule.UID] = rule
+	}
+	missingLabel := map[string][]string{}
+	missingAnnotation := map[string][]string{}
+	var out []lintFix
+	for _, rl := range linted {
+		rule := byUID[rl.UID]
+		if rule.Provenance == "file" {
+			continue
+		}
+		checks := map[string]bool{}
+		for _, f := range rl.Findings {
+			checks[f.Check] = true
+		}
+		if checks["missing-label"] {
+			for _, name := range l.labels {
+				if strings.TrimSpace(rule.Labels[name]) == "" {
+					missingLabel[name] = append(missingLabel[name], rl.UID)
+				}
+			}
+		}
+		if checks["missing-annotation"] {
+			for _, name := range l.annots {
+				if strings.TrimSpace(rule.Annotations[name]) == "" {
+					missingAnnotation[name] = append(missingAnnotation[name], rl.UID)
+				}
+			}
+		}
+		if checks["no-for"] {
+			out = append(out, lintFix{
+				Check: "no-for",
+				Tool:  "grafana_update_alert_rule",
+				Args:  map[string]interface{}{"uid": rl.UID, "for_duration": "5m"},
+				Note:  fmt.Sprintf("%s: 5m is a common starting point; use at least two evaluation intervals", rl.Title),
+			})
+		}
+	}
+
+	for _, name := range sortedKeys(missingLabel) {
+		fix := lintFix{
+			Check: "missing-label",
+			Tool:  "grafana_bulk_edit_alert_rules",
+			Args:  map[string]interface{}{"uids": missingLabel[name], "add_labels": map[string]string{name: "<value>"}},
+		}
+		if name == "severity" {
+			fix.Args["add_labels"] = map[string]string{name: "warning"}
+			fix.Note = "split the uids into critical, warning, and info calls as appropriate"
+		}
+		out = append(out, fix)
+	}
+	for _, name := range sortedKeys(missingAnnotation) {
+		for _, uid := range missingAnnotation[name] {
+			out = append(out, lintFix{
+				Check: "missing-annotation",
+				Tool:  "grafana_bulk_edit_alert_rules",
+				Args:  map[string]interface{}{"uids": []string{uid}, "add_annotations": map[string]string{name: annotationPlaceholder(name)}},
+				Note:  fmt.Sprintf("%s: replace the placeholder before applying", byUID[uid].Title),
+			})
+		}
+	}
+	if out == nil {
+		out = []lintFix{}
+	}
+	return out
+}
+
+func annotationPlaceholder(name string) string {
+	switch name {
+	case "summary":
+		return "<one line: what is wrong, e.g. High error rate on {{ $labels.service }}>"
+	case "description":
+		return "<impact and likely causes; {{ $value }} and {{ $labels }} are available>"
+	case "runbook_url":
+		return "<https://link to the runbook>"
+	}
+	return "<" + name + ">"
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,405 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/packs"
+)
+
+// Labels and annotation that tie a rendered rule to its template, so a
+// later call can find and re-render every instance
+const (
+	templateIDLabel             = "template_id"
+	templateInstanceLabel       = "template_instance"
+	templateVariablesAnnotation = "template_variables"
+)
+
+// templateSelectorLabels are the variables that scope an embedded
+// template's expressions, as label matchers, unless selector is given
+var templateSelectorLabels = []string{"cluster", "namespace", "job"}
+
+var (
+	// rulePlaceholder matches ${name} placeholders
+	rulePlaceholder = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+	slugSeparators  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// alertTemplateEnv is one environment a template is rendered for
+type alertTemplateEnv struct {
+	Name      string
+	FolderUID string
+	// Variables are those given for the environment, merged over the
+	// call's; they are stored on the rule for later re-rendering
+	Variables map[string]interface{}
+}
+
+func (r *Registry) grafanaApplyAlertTemplateTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_apply_alert_template",
+		Description: "Render one alert rule template for several environments or folders, substituting ${name} variables (cluster, namespace, thresholds, datasource_uid, ...). The template is either an alert rule supplied in Grafana's provisioning JSON or an alert of an embedded template from grafana_list_templates. Each instance is labelled template_id and template_instance and keeps its variables, so calling again with the same template_id updates existing instances, and omitting environments re-renders every instance of it",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"template_id":  {Type: "string", Description: "Identifier kept in the template_id label; required with rule (default for embedded alerts: <template>-<alert title>)"},
+				"rule":         {Type: "object", Description: "Alert rule template in provisioning JSON (title, folderUID, ruleGroup, condition, data, for, labels, annotations); any string may contain ${name}, and a string that is only ${name} takes the variable's value as is, e.g. a number for a threshold"},
+				"template":     {Type: "string", Description: "Embedded template name, e.g. node or postgres, instead of rule"},
+				"alert":        {Type: "string", Description: "Title of the embedded template's alert. Its expression is scoped by the cluster, namespace, and job variables (or selector), its threshold can be overridden with threshold, and its datasource is datasource_uid"},
+				"variables":    {Type: "object", Description: "Variables shared by all environments"},
+				"environments": {Type: "array", Description: "Instances to render: [{\"name\": \"prod\", \"folder_uid\": \"...\", \"variables\": {\"cluster\": \"prod-eu\", \"threshold\": 0.9}}]. name is available as ${environment}. Omit to re-render every existing instance of template_id with its stored variables, overridden by variables"},
+				"dry_run":      {Type: "boolean", Description: "Report the rendered rules without saving"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleApplyAlertTemplate(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	rawRule, _ := args["rule"].(map[string]interface{})
+	templateName, alertTitle := getString(args, "template"), getString(args, "alert")
+	templateID := getString(args, "template_id")
+
+	var embedded *packs.Template
+	var embeddedAlert packs.Alert
+	switch {
+	case rawRule != nil && templateName != "":
+		return errorResult("give either rule or template, not both"), nil
+	case rawRule != nil:
+		if templateID == "" {
+			return errorResult("template_id is required with rule"), nil
+		}
+	case templateName != "":
+		meta, err := templateByName(templateName)
+		if err != nil {
+			return errorResultFor(err), nil
+		}
+		var titles []string
+		for _, a := range meta.Alerts {
+			if strings.EqualFold(a.Title, alertTitle) {
+				embedded, embeddedAlert = meta, a
+			}
+			titles = append(titles, a.Title)
+		}
+		if embedded == nil {
+			return errorResult(fmt.Sprintf("template %s has no alert %q (alerts: %s)", templateName, alertTitle, strings.Join(titles, ", "))), nil
+		}
+		if templateID == "" {
+			templateID = templateName + "-" + templateSlug(embeddedAlert.Title)
+		}
+	default:
+		return errorResult("rule or template and alert are required"), nil
+	}
+
+	existing, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list alert rules", err), nil
+	}
+	instances := map[string]grafana.AlertRule{}
+	for _, rule := range existing {
+		if rule.Labels[templateIDLabel] == templateID {
+			instances[rule.Labels[templateInstanceLabel]] = rule
+		}
+	}
+
+	envs, err := alertTemplateEnvs(args, instances)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if len(envs) == 0 {
+		return errorResult(fmt.Sprintf("no environments given and no existing instances of template %s", templateID)), nil
+	}
+
+	dryRun := getBool(args, "dry_run")
+	result := newBulkResult(dryRun)
+	for _, env := range envs {
+		var rule *grafana.AlertRule
+		if embedded != nil {
+			rule, err = renderEmbeddedAlert(embedded.Name, embeddedAlert, env)
+		} else {
+			rule, err = renderAlertTemplate(rawRule, env, nil)
+		}
+		if err != nil {
+			result.Fail(env.Name, "", err.Error(), nil)
+			continue
+		}
+		if rule.RuleGroup == "" {
+			rule.RuleGroup = templateID
+		}
+		if err := tagTemplateInstance(rule, templateID, env); err != nil {
+			result.Fail(env.Name, rule.Title, err.Error(), nil)
+			continue
+		}
+
+		current, exists := instances[env.Name]
+		if exists && current.Provenance == "file" {
+			result.Skip(current.UID, current.Title, "rule is provisioned from a file", nil)
+			continue
+		}
+		detail := map[string]interface{}{"environment": env.Name, "rule": rule}
+		if dryRun {
+			status := "would_create"
+			if exists {
+				status = "would_update"
+				rule.UID = current.UID
+			}
+			result.Succeed(rule.UID, rule.Title, status, detail)
+			continue
+		}
+
+		var saved *grafana.AlertRule
+		switch {
+		case !exists:
+			saved, err = r.client.CreateAlertRuleKeepEditable(r.ctx, *rule)
+		case current.Provenance == "":
+			rule.UID = current.UID
+			saved, err = r.client.UpdateAlertRuleKeepEditable(r.ctx, current.UID, *rule)
+		default:
+			rule.UID = current.UID
+			saved, err = r.client.UpdateAlertRule(r.ctx, current.UID, *rule)
+		}
+		if err != nil {
+			result.Fail(env.Name, rule.Title, err.Error(), detail)
+			continue
+		}
+		status := "created"
+		if exists {
+			status = "updated"
+		}
+		result.Succeed(saved.UID, saved.Title, status, map[string]interface{}{
+			"environment": env.Name,
+			"folder_uid":  saved.FolderUID,
+			"rule_group":  saved.RuleGroup,
+		})
+	}
+	return bulkResult(result)
+}
+
+// alertTemplateEnvs reads the environments argument, or, without one,
+// recovers every existing instance's environment and stored variables,
+// with the call's variables overriding them
+func alertTemplateEnvs(args map[string]interface{}, instances map[string]grafana.AlertRule) ([]alertTemplateEnv, error) {
+	shared, _ := args["variables"].(map[string]interface{})
+	if _, ok := args["environments"]; !ok {
+		names := make([]string, 0, len(instances))
+		for name := range instances {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		envs := make([]alertTemplateEnv, 0, len(names))
+		for _, name := range names {
+			rule := instances[name]
+			vars := map[string]interface{}{}
+			if stored := rule.Annotations[templateVariablesAnnotation]; stored != "" {
+				if err := json.Unmarshal([]byte(stored), &vars); err != nil {
+					return nil, fmt.Errorf("instance %s (%s) has unreadable %s: %v", name, rule.UID, templateVariablesAnnotation, err)
+				}
+			}
+			envs = append(envs, alertTemplateEnv{Name: name, FolderUID: rule.FolderUID, Variables: mergeVariables(vars, shared)})
+		}
+		return envs, nil
+	}
+
+	seen := map[string]bool{}
+	var envs []alertTemplateEnv
+	for i, e := range getMapSlice(args, "environments") {
+		name := getString(e, "name")
+		if name == "" {
+			return nil, fmt.Errorf("environments[%d]: name is required", i)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("environments[%d]: duplicate name %q", i, name)
+		}
+		seen[name] = true
+		vars, _ := e["variables"].(map[string]interface{})
+		env := alertTemplateEnv{Name: name, FolderUID: getString(e, "folder_uid"), Variables: mergeVariables(shared, vars)}
+		if env.FolderUID == "" {
+			if rule, ok := instances[name]; ok {
+				env.FolderUID = rule.FolderUID
+			}
+		}
+		envs = append(envs, env)
+	}
+	return envs, nil
+}
+
+// renderEmbeddedAlert turns an embedded template's alert into a rule for
+// env, scoped by its selector variables
+func renderEmbeddedAlert(template string, a packs.Alert, env alertTemplateEnv) (*grafana.AlertRule, error) {
+	selector, _ := env.Variables["selector"].(string)
+	if selector == "" {
+		var matchers []string
+		for _, label := range templateSelectorLabels {
+			if v, ok := env.Variables[label]; ok {
+				matchers = append(matchers, fmt.Sprintf("%s=%q", label, fmt.Sprint(v)))
+			}
+		}
+		selector = strings.Join(matchers, ", ")
+	}
+	inputs := map[string]string{}
+	if meta, err := templateByName(template); err == nil {
+		for _, in := range meta.Inputs {
+			inputs[in.Name] = "${datasource_uid}"
+		}
+	}
+	t, err := packs.LoadTemplate(template, packs.TemplateParams{Datasources: inputs, Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+	for _, loaded := range t.Alerts {
+		if loaded.Title == a.Title {
+			a = loaded
+		}
+	}
+
+	rule := templateAlertRule(template, a, "${datasource_uid}", "", "")
+	rule.Title = a.Title + " (${environment})"
+	rule.Data[1].Model["conditions"] = []interface{}{map[string]interface{}{
+		"evaluator": map[string]interface{}{"type": a.Op, "params": []interface{}{"${threshold}"}},
+	}}
+	raw, err := toGenericMap(rule)
+	if err != nil {
+		return nil, err
+	}
+	return renderAlertTemplate(raw, env, map[string]interface{}{"threshold": a.Threshold})
+}
+
+// renderAlertTemplate substitutes env's variables into a rule template.
+// defaults fill variables env does not set.
+func renderAlertTemplate(raw map[string]interface{}, env alertTemplateEnv, defaults map[string]interface{}) (*grafana.AlertRule, error) {
+	vars := mergeVariables(defaults, env.Variables)
+	vars["environment"] = env.Name
+	if env.FolderUID != "" {
+		vars["folder_uid"] = env.FolderUID
+	}
+
+	missing := map[string]bool{}
+	rendered := substituteVariables(raw, vars, missing)
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for name := range missing {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("environment %s: no value for %s", env.Name, strings.Join(names, ", "))
+	}
+
+	data, err := json.Marshal(rendered)
+	if err != nil {
+		return nil, err
+	}
+	var rule grafana.AlertRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("environment %s: rendered rule is invalid: %v", env.Name, err)
+	}
+	if env.FolderUID != "" {
+		rule.FolderUID = env.FolderUID
+	}
+	if rule.FolderUID == "" {
+		return nil, fmt.Errorf("environment %s: folder_uid is required", env.Name)
+	}
+	if rule.Title == "" || rule.Condition == "" || len(rule.Data) == 0 {
+		return nil, fmt.Errorf("environment %s: the rule needs a title, condition, and data", env.Name)
+	}
+	// Instances sharing a folder need distinct titles
+	if title, _ := raw["title"].(string); !rulePlaceholder.MatchString(title) {
+		rule.Title += " (" + env.Name + ")"
+	}
+	rule.UID = ""
+	return &rule, nil
+}
+
+// tagTemplateInstance labels a rendered rule with its template and
+// environment and stores the variables it was rendered with
+func tagTemplateInstance(rule *grafana.AlertRule, templateID string, env alertTemplateEnv) error {
+	if rule.Labels == nil {
+		rule.Labels = map[string]string{}
+	}
+	rule.Labels[templateIDLabel] = templateID
+	rule.Labels[templateInstanceLabel] = env.Name
+	vars, err := json.Marshal(env.Variables)
+	if err != nil {
+		return err
+	}
+	if rule.Annotations == nil {
+		rule.Annotations = map[string]string{}
+	}
+	rule.Annotations[templateVariablesAnnotation] = string(vars)
+	return nil
+}
+
+// substituteVariables replaces ${name} in every string of v. A string that
+// is only a placeholder becomes the variable's value, keeping its type.
+// Names without a value are added to missing.
+func substituteVariables(v interface{}, vars map[string]interface{}, missing map[string]bool) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			out[k] = substituteVariables(item, vars, missing)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substituteVariables(item, vars, missing)
+		}
+		return out
+	case string:
+		if m := rulePlaceholder.FindStringSubmatch(v); m != nil && m[0] == v {
+			if value, ok := vars[m[1]]; ok {
+				return value
+			}
+			missing[m[1]] = true
+			return v
+		}
+		return rulePlaceholder.ReplaceAllStringFunc(v, func(p string) string {
+			name := p[2 : len(p)-1]
+			value, ok := vars[name]
+			if !ok {
+				missing[name] = true
+				return p
+			}
+			return fmt.Sprint(value)
+		})
+	}
+	return v
+}
+
+// mergeVariables returns base with over's values set on top
+func mergeVariables(base, over map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base)+len(over))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range over {
+		out[k] = v
+	}
+	return out
+}
+
+// toGenericMap converts v to the map form its JSON decodes to
+func toGenericMap(v interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// templateSlug lower-cases s and joins its words with hyphens
+func templateSlug(s string) string {
+	return strings.Trim(slugSeparators.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
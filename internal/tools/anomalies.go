@@ -0,0 +1,329 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	defaultAnomalyConcurrency = 4
+	maxAnomalyConcurrency     = 8
+	anomalySeriesPerPanel     = 3
+)
+
+func (r *Registry) grafanaFindDashboardAnomaliesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_find_dashboard_anomalies",
+		Description: "Run every panel query of a dashboard over a window, compare each series' recent values with its own earlier baseline, and rank the panels that deviate most (\"what looks wrong on this dashboard\")",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"dashboard_uid":   {Type: "string", Description: "UID of the dashboard to scan"},
+				"from":            {Type: "string", Description: "Start of the window, baseline included (default now-6h)"},
+				"to":              {Type: "string", Description: "End of the window (default now)"},
+				"recent":          {Type: "string", Description: "Trailing part of the window compared against the rest, e.g. 15m (default: last 10% of the window)"},
+				"vars":            {Type: "object", Description: "Template variable values overriding the dashboard's current ones, e.g. {\"env\": \"prod\"}"},
+				"top":             {Type: "integer", Description: "Number of panels to return (default 10)"},
+				"min_score":       {Type: "number", Description: "Only return panels scoring at least this many standard deviations (default 0)"},
+				"max_data_points": {Type: "integer", Description: "Points per series (default 300)"},
+				"concurrency":     {Type: "integer", Description: "Panels queried in parallel (default 4, max 8)"},
+			},
+			Required: []string{"dashboard_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// seriesDeviation is the deviation of one series of a panel
+type seriesDeviation struct {
+	Series string `json:"series"`
+	analysis.Deviation
+}
+
+// panelAnomaly is the scan outcome for one panel
+type panelAnomaly struct {
+	PanelID  int64             `json:"panel_id"`
+	Title    string            `json:"title,omitempty"`
+	Type     string            `json:"type,omitempty"`
+	Score    float64           `json:"score"`
+	Series   []seriesDeviation `json:"series,omitempty"`
+	PanelURL string            `json:"panel_url,omitempty"`
+	Error    string            `json:"error,omitempty"`
+
+	queries []grafana.QueryTarget
+}
+
+func (r *Registry) handleFindDashboardAnomalies(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "dashboard_uid")
+	if uid == "" {
+		return errorResult("dashboard_uid is required"), nil
+	}
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-6h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	recent := end.Sub(start) / 10
+	if s := getString(args, "recent"); s != "" {
+		if recent, err = parseGrafanaDuration(s); err != nil {
+			return errorResult(fmt.Sprintf("invalid recent %q: %v", s, err)), nil
+		}
+	}
+	if recent <= 0 || recent >= end.Sub(start) {
+		return errorResult("recent must be shorter than the window"), nil
+	}
+	top := getInt(args, "top")
+	if top <= 0 {
+		top = 10
+	}
+	minScore := getFloat(args, "min_score")
+	maxPoints := getInt(args, "max_data_points")
+	if maxPoints <= 0 {
+		maxPoints = 300
+	}
+	concurrency := getInt(args, "concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultAnomalyConcurrency
+	}
+	if concurrency > maxAnomalyConcurrency {
+		concurrency = maxAnomalyConcurrency
+	}
+
+	dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		return apiErrorResult("Failed to get dashboard", err), nil
+	}
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list datasources", err), nil
+	}
+
+	vars := dashboard.CurrentValues(dash.Dashboard)
+	for name, v := range getStringMap(args, "vars") {
+		vars[name] = []string{v}
+	}
+	resolver := newDatasourceResolver(datasources, vars)
+
+	var panels []*panelAnomaly
+	skipped := 0
+	for _, p := range dashboard.Panels(dash.Dashboard) {
+		if dashboard.IsRow(p) {
+			continue
+		}
+		pa := &panelAnomaly{
+			PanelID: dashboard.PanelID(p),
+			Title:   dashboard.String(p, "title"),
+			Type:    dashboard.String(p, "type"),
+		}
+		pa.queries, err = panelQueries(p, vars, resolver, maxPoints)
+		if err != nil {
+			pa.Error = err.Error()
+		} else if len(pa.queries) == 0 {
+			skipped++
+			continue
+		}
+		panels = append(panels, pa)
+	}
+
+	since := end.Add(-recent).UnixMilli()
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for _, pa := range panels {
+		if pa.Error != "" {
+			continue
+		}
+		wg.Add(1)
+		go func(pa *panelAnomaly) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			r.scorePanel(pa, start, end, since)
+		}(pa)
+	}
+	wg.Wait()
+
+	ranked := []panelAnomaly{}
+	failed := []panelAnomaly{}
+	noData := 0
+	for _, pa := range panels {
+		switch {
+		case pa.Error != "":
+			failed = append(failed, panelAnomaly{PanelID: pa.PanelID, Title: pa.Title, Error: pa.Error})
+		case len(pa.Series) == 0:
+			noData++
+		case pa.Score >= minScore:
+			pa.PanelURL = r.client.PanelURL(uid, pa.PanelID, getString(args, "from"), getString(args, "to"), getStringMap(args, "vars"))
+			ranked = append(ranked, *pa)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return ranked[i].Score > ranked[j].Score })
+	if len(ranked) > top {
+		ranked = ranked[:top]
+	}
+
+	return jsonResult(map[string]interface{}{
+		"dashboard_uid":  uid,
+		"from":           start.UTC().Format(time.RFC3339),
+		"to":             end.UTC().Format(time.RFC3339),
+		"recent_seconds": int64(recent.Seconds()),
+		"panels_scanned": len(panels) - len(failed),
+		"no_data":        noData,
+		"skipped":        skipped,
+		"anomalies":      ranked,
+		"errors":         failed,
+	})
+}
+
+// scorePanel runs the panel's queries and records its most deviating series
+func (r *Registry) scorePanel(pa *panelAnomaly, start, end time.Time, since int64) {
+	resp, err := r.query(grafana.QueryRequest{
+		From:    fmt.Sprintf("%d", start.UnixMilli()),
+		To:      fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: pa.queries,
+	})
+	if err != nil {
+		pa.Error = err.Error()
+		return
+	}
+
+	var frames []grafana.DataFrame
+	var errs []string
+	for _, q := range pa.queries {
+		res := resp.Results[q.RefID]
+		if res.Error != "" {
+			errs = append(errs, fmt.Sprintf("%s: %s", q.RefID, res.Error))
+		}
+		frames = append(frames, res.Frames...)
+	}
+
+	for _, s := range analysis.FramesToSeries(frames) {
+		if d, ok := analysis.RecentDeviation(s.Times, s.Values, since); ok {
+			pa.Series = append(pa.Series, seriesDeviation{Series: s.Name, Deviation: d})
+		}
+	}
+	if len(pa.Series) == 0 && len(errs) > 0 {
+		pa.Error = strings.Join(errs, "; ")
+		return
+	}
+	sort.SliceStable(pa.Series, func(i, j int) bool { return pa.Series[i].Score > pa.Series[j].Score })
+	if len(pa.Series) > anomalySeriesPerPanel {
+		pa.Series = pa.Series[:anomalySeriesPerPanel]
+	}
+	if len(pa.Series) > 0 {
+		pa.Score = pa.Series[0].Score
+	}
+}
+
+// panelQueries converts a panel's visible targets into executable queries,
+// interpolating template variables and resolving datasource references
+func panelQueries(p map[string]interface{}, vars map[string][]string, resolver *datasourceResolver, maxPoints int) ([]grafana.QueryTarget, error) {
+	targets, _ := p["targets"].([]interface{})
+	var queries []grafana.QueryTarget
+	for i, t := range targets {
+		tm, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if hide, _ := tm["hide"].(bool); hide {
+			continue
+		}
+		ref := tm["datasource"]
+		if ref == nil {
+			ref = p["datasource"]
+		}
+		ds, ok, err := resolver.resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+
+		extra := make(map[string]interface{}, len(tm))
+		for k, v := range tm {
+			if s, ok := v.(string); ok {
+				v = dashboard.Interpolate(s, vars)
+			}
+			extra[k] = v
+		}
+		refID := dashboard.String(tm, "refId")
+		if refID == "" {
+			refID = fmt.Sprintf("Q%d", i)
+		}
+		queries = append(queries, grafana.QueryTarget{
+			RefID:         refID,
+			Datasource:    ds,
+			MaxDataPoints: maxPoints,
+			Extra:         extra,
+		})
+	}
+	return queries, nil
+}
+
+// datasourceResolver maps the datasource references found in dashboards
+// (objects, legacy names, variables, or nothing for the default) to UIDs
+type datasourceResolver struct {
+	vars   map[string][]string
+	byUID  map[string]grafana.Datasource
+	byName map[string]grafana.Datasource
+	def    *grafana.Datasource
+}
+
+func newDatasourceResolver(datasources []grafana.Datasource, vars map[string][]string) *datasourceResolver {
+	dr := &datasourceResolver{
+		vars:   vars,
+		byUID:  make(map[string]grafana.Datasource, len(datasources)),
+		byName: make(map[string]grafana.Datasource, len(datasources)),
+	}
+	for i, ds := range datasources {
+		dr.byUID[ds.UID] = ds
+		dr.byName[ds.Name] = ds
+		if ds.IsDefault {
+			dr.def = &datasources[i]
+		}
+	}
+	return dr
+}
+
+// resolve returns the concrete datasource for ref. It reports false for
+// references that cannot be queried on their own: the Grafana, Mixed, and
+// Dashboard pseudo-datasources and server-side expressions.
+func (dr *datasourceResolver) resolve(ref interface{}) (grafana.DatasourceRef, bool, error) {
+	var key string
+	switch v := ref.(type) {
+	case nil:
+		if dr.def == nil {
+			return grafana.DatasourceRef{}, false, fmt.Errorf("panel uses the default datasource but none is set")
+		}
+		return grafana.DatasourceRef{Type: dr.def.Type, UID: dr.def.UID}, true, nil
+	case string:
+		key = v
+	case map[string]interface{}:
+		key = dashboard.String(v, "uid")
+	}
+	key = dashboard.Interpolate(key, dr.vars)
+	switch key {
+	case "", "-- Grafana --", "grafana", "-- Mixed --", "-- Dashboard --", "__expr__", "default":
+		if key == "default" && dr.def != nil {
+			return grafana.DatasourceRef{Type: dr.def.Type, UID: dr.def.UID}, true, nil
+		}
+		return grafana.DatasourceRef{}, false, nil
+	}
+	if ds, ok := dr.byUID[key]; ok {
+		return grafana.DatasourceRef{Type: ds.Type, UID: ds.UID}, true, nil
+	}
+	if ds, ok := dr.byName[key]; ok {
+		return grafana.DatasourceRef{Type: ds.Type, UID: ds.UID}, true, nil
+	}
+	return grafana.DatasourceRef{}, false, fmt.Errorf("datasource %q not found", key)
+}
@@ -0,0 +1,413 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	batchToolName = "grafana_batch"
+	// batchMaxSteps bounds the work a single batch call can queue
+	batchMaxSteps = 50
+)
+
+// batchPlaceholder matches a whole-string reference to an earlier step's
+// output: $step or $step.path.to.field, with numeric segments indexing lists
+var batchPlaceholder = regexp.MustCompile(`^\$([A-Za-z0-9_-]+)((?:\.[^.\s]+)*)$`)
+
+// batchStep is one tool invocation in a batch
+type batchStep struct {
+	ID        string
+	Tool      string
+	Args      map[string]interface{}
+	DependsOn []string
+}
+
+// BatchStepResult is the outcome of one step
+type BatchStepResult struct {
+	ID       string      `json:"id"`
+	Tool     string      `json:"tool"`
+	Status   string      `json:"status"`
+	Result   interface{} `json:"result,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	Reason   string      `json:"reason,omitempty"`
+	Duration string      `json:"duration,omitempty"`
+	// ErrorData details a step that failed on a Grafana API error
+	ErrorData *mcp.ErrorData `json:"error_data,omitempty"`
+}
+
+func (r *Registry) grafanaBatchTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        batchToolName,
+		Description: "Run several tool calls in one request, in order or by dependency, and return each step's result. A string argument of the form $<step id> or $<step id>.<path> is replaced by that step's output (e.g. \"$folder.uid\"), which makes the step a dependency. Steps whose dependencies failed are skipped.",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"steps":     {Type: "array", Description: "Steps to run: objects with tool, args, and optional id (default step1, step2, ...) and depends_on (list of step ids). Steps run in the order given unless dependencies require otherwise."},
+				"read_only": {Type: "boolean", Description: "Refuse the whole batch if any step calls a tool that can modify Grafana"},
+				"on_error":  {Type: "string", Description: "stop (default) skips all remaining steps after a failure; continue only skips steps that depend on the failed one", Enum: []string{"stop", "continue"}},
+			},
+			Required: []string{"steps"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleBatch(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	raw := getMapSlice(args, "steps")
+	if len(raw) == 0 {
+		return errorResult("steps is required"), nil
+	}
+	if len(raw) > batchMaxSteps {
+		return errorResult(fmt.Sprintf("a batch can have at most %d steps", batchMaxSteps)), nil
+	}
+	onError := getString(args, "on_error")
+	if onError == "" {
+		onError = "stop"
+	}
+	if onError != "stop" && onError != "continue" {
+		return errorResult("on_error must be stop or continue"), nil
+	}
+
+	steps, err := r.parseBatchSteps(raw, getBool(args, "read_only"))
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	order, err := batchOrder(steps)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	results := make(map[string]*BatchStepResult, len(steps))
+	outputs := map[string]interface{}{}
+	stopped := ""
+	for _, i := range order {
+		step := steps[i]
+		res := &BatchStepResult{ID: step.ID, Tool: step.Tool}
+		results[step.ID] = res
+
+		if stopped != "" {
+			res.Status, res.Reason = "skipped", fmt.Sprintf("step %s failed", stopped)
+			continue
+		}
+		if dep := failedDependency(step, results); dep != "" {
+			res.Status, res.Reason = "skipped", fmt.Sprintf("dependency %s did not succeed", dep)
+			continue
+		}
+		stepArgs, err := substituteBatchOutputs(step.Args, outputs)
+		if err != nil {
+			res.Status, res.Error = "error", err.Error()
+		} else {
+			started := time.Now()
+			out, err := r.CallTool(r.ctx, step.Tool, stepArgs)
+			res.Duration = time.Since(started).Round(time.Millisecond).String()
+			switch {
+			case err != nil:
+				res.Status, res.Error = "error", err.Error()
+			case out.IsError:
+				res.Status, res.Error = "error", resultText(out)
+				res.ErrorData = resultErrorData(out)
+			default:
+				res.Status = "ok"
+				res.Result = batchOutput(out)
+				outputs[step.ID] = res.Result
+			}
+		}
+		if res.Status == "error" && onError == "stop" {
+			stopped = step.ID
+		}
+	}
+
+	report := map[string]interface{}{}
+	ordered := make([]*BatchStepResult, len(steps))
+	counts := map[string]int{}
+	for i, step := range steps {
+		ordered[i] = results[step.ID]
+		counts[ordered[i].Status]++
+	}
+	report["steps"] = ordered
+	report["succeeded"] = counts["ok"]
+	report["failed"] = counts["error"]
+	report["skipped"] = counts["skipped"]
+	result, err := jsonResult(report)
+	if result != nil && counts["ok"] == 0 && counts["error"] > 0 {
+		result.IsError = true
+	}
+	return result, err
+}
+
+// parseBatchSteps validates every step before any runs: tools must exist,
+// be enabled, and be read-only when readOnly is set
+func (r *Registry) parseBatchSteps(raw []map[string]interface{}, readOnly bool) ([]batchStep, error) {
+	steps := make([]batchStep, len(raw))
+	ids := map[string]bool{}
+	for i, m := range raw {
+		s := batchStep{
+			ID:        getString(m, "id"),
+			Tool:      getString(m, "tool"),
+			DependsOn: getStringSlice(m, "depends_on"),
+		}
+		if s.ID == "" {
+			s.ID = fmt.Sprintf("step%d", i+1)
+		}
+		if a, ok := m["args"].(map[string]interface{}); ok {
+			s.Args = a
+		} else if m["args"] != nil {
+			return nil, fmt.Errorf("step %q: args must be an object", s.ID)
+		}
+		switch {
+		case ids[s.ID]:
+			return nil, fmt.Errorf("step id %q is used more than once", s.ID)
+		case s.Tool == "":
+			return nil, fmt.Errorf("step %q: tool is required", s.ID)
+		case s.Tool == batchToolName:
+			return nil, fmt.Errorf("step %q: batches cannot be nested", s.ID)
+		}
+		if _, ok := r.tools[s.Tool]; !ok {
+			return nil, fmt.Errorf("step %q: tool %s is unknown or disabled", s.ID, s.Tool)
+		}
+		if reason := r.unsupportedReason(s.Tool); reason != "" {
+			return nil, fmt.Errorf("step %q: %s", s.ID, reason)
+		}
+		if readOnly && !r.readOnly[s.Tool] {
+			return nil, fmt.Errorf("step %q: %s can modify Grafana and the batch is read_only", s.ID, s.Tool)
+		}
+		ids[s.ID] = true
+		steps[i] = s
+	}
+
+	for i := range steps {
+		deps := append([]string(nil), steps[i].DependsOn...)
+		for _, ref := range batchReferences(steps[i].Args) {
+			if ids[ref] && !contains(deps, ref) {
+				deps = append(deps, ref)
+			}
+		}
+		for _, dep := range deps {
+			if !ids[dep] {
+				return nil, fmt.Errorf("step %q depends on unknown step %q", steps[i].ID, dep)
+			}
+		}
+		steps[i].DependsOn = deps
+	}
+	return steps, nil
+}
+
+// batchOrder returns step indexes so that every step follows its
+// dependencies, otherwise keeping the order given
+func batchOrder(steps []batchStep) ([]int, error) {
+	done := map[string]bool{}
+	order := make([]int, 0, len(steps))
+	for len(order) < len(steps) {
+		progressed := false
+		for i, s := range steps {
+			if done[s.ID] {
+				continue
+			}
+			ready := true
+			for _, dep := range s.DependsOn {
+				ready = ready && done[dep]
+			}
+			if ready {
+				done[s.ID] = true
+				order = append(order, i)
+				progressed = true
+				break
+			}
+		}
+		if !progressed {
+			var pending []string
+			for _, s := range steps {
+				if !done[s.ID] {
+					pending = append(pending, s.ID)
+				}
+			}
+			return nil, fmt.Errorf("steps %s have a dependency cycle", strings.Join(pending, ", "))
+		}
+	}
+	return order, nil
+}
+
+func failedDependency(step batchStep, results map[string]*BatchStepResult) string {
+	for _, dep := range step.DependsOn {
+		if res := results[dep]; res == nil || res.Status != "ok" {
+			return dep
+		}
+	}
+	return ""
+}
+
+// batchReferences lists the step ids referenced by placeholders in v
+func batchReferences(v interface{}) []string {
+	var refs []string
+	switch v := v.(type) {
+	case string:
+		if m := batchPlaceholder.FindStringSubmatch(v); m != nil {
+			refs = append(refs, m[1])
+		}
+	case map[string]interface{}:
+		for _, child := range v {
+			refs = append(refs, batchReferences(child)...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			refs = append(refs, batchReferences(child)...)
+		}
+	}
+	return refs
+}
+
+// substituteBatchOutputs replaces placeholders with earlier step outputs.
+// Strings naming something other than a completed step, such as Grafana's
+// $__interval, are left as they are.
+func substituteBatchOutputs(v map[string]interface{}, outputs map[string]interface{}) (map[string]interface{}, error) {
+	out, err := substituteBatchValue(v, outputs)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := out.(map[string]interface{})
+	return m, nil
+}
+
+func substituteBatchValue(v interface{}, outputs map[string]interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case string:
+		m := batchPlaceholder.FindStringSubmatch(v)
+		if m == nil {
+			return v, nil
+		}
+		output, ok := outputs[m[1]]
+		if !ok {
+			return v, nil
+		}
+		found, err := lookupPath(output, strings.TrimPrefix(m[2], "."), v)
+		if err != nil {
+			return nil, err
+		}
+		return plainNumbers(found), nil
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			s, err := substituteBatchValue(child, outputs)
+			if err != nil {
+				return nil, err
+			}
+			out[k] = s
+		}
+		return out, nil
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			s, err := substituteBatchValue(child, outputs)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = s
+		}
+		return out, nil
+	}
+	return v, nil
+}
+
+// lookupPath follows a dotted path of keys and list indexes into v
+func lookupPath(v interface{}, path, placeholder string) (interface{}, error) {
+	if path == "" {
+		return v, nil
+	}
+	for _, seg := range strings.Split(path, ".") {
+		switch cur := v.(type) {
+		case map[string]interface{}:
+			next, ok := cur[seg]
+			if !ok {
+				return nil, fmt.Errorf("%s: the output has no field %q", placeholder, seg)
+			}
+			v = next
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(cur) {
+				return nil, fmt.Errorf("%s: %q is not an index into a list of %d", placeholder, seg, len(cur))
+			}
+			v = cur[i]
+		default:
+			return nil, fmt.Errorf("%s: cannot look up %q in a %T", placeholder, seg, v)
+		}
+	}
+	return v, nil
+}
+
+// plainNumbers converts decoded numbers to float64, the form tool arguments
+// arrive in from clients
+func plainNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case json.Number:
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for k, child := range v {
+			out[k] = plainNumbers(child)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = plainNumbers(child)
+		}
+		return out
+	}
+	return v
+}
+
+// batchOutput returns a step's output as JSON when it is JSON, and as text
+// otherwise; images are described rather than inlined
+func batchOutput(res *mcp.CallToolResult) interface{} {
+	var parts []interface{}
+	for _, c := range res.Content {
+		if c.Type != "text" {
+			parts = append(parts, map[string]string{"type": c.Type, "mimeType": c.MimeType, "note": "binary content omitted from batch output"})
+			continue
+		}
+		// Numbers are kept as written so large IDs survive the round trip
+		dec := json.NewDecoder(strings.NewReader(c.Text))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err == nil {
+			parts = append(parts, v)
+		} else {
+			parts = append(parts, c.Text)
+		}
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return parts
+}
+
+// resultErrorData returns the Grafana API error details of a failed tool
+// result, or nil
+func resultErrorData(res *mcp.CallToolResult) *mcp.ErrorData {
+	sc, _ := res.StructuredContent.(map[string]interface{})
+	data, _ := sc["error"].(*mcp.ErrorData)
+	return data
+}
+
+// resultText joins the text blocks of a result
+func resultText(res *mcp.CallToolResult) string {
+	var parts []string
+	for _, c := range res.Content {
+		if c.Type == "text" {
+			parts = append(parts, c.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
@@ -0,0 +1,429 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// serviceSlugLimit keeps UIDs derived from a service name within Grafana's
+// 40 characters
+const serviceSlugLimit = 30
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// installStep reports what one part of a multi-object install did
+type installStep struct {
+	Step   string `json:"step"`
+	Status string `json:"status"` // created, exists, updated, skipped, or failed
+	UID    string `json:"uid,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// bootstrapPlan is everything a bootstrap would create
+type bootstrapPlan struct {
+	Service      string                 `json:"service"`
+	FolderUID    string                 `json:"folder_uid"`
+	FolderTitle  string                 `json:"folder_title"`
+	Team         *grafana.Team          `json:"team,omitempty"`
+	Dashboard    map[string]interface{} `json:"dashboard"`
+	Detected     map[string]interface{} `json:"detected"`
+	AlertRules   []grafana.AlertRule    `json:"alert_rules"`
+	ContactPoint string                 `json:"contact_point,omitempty"`
+	Route        *grafana.Route         `json:"route,omitempty"`
+	Notes        []string               `json:"notes,omitempty"`
+}
+
+func (r *Registry) grafanaBootstrapServiceTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_bootstrap_service",
+		Description: "Onboard a service in one call: create its folder (granting a team Edit), a RED/USE dashboard with a deployment annotation query, baseline error-ratio and p95-latency alert rules labelled service and team, a notification policy routing service=<name> to a contact point, and an onboarding annotation. Existing objects are left alone, so the call can be re-run.",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"service":               {Type: "string", Description: "Service name (OTel service.name)"},
+				"prometheus_uid":        {Type: "string", Description: "Prometheus-compatible datasource UID"},
+				"tempo_uid":             {Type: "string", Description: "Tempo datasource UID for trace panels (optional)"},
+				"loki_uid":              {Type: "string", Description: "Loki datasource UID for a logs panel (optional)"},
+				"team":                  {Type: "string", Description: "Team name given Edit on the folder and used as the team label on alert rules (optional)"},
+				"contact_point":         {Type: "string", Description: "Contact point that alerts labelled service=<service> are routed to (optional)"},
+				"folder_title":          {Type: "string", Description: "Folder title (default: the service name)"},
+				"error_ratio_threshold": {Type: "number", Description: "Error ratio above which the error alert fires (default 0.05)"},
+				"latency_threshold":     {Type: "number", Description: "p95 latency in seconds above which the latency alert fires (default 1)"},
+				"dry_run":               {Type: "boolean", Description: "Return the plan without creating anything"},
+			},
+			Required: []string{"service", "prometheus_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleBootstrapService(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	service := getString(args, "service")
+	if service == "" {
+		return errorResult("service is required"), nil
+	}
+	promUID := getString(args, "prometheus_uid")
+	if promUID == "" {
+		return errorResult("prometheus_uid is required"), nil
+	}
+	slug := serviceSlug(service)
+	if slug == "" {
+		return errorResult("service must contain at least one letter or digit"), nil
+	}
+
+	plan, err := r.planBootstrap(service, slug, args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if getBool(args, "dry_run") {
+		return jsonResult(map[string]interface{}{"dry_run": true, "plan": plan})
+	}
+
+	steps, ok := r.applyBootstrap(plan)
+	out := map[string]interface{}{
+		"service":  service,
+		"detected": plan.Detected,
+		"steps":    steps,
+	}
+	if len(plan.Notes) > 0 {
+		out["notes"] = plan.Notes
+	}
+	result, err := jsonResult(out)
+	if result != nil && !ok {
+		result.IsError = true
+	}
+	return result, err
+}
+
+// planBootstrap resolves the team and contact point and builds every object,
+// so that bad input fails before anything is created
+func (r *Registry) planBootstrap(service, slug string, args map[string]interface{}) (*bootstrapPlan, error) {
+	plan := &bootstrapPlan{
+		Service:     service,
+		FolderUID:   "svc-" + slug,
+		FolderTitle: getString(args, "folder_title"),
+	}
+	if plan.FolderTitle == "" {
+		plan.FolderTitle = service
+	}
+
+	if team := getString(args, "team"); team != "" {
+		t, err := r.teamByName(team)
+		if err != nil {
+			return nil, err
+		}
+		plan.Team = t
+	}
+
+	if cp := getString(args, "contact_point"); cp != "" {
+		receivers, err := r.client.GetReceivers(r.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to list contact points: %v", err)
+		}
+		found := false
+		for _, rc := range receivers {
+			found = found || rc.Name == cp
+		}
+		if !found {
+			return nil, fmt.Errorf("contact point %q not found", cp)
+		}
+		plan.ContactPoint = cp
+		plan.Route = &grafana.Route{
+			Receiver:       cp,
+			ObjectMatchers: [][]string{{"service", "=", service}},
+		}
+	}
+
+	b, det, err := r.serviceDashboard(service, getString(args, "prometheus_uid"), getString(args, "tempo_uid"), "Service: "+service)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.serviceLogPanels(b, service, getString(args, "loki_uid"), det); err != nil {
+		return nil, err
+	}
+	dashUID := "svc-" + slug + "-red"
+	b.Set("uid", dashUID).
+		Set("tags", []string{"generated", "service", "otel", "service:" + service}).
+		Set("annotations", map[string]interface{}{"list": []interface{}{deploymentAnnotation(service)}})
+	plan.Dashboard = b.Dashboard()
+	plan.Detected = det.detected
+
+	if det.red == nil {
+		plan.Notes = append(plan.Notes, "no request duration histogram was found, so no baseline alert rules are planned")
+		return plan, nil
+	}
+	labels := map[string]string{"service": service}
+	if plan.Team != nil {
+		labels["team"] = plan.Team.Name
+	}
+	errRatio := getFloat(args, "error_ratio_threshold")
+	if errRatio <= 0 {
+		errRatio = 0.05
+	}
+	latency := getFloat(args, "latency_threshold")
+	if latency <= 0 {
+		latency = 1
+	}
+	plan.AlertRules = baselineRules(service, slug, plan.FolderUID, dashUID, det, labels, errRatio, latency)
+	return plan, nil
+}
+
+// applyBootstrap creates the planned objects in order. Only a folder failure
+// stops the run; ok is false when anything failed.
+func (r *Registry) applyBootstrap(plan *bootstrapPlan) ([]installStep, bool) {
+	var steps []installStep
+	ok := true
+	add := func(s installStep) {
+		if s.Status == "failed" {
+			ok = false
+		}
+		steps = append(steps, s)
+	}
+
+	folder := r.ensureFolder(plan.FolderUID, plan.FolderTitle)
+	add(folder)
+	if folder.Status == "failed" {
+		return steps, false
+	}
+
+	if plan.Team != nil {
+		s := installStep{Step: "team_permission", Status: "updated", Detail: fmt.Sprintf("team %s can edit the folder", plan.Team.Name)}
+		if err := r.client.SetFolderTeamPermission(r.ctx, plan.FolderUID, plan.Team.ID, "Edit"); err != nil {
+			s.Status, s.Detail = "failed", err.Error()
+		}
+		add(s)
+	}
+
+	dash := r.installDashboard(plan.Dashboard, plan.FolderUID, "Bootstrapped service via MCP", false)
+	if dash.Status == "exists" {
+		dash.Detail = "left unchanged; use grafana_generate_service_dashboard to regenerate it"
+	}
+	add(dash)
+
+	for _, s := range r.installAlertRules(plan.AlertRules) {
+		add(s)
+	}
+
+	if plan.Route != nil {
+		add(r.bootstrapRoute(plan.Route))
+	}
+
+	ann := installStep{Step: "annotation", Status: "created"}
+	created, err := r.client.CreateAnnotation(r.ctx, grafana.Annotation{
+		Time: time.Now().UnixMilli(),
+		Tags: []string{"service:" + plan.Service, "bootstrap"},
+		Text: fmt.Sprintf("Service %s onboarded", plan.Service),
+	})
+	if err != nil {
+		ann.Status, ann.Detail = "failed", err.Error()
+	} else {
+		ann.UID = fmt.Sprint(created.ID)
+	}
+	add(ann)
+	return steps, ok
+}
+
+// ensureFolder creates a folder with a fixed uid unless it exists
+func (r *Registry) ensureFolder(uid, title string) installStep {
+	s := installStep{Step: "folder", UID: uid}
+	if _, err := r.client.GetFolder(r.ctx, uid); err == nil {
+		s.Status = "exists"
+	} else if !isNotFound(err) {
+		s.Status, s.Detail = "failed", err.Error()
+	} else if _, err := r.client.CreateFolder(r.ctx, title, uid); err != nil {
+		s.Status, s.Detail = "failed", err.Error()
+	} else {
+		s.Status = "created"
+	}
+	return s
+}
+
+// installDashboard saves a dashboard model with a fixed uid, leaving an
+// existing dashboard alone unless overwrite is set
+func (r *Registry) installDashboard(model map[string]interface{}, folderUID, message string, overwrite bool) installStep {
+	uid, _ := model["uid"].(string)
+	title, _ := model["title"].(string)
+	s := installStep{Step: "dashboard", UID: uid, Detail: title}
+	status := "created"
+	if _, err := r.client.GetDashboard(r.ctx, uid); err == nil {
+		if !overwrite {
+			s.Status = "exists"
+			return s
+		}
+		status = "updated"
+	} else if !isNotFound(err) {
+		s.Status, s.Detail = "failed", err.Error()
+		return s
+	}
+	if _, err := r.client.SaveDashboardJSON(r.ctx, model, folderUID, message, overwrite); err != nil {
+		s.Status, s.Detail = "failed", err.Error()
+		return s
+	}
+	s.Status = status
+	return s
+}
+
+// installAlertRules creates rules, skipping any whose title already exists
+// in the rule's folder. Rules stay editable in the UI.
+func (r *Registry) installAlertRules(rules []grafana.AlertRule) []installStep {
+	if len(rules) == 0 {
+		return nil
+	}
+	existing, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return []installStep{{Step: "alert_rules", Status: "failed", Detail: err.Error()}}
+	}
+	steps := make([]installStep, 0, len(rules))
+	for _, rule := range rules {
+		s := installStep{Step: "alert_rule", Detail: rule.Title}
+		for _, e := range existing {
+			if e.FolderUID == rule.FolderUID && e.Title == rule.Title {
+				s.Status, s.UID = "exists", e.UID
+			}
+		}
+		if s.Status == "" {
+			if created, err := r.client.CreateAlertRuleKeepEditable(r.ctx, rule); err != nil {
+				s.Status, s.Detail = "failed", fmt.Sprintf("%s: %v", rule.Title, err)
+			} else {
+				s.Status, s.UID = "created", created.UID
+			}
+		}
+		steps = append(steps, s)
+	}
+	return steps
+}
+
+// bootstrapRoute adds route as the first child of the root notification
+// policy unless an identical policy exists
+func (r *Registry) bootstrapRoute(route *grafana.Route) installStep {
+	s := installStep{Step: "notification_policy", Detail: fmt.Sprintf("service=%s routes to %s", route.ObjectMatchers[0][2], route.Receiver)}
+	root, err := r.client.GetNotificationPolicyTree(r.ctx)
+	if err != nil {
+		s.Status, s.Detail = "failed", err.Error()
+		return s
+	}
+	for _, child := range root.Routes {
+		if child.Receiver == route.Receiver && len(child.ObjectMatchers) == 1 && strings.Join(child.ObjectMatchers[0], "") == strings.Join(route.ObjectMatchers[0], "") {
+			s.Status = "exists"
+			return s
+		}
+	}
+	root.Routes = append([]*grafana.Route{route}, root.Routes...)
+	if err := r.client.UpdateNotificationPolicyTree(r.ctx, *root); err != nil {
+		s.Status, s.Detail = "failed", err.Error()
+		return s
+	}
+	s.Status = "updated"
+	return s
+}
+
+// baselineRules builds error-ratio and p95-latency rules from the service's
+// request histogram
+func baselineRules(service, slug, folderUID, dashUID string, det *serviceDetection, labels map[string]string, errRatio, latency float64) []grafana.AlertRule {
+	p := det.red
+	inner := strings.TrimSuffix(strings.TrimPrefix(det.selector, "{"), "}")
+	count := p.histogram + "_count"
+	if p.unit == "ms" {
+		latency *= 1000
+	}
+	dsUID, _ := det.promRef["uid"].(string)
+
+	rule := func(title, summary, expr string, threshold float64) grafana.AlertRule {
+		return grafana.AlertRule{
+			Title:        title,
+			FolderUID:    folderUID,
+			RuleGroup:    slug,
+			Condition:    "B",
+			For:          "5m",
+			NoDataState:  "OK",
+			ExecErrState: "Error",
+			Labels:       labels,
+			Annotations: map[string]string{
+				"summary":          summary,
+				"__dashboardUid__": dashUID,
+			},
+			Data: thresholdRuleData(dsUID, expr, "gt", threshold),
+		}
+	}
+	return []grafana.AlertRule{
+		rule(service+" high error ratio",
+			fmt.Sprintf("%s is failing more than %g of requests", service, errRatio),
+			fmt.Sprintf("sum(rate(%s{%s, %s}[5m])) / sum(rate(%s%s[5m]))", count, inner, p.errors, count, det.selector),
+			errRatio),
+		rule(service+" high p95 latency",
+			fmt.Sprintf("%s p95 latency is above %g%s", service, latency, p.unit),
+			fmt.Sprintf("histogram_quantile(0.95, sum by (le) (rate(%s_bucket%s[5m])))", p.histogram, det.selector),
+			latency),
+	}
+}
+
+// thresholdRuleData is an instant Prometheus query A with a threshold
+// expression B firing when A is above (op gt) or below (op lt) threshold
+func thresholdRuleData(dsUID, expr, op string, threshold float64) []grafana.AlertQuery {
+	return []grafana.AlertQuery{
+		{
+			RefID:             "A",
+			RelativeTimeRange: grafana.RelativeTimeRange{From: 600, To: 0},
+			DatasourceUID:     dsUID,
+			Model: map[string]interface{}{
+				"refId":   "A",
+				"expr":    expr,
+				"instant": true,
+				"range":   false,
+			},
+		},
+		{
+			RefID:         "B",
+			DatasourceUID: expressionDatasource,
+			Model: map[string]interface{}{
+				"refId":      "B",
+				"type":       "threshold",
+				"expression": "A",
+				"conditions": []interface{}{map[string]interface{}{
+					"evaluator": map[string]interface{}{"type": op, "params": []interface{}{threshold}},
+				}},
+			},
+		},
+	}
+}
+
+// deploymentAnnotation shows annotations tagged service:<name>, such as
+// deploy markers posted by CI, on the service dashboard
+func deploymentAnnotation(service string) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       "Deployments",
+		"datasource": map[string]interface{}{"type": "grafana", "uid": "-- Grafana --"},
+		"enable":     true,
+		"iconColor":  "blue",
+		"target": map[string]interface{}{
+			"type":     "tags",
+			"tags":     []string{"service:" + service},
+			"matchAny": false,
+			"limit":    100,
+		},
+	}
+}
+
+// serviceSlug reduces a service name to lowercase letters, digits, and dashes
+func serviceSlug(service string) string {
+	slug := strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(service), "-"), "-")
+	if len(slug) > serviceSlugLimit {
+		slug = strings.TrimRight(slug[:serviceSlugLimit], "-")
+	}
+	return slug
+}
+
+func isNotFound(err error) bool {
+	var apiErr *grafana.APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"encoding/json"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// BulkItem is the outcome for one item of a batch operation
+type BulkItem struct {
+	ID     string      `json:"id"`
+	Title  string      `json:"title,omitempty"`
+	Status string      `json:"status"`
+	Reason string      `json:"reason,omitempty"`
+	Detail interface{} `json:"detail,omitempty"`
+}
+
+// BulkResult is the partial-failure report shared by batch tools. Items
+// that were changed (or would be, in a dry run) are succeeded; items left
+// alone on purpose are skipped; failed items carry the reason and their IDs
+// are repeated in RetryIDs so the caller can resubmit just that subset.
+type BulkResult struct {
+	DryRun    bool                   `json:"dry_run"`
+	Total     int                    `json:"total"`
+	Succeeded []BulkItem             `json:"succeeded"`
+	Failed    []BulkItem             `json:"failed"`
+	Skipped   []BulkItem             `json:"skipped"`
+	RetryIDs  []string               `json:"retry_ids,omitempty"`
+	Meta      map[string]interface{} `json:"meta,omitempty"`
+}
+
+func newBulkResult(dryRun bool) *BulkResult {
+	return &BulkResult{
+		DryRun:    dryRun,
+		Succeeded: []BulkItem{},
+		Failed:    []BulkItem{},
+		Skipped:   []BulkItem{},
+	}
+}
+
+// Succeed records an item that was changed; status names the change (e.g. "archived", "would_archive")
+func (b *BulkResult) Succeed(id, title, status string, detail interface{}) {
+	b.Succeeded = append(b.Succeeded, BulkItem{ID: id, Title: title, Status: status, Detail: detail})
+}
+
+// Skip records an item that needed no change or was deliberately left alone
+func (b *BulkResult) Skip(id, title, reason string, detail interface{}) {
+	b.Skipped = append(b.Skipped, BulkItem{ID: id, Title: title, Status: "skipped", Reason: reason, Detail: detail})
+}
+
+// Fail records an item that could not be processed
+func (b *BulkResult) Fail(id, title, reason string, detail interface{}) {
+	b.Failed = append(b.Failed, BulkItem{ID: id, Title: title, Status: "failed", Reason: reason, Detail: detail})
+	b.RetryIDs = append(b.RetryIDs, id)
+}
+
+// FailAll records items that failed before processing, such as dashboards
+// that could not be fetched, keyed by ID
+func (b *BulkResult) FailAll(reasons map[string]string) {
+	for _, id := range sortedStringKeys(reasons) {
+		b.Fail(id, "", reasons[id], nil)
+	}
+}
+
+// bulkResult returns the report as JSON text and as structuredContent. The
+// call is flagged as an error only when nothing succeeded and something failed.
+func bulkResult(b *BulkResult) (*mcp.CallToolResult, error) {
+	b.Total = len(b.Succeeded) + len(b.Failed) + len(b.Skipped)
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return apiErrorResult("Failed to marshal result", err), nil
+	}
+	return &mcp.CallToolResult{
+		Content:           []mcp.ContentBlock{{Type: "text", Text: string(data)}},
+		StructuredContent: b,
+		IsError:           len(b.Succeeded) == 0 && len(b.Failed) > 0,
+	}, nil
+}
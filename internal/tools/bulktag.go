@@ -0,0 +1,131 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaBulkTagTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_bulk_tag",
+		Description: "Add and/or remove tags on every dashboard in a folder, matching a search query, or listed by UID. Each dashboard is saved against its current version so concurrent edits are not overwritten",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"folder_uid":        {Type: "string", Description: "Tag dashboards in this folder"},
+				"query":             {Type: "string", Description: "Tag dashboards matching this search query"},
+				"tags":              {Type: "array", Description: "Only tag dashboards that already have these tags"},
+				"uids":              {Type: "array", Description: "Explicit dashboard UIDs (instead of folder/query filters)"},
+				"add":               {Type: "array", Description: "Tags to add"},
+				"remove":            {Type: "array", Description: "Tags to remove"},
+				"dry_run":           {Type: "boolean", Description: "Report what would change without saving"},
+				"retry_on_conflict": {Type: "integer", Description: "If a dashboard changes before its save, refetch it, reapply the tags, and retry up to this many times (default 0, max 5)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleBulkTag(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	add := getStringSlice(args, "add")
+	remove := getStringSlice(args, "remove")
+	if len(add) == 0 && len(remove) == 0 {
+		return errorResult("add or remove is required"), nil
+	}
+	if getString(args, "folder_uid") == "" && getString(args, "query") == "" &&
+		len(getStringSlice(args, "tags")) == 0 && len(getStringSlice(args, "uids")) == 0 {
+		return errorResult("one of folder_uid, query, tags, or uids is required"), nil
+	}
+	dryRun := getBool(args, "dry_run")
+
+	dashboards, failed, err := r.collectDashboards(args)
+	if err != nil {
+		return apiErrorResult("Failed to collect dashboards", err), nil
+	}
+
+	retag := func(model map[string]interface{}) bool {
+		modified := false
+		for _, t := range remove {
+			modified = removeTag(model, t) || modified
+		}
+		for _, t := range add {
+			modified = addTag(model, t) || modified
+		}
+		return modified
+	}
+	retries := conflictRetries(args)
+
+	result := newBulkResult(dryRun)
+	result.FailAll(failed)
+	for _, d := range dashboards {
+		before := dashboardTags(d.Model)
+		modified := retag(d.Model)
+		detail := map[string]interface{}{
+			"before": before,
+			"after":  dashboardTags(d.Model),
+		}
+
+		switch {
+		case !modified:
+			result.Skip(d.UID, d.Title, "tags already up to date", nil)
+			continue
+		case dryRun:
+			result.Succeed(d.UID, d.Title, "would_change", detail)
+			continue
+		}
+
+		model := d.Model
+		var saved *grafana.SaveDashboardResponse
+		_, err := retryOnConflict(r.ctx, retries, func() error {
+			var err error
+			saved, err = r.client.SaveDashboardJSON(r.ctx, model, d.FolderUID, "Bulk tag update via MCP", false)
+			if retries > 0 && grafana.IsVersionConflict(err) {
+				if latest, getErr := r.client.GetDashboardJSON(r.ctx, d.UID); getErr == nil {
+					model = latest.Dashboard
+					retag(model)
+				}
+			}
+			return err
+		})
+		if err != nil {
+			result.Fail(d.UID, d.Title, err.Error(), detail)
+			continue
+		}
+		detail["after"] = dashboardTags(model)
+		detail["version"] = saved.Version
+		result.Succeed(d.UID, d.Title, "changed", detail)
+	}
+
+	return bulkResult(result)
+}
+
+func dashboardTags(dash map[string]interface{}) []string {
+	raw, _ := dash["tags"].([]interface{})
+	tags := make([]string, 0, len(raw))
+	for _, t := range raw {
+		if s, ok := t.(string); ok {
+			tags = append(tags, s)
+		}
+	}
+	return tags
+}
+
+func removeTag(dash map[string]interface{}, tag string) bool {
+	raw, _ := dash["tags"].([]interface{})
+	kept := make([]interface{}, 0, len(raw))
+	for _, t := range raw {
+		if s, ok := t.(string); ok && s == tag {
+			continue
+		}
+		kept = append(kept, t)
+	}
+	if len(kept) == len(raw) {
+		return false
+	}
+	dash["tags"] = kept
+	return true
+}
@@ -0,0 +1,274 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// windowPlaceholder marks where the range goes in a burn-rate expression
+const windowPlaceholder = "$window"
+
+// burnRateWindows are the windows evaluated by grafana_burn_rate
+var burnRateWindows = []string{"5m", "30m", "1h", "2h", "6h", "1d", "3d"}
+
+// burnRateAlert is one multiwindow, multi-burn-rate alert from the Google
+// SRE workbook: it fires when both windows burn faster than the threshold
+type burnRateAlert struct {
+	Severity  string  `json:"severity"`
+	Long      string  `json:"long_window"`
+	Short     string  `json:"short_window"`
+	Threshold float64 `json:"threshold"`
+	// Budget is the share of a 30-day error budget consumed over the long window at the threshold
+	Budget string `json:"budget_consumed"`
+}
+
+var burnRateAlerts = []burnRateAlert{
+	{Severity: "page", Long: "1h", Short: "5m", Threshold: 14.4, Budget: "2%"},
+	{Severity: "page", Long: "6h", Short: "30m", Threshold: 6, Budget: "5%"},
+	{Severity: "ticket", Long: "1d", Short: "2h", Threshold: 3, Budget: "10%"},
+	{Severity: "ticket", Long: "3d", Short: "6h", Threshold: 1, Budget: "10%"},
+}
+
+func (r *Registry) grafanaBurnRateTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_burn_rate",
+		Description: "Compute SLO error-budget burn rates over 5m/30m/1h/2h/6h/1d/3d from an error-ratio or success-ratio PromQL expression, with multiwindow page/ticket verdicts, and optionally create the matching Grafana alert rules",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":  {Type: "string", Description: "UID of the Prometheus datasource"},
+				"expression":      {Type: "string", Description: "PromQL ratio with $window as the range, e.g. sum(rate(http_requests_total{code=~\"5..\"}[$window])) / sum(rate(http_requests_total[$window]))"},
+				"ratio":           {Type: "string", Description: "Whether the expression is the error ratio or the success ratio (default error)", Enum: []string{"error", "success"}},
+				"objective":       {Type: "number", Description: "SLO target as a percentage (99.9) or fraction (0.999)"},
+				"slo_name":        {Type: "string", Description: "Name used in generated alert rule titles and labels (default slo)"},
+				"generate_alerts": {Type: "boolean", Description: "Include the multiwindow burn-rate alert rule definitions in the result"},
+				"create_alerts":   {Type: "boolean", Description: "Create the multiwindow burn-rate alert rules in Grafana"},
+				"folder_uid":      {Type: "string", Description: "Folder for created alert rules (required with create_alerts)"},
+				"rule_group":      {Type: "string", Description: "Rule group for created alert rules (required with create_alerts)"},
+			},
+			Required: []string{"datasource_uid", "expression", "objective"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// burnRateWindow is the measured burn rate over one window
+type burnRateWindow struct {
+	Window     string   `json:"window"`
+	ErrorRatio *float64 `json:"error_ratio"`
+	BurnRate   *float64 `json:"burn_rate"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// burnRateVerdict is the state of one multiwindow alert
+type burnRateVerdict struct {
+	burnRateAlert
+	Firing bool   `json:"firing"`
+	Note   string `json:"note,omitempty"`
+}
+
+func (r *Registry) handleBurnRate(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	expr := getString(args, "expression")
+	objective := getFloat(args, "objective")
+	if dsUID == "" || expr == "" || objective == 0 {
+		return errorResult("datasource_uid, expression, and objective are required"), nil
+	}
+	if !strings.Contains(expr, windowPlaceholder) {
+		return errorResult("expression must use $window as the range, e.g. rate(errors_total[$window])"), nil
+	}
+	if objective > 1 {
+		objective /= 100
+	}
+	if objective <= 0 || objective >= 1 {
+		return errorResult("objective must be between 0 and 100 percent, exclusive"), nil
+	}
+	ratio := getString(args, "ratio")
+	if ratio == "" {
+		ratio = "error"
+	}
+	if ratio != "error" && ratio != "success" {
+		return errorResult("ratio must be error or success"), nil
+	}
+	sloName := getString(args, "slo_name")
+	if sloName == "" {
+		sloName = "slo"
+	}
+	create := getBool(args, "create_alerts")
+	folderUID := getString(args, "folder_uid")
+	ruleGroup := getString(args, "rule_group")
+	if create {
+		if folderUID == "" || ruleGroup == "" {
+			return errorResult("folder_uid and rule_group are required with create_alerts"), nil
+		}
+		// Honor profiles that disable alert rule writes
+		if !r.isEnabled("grafana_create_alert_rule") {
+			return errorResult("create_alerts requires grafana_create_alert_rule to be enabled"), nil
+		}
+		if reason := r.unsupportedReason("grafana_create_alert_rule"); reason != "" {
+			return errorResult(reason), nil
+		}
+	}
+	budget := math.Round((1-objective)*1e9) / 1e9
+
+	queries := make([]grafana.QueryTarget, 0, len(burnRateWindows))
+	for i, w := range burnRateWindows {
+		queries = append(queries, grafana.QueryTarget{
+			RefID:      fmt.Sprintf("W%d", i),
+			Datasource: grafana.DatasourceRef{Type: "prometheus", UID: dsUID},
+			Query:      errorRatioExpr(expr, ratio, w),
+			Extra:      map[string]interface{}{"instant": true, "range": false},
+		})
+	}
+	now := time.Now()
+	resp, err := r.query(grafana.QueryRequest{
+		From:    fmt.Sprintf("%d", now.Add(-5*time.Minute).UnixMilli()),
+		To:      fmt.Sprintf("%d", now.UnixMilli()),
+		Queries: queries,
+	})
+	if err != nil {
+		return apiErrorResult("Query failed", err), nil
+	}
+
+	windows := make([]burnRateWindow, 0, len(burnRateWindows))
+	byWindow := map[string]*float64{}
+	for i, w := range burnRateWindows {
+		bw := burnRateWindow{Window: w}
+		res := resp.Results[fmt.Sprintf("W%d", i)]
+		if res.Error != "" {
+			bw.Error = res.Error
+		} else if v, ok := worstValue(res.Frames); ok {
+			rate := v / budget
+			bw.ErrorRatio, bw.BurnRate = &v, &rate
+			byWindow[w] = bw.BurnRate
+		} else {
+			bw.Error = "no data"
+		}
+		windows = append(windows, bw)
+	}
+
+	verdicts := make([]burnRateVerdict, 0, len(burnRateAlerts))
+	for _, a := range burnRateAlerts {
+		v := burnRateVerdict{burnRateAlert: a}
+		long, short := byWindow[a.Long], byWindow[a.Short]
+		if long == nil || short == nil {
+			v.Note = "missing data for one of the windows"
+		} else {
+			v.Firing = *long > a.Threshold && *short > a.Threshold
+		}
+		verdicts = append(verdicts, v)
+	}
+
+	result := map[string]interface{}{
+		"objective":    objective,
+		"error_budget": budget,
+		"windows":      windows,
+		"alerts":       verdicts,
+	}
+
+	if !create && !getBool(args, "generate_alerts") {
+		return jsonResult(result)
+	}
+	rules := burnRateRules(sloName, dsUID, expr, ratio, budget, folderUID, ruleGroup)
+	if !create {
+		result["alert_rules"] = rules
+		return jsonResult(result)
+	}
+	created := make([]map[string]string, 0, len(rules))
+	for _, rule := range rules {
+		out, err := r.client.CreateAlertRule(r.ctx, rule)
+		if err != nil {
+			result["alert_rules_created"] = created
+			result["error"] = fmt.Sprintf("Failed to create alert rule %q: %v", rule.Title, err)
+			return jsonResult(result)
+		}
+		created = append(created, map[string]string{"uid": out.UID, "title": out.Title})
+	}
+	result["alert_rules_created"] = created
+	return jsonResult(result)
+}
+
+// errorRatioExpr substitutes the window and converts a success ratio into
+// an error ratio
+func errorRatioExpr(expr, ratio, window string) string {
+	e := strings.ReplaceAll(expr, windowPlaceholder, window)
+	if ratio == "success" {
+		return "1 - (" + e + ")"
+	}
+	return e
+}
+
+// worstValue returns the highest latest value across the returned series,
+// so unaggregated expressions report their worst-burning series
+func worstValue(frames []grafana.DataFrame) (float64, bool) {
+	worst, found := 0.0, false
+	for _, s := range analysis.FramesToSeries(frames) {
+		v := s.Values[len(s.Values)-1]
+		if math.IsInf(v, 0) {
+			continue
+		}
+		if !found || v > worst {
+			worst, found = v, true
+		}
+	}
+	return worst, found
+}
+
+// burnRateRules builds one Grafana-managed alert rule per multiwindow alert.
+// The PromQL only returns a value while both windows exceed the threshold,
+// so no data means healthy.
+func burnRateRules(sloName, dsUID, expr, ratio string, budget float64, folderUID, ruleGroup string) []grafana.AlertRule {
+	rules := make([]grafana.AlertRule, 0, len(burnRateAlerts))
+	for _, a := range burnRateAlerts {
+		query := fmt.Sprintf("(%s) / %g > %g and (%s) / %g > %g",
+			errorRatioExpr(expr, ratio, a.Long), budget, a.Threshold,
+			errorRatioExpr(expr, ratio, a.Short), budget, a.Threshold)
+		rules = append(rules, grafana.AlertRule{
+			Title:        fmt.Sprintf("%s burn rate %s (%s/%s)", sloName, a.Severity, a.Long, a.Short),
+			FolderUID:    folderUID,
+			RuleGroup:    ruleGroup,
+			Condition:    "B",
+			NoDataState:  "OK",
+			ExecErrState: "Error",
+			Labels:       map[string]string{"slo": sloName, "severity": a.Severity},
+			Annotations: map[string]string{
+				"summary": fmt.Sprintf("%s is burning its error budget at over %gx (%s of a 30-day budget in %s)", sloName, a.Threshold, a.Budget, a.Long),
+			},
+			Data: []grafana.AlertQuery{
+				{
+					RefID:             "A",
+					RelativeTimeRange: grafana.RelativeTimeRange{From: 600, To: 0},
+					DatasourceUID:     dsUID,
+					Model: map[string]interface{}{
+						"refId":   "A",
+						"expr":    query,
+						"instant": true,
+						"range":   false,
+					},
+				},
+				{
+					RefID:         "B",
+					DatasourceUID: "__expr__",
+					Model: map[string]interface{}{
+						"refId":      "B",
+						"type":       "threshold",
+						"expression": "A",
+						"conditions": []interface{}{map[string]interface{}{
+							"evaluator": map[string]interface{}{"type": "gt", "params": []interface{}{0}},
+						}},
+					},
+				},
+			},
+		})
+	}
+	return rules
+}
@@ -0,0 +1,226 @@
+package tools
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// categoryCapabilities summarises one tool category
+type categoryCapabilities struct {
+	Name    string `json:"name"`
+	Enabled int    `json:"enabled"`
+	Total   int    `json:"total"`
+	// Writable is set when any enabled tool of the category changes Grafana
+	Writable bool `json:"writable"`
+	// Disabled are turned off in the server's configuration
+	Disabled []string `json:"disabled,omitempty"`
+	// Unavailable are unsupported by the connected Grafana, with the reason
+	Unavailable map[string]string `json:"unavailable,omitempty"`
+}
+
+func (r *Registry) grafanaCapabilitiesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_capabilities",
+		Description: "Describe in one call what this server can do: enabled tool categories and whether they can write, tools disabled by configuration or unsupported by the instance, scoping restrictions on datasources, URLs, and query cost, the connected instance and identity, and Grafana features such as the image renderer. Call it at the start of a session to plan within those limits",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleCapabilities(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	var (
+		wg       sync.WaitGroup
+		settings *grafana.FrontendSettings
+		user     *grafana.User
+		org      *grafana.Organization
+		errs     [3]error
+	)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		settings, errs[0] = r.client.GetFrontendSettings(r.ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		user, errs[1] = r.client.GetCurrentUser(r.ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		org, errs[2] = r.client.GetCurrentOrg(r.ctx)
+	}()
+	wg.Wait()
+
+	// The report is still useful without Grafana, so failures are listed
+	// rather than returned
+	problems := []string{}
+	for i, what := range []string{"frontend settings", "current user", "current organization"} {
+		if errs[i] != nil {
+			problems = append(problems, fmt.Sprintf("could not read the %s: %v", what, errs[i]))
+		}
+	}
+
+	categories := make([]categoryCapabilities, 0)
+	enabled, writable := 0, 0
+	for _, g := range r.toolGroups() {
+		c := categoryCapabilities{Name: g.Name, Total: len(g.Tools)}
+		for _, t := range g.Tools {
+			switch {
+			case !r.isEnabled(t.Name):
+				c.Disabled = append(c.Disabled, t.Name)
+			case r.unsupportedReason(t.Name) != "":
+				if c.Unavailable == nil {
+					c.Unavailable = map[string]string{}
+				}
+				c.Unavailable[t.Name] = r.unsupportedReason(t.Name)
+			default:
+				c.Enabled++
+				if t.Annotations == nil || !t.Annotations.ReadOnlyHint {
+					c.Writable = true
+					writable++
+				}
+			}
+		}
+		enabled += c.Enabled
+		categories = append(categories, c)
+	}
+
+	instance := map[string]interface{}{"name": r.instanceName, "url": r.client.BaseURL()}
+	if names := r.instanceNames(); len(names) > 1 {
+		instance["connected_instances"] = names
+	}
+	if r.version != nil {
+		instance["version"] = r.version.String()
+	}
+	if settings != nil {
+		if settings.BuildInfo.Version != "" {
+			instance["version"] = settings.BuildInfo.Version
+		}
+		instance["edition"] = settings.BuildInfo.Edition
+	}
+	if user != nil {
+		instance["user"] = user.Login
+		instance["server_admin"] = user.IsGrafanaAdmin
+	}
+	if org != nil {
+		instance["org_id"] = org.ID
+		instance["org"] = org.Name
+	}
+
+	return jsonResult(map[string]interface{}{
+		"instance":       instance,
+		"read_only":      writable == 0,
+		"enabled_tools":  enabled,
+		"writable_tools": writable,
+		"categories":     categories,
+		"restrictions":   r.restrictions(),
+		"features":       r.capabilityFeatures(settings),
+		"errors":         problems,
+	})
+}
+
+// restrictions describes the configured limits on what tools may reach
+func (r *Registry) restrictions() map[string]interface{} {
+	out := map[string]interface{}{}
+	if a := r.access; a != nil {
+		patterns := make([]string, 0, len(a.DenyExpressions))
+		for _, rule := range a.DenyExpressions {
+			patterns = append(patterns, rule.Pattern.String())
+		}
+		out["datasource_access"] = map[string]interface{}{
+			"allow_uids":       a.AllowUIDs,
+			"allow_types":      a.AllowTypes,
+			"deny_uids":        a.DenyUIDs,
+			"deny_types":       a.DenyTypes,
+			"deny_expressions": patterns,
+		}
+	}
+	if p := r.urlPolicy; p != nil {
+		out["url_policy"] = map[string]interface{}{
+			"allow_hosts":  p.AllowHosts,
+			"deny_hosts":   p.DenyHosts,
+			"allow_cidrs":  cidrStrings(p.AllowCIDRs),
+			"deny_cidrs":   cidrStrings(p.DenyCIDRs),
+			"deny_private": p.DenyPrivate,
+		}
+	}
+	if g := r.guardrails; g != nil {
+		action := "warn"
+		if g.Refuse {
+			action = "refuse"
+		}
+		limits := map[string]interface{}{"action": action}
+		if g.MaxTimeRange > 0 {
+			limits["max_time_range"] = g.MaxTimeRange.String()
+		}
+		if g.MaxRangeSelector > 0 {
+			limits["max_range_selector"] = g.MaxRangeSelector.String()
+		}
+		if g.MaxSeries > 0 {
+			limits["max_series"] = g.MaxSeries
+		}
+		if g.MaxLogBytes > 0 {
+			limits["max_log_bytes"] = g.MaxLogBytes
+		}
+		if g.MaxLogStreams > 0 {
+			limits["max_log_streams"] = g.MaxLogStreams
+		}
+		out["query_guardrails"] = limits
+	}
+	if r.limiter != nil {
+		l := r.limiter.limits
+		out["concurrency"] = map[string]interface{}{
+			"max_calls":               l.MaxCalls,
+			"serialize_writes_by_uid": l.SerializeWritesByUID,
+		}
+	}
+	return out
+}
+
+// capabilityFeatures reports the Grafana features and server integrations
+// tools depend on. Settings Grafana does not report are left out.
+func (r *Registry) capabilityFeatures(settings *grafana.FrontendSettings) map[string]interface{} {
+	out := map[string]interface{}{
+		"mimir_ruler_configured": r.mimir != nil,
+		"scheduler_enabled":      r.scheduler != nil,
+	}
+	if settings == nil {
+		return out
+	}
+	for name, v := range map[string]*bool{
+		"renderer_available":        settings.RendererAvailable,
+		"unified_alerting_enabled":  settings.UnifiedAlertingEnabled,
+		"public_dashboards_enabled": settings.PublicDashboardsEnabled,
+	} {
+		if v != nil {
+			out[name] = *v
+		}
+	}
+	var names []string
+	for name, on := range settings.FeatureToggles {
+		if on {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	out["feature_toggles"] = names
+	return out
+}
+
+func cidrStrings(nets []*net.IPNet) []string {
+	s := make([]string, 0, len(nets))
+	for _, n := range nets {
+		s = append(s, n.String())
+	}
+	return s
+}
@@ -0,0 +1,136 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// compareWindow is the query rerun over a window shifted back by Offset
+type compareWindow struct {
+	Offset  string                         `json:"offset"`
+	From    string                         `json:"from"`
+	To      string                         `json:"to"`
+	Series  []seriesComparison             `json:"series"`
+	Missing []string                       `json:"missing_now,omitempty"`
+	New     []string                       `json:"new_now,omitempty"`
+	Error   string                         `json:"error,omitempty"`
+	Results map[string]grafana.QueryResult `json:"results,omitempty"`
+}
+
+// seriesComparison sets a series' statistics in the current window against
+// the same series in an earlier one. Deltas are percentages of the earlier
+// value and are left out when it is zero.
+type seriesComparison struct {
+	Name      string         `json:"name"`
+	Current   analysis.Stats `json:"current"`
+	Previous  analysis.Stats `json:"previous"`
+	MeanDelta *float64       `json:"mean_delta_pct,omitempty"`
+	MaxDelta  *float64       `json:"max_delta_pct,omitempty"`
+	LastDelta *float64       `json:"last_delta_pct,omitempty"`
+}
+
+// parseCompareOffsets validates the offsets of a compare request
+func parseCompareOffsets(offsets []string) ([]time.Duration, error) {
+	out := make([]time.Duration, 0, len(offsets))
+	for _, s := range offsets {
+		d, err := parseGrafanaDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid compare offset %q: %v", s, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("compare offset %q must be positive", s)
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+// compareWindows reruns req, whose range must be absolute, once per offset
+// and compares each series with the current result. Windows whose query
+// fails carry the error instead of failing the whole call.
+func (r *Registry) compareWindows(req grafana.QueryRequest, current *grafana.QueryResponse, names []string, offsets []time.Duration, topK topKOptions, useCache bool, start, end time.Time) []compareWindow {
+	now := seriesByName(current)
+	out := make([]compareWindow, len(offsets))
+	for i, offset := range offsets {
+		w := compareWindow{
+			Offset: names[i],
+			From:   r.formatMillis(start.Add(-offset).UnixMilli()),
+			To:     r.formatMillis(end.Add(-offset).UnixMilli()),
+		}
+		shifted := req
+		shifted.From = fmt.Sprintf("%d", start.Add(-offset).UnixMilli())
+		shifted.To = fmt.Sprintf("%d", end.Add(-offset).UnixMilli())
+		resp, err := r.runQuery(shifted, useCache)
+		if err == nil && resp.Results["A"].Error != "" {
+			err = fmt.Errorf("%s", resp.Results["A"].Error)
+		}
+		if err != nil {
+			w.Error = fmt.Sprintf("Query failed: %v", err)
+			out[i] = w
+			continue
+		}
+		if topK.K > 0 {
+			topK.filter(resp)
+		}
+		w.Results = resp.Results
+
+		before := seriesByName(resp)
+		w.Series = []seriesComparison{}
+		for _, name := range sortedSeriesNames(now) {
+			prev, ok := before[name]
+			if !ok {
+				w.New = append(w.New, name)
+				continue
+			}
+			cur := now[name]
+			w.Series = append(w.Series, seriesComparison{
+				Name:      name,
+				Current:   cur,
+				Previous:  prev,
+				MeanDelta: pctDelta(cur.Mean, prev.Mean),
+				MaxDelta:  pctDelta(cur.Max, prev.Max),
+				LastDelta: pctDelta(cur.Last, prev.Last),
+			})
+		}
+		for _, name := range sortedSeriesNames(before) {
+			if _, ok := now[name]; !ok {
+				w.Missing = append(w.Missing, name)
+			}
+		}
+		out[i] = w
+	}
+	return out
+}
+
+// seriesByName summarizes the series of query A by name
+func seriesByName(resp *grafana.QueryResponse) map[string]analysis.Stats {
+	out := map[string]analysis.Stats{}
+	for _, s := range analysis.FramesToSeries(resp.Results["A"].Frames) {
+		out[s.Name] = analysis.Summarize(s.Values)
+	}
+	return out
+}
+
+func sortedSeriesNames(m map[string]analysis.Stats) []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pctDelta returns the change from prev to cur as a percentage of prev,
+// rounded to one decimal, or nil when prev is zero
+func pctDelta(cur, prev float64) *float64 {
+	if prev == 0 {
+		return nil
+	}
+	d := math.Round((cur-prev)/math.Abs(prev)*1000) / 10
+	return &d
+}
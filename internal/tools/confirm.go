@@ -0,0 +1,180 @@
+package tools
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+)
+
+// confirmTokenArg is the argument destructive tools take a confirmation
+// token in when confirmation is required
+const confirmTokenArg = "confirm_token"
+
+// defaultConfirmTTL is how long a confirmation token stays valid unless
+// WithConfirmation sets otherwise
+const defaultConfirmTTL = 5 * time.Minute
+
+// WithConfirmation makes destructive tools ask before they run: a call
+// without a confirmation token changes nothing and returns one, and the
+// same call repeated with that token within ttl runs. A ttl of 0 uses the
+// default. Calls without an MCP session, such as scheduled jobs, run
+// without asking.
+func WithConfirmation(ttl time.Duration) Option {
+	return func(r *Registry) {
+		if ttl <= 0 {
+			ttl = defaultConfirmTTL
+		}
+		r.confirm = &confirmations{ttl: ttl, pending: make(map[string]pendingConfirmation)}
+	}
+}
+
+// confirmations holds the tokens issued to destructive calls until they
+// are used or expire. Tokens are also kept in the state store, so one
+// issued before a restart can still be used after it.
+type confirmations struct {
+	ttl   time.Duration
+	state *state.Store
+	mu    sync.Mutex
+	// pending is keyed by token
+	pending map[string]pendingConfirmation
+}
+
+// pendingConfirmation is the call a token confirms
+type pendingConfirmation struct {
+	Session  string `json:"session"`
+	Instance string `json:"instance"`
+	Tool     string `json:"tool"`
+	// Args is a digest of the call's arguments, so the token cannot
+	// confirm a call on another object
+	Args    string    `json:"args"`
+	Expires time.Time `json:"expires"`
+}
+
+// confirmationRequired is the structured content of a call that waits for
+// confirmation
+type confirmationRequired struct {
+	Token   string    `json:"confirm_token"`
+	Tool    string    `json:"tool"`
+	Expires time.Time `json:"expires"`
+}
+
+type confirmedKey struct{}
+
+// check reports whether the call may run. A call without a token, or with
+// one that does not match it, gets a result asking for confirmation; a
+// matching token is used up. The calls a confirmed call makes, such as the
+// steps of a batch, need no token of their own. The returned ctx and args
+// are those to run the call with, args without the token.
+func (c *confirmations) check(ctx context.Context, instance, tool string, args map[string]interface{}) (context.Context, map[string]interface{}, *mcp.CallToolResult) {
+	token := getString(args, confirmTokenArg)
+	if _, ok := args[confirmTokenArg]; ok {
+		rest := make(map[string]interface{}, len(args)-1)
+		for k, v := range args {
+			if k != confirmTokenArg {
+				rest[k] = v
+			}
+		}
+		args = rest
+	}
+	session := sessionFrom(ctx)
+	if session == "" || ctx.Value(confirmedKey{}) != nil {
+		return ctx, args, nil
+	}
+	call := pendingConfirmation{Session: session, Instance: instance, Tool: tool, Args: argsDigest(args)}
+	if token != "" {
+		if c.use(token, call) {
+			return context.WithValue(ctx, confirmedKey{}, true), args, nil
+		}
+		return ctx, args, c.ask(call, fmt.Sprintf("confirm_token is unknown, expired, or was issued for another call. %s changes or deletes data; to go ahead, call it again with the same arguments and", tool))
+	}
+	return ctx, args, c.ask(call, fmt.Sprintf("%s changes or deletes data and needs confirmation. To go ahead, call it again with the same arguments and", tool))
+}
+
+// ask issues a token for call and returns the result asking for it
+func (c *confirmations) ask(call pendingConfirmation, msg string) *mcp.CallToolResult {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	call.Expires = time.Now().Add(c.ttl).UTC()
+
+	c.mu.Lock()
+	now := time.Now()
+	for t, p := range c.pending {
+		if now.After(p.Expires) {
+			delete(c.pending, t)
+		}
+	}
+	c.pending[token] = call
+	c.mu.Unlock()
+	if err := c.state.Put(state.BucketConfirm, token, call, c.ttl); err != nil {
+		log.Printf("Warning: could not save confirmation token: %v", err)
+	}
+
+	res := errorResult(fmt.Sprintf("%s confirm_token %q within %s", msg, token, c.ttl))
+	res.StructuredContent = map[string]interface{}{
+		"confirmation": confirmationRequired{Token: token, Tool: call.Tool, Expires: call.Expires},
+	}
+	return res
+}
+
+// use reports whether token was issued for call and unexpired, removing it
+// if so
+func (c *confirmations) use(token string, call pendingConfirmation) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p, ok := c.pending[token]
+	if !ok {
+		found, err := c.state.Get(state.BucketConfirm, token, &p)
+		if err != nil {
+			log.Printf("Warning: could not read confirmation token: %v", err)
+		}
+		ok = found
+	}
+	if !ok || time.Now().After(p.Expires) {
+		return false
+	}
+	call.Expires = p.Expires
+	if p != call {
+		return false
+	}
+	delete(c.pending, token)
+	if err := c.state.Delete(state.BucketConfirm, token); err != nil {
+		log.Printf("Warning: could not remove used confirmation token: %v", err)
+	}
+	return true
+}
+
+// argsDigest fingerprints call arguments. Map keys marshal in sorted order,
+// so equal arguments give equal digests.
+func argsDigest(args map[string]interface{}) string {
+	data, _ := json.Marshal(args)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// withConfirmArg adds the confirmation token argument to a destructive
+// tool's schema when confirmation is required
+func (r *Registry) withConfirmArg(t mcp.Tool) mcp.Tool {
+	if r.confirm == nil || t.Annotations == nil || !t.Annotations.DestructiveHint {
+		return t
+	}
+	props := make(map[string]mcp.Property, len(t.InputSchema.Properties)+1)
+	for k, v := range t.InputSchema.Properties {
+		props[k] = v
+	}
+	props[confirmTokenArg] = mcp.Property{
+		Type:        "string",
+		Description: "Token from this call's first attempt, to confirm it; the first attempt changes nothing and returns one",
+	}
+	t.InputSchema.Properties = props
+	return t
+}
@@ -0,0 +1,108 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+// confirmToken returns the token a call held for confirmation returned
+func confirmToken(t *testing.T, res *mcp.CallToolResult) string {
+	t.Helper()
+	if !res.IsError {
+		t.Fatalf("call ran without confirmation")
+	}
+	data, _ := json.Marshal(res.StructuredContent)
+	var sc struct {
+		Confirmation struct {
+			Token string `json:"confirm_token"`
+		} `json:"confirmation"`
+	}
+	if err := json.Unmarshal(data, &sc); err != nil || sc.Confirmation.Token == "" {
+		t.Fatalf("no confirmation token in %s", data)
+	}
+	return sc.Confirmation.Token
+}
+
+func TestDestructiveCallsNeedConfirmation(t *testing.T) {
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithConfirmation(0)))
+	keep := h.AddDashboard(map[string]interface{}{"title": "Keep"}, "")
+	drop := h.AddDashboard(map[string]interface{}{"title": "Drop"}, "")
+
+	first := h.Call("grafana_delete_dashboard", map[string]interface{}{"uid": drop})
+	first.Error("needs confirmation")
+	token := confirmToken(t, first.CallToolResult)
+	if _, _, ok := h.Dashboard(drop); !ok {
+		t.Fatal("dashboard deleted before confirmation")
+	}
+
+	// The token confirms only the call it was issued for, in its session
+	h.Call("grafana_delete_dashboard", map[string]interface{}{"uid": keep, "confirm_token": token}).Error("confirm_token is unknown")
+	h.NewSession().Call("grafana_delete_dashboard", map[string]interface{}{"uid": drop, "confirm_token": token}).Error("confirm_token is unknown")
+	if _, _, ok := h.Dashboard(keep); !ok {
+		t.Fatal("a token for one dashboard deleted another")
+	}
+
+	h.Call("grafana_delete_dashboard", map[string]interface{}{"uid": drop, "confirm_token": token}).OK()
+	if _, _, ok := h.Dashboard(drop); ok {
+		t.Fatal("confirmed delete left the dashboard")
+	}
+	h.Call("grafana_delete_dashboard", map[string]interface{}{"uid": keep, "confirm_token": token}).Error("confirm_token is unknown")
+
+	// Read-only tools and calls without a session, such as scheduled jobs,
+	// are not held
+	h.Call("grafana_get_dashboard", map[string]interface{}{"uid": keep}).OK()
+	if _, err := h.Registry.RunTool("grafana_delete_dashboard", map[string]interface{}{"uid": keep}); err != nil {
+		t.Fatalf("scheduled delete: %v", err)
+	}
+}
+
+func TestConfirmationTokenSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	st, err := state.Open(dir)
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	h := testkit.New(t)
+	uid := h.AddDashboard(map[string]interface{}{"title": "Drop"}, "")
+	ctx := tools.WithSession(context.Background(), "s1")
+	args := map[string]interface{}{"uid": uid}
+
+	before := tools.NewRegistry(h.Client(), nil, tools.WithStateStore(st), tools.WithConfirmation(0))
+	res, err := before.CallTool(ctx, "grafana_delete_dashboard", args)
+	if err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	token := confirmToken(t, res)
+
+	reopened, err := state.Open(dir)
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	after := tools.NewRegistry(h.Client(), nil, tools.WithStateStore(reopened), tools.WithConfirmation(0))
+	res, err = after.CallTool(ctx, "grafana_delete_dashboard", map[string]interface{}{"uid": uid, "confirm_token": token})
+	if err != nil || res.IsError {
+		t.Fatalf("confirmed delete after restart: %v %+v", err, res)
+	}
+	if _, _, ok := h.Dashboard(uid); ok {
+		t.Fatal("confirmed delete after restart left the dashboard")
+	}
+}
+
+func TestConfirmedBatchRunsItsSteps(t *testing.T) {
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithConfirmation(0)))
+	uid := h.AddDashboard(map[string]interface{}{"title": "Drop"}, "")
+	steps := []interface{}{map[string]interface{}{"tool": "grafana_delete_dashboard", "args": map[string]interface{}{"uid": uid}}}
+
+	first := h.Call("grafana_batch", map[string]interface{}{"steps": steps})
+	first.Error("needs confirmation")
+	h.Call("grafana_batch", map[string]interface{}{"steps": steps, "confirm_token": confirmToken(t, first.CallToolResult)}).OK()
+	if _, _, ok := h.Dashboard(uid); ok {
+		t.Fatal("the confirmed batch's delete step was held for confirmation of its own")
+	}
+}
@@ -0,0 +1,521 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaTestContactPointTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_test_contact_point",
+		Description: "Send a test notification through a contact point (Slack, PagerDuty, email, webhook, ...) and report the delivery status of each integration. Tests a saved contact point by name, or an unsaved integration given its type and settings",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"name":            {Type: "string", Description: "Contact point name"},
+				"integration_uid": {Type: "string", Description: "Only test this integration of the contact point"},
+				"type":            {Type: "string", Description: "Integration type for an unsaved contact point (e.g. slack, pagerduty, email, webhook)"},
+				"settings":        {Type: "object", Description: "Integration settings for an unsaved contact point, including secrets (e.g. {\"url\": \"https://hooks.slack.com/...\"})"},
+				"labels":          {Type: "object", Description: "Labels on the test alert (default: Grafana's TestAlert labels)"},
+				"annotations":     {Type: "object", Description: "Annotations on the test alert (e.g. summary, description)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleTestContactPoint(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	name := getString(args, "name")
+	typ := getString(args, "type")
+	if name == "" && typ == "" {
+		return errorResult("name or type is required"), nil
+	}
+
+	var receiver grafana.Receiver
+	if typ != "" {
+		settings, _ := args["settings"].(map[string]interface{})
+		if settings == nil {
+			return errorResult("settings is required when type is set"), nil
+		}
+		if err := r.checkSettingURLs(settings); err != nil {
+			return errorResultFor(err), nil
+		}
+		if name == "" {
+			name = "mcp-test"
+		}
+		receiver = grafana.Receiver{
+			Name:         name,
+			Integrations: []grafana.Integration{{Name: name, Type: typ, Settings: settings}},
+		}
+	} else {
+		receivers, err := r.client.GetReceivers(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to get contact points", err), nil
+		}
+		found := false
+		var names []string
+		for _, rc := range receivers {
+			names = append(names, rc.Name)
+			if rc.Name == name {
+				receiver, found = rc, true
+			}
+		}
+		if !found {
+			return errorResult(fmt.Sprintf("contact point %q not found (available: %s)", name, strings.Join(names, ", "))), nil
+		}
+		if uid := getString(args, "integration_uid"); uid != "" {
+			var selected []grafana.Integration
+			for _, in := range receiver.Integrations {
+				if in.UID == uid {
+					selected = append(selected, in)
+				}
+			}
+			if len(selected) == 0 {
+				return errorResult(fmt.Sprintf("integration %q not found in contact point %q", uid, name)), nil
+			}
+			receiver.Integrations = selected
+		}
+		if len(receiver.Integrations) == 0 {
+			return errorResult(fmt.Sprintf("contact point %q has no integrations", name)), nil
+		}
+	}
+
+	var alert *grafana.TestAlert
+	labels := getStringMap(args, "labels")
+	annotations := getStringMap(args, "annotations")
+	if len(labels) > 0 || len(annotations) > 0 {
+		alert = &grafana.TestAlert{Labels: labels, Annotations: annotations}
+	}
+
+	result, err := r.client.TestReceivers(r.ctx, []grafana.Receiver{receiver}, alert)
+	if err != nil {
+		return apiErrorResult("Failed to test contact point", err), nil
+	}
+
+	var integrations []map[string]interface{}
+	ok := true
+	for _, rc := range result.Receivers {
+		for _, res := range rc.Results {
+			entry := map[string]interface{}{
+				"uid":    res.UID,
+				"name":   res.Name,
+				"status": res.Status,
+			}
+			if res.Error != "" {
+				entry["error"] = res.Error
+			}
+			if res.Status != "ok" {
+				ok = false
+			}
+			integrations = append(integrations, entry)
+		}
+	}
+
+	return jsonResult(map[string]interface{}{
+		"contact_point": name,
+		"success":       ok,
+		"notified_at":   result.NotifiedAt,
+		"alert":         result.Alert,
+		"integrations":  integrations,
+	})
+}
+
+func (r *Registry) grafanaContactPointInventoryTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_contact_point_inventory",
+		Description: "List contact points with their integrations, the notification policies and alert rules (simplified routing) that send to them, and whether any alert can reach them. Flags orphaned contact points that nothing routes to, policies shadowed by an earlier catch-all sibling, and policies or rules that name a contact point that no longer exists",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// contactPointEntry is one contact point of grafana_contact_point_inventory
+type contactPointEntry struct {
+	Name         string   `json:"name"`
+	Integrations []string `json:"integrations"`
+	// Default is set for the root policy's contact point, which receives
+	// every alert no other policy matches
+	Default  bool       `json:"default,omitempty"`
+	Policies [][]string `json:"policies,omitempty"`
+	// ShadowedPolicies name the contact point but are never reached
+	ShadowedPolicies [][]string `json:"shadowed_policies,omitempty"`
+	Rules            []string   `json:"rules,omitempty"`
+	Reachable        bool       `json:"reachable"`
+}
+
+// policyRef is a notification policy and the contact point it names
+type policyRef struct {
+	Path     []string `json:"policy_path"`
+	Receiver string   `json:"contact_point"`
+	// Shadowed is set when an earlier sibling without continue matches
+	// every alert, so the policy never sees one
+	Shadowed bool `json:"shadowed,omitempty"`
+}
+
+func (r *Registry) handleContactPointInventory(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	receivers, err := r.client.GetReceivers(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get contact points", err), nil
+	}
+	tree, err := r.client.GetNotificationPolicyTree(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get notification policies", err), nil
+	}
+	rules, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get alert rules", err), nil
+	}
+
+	entries := make([]*contactPointEntry, 0, len(receivers))
+	byName := make(map[string]*contactPointEntry, len(receivers))
+	for _, rc := range receivers {
+		e := &contactPointEntry{Name: rc.Name, Integrations: []string{}}
+		for _, in := range rc.Integrations {
+			e.Integrations = append(e.Integrations, in.Type)
+		}
+		entries = append(entries, e)
+		byName[rc.Name] = e
+	}
+
+	var refs []policyRef
+	if err := collectPolicyRefs(tree, nil, false, &refs); err != nil {
+		return apiErrorResult("Failed to read notification policies", err), nil
+	}
+	missingPolicies := []policyRef{}
+	shadowed := []policyRef{}
+	for _, ref := range refs {
+		if ref.Shadowed {
+			shadowed = append(shadowed, ref)
+		}
+		e, ok := byName[ref.Receiver]
+		if !ok {
+			missingPolicies = append(missingPolicies, ref)
+			continue
+		}
+		if ref.Shadowed {
+			e.ShadowedPolicies = append(e.ShadowedPolicies, ref.Path)
+			continue
+		}
+		e.Policies = append(e.Policies, ref.Path)
+		e.Reachable = true
+	}
+	if e, ok := byName[tree.Receiver]; ok {
+		e.Default = true
+	}
+
+	missingRules := []map[string]interface{}{}
+	for _, rule := range rules {
+		receiver, _ := rule.NotificationSettings["receiver"].(string)
+		if receiver == "" {
+			continue
+		}
+		e, ok := byName[receiver]
+		if !ok {
+			missingRules = append(missingRules, map[string]interface{}{
+				"rule_uid":      rule.UID,
+				"title":         rule.Title,
+				"contact_point": receiver,
+			})
+			continue
+		}
+		e.Rules = append(e.Rules, rule.UID)
+		e.Reachable = true
+	}
+
+	orphaned := []string{}
+	for _, e := range entries {
+		if !e.Reachable {
+			orphaned = append(orphaned, e.Name)
+		}
+	}
+	return jsonResult(map[string]interface{}{
+		"contact_points":    entries,
+		"orphaned":          orphaned,
+		"shadowed_policies": shadowed,
+		"missing_contact_points": map[string]interface{}{
+			"policies": missingPolicies,
+			"rules":    missingRules,
+		},
+		"summary": map[string]int{
+			"contact_points":  len(entries),
+			"orphaned":        len(orphaned),
+			"shadowed":        len(shadowed),
+			"missing_targets": len(missingPolicies) + len(missingRules),
+		},
+	})
+}
+
+// collectPolicyRefs lists the policies under route that name a contact
+// point, in tree order. Policies after a sibling that matches every alert
+// without continue are shadowed, as are their children.
+func collectPolicyRefs(route *grafana.Route, path []string, shadowed bool, out *[]policyRef) error {
+	matchers, err := routeMatchers(route)
+	if err != nil {
+		return err
+	}
+	step := "root"
+	if len(path) > 0 {
+		var names []string
+		for _, m := range matchers {
+			names = append(names, m.String())
+		}
+		step = strings.Join(names, ", ")
+		if step == "" {
+			step = "(match all)"
+		}
+	}
+	path = append(append([]string{}, path...), step)
+	if route.Receiver != "" {
+		*out = append(*out, policyRef{Path: path, Receiver: route.Receiver, Shadowed: shadowed})
+	}
+
+	caughtAll := false
+	for _, child := range route.Routes {
+		if err := collectPolicyRefs(child, path, shadowed || caughtAll, out); err != nil {
+			return err
+		}
+		if !child.Continue && !caughtAll {
+			childMatchers, err := routeMatchers(child)
+			if err != nil {
+				return err
+			}
+			caughtAll = len(childMatchers) == 0
+		}
+	}
+	return nil
+}
+
+func (r *Registry) grafanaListContactPointsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_contact_points",
+		Description: "List contact points and their integrations (Slack, PagerDuty, email, webhook, ...). Each entry is one integration with its own UID; integrations sharing a name form one contact point. Secrets are redacted",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"name": {Type: "string", Description: "Only list the integrations of this contact point"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaGetContactPointTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_get_contact_point",
+		Description: "Get a contact point integration by UID, or every integration of a contact point by name. Secrets are redacted",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uid":  {Type: "string", Description: "Integration UID"},
+				"name": {Type: "string", Description: "Contact point name, instead of uid"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaCreateContactPointTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_create_contact_point",
+		Description: "Create a contact point integration, such as a Slack channel, PagerDuty service, email list, or webhook. Creating another integration with an existing name adds it to that contact point. Notification policies route alerts to contact points by name",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"name":                    {Type: "string", Description: "Contact point name"},
+				"type":                    {Type: "string", Description: "Integration type (e.g. slack, pagerduty, email, webhook, teams, opsgenie)"},
+				"settings":                {Type: "object", Description: "Integration settings, including secrets (e.g. {\"url\": \"https://hooks.slack.com/...\"} for slack, {\"integrationKey\": \"...\"} for pagerduty, {\"addresses\": \"a@example.com;b@example.com\"} for email)"},
+				"disable_resolve_message": {Type: "boolean", Description: "Do not notify when alerts resolve"},
+				"uid":                     {Type: "string", Description: "UID for the integration (default: generated)"},
+			},
+			Required: []string{"name", "type", "settings"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) grafanaUpdateContactPointTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_update_contact_point",
+		Description: "Update a contact point integration by UID. Given settings are merged into the current ones (null removes a setting); redacted secrets are kept. Renaming moves the integration to another contact point, so policies routing to the old name stop reaching it",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uid":                     {Type: "string", Description: "Integration UID to update"},
+				"name":                    {Type: "string", Description: "New contact point name"},
+				"type":                    {Type: "string", Description: "New integration type"},
+				"settings":                {Type: "object", Description: "Settings to change; null removes a setting"},
+				"disable_resolve_message": {Type: "boolean", Description: "Do not notify when alerts resolve"},
+			},
+			Required: []string{"uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) grafanaDeleteContactPointTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_delete_contact_point",
+		Description: "Delete a contact point integration by UID. Grafana refuses to delete the last integration of a contact point that notification policies or alert rules still use",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uid": {Type: "string", Description: "Integration UID to delete"},
+			},
+			Required: []string{"uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleListContactPoints(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	points, err := r.client.GetContactPoints(r.ctx, getString(args, "name"))
+	if err != nil {
+		return apiErrorResult("Failed to list contact points", err), nil
+	}
+	return jsonResult(points)
+}
+
+func (r *Registry) handleGetContactPoint(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "uid")
+	name := getString(args, "name")
+	if uid == "" && name == "" {
+		return errorResult("uid or name is required"), nil
+	}
+	if uid == "" {
+		points, err := r.client.GetContactPoints(r.ctx, name)
+		if err != nil {
+			return apiErrorResult("Failed to get contact point", err), nil
+		}
+		if len(points) == 0 {
+			return errorResult(fmt.Sprintf("contact point %q not found", name)), nil
+		}
+		return jsonResult(points)
+	}
+	cp, err := r.contactPoint(uid)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	return jsonResult(cp)
+}
+
+func (r *Registry) handleCreateContactPoint(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	name := getString(args, "name")
+	typ := getString(args, "type")
+	settings, _ := args["settings"].(map[string]interface{})
+	if name == "" || typ == "" || settings == nil {
+		return errorResult("name, type, and settings are required"), nil
+	}
+	if err := r.checkSettingURLs(settings); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	result, err := r.client.CreateContactPoint(r.ctx, grafana.ContactPoint{
+		UID:                   getString(args, "uid"),
+		Name:                  name,
+		Type:                  typ,
+		Settings:              settings,
+		DisableResolveMessage: getBool(args, "disable_resolve_message"),
+	})
+	if err != nil {
+		return apiErrorResult("Failed to create contact point", err), nil
+	}
+	return jsonResult(result)
+}
+
+func (r *Registry) handleUpdateContactPoint(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "uid")
+	if uid == "" {
+		return errorResult("uid is required"), nil
+	}
+	cp, err := r.contactPoint(uid)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	if name := getString(args, "name"); name != "" {
+		cp.Name = name
+	}
+	if typ := getString(args, "type"); typ != "" {
+		cp.Type = typ
+	}
+	if _, ok := args["disable_resolve_message"]; ok {
+		cp.DisableResolveMessage = getBool(args, "disable_resolve_message")
+	}
+	if settings, ok := args["settings"].(map[string]interface{}); ok {
+		if err := r.checkSettingURLs(settings); err != nil {
+			return errorResultFor(err), nil
+		}
+		if cp.Settings == nil {
+			cp.Settings = map[string]interface{}{}
+		}
+		for k, v := range settings {
+			if v == nil {
+				delete(cp.Settings, k)
+				continue
+			}
+			cp.Settings[k] = v
+		}
+	}
+
+	if err := r.client.UpdateContactPoint(r.ctx, uid, *cp); err != nil {
+		return apiErrorResult("Failed to update contact point", err), nil
+	}
+	updated, err := r.contactPoint(uid)
+	if err != nil {
+		return jsonResult(map[string]string{"status": "updated", "uid": uid})
+	}
+	return jsonResult(updated)
+}
+
+func (r *Registry) handleDeleteContactPoint(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "uid")
+	if uid == "" {
+		return errorResult("uid is required"), nil
+	}
+	if err := r.client.DeleteContactPoint(r.ctx, uid); err != nil {
+		return apiErrorResult("Failed to delete contact point", err), nil
+	}
+	return jsonResult(map[string]string{"status": "deleted", "uid": uid})
+}
+
+// contactPoint finds a contact point integration by UID; the provisioning
+// API has no lookup by UID
+func (r *Registry) contactPoint(uid string) (*grafana.ContactPoint, error) {
+	points, err := r.client.GetContactPoints(r.ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get contact point: %w", err)
+	}
+	for i := range points {
+		if points[i].UID == uid {
+			return &points[i], nil
+		}
+	}
+	return nil, fmt.Errorf("contact point integration %q not found", uid)
+}
@@ -0,0 +1,81 @@
+package tools_test
+
+import (
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+func TestContactPointLifecycle(t *testing.T) {
+	h := testkit.New(t)
+
+	var created grafana.ContactPoint
+	h.Call("grafana_create_contact_point", map[string]interface{}{
+		"name":     "oncall",
+		"type":     "slack",
+		"settings": map[string]interface{}{"url": "https://hooks.slack.com/services/T0/B0/secret", "recipient": "#alerts"},
+	}).OK().JSON(&created)
+	if created.UID == "" || created.Settings["url"] != "[REDACTED]" {
+		t.Fatalf("created %+v, want a uid and a redacted url", created)
+	}
+	if reqs := h.RequestsTo("POST", "/api/v1/provisioning/contact-points"); reqs[0].Header.Get("X-Disable-Provenance") == "" {
+		t.Fatal("contact point created as provisioned")
+	}
+
+	var listed []grafana.ContactPoint
+	h.Call("grafana_get_contact_point", map[string]interface{}{"name": "oncall"}).OK().JSON(&listed)
+	if len(listed) != 1 || listed[0].UID != created.UID {
+		t.Fatalf("contact point oncall = %+v, want %s", listed, created.UID)
+	}
+
+	var updated grafana.ContactPoint
+	h.Call("grafana_update_contact_point", map[string]interface{}{
+		"uid":      created.UID,
+		"settings": map[string]interface{}{"recipient": "#oncall", "title": "{{ .CommonLabels.alertname }}"},
+	}).OK().JSON(&updated)
+	if updated.Settings["recipient"] != "#oncall" || updated.Settings["title"] == nil {
+		t.Fatalf("updated settings %v, want the new recipient and title", updated.Settings)
+	}
+	stored, _ := h.ContactPoint(created.UID)
+	if stored.Settings["url"] != "https://hooks.slack.com/services/T0/B0/secret" {
+		t.Fatalf("stored url %v after an update, want the secret kept", stored.Settings["url"])
+	}
+
+	h.Call("grafana_delete_contact_point", map[string]interface{}{"uid": created.UID}).OK()
+	if _, ok := h.ContactPoint(created.UID); ok {
+		t.Fatal("contact point still stored after delete")
+	}
+	h.Call("grafana_get_contact_point", map[string]interface{}{"uid": created.UID}).Error("not found")
+}
+
+func TestUpdateContactPointRemovesNullSettings(t *testing.T) {
+	h := testkit.New(t)
+	uid := h.AddContactPoint(grafana.ContactPoint{Name: "ops", Type: "email", Settings: map[string]interface{}{
+		"addresses": "ops@example.com",
+		"subject":   "alert",
+	}})
+
+	h.Call("grafana_update_contact_point", map[string]interface{}{
+		"uid":      uid,
+		"settings": map[string]interface{}{"subject": nil},
+	}).OK()
+	stored, _ := h.ContactPoint(uid)
+	if _, ok := stored.Settings["subject"]; ok || stored.Settings["addresses"] != "ops@example.com" {
+		t.Fatalf("settings %v, want subject removed and addresses kept", stored.Settings)
+	}
+}
+
+func TestDeleteRoutedContactPointRefused(t *testing.T) {
+	h := testkit.New(t)
+	uid := h.AddContactPoint(grafana.ContactPoint{Name: "pager", Type: "webhook", Settings: map[string]interface{}{"url": "https://example.com/hook"}})
+	h.SetNotificationPolicies(grafana.Route{
+		Receiver: "grafana-default-email",
+		Routes:   []*grafana.Route{{Receiver: "pager", ObjectMatchers: [][]string{{"severity", "=", "critical"}}}},
+	})
+
+	h.Call("grafana_delete_contact_point", map[string]interface{}{"uid": uid}).Error("Failed to delete contact point")
+	if _, ok := h.ContactPoint(uid); !ok {
+		t.Fatal("routed contact point deleted")
+	}
+}
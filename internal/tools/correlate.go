@@ -0,0 +1,203 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaCorrelateChangesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_correlate_changes",
+		Description: "Detect change points in a metric query (z-score or CUSUM) and correlate each with annotations (deploys, incidents) and alert state transitions around it",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":  {Type: "string", Description: "Datasource UID to query"},
+				"datasource_type": {Type: "string", Description: "Datasource type (e.g., prometheus)"},
+				"query":           {Type: "string", Description: "Metric query expression"},
+				"from":            {Type: "string", Description: "Start time (default now-6h)"},
+				"to":              {Type: "string", Description: "End time (default now)"},
+				"method":          {Type: "string", Description: "Change point method (default cusum)", Enum: []string{"cusum", "zscore"}},
+				"threshold":       {Type: "number", Description: "Detection threshold in standard deviations (default 5 for cusum, 3 for zscore)"},
+				"window_minutes":  {Type: "integer", Description: "Minutes around each change point to search for events (default 15)"},
+				"dashboard_uid":   {Type: "string", Description: "Only consider annotations on this dashboard"},
+				"tags":            {Type: "array", Description: "Only consider annotations with these tags"},
+				"max_series":      {Type: "integer", Description: "Maximum number of series to analyze (default 5)"},
+			},
+			Required: []string{"datasource_uid", "datasource_type", "query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// correlatedEvent is an annotation or alert transition near a change point
+type correlatedEvent struct {
+	Kind          string   `json:"kind"`
+	ID            int64    `json:"id"`
+	Time          int64    `json:"time"`
+	TimeLocal     string   `json:"time_local,omitempty"`
+	OffsetSeconds int64    `json:"offset_seconds"`
+	Text          string   `json:"text,omitempty"`
+	Tags          []string `json:"tags,omitempty"`
+	AlertName     string   `json:"alert_name,omitempty"`
+	NewState      string   `json:"new_state,omitempty"`
+	DashboardUID  string   `json:"dashboard_uid,omitempty"`
+}
+
+type correlatedChange struct {
+	analysis.ChangePoint
+	Events []correlatedEvent `json:"events"`
+}
+
+type seriesChanges struct {
+	Series  string             `json:"series"`
+	Changes []correlatedChange `json:"changes"`
+}
+
+func (r *Registry) handleCorrelateChanges(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	dsType := getString(args, "datasource_type")
+	query := getString(args, "query")
+	if dsUID == "" || dsType == "" || query == "" {
+		return errorResult("datasource_uid, datasource_type, and query are required"), nil
+	}
+
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-6h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	method := getString(args, "method")
+	if method == "" {
+		method = "cusum"
+	}
+	threshold := getFloat(args, "threshold")
+	if threshold <= 0 {
+		threshold = 5
+		if method == "zscore" {
+			threshold = 3
+		}
+	}
+	window := time.Duration(getInt(args, "window_minutes")) * time.Minute
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	maxSeries := getInt(args, "max_series")
+	if maxSeries <= 0 {
+		maxSeries = 5
+	}
+
+	resp, err := r.query(grafana.QueryRequest{
+		From: fmt.Sprintf("%d", start.UnixMilli()),
+		To:   fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: []grafana.QueryTarget{{
+			RefID:      "A",
+			Datasource: grafana.DatasourceRef{Type: dsType, UID: dsUID},
+			Query:      query,
+		}},
+	})
+	if err != nil {
+		return apiErrorResult("Query failed", err), nil
+	}
+	res := resp.Results["A"]
+	if res.Error != "" {
+		return errorResult(fmt.Sprintf("Query failed: %s", res.Error)), nil
+	}
+
+	series := analysis.FramesToSeries(res.Frames)
+	if len(series) == 0 {
+		return errorResult("query returned no numeric series"), nil
+	}
+	if len(series) > maxSeries {
+		series = series[:maxSeries]
+	}
+
+	// Fetch events once for the padded range and match them locally
+	annotations, err := r.client.QueryAnnotations(r.ctx, grafana.AnnotationsQuery{
+		From:         start.Add(-window).UnixMilli(),
+		To:           end.Add(window).UnixMilli(),
+		DashboardUID: getString(args, "dashboard_uid"),
+		Tags:         getStringSlice(args, "tags"),
+		Limit:        1000,
+	})
+	if err != nil {
+		return apiErrorResult("Failed to list annotations", err), nil
+	}
+
+	results := make([]seriesChanges, 0, len(series))
+	totalChanges := 0
+	for _, s := range series {
+		var points []analysis.ChangePoint
+		if method == "zscore" {
+			points = analysis.ZScoreChangePoints(s.Times, s.Values, 20, threshold)
+		} else {
+			points = analysis.CUSUMChangePoints(s.Times, s.Values, 0, threshold)
+		}
+
+		sc := seriesChanges{Series: s.Name, Changes: make([]correlatedChange, 0, len(points))}
+		for _, p := range points {
+			sc.Changes = append(sc.Changes, correlatedChange{
+				ChangePoint: p,
+				Events:      r.eventsNear(annotations, p.Time, window),
+			})
+		}
+		totalChanges += len(sc.Changes)
+		results = append(results, sc)
+	}
+
+	return jsonResult(map[string]interface{}{
+		"method":         method,
+		"threshold":      threshold,
+		"window_minutes": int(window.Minutes()),
+		"from":           start.UTC().Format(time.RFC3339),
+		"to":             end.UTC().Format(time.RFC3339),
+		"change_points":  totalChanges,
+		"series":         results,
+	})
+}
+
+// eventsNear returns annotations within window of t, closest first, with
+// events preceding the change ranked ahead of events at equal distance.
+func (r *Registry) eventsNear(annotations []grafana.Annotation, t int64, window time.Duration) []correlatedEvent {
+	events := []correlatedEvent{}
+	limit := window.Milliseconds()
+	for _, a := range annotations {
+		offset := a.Time - t
+		if offset < -limit || offset > limit {
+			continue
+		}
+		kind := "annotation"
+		if a.AlertID > 0 || a.NewState != "" {
+			kind = "alert_transition"
+		}
+		events = append(events, correlatedEvent{
+			Kind:          kind,
+			ID:            a.ID,
+			Time:          a.Time,
+			TimeLocal:     r.formatMillis(a.Time),
+			OffsetSeconds: offset / 1000,
+			Text:          a.Text,
+			Tags:          a.Tags,
+			AlertName:     a.AlertName,
+			NewState:      a.NewState,
+			DashboardUID:  a.DashboardUID,
+		})
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		di, dj := math.Abs(float64(events[i].OffsetSeconds)), math.Abs(float64(events[j].OffsetSeconds))
+		if di != dj {
+			return di < dj
+		}
+		return events[i].OffsetSeconds < events[j].OffsetSeconds
+	})
+	return events
+}
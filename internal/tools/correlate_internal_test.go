@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+func TestEventsNear(t *testing.T) {
+	r := &Registry{timeFormat: TimeFormat{Location: time.UTC, Layout: time.RFC3339}}
+	const change = int64(1_700_000_000_000)
+	annotations := []grafana.Annotation{
+		{ID: 1, Time: change + 10*60000, Text: "late"},
+		{ID: 2, Time: change - 2*60000, Text: "deploy"},
+		{ID: 3, Time: change + 2*60000, AlertID: 9, AlertName: "High latency", NewState: "alerting"},
+		{ID: 4, Time: change - 16*60000, Text: "too early"},
+		{ID: 5, Time: change + 15*60000, Text: "edge of the window"},
+		{ID: 6, Time: change, NewState: "pending"},
+	}
+
+	tests := []struct {
+		name   string
+		window time.Duration
+		want   []int64
+	}{
+		{"closest first, earlier wins ties", 15 * time.Minute, []int64{6, 2, 3, 1, 5}},
+		{"narrow window", 2 * time.Minute, []int64{6, 2, 3}},
+		{"zero window keeps simultaneous events", 0, []int64{6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events := r.eventsNear(annotations, change, tt.window)
+			got := []int64{}
+			for _, e := range events {
+				got = append(got, e.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("events = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	events := r.eventsNear(annotations, change, 2*time.Minute)
+	if e := events[0]; e.Kind != "alert_transition" || e.OffsetSeconds != 0 {
+		t.Errorf("event with a new state = %+v, want an alert transition at offset 0", e)
+	}
+	if e := events[1]; e.Kind != "annotation" || e.OffsetSeconds != -120 || e.TimeLocal != "2023-11-14T22:11:20Z" {
+		t.Errorf("deploy = %+v, want an annotation 120s before at 2023-11-14T22:11:20Z", e)
+	}
+	if e := events[2]; e.Kind != "alert_transition" || e.AlertName != "High latency" {
+		t.Errorf("alert = %+v, want an alert transition", e)
+	}
+}
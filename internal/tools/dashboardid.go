@@ -0,0 +1,169 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// Dashboards are addressed by uid, but older automations and /dashboard/db
+// URLs only carry the numeric id Grafana used before uids. Every dashboard
+// uid argument therefore has an id twin (uid/id, dashboard_uid/dashboard_id,
+// uids/ids) that is resolved to the uid before the tool runs. A number
+// passed for the uid argument itself is treated as an id too.
+
+// dashboardIDArgs maps the dashboard uid arguments of a tool's schema to
+// their id twins. Twins that would collide with an existing argument are
+// left out.
+func dashboardIDArgs(t mcp.Tool) map[string]string {
+	out := map[string]string{}
+	for key := range t.InputSchema.Properties {
+		if !isUIDArg(key) || argRefKind(t.Name, key) != refDashboard {
+			continue
+		}
+		idKey := strings.Replace(key, "uid", "id", 1)
+		if _, taken := t.InputSchema.Properties[idKey]; taken {
+			continue
+		}
+		out[key] = idKey
+	}
+	return out
+}
+
+// withDashboardIDArgs adds the id twins to a tool's schema. A uid that was
+// required becomes optional, since its twin may be given instead; the tool
+// still reports the uid as missing when neither is.
+func withDashboardIDArgs(t mcp.Tool) mcp.Tool {
+	twins := dashboardIDArgs(t)
+	if len(twins) == 0 {
+		return t
+	}
+	props := make(map[string]mcp.Property, len(t.InputSchema.Properties)+len(twins))
+	for k, v := range t.InputSchema.Properties {
+		props[k] = v
+	}
+	for key, idKey := range twins {
+		if strings.HasSuffix(key, "s") {
+			props[idKey] = mcp.Property{Type: "array", Description: fmt.Sprintf("Legacy numeric dashboard ids, resolved to uids; alternative to %s", key)}
+		} else {
+			props[idKey] = mcp.Property{Type: "integer", Description: fmt.Sprintf("Legacy numeric dashboard id, resolved to a uid; alternative to %s", key)}
+		}
+	}
+	var required []string
+	for _, name := range t.InputSchema.Required {
+		if _, ok := twins[name]; !ok {
+			required = append(required, name)
+		}
+	}
+	t.InputSchema.Properties = props
+	t.InputSchema.Required = required
+	return t
+}
+
+// resolveDashboardIDArgs replaces dashboard ids given for a tool with the
+// uids they name
+func (r *Registry) resolveDashboardIDArgs(tool string, args map[string]interface{}) (map[string]interface{}, error) {
+	twins := r.dashboardIDs[tool]
+	if len(twins) == 0 {
+		return args, nil
+	}
+
+	var out map[string]interface{}
+	keys := make([]string, 0, len(twins))
+	for key := range twins {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		idKey := twins[key]
+		var resolved interface{}
+		var err error
+		switch uidVal, idVal := args[key], args[idKey]; {
+		case hasIDs(uidVal):
+			resolved, err = r.resolveDashboardIDs(key, uidVal, false)
+		case (uidVal == nil || uidVal == "") && idVal != nil:
+			resolved, err = r.resolveDashboardIDs(idKey, idVal, true)
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = make(map[string]interface{}, len(args))
+			for k, v := range args {
+				out[k] = v
+			}
+		}
+		out[key] = resolved
+		delete(out, idKey)
+	}
+	if out == nil {
+		return args, nil
+	}
+	return out, nil
+}
+
+// hasIDs reports whether a uid argument holds numbers, which are ids
+func hasIDs(v interface{}) bool {
+	switch v := v.(type) {
+	case float64:
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if _, ok := item.(float64); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// resolveDashboardIDs resolves an id, or an array of ids, to uids. Strings
+// are uids and kept as they are, unless they come from an id argument.
+func (r *Registry) resolveDashboardIDs(key string, v interface{}, stringIDs bool) (interface{}, error) {
+	items, isList := v.([]interface{})
+	if !isList {
+		return r.dashboardUIDForID(key, v, stringIDs)
+	}
+	uids := make([]interface{}, len(items))
+	for i, item := range items {
+		uid, err := r.dashboardUIDForID(key, item, stringIDs)
+		if err != nil {
+			return nil, err
+		}
+		uids[i] = uid
+	}
+	return uids, nil
+}
+
+// dashboardUIDForID looks up the uid of the dashboard with a numeric id
+func (r *Registry) dashboardUIDForID(key string, v interface{}, stringIDs bool) (string, error) {
+	var id int64
+	switch v := v.(type) {
+	case float64:
+		id = int64(v)
+	case string:
+		if !stringIDs {
+			return v, nil
+		}
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return "", fmt.Errorf("%s must be a numeric dashboard id, got %q", key, v)
+		}
+		id = n
+	default:
+		return "", fmt.Errorf("%s must be a numeric dashboard id", key)
+	}
+	if id <= 0 {
+		return "", fmt.Errorf("%s must be a positive dashboard id", key)
+	}
+	uid, err := r.client.GetDashboardUIDByID(r.ctx, id)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve dashboard id %d: %v", id, err)
+	}
+	return uid, nil
+}
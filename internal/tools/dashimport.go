@@ -0,0 +1,309 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaImportDashboardTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_import_dashboard",
+		Description: "Import dashboard JSON exported from another Grafana instance or grafana.com, resolving its datasource references here: ${DS_*} inputs, UIDs, and names are matched by UID, then name, then the default or only datasource of the same type. References that stay ambiguous are returned with their candidates instead of saving, to be chosen through datasources",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"dashboard":   {Type: "object", Description: "Dashboard JSON, as an object or a JSON string; an export with __inputs or a {dashboard, meta} API response also works"},
+				"datasources": {Type: "object", Description: "Datasource UID here for an input name, source UID, or source name, e.g. {\"DS_PROMETHEUS\": \"prom-uid\"}"},
+				"folder_uid":  {Type: "string", Description: "Folder UID to import into"},
+				"overwrite":   {Type: "boolean", Description: "Replace an existing dashboard with the same UID or title"},
+				"message":     {Type: "string", Description: "Version history message"},
+				"dry_run":     {Type: "boolean", Description: "Resolve datasources and return the mapped dashboard without saving"},
+			},
+			Required: []string{"dashboard"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// importSource is one datasource the imported dashboard refers to and what
+// it resolved to here
+type importSource struct {
+	Source     string         `json:"source"`
+	Type       string         `json:"type,omitempty"`
+	UID        string         `json:"uid,omitempty"`
+	Name       string         `json:"name,omitempty"`
+	ResolvedBy string         `json:"resolved_by,omitempty"` // mapped, uid, name, default, or only
+	References int            `json:"references"`
+	Candidates []importChoice `json:"candidates,omitempty"`
+	Problem    string         `json:"problem,omitempty"`
+
+	refs []map[string]interface{}
+}
+
+type importChoice struct {
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+}
+
+func (r *Registry) handleImportDashboard(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	model, err := importModel(args["dashboard"])
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if dashboardTitle(model) == "" {
+		return errorResult("dashboard has no title"), nil
+	}
+
+	sources := collectImportSources(model)
+	if len(sources) > 0 {
+		all, err := r.client.GetDatasources(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to list datasources", err), nil
+		}
+		explicit := getStringMap(args, "datasources")
+		for _, src := range sources {
+			if err := resolveImportSource(src, explicit, all); err != nil {
+				return errorResultFor(err), nil
+			}
+		}
+	}
+
+	var unresolved []*importSource
+	for _, src := range sources {
+		if src.UID == "" {
+			unresolved = append(unresolved, src)
+		}
+	}
+	if len(unresolved) > 0 {
+		res, err := jsonResult(map[string]interface{}{
+			"title":       dashboardTitle(model),
+			"datasources": sources,
+			"unresolved":  len(unresolved),
+			"hint":        "choose a datasource for each unresolved source and call again with datasources, e.g. {\"" + unresolved[0].Source + "\": \"<uid>\"}",
+		})
+		if res != nil {
+			res.IsError = true
+		}
+		return res, err
+	}
+
+	for _, src := range sources {
+		for _, ref := range src.refs {
+			ref["uid"], ref["type"] = src.UID, src.Type
+		}
+	}
+	delete(model, "id")
+	delete(model, "__inputs")
+	delete(model, "__requires")
+
+	out := map[string]interface{}{"datasources": sources}
+	if getBool(args, "dry_run") {
+		out["dry_run"] = true
+		out["dashboard"] = model
+		return jsonResult(out)
+	}
+	message := getString(args, "message")
+	if message == "" {
+		message = "Imported via MCP"
+	}
+	saved, err := r.client.SaveDashboardJSON(r.ctx, model, getString(args, "folder_uid"), message, getBool(args, "overwrite"))
+	if err != nil {
+		return apiErrorResult("Failed to save dashboard", err), nil
+	}
+	out["uid"], out["url"], out["version"] = saved.UID, saved.URL, saved.Version
+	return jsonResult(out)
+}
+
+// importModel reads the dashboard argument, unwrapping an API response
+func importModel(v interface{}) (map[string]interface{}, error) {
+	var model map[string]interface{}
+	switch d := v.(type) {
+	case map[string]interface{}:
+		model = d
+	case string:
+		if err := json.Unmarshal([]byte(d), &model); err != nil {
+			return nil, fmt.Errorf("dashboard is not valid JSON: %v", err)
+		}
+	default:
+		return nil, fmt.Errorf("dashboard is required")
+	}
+	if inner, ok := model["dashboard"].(map[string]interface{}); ok && model["panels"] == nil {
+		model = inner
+	}
+	return model, nil
+}
+
+func dashboardTitle(model map[string]interface{}) string {
+	title, _ := model["title"].(string)
+	return title
+}
+
+// collectImportSources finds every datasource reference in the dashboard,
+// grouped by the datasource it names. References to the default datasource,
+// to built-in datasources, and to datasource variables are left alone.
+// Legacy name references are replaced by {uid, type} objects, so every
+// reference collected can be rewritten in place.
+func collectImportSources(model map[string]interface{}) []*importSource {
+	inputs := map[string]string{}
+	list, _ := model["__inputs"].([]interface{})
+	for _, item := range list {
+		in, _ := item.(map[string]interface{})
+		if in["type"] == "datasource" {
+			name, _ := in["name"].(string)
+			plugin, _ := in["pluginId"].(string)
+			inputs["${"+name+"}"] = plugin
+		}
+	}
+
+	bySource := map[string]*importSource{}
+	var order []string
+	add := func(key, typ string, ref map[string]interface{}) {
+		src := bySource[key]
+		if src == nil {
+			src = &importSource{Source: strings.TrimSuffix(strings.TrimPrefix(key, "${"), "}"), Type: typ}
+			bySource[key] = src
+			order = append(order, key)
+		}
+		if src.Type == "" {
+			src.Type = typ
+		}
+		src.References++
+		src.refs = append(src.refs, ref)
+	}
+
+	var walk func(v interface{})
+	walk = func(v interface{}) {
+		switch t := v.(type) {
+		case map[string]interface{}:
+			for k, child := range t {
+				if k != "datasource" {
+					walk(child)
+					continue
+				}
+				switch ds := child.(type) {
+				case string:
+					if key, typ, ok := importKey(ds, "", inputs); ok {
+						ref := map[string]interface{}{}
+						t[k] = ref
+						add(key, typ, ref)
+					}
+				case map[string]interface{}:
+					uid, _ := ds["uid"].(string)
+					typ, _ := ds["type"].(string)
+					if key, inputType, ok := importKey(uid, typ, inputs); ok {
+						if typ == "" {
+							typ = inputType
+						}
+						add(key, typ, ds)
+					}
+				}
+			}
+		case []interface{}:
+			for _, child := range t {
+				walk(child)
+			}
+		}
+	}
+	walk(model["panels"])
+	walk(model["templating"])
+	walk(model["annotations"])
+	walk(model["rows"])
+
+	out := make([]*importSource, 0, len(order))
+	for _, key := range order {
+		out = append(out, bySource[key])
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
+}
+
+// importKey returns the source a datasource reference names and, for an
+// __inputs placeholder, its type. ok is false for references kept as they
+// are.
+func importKey(ref, typ string, inputs map[string]string) (string, string, bool) {
+	if inputType, ok := inputs[ref]; ok {
+		return ref, inputType, true
+	}
+	if ref == "" || strings.HasPrefix(ref, "$") || typ == "datasource" || typ == expressionDatasource || ref == expressionDatasource {
+		return "", "", false
+	}
+	if _, ok := builtinDatasources[ref]; ok || ref == "grafana" {
+		return "", "", false
+	}
+	return ref, typ, true
+}
+
+// resolveImportSource picks a datasource here for src: the one mapped
+// explicitly, then one with the same UID, then the same name, then the
+// default or only datasource of its type. Ambiguous and missing sources
+// are left without a UID and explain why.
+func resolveImportSource(src *importSource, explicit map[string]string, all []grafana.Datasource) error {
+	pick := func(ds grafana.Datasource, by string) {
+		src.UID, src.Name, src.ResolvedBy = ds.UID, ds.Name, by
+		src.Type = ds.Type
+	}
+	if uid, ok := explicit[src.Source]; ok {
+		for _, ds := range all {
+			if ds.UID == uid {
+				if src.Type != "" && ds.Type != src.Type {
+					return fmt.Errorf("datasources maps %s to %s, a %s datasource, but the dashboard uses it as %s", src.Source, ds.Name, ds.Type, src.Type)
+				}
+				pick(ds, "mapped")
+				return nil
+			}
+		}
+		return fmt.Errorf("datasources maps %s to %q, which does not exist", src.Source, uid)
+	}
+
+	sameType := func(ds grafana.Datasource) bool { return src.Type == "" || ds.Type == src.Type }
+	for _, ds := range all {
+		if ds.UID == src.Source && sameType(ds) {
+			pick(ds, "uid")
+			return nil
+		}
+	}
+	for _, ds := range all {
+		if ds.Name == src.Source && sameType(ds) {
+			pick(ds, "name")
+			return nil
+		}
+	}
+	if src.Type == "" {
+		src.Problem = fmt.Sprintf("no datasource named %q exists and the dashboard does not say its type", src.Source)
+		return nil
+	}
+
+	var candidates []grafana.Datasource
+	for _, ds := range all {
+		if ds.Type == src.Type {
+			candidates = append(candidates, ds)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		src.Problem = fmt.Sprintf("no %s datasource exists", src.Type)
+		return nil
+	case 1:
+		pick(candidates[0], "only")
+		return nil
+	}
+	for _, ds := range candidates {
+		if ds.IsDefault {
+			pick(ds, "default")
+			return nil
+		}
+	}
+	src.Problem = fmt.Sprintf("%d %s datasources match and none is the default", len(candidates), src.Type)
+	for _, ds := range candidates {
+		src.Candidates = append(src.Candidates, importChoice{UID: ds.UID, Name: ds.Name})
+	}
+	return nil
+}
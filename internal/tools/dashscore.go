@@ -0,0 +1,40 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaScoreDashboardTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_score_dashboard",
+		Description: "Score a dashboard's readability 0-100 on panel count, description coverage, unit configuration, legend overload, and threshold color consistency, with concrete suggestions. Suggestions with a path and value are mechanical: set value at that path in the panel JSON and save with grafana_update_dashboard",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uid": {Type: "string", Description: "Dashboard UID"},
+			},
+			Required: []string{"uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleScoreDashboard(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "uid")
+	if uid == "" {
+		return errorResult("uid is required"), nil
+	}
+	dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		return apiErrorResult("Failed to get dashboard", err), nil
+	}
+	return jsonResult(map[string]interface{}{
+		"uid":    uid,
+		"title":  dashboard.String(dash.Dashboard, "title"),
+		"report": dashboard.Score(dash.Dashboard),
+	})
+}
@@ -0,0 +1,127 @@
+package tools
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/cache"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	// deltaCursorTTL is how long a list snapshot can be diffed against
+	deltaCursorTTL = time.Hour
+	// deltaCursorLimit is the number of snapshots kept across all tools
+	deltaCursorLimit = 64
+)
+
+// listSnapshot records what a list call returned, as item hashes by key
+type listSnapshot struct {
+	tool   string
+	filter string
+	// orgID is the organization listed, since UIDs only mean something
+	// within one
+	orgID  int64
+	hashes map[string]string
+}
+
+// deltaProperties adds the delta-mode arguments to a list tool's schema
+func deltaProperties(props map[string]mcp.Property) map[string]mcp.Property {
+	props["delta"] = mcp.Property{Type: "boolean", Description: "Return a cursor with the list so later calls can ask for changes only"}
+	props["if_changed_since"] = mcp.Property{Type: "string", Description: "Cursor from a previous delta call; only added, changed, and removed items since then are returned, with a new cursor"}
+	return props
+}
+
+// deltaRequested reports whether a list call asked for delta mode
+func deltaRequested(args map[string]interface{}) bool {
+	return getBool(args, "delta") || getString(args, "if_changed_since") != ""
+}
+
+// deltaList answers a list call in delta mode. Without a usable
+// if_changed_since cursor it returns every item; with one it returns only
+// the difference from that snapshot. Either way the response carries a new
+// cursor. filter identifies the call's filters, since a cursor only makes
+// sense against the same query. extra fields are merged into the response.
+func deltaList[T any](r *Registry, tool, filter string, args map[string]interface{}, items []T, key func(T) string, extra map[string]interface{}) (*mcp.CallToolResult, error) {
+	snap := listSnapshot{tool: tool, filter: filter, orgID: r.orgID(), hashes: make(map[string]string, len(items))}
+	for _, item := range items {
+		snap.hashes[key(item)] = itemHash(item)
+	}
+	cursor := newCursor()
+	r.deltas.Set(cursor, snap)
+
+	out := map[string]interface{}{"cursor": cursor, "total": len(items)}
+	for k, v := range extra {
+		out[k] = v
+	}
+
+	since := getString(args, "if_changed_since")
+	prev, ok := r.deltas.Get(since)
+	switch {
+	case since == "":
+		out["items"] = items
+		return jsonResult(out)
+	case !ok:
+		out["items"] = items
+		out["note"] = "the if_changed_since cursor is unknown or expired; the full list is returned"
+		return jsonResult(out)
+	case prev.tool != tool || prev.filter != filter || prev.orgID != snap.orgID:
+		out["items"] = items
+		out["note"] = "the if_changed_since cursor belongs to a different tool, filter, or organization; the full list is returned"
+		return jsonResult(out)
+	}
+
+	added, changed := []T{}, []T{}
+	unchanged := 0
+	for _, item := range items {
+		k := key(item)
+		switch old, seen := prev.hashes[k]; {
+		case !seen:
+			added = append(added, item)
+		case old != snap.hashes[k]:
+			changed = append(changed, item)
+		default:
+			unchanged++
+		}
+	}
+	removed := []string{}
+	for k := range prev.hashes {
+		if _, ok := snap.hashes[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	sort.Strings(removed)
+
+	out["since"] = since
+	out["added"] = added
+	out["changed"] = changed
+	out["removed"] = removed
+	out["unchanged"] = unchanged
+	return jsonResult(out)
+}
+
+func itemHash(v interface{}) string {
+	data, _ := json.Marshal(v)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func newCursor() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newDeltaSnapshots() *cache.Cache[listSnapshot] {
+	return cache.New[listSnapshot](deltaCursorLimit, deltaCursorTTL)
+}
+
+// searchResultKey identifies a dashboard or folder search hit
+func searchResultKey(v grafana.SearchDashboardsResponse) string {
+	return v.Type + "/" + v.UID
+}
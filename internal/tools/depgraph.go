@@ -0,0 +1,416 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// depGraphDashboardLimit caps the dashboards scanned by default
+const depGraphDashboardLimit = 500
+
+func (r *Registry) grafanaDependencyGraphTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_dependency_graph",
+		Description: "Build the graph of alert rules, dashboards, and datasources: which datasources each rule and dashboard queries, and which dashboards a rule belongs to through its dashboard/panel annotations or a query shared with a panel. With focus, returns only what depends on that object, directly or through others, answering \"what breaks if I delete this datasource?\". Output is adjacency JSON or Graphviz DOT",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"focus":          {Type: "string", Description: "Node to compute the blast radius of, as type:uid, e.g. datasource:prom-uid, dashboard:abc, or alert_rule:xyz"},
+				"format":         {Type: "string", Description: "Output format (default json)", Enum: []string{"json", "dot"}},
+				"folder_uid":     {Type: "string", Description: "Only scan dashboards and alert rules in this folder"},
+				"max_dashboards": {Type: "integer", Description: "Dashboards to scan (default 500)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// Edge relations in the dependency graph
+const (
+	relQueries     = "queries"
+	relLinkedPanel = "linked_panel"
+	relSharedQuery = "shared_query"
+)
+
+// depNode is a rule, dashboard, or datasource in the dependency graph
+type depNode struct {
+	ID      string `json:"id"`
+	Type    string `json:"type"`
+	UID     string `json:"uid"`
+	Name    string `json:"name,omitempty"`
+	Missing bool   `json:"missing,omitempty"`
+}
+
+// depEdge points from an object to one it depends on
+type depEdge struct {
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Relation string  `json:"relation"`
+	PanelIDs []int64 `json:"panel_ids,omitempty"`
+}
+
+// depGraph is built node by node; edges between the same pair with the same
+// relation are merged, collecting their panels
+type depGraph struct {
+	nodes map[string]*depNode
+	edges map[string]*depEdge
+}
+
+func depID(kind, uid string) string {
+	return kind + ":" + uid
+}
+
+func (g *depGraph) node(kind, uid, name string) string {
+	id := depID(kind, uid)
+	if n, ok := g.nodes[id]; ok {
+		if n.Name == "" {
+			n.Name = name
+		}
+		return id
+	}
+	g.nodes[id] = &depNode{ID: id, Type: kind, UID: uid, Name: name}
+	return id
+}
+
+func (g *depGraph) edge(from, to, relation string, panelID int64) {
+	key := from + "\x00" + to + "\x00" + relation
+	e := g.edges[key]
+	if e == nil {
+		e = &depEdge{From: from, To: to, Relation: relation}
+		g.edges[key] = e
+	}
+	if panelID != 0 && !containsInt64(e.PanelIDs, panelID) {
+		e.PanelIDs = append(e.PanelIDs, panelID)
+	}
+}
+
+func containsInt64(list []int64, v int64) bool {
+	for _, x := range list {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
+
+// panelQuery is a panel's query text, kept to match rules sharing it
+type panelQuery struct {
+	dashboard string
+	panelID   int64
+	dsUID     string
+}
+
+func (r *Registry) handleDependencyGraph(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	format := getString(args, "format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "dot" {
+		return errorResult("format must be json or dot"), nil
+	}
+	focus := getString(args, "focus")
+	if focus != "" && !strings.Contains(focus, ":") {
+		return errorResult("focus must be type:uid, e.g. datasource:prom-uid"), nil
+	}
+	folderUID := getString(args, "folder_uid")
+	limit := getInt(args, "max_dashboards")
+	if limit <= 0 {
+		limit = depGraphDashboardLimit
+	}
+
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list datasources", err), nil
+	}
+	rules, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list alert rules", err), nil
+	}
+	q := grafana.SearchQuery{Type: "dash-db", Limit: limit}
+	if folderUID != "" {
+		q.FolderUIDs = []string{folderUID}
+	}
+	hits, err := r.client.Search(r.ctx, q)
+	if err != nil {
+		return apiErrorResult("Failed to search dashboards", err), nil
+	}
+
+	g := &depGraph{nodes: map[string]*depNode{}, edges: map[string]*depEdge{}}
+	known := make(map[string]bool, len(datasources))
+	for _, ds := range datasources {
+		g.node(refDatasource, ds.UID, ds.Name)
+		known[ds.UID] = true
+	}
+	dsNode := func(uid string) string {
+		id := g.node(refDatasource, uid, "")
+		if !known[uid] {
+			g.nodes[id].Missing = true
+		}
+		return id
+	}
+
+	// Dashboards are fetched in parallel and merged into the graph in
+	// search order
+	dashes := make([]*grafana.DashboardJSON, len(hits))
+	var notes []string
+	var mu sync.Mutex
+	sem := make(chan struct{}, defaultAnomalyConcurrency)
+	var wg sync.WaitGroup
+	for i, h := range hits {
+		wg.Add(1)
+		go func(i int, uid string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+			if err != nil {
+				mu.Lock()
+				notes = append(notes, fmt.Sprintf("dashboard %s skipped: %v", uid, err))
+				mu.Unlock()
+				return
+			}
+			dashes[i] = dash
+		}(i, h.UID)
+	}
+	wg.Wait()
+
+	queries := map[string][]panelQuery{}
+	scanned := map[string]bool{}
+	for i, dash := range dashes {
+		if dash == nil {
+			continue
+		}
+		scanned[hits[i].UID] = true
+		dashID := g.node(refDashboard, hits[i].UID, hits[i].Title)
+		vars := dashboard.CurrentValues(dash.Dashboard)
+		resolver := newDatasourceResolver(datasources, vars)
+		use := func(ref interface{}, panelID int64) string {
+			ds, ok, err := resolver.resolve(ref)
+			if err != nil {
+				// A reference to a datasource that no longer exists is
+				// exactly what a blast-radius question needs to see
+				if uid := missingRefUID(ref); uid != "" {
+					g.edge(dashID, dsNode(uid), relQueries, panelID)
+				}
+				return ""
+			}
+			if !ok {
+				return ""
+			}
+			g.edge(dashID, dsNode(ds.UID), relQueries, panelID)
+			return ds.UID
+		}
+		for _, p := range dashboard.Panels(dash.Dashboard) {
+			if dashboard.IsRow(p) {
+				continue
+			}
+			id := dashboard.PanelID(p)
+			targets, _ := p["targets"].([]interface{})
+			for _, t := range targets {
+				tm, ok := t.(map[string]interface{})
+				if !ok {
+					continue
+				}
+				ref := tm["datasource"]
+				if ref == nil {
+					ref = p["datasource"]
+				}
+				dsUID := use(ref, id)
+				if expr := normalizeQuery(dashboard.Interpolate(dashboard.String(tm, "expr"), vars)); expr != "" && dsUID != "" {
+					queries[expr] = append(queries[expr], panelQuery{dashboard: hits[i].UID, panelID: id, dsUID: dsUID})
+				}
+			}
+		}
+		templating, _ := dash.Dashboard["templating"].(map[string]interface{})
+		list, _ := templating["list"].([]interface{})
+		for _, v := range list {
+			vm, _ := v.(map[string]interface{})
+			if vm["type"] == "query" && vm["datasource"] != nil {
+				use(vm["datasource"], 0)
+			}
+		}
+	}
+
+	for _, rule := range rules {
+		if folderUID != "" && rule.FolderUID != folderUID {
+			continue
+		}
+		ruleID := g.node(refAlertRule, rule.UID, rule.Title)
+		for _, data := range rule.Data {
+			if data.DatasourceUID == "" || data.DatasourceUID == expressionDatasource || data.DatasourceUID == "-100" {
+				continue
+			}
+			g.edge(ruleID, dsNode(data.DatasourceUID), relQueries, 0)
+			expr, _ := data.Model["expr"].(string)
+			for _, pq := range queries[normalizeQuery(expr)] {
+				if pq.dsUID == data.DatasourceUID {
+					g.edge(ruleID, depID(refDashboard, pq.dashboard), relSharedQuery, pq.panelID)
+				}
+			}
+		}
+		if uid := rule.Annotations["__dashboardUid__"]; uid != "" {
+			panelID, _ := strconv.ParseInt(rule.Annotations["__panelId__"], 10, 64)
+			dashID := g.node(refDashboard, uid, "")
+			if !scanned[uid] && folderUID == "" && len(hits) < limit {
+				g.nodes[dashID].Missing = true
+			}
+			g.edge(ruleID, dashID, relLinkedPanel, panelID)
+		}
+	}
+
+	nodes, edges := g.sorted()
+	var impacted []depNode
+	if focus != "" {
+		if _, ok := g.nodes[focus]; !ok {
+			return errorResult(fmt.Sprintf("%s is not in the graph; use type:uid with type datasource, dashboard, or alert_rule", focus)), nil
+		}
+		nodes, edges, impacted = dependents(focus, nodes, edges)
+	}
+
+	if format == "dot" {
+		return &mcp.CallToolResult{
+			Content: []mcp.ContentBlock{{Type: "text", Text: depGraphDOT(nodes, edges, focus)}},
+		}, nil
+	}
+	out := map[string]interface{}{
+		"nodes": nodes,
+		"edges": edges,
+	}
+	if focus != "" {
+		out["focus"] = focus
+		out["impacted"] = impacted
+	}
+	if len(hits) >= limit {
+		notes = append(notes, fmt.Sprintf("only the first %d dashboards were scanned; raise max_dashboards to scan more", limit))
+	}
+	if len(notes) > 0 {
+		out["notes"] = notes
+	}
+	return jsonResult(out)
+}
+
+// missingRefUID returns the uid a datasource reference names when it is not
+// a variable
+func missingRefUID(ref interface{}) string {
+	var uid string
+	switch v := ref.(type) {
+	case string:
+		uid = v
+	case map[string]interface{}:
+		uid = dashboard.String(v, "uid")
+	}
+	if strings.HasPrefix(uid, "$") {
+		return ""
+	}
+	return uid
+}
+
+// normalizeQuery collapses whitespace so that formatting differences do not
+// hide a shared query
+func normalizeQuery(q string) string {
+	return strings.Join(strings.Fields(q), " ")
+}
+
+func (g *depGraph) sorted() ([]depNode, []depEdge) {
+	nodes := make([]depNode, 0, len(g.nodes))
+	for _, n := range g.nodes {
+		nodes = append(nodes, *n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	edges := make([]depEdge, 0, len(g.edges))
+	for _, e := range g.edges {
+		sort.Slice(e.PanelIDs, func(i, j int) bool { return e.PanelIDs[i] < e.PanelIDs[j] })
+		edges = append(edges, *e)
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].From != edges[j].From {
+			return edges[i].From < edges[j].From
+		}
+		if edges[i].To != edges[j].To {
+			return edges[i].To < edges[j].To
+		}
+		return edges[i].Relation < edges[j].Relation
+	})
+	return nodes, edges
+}
+
+// dependents returns the subgraph of focus and everything that depends on
+// it, directly or through other nodes, with the dependents listed apart
+func dependents(focus string, nodes []depNode, edges []depEdge) ([]depNode, []depEdge, []depNode) {
+	reverse := map[string][]string{}
+	for _, e := range edges {
+		reverse[e.To] = append(reverse[e.To], e.From)
+	}
+	keep := map[string]bool{focus: true}
+	queue := []string{focus}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		for _, from := range reverse[id] {
+			if !keep[from] {
+				keep[from] = true
+				queue = append(queue, from)
+			}
+		}
+	}
+	var subNodes, impacted []depNode
+	for _, n := range nodes {
+		if keep[n.ID] {
+			subNodes = append(subNodes, n)
+			if n.ID != focus {
+				impacted = append(impacted, n)
+			}
+		}
+	}
+	var subEdges []depEdge
+	for _, e := range edges {
+		if keep[e.From] && keep[e.To] {
+			subEdges = append(subEdges, e)
+		}
+	}
+	if impacted == nil {
+		impacted = []depNode{}
+	}
+	return subNodes, subEdges, impacted
+}
+
+// depGraphDOT renders the graph for Graphviz: datasources as cylinders,
+// dashboards as boxes, and rules as ellipses, with missing objects dashed
+func depGraphDOT(nodes []depNode, edges []depEdge, focus string) string {
+	var b strings.Builder
+	b.WriteString("digraph grafana {\n  rankdir=LR;\n")
+	shapes := map[string]string{refDatasource: "cylinder", refDashboard: "box", refAlertRule: "ellipse"}
+	for _, n := range nodes {
+		label := n.Name
+		if label == "" {
+			label = n.UID
+		}
+		attrs := fmt.Sprintf("label=%s, shape=%s", strconv.Quote(n.Type+"\n"+label), shapes[n.Type])
+		if n.Missing {
+			attrs += ", style=dashed"
+		}
+		if n.ID == focus {
+			attrs += ", penwidth=2"
+		}
+		fmt.Fprintf(&b, "  %s [%s];\n", strconv.Quote(n.ID), attrs)
+	}
+	for _, e := range edges {
+		attrs := ""
+		if e.Relation != relQueries {
+			attrs = fmt.Sprintf(" [label=%s, style=dotted]", strconv.Quote(e.Relation))
+		}
+		fmt.Fprintf(&b, "  %s -> %s%s;\n", strconv.Quote(e.From), strconv.Quote(e.To), attrs)
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
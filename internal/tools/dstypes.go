@@ -0,0 +1,397 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// datasourceField is a setting a datasource type reads from jsonData or
+// secureJsonData
+type datasourceField struct {
+	Name        string      `json:"name"`
+	Type        string      `json:"type"`
+	Description string      `json:"description"`
+	Required    bool        `json:"required,omitempty"`
+	Default     interface{} `json:"default,omitempty"`
+	Enum        []string    `json:"enum,omitempty"`
+}
+
+// datasourceSchema describes how to configure one datasource type. Plugins
+// do not publish their settings schema through the API, so these hints are
+// kept for the core and most common plugins.
+type datasourceSchema struct {
+	// URL is "required", "optional", or "none"
+	URL            string            `json:"url"`
+	URLHint        string            `json:"url_hint,omitempty"`
+	User           string            `json:"user,omitempty"`
+	JSONData       []datasourceField `json:"json_data"`
+	SecureJSONData []datasourceField `json:"secure_json_data"`
+	Notes          string            `json:"notes,omitempty"`
+	// HTTP is whether the common HTTP client settings apply
+	HTTP bool `json:"-"`
+}
+
+// httpDatasourceFields are the jsonData settings shared by datasources that
+// talk to their backend over HTTP
+var httpDatasourceFields = []datasourceField{
+	{Name: "timeout", Type: "integer", Description: "HTTP request timeout in seconds"},
+	{Name: "tlsSkipVerify", Type: "boolean", Description: "Skip TLS certificate verification"},
+	{Name: "tlsAuth", Type: "boolean", Description: "Present a TLS client certificate (tlsClientCert/tlsClientKey)"},
+	{Name: "tlsAuthWithCACert", Type: "boolean", Description: "Verify the server against tlsCACert"},
+	{Name: "oauthPassThru", Type: "boolean", Description: "Forward the user's OAuth identity"},
+	{Name: "httpHeaderName1", Type: "string", Description: "Name of a custom header sent with every request; its value is secureJsonData.httpHeaderValue1 (use 2, 3, ... for more)"},
+}
+
+var httpDatasourceSecureFields = []datasourceField{
+	{Name: "basicAuthPassword", Type: "string", Description: "Password for basic auth, with basicAuth and basicAuthUser set"},
+	{Name: "httpHeaderValue1", Type: "string", Description: "Value of the custom header named by jsonData.httpHeaderName1"},
+	{Name: "tlsCACert", Type: "string", Description: "PEM CA certificate"},
+	{Name: "tlsClientCert", Type: "string", Description: "PEM client certificate"},
+	{Name: "tlsClientKey", Type: "string", Description: "PEM client key"},
+}
+
+var sqlPoolFields = []datasourceField{
+	{Name: "maxOpenConns", Type: "integer", Description: "Maximum open connections (0 unlimited)"},
+	{Name: "maxIdleConns", Type: "integer", Description: "Maximum idle connections"},
+	{Name: "connMaxLifetime", Type: "integer", Description: "Seconds a connection may be reused"},
+}
+
+var traceToLogsField = datasourceField{Name: "tracesToLogsV2", Type: "object", Description: "Link spans to logs: {datasourceUid, spanStartTimeShift, spanEndTimeShift, filterByTraceID, tags}"}
+
+var datasourceSchemas = map[string]datasourceSchema{
+	"prometheus": {
+		URL: "required", URLHint: "http://prometheus:9090", HTTP: true,
+		JSONData: []datasourceField{
+			{Name: "httpMethod", Type: "string", Description: "HTTP method for queries", Default: "POST", Enum: []string{"POST", "GET"}},
+			{Name: "timeInterval", Type: "string", Description: "Scrape interval, the lower bound for $__interval, e.g. 15s"},
+			{Name: "queryTimeout", Type: "string", Description: "Query timeout, e.g. 60s"},
+			{Name: "prometheusType", Type: "string", Description: "Backend flavour, enabling its API features", Enum: []string{"Prometheus", "Mimir", "Cortex", "Thanos"}},
+			{Name: "prometheusVersion", Type: "string", Description: "Backend version, e.g. 2.50.0"},
+			{Name: "manageAlerts", Type: "boolean", Description: "Manage the backend's alert and recording rules from Grafana"},
+			{Name: "exemplarTraceIdDestinations", Type: "array", Description: "Exemplar links: [{name, datasourceUid}]"},
+			{Name: "customQueryParameters", Type: "string", Description: "Extra query string added to every request, e.g. dedup=false"},
+		},
+	},
+	"loki": {
+		URL: "required", URLHint: "http://loki:3100", HTTP: true,
+		JSONData: []datasourceField{
+			{Name: "maxLines", Type: "integer", Description: "Default maximum lines returned by log queries", Default: 1000},
+			{Name: "derivedFields", Type: "array", Description: "Links extracted from log lines: [{name, matcherRegex, url, datasourceUid}]"},
+		},
+	},
+	"tempo": {
+		URL: "required", URLHint: "http://tempo:3200", HTTP: true,
+		JSONData: []datasourceField{
+			traceToLogsField,
+			{Name: "tracesToMetrics", Type: "object", Description: "Link spans to metrics: {datasourceUid, queries}"},
+			{Name: "serviceMap", Type: "object", Description: "Service graph source: {datasourceUid} of a Prometheus with span metrics"},
+			{Name: "nodeGraph", Type: "object", Description: "{enabled: true} to show the node graph"},
+			{Name: "lokiSearch", Type: "object", Description: "{datasourceUid} of a Loki to search trace ids in"},
+		},
+	},
+	"jaeger": {
+		URL: "required", URLHint: "http://jaeger-query:16686", HTTP: true,
+		JSONData: []datasourceField{traceToLogsField},
+	},
+	"zipkin": {
+		URL: "required", URLHint: "http://zipkin:9411", HTTP: true,
+		JSONData: []datasourceField{traceToLogsField},
+	},
+	"alertmanager": {
+		URL: "required", URLHint: "http://alertmanager:9093", HTTP: true,
+		JSONData: []datasourceField{
+			{Name: "implementation", Type: "string", Description: "Alertmanager flavour", Default: "prometheus", Enum: []string{"prometheus", "mimir", "cortex"}},
+			{Name: "handleGrafanaManagedAlerts", Type: "boolean", Description: "Send Grafana-managed alerts to this Alertmanager"},
+		},
+	},
+	"elasticsearch": {
+		URL: "required", URLHint: "http://elasticsearch:9200", HTTP: true,
+		JSONData: []datasourceField{
+			{Name: "index", Type: "string", Description: "Index name or pattern, e.g. logs-* or [logs-]YYYY.MM.DD", Required: true},
+			{Name: "timeField", Type: "string", Description: "Timestamp field", Required: true, Default: "@timestamp"},
+			{Name: "interval", Type: "string", Description: "Index rotation for date patterns", Enum: []string{"Hourly", "Daily", "Weekly", "Monthly", "Yearly"}},
+			{Name: "maxConcurrentShardRequests", Type: "integer", Description: "Concurrent shard requests per search", Default: 5},
+			{Name: "logMessageField", Type: "string", Description: "Field shown as the log line"},
+			{Name: "logLevelField", Type: "string", Description: "Field holding the log level"},
+		},
+	},
+	"influxdb": {
+		URL: "required", URLHint: "http://influxdb:8086", HTTP: true,
+		JSONData: []datasourceField{
+			{Name: "version", Type: "string", Description: "Query language; selects the other settings used", Default: "InfluxQL", Enum: []string{"InfluxQL", "Flux", "SQL"}},
+			{Name: "dbName", Type: "string", Description: "Database (InfluxQL)"},
+			{Name: "httpMode", Type: "string", Description: "HTTP method (InfluxQL)", Enum: []string{"GET", "POST"}},
+			{Name: "organization", Type: "string", Description: "Organization (Flux)"},
+			{Name: "defaultBucket", Type: "string", Description: "Default bucket (Flux)"},
+		},
+		SecureJSONData: []datasourceField{
+			{Name: "password", Type: "string", Description: "Password for the user (InfluxQL)"},
+			{Name: "token", Type: "string", Description: "API token (Flux and SQL)"},
+		},
+	},
+	"graphite": {
+		URL: "required", URLHint: "http://graphite:8080", HTTP: true,
+		JSONData: []datasourceField{
+			{Name: "graphiteVersion", Type: "string", Description: "Graphite version, enabling newer functions", Default: "1.1"},
+			{Name: "graphiteType", Type: "string", Description: "Backend", Default: "default", Enum: []string{"default", "metrictank"}},
+		},
+	},
+	"postgres": {
+		URL: "required", URLHint: "host:5432 (no scheme)", User: "Database user (top-level user field)",
+		JSONData: append([]datasourceField{
+			{Name: "database", Type: "string", Description: "Database name", Required: true},
+			{Name: "sslmode", Type: "string", Description: "TLS mode", Default: "require", Enum: []string{"disable", "require", "verify-ca", "verify-full"}},
+			{Name: "postgresVersion", Type: "integer", Description: "Server version as major*100, e.g. 1500 for 15", Default: 903},
+			{Name: "timescaledb", Type: "boolean", Description: "Enable TimescaleDB functions"},
+		}, sqlPoolFields...),
+		SecureJSONData: []datasourceField{{Name: "password", Type: "string", Description: "Password for user"}},
+	},
+	"mysql": {
+		URL: "required", URLHint: "host:3306 (no scheme)", User: "Database user (top-level user field)",
+		JSONData: append([]datasourceField{
+			{Name: "database", Type: "string", Description: "Database name", Required: true},
+			{Name: "tlsAuth", Type: "boolean", Description: "Use a TLS client certificate"},
+			{Name: "tlsSkipVerify", Type: "boolean", Description: "Skip TLS certificate verification"},
+			{Name: "timezone", Type: "string", Description: "Session timezone, e.g. +00:00"},
+		}, sqlPoolFields...),
+		SecureJSONData: []datasourceField{{Name: "password", Type: "string", Description: "Password for user"}},
+	},
+	"mssql": {
+		URL: "required", URLHint: "host:1433 (no scheme)", User: "Database user (top-level user field)",
+		JSONData: append([]datasourceField{
+			{Name: "database", Type: "string", Description: "Database name", Required: true},
+			{Name: "authenticationType", Type: "string", Description: "Login method", Default: "SQL Server Authentication", Enum: []string{"SQL Server Authentication", "Windows Authentication", "Azure AD Authentication"}},
+			{Name: "encrypt", Type: "string", Description: "TLS encryption", Default: "false", Enum: []string{"false", "true", "disable"}},
+		}, sqlPoolFields...),
+		SecureJSONData: []datasourceField{{Name: "password", Type: "string", Description: "Password for user"}},
+	},
+	"cloudwatch": {
+		URL: "none",
+		JSONData: []datasourceField{
+			{Name: "authType", Type: "string", Description: "AWS credentials source", Required: true, Enum: []string{"default", "keys", "credentials", "ec2_iam_role", "grafana_assume_role"}},
+			{Name: "defaultRegion", Type: "string", Description: "Region queried by default, e.g. us-east-1", Required: true},
+			{Name: "assumeRoleArn", Type: "string", Description: "IAM role to assume"},
+			{Name: "externalId", Type: "string", Description: "External id for the assumed role"},
+			{Name: "profile", Type: "string", Description: "Credentials profile (authType credentials)"},
+			{Name: "customMetricsNamespaces", Type: "string", Description: "Comma-separated custom namespaces"},
+		},
+		SecureJSONData: []datasourceField{
+			{Name: "accessKey", Type: "string", Description: "Access key id (authType keys)"},
+			{Name: "secretKey", Type: "string", Description: "Secret access key (authType keys)"},
+		},
+	},
+	"grafana-testdata-datasource": {
+		URL:   "none",
+		Notes: "Generates test data; needs no settings",
+	},
+}
+
+// datasourceTypeAliases maps plugin ids that share a schema
+var datasourceTypeAliases = map[string]string{
+	"grafana-postgresql-datasource": "postgres",
+	"grafana-mysql-datasource":      "mysql",
+	"grafana-mssql-datasource":      "mssql",
+	"testdata":                      "grafana-testdata-datasource",
+}
+
+// lookupDatasourceSchema returns the settings hints for a plugin id,
+// including the shared HTTP settings where they apply
+func lookupDatasourceSchema(pluginID string) (datasourceSchema, bool) {
+	id := pluginID
+	if alias, ok := datasourceTypeAliases[id]; ok {
+		id = alias
+	}
+	s, ok := datasourceSchemas[id]
+	if !ok {
+		return datasourceSchema{}, false
+	}
+	if s.HTTP {
+		s.JSONData = append(append([]datasourceField(nil), s.JSONData...), httpDatasourceFields...)
+		s.SecureJSONData = append(append([]datasourceField(nil), s.SecureJSONData...), httpDatasourceSecureFields...)
+	}
+	if s.JSONData == nil {
+		s.JSONData = []datasourceField{}
+	}
+	if s.SecureJSONData == nil {
+		s.SecureJSONData = []datasourceField{}
+	}
+	return s, true
+}
+
+// withRequiredDefaults fills required jsonData settings that have a default
+// and were left out
+func withRequiredDefaults(dsType string, jsonData map[string]interface{}) map[string]interface{} {
+	schema, ok := lookupDatasourceSchema(dsType)
+	if !ok {
+		return jsonData
+	}
+	for _, f := range schema.JSONData {
+		if !f.Required || f.Default == nil {
+			continue
+		}
+		if _, set := jsonData[f.Name]; set {
+			continue
+		}
+		if jsonData == nil {
+			jsonData = map[string]interface{}{}
+		}
+		jsonData[f.Name] = f.Default
+	}
+	return jsonData
+}
+
+// missingDatasourceSettings lists the required settings a create call for
+// dsType leaves out
+func missingDatasourceSettings(dsType, dsURL string, jsonData map[string]interface{}, database string) []string {
+	schema, ok := lookupDatasourceSchema(dsType)
+	if !ok {
+		return nil
+	}
+	var missing []string
+	if schema.URL == "required" && dsURL == "" {
+		missing = append(missing, "url")
+	}
+	for _, f := range schema.JSONData {
+		if !f.Required {
+			continue
+		}
+		if v, ok := jsonData[f.Name]; ok && v != nil && v != "" {
+			continue
+		}
+		// SQL datasources still accept the database as the top-level field
+		if f.Name == "database" && database != "" {
+			continue
+		}
+		missing = append(missing, "json_data."+f.Name)
+	}
+	return missing
+}
+
+func (r *Registry) grafanaListDatasourceTypesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_datasource_types",
+		Description: "List the installed datasource plugins with the jsonData and secureJsonData settings each expects, which are required, and the settings existing datasources of the type use. Check this before grafana_create_datasource",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"type": {Type: "string", Description: "Only describe this plugin id, e.g. prometheus"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// datasourceType is an installed datasource plugin and how to configure it
+type datasourceType struct {
+	ID          string `json:"id"`
+	Name        string `json:"name,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Signature   string `json:"signature,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Datasources is how many datasources of the type exist
+	Datasources int `json:"datasources"`
+	*datasourceSchema
+	// ObservedJSONData are the jsonData keys set on existing datasources of
+	// the type, a hint for plugins without a known schema
+	ObservedJSONData []string `json:"observed_json_data,omitempty"`
+	SchemaKnown      bool     `json:"schema_known"`
+}
+
+func (r *Registry) handleListDatasourceTypes(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	only := getString(args, "type")
+
+	var warnings []string
+	plugins, err := r.client.ListPlugins(r.ctx, "datasource")
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not list plugins (%v); types are taken from existing datasources and the built-in catalog", err))
+	}
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		warnings = append(warnings, fmt.Sprintf("could not list datasources: %v", err))
+	}
+
+	types := map[string]*datasourceType{}
+	for _, p := range plugins {
+		types[p.ID] = &datasourceType{
+			ID:          p.ID,
+			Name:        p.Name,
+			Category:    p.Category,
+			Version:     p.Info.Version,
+			Signature:   p.Signature,
+			Description: p.Info.Description,
+		}
+	}
+	observed := map[string]map[string]bool{}
+	for _, ds := range datasources {
+		t, ok := types[ds.Type]
+		if !ok {
+			t = &datasourceType{ID: ds.Type, Name: ds.TypeName}
+			types[ds.Type] = t
+		}
+		t.Datasources++
+		if observed[ds.Type] == nil {
+			observed[ds.Type] = map[string]bool{}
+		}
+		for k := range ds.JSONData {
+			observed[ds.Type][k] = true
+		}
+	}
+	if len(plugins) == 0 {
+		for id := range datasourceSchemas {
+			if _, ok := types[id]; !ok {
+				types[id] = &datasourceType{ID: id}
+			}
+		}
+	}
+
+	out := []datasourceType{}
+	for id, t := range types {
+		if only != "" && id != only {
+			continue
+		}
+		if schema, ok := lookupDatasourceSchema(id); ok {
+			t.datasourceSchema, t.SchemaKnown = &schema, true
+		}
+		for k := range observed[id] {
+			t.ObservedJSONData = append(t.ObservedJSONData, k)
+		}
+		sort.Strings(t.ObservedJSONData)
+		out = append(out, *t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	if only != "" && len(out) == 0 {
+		return errorResult(fmt.Sprintf("datasource type %q is not installed", only)), nil
+	}
+
+	result := map[string]interface{}{"types": out, "count": len(out)}
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+	if unknown := countUnknownSchemas(out); unknown > 0 {
+		result["note"] = fmt.Sprintf("no settings schema is known for %d of these types; observed_json_data lists the keys their existing datasources use", unknown)
+	}
+	return jsonResult(result)
+}
+
+func countUnknownSchemas(types []datasourceType) int {
+	n := 0
+	for _, t := range types {
+		if !t.SchemaKnown {
+			n++
+		}
+	}
+	return n
+}
+
+// describeMissingSettings formats the error for a create call that lacks
+// required settings
+func describeMissingSettings(dsType string, missing []string) string {
+	return fmt.Sprintf("%s datasources need %s; see grafana_list_datasource_types for their settings", dsType, strings.Join(missing, ", "))
+}
@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaExploreLinkTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_explore_link",
+		Description: "Build a ready-to-open Grafana Explore URL for a datasource, queries, and time range, optionally with a second split pane",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":        {Type: "string", Description: "Datasource UID for the left pane"},
+				"datasource_type":       {Type: "string", Description: "Datasource type for the left pane (e.g., prometheus, loki)"},
+				"query":                 {Type: "string", Description: "Query expression for the left pane"},
+				"queries":               {Type: "array", Description: "Full query models for the left pane (overrides query)"},
+				"from":                  {Type: "string", Description: "Time range from (default now-1h)"},
+				"to":                    {Type: "string", Description: "Time range to (default now)"},
+				"right_datasource_uid":  {Type: "string", Description: "Datasource UID for a split right pane"},
+				"right_datasource_type": {Type: "string", Description: "Datasource type for the right pane"},
+				"right_query":           {Type: "string", Description: "Query expression for the right pane"},
+				"right_queries":         {Type: "array", Description: "Full query models for the right pane (overrides right_query)"},
+				"legacy":                {Type: "boolean", Description: "Use the left/right URL format understood by Grafana versions before 10"},
+			},
+			Required: []string{"datasource_uid", "datasource_type"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleExploreLink(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	dsType := getString(args, "datasource_type")
+	if dsUID == "" || dsType == "" {
+		return errorResult("datasource_uid and datasource_type are required"), nil
+	}
+
+	from := getString(args, "from")
+	to := getString(args, "to")
+	if from == "" {
+		from = "now-1h"
+	}
+	if to == "" {
+		to = "now"
+	}
+
+	panes := []grafana.ExplorePane{
+		explorePane(dsUID, dsType, getString(args, "query"), getMapSlice(args, "queries"), from, to),
+	}
+	if rightUID := getString(args, "right_datasource_uid"); rightUID != "" {
+		rightType := getString(args, "right_datasource_type")
+		if rightType == "" {
+			return errorResult("right_datasource_type is required with right_datasource_uid"), nil
+		}
+		panes = append(panes, explorePane(rightUID, rightType, getString(args, "right_query"), getMapSlice(args, "right_queries"), from, to))
+	}
+
+	link, err := r.client.ExploreURL(panes, getBool(args, "legacy"))
+	if err != nil {
+		return apiErrorResult("Failed to build explore link", err), nil
+	}
+	return jsonResult(map[string]interface{}{"url": link, "panes": len(panes)})
+}
+
+// explorePane builds a pane from either full query models or a single expression
+func explorePane(uid, dsType, query string, queries []map[string]interface{}, from, to string) grafana.ExplorePane {
+	if len(queries) == 0 && query != "" {
+		queries = []map[string]interface{}{{grafana.QueryField(dsType): query}}
+	}
+	return grafana.ExplorePane{
+		DatasourceUID:  uid,
+		DatasourceType: dsType,
+		Queries:        queries,
+		From:           from,
+		To:             to,
+	}
+}
@@ -0,0 +1,289 @@
+package tools
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/npcomplete777/grafana-mcp/internal/export"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const exportSearchLimit = 5000
+
+func (r *Registry) grafanaExportProvisioningTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_export_provisioning",
+		Description: "Write selected dashboards to a local directory in Grafana file-provisioning layout (one folder per Grafana folder plus a dashboard provider YAML), to bootstrap a GitOps repository. Files are written only within the configured export directory (files.export_dir)",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"output_dir":       {Type: "string", Description: "Directory to write into, relative to the export directory; absolute paths must lie within it"},
+				"uids":             {Type: "array", Description: "Dashboard UIDs to export (default: all matching the filters)"},
+				"folder_uid":       {Type: "string", Description: "Only export dashboards in this folder"},
+				"query":            {Type: "string", Description: "Only export dashboards matching this search query"},
+				"tags":             {Type: "array", Description: "Only export dashboards with these tags"},
+				"provider_name":    {Type: "string", Description: "Dashboard provider name (default grafana-mcp)"},
+				"dashboards_path":  {Type: "string", Description: "Path where Grafana will read the dashboards (default /var/lib/grafana/dashboards)"},
+				"allow_ui_updates": {Type: "boolean", Description: "Allow edits in the Grafana UI to provisioned dashboards"},
+			},
+			Required: []string{"output_dir"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleExportProvisioning(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dir := getString(args, "output_dir")
+	if dir == "" {
+		return errorResult("output_dir is required"), nil
+	}
+	dir, err := r.exportPath(dir)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	dashboards, failed, err := r.collectDashboards(args)
+	if err != nil {
+		return apiErrorResult("Failed to collect dashboards", err), nil
+	}
+	if len(dashboards) == 0 {
+		return errorResult("no dashboards matched the selection"), nil
+	}
+
+	files, err := export.WriteProvisioning(dir, dashboards, export.ProviderOptions{
+		Name:           getString(args, "provider_name"),
+		DashboardsPath: getString(args, "dashboards_path"),
+		AllowUIUpdates: getBool(args, "allow_ui_updates"),
+	})
+	if err != nil {
+		return apiErrorResult("Failed to write provisioning files", err), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"output_dir": dir,
+		"dashboards": len(dashboards),
+		"files":      files,
+		"failed":     failed,
+	})
+}
+
+// collectDashboards resolves the uids/folder_uid/query/tags selection
+// arguments into full dashboard models. Dashboards that cannot be fetched are
+// reported in failed, keyed by UID, rather than aborting the whole export.
+func (r *Registry) collectDashboards(args map[string]interface{}) ([]export.Dashboard, map[string]string, error) {
+	uids := getStringSlice(args, "uids")
+	if len(uids) == 0 {
+		q := grafana.SearchQuery{
+			Type:  "dash-db",
+			Query: getString(args, "query"),
+			Tags:  getStringSlice(args, "tags"),
+			Limit: exportSearchLimit,
+		}
+		if folderUID := getString(args, "folder_uid"); folderUID != "" {
+			q.FolderUIDs = []string{folderUID}
+		}
+		hits, err := r.client.Search(r.ctx, q)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, h := range hits {
+			uids = append(uids, h.UID)
+		}
+	}
+
+	failed := map[string]string{}
+	dashboards := make([]export.Dashboard, 0, len(uids))
+	for _, uid := range uids {
+		dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+		if err != nil {
+			failed[uid] = err.Error()
+			continue
+		}
+		title, _ := dash.Dashboard["title"].(string)
+		dashboards = append(dashboards, export.Dashboard{
+			UID:         uid,
+			Title:       title,
+			FolderUID:   dash.Meta.FolderUID,
+			FolderTitle: dash.Meta.FolderTitle,
+			Model:       dash.Dashboard,
+		})
+	}
+	return dashboards, failed, nil
+}
+
+func (r *Registry) grafanaExportIaCTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_export_iac",
+		Description: "Export folders, dashboards, datasources, and alert rules as Terraform grafana-provider HCL or Grizzly resource YAML, either returned inline or written to a directory within the configured export directory (files.export_dir)",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"format":     {Type: "string", Description: "Output format", Enum: []string{"terraform", "grizzly"}},
+				"resources":  {Type: "array", Description: "Resource kinds to include: folders, dashboards, datasources, alert_rules (default all)"},
+				"output_dir": {Type: "string", Description: "Directory to write files into, relative to the export directory (default: return content inline)"},
+				"uids":       {Type: "array", Description: "Dashboard UIDs to export (default: all matching the filters)"},
+				"folder_uid": {Type: "string", Description: "Only export dashboards in this folder"},
+				"query":      {Type: "string", Description: "Only export dashboards matching this search query"},
+				"tags":       {Type: "array", Description: "Only export dashboards with these tags"},
+			},
+			Required: []string{"format"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleExportIaC(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	format := getString(args, "format")
+	if format != "terraform" && format != "grizzly" {
+		return errorResult("format must be terraform or grizzly"), nil
+	}
+	dir := getString(args, "output_dir")
+	if dir != "" {
+		var err error
+		if dir, err = r.exportPath(dir); err != nil {
+			return errorResultFor(err), nil
+		}
+	}
+
+	var notes []string
+	kinds := map[string]bool{"folders": true, "dashboards": true, "datasources": true, "alert_rules": true}
+	if requested := getStringSlice(args, "resources"); len(requested) > 0 {
+		kinds = map[string]bool{}
+		for _, k := range requested {
+			kinds[k] = true
+		}
+		if kinds["alert_rules"] && !r.supports(alertingProvisioningVersion) {
+			return errorResult(fmt.Sprintf("exporting alert_rules requires Grafana >= %s (connected to %s)", alertingProvisioningVersion, r.version)), nil
+		}
+	} else if !r.supports(alertingProvisioningVersion) {
+		delete(kinds, "alert_rules")
+		notes = append(notes, fmt.Sprintf("alert_rules skipped: requires Grafana >= %s", alertingProvisioningVersion))
+	}
+
+	var res export.Resources
+	failed := map[string]string{}
+	if kinds["folders"] {
+		folders, err := r.client.GetFolders(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to list folders", err), nil
+		}
+		res.Folders = folders
+	}
+	if kinds["datasources"] {
+		datasources, err := r.client.GetDatasources(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to list datasources", err), nil
+		}
+		res.Datasources = datasources
+	}
+	if kinds["dashboards"] {
+		dashboards, dashFailed, err := r.collectDashboards(args)
+		if err != nil {
+			return apiErrorResult("Failed to collect dashboards", err), nil
+		}
+		res.Dashboards = dashboards
+		for uid, msg := range dashFailed {
+			failed["dashboard/"+uid] = msg
+		}
+	}
+	if kinds["alert_rules"] {
+		groups, err := r.collectRuleGroups()
+		if err != nil {
+			return apiErrorResult("Failed to list alert rules", err), nil
+		}
+		res.RuleGroups = groups
+	}
+
+	var (
+		files map[string][]byte
+		err   error
+	)
+	if format == "terraform" {
+		files, err = export.Terraform(res, dir != "")
+	} else {
+		files, err = export.Grizzly(res)
+	}
+	if err != nil {
+		return apiErrorResult(fmt.Sprintf("Failed to render %s", format), err), nil
+	}
+
+	summary := map[string]interface{}{
+		"format":      format,
+		"folders":     len(res.Folders),
+		"dashboards":  len(res.Dashboards),
+		"datasources": len(res.Datasources),
+		"rule_groups": len(res.RuleGroups),
+		"failed":      failed,
+	}
+	if len(notes) > 0 {
+		summary["notes"] = notes
+	}
+	if dir == "" {
+		content := make(map[string]string, len(files))
+		for path, data := range files {
+			content[path] = string(data)
+		}
+		summary["files"] = content
+		return jsonResult(summary)
+	}
+
+	written, err := writeFiles(dir, files)
+	if err != nil {
+		return apiErrorResult("Failed to write files", err), nil
+	}
+	summary["output_dir"] = dir
+	summary["files"] = written
+	return jsonResult(summary)
+}
+
+// collectRuleGroups groups all alert rules by folder and rule group and looks
+// up each group's evaluation interval.
+func (r *Registry) collectRuleGroups() ([]grafana.RuleGroup, error) {
+	rules, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []grafana.RuleGroup
+	index := map[string]int{}
+	for _, rule := range rules {
+		key := rule.FolderUID + "/" + rule.RuleGroup
+		i, ok := index[key]
+		if !ok {
+			g := grafana.RuleGroup{Title: rule.RuleGroup, FolderUID: rule.FolderUID, Interval: 60}
+			if full, err := r.client.GetRuleGroup(r.ctx, rule.FolderUID, rule.RuleGroup); err == nil && full.Interval > 0 {
+				g.Interval = full.Interval
+			}
+			groups = append(groups, g)
+			i = len(groups) - 1
+			index[key] = i
+		}
+		groups[i].Rules = append(groups[i].Rules, rule)
+	}
+	return groups, nil
+}
+
+// writeFiles writes files keyed by relative path under dir and returns the
+// sorted list of absolute paths written.
+func writeFiles(dir string, files map[string][]byte) ([]string, error) {
+	written := make([]string, 0, len(files))
+	for rel, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return written, err
+		}
+		written = append(written, path)
+	}
+	sort.Strings(written)
+	return written, nil
+}
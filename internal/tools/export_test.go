@@ -0,0 +1,82 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+func TestExportProvisioningStaysInExportDir(t *testing.T) {
+	root := t.TempDir()
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithExportDir(root)))
+	h.AddDashboard(map[string]interface{}{"uid": "checkout", "title": "Checkout"}, "")
+
+	var out struct {
+		OutputDir string   `json:"output_dir"`
+		Files     []string `json:"files"`
+	}
+	h.Call("grafana_export_provisioning", map[string]interface{}{"output_dir": "nightly"}).OK().JSON(&out)
+	if len(out.Files) == 0 {
+		t.Fatal("no files written")
+	}
+	for _, f := range out.Files {
+		if _, err := os.Stat(f); err != nil {
+			t.Errorf("reported file %s: %v", f, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(root, "nightly", "dashboards", "checkout.json")); err != nil {
+		t.Fatalf("dashboard not written under the export directory: %v", err)
+	}
+
+	for _, dir := range []string{"../elsewhere", t.TempDir(), "/etc"} {
+		h.Call("grafana_export_provisioning", map[string]interface{}{"output_dir": dir}).Error("outside")
+	}
+}
+
+func TestExportProvisioningNeedsExportDir(t *testing.T) {
+	h := testkit.New(t)
+	h.AddDashboard(map[string]interface{}{"uid": "checkout", "title": "Checkout"}, "")
+	h.Call("grafana_export_provisioning", map[string]interface{}{"output_dir": t.TempDir()}).Error("files.export_dir")
+}
+
+func TestExportIaCStaysInExportDir(t *testing.T) {
+	root := t.TempDir()
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithExportDir(root)))
+	h.AddDashboard(map[string]interface{}{"uid": "checkout", "title": "Checkout"}, "")
+	args := func(dir string) map[string]interface{} {
+		return map[string]interface{}{"format": "grizzly", "resources": []interface{}{"dashboards"}, "output_dir": dir}
+	}
+
+	var out struct {
+		Files []string `json:"files"`
+	}
+	h.Call("grafana_export_iac", args("iac")).OK().JSON(&out)
+	if len(out.Files) == 0 {
+		t.Fatal("no files written")
+	}
+	for _, f := range out.Files {
+		if rel, err := filepath.Rel(root, f); err != nil || strings.HasPrefix(rel, "..") {
+			t.Errorf("file %s written outside %s", f, root)
+		}
+	}
+
+	h.Call("grafana_export_iac", args("../../tmp/iac")).Error("outside")
+	h.Call("grafana_export_iac", args("/etc/grafana")).Error("outside")
+}
+
+func TestExportIaCInlineNeedsNoExportDir(t *testing.T) {
+	h := testkit.New(t)
+	h.AddDashboard(map[string]interface{}{"uid": "checkout", "title": "Checkout"}, "")
+	var out struct {
+		Files map[string]string `json:"files"`
+	}
+	h.Call("grafana_export_iac", map[string]interface{}{"format": "terraform", "resources": []interface{}{"dashboards"}}).OK().JSON(&out)
+	if len(out.Files) == 0 {
+		t.Fatal("no inline content returned")
+	}
+	h.Call("grafana_export_iac", map[string]interface{}{"format": "terraform", "output_dir": "iac"}).Error("files.export_dir")
+}
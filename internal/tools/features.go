@@ -0,0 +1,57 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+)
+
+// toolFeatures maps tools to the Grafana features (feature toggles or
+// frontend settings) they depend on. A "!" prefix means the feature must be
+// off. Features the instance does not report are assumed to be fine.
+var toolFeatures = map[string][]string{
+	"grafana_list_alert_rules":        {"unifiedAlertingEnabled"},
+	"grafana_get_alert_rule":          {"unifiedAlertingEnabled"},
+	"grafana_create_alert_rule":       {"unifiedAlertingEnabled"},
+	"grafana_update_alert_rule":       {"unifiedAlertingEnabled"},
+	"grafana_delete_alert_rule":       {"unifiedAlertingEnabled"},
+	"grafana_bulk_edit_alert_rules":   {"unifiedAlertingEnabled"},
+	"grafana_list_contact_points":     {"unifiedAlertingEnabled"},
+	"grafana_get_contact_point":       {"unifiedAlertingEnabled"},
+	"grafana_create_contact_point":    {"unifiedAlertingEnabled"},
+	"grafana_update_contact_point":    {"unifiedAlertingEnabled"},
+	"grafana_delete_contact_point":    {"unifiedAlertingEnabled"},
+	"grafana_test_contact_point":      {"unifiedAlertingEnabled"},
+	"grafana_preview_alert_routing":   {"unifiedAlertingEnabled"},
+	"grafana_contact_point_inventory": {"unifiedAlertingEnabled"},
+	// The noise report reads state history from annotations, which are not
+	// written when history is kept only in Loki
+	"grafana_alert_noise_report": {"unifiedAlertingEnabled", "!alertStateHistoryLokiOnly"},
+}
+
+// WithFeatures hides tools whose required features are disabled on the
+// instance. features holds feature toggles and boolean frontend settings,
+// as returned by grafana.FrontendSettings.Features.
+func WithFeatures(features map[string]bool) Option {
+	return func(r *Registry) {
+		r.features = features
+	}
+}
+
+// missingFeature explains which required feature is unavailable for a
+// tool, or returns "" if none is
+func (r *Registry) missingFeature(name string) string {
+	for _, req := range toolFeatures[name] {
+		feature := strings.TrimPrefix(req, "!")
+		enabled, known := r.features[feature]
+		if !known {
+			continue
+		}
+		if req[0] == '!' && enabled {
+			return fmt.Sprintf("%s is unavailable because %s is enabled on this Grafana instance", name, feature)
+		}
+		if req[0] != '!' && !enabled {
+			return fmt.Sprintf("%s requires %s, which is disabled on this Grafana instance", name, feature)
+		}
+	}
+	return ""
+}
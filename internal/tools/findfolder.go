@@ -0,0 +1,285 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// minFolderScore is the lowest fuzzy score reported as a match
+const minFolderScore = 0.5
+
+func (r *Registry) grafanaFindFolderTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_find_folder",
+		Description: "Find folders by title. Exact and case-insensitive matches rank first, then prefix, substring, and fuzzy (typo-tolerant) matches, each with a score",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"title":   {Type: "string", Description: "Folder title or part of it, e.g. Payments"},
+				"exact":   {Type: "boolean", Description: "Only return folders whose title matches exactly, ignoring case"},
+				"limit":   {Type: "integer", Description: "Maximum number of matches (default 5)"},
+				"refresh": {Type: "boolean", Description: "Fetch live instead of answering from the prefetched inventory or cached responses"},
+			},
+			Required: []string{"title"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// folderMatch is a folder that matched a title lookup
+type folderMatch struct {
+	UID   string  `json:"uid"`
+	Title string  `json:"title"`
+	URL   string  `json:"url,omitempty"`
+	Match string  `json:"match"`
+	Score float64 `json:"score"`
+}
+
+func (r *Registry) handleFindFolder(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	title := strings.TrimSpace(getString(args, "title"))
+	if title == "" {
+		return errorResult("title is required"), nil
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = 5
+	}
+
+	folders, err := r.folderList(getBool(args, "refresh"))
+	if err != nil {
+		return apiErrorResult("Failed to list folders", err), nil
+	}
+	matches := matchFolders(folders, title)
+	if getBool(args, "exact") {
+		exact := matches[:0]
+		for _, m := range matches {
+			if m.Match == "exact" || m.Match == "case_insensitive" {
+				exact = append(exact, m)
+			}
+		}
+		matches = exact
+	}
+	if len(matches) > limit {
+		matches = matches[:limit]
+	}
+	return jsonResult(map[string]interface{}{
+		"title":   title,
+		"matches": matches,
+		"count":   len(matches),
+	})
+}
+
+// folderList returns every folder, from the inventory unless refresh is set
+func (r *Registry) folderList(refresh bool) ([]grafana.Folder, error) {
+	if !refresh {
+		if folders, _, ok := r.prefetchedFolders(); ok {
+			return folders, nil
+		}
+	}
+	if refresh {
+		return r.client.GetFolders(grafana.Uncached(r.ctx))
+	}
+	return r.client.GetFolders(r.ctx)
+}
+
+// matchFolders ranks folders by how well their title matches, best first,
+// dropping those below minFolderScore
+func matchFolders(folders []grafana.Folder, title string) []folderMatch {
+	want := normalizeTitle(title)
+	out := []folderMatch{}
+	for _, f := range folders {
+		got := normalizeTitle(f.Title)
+		m := folderMatch{UID: f.UID, Title: f.Title, URL: f.URL}
+		switch {
+		case f.Title == title:
+			m.Match, m.Score = "exact", 1
+		case strings.EqualFold(f.Title, title):
+			m.Match, m.Score = "case_insensitive", 0.95
+		case got == want:
+			m.Match, m.Score = "normalized", 0.9
+		case want != "" && strings.HasPrefix(got, want):
+			m.Match, m.Score = "prefix", 0.8
+		case want != "" && strings.Contains(got, want):
+			m.Match, m.Score = "contains", 0.7
+		default:
+			m.Match, m.Score = "fuzzy", 0.7*titleSimilarity(got, want)
+		}
+		if m.Score >= minFolderScore {
+			m.Score = float64(int(m.Score*100+0.5)) / 100
+			out = append(out, m)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return out[i].Title < out[j].Title
+	})
+	return out
+}
+
+// normalizeTitle lowercases a title and keeps only letters and digits, so
+// "Payments / Prod" and "payments-prod" compare equal
+func normalizeTitle(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// titleSimilarity is 1 minus the edit distance relative to the longer title
+func titleSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 0
+	}
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+// folderTitleArgs returns the folder uid arguments of a tool's schema that
+// get a folder_title twin
+func folderTitleArgs(t mcp.Tool) []string {
+	var out []string
+	for key := range t.InputSchema.Properties {
+		if key != "folder_uid" && !strings.HasSuffix(key, "_folder_uid") {
+			continue
+		}
+		if _, taken := t.InputSchema.Properties[folderTitleKey(key)]; taken {
+			continue
+		}
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// folderTitleKey is the title twin of a folder uid argument, e.g.
+// target_folder_uid -> target_folder_title
+func folderTitleKey(uidKey string) string {
+	return strings.TrimSuffix(uidKey, "_uid") + "_title"
+}
+
+// withFolderTitleArgs adds the folder_title twins to a tool's schema. A
+// folder uid that was required becomes optional, since its title may be
+// given instead.
+func withFolderTitleArgs(t mcp.Tool) mcp.Tool {
+	keys := folderTitleArgs(t)
+	if len(keys) == 0 {
+		return t
+	}
+	props := make(map[string]mcp.Property, len(t.InputSchema.Properties)+len(keys))
+	for k, v := range t.InputSchema.Properties {
+		props[k] = v
+	}
+	for _, key := range keys {
+		props[folderTitleKey(key)] = mcp.Property{Type: "string", Description: fmt.Sprintf("Folder title, resolved to a uid; alternative to %s", key)}
+	}
+	var required []string
+	for _, name := range t.InputSchema.Required {
+		if !contains(keys, name) {
+			required = append(required, name)
+		}
+	}
+	t.InputSchema.Properties = props
+	t.InputSchema.Required = required
+	return t
+}
+
+// resolveFolderTitleArgs replaces folder titles given for a tool with their
+// uids. Only exact, case-insensitive matches resolve; anything else is an
+// error that lists the closest folders, so a typo never picks a folder.
+func (r *Registry) resolveFolderTitleArgs(tool string, args map[string]interface{}) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	for _, key := range r.folderTitles[tool] {
+		titleKey := folderTitleKey(key)
+		title := strings.TrimSpace(getString(args, titleKey))
+		if title == "" || getString(args, key) != "" {
+			continue
+		}
+		uid, err := r.folderUIDForTitle(titleKey, title)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = make(map[string]interface{}, len(args))
+			for k, v := range args {
+				out[k] = v
+			}
+		}
+		out[key] = uid
+		delete(out, titleKey)
+	}
+	if out == nil {
+		return args, nil
+	}
+	return out, nil
+}
+
+// folderUIDForTitle looks up the uid of the one folder with a title. The
+// General folder, which has no uid, resolves to "".
+func (r *Registry) folderUIDForTitle(key, title string) (string, error) {
+	folders, err := r.folderList(false)
+	if err != nil {
+		return "", fmt.Errorf("could not resolve %s %q: %v", key, title, err)
+	}
+	var exact []folderMatch
+	matches := matchFolders(folders, title)
+	for _, m := range matches {
+		if m.Match == "exact" || m.Match == "case_insensitive" {
+			exact = append(exact, m)
+		}
+	}
+	switch {
+	case len(exact) == 1:
+		return exact[0].UID, nil
+	case len(exact) > 1:
+		return "", fmt.Errorf("%s %q matches %d folders (%s); pass the folder uid instead", key, title, len(exact), describeFolderMatches(exact))
+	case strings.EqualFold(title, "General"):
+		return "", nil
+	case len(matches) > 0:
+		if len(matches) > 3 {
+			matches = matches[:3]
+		}
+		return "", fmt.Errorf("no folder titled %q; did you mean %s?", title, describeFolderMatches(matches))
+	}
+	return "", fmt.Errorf("no folder titled %q", title)
+}
+
+func describeFolderMatches(matches []folderMatch) string {
+	parts := make([]string, len(matches))
+	for i, m := range matches {
+		parts[i] = fmt.Sprintf("%q uid %s", m.Title, m.UID)
+	}
+	return strings.Join(parts, ", ")
+}
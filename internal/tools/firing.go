@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+const (
+	// firingAlertsURI is the resource of alerts currently firing
+	firingAlertsURI = "grafana://alerts/firing"
+	// defaultFiringAlertsInterval is how often firing alerts are polled
+	// while a client is subscribed, when no interval is configured
+	defaultFiringAlertsInterval = 30 * time.Second
+)
+
+// ErrNotSubscribable is returned by SubscribeResource for a resource that
+// is served but never announces updates
+var ErrNotSubscribable = errors.New("resource does not support subscriptions")
+
+// WithFiringAlertsInterval sets how often firing alerts are polled while a
+// client is subscribed to grafana://alerts/firing
+func WithFiringAlertsInterval(every time.Duration) Option {
+	return func(r *Registry) {
+		if every > 0 {
+			r.firing.interval = every
+		}
+	}
+}
+
+// firingWatch polls the firing alerts while any client is subscribed to
+// them, and tells the subscribers when the set of alerts changes
+type firingWatch struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	nextID int
+	subs   map[int]func(uri string)
+	// digest identifies the alerts of the last successful poll, "" before
+	// the first
+	digest string
+	stop   chan struct{}
+}
+
+func newFiringWatch() *firingWatch {
+	return &firingWatch{interval: defaultFiringAlertsInterval, subs: map[int]func(string){}}
+}
+
+// firingAlert is an alert listed in the firing alerts resource
+type firingAlert struct {
+	AlertName   string            `json:"alertname"`
+	Rule        *Ref              `json:"rule,omitempty"`
+	Folder      string            `json:"folder,omitempty"`
+	Severity    string            `json:"severity,omitempty"`
+	Summary     string            `json:"summary,omitempty"`
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	StartsAt    string            `json:"starts_at"`
+	// FiringFor is how long the alert has been firing, e.g. 2h5m
+	FiringFor    string   `json:"firing_for"`
+	Receivers    []string `json:"receivers,omitempty"`
+	GeneratorURL string   `json:"generator_url,omitempty"`
+}
+
+// SubscribeResource calls updated with uri whenever the resource at uri
+// changes, until the returned function is called. Only
+// grafana://alerts/firing announces updates: it is polled while anyone is
+// subscribed, and updated is called when alerts start or stop firing.
+func (r *Registry) SubscribeResource(uri string, updated func(uri string)) (func(), error) {
+	served := false
+	for _, def := range r.allResources() {
+		if def.resource.URI == uri && r.resourceEnabled(def) {
+			served = true
+		}
+	}
+	for _, obj := range r.allObjectResources() {
+		if strings.HasPrefix(uri, obj.prefix) && r.objectResourceEnabled(obj) {
+			served = true
+		}
+	}
+	if !served {
+		return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+	}
+	if uri != firingAlertsURI {
+		return nil, fmt.Errorf("%w: %s", ErrNotSubscribable, uri)
+	}
+
+	w := r.firing
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	id := w.nextID
+	w.nextID++
+	w.subs[id] = updated
+	if w.stop == nil {
+		w.stop = make(chan struct{})
+		go r.watchFiringAlerts(w, w.stop)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			w.mu.Lock()
+			defer w.mu.Unlock()
+			delete(w.subs, id)
+			if len(w.subs) == 0 && w.stop != nil {
+				close(w.stop)
+				w.stop = nil
+			}
+		})
+	}, nil
+}
+
+// watchFiringAlerts polls the firing alerts every interval until stop is
+// closed. The first poll only records the alerts, which subscribers read
+// for themselves.
+func (r *Registry) watchFiringAlerts(w *firingWatch, stop chan struct{}) {
+	t := time.NewTicker(w.interval)
+	defer t.Stop()
+	for {
+		digest, err := r.firingDigest()
+		if err != nil {
+			log.Printf("Warning: could not poll firing alerts: %v", err)
+		}
+
+		w.mu.Lock()
+		var notify []func(string)
+		if err == nil {
+			if w.digest != "" && digest != w.digest {
+				for _, f := range w.subs {
+					notify = append(notify, f)
+				}
+			}
+			w.digest = digest
+		}
+		w.mu.Unlock()
+		for _, f := range notify {
+			f(firingAlertsURI)
+		}
+
+		select {
+		case <-stop:
+			w.mu.Lock()
+			if w.stop == nil {
+				// Forget the alerts seen, so a later subscription is not
+				// told about changes made while nobody watched
+				w.digest = ""
+			}
+			w.mu.Unlock()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+// firingDigest identifies the alerts firing now by their fingerprints and
+// start times, so a change of annotations alone does not count as an update
+func (r *Registry) firingDigest() (string, error) {
+	alerts, err := r.client.GetFiringAlerts(context.Background())
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(alerts))
+	for _, a := range alerts {
+		keys = append(keys, a.Fingerprint+"@"+a.StartsAt.UTC().Format(time.RFC3339))
+	}
+	sort.Strings(keys)
+	return "firing:" + strings.Join(keys, ","), nil
+}
+
+// readFiringAlerts lists the alerts firing now, longest firing first,
+// leaving out silenced and inhibited ones
+func (r *Registry) readFiringAlerts() (interface{}, error) {
+	alerts, err := r.client.GetFiringAlerts(r.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list firing alerts: %w", err)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		if !alerts[i].StartsAt.Equal(alerts[j].StartsAt) {
+			return alerts[i].StartsAt.Before(alerts[j].StartsAt)
+		}
+		return alerts[i].Fingerprint < alerts[j].Fingerprint
+	})
+
+	now := time.Now()
+	firing := make([]firingAlert, 0, len(alerts))
+	bySeverity := map[string]int{}
+	for _, a := range alerts {
+		out := r.newFiringAlert(a, now)
+		firing = append(firing, out)
+		if out.Severity != "" {
+			bySeverity[out.Severity]++
+		}
+	}
+	return map[string]interface{}{
+		"count":       len(firing),
+		"by_severity": bySeverity,
+		"alerts":      firing,
+		"fetched_at":  r.formatMillis(now.UnixMilli()),
+	}, nil
+}
+
+func (r *Registry) newFiringAlert(a grafana.AlertmanagerAlert, now time.Time) firingAlert {
+	labels := make(map[string]string, len(a.Labels))
+	for k, v := range a.Labels {
+		// Leave out Grafana's internal labels such as __alert_rule_uid__
+		if !strings.HasPrefix(k, "__") {
+			labels[k] = v
+		}
+	}
+	out := firingAlert{
+		AlertName:    a.Labels["alertname"],
+		Folder:       a.Labels["grafana_folder"],
+		Severity:     a.Labels["severity"],
+		Summary:      a.Annotations["summary"],
+		Labels:       labels,
+		Annotations:  a.Annotations,
+		StartsAt:     r.formatMillis(a.StartsAt.UnixMilli()),
+		FiringFor:    now.Sub(a.StartsAt).Truncate(time.Second).String(),
+		GeneratorURL: a.GeneratorURL,
+	}
+	if uid := a.Labels["__alert_rule_uid__"]; uid != "" {
+		out.Rule = &Ref{Type: refAlertRule, UID: uid, URL: r.client.BaseURL() + refURL(refAlertRule, uid)}
+	}
+	for _, recv := range a.Receivers {
+		out.Receivers = append(out.Receivers, recv.Name)
+	}
+	return out
+}
@@ -0,0 +1,193 @@
+package tools
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const maxForecastSeries = 10
+
+func (r *Registry) grafanaForecastTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_forecast",
+		Description: "Query a metric over a long range, fit a linear or Holt-Winters trend locally, and report when each series will cross a threshold (e.g. the date a disk fills up). Optionally records the projected crossing as an annotation",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":  {Type: "string", Description: "Datasource UID to query"},
+				"datasource_type": {Type: "string", Description: "Datasource type (default prometheus)"},
+				"query":           {Type: "string", Description: "Metric query, e.g. node_filesystem_avail_bytes{mountpoint=\"/\"}"},
+				"threshold":       {Type: "number", Description: "Value whose crossing is projected, e.g. 0 for bytes available"},
+				"from":            {Type: "string", Description: "Start of the history to fit (default now-30d)"},
+				"to":              {Type: "string", Description: "End of the history (default now)"},
+				"method":          {Type: "string", Description: "Trend model (default linear)", Enum: []string{"linear", "holt", "holt_winters"}},
+				"season":          {Type: "string", Description: "Season length for holt_winters (default 1d)"},
+				"horizon":         {Type: "string", Description: "How far ahead to look for a crossing (default 90d)"},
+				"max_data_points": {Type: "integer", Description: "Points per series (default 1000)"},
+				"annotate":        {Type: "boolean", Description: "Create an annotation at the earliest projected crossing"},
+				"dashboard_uid":   {Type: "string", Description: "Dashboard for the annotation (omit for an organization-wide annotation)"},
+				"panel_id":        {Type: "integer", Description: "Panel for the annotation"},
+			},
+			Required: []string{"datasource_uid", "query", "threshold"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// seriesForecast is the projection for one series
+type seriesForecast struct {
+	Series    string             `json:"series"`
+	Current   float64            `json:"current"`
+	Forecast  *analysis.Forecast `json:"forecast,omitempty"`
+	Crosses   bool               `json:"crosses"`
+	CrossesAt string             `json:"crosses_at,omitempty"`
+	DaysLeft  *float64           `json:"days_left,omitempty"`
+	Error     string             `json:"error,omitempty"`
+
+	crossing int64
+}
+
+func (r *Registry) handleForecast(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	query := getString(args, "query")
+	if _, ok := args["threshold"]; dsUID == "" || query == "" || !ok {
+		return errorResult("datasource_uid, query, and threshold are required"), nil
+	}
+	threshold := getFloat(args, "threshold")
+	dsType := getString(args, "datasource_type")
+	if dsType == "" {
+		dsType = "prometheus"
+	}
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-30d")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	method := getString(args, "method")
+	if method == "" {
+		method = "linear"
+	}
+	horizon := 90 * 24 * time.Hour
+	if s := getString(args, "horizon"); s != "" {
+		if horizon, err = parseGrafanaDuration(s); err != nil {
+			return errorResult(fmt.Sprintf("invalid horizon %q: %v", s, err)), nil
+		}
+	}
+	season := 24 * time.Hour
+	if s := getString(args, "season"); s != "" {
+		if season, err = parseGrafanaDuration(s); err != nil {
+			return errorResult(fmt.Sprintf("invalid season %q: %v", s, err)), nil
+		}
+	}
+	maxPoints := getInt(args, "max_data_points")
+	if maxPoints <= 0 {
+		maxPoints = 1000
+	}
+
+	resp, err := r.query(grafana.QueryRequest{
+		From: fmt.Sprintf("%d", start.UnixMilli()),
+		To:   fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: []grafana.QueryTarget{{
+			RefID:         "A",
+			Datasource:    grafana.DatasourceRef{Type: dsType, UID: dsUID},
+			Query:         query,
+			MaxDataPoints: maxPoints,
+		}},
+	})
+	if err != nil {
+		return apiErrorResult("Query failed", err), nil
+	}
+	res := resp.Results["A"]
+	if res.Error != "" {
+		return errorResult(fmt.Sprintf("Query failed: %s", res.Error)), nil
+	}
+	series := analysis.FramesToSeries(res.Frames)
+	if len(series) == 0 {
+		return errorResult("query returned no numeric series"), nil
+	}
+	truncated := len(series) > maxForecastSeries
+	if truncated {
+		series = series[:maxForecastSeries]
+	}
+
+	limit := end.Add(horizon).UnixMilli()
+	results := make([]seriesForecast, 0, len(series))
+	var earliest *seriesForecast
+	for _, s := range series {
+		sf := seriesForecast{Series: s.Name, Current: s.Values[len(s.Values)-1]}
+		var f *analysis.Forecast
+		switch method {
+		case "holt":
+			f, err = analysis.HoltWintersForecast(s.Times, s.Values, 0, 0.5, 0.1, 0)
+		case "holt_winters":
+			points := 0
+			if step := seriesStep(s.Times); step > 0 {
+				points = int(season.Milliseconds() / step)
+			}
+			f, err = analysis.HoltWintersForecast(s.Times, s.Values, points, 0.5, 0.1, 0.1)
+		default:
+			f, err = analysis.LinearForecast(s.Times, s.Values)
+		}
+		if err != nil {
+			sf.Error = err.Error()
+			results = append(results, sf)
+			continue
+		}
+		sf.Forecast = f
+		if t, ok := f.Crossing(threshold, limit); ok {
+			days := float64(t-end.UnixMilli()) / float64(24*time.Hour/time.Millisecond)
+			sf.Crosses, sf.crossing, sf.DaysLeft = true, t, &days
+			sf.CrossesAt = time.UnixMilli(t).UTC().Format(time.RFC3339)
+		}
+		results = append(results, sf)
+		if sf.Crosses && (earliest == nil || sf.crossing < earliest.crossing) {
+			earliest = &results[len(results)-1]
+		}
+	}
+
+	result := map[string]interface{}{
+		"method":    method,
+		"threshold": threshold,
+		"from":      start.UTC().Format(time.RFC3339),
+		"to":        end.UTC().Format(time.RFC3339),
+		"horizon":   end.Add(horizon).UTC().Format(time.RFC3339),
+		"series":    results,
+	}
+	if truncated {
+		result["note"] = fmt.Sprintf("only the first %d series were forecast", maxForecastSeries)
+	}
+	if !getBool(args, "annotate") {
+		return jsonResult(result)
+	}
+	if earliest == nil {
+		result["annotation"] = "not created: no series crosses the threshold within the horizon"
+		return jsonResult(result)
+	}
+	ann, err := r.client.CreateAnnotation(r.ctx, grafana.Annotation{
+		DashboardUID: getString(args, "dashboard_uid"),
+		PanelID:      getInt64(args, "panel_id"),
+		Time:         earliest.crossing,
+		Tags:         []string{"forecast"},
+		Text:         fmt.Sprintf("Forecast: %s crosses %g (%s trend fitted %s to %s)", earliest.Series, threshold, method, start.UTC().Format("2006-01-02"), end.UTC().Format("2006-01-02")),
+	})
+	if err != nil {
+		result["annotation"] = fmt.Sprintf("Failed to create annotation: %v", err)
+		return jsonResult(result)
+	}
+	result["annotation"] = ann
+	return jsonResult(result)
+}
+
+// seriesStep returns the typical spacing of a series in milliseconds
+func seriesStep(times []int64) int64 {
+	if len(times) < 2 {
+		return 0
+	}
+	return (times[len(times)-1] - times[0]) / int64(len(times)-1)
+}
@@ -0,0 +1,182 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const defaultMaxGeneratedPanels = 200
+
+// alertComparison splits "expr > 0.5" into the expression and its threshold
+var alertComparison = regexp.MustCompile(`^(?s)(.*?)\s*(>=|<=|>|<|==|!=)\s*([-+]?[0-9]*\.?[0-9]+(?:[eE][-+]?[0-9]+)?)\s*$`)
+
+func (r *Registry) grafanaGenerateDashboardFromRulesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_generate_dashboard_from_rules",
+		Description: "Build a dashboard from the recording and alerting rules loaded in a Prometheus-compatible datasource: one row per rule group, one panel per rule expression, with alert thresholds drawn where they can be parsed",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: "Prometheus, Mimir, Cortex, or Thanos datasource UID"},
+				"groups":         {Type: "array", Description: "Only include these rule group names"},
+				"rule_type":      {Type: "string", Description: "Rules to include (default all)", Enum: []string{"all", "recording", "alerting"}},
+				"title":          {Type: "string", Description: "Dashboard title (default 'Rules: <datasource name>')"},
+				"folder_uid":     {Type: "string", Description: "Folder to save the dashboard in"},
+				"max_panels":     {Type: "integer", Description: "Maximum number of rule panels (default 200)"},
+				"dry_run":        {Type: "boolean", Description: "Return the generated dashboard without saving"},
+			},
+			Required: []string{"datasource_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleGenerateDashboardFromRules(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	if dsUID == "" {
+		return errorResult("datasource_uid is required"), nil
+	}
+	ruleType := getString(args, "rule_type")
+	if ruleType == "" {
+		ruleType = "all"
+	}
+	maxPanels := getInt(args, "max_panels")
+	if maxPanels <= 0 {
+		maxPanels = defaultMaxGeneratedPanels
+	}
+	onlyGroups := map[string]bool{}
+	for _, g := range getStringSlice(args, "groups") {
+		onlyGroups[g] = true
+	}
+
+	ds, err := r.client.GetDatasource(r.ctx, dsUID)
+	if err != nil {
+		return apiErrorResult("Failed to get datasource", err), nil
+	}
+	groups, err := r.client.GetPrometheusRules(r.ctx, dsUID)
+	if err != nil {
+		return apiErrorResult("Failed to list rules", err), nil
+	}
+
+	title := getString(args, "title")
+	if title == "" {
+		title = "Rules: " + ds.Name
+	}
+	ref := dashboard.Ref(ds.Type, ds.UID)
+	b := dashboard.NewBuilder(title).Set("tags", []string{"generated", "rules"})
+
+	panels, truncated := 0, false
+	for _, g := range groups {
+		if len(onlyGroups) > 0 && !onlyGroups[g.Name] {
+			continue
+		}
+		rules := make([]grafana.PrometheusRule, 0, len(g.Rules))
+		for _, rule := range g.Rules {
+			if ruleType == "all" || rule.Type == ruleType {
+				rules = append(rules, rule)
+			}
+		}
+		if len(rules) == 0 {
+			continue
+		}
+		if panels+len(rules) > maxPanels {
+			rules = rules[:maxPanels-panels]
+			truncated = true
+		}
+		if len(rules) == 0 {
+			break
+		}
+
+		b.Row(g.Name)
+		for _, rule := range rules {
+			b.Panel(rulePanel(rule, ref), 12, 8)
+			panels++
+		}
+		if truncated {
+			break
+		}
+	}
+	if panels == 0 {
+		return errorResult("no rules matched the selection"), nil
+	}
+
+	model := b.Dashboard()
+	if getBool(args, "dry_run") {
+		return jsonResult(map[string]interface{}{
+			"dry_run":   true,
+			"panels":    panels,
+			"truncated": truncated,
+			"dashboard": model,
+		})
+	}
+
+	saved, err := r.client.SaveDashboardJSON(r.ctx, model, getString(args, "folder_uid"), "Generated from rules via MCP", false)
+	if err != nil {
+		return apiErrorResult("Failed to save dashboard", err), nil
+	}
+	return jsonResult(map[string]interface{}{
+		"panels":    panels,
+		"truncated": truncated,
+		"dashboard": saved,
+	})
+}
+
+// rulePanel charts a recording rule by its recorded series and an alerting
+// rule by its expression, with the comparison drawn as a threshold.
+func rulePanel(rule grafana.PrometheusRule, ds map[string]interface{}) map[string]interface{} {
+	if rule.Type == "recording" {
+		p := dashboard.TimeseriesPanel(rule.Name, ds, "", dashboard.Target("A", ds, rule.Name, ""))
+		p["description"] = "Recording rule: " + rule.Query
+		return p
+	}
+
+	expr := rule.Query
+	lhs, threshold, ok := splitComparison(rule.Query)
+	if ok {
+		expr = lhs
+	}
+	p := dashboard.TimeseriesPanel("Alert: "+rule.Name, ds, "", dashboard.Target("A", ds, expr, ""))
+	if ok {
+		dashboard.SetThreshold(p, threshold)
+	}
+
+	desc := []string{"Alerting rule: " + rule.Query}
+	if rule.Duration > 0 {
+		desc = append(desc, fmt.Sprintf("for: %gs", rule.Duration))
+	}
+	if s := rule.Annotations["summary"]; s != "" {
+		desc = append(desc, s)
+	}
+	p["description"] = strings.Join(desc, "\n\n")
+	return p
+}
+
+// splitComparison splits an alert expression of the form "<expr> > 0.5" into
+// the expression and the threshold. Expressions combining several
+// comparisons with and/or/unless are not split.
+func splitComparison(query string) (string, float64, bool) {
+	m := alertComparison.FindStringSubmatch(query)
+	if m == nil || strings.Count(m[1], "(") != strings.Count(m[1], ")") {
+		return "", 0, false
+	}
+	lower := strings.ToLower(m[1])
+	for _, op := range []string{" and ", " or ", " unless "} {
+		if strings.Contains(lower, op) {
+			return "", 0, false
+		}
+	}
+	v, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return m[1], v, true
+}
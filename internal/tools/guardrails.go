@@ -0,0 +1,334 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/promql"
+)
+
+// seriesProbeLimit bounds the series listed per selector when no max_series
+// is configured, so an estimate cannot itself become the expensive query
+const seriesProbeLimit = 10000
+
+// QueryGuardrails bounds what a query may scan before grafana_query sends
+// it. Zero limits are unlimited.
+type QueryGuardrails struct {
+	// Refuse rejects queries over a limit; otherwise they run and the
+	// estimate is attached to the result
+	Refuse           bool
+	MaxTimeRange     time.Duration
+	MaxRangeSelector time.Duration
+	MaxSeries        int
+	MaxLogBytes      int64
+	MaxLogStreams    int64
+}
+
+// WithQueryGuardrails estimates the cost of Prometheus and Loki queries in
+// grafana_query and warns about or refuses those over the limits
+func WithQueryGuardrails(g QueryGuardrails) Option {
+	return func(r *Registry) {
+		r.guardrails = &g
+	}
+}
+
+func (r *Registry) grafanaEstimateQueryCostTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_estimate_query_cost",
+		Description: "Estimate what a Prometheus or Loki query would scan without running it: time range, longest range selector, series matched (Prometheus series API), and log streams and bytes (Loki index stats). Reports which configured guardrail limits grafana_query would enforce",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":  {Type: "string", Description: "Datasource UID"},
+				"datasource_type": {Type: "string", Description: "Datasource type (prometheus or loki)"},
+				"query":           {Type: "string", Description: "PromQL or LogQL query"},
+				"from":            {Type: "string", Description: "Start time (default now-1h)"},
+				"to":              {Type: "string", Description: "End time (default now)"},
+			},
+			Required: []string{"datasource_uid", "datasource_type", "query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleEstimateQueryCost(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	dsType := getString(args, "datasource_type")
+	query := getString(args, "query")
+	if dsUID == "" || dsType == "" || query == "" {
+		return errorResult("datasource_uid, datasource_type, and query are required"), nil
+	}
+	if !promQLTypes[dsType] && dsType != "loki" {
+		return errorResult(fmt.Sprintf("cost estimates support Prometheus and Loki datasources, not %s", dsType)), nil
+	}
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-1h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: dsUID, Type: dsType}, query); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	limits := QueryGuardrails{}
+	if r.guardrails != nil {
+		limits = *r.guardrails
+	}
+	est := r.estimateQuery(dsUID, dsType, query, start, end, limits)
+	return jsonResult(map[string]interface{}{
+		"estimate":           est,
+		"guardrails_enabled": r.guardrails != nil,
+		"would_refuse":       r.guardrails != nil && r.guardrails.Refuse && len(est.Violations) > 0,
+	})
+}
+
+// selectorEstimate is the size of one selector of a query
+type selectorEstimate struct {
+	Selector string `json:"selector"`
+	Series   int    `json:"series,omitempty"`
+	// AtLeast is set when the series listing stopped at the probe limit
+	AtLeast bool   `json:"at_least,omitempty"`
+	Streams int64  `json:"streams,omitempty"`
+	Entries int64  `json:"entries,omitempty"`
+	Bytes   int64  `json:"bytes,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// queryEstimate is the estimated cost of a query and the limits it exceeds
+type queryEstimate struct {
+	TimeRange            string             `json:"time_range"`
+	LongestRangeSelector string             `json:"longest_range_selector,omitempty"`
+	Series               int                `json:"series,omitempty"`
+	LogStreams           int64              `json:"log_streams,omitempty"`
+	LogBytes             int64              `json:"log_bytes,omitempty"`
+	Selectors            []selectorEstimate `json:"selectors,omitempty"`
+	Violations           []string           `json:"violations"`
+	Notes                []string           `json:"notes,omitempty"`
+}
+
+// guardQuery estimates a grafana_query call against the configured
+// guardrails. It returns nil when guardrails are off or do not apply.
+func (r *Registry) guardQuery(dsUID, dsType, query, from, to string) *queryEstimate {
+	if r.guardrails == nil || (!promQLTypes[dsType] && dsType != "loki") {
+		return nil
+	}
+	start, end, err := parseTimeRange(from, to, "now-1h")
+	if err != nil {
+		// The query itself reports the bad range
+		return nil
+	}
+	est := r.estimateQuery(dsUID, dsType, query, start, end, *r.guardrails)
+	return &est
+}
+
+// estimateQuery sizes query over [start, end] and checks it against limits
+func (r *Registry) estimateQuery(dsUID, dsType, query string, start, end time.Time, limits QueryGuardrails) queryEstimate {
+	est := queryEstimate{TimeRange: shortDuration(end.Sub(start)), Violations: []string{}}
+	if limits.MaxTimeRange > 0 && end.Sub(start) > limits.MaxTimeRange {
+		est.Violations = append(est.Violations, fmt.Sprintf("time range %s exceeds the %s limit", est.TimeRange, shortDuration(limits.MaxTimeRange)))
+	}
+
+	var selectors []string
+	var window time.Duration
+	if dsType == "loki" {
+		selectors, window = logQLSelectors(query, &est)
+	} else {
+		selectors, window = promQLSelectors(query, &est)
+	}
+	if window > 0 {
+		est.LongestRangeSelector = shortDuration(window)
+		if limits.MaxRangeSelector > 0 && window > limits.MaxRangeSelector {
+			est.Violations = append(est.Violations, fmt.Sprintf("range selector [%s] exceeds the %s limit", est.LongestRangeSelector, shortDuration(limits.MaxRangeSelector)))
+		}
+	}
+
+	// Range selectors reach back before the start of the range
+	lookback := start.Add(-window)
+	for _, sel := range selectors {
+		se := selectorEstimate{Selector: sel}
+		if dsType == "loki" {
+			stats, err := r.client.GetLokiIndexStats(r.ctx, dsUID, sel, lookback, end)
+			if err != nil {
+				se.Error = err.Error()
+			} else {
+				se.Streams, se.Entries, se.Bytes = stats.Streams, stats.Entries, stats.Bytes
+				est.LogStreams += stats.Streams
+				est.LogBytes += stats.Bytes
+			}
+		} else {
+			limit := seriesProbeLimit
+			if limits.MaxSeries > 0 {
+				limit = limits.MaxSeries + 1
+			}
+			n, err := r.client.CountPrometheusSeries(r.ctx, dsUID, sel, lookback, end, limit)
+			if err != nil {
+				se.Error = err.Error()
+			} else {
+				se.Series, se.AtLeast = n, n >= limit
+				est.Series += n
+			}
+		}
+		est.Selectors = append(est.Selectors, se)
+	}
+
+	if limits.MaxSeries > 0 && est.Series > limits.MaxSeries {
+		count := strconv.Itoa(est.Series)
+		for _, se := range est.Selectors {
+			if se.AtLeast {
+				count = "at least " + count
+				break
+			}
+		}
+		est.Violations = append(est.Violations, fmt.Sprintf("selectors match %s series (limit %d)", count, limits.MaxSeries))
+	}
+	if limits.MaxLogStreams > 0 && est.LogStreams > limits.MaxLogStreams {
+		est.Violations = append(est.Violations, fmt.Sprintf("stream selectors match %d streams (limit %d)", est.LogStreams, limits.MaxLogStreams))
+	}
+	if limits.MaxLogBytes > 0 && est.LogBytes > limits.MaxLogBytes {
+		est.Violations = append(est.Violations, fmt.Sprintf("stream selectors cover %s of logs (limit %s)", humanBytes(est.LogBytes), humanBytes(limits.MaxLogBytes)))
+	}
+	return est
+}
+
+// promQLSelectors returns the distinct vector selectors of a PromQL query
+// and its longest range selector or subquery window
+func promQLSelectors(query string, est *queryEstimate) ([]string, time.Duration) {
+	expr, err := promql.Parse(query)
+	if err != nil {
+		est.Notes = append(est.Notes, "the query does not parse, so selectors were not sized: "+err.Error())
+		return nil, 0
+	}
+	seen := map[string]bool{}
+	var selectors []string
+	var window time.Duration
+	promql.Inspect(expr, func(e promql.Expr, _ []promql.Expr) bool {
+		switch n := e.(type) {
+		case *promql.MatrixSelector:
+			window = maxWindow(window, n.Range, est)
+		case *promql.Subquery:
+			window = maxWindow(window, n.Range, est)
+		case *promql.VectorSelector:
+			sel := renderSelector(n)
+			if promql.HasVariables(sel) {
+				est.Notes = append(est.Notes, fmt.Sprintf("%s uses template variables and was not sized", sel))
+			} else if !seen[sel] {
+				seen[sel] = true
+				selectors = append(selectors, sel)
+			}
+		}
+		return true
+	})
+	return selectors, window
+}
+
+// logQLSelectors returns the distinct stream selectors of a LogQL query and
+// its longest range window
+func logQLSelectors(query string, est *queryEstimate) ([]string, time.Duration) {
+	toks, qerr := scanQuery(query, true)
+	if qerr != nil {
+		est.Notes = append(est.Notes, "the query does not parse, so selectors were not sized: "+qerr.Message)
+		return nil, 0
+	}
+	seen := map[string]bool{}
+	var selectors []string
+	var window time.Duration
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case !t.str && t.text == "{":
+			end := i + 1
+			for end < len(toks) && (toks[end].str || toks[end].text != "}") {
+				end++
+			}
+			sel := query[t.pos : toks[end].pos+1]
+			if promql.HasVariables(sel) {
+				est.Notes = append(est.Notes, fmt.Sprintf("%s uses template variables and was not sized", sel))
+			} else if !seen[sel] {
+				seen[sel] = true
+				selectors = append(selectors, sel)
+			}
+			i = end
+		case !t.str && t.text == "[" && i+2 < len(toks) && toks[i+2].text == "]":
+			window = maxWindow(window, toks[i+1].text, est)
+		}
+	}
+	return selectors, window
+}
+
+// maxWindow returns the longer of cur and the range written as s. Ranges
+// set by template variables are noted and skipped.
+func maxWindow(cur time.Duration, s string, est *queryEstimate) time.Duration {
+	if promql.HasVariables(s) {
+		return cur
+	}
+	d, err := parseGrafanaDuration(s)
+	if err != nil {
+		est.Notes = append(est.Notes, fmt.Sprintf("could not read range %q: %v", s, err))
+		return cur
+	}
+	if d > cur {
+		return d
+	}
+	return cur
+}
+
+// renderSelector writes a vector selector back out as PromQL
+func renderSelector(vs *promql.VectorSelector) string {
+	var b strings.Builder
+	b.WriteString(vs.Name)
+	if len(vs.Matchers) > 0 || vs.Name == "" {
+		parts := make([]string, len(vs.Matchers))
+		for i, m := range vs.Matchers {
+			parts[i] = m.Name + m.Op + strconv.Quote(m.Value)
+		}
+		b.WriteString("{" + strings.Join(parts, ",") + "}")
+	}
+	return b.String()
+}
+
+// guardrailRefusal is the error for a query refused by the guardrails
+func guardrailRefusal(est *queryEstimate) *mcp.CallToolResult {
+	return errorResult(fmt.Sprintf("Query refused by guardrails: %s. Narrow the time range, shorten range selectors, or add label matchers; grafana_estimate_query_cost shows the estimate",
+		strings.Join(est.Violations, "; ")))
+}
+
+// shortDuration formats d with the largest whole units, e.g. 30d or 1h30m
+func shortDuration(d time.Duration) string {
+	if d <= 0 {
+		return "0s"
+	}
+	var b strings.Builder
+	for _, u := range []struct {
+		unit string
+		d    time.Duration
+	}{{"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}, {"s", time.Second}} {
+		if n := d / u.d; n > 0 {
+			fmt.Fprintf(&b, "%d%s", n, u.unit)
+			d -= n * u.d
+		}
+	}
+	if b.Len() == 0 {
+		return d.String()
+	}
+	return b.String()
+}
+
+// humanBytes formats n with binary units, e.g. 1.5GiB
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
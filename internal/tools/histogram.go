@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// histogramSummary is a histogram reduced to quantiles, the form
+// grafana_query returns instead of raw bucket series
+type histogramSummary struct {
+	Name      string            `json:"name"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	Quantiles []quantileSummary `json:"quantiles"`
+}
+
+// quantileSummary is one quantile with its statistics and [time, value]
+// points
+type quantileSummary struct {
+	Name   string         `json:"name"`
+	Stats  analysis.Stats `json:"stats"`
+	Points [][2]float64   `json:"points"`
+}
+
+// getQuantiles reads quantiles between 0 and 1
+func getQuantiles(args map[string]interface{}, key string) ([]float64, error) {
+	arr, _ := args[key].([]interface{})
+	out := make([]float64, 0, len(arr))
+	for _, item := range arr {
+		q, ok := item.(float64)
+		if !ok || q < 0 || q > 1 {
+			return nil, fmt.Errorf("%s must be numbers between 0 and 1, e.g. [0.5, 0.9, 0.99]", key)
+		}
+		out = append(out, q)
+	}
+	return out, nil
+}
+
+// summarizeHistograms reduces the histogram in a query result to its
+// quantiles over time. ok is false when the result holds no histogram.
+func summarizeHistograms(res grafana.QueryResult, quantiles []float64) ([]histogramSummary, bool) {
+	hs, ok := analysis.HistogramQuantiles(res.Frames, quantiles)
+	if !ok {
+		return nil, false
+	}
+	out := make([]histogramSummary, 0, len(hs))
+	for _, h := range hs {
+		sum := histogramSummary{Name: h.Name, Labels: h.Labels}
+		for _, qs := range h.Quantiles {
+			points := make([][2]float64, len(qs.Values))
+			for i, v := range qs.Values {
+				points[i] = [2]float64{float64(qs.Times[i]), v}
+			}
+			sum.Quantiles = append(sum.Quantiles, quantileSummary{Name: quantileName(qs.Quantile), Stats: qs.Stats, Points: points})
+		}
+		out = append(out, sum)
+	}
+	return out, true
+}
+
+// quantileName renders 0.99 as p99 and 0.999 as p99.9
+func quantileName(q float64) string {
+	return "p" + strconv.FormatFloat(math.Round(q*1e6)/1e4, 'f', -1, 64)
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuantileName(t *testing.T) {
+	tests := []struct {
+		q    float64
+		want string
+	}{
+		{0, "p0"},
+		{0.5, "p50"},
+		{0.9, "p90"},
+		{0.99, "p99"},
+		{0.999, "p99.9"},
+		{0.9999, "p99.99"},
+		{1, "p100"},
+	}
+	for _, tt := range tests {
+		if got := quantileName(tt.q); got != tt.want {
+			t.Errorf("quantileName(%v) = %q, want %q", tt.q, got, tt.want)
+		}
+	}
+}
+
+func TestGetQuantiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    []float64
+		wantErr bool
+	}{
+		{"missing", map[string]interface{}{}, []float64{}, false},
+		{"valid", map[string]interface{}{"quantiles": []interface{}{0.5, 0.99, 1.0}}, []float64{0.5, 0.99, 1}, false},
+		{"above 1", map[string]interface{}{"quantiles": []interface{}{0.5, 99.0}}, nil, true},
+		{"not a number", map[string]interface{}{"quantiles": []interface{}{"p99"}}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := getQuantiles(tt.args, "quantiles")
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: getQuantiles = %v, %v, want %v (error %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package tools
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	maxImportEvents          = 10000
+	defaultImportConcurrency = 4
+	maxImportConcurrency     = 16
+	defaultImportRate        = 10
+)
+
+// eventTimeLayouts are accepted in addition to parseTime's formats
+var eventTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"}
+
+func (r *Registry) grafanaImportAnnotationsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_import_annotations",
+		Description: "Create many annotations at once from a JSON array or CSV text (columns time, time_end, text, tags, dashboard_uid, panel_id), concurrently with rate limiting. Useful for backfilling deploy or incident history",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"events":          {Type: "array", Description: "Events as objects with time, time_end, text, tags, dashboard_uid, panel_id. Times are epoch ms/seconds, RFC 3339, or 'YYYY-MM-DD HH:MM:SS' (UTC)"},
+				"csv":             {Type: "string", Description: "CSV text with a header row using the same column names; tags separated by ';' or '|'"},
+				"default_tags":    {Type: "array", Description: "Tags added to every event"},
+				"dashboard_uid":   {Type: "string", Description: "Dashboard for events without one (default: organization-wide annotations)"},
+				"dashboard_map":   {Type: "object", Description: "Maps dashboard_uid values (e.g. service names) to dashboard UIDs"},
+				"concurrency":     {Type: "integer", Description: "Parallel requests (default 4, max 16)"},
+				"rate_per_second": {Type: "integer", Description: "Maximum annotations created per second (default 10)"},
+				"dry_run":         {Type: "boolean", Description: "Parse and validate without creating annotations"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// importedEvent is one parsed event with its position in the input
+type importedEvent struct {
+	Row        int
+	Annotation grafana.Annotation
+	ID         int64
+	Error      string
+}
+
+func (r *Registry) handleImportAnnotations(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	rows := getMapSlice(args, "events")
+	if text := getString(args, "csv"); text != "" {
+		parsed, err := parseEventsCSV(text)
+		if err != nil {
+			return apiErrorResult("Failed to parse csv", err), nil
+		}
+		rows = append(rows, parsed...)
+	}
+	if len(rows) == 0 {
+		return errorResult("events or csv is required"), nil
+	}
+	if len(rows) > maxImportEvents {
+		return errorResult(fmt.Sprintf("too many events: %d (max %d)", len(rows), maxImportEvents)), nil
+	}
+
+	concurrency := getInt(args, "concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultImportConcurrency
+	}
+	if concurrency > maxImportConcurrency {
+		concurrency = maxImportConcurrency
+	}
+	rate := getInt(args, "rate_per_second")
+	if rate <= 0 {
+		rate = defaultImportRate
+	}
+
+	defaults := eventDefaults{
+		tags:         getStringSlice(args, "default_tags"),
+		dashboardUID: getString(args, "dashboard_uid"),
+		dashboardMap: getStringMap(args, "dashboard_map"),
+	}
+	var events, invalid []*importedEvent
+	for i, row := range rows {
+		ann, err := defaults.annotation(row)
+		ev := &importedEvent{Row: i + 1, Annotation: ann}
+		if err != nil {
+			ev.Error = err.Error()
+			invalid = append(invalid, ev)
+			continue
+		}
+		events = append(events, ev)
+	}
+
+	dryRun := getBool(args, "dry_run")
+	result := newBulkResult(dryRun)
+	for _, ev := range invalid {
+		result.Fail(strconv.Itoa(ev.Row), "", ev.Error, ev.Annotation)
+	}
+	if dryRun {
+		for _, ev := range events {
+			result.Succeed(strconv.Itoa(ev.Row), ev.Annotation.Text, "would_create", ev.Annotation)
+		}
+		return bulkResult(result)
+	}
+
+	ticker := time.NewTicker(time.Second / time.Duration(rate))
+	defer ticker.Stop()
+	jobs := make(chan *importedEvent)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range jobs {
+				<-ticker.C
+				created, err := r.client.CreateAnnotation(r.ctx, ev.Annotation)
+				if err != nil {
+					ev.Error = err.Error()
+					continue
+				}
+				ev.ID = created.ID
+			}
+		}()
+	}
+	for _, ev := range events {
+		jobs <- ev
+	}
+	close(jobs)
+	wg.Wait()
+
+	for _, ev := range events {
+		if ev.Error != "" {
+			result.Fail(strconv.Itoa(ev.Row), ev.Annotation.Text, ev.Error, ev.Annotation)
+			continue
+		}
+		result.Succeed(strconv.Itoa(ev.Row), ev.Annotation.Text, "created", map[string]int64{"annotation_id": ev.ID})
+	}
+	return bulkResult(result)
+}
+
+type eventDefaults struct {
+	tags         []string
+	dashboardUID string
+	dashboardMap map[string]string
+}
+
+// annotation converts a JSON or CSV event row into an annotation
+func (d eventDefaults) annotation(row map[string]interface{}) (grafana.Annotation, error) {
+	ann := grafana.Annotation{Text: getString(row, "text")}
+	if ann.Text == "" {
+		return ann, fmt.Errorf("text is required")
+	}
+
+	start, err := eventTime(row["time"])
+	if err != nil {
+		return ann, err
+	}
+	if start == 0 {
+		return ann, fmt.Errorf("time is required")
+	}
+	ann.Time = start
+	if ann.TimeEnd, err = eventTime(row["time_end"]); err != nil {
+		return ann, err
+	}
+
+	ann.Tags = append(ann.Tags, d.tags...)
+	switch t := row["tags"].(type) {
+	case []interface{}:
+		ann.Tags = append(ann.Tags, getStringSlice(row, "tags")...)
+	case string:
+		for _, tag := range strings.FieldsFunc(t, func(r rune) bool { return r == ';' || r == '|' || r == ',' }) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				ann.Tags = append(ann.Tags, tag)
+			}
+		}
+	}
+
+	ann.DashboardUID = getString(row, "dashboard_uid")
+	if mapped, ok := d.dashboardMap[ann.DashboardUID]; ok {
+		ann.DashboardUID = mapped
+	}
+	if ann.DashboardUID == "" {
+		ann.DashboardUID = d.dashboardUID
+	}
+	switch v := row["panel_id"].(type) {
+	case string:
+		if v != "" {
+			id, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return ann, fmt.Errorf("invalid panel_id %q", v)
+			}
+			ann.PanelID = id
+		}
+	default:
+		ann.PanelID = getInt64(row, "panel_id")
+	}
+	return ann, nil
+}
+
+// eventTime converts a JSON number or string to epoch milliseconds. Numbers
+// below 1e12 are treated as seconds. A missing value returns 0.
+func eventTime(v interface{}) (int64, error) {
+	switch t := v.(type) {
+	case nil:
+		return 0, nil
+	case float64:
+		if t < 1e12 {
+			return int64(t * 1000), nil
+		}
+		return int64(t), nil
+	case string:
+		t = strings.TrimSpace(t)
+		if t == "" {
+			return 0, nil
+		}
+		if n, err := strconv.ParseFloat(t, 64); err == nil {
+			return eventTime(n)
+		}
+		for _, layout := range eventTimeLayouts {
+			if ts, err := time.Parse(layout, t); err == nil {
+				return ts.UnixMilli(), nil
+			}
+		}
+		ts, err := parseTime(t, time.Now())
+		if err != nil {
+			return 0, err
+		}
+		return ts.UnixMilli(), nil
+	}
+	return 0, fmt.Errorf("invalid time %v", v)
+}
+
+// parseEventsCSV reads CSV text with a header row into event rows
+func parseEventsCSV(text string) ([]map[string]interface{}, error) {
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("expected a header row and at least one event")
+	}
+
+	header := make([]string, len(records[0]))
+	for i, h := range records[0] {
+		h = strings.ToLower(strings.TrimSpace(h))
+		switch h {
+		case "timeend", "end", "end_time":
+			h = "time_end"
+		case "dashboard", "dashboarduid":
+			h = "dashboard_uid"
+		case "panel", "panelid":
+			h = "panel_id"
+		}
+		header[i] = h
+	}
+
+	rows := make([]map[string]interface{}, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		row := map[string]interface{}{}
+		for i, v := range rec {
+			if i < len(header) && header[i] != "" {
+				row[header[i]] = v
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"sort"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaGetInstanceInfoTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_get_instance_info",
+		Description: "Get Grafana build information (version, edition, commit), enabled feature toggles, optional capabilities such as the image renderer and unified alerting, and which tools are unavailable on this instance and why",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleGetInstanceInfo(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	health, err := r.client.GetHealth(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get health", err), nil
+	}
+	settings, err := r.client.GetFrontendSettings(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get frontend settings", err), nil
+	}
+
+	var toggles []string
+	for name, on := range settings.FeatureToggles {
+		if on {
+			toggles = append(toggles, name)
+		}
+	}
+	sort.Strings(toggles)
+
+	unavailable := map[string]string{}
+	for _, t := range r.allTools() {
+		if !r.isEnabled(t.Name) {
+			continue
+		}
+		if reason := r.unsupportedReason(t.Name); reason != "" {
+			unavailable[t.Name] = reason
+		}
+	}
+
+	version := settings.BuildInfo.Version
+	if version == "" {
+		version = health.Version
+	}
+	return jsonResult(map[string]interface{}{
+		"version":                   version,
+		"commit":                    health.Commit,
+		"edition":                   settings.BuildInfo.Edition,
+		"database":                  health.Database,
+		"app_sub_url":               settings.AppSubURL,
+		"anonymous_enabled":         settings.AnonymousEnabled,
+		"renderer_available":        settings.RendererAvailable,
+		"unified_alerting_enabled":  settings.UnifiedAlertingEnabled,
+		"public_dashboards_enabled": settings.PublicDashboardsEnabled,
+		"feature_toggles":           toggles,
+		"unavailable_tools":         unavailable,
+	})
+}
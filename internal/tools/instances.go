@@ -0,0 +1,316 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+)
+
+// defaultInstanceName names a registry's Grafana instance when none is set
+const defaultInstanceName = "default"
+
+// instanceArg is the argument every tool takes to address another instance
+const instanceArg = "instance"
+
+// instanceSet is the Grafana instances connected registries can reach, each
+// served by its own registry with its own client, caches, and version checks
+type instanceSet struct {
+	// names lists the instances with the default first
+	names  []string
+	byName map[string]*Registry
+	// state persists each session's selected instance; it is the default
+	// instance's store
+	state *state.Store
+	mu    sync.Mutex
+	// selected is the instance each session chose with
+	// grafana_use_instance, "" for the default; sessions not yet looked
+	// up are absent
+	selected map[string]string
+}
+
+// selection returns the instance session chose, or "" for the default. A
+// choice restored from the state store that names an instance no longer
+// configured is ignored.
+func (s *instanceSet) selection(session string) string {
+	if session == "" {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, ok := s.selected[session]
+	if ok {
+		return name
+	}
+	if _, err := s.state.Get(state.BucketInstances, session, &name); err != nil {
+		log.Printf("Warning: could not restore the instance of session %s: %v", session, err)
+	}
+	if _, known := s.byName[name]; !known {
+		name = ""
+	}
+	s.selected[session] = name
+	return name
+}
+
+// selectInstance makes name, or the default for "", the instance session's
+// calls without an instance argument go to
+func (s *instanceSet) selectInstance(session, name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.selected[session] = name
+	if name == "" {
+		return s.state.Delete(state.BucketInstances, session)
+	}
+	return s.state.Put(state.BucketInstances, session, name, 0)
+}
+
+type callInstanceKey struct{}
+
+// withCallInstance returns ctx for a call routed to the named instance, so
+// that the calls it makes in turn, such as the steps of a batch, stay there
+func withCallInstance(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, callInstanceKey{}, name)
+}
+
+// WithInstanceName names the Grafana instance the registry's client talks
+// to, as the instance argument of tools addresses it
+func WithInstanceName(name string) Option {
+	return func(r *Registry) {
+		r.instanceName = name
+	}
+}
+
+// ConnectInstances lets the tools of each registry address every other
+// registry's Grafana instance by name. The first registry serves calls
+// without an instance argument and is the one given to the transport; its
+// concurrency limits, confirmation tokens, and undo journal apply to calls
+// on all instances.
+func ConnectInstances(regs ...*Registry) error {
+	set := &instanceSet{byName: make(map[string]*Registry, len(regs)), selected: make(map[string]string)}
+	if len(regs) > 0 {
+		set.state = regs[0].state
+	}
+	for _, reg := range regs {
+		if _, dup := set.byName[reg.instanceName]; dup {
+			return fmt.Errorf("instance %q is configured twice", reg.instanceName)
+		}
+		set.names = append(set.names, reg.instanceName)
+		set.byName[reg.instanceName] = reg
+	}
+	for _, reg := range regs {
+		reg.instances = set
+		reg.limiter = regs[0].limiter
+		reg.confirm = regs[0].confirm
+		reg.journal = regs[0].journal
+	}
+	return nil
+}
+
+// instanceRegistry returns the registry serving the instance args name, and
+// args without the instance argument. Without one, the call goes to the
+// instance of the call that made it, else to the one its session chose.
+func (r *Registry) instanceRegistry(ctx context.Context, args map[string]interface{}) (*Registry, map[string]interface{}, error) {
+	v, ok := args[instanceArg]
+	if !ok {
+		name, _ := ctx.Value(callInstanceKey{}).(string)
+		if name == "" && r.instances != nil {
+			name = r.instances.selection(sessionFrom(ctx))
+		}
+		if target, ok := r.instances.lookup(name); ok {
+			return target, args, nil
+		}
+		return r, args, nil
+	}
+	rest := make(map[string]interface{}, len(args)-1)
+	for k, val := range args {
+		if k != instanceArg {
+			rest[k] = val
+		}
+	}
+	name, _ := v.(string)
+	name = strings.TrimSpace(name)
+	if name == "" || name == r.instanceName {
+		return r, rest, nil
+	}
+	if target, ok := r.instances.lookup(name); ok {
+		return target, rest, nil
+	}
+	return nil, nil, fmt.Errorf("unknown instance %q: configured instances are %s", name, strings.Join(r.instanceNames(), ", "))
+}
+
+// lookup returns the registry serving the named instance; a nil set knows
+// none
+func (s *instanceSet) lookup(name string) (*Registry, bool) {
+	if s == nil || name == "" {
+		return nil, false
+	}
+	reg, ok := s.byName[name]
+	return reg, ok
+}
+
+// instanceNames lists the instances tools can address, the default first
+func (r *Registry) instanceNames() []string {
+	if r.instances == nil {
+		return []string{r.instanceName}
+	}
+	return r.instances.names
+}
+
+// withInstanceArg adds the instance argument to a tool's schema when more
+// than one instance is configured
+func (r *Registry) withInstanceArg(t mcp.Tool) mcp.Tool {
+	names := r.instanceNames()
+	if len(names) < 2 {
+		return t
+	}
+	if _, taken := t.InputSchema.Properties[instanceArg]; taken {
+		return t
+	}
+	props := make(map[string]mcp.Property, len(t.InputSchema.Properties)+1)
+	for k, v := range t.InputSchema.Properties {
+		props[k] = v
+	}
+	props[instanceArg] = mcp.Property{
+		Type:        "string",
+		Description: fmt.Sprintf("Grafana instance to run against (default %s); see grafana_list_instances", names[0]),
+		Enum:        names,
+	}
+	t.InputSchema.Properties = props
+	return t
+}
+
+// instanceSummary describes one configured instance for grafana_list_instances
+type instanceSummary struct {
+	Name    string `json:"name"`
+	URL     string `json:"url"`
+	Default bool   `json:"default"`
+	// Current is set for the instance the call ran against
+	Current bool   `json:"current,omitempty"`
+	Healthy bool   `json:"healthy"`
+	Version string `json:"version,omitempty"`
+	// Unavailable counts tools hidden because the instance's Grafana
+	// version or features do not support them
+	Unavailable int    `json:"unavailable_tools,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+func (r *Registry) grafanaListInstancesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_instances",
+		Description: "List the Grafana instances this server can reach, such as dev, stage, and prod, with their URL, health, and version. Pass an instance's name as the instance argument of any tool to run it there; calls without one go to the default instance",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleListInstances(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	names := r.instanceNames()
+	out := make([]instanceSummary, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		reg := r
+		if r.instances != nil {
+			reg = r.instances.byName[name]
+		}
+		out[i] = instanceSummary{
+			Name:    name,
+			URL:     reg.client.BaseURL(),
+			Default: i == 0,
+			Current: name == r.instanceName,
+		}
+		wg.Add(1)
+		go func(s *instanceSummary, reg *Registry) {
+			defer wg.Done()
+			s.Unavailable = reg.unavailableCount()
+			health, err := reg.client.GetHealth(r.ctx)
+			if err != nil {
+				s.Error = err.Error()
+				return
+			}
+			s.Healthy = health.Database == "ok"
+			s.Version = health.Version
+		}(&out[i], reg)
+	}
+	wg.Wait()
+	return jsonResult(map[string]interface{}{
+		"instances": out,
+		"default":   names[0],
+	})
+}
+
+func (r *Registry) grafanaUseInstanceTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_use_instance",
+		Description: "Choose the Grafana instance later tool calls of this session go to when they have no instance argument, such as stage while working on a change before prod. The choice is kept across reconnects and, with a state directory, server restarts; other sessions keep their own",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"name": {Type: "string", Description: "Instance to use, as listed by grafana_list_instances; omit to return to the default instance"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:   true,
+			IdempotentHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleUseInstance(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	session := sessionFrom(r.ctx)
+	if session == "" {
+		return errorResult("grafana_use_instance needs an MCP session; pass the instance argument instead"), nil
+	}
+	names := r.instanceNames()
+	name := strings.TrimSpace(getString(args, "name"))
+	if name == names[0] {
+		name = ""
+	}
+	if _, ok := r.instances.lookup(name); name != "" && !ok {
+		return errorResult(fmt.Sprintf("unknown instance %q: configured instances are %s", name, strings.Join(names, ", "))), nil
+	}
+	if r.instances != nil {
+		if err := r.instances.selectInstance(session, name); err != nil {
+			log.Printf("Warning: could not save the instance of session %s: %v", session, err)
+		}
+	}
+	if name == "" {
+		name = names[0]
+	}
+	return jsonResult(map[string]interface{}{
+		"instance": name,
+		"default":  names[0],
+	})
+}
+
+// unavailableCount counts the tools the instance does not support
+func (r *Registry) unavailableCount() int {
+	n := 0
+	for _, t := range r.allTools() {
+		if r.unsupportedReason(t.Name) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// otherInstances runs f on the registries of the other connected instances
+func (r *Registry) otherInstances(f func(*Registry)) {
+	if r.instances == nil {
+		return
+	}
+	for _, name := range r.instances.names {
+		if reg := r.instances.byName[name]; reg != r {
+			f(reg)
+		}
+	}
+}
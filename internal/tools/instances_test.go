@@ -0,0 +1,94 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+type instanceList struct {
+	Instances []struct {
+		Name    string `json:"name"`
+		Current bool   `json:"current"`
+	} `json:"instances"`
+}
+
+// current is the instance grafana_list_instances ran against
+func (l instanceList) current() string {
+	for _, inst := range l.Instances {
+		if inst.Current {
+			return inst.Name
+		}
+	}
+	return ""
+}
+
+func connect(t *testing.T, regs ...*tools.Registry) {
+	t.Helper()
+	if err := tools.ConnectInstances(regs...); err != nil {
+		t.Fatalf("ConnectInstances: %v", err)
+	}
+}
+
+func TestUseInstanceIsPerSessionAndPersisted(t *testing.T) {
+	dir := t.TempDir()
+	st, err := state.Open(dir)
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithStateStore(st)))
+	prod := testkit.New(t, testkit.WithToolOptions(tools.WithInstanceName("prod")))
+	connect(t, h.Registry, prod.Registry)
+	other := h.NewSession()
+
+	h.Call("grafana_use_instance", map[string]interface{}{"name": "staging"}).Error(`unknown instance "staging"`)
+	h.Call("grafana_use_instance", map[string]interface{}{"name": "prod"}).OK()
+
+	var mine, theirs, explicit instanceList
+	h.Call("grafana_list_instances", nil).OK().JSON(&mine)
+	if got := mine.current(); got != "prod" {
+		t.Fatalf("session that chose prod ran on %q", got)
+	}
+	other.Call("grafana_list_instances", nil).OK().JSON(&theirs)
+	if got := theirs.current(); got != "default" {
+		t.Fatalf("other session ran on %q, want default", got)
+	}
+	h.Call("grafana_list_instances", map[string]interface{}{"instance": "default"}).OK().JSON(&explicit)
+	if got := explicit.current(); got != "default" {
+		t.Fatalf("call with instance default ran on %q", got)
+	}
+
+	// A restarted server reads the choice back from the state directory
+	reopened, err := state.Open(dir)
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	sessions, err := reopened.Keys(state.BucketInstances)
+	if err != nil || len(sessions) != 1 {
+		t.Fatalf("persisted sessions = %v (err %v), want one", sessions, err)
+	}
+	primary := tools.NewRegistry(h.Client(), nil, tools.WithStateStore(reopened))
+	connect(t, primary, tools.NewRegistry(prod.Client(), nil, tools.WithInstanceName("prod")))
+	res, err := primary.CallTool(tools.WithSession(context.Background(), sessions[0]), "grafana_list_instances", map[string]interface{}{})
+	if err != nil || res.IsError {
+		t.Fatalf("list instances after restart: %v %+v", err, res)
+	}
+	var restored instanceList
+	if err := json.Unmarshal([]byte(res.Content[0].Text), &restored); err != nil {
+		t.Fatalf("decoding %s: %v", res.Content[0].Text, err)
+	}
+	if got := restored.current(); got != "prod" {
+		t.Fatalf("after restart the session ran on %q, want prod", got)
+	}
+
+	// Choosing the default clears the choice
+	h.Call("grafana_use_instance", nil).OK()
+	h.Call("grafana_list_instances", nil).OK().JSON(&mine)
+	if got := mine.current(); got != "default" {
+		t.Fatalf("after returning to the default the session ran on %q", got)
+	}
+}
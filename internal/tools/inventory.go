@@ -0,0 +1,273 @@
+package tools
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// inventorySearchLimit is the most dashboards and folders prefetched; larger
+// instances fall back to live searches
+const inventorySearchLimit = 5000
+
+// Inventory kinds
+const (
+	inventoryFolders     = "folders"
+	inventoryDatasources = "datasources"
+	inventoryDashboards  = "dashboards"
+)
+
+// InventorySettings controls the background prefetch of folders,
+// datasources, and dashboard metadata
+type InventorySettings struct {
+	// Interval is how often the inventory is refreshed; 0 fetches it once
+	// at startup
+	Interval time.Duration
+}
+
+// DefaultInventorySettings returns the settings used when none are configured
+func DefaultInventorySettings() InventorySettings {
+	return InventorySettings{Interval: 5 * time.Minute}
+}
+
+// WithInventoryPrefetch serves folder, datasource, and dashboard listings
+// from a background-refreshed inventory once StartPrefetch has run
+func WithInventoryPrefetch(s InventorySettings) Option {
+	return func(r *Registry) {
+		r.inventory = &inventory{
+			interval: s.Interval,
+			fetched:  map[string]time.Time{},
+			errors:   map[string]string{},
+			kick:     make(chan struct{}, 1),
+		}
+	}
+}
+
+// inventory holds prefetched listings and when each was fetched
+type inventory struct {
+	interval time.Duration
+
+	mu          sync.RWMutex
+	folders     []grafana.Folder
+	datasources []grafana.Datasource
+	dashboards  []grafana.SearchDashboardsResponse
+	// complete is set when the dashboard search returned everything
+	complete bool
+	fetched  map[string]time.Time
+	errors   map[string]string
+	// generation counts invalidations, so a refresh that raced a write
+	// does not record data from before it
+	generation int
+
+	// kick requests an early refresh, e.g. after a write
+	kick chan struct{}
+	stop chan struct{}
+	done chan struct{}
+}
+
+// inventoryStatus tells callers how old a prefetched listing is
+type inventoryStatus struct {
+	Source     string    `json:"source"`
+	FetchedAt  time.Time `json:"fetched_at"`
+	AgeSeconds int64     `json:"age_seconds"`
+	// Stale is set when the listing is older than two refresh intervals or
+	// the last refresh failed; pass refresh: true for live data
+	Stale        bool   `json:"stale"`
+	RefreshError string `json:"refresh_error,omitempty"`
+}
+
+// StartPrefetch fetches the inventory in the background now and then every
+// interval. It does nothing unless WithInventoryPrefetch was given.
+func (r *Registry) StartPrefetch() {
+	inv := r.inventory
+	if inv == nil || inv.stop != nil {
+		return
+	}
+	inv.stop, inv.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(inv.done)
+		var tick <-chan time.Time
+		if inv.interval > 0 {
+			t := time.NewTicker(inv.interval)
+			defer t.Stop()
+			tick = t.C
+		}
+		for {
+			r.refreshInventory()
+			select {
+			case <-inv.stop:
+				return
+			case <-tick:
+			case <-inv.kick:
+			}
+		}
+	}()
+}
+
+// StopPrefetch stops background refreshes and waits for one in flight
+func (r *Registry) StopPrefetch() {
+	inv := r.inventory
+	if inv == nil || inv.stop == nil {
+		return
+	}
+	close(inv.stop)
+	<-inv.done
+}
+
+// refreshInventory fetches every listing, keeping the previous copy of any
+// that fails
+func (r *Registry) refreshInventory() {
+	inv := r.inventory
+	inv.mu.RLock()
+	gen := inv.generation
+	inv.mu.RUnlock()
+
+	ctx := grafana.Uncached(context.Background())
+	folders, ferr := r.client.GetFolders(ctx)
+	datasources, derr := r.client.GetDatasources(ctx)
+	dashboards, serr := r.client.Search(ctx, grafana.SearchQuery{Limit: inventorySearchLimit})
+
+	now := time.Now()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	if inv.generation != gen {
+		// A write happened meanwhile; the early refresh it requested follows
+		return
+	}
+	record := func(kind string, err error) bool {
+		if err != nil {
+			inv.errors[kind] = err.Error()
+			log.Printf("Warning: inventory prefetch of %s failed: %v", kind, err)
+			return false
+		}
+		delete(inv.errors, kind)
+		inv.fetched[kind] = now
+		return true
+	}
+	if record(inventoryFolders, ferr) {
+		inv.folders = folders
+	}
+	if record(inventoryDatasources, derr) {
+		inv.datasources = datasources
+	}
+	if record(inventoryDashboards, serr) {
+		inv.dashboards = dashboards
+		inv.complete = len(dashboards) < inventorySearchLimit
+	}
+}
+
+// invalidateInventory drops every prefetched listing after a write and asks
+// for an early refresh, so no call sees data from before its own change
+func (r *Registry) invalidateInventory() {
+	inv := r.inventory
+	if inv == nil {
+		return
+	}
+	inv.mu.Lock()
+	inv.fetched = map[string]time.Time{}
+	inv.generation++
+	inv.mu.Unlock()
+	select {
+	case inv.kick <- struct{}{}:
+	default:
+	}
+}
+
+// status describes the listing of kind; ok is false when there is none
+func (inv *inventory) status(kind string) (inventoryStatus, bool) {
+	fetched, ok := inv.fetched[kind]
+	if !ok {
+		return inventoryStatus{}, false
+	}
+	age := time.Since(fetched)
+	staleAfter := 2 * inv.interval
+	if staleAfter <= 0 {
+		staleAfter = 2 * DefaultInventorySettings().Interval
+	}
+	st := inventoryStatus{
+		Source:       "prefetch",
+		FetchedAt:    fetched.UTC(),
+		AgeSeconds:   int64(age.Seconds()),
+		RefreshError: inv.errors[kind],
+	}
+	st.Stale = age > staleAfter || st.RefreshError != ""
+	return st, true
+}
+
+// prefetchServes reports whether the inventory answers the call: it is
+// fetched for the configured organization, so sessions that switched to
+// another one list live
+func (r *Registry) prefetchServes() bool {
+	return r.inventory != nil && r.orgID() == r.client.OrgID()
+}
+
+// prefetchedFolders returns the prefetched folders, if any
+func (r *Registry) prefetchedFolders() ([]grafana.Folder, inventoryStatus, bool) {
+	if !r.prefetchServes() {
+		return nil, inventoryStatus{}, false
+	}
+	inv := r.inventory
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	st, ok := inv.status(inventoryFolders)
+	return inv.folders, st, ok
+}
+
+// prefetchedDatasources returns the prefetched datasources, if any
+func (r *Registry) prefetchedDatasources() ([]grafana.Datasource, inventoryStatus, bool) {
+	if !r.prefetchServes() {
+		return nil, inventoryStatus{}, false
+	}
+	inv := r.inventory
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	st, ok := inv.status(inventoryDatasources)
+	return inv.datasources, st, ok
+}
+
+// searchPrefetched answers a dashboard search from the inventory the way
+// /api/search would: case-insensitive title match, every tag, and type
+func (r *Registry) searchPrefetched(query string, tags []string, typ string, limit int) ([]grafana.SearchDashboardsResponse, inventoryStatus, bool) {
+	if !r.prefetchServes() {
+		return nil, inventoryStatus{}, false
+	}
+	inv := r.inventory
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	st, ok := inv.status(inventoryDashboards)
+	if !ok || !inv.complete {
+		return nil, st, false
+	}
+
+	query = strings.ToLower(query)
+	out := []grafana.SearchDashboardsResponse{}
+	for _, d := range inv.dashboards {
+		if limit > 0 && len(out) >= limit {
+			break
+		}
+		if typ != "" && d.Type != typ {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(d.Title), query) {
+			continue
+		}
+		if !hasAllTags(d.Tags, tags) {
+			continue
+		}
+		out = append(out, d)
+	}
+	return out, st, true
+}
+
+func hasAllTags(have, want []string) bool {
+	for _, w := range want {
+		if !contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaApplyJsonnetDashboardTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_apply_jsonnet_dashboard",
+		Description: "Evaluate a Jsonnet/grafonnet dashboard (inline source or local file) and save the resulting dashboard to Grafana. Files and imports are read only from the configured Jsonnet directory (files.jsonnet_dir); without one, only inline source without imports is accepted. Use dry_run to only return the rendered JSON",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"source":     {Type: "string", Description: "Inline Jsonnet source; its imports resolve from the Jsonnet directory"},
+				"file":       {Type: "string", Description: "Path of a .jsonnet/.libsonnet file within the Jsonnet directory (alternative to source)"},
+				"jpath":      {Type: "array", Description: "Extra library search paths within the Jsonnet directory, e.g. a jsonnet-bundler vendor directory"},
+				"ext_vars":   {Type: "object", Description: "External variables available via std.extVar (string values)"},
+				"tla_vars":   {Type: "object", Description: "Top-level function arguments (string values)"},
+				"folder_uid": {Type: "string", Description: "Folder UID to save the dashboard in"},
+				"message":    {Type: "string", Description: "Save message/commit description"},
+				"overwrite":  {Type: "boolean", Description: "Overwrite an existing dashboard with the same UID or title"},
+				"dry_run":    {Type: "boolean", Description: "Evaluate only and return the dashboard JSON without saving"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleApplyJsonnetDashboard(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	src := dashboard.JsonnetSource{
+		Snippet: getString(args, "source"),
+		File:    getString(args, "file"),
+		JPath:   getStringSlice(args, "jpath"),
+		ExtVars: getStringMap(args, "ext_vars"),
+		TLAVars: getStringMap(args, "tla_vars"),
+		Timeout: r.jsonnetTimeout,
+	}
+	if src.Snippet == "" && src.File == "" {
+		return errorResult("source or file is required"), nil
+	}
+	if src.Snippet != "" && src.File != "" {
+		return errorResult("source and file are mutually exclusive"), nil
+	}
+	if r.jsonnetDir == "" {
+		if src.File != "" || len(src.JPath) > 0 {
+			return errorResult("file and jpath need a Jsonnet directory: set files.jsonnet_dir in the config file, or pass inline source"), nil
+		}
+	} else {
+		// Imports are resolved from absolute paths, so relative ones are
+		// not taken relative to the directory twice
+		dir, err := confinePath(r.jsonnetDir, "")
+		if err != nil {
+			return errorResultFor(err), nil
+		}
+		src.Dir = dir
+		src.Resolve = func(path string) (string, error) {
+			return confinePath(r.jsonnetDir, path)
+		}
+		for i, dir := range src.JPath {
+			resolved, err := confinePath(r.jsonnetDir, dir)
+			if err != nil {
+				return errorResultFor(err), nil
+			}
+			src.JPath[i] = resolved
+		}
+	}
+
+	model, err := dashboard.EvaluateJsonnet(r.ctx, src)
+	if err != nil {
+		return apiErrorResult("Failed to evaluate jsonnet", err), nil
+	}
+
+	if getBool(args, "dry_run") {
+		return jsonResult(map[string]interface{}{
+			"dry_run":   true,
+			"dashboard": model,
+		})
+	}
+
+	message := getString(args, "message")
+	if message == "" {
+		message = "Applied from jsonnet via MCP"
+	}
+	result, err := r.client.SaveDashboardJSON(r.ctx, model, getString(args, "folder_uid"), message, getBool(args, "overwrite"))
+	if err != nil {
+		return apiErrorResult("Failed to save dashboard", err), nil
+	}
+
+	return jsonResult(result)
+}
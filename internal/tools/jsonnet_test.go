@@ -0,0 +1,61 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestJsonnetReadsOnlyFromLibraryDir(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "vendor", "lib", "panels.libsonnet"), `{ title: 'From library' }`)
+	writeFile(t, filepath.Join(root, "dash.jsonnet"), `(import 'lib/panels.libsonnet') + { uid: 'lib' }`)
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, "secret.libsonnet"), `{ title: 'secret' }`)
+
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithJsonnetDir(root)))
+	var out struct {
+		Dashboard map[string]interface{} `json:"dashboard"`
+	}
+	h.Call("grafana_apply_jsonnet_dashboard", map[string]interface{}{"file": "dash.jsonnet", "dry_run": true}).OK().JSON(&out)
+	if out.Dashboard["title"] != "From library" {
+		t.Fatalf("file evaluated to %v", out.Dashboard)
+	}
+	h.Call("grafana_apply_jsonnet_dashboard", map[string]interface{}{"source": `import 'lib/panels.libsonnet'`, "dry_run": true}).OK()
+
+	for name, args := range map[string]map[string]interface{}{
+		"file outside":        {"file": filepath.Join(outside, "secret.libsonnet")},
+		"file through ..":     {"file": "../secret.libsonnet"},
+		"jpath outside":       {"source": `{ title: 'x' }`, "jpath": []interface{}{outside}},
+		"absolute import":     {"source": `import '` + filepath.Join(outside, "secret.libsonnet") + `'`},
+		"import through ..":   {"source": `import '../` + filepath.Base(outside) + `/secret.libsonnet'`},
+		"importstr of a file": {"source": `{ title: importstr '/etc/hostname' }`},
+	} {
+		args["dry_run"] = true
+		res := h.Call("grafana_apply_jsonnet_dashboard", args)
+		if !res.IsError {
+			t.Errorf("%s: evaluated, want it refused: %s", name, res.Text())
+		}
+	}
+}
+
+func TestJsonnetWithoutLibraryDirIsInlineOnly(t *testing.T) {
+	h := testkit.New(t)
+	h.Call("grafana_apply_jsonnet_dashboard", map[string]interface{}{"source": `{ title: 'Inline' }`, "dry_run": true}).OK()
+	h.Call("grafana_apply_jsonnet_dashboard", map[string]interface{}{"file": "dash.jsonnet", "dry_run": true}).Error("files.jsonnet_dir")
+	h.Call("grafana_apply_jsonnet_dashboard", map[string]interface{}{"source": `{ title: 'x' }`, "jpath": []interface{}{"/"}, "dry_run": true}).Error("files.jsonnet_dir")
+	h.Call("grafana_apply_jsonnet_dashboard", map[string]interface{}{"source": `import '/etc/passwd'`, "dry_run": true}).Error("imports are disabled")
+}
@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/packs"
+)
+
+const (
+	k8sPackFolderUID   = "k8s-pack"
+	k8sPackFolderTitle = "Kubernetes"
+	k8sPackRuleGroup   = "kubernetes"
+)
+
+// k8sExporters maps each exporter a pack asset can require to a metric
+// that only it produces
+var k8sExporters = []struct{ name, metric string }{
+	{"kube-state-metrics", "kube_pod_info"},
+	{"cadvisor", "container_cpu_usage_seconds_total"},
+	{"node-exporter", "node_uname_info"},
+}
+
+// clusterLabelCandidates are the labels commonly used to tell clusters apart
+// in a shared Prometheus, in preference order
+var clusterLabelCandidates = []string{"cluster", "k8s_cluster_name", "cluster_name", "kubernetes_cluster"}
+
+func (r *Registry) grafanaInstallKubernetesPackTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_install_kubernetes_pack",
+		Description: "Detect kube-state-metrics, cAdvisor, and node-exporter series in a Prometheus datasource and install the matching curated Kubernetes dashboards (cluster overview, namespace workloads, pod resources, node resources) and recommended alert rules, parameterized by the cluster label",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: "Prometheus-compatible datasource UID"},
+				"cluster_label":  {Type: "string", Description: "Label that identifies the cluster (default: detected from cluster, k8s_cluster_name, cluster_name, kubernetes_cluster; none for single-cluster setups)"},
+				"cluster":        {Type: "string", Description: "Cluster to preselect on dashboards and scope alert rules to (default: all clusters)"},
+				"folder_uid":     {Type: "string", Description: "Folder to install into (default: a 'Kubernetes' folder, created if needed)"},
+				"alert_rules":    {Type: "boolean", Description: "Also create the recommended alert rules (default true)"},
+				"overwrite":      {Type: "boolean", Description: "Replace pack dashboards that already exist (default: leave them unchanged)"},
+				"dry_run":        {Type: "boolean", Description: "Report what was detected and would be installed without saving"},
+			},
+			Required: []string{"datasource_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleInstallKubernetesPack(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	if dsUID == "" {
+		return errorResult("datasource_uid is required"), nil
+	}
+	ds, err := r.client.GetDatasource(r.ctx, dsUID)
+	if err != nil {
+		return apiErrorResult("Failed to get datasource", err), nil
+	}
+
+	found, err := r.detectK8sExporters(dsUID)
+	if err != nil {
+		return apiErrorResult("Failed to detect Kubernetes metrics", err), nil
+	}
+	if !found["kube-state-metrics"] && !found["cadvisor"] {
+		return errorResult(fmt.Sprintf("no kube-state-metrics or cAdvisor series found in datasource %s", ds.Name)), nil
+	}
+
+	params := packs.Params{ClusterLabel: getString(args, "cluster_label"), Cluster: getString(args, "cluster")}
+	var clusters []string
+	if params.ClusterLabel == "" {
+		params.ClusterLabel, clusters = r.detectClusterLabel(dsUID)
+	} else if clusters, err = r.client.GetPrometheusLabelValues(r.ctx, dsUID, params.ClusterLabel, []string{k8sExporterSelector()}); err != nil {
+		return apiErrorResult("Failed to list clusters", err), nil
+	}
+	if params.Cluster != "" && params.ClusterLabel == "" {
+		return errorResult("cluster was given but no cluster label was found; pass cluster_label"), nil
+	}
+	if params.Cluster != "" && !contains(clusters, params.Cluster) {
+		return errorResult(fmt.Sprintf("cluster %q not found in label %q (found: %s)", params.Cluster, params.ClusterLabel, strings.Join(clusters, ", "))), nil
+	}
+
+	pack, err := packs.Kubernetes(params)
+	if err != nil {
+		return apiErrorResult("Failed to load the Kubernetes pack", err), nil
+	}
+
+	folderUID := getString(args, "folder_uid")
+	packFolder := folderUID == ""
+	if packFolder {
+		folderUID = k8sPackFolderUID
+	}
+	withAlerts := true
+	if _, ok := args["alert_rules"]; ok {
+		withAlerts = getBool(args, "alert_rules")
+	}
+
+	var exporters []string
+	for name := range found {
+		exporters = append(exporters, name)
+	}
+	sort.Strings(exporters)
+	out := map[string]interface{}{
+		"detected": map[string]interface{}{
+			"exporters":     exporters,
+			"cluster_label": params.ClusterLabel,
+			"clusters":      clusters,
+		},
+	}
+
+	var dashboards []map[string]interface{}
+	var skipped []string
+	for _, d := range pack.Dashboards {
+		if missing := missingExporters(d.Requires, found); len(missing) > 0 {
+			skipped = append(skipped, fmt.Sprintf("%v: needs %s", d.Model["title"], strings.Join(missing, ", ")))
+			continue
+		}
+		setDatasourceVariable(d.Model, ds)
+		dashboards = append(dashboards, d.Model)
+	}
+	var rules []grafana.AlertRule
+	if withAlerts {
+		for _, a := range pack.Alerts {
+			if missing := missingExporters(a.Requires, found); len(missing) > 0 {
+				skipped = append(skipped, fmt.Sprintf("alert %s: needs %s", a.Title, strings.Join(missing, ", ")))
+				continue
+			}
+			rules = append(rules, k8sAlertRule(a, ds.UID, folderUID, params.Cluster))
+		}
+	}
+	if len(skipped) > 0 {
+		out["skipped"] = skipped
+	}
+
+	if getBool(args, "dry_run") {
+		out["dry_run"] = true
+		out["dashboards"] = dashboards
+		out["alert_rules"] = rules
+		return jsonResult(out)
+	}
+
+	var steps []installStep
+	if packFolder {
+		folder := r.ensureFolder(folderUID, k8sPackFolderTitle)
+		steps = append(steps, folder)
+		if folder.Status == "failed" {
+			out["steps"] = steps
+			res, err := jsonResult(out)
+			if res != nil {
+				res.IsError = true
+			}
+			return res, err
+		}
+	}
+	for _, d := range dashboards {
+		steps = append(steps, r.installDashboard(d, folderUID, "Installed Kubernetes pack via MCP", getBool(args, "overwrite")))
+	}
+	steps = append(steps, r.installAlertRules(rules)...)
+	out["steps"] = steps
+	return jsonResult(out)
+}
+
+// detectK8sExporters reports which exporters have series in the datasource
+func (r *Registry) detectK8sExporters(dsUID string) (map[string]bool, error) {
+	names, err := r.client.GetPrometheusLabelValues(r.ctx, dsUID, "__name__", []string{k8sExporterSelector()})
+	if err != nil {
+		return nil, err
+	}
+	found := map[string]bool{}
+	for _, e := range k8sExporters {
+		if contains(names, e.metric) {
+			found[e.name] = true
+		}
+	}
+	return found, nil
+}
+
+// detectClusterLabel returns the first candidate label that has values on
+// the exporter metrics, and those values; empty when there is none
+func (r *Registry) detectClusterLabel(dsUID string) (string, []string) {
+	for _, label := range clusterLabelCandidates {
+		values, err := r.client.GetPrometheusLabelValues(r.ctx, dsUID, label, []string{k8sExporterSelector()})
+		if err == nil && len(values) > 0 {
+			return label, values
+		}
+	}
+	return "", nil
+}
+
+func k8sExporterSelector() string {
+	metrics := make([]string, len(k8sExporters))
+	for i, e := range k8sExporters {
+		metrics[i] = e.metric
+	}
+	return fmt.Sprintf(`{__name__=~"%s"}`, strings.Join(metrics, "|"))
+}
+
+func missingExporters(requires []string, found map[string]bool) []string {
+	var missing []string
+	for _, req := range requires {
+		if !found[req] {
+			missing = append(missing, req)
+		}
+	}
+	return missing
+}
+
+// setDatasourceVariable preselects ds in a pack dashboard's datasource picker
+func setDatasourceVariable(model map[string]interface{}, ds *grafana.Datasource) {
+	templating, _ := model["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, v := range list {
+		if m, ok := v.(map[string]interface{}); ok && m["name"] == "datasource" {
+			m["current"] = map[string]interface{}{"text": ds.Name, "value": ds.UID}
+		}
+	}
+}
+
+func k8sAlertRule(a packs.Alert, dsUID, folderUID, cluster string) grafana.AlertRule {
+	labels := map[string]string{"severity": a.Severity, "pack": "kubernetes"}
+	title := a.Title
+	if cluster != "" {
+		title += " (" + cluster + ")"
+	}
+	return grafana.AlertRule{
+		Title:        title,
+		FolderUID:    folderUID,
+		RuleGroup:    k8sPackRuleGroup,
+		Condition:    "B",
+		For:          a.For,
+		NoDataState:  "OK",
+		ExecErrState: "Error",
+		Labels:       labels,
+		Annotations:  map[string]string{"summary": a.Summary},
+		Data:         thresholdRuleData(dsUID, a.Expr, a.Op, a.Threshold),
+	}
+}
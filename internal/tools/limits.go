@@ -0,0 +1,190 @@
+package tools
+
+import (
+	"context"
+	"sort"
+	"sync"
+)
+
+// Option configures a Registry
+type Option func(*Registry)
+
+// ConcurrencyLimits bounds how many tool calls may run at once
+type ConcurrencyLimits struct {
+	// MaxCalls caps in-flight calls across all tools; 0 means unlimited
+	MaxCalls int
+	// PerTool returns the limit for a single tool; 0 means unlimited
+	PerTool func(name string) int
+	// SerializeWritesByUID runs write tools targeting the same object, named
+	// by any uid argument such as uid, uids or dashboard_uid, one at a time,
+	// so concurrent saves of one dashboard or alert rule cannot race into a
+	// version conflict
+	SerializeWritesByUID bool
+}
+
+// WithConcurrencyLimits enforces the given limits in CallTool
+func WithConcurrencyLimits(l ConcurrencyLimits) Option {
+	return func(r *Registry) {
+		r.limiter = newLimiter(l)
+	}
+}
+
+// limiter holds the semaphores and uid locks backing ConcurrencyLimits
+type limiter struct {
+	limits  ConcurrencyLimits
+	global  chan struct{}
+	mu      sync.Mutex
+	perTool map[string]chan struct{}
+	uids    *keyedMutex
+}
+
+func newLimiter(l ConcurrencyLimits) *limiter {
+	lim := &limiter{limits: l, perTool: make(map[string]chan struct{})}
+	if l.MaxCalls > 0 {
+		lim.global = make(chan struct{}, l.MaxCalls)
+	}
+	if l.SerializeWritesByUID {
+		lim.uids = newKeyedMutex()
+	}
+	return lim
+}
+
+func (l *limiter) toolSemaphore(name string) chan struct{} {
+	if l.limits.PerTool == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	sem, ok := l.perTool[name]
+	if !ok {
+		if n := l.limits.PerTool(name); n > 0 {
+			sem = make(chan struct{}, n)
+		}
+		l.perTool[name] = sem
+	}
+	return sem
+}
+
+// acquire blocks until the call may run and returns the function releasing
+// its slots. Write calls take their uid locks before any semaphore so that
+// a call waiting on a busy uid does not hold a global slot. If ctx is done
+// first, the slots already taken are released and ctx's error is returned.
+func (l *limiter) acquire(ctx context.Context, name string, write bool, args map[string]interface{}) (func(), error) {
+	var release []func()
+	releaseAll := func() {
+		for i := len(release) - 1; i >= 0; i-- {
+			release[i]()
+		}
+	}
+	if write && l.uids != nil {
+		for _, key := range lockKeys(name, args) {
+			unlock, err := l.uids.Lock(ctx, key)
+			if err != nil {
+				releaseAll()
+				return nil, err
+			}
+			release = append(release, unlock)
+		}
+	}
+	for _, sem := range []chan struct{}{l.toolSemaphore(name), l.global} {
+		if sem == nil {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+			s := sem
+			release = append(release, func() { <-s })
+		case <-ctx.Done():
+			releaseAll()
+			return nil, ctx.Err()
+		}
+	}
+	return releaseAll, nil
+}
+
+// lockKeys returns the sorted, de-duplicated lock keys for the objects a
+// write call names in its uid arguments. Keys are namespaced by kind, as in
+// "dashboard:<uid>" or "folder:<uid>", so objects of different kinds that
+// share a uid do not block each other. A consistent lock order prevents
+// deadlock between multi-uid calls.
+func lockKeys(tool string, args map[string]interface{}) []string {
+	seen := map[string]bool{}
+	for key := range args {
+		if !isUIDArg(key) {
+			continue
+		}
+		kind := argRefKind(tool, key)
+		if kind == "" {
+			kind = toolRefKind(tool)
+		}
+		if kind == "" {
+			// Write tools not named after a kind, such as grafana_bulk_tag
+			// and the exports, take dashboard uids
+			kind = refDashboard
+		}
+		uids := getStringSlice(args, key)
+		if uid := getString(args, key); uid != "" {
+			uids = append(uids, uid)
+		}
+		for _, uid := range uids {
+			if uid != "" {
+				seen[kind+":"+uid] = true
+			}
+		}
+	}
+	out := make([]string, 0, len(seen))
+	for key := range seen {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// keyedMutex is a set of mutexes created on demand per key and removed once unused
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*refMutex
+}
+
+// refMutex is a mutex held by sending to lock, so waiting for it can be
+// abandoned when a context is done
+type refMutex struct {
+	lock chan struct{}
+	refs int
+}
+
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*refMutex)}
+}
+
+// Lock locks key and returns its unlock function, or ctx's error if ctx is
+// done before the lock is free
+func (k *keyedMutex) Lock(ctx context.Context, key string) (func(), error) {
+	k.mu.Lock()
+	m, ok := k.locks[key]
+	if !ok {
+		m = &refMutex{lock: make(chan struct{}, 1)}
+		k.locks[key] = m
+	}
+	m.refs++
+	k.mu.Unlock()
+
+	drop := func() {
+		k.mu.Lock()
+		m.refs--
+		if m.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+	select {
+	case m.lock <- struct{}{}:
+	case <-ctx.Done():
+		drop()
+		return nil, ctx.Err()
+	}
+	return func() {
+		<-m.lock
+		drop()
+	}, nil
+}
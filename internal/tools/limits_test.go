@@ -0,0 +1,162 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// acquireAsync runs acquire in a goroutine and reports its error once it returns
+func acquireAsync(l *limiter, ctx context.Context, name string, write bool, args map[string]interface{}) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		release, err := l.acquire(ctx, name, write, args)
+		if release != nil {
+			release()
+		}
+		done <- err
+	}()
+	return done
+}
+
+func TestAcquireReturnsWhenQueuedCallIsCancelled(t *testing.T) {
+	tests := []struct {
+		name   string
+		limits ConcurrencyLimits
+		write  bool
+	}{
+		{name: "global slot", limits: ConcurrencyLimits{MaxCalls: 1}},
+		{name: "tool slot", limits: ConcurrencyLimits{PerTool: func(string) int { return 1 }}},
+		{name: "uid lock", limits: ConcurrencyLimits{SerializeWritesByUID: true}, write: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := newLimiter(tt.limits)
+			args := map[string]interface{}{"uid": "abc"}
+			hold, err := l.acquire(context.Background(), "grafana_update_dashboard", tt.write, args)
+			if err != nil {
+				t.Fatalf("first acquire: %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := acquireAsync(l, ctx, "grafana_update_dashboard", tt.write, args)
+			select {
+			case err := <-done:
+				t.Fatalf("queued acquire returned %v while the slot was held", err)
+			case <-time.After(20 * time.Millisecond):
+			}
+			cancel()
+			select {
+			case err := <-done:
+				if !errors.Is(err, context.Canceled) {
+					t.Fatalf("err = %v, want context.Canceled", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("queued acquire did not return after cancellation")
+			}
+
+			hold()
+			select {
+			case err := <-acquireAsync(l, context.Background(), "grafana_update_dashboard", tt.write, args):
+				if err != nil {
+					t.Fatalf("acquire after release: %v", err)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("the cancelled call left its slot taken")
+			}
+		})
+	}
+}
+
+func TestAcquireReleasesUIDLocksWhenCancelledOnSemaphore(t *testing.T) {
+	l := newLimiter(ConcurrencyLimits{MaxCalls: 1, SerializeWritesByUID: true})
+	hold, err := l.acquire(context.Background(), "grafana_get_dashboard", false, nil)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer hold()
+
+	// The write takes the uid lock, then waits on the full global semaphore
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := l.acquire(ctx, "grafana_update_dashboard", true, map[string]interface{}{"uid": "abc"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+
+	unlock, err := l.uids.Lock(context.Background(), "dashboard:abc")
+	if err != nil {
+		t.Fatalf("uid lock after cancellation: %v", err)
+	}
+	unlock()
+	if len(l.uids.locks) != 0 {
+		t.Fatalf("%d uid locks left, want none", len(l.uids.locks))
+	}
+}
+
+func TestAcquireSerializesWritesByDashboardUID(t *testing.T) {
+	l := newLimiter(ConcurrencyLimits{SerializeWritesByUID: true})
+	args := map[string]interface{}{"dashboard_uid": "abc", "text": "deploy"}
+	hold, err := l.acquire(context.Background(), "grafana_create_annotation", true, args)
+	if err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	done := acquireAsync(l, context.Background(), "grafana_create_annotation", true, args)
+	select {
+	case err := <-done:
+		t.Fatalf("second write to the dashboard ran while the first held it (err %v)", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	hold()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("second acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("second write did not run after the first released")
+	}
+}
+
+func TestAcquireDoesNotSerializeKindsSharingAUID(t *testing.T) {
+	l := newLimiter(ConcurrencyLimits{SerializeWritesByUID: true})
+	hold, err := l.acquire(context.Background(), "grafana_update_dashboard", true, map[string]interface{}{"uid": "abc"})
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	defer hold()
+
+	select {
+	case err := <-acquireAsync(l, context.Background(), "grafana_update_folder", true, map[string]interface{}{"uid": "abc"}):
+		if err != nil {
+			t.Fatalf("folder acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("a folder write waited on a dashboard with the same uid")
+	}
+}
+
+func TestLockKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+		want []string
+	}{
+		{"bare uid takes the tool's kind", "grafana_update_alert_rule", map[string]interface{}{"uid": "r1"}, []string{"alert_rule:r1"}},
+		{"uids and uid are merged", "grafana_delete_folder", map[string]interface{}{"uid": "b", "uids": []interface{}{"a", "b"}}, []string{"folder:a", "folder:b"}},
+		{"named uid arguments", "grafana_create_dashboard", map[string]interface{}{"folder_uid": "f1", "datasource_uid": "prom"}, []string{"datasource:prom", "folder:f1"}},
+		{"dashboard_uid", "grafana_create_annotation", map[string]interface{}{"dashboard_uid": "d1"}, []string{"dashboard:d1"}},
+		{"tools not named after a kind take dashboard uids", "grafana_bulk_tag", map[string]interface{}{"uids": []interface{}{"d1"}, "folder_uid": "f1"}, []string{"dashboard:d1", "folder:f1"}},
+		{"empty and non-uid arguments are ignored", "grafana_update_dashboard", map[string]interface{}{"uid": "", "title": "x"}, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lockKeys(tt.tool, tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("lockKeys = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
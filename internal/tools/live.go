@@ -0,0 +1,72 @@
+package tools
+
+import (
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	defaultLiveDuration = 10
+	maxLiveDuration     = 60
+	defaultLiveMessages = 100
+	maxLiveMessages     = 1000
+)
+
+func (r *Registry) grafanaLiveSubscribeTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_live_subscribe",
+		Description: "Subscribe to a Grafana Live channel for a bounded duration and return the frames received (e.g., streaming datasources or grafana/dashboard/uid/<uid> presence events). Requires Grafana 10+",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"channel":          {Type: "string", Description: "Live channel path, e.g. grafana/dashboard/uid/abc123 or ds/<datasource uid>/<path>"},
+				"duration_seconds": {Type: "integer", Description: "How long to listen (default 10, max 60)"},
+				"max_messages":     {Type: "integer", Description: "Stop after this many messages (default 100, max 1000)"},
+			},
+			Required: []string{"channel"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleLiveSubscribe(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	channel := getString(args, "channel")
+	if channel == "" {
+		return errorResult("channel is required"), nil
+	}
+
+	duration := getInt(args, "duration_seconds")
+	if duration <= 0 {
+		duration = defaultLiveDuration
+	}
+	if duration > maxLiveDuration {
+		duration = maxLiveDuration
+	}
+	maxMessages := getInt(args, "max_messages")
+	if maxMessages <= 0 {
+		maxMessages = defaultLiveMessages
+	}
+	if maxMessages > maxLiveMessages {
+		maxMessages = maxLiveMessages
+	}
+
+	messages, err := r.client.SubscribeLive(r.ctx, channel, time.Duration(duration)*time.Second, maxMessages)
+	if err != nil && len(messages) == 0 {
+		return apiErrorResult("Live subscription failed", err), nil
+	}
+
+	result := map[string]interface{}{
+		"channel":          channel,
+		"duration_seconds": duration,
+		"count":            len(messages),
+		"messages":         messages,
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	return jsonResult(result)
+}
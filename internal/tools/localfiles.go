@@ -0,0 +1,80 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// errNoExportDir is returned when a tool is asked to write files but no
+// export directory is configured
+var errNoExportDir = errors.New("writing files is disabled: set files.export_dir in the config file to the directory tools may write into")
+
+// WithExportDir lets export tools write files, within dir only
+func WithExportDir(dir string) Option {
+	return func(r *Registry) {
+		r.exportDir = dir
+	}
+}
+
+// WithJsonnetDir lets Jsonnet programs read files and import libraries,
+// within dir only
+func WithJsonnetDir(dir string) Option {
+	return func(r *Registry) {
+		r.jsonnetDir = dir
+	}
+}
+
+// WithJsonnetTimeout bounds how long a Jsonnet program may evaluate
+func WithJsonnetTimeout(d time.Duration) Option {
+	return func(r *Registry) {
+		r.jsonnetTimeout = d
+	}
+}
+
+// exportPath resolves an output_dir argument within the export directory
+func (r *Registry) exportPath(dir string) (string, error) {
+	if r.exportDir == "" {
+		return "", errNoExportDir
+	}
+	return confinePath(r.exportDir, dir)
+}
+
+// confinePath resolves path, taken relative to root unless absolute, and
+// refuses one that leaves root through .. or a symlink
+func confinePath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	absRoot = resolveExisting(absRoot)
+	p := filepath.FromSlash(path)
+	if !filepath.IsAbs(p) {
+		p = filepath.Join(absRoot, p)
+	}
+	p = resolveExisting(filepath.Clean(p))
+	rel, err := filepath.Rel(absRoot, p)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside %s", path, root)
+	}
+	return p, nil
+}
+
+// resolveExisting follows the symlinks of the longest part of p that
+// exists, keeping the rest as is
+func resolveExisting(p string) string {
+	rest := ""
+	for {
+		if real, err := filepath.EvalSymlinks(p); err == nil {
+			return filepath.Join(real, rest)
+		}
+		parent := filepath.Dir(p)
+		if parent == p {
+			return filepath.Join(p, rest)
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		p = parent
+	}
+}
@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfinePath(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+	real := resolveExisting(root)
+
+	tests := []struct {
+		path string
+		want string // "" when the path must be refused
+	}{
+		{"", real},
+		{".", real},
+		{"backups", filepath.Join(real, "backups")},
+		{"a/b/../c", filepath.Join(real, "a", "c")},
+		{filepath.Join(root, "nested", "dir"), filepath.Join(real, "nested", "dir")},
+		{"..", ""},
+		{"../sibling", ""},
+		{"a/../../x", ""},
+		{outside, ""},
+		{"/etc", ""},
+		{"escape", ""},
+		{"escape/deeper", ""},
+	}
+	for _, tt := range tests {
+		got, err := confinePath(root, tt.path)
+		if tt.want == "" {
+			if err == nil || !strings.Contains(err.Error(), "outside") {
+				t.Errorf("confinePath(%q) = %q, %v; want an outside error", tt.path, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("confinePath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	defaultPatternLines = 1000
+	maxPatternLines     = 5000
+	defaultPatternTop   = 20
+)
+
+func (r *Registry) grafanaLogPatternsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_log_patterns",
+		Description: "Run a LogQL query and cluster the returned lines into templates (Drain-style pattern mining), returning the most frequent patterns with counts, first/last seen times, and example lines instead of raw logs",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: "UID of the Loki datasource"},
+				"query":          {Type: "string", Description: "LogQL log query, e.g. {app=\"checkout\"} |= \"error\""},
+				"from":           {Type: "string", Description: "Start time (default now-1h)"},
+				"to":             {Type: "string", Description: "End time (default now)"},
+				"limit":          {Type: "integer", Description: "Maximum lines to analyze (default 1000, max 5000)"},
+				"similarity":     {Type: "number", Description: "Fraction of tokens that must match to join a pattern, 0-1 (default 0.5)"},
+				"top":            {Type: "integer", Description: "Number of patterns to return (default 20)"},
+				"examples":       {Type: "integer", Description: "Example lines per pattern (default 3)"},
+			},
+			Required: []string{"datasource_uid", "query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleLogPatterns(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	query := getString(args, "query")
+	if dsUID == "" || query == "" {
+		return errorResult("datasource_uid and query are required"), nil
+	}
+
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-1h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = defaultPatternLines
+	}
+	if limit > maxPatternLines {
+		limit = maxPatternLines
+	}
+	top := getInt(args, "top")
+	if top <= 0 {
+		top = defaultPatternTop
+	}
+	examples := getInt(args, "examples")
+	if examples <= 0 {
+		examples = 3
+	}
+
+	resp, err := r.query(grafana.QueryRequest{
+		From: fmt.Sprintf("%d", start.UnixMilli()),
+		To:   fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: []grafana.QueryTarget{{
+			RefID:      "A",
+			Datasource: grafana.DatasourceRef{Type: "loki", UID: dsUID},
+			Query:      query,
+			QueryType:  "range",
+			MaxLines:   limit,
+		}},
+	})
+	if err != nil {
+		return apiErrorResult("Query failed", err), nil
+	}
+	res := resp.Results["A"]
+	if res.Error != "" {
+		return errorResult(fmt.Sprintf("Query failed: %s", res.Error)), nil
+	}
+
+	lines := analysis.FramesToLogLines(res.Frames)
+	if len(lines) == 0 {
+		return errorResult("query returned no log lines; check that it is a log query, not a metric query"), nil
+	}
+
+	miner := analysis.NewPatternMiner(getFloat(args, "similarity"), examples)
+	for _, l := range lines {
+		miner.Add(l.Line, l.Time)
+	}
+	patterns := miner.Patterns()
+	total := len(patterns)
+	if len(patterns) > top {
+		patterns = patterns[:top]
+	}
+	covered := 0
+	for _, p := range patterns {
+		covered += p.Count
+	}
+
+	return jsonResult(map[string]interface{}{
+		"query":          query,
+		"lines":          len(lines),
+		"line_limit_hit": len(lines) >= limit,
+		"pattern_count":  total,
+		"returned":       len(patterns),
+		"coverage":       float64(covered) / float64(len(lines)),
+		"patterns":       patterns,
+	})
+}
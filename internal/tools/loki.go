@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	defaultTailDuration = 10
+	maxTailDuration     = 120
+	defaultTailLines    = 500
+	maxTailLines        = 5000
+)
+
+func (r *Registry) grafanaLokiTailTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_loki_tail",
+		Description: "Tail logs from a Loki datasource for a bounded duration and return the matching lines with per-stream counts (e.g., watch checkout logs during a redeploy)",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":   {Type: "string", Description: "UID of the Loki datasource"},
+				"query":            {Type: "string", Description: "LogQL log query, e.g. {app=\"checkout\"} |= \"error\""},
+				"duration_seconds": {Type: "integer", Description: "How long to tail (default 10, max 120)"},
+				"max_lines":        {Type: "integer", Description: "Stop after this many lines (default 500, max 5000)"},
+				"since":            {Type: "string", Description: "Also include lines from this far back, e.g. now-5m (default now: only new lines)"},
+			},
+			Required: []string{"datasource_uid", "query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleLokiTail(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	query := getString(args, "query")
+	if dsUID == "" || query == "" {
+		return errorResult("datasource_uid and query are required"), nil
+	}
+
+	duration := getInt(args, "duration_seconds")
+	if duration <= 0 {
+		duration = defaultTailDuration
+	}
+	if duration > maxTailDuration {
+		duration = maxTailDuration
+	}
+	maxLines := getInt(args, "max_lines")
+	if maxLines <= 0 {
+		maxLines = defaultTailLines
+	}
+	if maxLines > maxTailLines {
+		maxLines = maxTailLines
+	}
+	start, err := parseTime(getString(args, "since"), time.Now())
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: dsUID, Type: "loki"}, query); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	tail, err := r.client.TailLoki(r.ctx, dsUID, query, start, time.Duration(duration)*time.Second, maxLines)
+	if err != nil && (tail == nil || len(tail.Lines) == 0) {
+		return apiErrorResult("Loki tail failed", err), nil
+	}
+
+	sort.SliceStable(tail.Lines, func(i, j int) bool {
+		return tail.Lines[i].Timestamp.Before(tail.Lines[j].Timestamp)
+	})
+	streams := map[string]int{}
+	for _, l := range tail.Lines {
+		streams[labelSet(l.Labels)]++
+	}
+
+	result := map[string]interface{}{
+		"query":            query,
+		"duration_seconds": duration,
+		"count":            len(tail.Lines),
+		"streams":          streams,
+		"dropped":          tail.Dropped,
+		"truncated":        tail.Truncated,
+		"lines":            tail.Lines,
+	}
+	if err != nil {
+		result["error"] = err.Error()
+	}
+	return jsonResult(result)
+}
+
+// labelSet formats labels as a LogQL stream selector with sorted names
+func labelSet(labels map[string]string) string {
+	parts := make([]string, 0, len(labels))
+	for _, name := range sortedStringKeys(labels) {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, labels[name]))
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
@@ -0,0 +1,237 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	defaultVolumeLimit = 20
+	// volumeSpikeRatio is the growth over the previous window at which a
+	// stream group is reported as a spike
+	volumeSpikeRatio = 2.0
+	// volumeSpikeMinShare ignores growth in groups too small to matter:
+	// below this share of the window's bytes
+	volumeSpikeMinShare = 0.01
+	// lokiSeriesLimit caps the streams read for the cardinality breakdown
+	lokiSeriesLimit = 20000
+)
+
+func (r *Registry) grafanaLokiStatsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_loki_stats",
+		Description: "Measure Loki ingestion for a stream selector from the index, without reading log lines: total streams, entries, and bytes; the largest streams or label groups by bytes, compared with the previous window to flag volume spikes; and, optionally, the labels with the most distinct values (cardinality offenders)",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: "UID of the Loki datasource"},
+				"selector":       {Type: "string", Description: "Stream selector, e.g. {namespace=\"prod\"}"},
+				"from":           {Type: "string", Description: "Start time (default now-1h)"},
+				"to":             {Type: "string", Description: "End time (default now)"},
+				"group_by":       {Type: "array", Description: "Labels to aggregate volume by, e.g. [\"app\"] (default: each stream)"},
+				"limit":          {Type: "integer", Description: "Number of largest groups returned (default 20)"},
+				"compare":        {Type: "boolean", Description: "Compare with the previous window of the same length to find spikes (default true)"},
+				"cardinality":    {Type: "boolean", Description: "Also count the distinct values of each label across the matching streams"},
+			},
+			Required: []string{"datasource_uid", "selector"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// volumeGroup is a stream or label group in the volume report
+type volumeGroup struct {
+	Stream        string  `json:"stream"`
+	Bytes         int64   `json:"bytes"`
+	Size          string  `json:"size"`
+	Share         float64 `json:"share"`
+	PreviousBytes *int64  `json:"previous_bytes,omitempty"`
+	Growth        float64 `json:"growth,omitempty"`
+	Spike         bool    `json:"spike,omitempty"`
+	New           bool    `json:"new,omitempty"`
+}
+
+// labelCardinality is how many distinct values a label takes
+type labelCardinality struct {
+	Label   string   `json:"label"`
+	Values  int      `json:"values"`
+	Streams int      `json:"streams"`
+	Sample  []string `json:"sample,omitempty"`
+}
+
+func (r *Registry) handleLokiStats(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	selector := strings.TrimSpace(getString(args, "selector"))
+	if dsUID == "" || selector == "" {
+		return errorResult("datasource_uid and selector are required"), nil
+	}
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-1h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	groupBy := getStringSlice(args, "group_by")
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = defaultVolumeLimit
+	}
+	compare := true
+	if _, ok := args["compare"]; ok {
+		compare = getBool(args, "compare")
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: dsUID, Type: "loki"}, selector); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	stats, err := r.client.GetLokiIndexStats(r.ctx, dsUID, selector, start, end)
+	if err != nil {
+		return apiErrorResult("Failed to get index stats", err), nil
+	}
+	out := map[string]interface{}{
+		"selector": selector,
+		"from":     r.formatMillis(start.UnixMilli()),
+		"to":       r.formatMillis(end.UnixMilli()),
+		"totals":   lokiTotals(stats),
+	}
+	var notes []string
+
+	groups := []volumeGroup{}
+	volumes, err := r.client.GetLokiVolume(r.ctx, dsUID, selector, start, end, groupBy, limit)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("volume breakdown unavailable (needs Loki 2.9+ with volume_enabled): %v", err))
+	}
+	for _, v := range volumes {
+		g := volumeGroup{Stream: labelSet(v.Labels), Bytes: v.Bytes, Size: humanBytes(v.Bytes)}
+		if stats.Bytes > 0 {
+			g.Share = math.Round(float64(v.Bytes)/float64(stats.Bytes)*1e4) / 1e4
+		}
+		groups = append(groups, g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].Bytes > groups[j].Bytes })
+
+	if compare && len(groups) > 0 {
+		prevStart := start.Add(-end.Sub(start))
+		prevStats, err := r.client.GetLokiIndexStats(r.ctx, dsUID, selector, prevStart, start)
+		if err == nil {
+			out["previous_totals"] = lokiTotals(prevStats)
+		}
+		// Ask for more groups than shown so those that just entered the
+		// top list still find their previous volume
+		prev, err := r.client.GetLokiVolume(r.ctx, dsUID, selector, prevStart, start, groupBy, limit*5)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("previous window unavailable: %v", err))
+		} else {
+			markVolumeSpikes(groups, prev, len(prev) < limit*5)
+		}
+	}
+
+	spikes := []volumeGroup{}
+	for _, g := range groups {
+		if g.Spike {
+			spikes = append(spikes, g)
+		}
+	}
+	out["top"] = groups
+	if compare {
+		out["spikes"] = spikes
+	}
+
+	if getBool(args, "cardinality") {
+		out["cardinality"] = r.lokiCardinality(dsUID, selector, start, end)
+	}
+	if len(notes) > 0 {
+		out["notes"] = notes
+	}
+	return jsonResult(out)
+}
+
+// markVolumeSpikes compares each group with its volume in the previous
+// window. A group missing from the previous window is new only when that
+// window was listed in full.
+func markVolumeSpikes(groups []volumeGroup, prev []grafana.LokiVolume, complete bool) {
+	before := make(map[string]int64, len(prev))
+	for _, v := range prev {
+		before[labelSet(v.Labels)] = v.Bytes
+	}
+	for i := range groups {
+		g := &groups[i]
+		b, ok := before[g.Stream]
+		if !ok {
+			if complete {
+				zero := int64(0)
+				g.PreviousBytes, g.New = &zero, true
+				g.Spike = g.Share >= volumeSpikeMinShare
+			}
+			continue
+		}
+		g.PreviousBytes = &b
+		if b > 0 {
+			g.Growth = math.Round(float64(g.Bytes)/float64(b)*100) / 100
+			g.Spike = g.Growth >= volumeSpikeRatio && g.Share >= volumeSpikeMinShare
+		}
+	}
+}
+
+// lokiCardinality counts the distinct values of each label across the
+// streams matching selector, highest first
+func (r *Registry) lokiCardinality(dsUID, selector string, start, end time.Time) map[string]interface{} {
+	series, err := r.client.GetLokiSeries(r.ctx, dsUID, selector, start, end)
+	if err != nil {
+		return map[string]interface{}{"error": fmt.Sprintf("could not list streams: %v", err)}
+	}
+	out := map[string]interface{}{"streams": len(series)}
+	if len(series) > lokiSeriesLimit {
+		series = series[:lokiSeriesLimit]
+		out["note"] = fmt.Sprintf("counted the first %d streams", lokiSeriesLimit)
+	}
+
+	values := map[string]map[string]bool{}
+	streams := map[string]int{}
+	for _, s := range series {
+		for k, v := range s {
+			if values[k] == nil {
+				values[k] = map[string]bool{}
+			}
+			values[k][v] = true
+			streams[k]++
+		}
+	}
+	labels := make([]labelCardinality, 0, len(values))
+	for k, set := range values {
+		sample := make([]string, 0, len(set))
+		for v := range set {
+			sample = append(sample, v)
+		}
+		sort.Strings(sample)
+		if len(sample) > 5 {
+			sample = sample[:5]
+		}
+		labels = append(labels, labelCardinality{Label: k, Values: len(set), Streams: streams[k], Sample: sample})
+	}
+	sort.Slice(labels, func(i, j int) bool {
+		if labels[i].Values != labels[j].Values {
+			return labels[i].Values > labels[j].Values
+		}
+		return labels[i].Label < labels[j].Label
+	})
+	out["labels"] = labels
+	return out
+}
+
+func lokiTotals(s *grafana.LokiIndexStats) map[string]interface{} {
+	return map[string]interface{}{
+		"streams": s.Streams,
+		"chunks":  s.Chunks,
+		"entries": s.Entries,
+		"bytes":   s.Bytes,
+		"size":    humanBytes(s.Bytes),
+	}
+}
@@ -0,0 +1,244 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/manifest"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const manifestMessage = "Applied manifest via MCP"
+
+func (r *Registry) grafanaApplyManifestTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_apply_manifest",
+		Description: "Reconcile Grafana to a desired-state manifest (YAML or JSON with folders, datasources, dashboards, alert_rules, each identified by uid). Reports the plan first: creates, updates with field-level diffs of what the manifest declares, and with prune, deletes of undeclared dashboards and alert rules in the manifest's folders. Use dry_run to review the plan without applying it.",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"manifest": {Type: "string", Description: "Manifest as YAML or JSON text (or a JSON object). Dashboards are {folder_uid, dashboard}; other entries use the shapes the get/list tools return"},
+				"path":     {Type: "string", Description: "Local file to read the manifest from instead"},
+				"prune":    {Type: "boolean", Description: "Delete dashboards and alert rules in the manifest's folders that it does not declare. Folders and datasources are never deleted"},
+				"dry_run":  {Type: "boolean", Description: "Return the plan without applying it"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleApplyManifest(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	data, err := manifestSource(args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	m, err := manifest.Parse(data)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	for _, ds := range m.Datasources {
+		if err := r.checkURL(ds.URL); err != nil {
+			return errorResult(fmt.Sprintf("datasource %s: %v", ds.UID, err)), nil
+		}
+		if err := r.checkSettingURLs(ds.JSONData); err != nil {
+			return errorResult(fmt.Sprintf("datasource %s: jsonData.%v", ds.UID, err)), nil
+		}
+	}
+	prune := getBool(args, "prune")
+	state, err := r.manifestState(m, prune)
+	if err != nil {
+		return apiErrorResult("Failed to read current state", err), nil
+	}
+
+	plan, unchanged := manifest.Plan(m, state, prune)
+	counts := map[string]int{manifest.OpCreate: 0, manifest.OpUpdate: 0, manifest.OpDelete: 0, "unchanged": unchanged}
+	for _, a := range plan {
+		counts[a.Op]++
+	}
+	out := map[string]interface{}{
+		"plan":    plan,
+		"summary": counts,
+	}
+	if getBool(args, "dry_run") {
+		out["dry_run"] = true
+		return jsonResult(out)
+	}
+	if len(plan) == 0 {
+		return jsonResult(out)
+	}
+
+	steps, ok := r.applyManifest(m, state, plan)
+	out["steps"] = steps
+	result, err := jsonResult(out)
+	if result != nil && !ok {
+		result.IsError = true
+	}
+	return result, err
+}
+
+// manifestSource returns the manifest document from the manifest or path
+// argument
+func manifestSource(args map[string]interface{}) ([]byte, error) {
+	path := getString(args, "path")
+	switch v := args["manifest"].(type) {
+	case nil:
+		if path == "" {
+			return nil, fmt.Errorf("manifest or path is required")
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read manifest: %v", err)
+		}
+		return data, nil
+	case string:
+		if path != "" {
+			return nil, fmt.Errorf("pass either manifest or path, not both")
+		}
+		return []byte(v), nil
+	default:
+		if path != "" {
+			return nil, fmt.Errorf("pass either manifest or path, not both")
+		}
+		return json.Marshal(v)
+	}
+}
+
+// manifestState fetches the objects the manifest declares and, when
+// pruning, the dashboards and alert rules in its folders
+func (r *Registry) manifestState(m *manifest.Manifest, prune bool) (*manifest.State, error) {
+	state := &manifest.State{
+		Folders:     map[string]grafana.Folder{},
+		Datasources: map[string]grafana.Datasource{},
+		Dashboards:  map[string]manifest.Dashboard{},
+		AlertRules:  map[string]grafana.AlertRule{},
+	}
+	for _, f := range m.Folders {
+		cur, err := r.client.GetFolder(r.ctx, f.UID)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("folder %s: %w", f.UID, err)
+		}
+		state.Folders[f.UID] = *cur
+	}
+	for _, ds := range m.Datasources {
+		cur, err := r.client.GetDatasource(r.ctx, ds.UID)
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("datasource %s: %w", ds.UID, err)
+		}
+		state.Datasources[ds.UID] = *cur
+	}
+	for _, d := range m.Dashboards {
+		cur, err := r.client.GetDashboardJSON(r.ctx, d.UID())
+		if isNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("dashboard %s: %w", d.UID(), err)
+		}
+		state.Dashboards[d.UID()] = manifest.Dashboard{FolderUID: cur.Meta.FolderUID, Dashboard: cur.Dashboard}
+	}
+
+	if prune && len(m.Folders) > 0 {
+		folderUIDs := make([]string, len(m.Folders))
+		for i, f := range m.Folders {
+			folderUIDs[i] = f.UID
+		}
+		// Every page, so pruning sees all of a large folder's dashboards
+		hits, _, err := fetchPages(exportSearchLimit, 0, func(page, perPage int) ([]grafana.SearchDashboardsResponse, int64, error) {
+			hits, err := r.client.Search(r.ctx, grafana.SearchQuery{Type: "dash-db", FolderUIDs: folderUIDs, Limit: perPage, Page: page})
+			return hits, 0, err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("search dashboards: %w", err)
+		}
+		for _, h := range hits {
+			if _, ok := state.Dashboards[h.UID]; !ok {
+				state.Dashboards[h.UID] = manifest.Dashboard{
+					FolderUID: h.FolderUID,
+					Dashboard: map[string]interface{}{"uid": h.UID, "title": h.Title},
+				}
+			}
+		}
+	}
+	if len(m.AlertRules) > 0 || prune && len(m.Folders) > 0 {
+		rules, err := r.client.GetAlertRules(r.ctx)
+		if err != nil {
+			return nil, fmt.Errorf("alert rules: %w", err)
+		}
+		for _, rule := range rules {
+			state.AlertRules[rule.UID] = rule
+		}
+	}
+	return state, nil
+}
+
+// applyManifest carries out a plan in order, continuing past failures so
+// one bad object does not block the rest
+func (r *Registry) applyManifest(m *manifest.Manifest, state *manifest.State, plan []manifest.Action) ([]installStep, bool) {
+	folders := map[string]grafana.Folder{}
+	for _, f := range m.Folders {
+		folders[f.UID] = f
+	}
+	datasources := map[string]grafana.Datasource{}
+	for _, ds := range m.Datasources {
+		if ds.Access == "" {
+			ds.Access = "proxy"
+		}
+		datasources[ds.UID] = ds
+	}
+	dashboards := map[string]manifest.Dashboard{}
+	for _, d := range m.Dashboards {
+		dashboards[d.UID()] = d
+	}
+	rules := map[string]grafana.AlertRule{}
+	for _, rule := range m.AlertRules {
+		rules[rule.UID] = rule
+	}
+
+	steps := make([]installStep, 0, len(plan))
+	ok := true
+	for _, a := range plan {
+		var err error
+		switch a.Kind + "/" + a.Op {
+		case "folder/create":
+			_, err = r.client.CreateFolder(r.ctx, folders[a.UID].Title, a.UID)
+		case "folder/update":
+			_, err = r.client.UpdateFolder(r.ctx, a.UID, folders[a.UID].Title, state.Folders[a.UID].Version)
+		case "datasource/create":
+			_, err = r.client.CreateDatasource(r.ctx, datasources[a.UID])
+		case "datasource/update":
+			_, err = r.client.UpdateDatasource(r.ctx, a.UID, datasources[a.UID])
+		case "dashboard/create", "dashboard/update":
+			d := dashboards[a.UID]
+			model := make(map[string]interface{}, len(d.Dashboard))
+			for k, v := range d.Dashboard {
+				if k != "id" && k != "version" {
+					model[k] = v
+				}
+			}
+			_, err = r.client.SaveDashboardJSON(r.ctx, model, d.FolderUID, manifestMessage, true)
+		case "dashboard/delete":
+			err = r.client.DeleteDashboard(r.ctx, a.UID)
+		case "alert_rule/create":
+			_, err = r.client.CreateAlertRule(r.ctx, rules[a.UID])
+		case "alert_rule/update":
+			_, err = r.client.UpdateAlertRule(r.ctx, a.UID, rules[a.UID])
+		case "alert_rule/delete":
+			err = r.client.DeleteAlertRule(r.ctx, a.UID)
+		}
+		s := installStep{Step: a.Kind, UID: a.UID, Status: a.Op + "d", Detail: a.Name}
+		if err != nil {
+			s.Status, s.Detail = "failed", fmt.Sprintf("%s %s: %v", a.Op, a.Name, err)
+			ok = false
+		}
+		steps = append(steps, s)
+	}
+	return steps, ok
+}
@@ -0,0 +1,32 @@
+package tools_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+func TestApplyManifestPrunesEverySearchPage(t *testing.T) {
+	h := testkit.New(t)
+	h.AddFolder(grafana.Folder{UID: "team", Title: "Team"})
+	// One more than a search page holds
+	const stale = 5001
+	for i := 0; i < stale; i++ {
+		h.AddDashboard(map[string]interface{}{"uid": fmt.Sprintf("stale-%04d", i), "title": fmt.Sprintf("Stale %d", i)}, "team")
+	}
+	h.AddDashboard(map[string]interface{}{"uid": "kept", "title": "Kept"}, "")
+
+	var out struct {
+		Summary map[string]int `json:"summary"`
+	}
+	h.Call("grafana_apply_manifest", map[string]interface{}{
+		"manifest": `folders: [{uid: team, title: Team}]`,
+		"prune":    true,
+		"dry_run":  true,
+	}).OK().JSON(&out)
+	if out.Summary["delete"] != stale {
+		t.Fatalf("plan deletes %d dashboards, want all %d in the folder", out.Summary["delete"], stale)
+	}
+}
@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// labelMatcher is an Alertmanager-style label matcher (=, !=, =~, !~)
+type labelMatcher struct {
+	Name  string `json:"name"`
+	Op    string `json:"op"`
+	Value string `json:"value"`
+	re    *regexp.Regexp
+}
+
+// newLabelMatcher builds a matcher, anchoring regular expressions as
+// Alertmanager does
+func newLabelMatcher(name, op, value string) (labelMatcher, error) {
+	m := labelMatcher{Name: name, Op: op, Value: value}
+	switch op {
+	case "=", "!=":
+	case "=~", "!~":
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return m, fmt.Errorf("invalid regex in matcher %s%s%q: %w", name, op, value, err)
+		}
+		m.re = re
+	default:
+		return m, fmt.Errorf("invalid matcher operator %q", op)
+	}
+	return m, nil
+}
+
+// parseLabelMatcher parses "name=value", "name!=value", "name=~regex" or
+// "name!~regex"; the value may be double-quoted
+func parseLabelMatcher(s string) (labelMatcher, error) {
+	s = strings.TrimSpace(s)
+	// Label names cannot hold = or !, so the operator starts at the first
+	// one; the value may contain either
+	if i := strings.IndexAny(s, "=!"); i > 0 {
+		for _, op := range []string{"=~", "!~", "!=", "="} {
+			if !strings.HasPrefix(s[i:], op) {
+				continue
+			}
+			name := strings.TrimSpace(s[:i])
+			value := strings.TrimSpace(s[i+len(op):])
+			if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+				value = value[1 : len(value)-1]
+			}
+			return newLabelMatcher(name, op, value)
+		}
+	}
+	return labelMatcher{}, fmt.Errorf("invalid matcher %q: expected name=value, name!=value, name=~regex or name!~regex", s)
+}
+
+func parseLabelMatchers(in []string) ([]labelMatcher, error) {
+	out := make([]labelMatcher, 0, len(in))
+	for _, s := range in {
+		m, err := parseLabelMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// Matches reports whether the label set satisfies the matcher. A missing
+// label is treated as the empty string.
+func (m labelMatcher) Matches(labels map[string]string) bool {
+	v := labels[m.Name]
+	switch m.Op {
+	case "=":
+		return v == m.Value
+	case "!=":
+		return v != m.Value
+	case "=~":
+		return m.re.MatchString(v)
+	case "!~":
+		return !m.re.MatchString(v)
+	}
+	return false
+}
+
+func (m labelMatcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Op, m.Value)
+}
+
+func matchAll(matchers []labelMatcher, labels map[string]string) bool {
+	for _, m := range matchers {
+		if !m.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,155 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLabelMatcher(t *testing.T) {
+	tests := []struct {
+		in   string
+		want labelMatcher
+	}{
+		{"team=payments", labelMatcher{Name: "team", Op: "=", Value: "payments"}},
+		{` team = "payments" `, labelMatcher{Name: "team", Op: "=", Value: "payments"}},
+		{"team!=payments", labelMatcher{Name: "team", Op: "!=", Value: "payments"}},
+		{`team=~"pay.*"`, labelMatcher{Name: "team", Op: "=~", Value: "pay.*"}},
+		{"team!~pay.*|ops", labelMatcher{Name: "team", Op: "!~", Value: "pay.*|ops"}},
+		{"team=", labelMatcher{Name: "team", Op: "=", Value: ""}},
+		{`team=""`, labelMatcher{Name: "team", Op: "=", Value: ""}},
+		{`summary="a!=b"`, labelMatcher{Name: "summary", Op: "=", Value: "a!=b"}},
+		{`summary!="x=~y"`, labelMatcher{Name: "summary", Op: "!=", Value: "x=~y"}},
+		{`path=~"/api/.*=.*"`, labelMatcher{Name: "path", Op: "=~", Value: "/api/.*=.*"}},
+		{`team="`, labelMatcher{Name: "team", Op: "=", Value: `"`}},
+	}
+	for _, tt := range tests {
+		got, err := parseLabelMatcher(tt.in)
+		if err != nil {
+			t.Errorf("parseLabelMatcher(%q): %v", tt.in, err)
+			continue
+		}
+		got.re = nil
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseLabelMatcher(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabelMatcherRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", "invalid matcher"},
+		{"team", "invalid matcher"},
+		{"=payments", "invalid matcher"},
+		{"team!payments", "invalid matcher"},
+		{"team=~(", "invalid regex"},
+		{"team!~[a-", "invalid regex"},
+	}
+	for _, tt := range tests {
+		if _, err := parseLabelMatcher(tt.in); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseLabelMatcher(%q) = %v, want an error containing %q", tt.in, err, tt.want)
+		}
+	}
+	if _, err := newLabelMatcher("team", "==", "x"); err == nil {
+		t.Error("newLabelMatcher accepted operator ==")
+	}
+	if _, err := parseLabelMatchers([]string{"team=a", "bad"}); err == nil {
+		t.Error("parseLabelMatchers accepted an invalid matcher")
+	}
+}
+
+func TestLabelMatcherMatches(t *testing.T) {
+	labels := map[string]string{"team": "payments", "severity": "critical", "env": ""}
+	tests := []struct {
+		matcher string
+		want    bool
+	}{
+		{"team=payments", true},
+		{"team=pay", false},
+		{"team!=payments", false},
+		{"team!=ops", true},
+		{"team=~pay.*", true},
+		{"team=~pay", false}, // anchored
+		{"team=~ops|payments", true},
+		{"team!~pay.*", false},
+		{"team!~ops", true},
+		{"severity=~(?i)CRITICAL", true},
+		{"missing=", true},
+		{"missing!=", false},
+		{"missing=~.*", true},
+		{"missing=~.+", false},
+		{"missing!~.+", true},
+		{"env=", true},
+	}
+	for _, tt := range tests {
+		m, err := parseLabelMatcher(tt.matcher)
+		if err != nil {
+			t.Fatalf("parseLabelMatcher(%q): %v", tt.matcher, err)
+		}
+		if got := m.Matches(labels); got != tt.want {
+			t.Errorf("%s matches %v = %v, want %v", tt.matcher, labels, got, tt.want)
+		}
+	}
+}
+
+func TestMatchAll(t *testing.T) {
+	matchers, err := parseLabelMatchers([]string{"team=payments", "severity!~info|warning"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		labels map[string]string
+		want   bool
+	}{
+		{map[string]string{"team": "payments", "severity": "critical"}, true},
+		{map[string]string{"team": "payments", "severity": "warning"}, false},
+		{map[string]string{"team": "ops", "severity": "critical"}, false},
+		{map[string]string{"team": "payments"}, true},
+	}
+	for _, tt := range tests {
+		if got := matchAll(matchers, tt.labels); got != tt.want {
+			t.Errorf("matchAll(%v) = %v, want %v", tt.labels, got, tt.want)
+		}
+	}
+	if !matchAll(nil, nil) {
+		t.Error("no matchers should match every label set")
+	}
+}
+
+func TestEditMap(t *testing.T) {
+	tests := []struct {
+		name        string
+		m, add      map[string]string
+		remove      []string
+		want        map[string]string
+		wantChanged bool
+	}{
+		{"add to nil", nil, map[string]string{"team": "payments"}, nil, map[string]string{"team": "payments"}, true},
+		{"same value is no change", map[string]string{"team": "payments"}, map[string]string{"team": "payments"}, nil, map[string]string{"team": "payments"}, false},
+		{"overwrite", map[string]string{"team": "ops"}, map[string]string{"team": "payments"}, nil, map[string]string{"team": "payments"}, true},
+		{"remove", map[string]string{"team": "ops", "env": "prod"}, nil, []string{"team"}, map[string]string{"env": "prod"}, true},
+		{"remove missing is no change", map[string]string{"env": "prod"}, nil, []string{"team"}, map[string]string{"env": "prod"}, false},
+		{"remove then add the same key", map[string]string{"team": "ops"}, map[string]string{"team": "payments"}, []string{"team"}, map[string]string{"team": "payments"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before map[string]string
+			if tt.m != nil {
+				before = make(map[string]string)
+				for k, v := range tt.m {
+					before[k] = v
+				}
+			}
+			got, changed := editMap(tt.m, tt.add, tt.remove)
+			if !reflect.DeepEqual(got, tt.want) || changed != tt.wantChanged {
+				t.Fatalf("editMap = %v, %v, want %v, %v", got, changed, tt.want, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(tt.m, before) {
+				t.Fatalf("editMap modified its input: %v, was %v", tt.m, before)
+			}
+		})
+	}
+}
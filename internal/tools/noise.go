@@ -0,0 +1,183 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const noiseAnnotationLimit = 5000
+
+func (r *Registry) grafanaAlertNoiseReportTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_alert_noise_report",
+		Description: "Aggregate alert state history over a period and rank alert rules by number of firings, mean time spent firing, and flappiness to find noisy alerts",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"from":                   {Type: "string", Description: "Start of the period (default now-7d)"},
+				"to":                     {Type: "string", Description: "End of the period (default now)"},
+				"sort_by":                {Type: "string", Description: "Ranking key (default firings)", Enum: []string{"firings", "time_firing", "flappiness"}},
+				"flap_threshold_minutes": {Type: "integer", Description: "Firings resolved faster than this count as flaps (default 10)"},
+				"limit":                  {Type: "integer", Description: "Maximum number of rules to return (default 20)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// ruleNoise is the aggregated history of a single alert rule
+type ruleNoise struct {
+	RuleID             int64   `json:"rule_id"`
+	RuleUID            string  `json:"rule_uid,omitempty"`
+	Title              string  `json:"title"`
+	Firings            int     `json:"firings"`
+	Transitions        int     `json:"transitions"`
+	MeanFiringSeconds  float64 `json:"mean_firing_seconds"`
+	TotalFiringSeconds float64 `json:"total_firing_seconds"`
+	ShortFirings       int     `json:"short_firings"`
+	Flappiness         float64 `json:"flappiness"`
+	StillFiring        int     `json:"still_firing"`
+}
+
+func (r *Registry) handleAlertNoiseReport(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-7d")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	sortBy := getString(args, "sort_by")
+	if sortBy == "" {
+		sortBy = "firings"
+	}
+	flapThreshold := time.Duration(getInt(args, "flap_threshold_minutes")) * time.Minute
+	if flapThreshold <= 0 {
+		flapThreshold = 10 * time.Minute
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = 20
+	}
+
+	history, err := r.client.QueryAnnotations(r.ctx, grafana.AnnotationsQuery{
+		From:  start.UnixMilli(),
+		To:    end.UnixMilli(),
+		Type:  "alert",
+		Limit: noiseAnnotationLimit,
+	})
+	if err != nil {
+		return apiErrorResult("Failed to get alert state history", err), nil
+	}
+
+	// Rule titles and UIDs are best effort; history is still useful without them
+	rulesByID := map[int64]grafana.AlertRule{}
+	if rules, err := r.client.GetAlertRules(r.ctx); err == nil {
+		for _, rule := range rules {
+			rulesByID[rule.ID] = rule
+		}
+	}
+
+	report := aggregateNoise(history, rulesByID, end, flapThreshold)
+	sort.SliceStable(report, func(i, j int) bool {
+		switch sortBy {
+		case "time_firing":
+			return report[i].TotalFiringSeconds > report[j].TotalFiringSeconds
+		case "flappiness":
+			if report[i].Flappiness != report[j].Flappiness {
+				return report[i].Flappiness > report[j].Flappiness
+			}
+		}
+		return report[i].Firings > report[j].Firings
+	})
+
+	total := len(report)
+	if len(report) > limit {
+		report = report[:limit]
+	}
+
+	return jsonResult(map[string]interface{}{
+		"from":            start.UTC().Format(time.RFC3339),
+		"to":              end.UTC().Format(time.RFC3339),
+		"sort_by":         sortBy,
+		"events":          len(history),
+		"truncated":       len(history) >= noiseAnnotationLimit,
+		"rules_with_data": total,
+		"rules":           report,
+	})
+}
+
+// aggregateNoise folds state transitions into per-rule statistics. Each
+// alert instance (rule plus label set) is tracked separately so that
+// multi-dimensional rules pair their firing and resolving events correctly.
+func aggregateNoise(history []grafana.Annotation, rules map[int64]grafana.AlertRule, end time.Time, flapThreshold time.Duration) []ruleNoise {
+	sort.SliceStable(history, func(i, j int) bool { return history[i].Time < history[j].Time })
+
+	byRule := map[int64]*ruleNoise{}
+	firingSince := map[string]int64{}
+	for _, a := range history {
+		rn, ok := byRule[a.AlertID]
+		if !ok {
+			rn = &ruleNoise{RuleID: a.AlertID, Title: a.AlertName}
+			if rule, ok := rules[a.AlertID]; ok {
+				rn.RuleUID = rule.UID
+				rn.Title = rule.Title
+			}
+			byRule[a.AlertID] = rn
+		}
+		rn.Transitions++
+
+		key := fmt.Sprintf("%d|%s", a.AlertID, instanceKey(a.Text))
+		if strings.HasPrefix(a.NewState, "Alerting") {
+			rn.Firings++
+			if _, open := firingSince[key]; !open {
+				firingSince[key] = a.Time
+			}
+			continue
+		}
+		if since, open := firingSince[key]; open && !strings.HasPrefix(a.NewState, "Pending") {
+			d := time.Duration(a.Time-since) * time.Millisecond
+			rn.TotalFiringSeconds += d.Seconds()
+			if d < flapThreshold {
+				rn.ShortFirings++
+			}
+			delete(firingSince, key)
+		}
+	}
+
+	// Instances still firing at the end of the window count up to the end
+	for key, since := range firingSince {
+		var id int64
+		fmt.Sscanf(key, "%d|", &id)
+		if rn, ok := byRule[id]; ok {
+			rn.TotalFiringSeconds += end.Sub(time.UnixMilli(since)).Seconds()
+			rn.StillFiring++
+		}
+	}
+
+	out := make([]ruleNoise, 0, len(byRule))
+	for _, rn := range byRule {
+		if rn.Firings > 0 {
+			rn.MeanFiringSeconds = rn.TotalFiringSeconds / float64(rn.Firings)
+			rn.Flappiness = float64(rn.ShortFirings) / float64(rn.Firings)
+		}
+		out = append(out, *rn)
+	}
+	return out
+}
+
+// instanceKey extracts the label set that prefixes unified alerting history
+// text ("{alertname=..., pod=...} - B=1"), identifying one alert instance.
+func instanceKey(text string) string {
+	if strings.HasPrefix(text, "{") {
+		if i := strings.Index(text, "}"); i > 0 {
+			return text[:i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,146 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaListOrgsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_orgs",
+		Description: "List the organizations the current user belongs to, with their role in each and which one tools are working in. With all=true, list every organization of the instance instead (requires a Grafana server admin)",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"all": {Type: "boolean", Description: "List every organization of the instance, not only the user's (requires a Grafana server admin)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaSwitchOrgTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_switch_org",
+		Description: "Switch the organization later tool calls of this session work in, by sending X-Grafana-Org-Id with each of its requests to this Grafana instance. Other sessions stay in their organization. The user must be a member of the organization; service account tokens and API keys belong to a single organization and cannot switch",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"org_id":   {Type: "integer", Description: "ID of the organization to switch to"},
+				"org_name": {Type: "string", Description: "Name of the organization to switch to, instead of org_id"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			IdempotentHint: true,
+			OpenWorldHint:  true,
+		},
+	}
+}
+
+func (r *Registry) handleListOrgs(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	current, err := r.client.GetCurrentOrg(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get organization", err), nil
+	}
+
+	var orgs []map[string]interface{}
+	if getBool(args, "all") {
+		all, err := r.client.GetOrgs(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to list organizations", err), nil
+		}
+		for _, o := range all {
+			orgs = append(orgs, map[string]interface{}{
+				"id":      o.ID,
+				"name":    o.Name,
+				"current": o.ID == current.ID,
+			})
+		}
+	} else {
+		mine, err := r.client.GetUserOrgs(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to list organizations", err), nil
+		}
+		for _, o := range mine {
+			orgs = append(orgs, map[string]interface{}{
+				"id":      o.OrgID,
+				"name":    o.Name,
+				"role":    o.Role,
+				"current": o.OrgID == current.ID,
+			})
+		}
+	}
+
+	return jsonResult(map[string]interface{}{
+		"current_org_id": current.ID,
+		"orgs":           orgs,
+	})
+}
+
+func (r *Registry) handleSwitchOrg(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	id := getInt64(args, "org_id")
+	name := strings.TrimSpace(getString(args, "org_name"))
+	if id <= 0 && name == "" {
+		return errorResult("org_id or org_name is required"), nil
+	}
+	if id <= 0 {
+		mine, err := r.client.GetUserOrgs(r.ctx)
+		if err != nil {
+			return apiErrorResult("Failed to list organizations", err), nil
+		}
+		var names []string
+		for _, o := range mine {
+			names = append(names, o.Name)
+			if strings.EqualFold(o.Name, name) {
+				id = o.OrgID
+			}
+		}
+		if id <= 0 {
+			return errorResult(fmt.Sprintf("organization %q not found among the user's organizations (%s)", name, strings.Join(names, ", "))), nil
+		}
+	}
+
+	previous, err := r.client.GetCurrentOrg(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get organization", err), nil
+	}
+	if previous.ID == id {
+		return jsonResult(map[string]interface{}{
+			"org_id": previous.ID,
+			"name":   previous.Name,
+			"status": "unchanged",
+		})
+	}
+
+	prevID := r.client.OrgIDFor(r.ctx)
+	if !r.client.SetSessionOrgID(r.ctx, id) {
+		return errorResult("this call belongs to no MCP session to switch; set GRAFANA_ORG_ID, or org_id of the instance in the config file, instead"), nil
+	}
+	org, err := r.client.GetCurrentOrg(r.ctx)
+	if err == nil && org.ID != id {
+		err = fmt.Errorf("grafana answered for organization %d instead; the credentials may be bound to a single organization", org.ID)
+	}
+	if err != nil {
+		r.client.SetSessionOrgID(r.ctx, prevID)
+		return apiErrorResult(fmt.Sprintf("Failed to switch to organization %d", id), err), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"org_id":          org.ID,
+		"name":            org.Name,
+		"previous_org_id": previous.ID,
+		"status":          "switched",
+	})
+}
+
+// orgID is the organization the call's requests go to, 0 for the user's
+// current one. UIDs of datasources and dashboards only mean something within
+// one organization, so state shared between sessions is keyed by it.
+func (r *Registry) orgID() int64 {
+	return r.client.OrgIDFor(r.ctx)
+}
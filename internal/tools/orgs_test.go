@@ -0,0 +1,69 @@
+package tools_test
+
+import (
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+type orgList struct {
+	CurrentOrgID int64 `json:"current_org_id"`
+	Orgs         []struct {
+		ID      int64  `json:"id"`
+		Name    string `json:"name"`
+		Current bool   `json:"current"`
+	} `json:"orgs"`
+}
+
+func TestSwitchOrgIsPerSession(t *testing.T) {
+	h := testkit.New(t)
+	staging := h.AddOrg("Staging", "Editor")
+	other := h.NewSession()
+
+	var switched struct {
+		OrgID    int64  `json:"org_id"`
+		Name     string `json:"name"`
+		Previous int64  `json:"previous_org_id"`
+		Status   string `json:"status"`
+	}
+	h.Call("grafana_switch_org", map[string]interface{}{"org_name": "staging"}).OK().JSON(&switched)
+	if switched.OrgID != staging || switched.Previous != 1 || switched.Status != "switched" {
+		t.Fatalf("switch = %+v, want org %d switched from 1", switched, staging)
+	}
+
+	var mine, theirs orgList
+	h.Call("grafana_list_orgs", nil).OK().JSON(&mine)
+	if mine.CurrentOrgID != staging || len(mine.Orgs) != 2 {
+		t.Fatalf("switched session lists %+v, want current org %d of 2", mine, staging)
+	}
+	other.Call("grafana_list_orgs", nil).OK().JSON(&theirs)
+	if theirs.CurrentOrgID != 1 {
+		t.Fatalf("other session works in org %d, want 1", theirs.CurrentOrgID)
+	}
+
+	reqs := h.RequestsTo("GET", "/api/org")
+	if got := reqs[len(reqs)-1].Header.Get("X-Grafana-Org-Id"); got != "" {
+		t.Fatalf("other session sent X-Grafana-Org-Id %q, want none", got)
+	}
+}
+
+func TestSwitchOrgFailureKeepsOrg(t *testing.T) {
+	h := testkit.New(t)
+	h.Call("grafana_switch_org", map[string]interface{}{"org_id": 42}).Error("Failed to switch to organization 42")
+
+	var orgs orgList
+	h.Call("grafana_list_orgs", nil).OK().JSON(&orgs)
+	if orgs.CurrentOrgID != 1 {
+		t.Fatalf("current org %d after a failed switch, want 1", orgs.CurrentOrgID)
+	}
+	for _, r := range h.RequestsTo("GET", "/api/user/orgs") {
+		if got := r.Header.Get("X-Grafana-Org-Id"); got != "" {
+			t.Fatalf("X-Grafana-Org-Id %q sent after a failed switch", got)
+		}
+	}
+}
+
+func TestSwitchOrgUnknownName(t *testing.T) {
+	h := testkit.New(t)
+	h.Call("grafana_switch_org", map[string]interface{}{"org_name": "nope"}).Error(`organization "nope" not found`)
+}
@@ -0,0 +1,249 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// teamPageSize is the page size used when listing every team
+const teamPageSize = 1000
+
+func (r *Registry) grafanaAlertOwnershipReportTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_alert_ownership_report",
+		Description: "Join alert rules' owner labels (team, owner) against Grafana teams: rule counts per team, rules with no owner, owners that match no team (with the closest team), and teams that own no rules. Returns bulk-edit fixes that label the rules",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"owner_labels": {Type: "array", Description: "Labels naming a rule's owner, checked in order (default [\"team\", \"owner\"])"},
+				"folder_uid":   {Type: "string", Description: "Only rules in this folder"},
+				"matchers":     {Type: "array", Description: "Label matchers the rule labels must satisfy, e.g. [\"severity=critical\"]"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// ownedRule is an alert rule listed in the ownership report
+type ownedRule struct {
+	UID       string `json:"uid"`
+	Title     string `json:"title"`
+	FolderUID string `json:"folder_uid"`
+	RuleGroup string `json:"rule_group"`
+}
+
+// teamOwnership is the rules one team owns
+type teamOwnership struct {
+	Team     string         `json:"team"`
+	TeamID   int64          `json:"team_id"`
+	Members  int            `json:"members"`
+	Rules    int            `json:"rules"`
+	Labels   map[string]int `json:"labels,omitempty"`
+	RuleUIDs []string       `json:"rule_uids,omitempty"`
+}
+
+// unknownOwner is an owner label value that matches no team
+type unknownOwner struct {
+	Owner      string      `json:"owner"`
+	Label      string      `json:"label"`
+	Rules      []ownedRule `json:"rules"`
+	Suggestion string      `json:"suggestion,omitempty"`
+}
+
+func (r *Registry) handleAlertOwnershipReport(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	ownerLabels := getStringSlice(args, "owner_labels")
+	if len(ownerLabels) == 0 {
+		ownerLabels = []string{"team", "owner"}
+	}
+	folderUID := getString(args, "folder_uid")
+	matchers, err := parseLabelMatchers(getStringSlice(args, "matchers"))
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	rules, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list alert rules", err), nil
+	}
+	teams, err := r.allTeams()
+	if err != nil {
+		return apiErrorResult("Failed to list teams", err), nil
+	}
+
+	byName := make(map[string]*teamOwnership, len(teams))
+	owned := make([]*teamOwnership, 0, len(teams))
+	for _, t := range teams {
+		o := &teamOwnership{Team: t.Name, TeamID: t.ID, Members: t.MemberCount}
+		owned = append(owned, o)
+		byName[strings.ToLower(t.Name)] = o
+		if key := normalizeTitle(t.Name); key != "" {
+			if _, taken := byName[key]; !taken {
+				byName[key] = o
+			}
+		}
+	}
+
+	var unowned []ownedRule
+	unknown := map[string]*unknownOwner{}
+	checked := 0
+	for _, rule := range rules {
+		if (folderUID != "" && rule.FolderUID != folderUID) || !matchAll(matchers, rule.Labels) {
+			continue
+		}
+		checked++
+		entry := ownedRule{UID: rule.UID, Title: rule.Title, FolderUID: rule.FolderUID, RuleGroup: rule.RuleGroup}
+
+		label, owner := ruleOwner(rule, ownerLabels)
+		if owner == "" {
+			unowned = append(unowned, entry)
+			continue
+		}
+		o := byName[strings.ToLower(owner)]
+		if o == nil {
+			o = byName[normalizeTitle(owner)]
+		}
+		if o == nil {
+			key := label + "=" + owner
+			if unknown[key] == nil {
+				unknown[key] = &unknownOwner{Owner: owner, Label: label, Suggestion: closestTeam(teams, owner)}
+			}
+			unknown[key].Rules = append(unknown[key].Rules, entry)
+			continue
+		}
+		o.Rules++
+		o.RuleUIDs = append(o.RuleUIDs, rule.UID)
+		if o.Labels == nil {
+			o.Labels = map[string]int{}
+		}
+		o.Labels[label+"="+owner]++
+	}
+
+	sort.SliceStable(owned, func(i, j int) bool {
+		if owned[i].Rules != owned[j].Rules {
+			return owned[i].Rules > owned[j].Rules
+		}
+		return owned[i].Team < owned[j].Team
+	})
+	idle := []string{}
+	for _, o := range owned {
+		if o.Rules == 0 {
+			idle = append(idle, o.Team)
+		}
+	}
+	unknownList := make([]*unknownOwner, 0, len(unknown))
+	for _, u := range unknown {
+		unknownList = append(unknownList, u)
+	}
+	sort.Slice(unknownList, func(i, j int) bool {
+		if len(unknownList[i].Rules) != len(unknownList[j].Rules) {
+			return len(unknownList[i].Rules) > len(unknownList[j].Rules)
+		}
+		return unknownList[i].Owner < unknownList[j].Owner
+	})
+	if unowned == nil {
+		unowned = []ownedRule{}
+	}
+
+	unknownRules := 0
+	for _, u := range unknownList {
+		unknownRules += len(u.Rules)
+	}
+	summary := map[string]int{
+		"owned":               checked - len(unowned) - unknownRules,
+		"unowned":             len(unowned),
+		"unknown_owner":       unknownRules,
+		"teams":               len(teams),
+		"teams_without_rules": len(idle),
+	}
+	return jsonResult(map[string]interface{}{
+		"owner_labels":        ownerLabels,
+		"rules_checked":       checked,
+		"summary":             summary,
+		"teams":               owned,
+		"unowned_rules":       unowned,
+		"unknown_owners":      unknownList,
+		"teams_without_rules": idle,
+		"fixes":               ownershipFixes(ownerLabels[0], unowned, unknownList),
+	})
+}
+
+// allTeams pages through every team of the organization
+func (r *Registry) allTeams() ([]grafana.Team, error) {
+	teams, _, err := fetchPages(teamPageSize, 0, func(page, perPage int) ([]grafana.Team, int64, error) {
+		res, err := r.client.SearchTeams(r.ctx, "", page, perPage)
+		if err != nil {
+			return nil, 0, err
+		}
+		return res.Teams, res.TotalCount, nil
+	})
+	return teams, err
+}
+
+// ruleOwner returns the first owner label set on a rule and its value
+func ruleOwner(rule grafana.AlertRule, labels []string) (string, string) {
+	for _, name := range labels {
+		if v := strings.TrimSpace(rule.Labels[name]); v != "" {
+			return name, v
+		}
+	}
+	return "", ""
+}
+
+// closestTeam returns the team whose name is most like owner, if any is
+// close enough to be a likely typo or variant
+func closestTeam(teams []grafana.Team, owner string) string {
+	want := normalizeTitle(owner)
+	best, bestScore := "", minFolderScore
+	for _, t := range teams {
+		got := normalizeTitle(t.Name)
+		score := titleSimilarity(got, want)
+		if got != "" && want != "" && (strings.Contains(got, want) || strings.Contains(want, got)) {
+			score = max(score, 0.8)
+		}
+		if score > bestScore {
+			best, bestScore = t.Name, score
+		}
+	}
+	return best
+}
+
+// ownershipFixes proposes bulk edits: unknown owners with a likely team are
+// relabelled, and unowned rules get a placeholder owner to fill in
+func ownershipFixes(label string, unowned []ownedRule, unknown []*unknownOwner) []lintFix {
+	fixes := []lintFix{}
+	for _, u := range unknown {
+		if u.Suggestion == "" {
+			continue
+		}
+		fixes = append(fixes, lintFix{
+			Check: "unknown-owner",
+			Tool:  "grafana_bulk_edit_alert_rules",
+			Args:  map[string]interface{}{"uids": ruleUIDs(u.Rules), "add_labels": map[string]string{u.Label: u.Suggestion}},
+			Note:  fmt.Sprintf("%s=%s matches no team; %q is the closest", u.Label, u.Owner, u.Suggestion),
+		})
+	}
+	if len(unowned) > 0 {
+		fixes = append(fixes, lintFix{
+			Check: "unowned",
+			Tool:  "grafana_bulk_edit_alert_rules",
+			Args:  map[string]interface{}{"uids": ruleUIDs(unowned), "add_labels": map[string]string{label: "<team>"}},
+			Note:  "split by folder or rule group as needed; file-provisioned rules are skipped and must be labelled in their files",
+		})
+	}
+	return fixes
+}
+
+func ruleUIDs(rules []ownedRule) []string {
+	uids := make([]string, len(rules))
+	for i, rule := range rules {
+		uids[i] = rule.UID
+	}
+	return uids
+}
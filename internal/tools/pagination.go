@@ -0,0 +1,94 @@
+package tools
+
+import "fmt"
+
+// Page sizes and caps for listings that fetch every page
+const (
+	// listPageSize is the page size used when fetching every page
+	listPageSize = 500
+	// defaultListMax is how many results a fetch-all listing returns by default
+	defaultListMax = 5000
+	// maxListMax is the most results a fetch-all listing may return
+	maxListMax = 50000
+)
+
+// listPaging is how a paginated listing tool was asked to fetch results
+type listPaging struct {
+	page, perPage int
+	// all fetches every page from the first, up to max results
+	all bool
+	max int
+}
+
+// listPagingFromArgs reads page, per_page, all, and max_results
+func listPagingFromArgs(args map[string]interface{}) (listPaging, error) {
+	p := listPaging{
+		page:    getInt(args, "page"),
+		perPage: getInt(args, "per_page"),
+		all:     getBool(args, "all"),
+		max:     getInt(args, "max_results"),
+	}
+	if p.page < 0 || p.perPage < 0 || p.max < 0 {
+		return p, fmt.Errorf("page, per_page, and max_results must not be negative")
+	}
+	if p.all && p.page > 0 {
+		return p, fmt.Errorf("page cannot be combined with all, which starts from the first page")
+	}
+	if p.max == 0 {
+		p.max = defaultListMax
+	}
+	if p.max > maxListMax {
+		p.max = maxListMax
+	}
+	return p, nil
+}
+
+// fetchPages calls fetch for successive pages of perPage until total
+// results are collected, a page comes back short, or max is reached; max 0
+// fetches everything. It returns the results and the total Grafana reports.
+func fetchPages[T any](perPage, max int, fetch func(page, perPage int) ([]T, int64, error)) ([]T, int64, error) {
+	var all []T
+	var total int64
+	for page := 1; ; page++ {
+		items, n, err := fetch(page, perPage)
+		if err != nil {
+			return nil, 0, err
+		}
+		all, total = append(all, items...), n
+		if max > 0 && len(all) >= max {
+			return all[:max], total, nil
+		}
+		// Grafana versions without a total count stop on a short page
+		if len(items) < perPage || (total > 0 && int64(len(all)) >= total) {
+			return all, total, nil
+		}
+	}
+}
+
+// pagedResult is the output of a paginated listing: the results under key,
+// with the total count and, for a single page, where it is
+func pagedResult(key string, items interface{}, returned int, total int64, p listPaging) map[string]interface{} {
+	out := map[string]interface{}{
+		key:           items,
+		"total_count": total,
+		"returned":    returned,
+	}
+	if p.all {
+		out["truncated"] = int64(returned) < total
+		if int64(returned) < total {
+			out["note"] = fmt.Sprintf("stopped at max_results=%d of %d; raise max_results (up to %d) or narrow the query", p.max, total, maxListMax)
+		}
+		return out
+	}
+	out["page"], out["per_page"] = p.page, p.perPage
+	out["has_more"] = int64(p.pageStart()+returned) < total
+	return out
+}
+
+// pageStart counts the results before the requested page
+func (p listPaging) pageStart() int {
+	if p.page <= 1 || p.perPage <= 0 {
+		return 0
+	}
+	return (p.page - 1) * p.perPage
+}
@@ -0,0 +1,133 @@
+package tools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// pager serves n numbered items a page at a time, reporting total as
+// Grafana's total count, and records the pages asked for
+type pager struct {
+	n     int
+	total int64
+	pages []int
+}
+
+func (p *pager) fetch(page, perPage int) ([]int, int64, error) {
+	p.pages = append(p.pages, page)
+	var items []int
+	for i := (page - 1) * perPage; i < page*perPage && i < p.n; i++ {
+		items = append(items, i)
+	}
+	return items, p.total, nil
+}
+
+func TestFetchPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		n         int
+		total     int64
+		perPage   int
+		max       int
+		wantLen   int
+		wantPages int
+	}{
+		{"empty", 0, 0, 10, 0, 0, 1},
+		{"short last page", 25, 25, 10, 0, 25, 3},
+		{"total reached on a full page", 20, 20, 10, 0, 20, 2},
+		{"no total count stops on a short page", 20, 0, 10, 0, 20, 3},
+		{"max within a page", 25, 25, 10, 15, 15, 2},
+		{"max on a page boundary", 25, 25, 10, 10, 10, 1},
+		{"max beyond the results", 5, 5, 10, 100, 5, 1},
+		{"total larger than served", 15, 40, 10, 0, 15, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &pager{n: tt.n, total: tt.total}
+			got, total, err := fetchPages(tt.perPage, tt.max, p.fetch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.wantLen || len(p.pages) != tt.wantPages {
+				t.Fatalf("got %d results from %d pages, want %d from %d", len(got), len(p.pages), tt.wantLen, tt.wantPages)
+			}
+			if total != tt.total {
+				t.Fatalf("total = %d, want %d", total, tt.total)
+			}
+			for i, v := range got {
+				if v != i {
+					t.Fatalf("result %d = %d, want results in page order", i, v)
+				}
+			}
+		})
+	}
+}
+
+func TestFetchPagesError(t *testing.T) {
+	boom := errors.New("boom")
+	calls := 0
+	got, total, err := fetchPages(10, 0, func(page, perPage int) ([]int, int64, error) {
+		calls++
+		if page == 2 {
+			return nil, 0, boom
+		}
+		return make([]int, perPage), 30, nil
+	})
+	if !errors.Is(err, boom) || got != nil || total != 0 {
+		t.Fatalf("fetchPages = %v, %d, %v; want no results and the page error", got, total, err)
+	}
+	if calls != 2 {
+		t.Fatalf("%d pages fetched, want to stop at the failing one", calls)
+	}
+}
+
+func TestListPagingFromArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    map[string]interface{}
+		want    listPaging
+		wantErr string
+	}{
+		{"defaults", map[string]interface{}{}, listPaging{max: defaultListMax}, ""},
+		{"one page", map[string]interface{}{"page": float64(3), "per_page": float64(50)}, listPaging{page: 3, perPage: 50, max: defaultListMax}, ""},
+		{"all", map[string]interface{}{"all": true, "max_results": float64(200)}, listPaging{all: true, max: 200}, ""},
+		{"max capped", map[string]interface{}{"all": true, "max_results": float64(maxListMax + 1)}, listPaging{all: true, max: maxListMax}, ""},
+		{"negative page", map[string]interface{}{"page": float64(-1)}, listPaging{}, "must not be negative"},
+		{"negative per_page", map[string]interface{}{"per_page": float64(-5)}, listPaging{}, "must not be negative"},
+		{"negative max", map[string]interface{}{"max_results": float64(-1)}, listPaging{}, "must not be negative"},
+		{"all with a page", map[string]interface{}{"all": true, "page": float64(2)}, listPaging{}, "cannot be combined"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := listPagingFromArgs(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPagedResult(t *testing.T) {
+	single := pagedResult("items", []int{}, 10, 35, listPaging{page: 3, perPage: 10})
+	if single["has_more"] != true {
+		t.Fatalf("page 3 of 35 at 10 per page: has_more = %v, want true", single["has_more"])
+	}
+	last := pagedResult("items", []int{}, 5, 35, listPaging{page: 4, perPage: 10})
+	if last["has_more"] != false {
+		t.Fatalf("last page: has_more = %v, want false", last["has_more"])
+	}
+	truncated := pagedResult("items", []int{}, 100, 250, listPaging{all: true, max: 100})
+	if truncated["truncated"] != true || truncated["note"] == nil {
+		t.Fatalf("all stopped at max: %v, want truncated with a note", truncated)
+	}
+}
@@ -0,0 +1,299 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaExplainPanelErrorsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_explain_panel_errors",
+		Description: "Re-run a dashboard's panel queries and explain the ones that fail: each backend error (parse errors, series and sample limits, timeouts, missing datasources, permissions) comes back panel by panel with a plain-language explanation and a suggested fix",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"dashboard_uid": {Type: "string", Description: "UID of the dashboard to check"},
+				"panel_ids":     {Type: "array", Description: "Panels to check (default: every panel)"},
+				"from":          {Type: "string", Description: "Start of the time range (default now-6h)"},
+				"to":            {Type: "string", Description: "End of the time range (default now)"},
+				"vars":          {Type: "object", Description: "Template variable values overriding the dashboard's current ones, e.g. {\"env\": \"prod\"}"},
+				"include_ok":    {Type: "boolean", Description: "Also list panels whose queries all succeed"},
+			},
+			Required: []string{"dashboard_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// queryErrorKind is a family of backend errors with the same cause
+type queryErrorKind struct {
+	kind        string
+	pattern     *regexp.Regexp
+	explanation string
+	fix         string
+}
+
+// queryErrorKinds are checked in order, so narrower patterns come first
+var queryErrorKinds = []queryErrorKind{
+	{
+		kind:        "unresolved_variable",
+		pattern:     regexp.MustCompile(`(?i)(unexpected|invalid|illegal|unknown)[^\n]*\$\{?\w`),
+		explanation: "A dashboard variable was sent to the datasource without being replaced, usually because it has no current value or is used outside the panel's datasource",
+		fix:         "Give the variable a value with vars, or check its name against the dashboard's templating list",
+	},
+	{
+		kind:        "series_limit",
+		pattern:     regexp.MustCompile(`(?i)maximum number of series|max(imum)?[ _]series|series limit|too many series|max_query_series|limit of \d+ series`),
+		explanation: "The query matches more series than the datasource returns in one response",
+		fix:         "Narrow the selector with more label matchers, or aggregate with sum by (...) to the labels the panel shows",
+	},
+	{
+		kind:        "sample_limit",
+		pattern:     regexp.MustCompile(`(?i)too many samples|max(imum)?[ _]samples|query processing would load`),
+		explanation: "Evaluating the query would load more samples into memory than the datasource allows",
+		fix:         "Shorten the time range or range vectors, raise the panel's min interval, or pre-aggregate with a recording rule",
+	},
+	{
+		kind:        "resolution",
+		pattern:     regexp.MustCompile(`(?i)exceeded maximum resolution|too many (data )?points|max(imum)? data ?points`),
+		explanation: "The time range divided by the step gives more points per series than the datasource returns",
+		fix:         "Raise the panel's min interval or lower max data points in its query options",
+	},
+	{
+		kind:        "range_limit",
+		pattern:     regexp.MustCompile(`(?i)time range exceeds|query_length|max_query_length|query time range .*(limit|too long)|max_query_lookback|too far (in the past|back)`),
+		explanation: "The time range is longer than, or reaches further back than, the datasource allows for a single query",
+		fix:         "Query a shorter range, or split it with a relative time override on the panel",
+	},
+	{
+		kind:        "timeout",
+		pattern:     regexp.MustCompile(`(?i)deadline exceeded|timed? ?out|timeout|context canceled|\b504\b`),
+		explanation: "The datasource did not answer before the query timeout",
+		fix:         "Make the query cheaper (fewer series, shorter ranges, a recording rule) or raise the datasource's timeout setting",
+	},
+	{
+		kind:        "many_to_many",
+		pattern:     regexp.MustCompile(`(?i)many-to-many matching|same labelset|found duplicate series|multiple matches for labels`),
+		explanation: "A binary operation matched several series on one side to the same series on the other",
+		fix:         "Match on the labels that identify a series with on(...) or ignoring(...), adding group_left or group_right for one-to-many joins",
+	},
+	{
+		kind:        "parse",
+		pattern:     regexp.MustCompile(`(?i)parse error|syntax error|unexpected|bad_data|invalid (query|expression)|could not parse|unknown function|not defined`),
+		explanation: "The datasource could not parse the query",
+		fix:         "Fix the query at the position the error gives; grafana_validate_promql, grafana_validate_logql, and grafana_validate_traceql locate errors in their languages",
+	},
+	{
+		kind:        "datasource_missing",
+		pattern:     regexp.MustCompile(`(?i)data ?source .*not found|datasource not found|default datasource but none`),
+		explanation: "The panel refers to a datasource that does not exist in this Grafana",
+		fix:         "Point the panel at an existing datasource, or map the old one with grafana_import_dashboard",
+	},
+	{
+		kind:        "permission",
+		pattern:     regexp.MustCompile(`(?i)\b(401|403)\b|unauthori[sz]ed|forbidden|permission denied|access denied|not allowed`),
+		explanation: "The credentials used for the query may not read this datasource, or the datasource's own credentials were rejected",
+		fix:         "Check the datasource's permissions in Grafana and the credentials stored on it",
+	},
+	{
+		kind:        "rate_limit",
+		pattern:     regexp.MustCompile(`(?i)\b429\b|too many requests|rate limit`),
+		explanation: "The datasource is rejecting queries because too many arrived at once",
+		fix:         "Retry later, or reduce how many panels query at once by collapsing rows or raising the dashboard refresh interval",
+	},
+	{
+		kind:        "unreachable",
+		pattern:     regexp.MustCompile(`(?i)connection refused|no such host|dial tcp|bad gateway|\b502\b|\b503\b|connection reset|\bEOF\b`),
+		explanation: "Grafana could not reach the datasource",
+		fix:         "Check the datasource URL and that its backend is running",
+	},
+}
+
+// panelQueryError is one failing query with what it likely means
+type panelQueryError struct {
+	RefID       string `json:"ref_id,omitempty"`
+	Datasource  string `json:"datasource,omitempty"`
+	Query       string `json:"query,omitempty"`
+	Error       string `json:"error"`
+	Kind        string `json:"kind"`
+	Explanation string `json:"explanation,omitempty"`
+	Fix         string `json:"fix,omitempty"`
+}
+
+// panelErrors is the outcome of re-running one panel's queries
+type panelErrors struct {
+	PanelID  int64             `json:"panel_id"`
+	Title    string            `json:"title,omitempty"`
+	Queries  int               `json:"queries"`
+	Errors   []panelQueryError `json:"errors,omitempty"`
+	PanelURL string            `json:"panel_url,omitempty"`
+}
+
+func (r *Registry) handleExplainPanelErrors(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "dashboard_uid")
+	if uid == "" {
+		return errorResult("dashboard_uid is required"), nil
+	}
+	from, to := getString(args, "from"), getString(args, "to")
+	start, end, err := parseTimeRange(from, to, "now-6h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		return apiErrorResult("Failed to get dashboard", err), nil
+	}
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list datasources", err), nil
+	}
+
+	var panels []map[string]interface{}
+	if ids := getInt64Slice(args, "panel_ids"); len(ids) > 0 {
+		for _, id := range ids {
+			p := dashboard.FindPanel(dash.Dashboard, id)
+			if p == nil {
+				return errorResult(fmt.Sprintf("panel %d not found", id)), nil
+			}
+			panels = append(panels, p)
+		}
+	} else {
+		for _, p := range dashboard.Panels(dash.Dashboard) {
+			if !dashboard.IsRow(p) {
+				panels = append(panels, p)
+			}
+		}
+	}
+
+	userVars := getStringMap(args, "vars")
+	vars := dashboard.CurrentValues(dash.Dashboard)
+	for name, v := range userVars {
+		vars[name] = []string{v}
+	}
+	resolver := newDatasourceResolver(datasources, vars)
+	names := make(map[string]string, len(datasources))
+	for _, ds := range datasources {
+		names[ds.UID] = ds.Name
+	}
+
+	results := make([]*panelErrors, len(panels))
+	sem := make(chan struct{}, defaultAnomalyConcurrency)
+	var wg sync.WaitGroup
+	for i, p := range panels {
+		pe := &panelErrors{PanelID: dashboard.PanelID(p), Title: dashboard.String(p, "title")}
+		results[i] = pe
+		queries, err := panelQueries(p, vars, resolver, 300)
+		if err != nil {
+			pe.Errors = []panelQueryError{explainQueryError(panelQueryError{Error: err.Error()})}
+			continue
+		}
+		pe.Queries = len(queries)
+		if len(queries) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(pe *panelErrors, queries []grafana.QueryTarget) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			pe.Errors = r.panelQueryErrors(queries, names, start, end)
+		}(pe, queries)
+	}
+	wg.Wait()
+
+	out := []*panelErrors{}
+	failing := 0
+	for _, pe := range results {
+		if len(pe.Errors) > 0 {
+			failing++
+			pe.PanelURL = r.client.PanelURL(uid, pe.PanelID, from, to, userVars)
+		} else if !getBool(args, "include_ok") {
+			continue
+		}
+		out = append(out, pe)
+	}
+	return jsonResult(map[string]interface{}{
+		"dashboard":          dashboard.String(dash.Dashboard, "title"),
+		"from":               r.formatMillis(start.UnixMilli()),
+		"to":                 r.formatMillis(end.UnixMilli()),
+		"panels_checked":     len(results),
+		"panels_with_errors": failing,
+		"panels":             out,
+	})
+}
+
+// panelQueryErrors runs a panel's queries uncached and returns the ones that
+// failed. Grafana answers a failed query with a 4xx or 5xx status whose body
+// still carries the per-query results, so those are unpacked from the error.
+func (r *Registry) panelQueryErrors(queries []grafana.QueryTarget, names map[string]string, start, end time.Time) []panelQueryError {
+	resp, err := r.runQuery(grafana.QueryRequest{
+		From:    fmt.Sprintf("%d", start.UnixMilli()),
+		To:      fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: queries,
+	}, false)
+	if err != nil {
+		var apiErr *grafana.APIError
+		var body grafana.QueryResponse
+		if !errors.As(err, &apiErr) || json.Unmarshal([]byte(apiErr.Body), &body) != nil || len(body.Results) == 0 {
+			body.Results = make(map[string]grafana.QueryResult, len(queries))
+			for _, q := range queries {
+				body.Results[q.RefID] = grafana.QueryResult{Error: err.Error()}
+			}
+		}
+		resp = &body
+	}
+
+	var out []panelQueryError
+	for _, q := range queries {
+		res, ok := resp.Results[q.RefID]
+		if !ok || res.Error == "" {
+			continue
+		}
+		out = append(out, explainQueryError(panelQueryError{
+			RefID:      q.RefID,
+			Datasource: names[q.Datasource.UID],
+			Query:      panelQueryText(q),
+			Error:      res.Error,
+		}))
+	}
+	return out
+}
+
+// explainQueryError fills in the kind, explanation, and fix of the first
+// error family qe.Error matches
+func explainQueryError(qe panelQueryError) panelQueryError {
+	for _, k := range queryErrorKinds {
+		if k.pattern.MatchString(qe.Error) {
+			qe.Kind, qe.Explanation, qe.Fix = k.kind, k.explanation, k.fix
+			return qe
+		}
+	}
+	qe.Kind = "unknown"
+	return qe
+}
+
+// panelQueryText returns the query a target sends, whichever field its
+// datasource keeps it in
+func panelQueryText(q grafana.QueryTarget) string {
+	for _, key := range []string{"expr", "query", "rawSql", "rawQuery", "queryText", "target"} {
+		if s, ok := q.Extra[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	if q.Query != "" {
+		return q.Query
+	}
+	return q.RawQuery
+}
@@ -0,0 +1,412 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// permissionsFolderLimit is the most folders the permissions report covers
+const permissionsFolderLimit = 5000
+
+// permissionsDashboardLimit is the default number of dashboards whose own
+// permissions the report checks
+const permissionsDashboardLimit = 500
+
+func (r *Registry) grafanaPermissionsReportTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_permissions_report",
+		Description: "Audit folder and dashboard permissions in one compliance report: every folder's ACL, dashboards with permissions of their own, and public dashboards, flagging items the Viewer role can edit, public dashboards anyone with the link can open, and folders where no user or team is admin",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"folder_uid":         {Type: "string", Description: "Only this folder and its dashboards"},
+				"include_dashboards": {Type: "boolean", Description: "Check each dashboard's own permissions (default true); false audits folders and public dashboards only"},
+				"max_dashboards":     {Type: "integer", Description: "Most dashboards to check (default 500)"},
+				"format":             {Type: "string", Description: "Report format (default json); markdown suits audit evidence", Enum: []string{"json", "markdown"}},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// permissionGrant is an ACL entry as the report lists it
+type permissionGrant struct {
+	Principal  string `json:"principal"`
+	Permission string `json:"permission"`
+}
+
+// permissionItem is a folder or dashboard with the grants it holds
+type permissionItem struct {
+	UID         string            `json:"uid"`
+	Title       string            `json:"title"`
+	FolderTitle string            `json:"folder,omitempty"`
+	URL         string            `json:"url,omitempty"`
+	Grants      []permissionGrant `json:"permissions"`
+}
+
+// permissionFinding is one compliance issue in the report
+type permissionFinding struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`
+	Kind     string `json:"kind"`
+	UID      string `json:"uid"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail"`
+	URL      string `json:"url,omitempty"`
+}
+
+// permissionsReport is the result of grafana_permissions_report
+type permissionsReport struct {
+	GeneratedAt      string                    `json:"generated_at"`
+	Scope            string                    `json:"scope"`
+	Summary          map[string]int            `json:"summary"`
+	FindingsByCheck  map[string]int            `json:"findings_by_check"`
+	Findings         []permissionFinding       `json:"findings"`
+	Folders          []permissionItem          `json:"folders"`
+	Dashboards       []permissionItem          `json:"dashboards_with_own_permissions,omitempty"`
+	PublicDashboards []grafana.PublicDashboard `json:"public_dashboards"`
+	Notes            []string                  `json:"notes,omitempty"`
+}
+
+func (r *Registry) handlePermissionsReport(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	format := getString(args, "format")
+	if format == "" {
+		format = "json"
+	}
+	if format != "json" && format != "markdown" {
+		return errorResult("format must be json or markdown"), nil
+	}
+	folderUID := getString(args, "folder_uid")
+	includeDashboards := true
+	if _, ok := args["include_dashboards"]; ok {
+		includeDashboards = getBool(args, "include_dashboards")
+	}
+	limit := getInt(args, "max_dashboards")
+	if limit <= 0 {
+		limit = permissionsDashboardLimit
+	}
+
+	report := &permissionsReport{
+		GeneratedAt: r.formatMillis(time.Now().UnixMilli()),
+		Scope:       "organization",
+		Findings:    []permissionFinding{},
+	}
+	var folders []grafana.SearchDashboardsResponse
+	if folderUID != "" {
+		folder, err := r.client.GetFolder(r.ctx, folderUID)
+		if err != nil {
+			return apiErrorResult("Failed to get folder", err), nil
+		}
+		folders = []grafana.SearchDashboardsResponse{{UID: folder.UID, Title: folder.Title, URL: folder.URL}}
+		report.Scope = "folder " + folder.Title
+	} else {
+		hits, err := r.client.Search(r.ctx, grafana.SearchQuery{Type: "dash-folder", Limit: permissionsFolderLimit})
+		if err != nil {
+			return apiErrorResult("Failed to list folders", err), nil
+		}
+		folders = hits
+		if len(hits) >= permissionsFolderLimit {
+			report.Notes = append(report.Notes, fmt.Sprintf("only the first %d folders were checked", permissionsFolderLimit))
+		}
+	}
+	// Dashboards are listed even when their ACLs are not checked, to place
+	// public dashboards in the folder scope
+	q := grafana.SearchQuery{Type: "dash-db", Limit: limit}
+	if folderUID != "" {
+		q.FolderUIDs = []string{folderUID}
+	}
+	dashboards, err := r.client.Search(r.ctx, q)
+	if err != nil {
+		return apiErrorResult("Failed to search dashboards", err), nil
+	}
+	if len(dashboards) >= limit {
+		report.Notes = append(report.Notes, fmt.Sprintf("only the first %d dashboards were checked; raise max_dashboards to check more", limit))
+	}
+	checked := dashboards
+	if !includeDashboards {
+		checked = nil
+	}
+
+	// ACLs are fetched in parallel and reported in search order
+	folderACLs := make([][]grafana.Permission, len(folders))
+	dashACLs := make([][]grafana.Permission, len(checked))
+	var mu sync.Mutex
+	sem := make(chan struct{}, defaultAnomalyConcurrency)
+	var wg sync.WaitGroup
+	fetch := func(kind, uid string, get func() ([]grafana.Permission, error), into *[]grafana.Permission) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			acl, err := get()
+			if err != nil {
+				mu.Lock()
+				report.Notes = append(report.Notes, fmt.Sprintf("%s %s skipped: %v", kind, uid, err))
+				mu.Unlock()
+				return
+			}
+			*into = acl
+		}()
+	}
+	for i, f := range folders {
+		uid := f.UID
+		fetch("folder", uid, func() ([]grafana.Permission, error) { return r.client.GetFolderPermissions(r.ctx, uid) }, &folderACLs[i])
+	}
+	for i, d := range checked {
+		uid := d.UID
+		fetch("dashboard", uid, func() ([]grafana.Permission, error) { return r.client.GetDashboardPermissions(r.ctx, uid) }, &dashACLs[i])
+	}
+	public, publicErr := r.client.GetPublicDashboards(r.ctx)
+	wg.Wait()
+
+	flag := func(check, severity, kind string, hit grafana.SearchDashboardsResponse, detail string) {
+		report.Findings = append(report.Findings, permissionFinding{
+			Check: check, Severity: severity, Kind: kind,
+			UID: hit.UID, Title: hit.Title, Detail: detail, URL: r.itemURL(hit.URL),
+		})
+	}
+	report.Folders = []permissionItem{}
+	for i, f := range folders {
+		if folderACLs[i] == nil {
+			continue
+		}
+		item := permissionItem{UID: f.UID, Title: f.Title, FolderTitle: f.FolderTitle, URL: r.itemURL(f.URL), Grants: permissionGrants(folderACLs[i])}
+		report.Folders = append(report.Folders, item)
+		if p := viewerCanEdit(folderACLs[i]); p != "" {
+			flag("viewer_can_edit", "high", "folder", f, fmt.Sprintf("the Viewer role has %s on the folder and every dashboard in it", p))
+		}
+		if !hasAdmin(folderACLs[i]) {
+			flag("folder_without_admin", "medium", "folder", f, "no user or team is admin, so only organization admins can manage its permissions")
+		}
+	}
+	customized := 0
+	for i, d := range checked {
+		var own []grafana.Permission
+		for _, p := range dashACLs[i] {
+			if !p.Inherited {
+				own = append(own, p)
+			}
+		}
+		if len(own) == 0 {
+			continue
+		}
+		customized++
+		report.Dashboards = append(report.Dashboards, permissionItem{UID: d.UID, Title: d.Title, FolderTitle: d.FolderTitle, URL: r.itemURL(d.URL), Grants: permissionGrants(own)})
+		if p := viewerCanEdit(own); p != "" {
+			flag("viewer_can_edit", "high", "dashboard", d, fmt.Sprintf("the Viewer role has %s on the dashboard", p))
+		}
+	}
+
+	report.PublicDashboards = []grafana.PublicDashboard{}
+	switch {
+	case isNotFound(publicErr):
+		report.Notes = append(report.Notes, "this Grafana has no public dashboards API, so public dashboards were not checked")
+	case publicErr != nil:
+		report.Notes = append(report.Notes, fmt.Sprintf("public dashboards were not checked: %v", publicErr))
+	default:
+		inScope := map[string]grafana.SearchDashboardsResponse{}
+		for _, d := range dashboards {
+			inScope[d.UID] = d
+		}
+		for _, pd := range public {
+			hit, ok := inScope[pd.DashboardUID]
+			if folderUID != "" && !ok {
+				continue
+			}
+			report.PublicDashboards = append(report.PublicDashboards, pd)
+			if !pd.IsEnabled || (pd.ShareType != "" && pd.ShareType != "public") {
+				continue
+			}
+			if !ok {
+				hit = grafana.SearchDashboardsResponse{UID: pd.DashboardUID, Title: pd.Title}
+			}
+			finding := permissionFinding{
+				Check: "public_dashboard", Severity: "high", Kind: "dashboard",
+				UID: hit.UID, Title: hit.Title, URL: r.itemURL(hit.URL),
+				Detail: "anyone with the link can view it without logging in",
+			}
+			if pd.AccessToken != "" {
+				finding.Detail += " at " + r.client.BaseURL() + "/public-dashboards/" + pd.AccessToken
+			}
+			report.Findings = append(report.Findings, finding)
+		}
+	}
+
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return severityRank(report.Findings[i].Severity) < severityRank(report.Findings[j].Severity)
+	})
+	sort.Strings(report.Notes)
+	report.Summary = map[string]int{
+		"folders":                   len(report.Folders),
+		"dashboards_checked":        len(checked),
+		"dashboards_with_own_perms": customized,
+		"public_dashboards":         len(report.PublicDashboards),
+		"findings":                  len(report.Findings),
+	}
+	report.FindingsByCheck = map[string]int{}
+	for _, f := range report.Findings {
+		report.FindingsByCheck[f.Check]++
+	}
+
+	if format == "markdown" {
+		return &mcp.CallToolResult{
+			Content: []mcp.ContentBlock{{Type: "text", Text: permissionsMarkdown(report)}},
+		}, nil
+	}
+	return jsonResult(report)
+}
+
+// itemURL makes a search hit's relative URL absolute
+func (r *Registry) itemURL(path string) string {
+	if path == "" {
+		return ""
+	}
+	return r.client.BaseURL() + path
+}
+
+// permissionGrants lists an ACL by principal
+func permissionGrants(acl []grafana.Permission) []permissionGrant {
+	out := make([]permissionGrant, 0, len(acl))
+	for _, p := range acl {
+		out = append(out, permissionGrant{Principal: permissionPrincipal(p), Permission: permissionName(p)})
+	}
+	return out
+}
+
+func permissionPrincipal(p grafana.Permission) string {
+	switch {
+	case p.UserID != 0 || p.UserLogin != "":
+		return "user:" + p.UserLogin
+	case p.TeamID != 0 || p.Team != "":
+		return "team:" + p.Team
+	default:
+		return "role:" + p.Role
+	}
+}
+
+func permissionName(p grafana.Permission) string {
+	if p.PermissionName != "" {
+		return p.PermissionName
+	}
+	switch p.Permission {
+	case grafana.PermissionAdmin:
+		return "Admin"
+	case grafana.PermissionEdit:
+		return "Edit"
+	case grafana.PermissionView:
+		return "View"
+	}
+	return fmt.Sprintf("%d", p.Permission)
+}
+
+// viewerCanEdit returns the permission an ACL gives the Viewer role when it
+// is Edit or higher
+func viewerCanEdit(acl []grafana.Permission) string {
+	for _, p := range acl {
+		if p.Role == "Viewer" && p.UserID == 0 && p.TeamID == 0 && p.Permission >= grafana.PermissionEdit {
+			return permissionName(p)
+		}
+	}
+	return ""
+}
+
+// hasAdmin reports whether a user or team is admin in an ACL
+func hasAdmin(acl []grafana.Permission) bool {
+	for _, p := range acl {
+		if (p.UserID != 0 || p.TeamID != 0) && p.Permission >= grafana.PermissionAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+func severityRank(s string) int {
+	switch s {
+	case "high":
+		return 0
+	case "medium":
+		return 1
+	}
+	return 2
+}
+
+// permissionsMarkdown renders the report as a document for audit evidence
+func permissionsMarkdown(rep *permissionsReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Grafana permissions report\n\nScope: %s  \nGenerated: %s\n\n", rep.Scope, rep.GeneratedAt)
+
+	b.WriteString("## Summary\n\n| Item | Count |\n|---|---|\n")
+	keys := make([]string, 0, len(rep.Summary))
+	for k := range rep.Summary {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "| %s | %d |\n", k, rep.Summary[k])
+	}
+
+	b.WriteString("\n## Findings\n\n")
+	if len(rep.Findings) == 0 {
+		b.WriteString("No findings.\n")
+	} else {
+		b.WriteString("| Severity | Check | Kind | Title | UID | Detail |\n|---|---|---|---|---|---|\n")
+		for _, f := range rep.Findings {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", f.Severity, f.Check, f.Kind, markdownCell(f.Title), f.UID, markdownCell(f.Detail))
+		}
+	}
+
+	writeItems := func(title string, items []permissionItem) {
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		if len(items) == 0 {
+			b.WriteString("None.\n")
+			return
+		}
+		b.WriteString("| Title | UID | Permissions |\n|---|---|---|\n")
+		for _, item := range items {
+			grants := make([]string, len(item.Grants))
+			for i, g := range item.Grants {
+				grants[i] = g.Principal + " " + g.Permission
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s |\n", markdownCell(item.Title), item.UID, markdownCell(strings.Join(grants, ", ")))
+		}
+	}
+	writeItems("Folder permissions", rep.Folders)
+	writeItems("Dashboards with their own permissions", rep.Dashboards)
+
+	b.WriteString("\n## Public dashboards\n\n")
+	if len(rep.PublicDashboards) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Title | Dashboard UID | Enabled | Share type |\n|---|---|---|---|\n")
+		for _, pd := range rep.PublicDashboards {
+			share := pd.ShareType
+			if share == "" {
+				share = "public"
+			}
+			fmt.Fprintf(&b, "| %s | %s | %t | %s |\n", markdownCell(pd.Title), pd.DashboardUID, pd.IsEnabled, share)
+		}
+	}
+
+	if len(rep.Notes) > 0 {
+		b.WriteString("\n## Notes\n\n")
+		for _, n := range rep.Notes {
+			fmt.Fprintf(&b, "- %s\n", n)
+		}
+	}
+	return b.String()
+}
+
+// markdownCell escapes text for a markdown table cell
+func markdownCell(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(s)
+}
@@ -0,0 +1,431 @@
+package tools
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	// probeUID is the default uid of the endpoint probes folder and
+	// dashboard, and the name of the alert rule group
+	probeUID         = "endpoint-probes"
+	probeTitle       = "Endpoint Probes"
+	probeVariable    = "target"
+	defaultProbeFor  = "2m"
+	defaultCertDays  = 14
+	blackboxProbeURL = "/probe"
+)
+
+func (r *Registry) grafanaMonitorEndpointTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_monitor_endpoint",
+		Description: "Start monitoring an endpoint probed by a blackbox exporter: checks that the Prometheus scrapes a probe for it, adds it to the Endpoint Probes dashboard (status, uptime, latency, HTTP status, certificate expiry), and creates or updates an alert rule on probe_success, plus a certificate expiry rule for https. Re-running updates the rules in place",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"url":              {Type: "string", Description: "Probed target as the blackbox exporter sees it, e.g. https://example.com/health or host:443"},
+				"prometheus_uid":   {Type: "string", Description: "UID of the Prometheus datasource that scrapes the blackbox exporter"},
+				"job":              {Type: "string", Description: "Scrape job of the probe (default: detected from the scrape targets)"},
+				"folder_uid":       {Type: "string", Description: "Folder for the dashboard and alert rules (default endpoint-probes, created if missing)"},
+				"dashboard_uid":    {Type: "string", Description: "Probe dashboard to create or add the endpoint to (default endpoint-probes)"},
+				"for":              {Type: "string", Description: "How long the probe must fail before the alert fires (default 2m)"},
+				"cert_expiry_days": {Type: "integer", Description: "Days before certificate expiry to alert for https endpoints (default 14, 0 for no certificate rule)"},
+				"labels":           {Type: "object", Description: "Extra labels for the alert rules, e.g. {\"team\": \"web\"}"},
+				"dry_run":          {Type: "boolean", Description: "Report what would be created or updated without saving anything"},
+			},
+			Required: []string{"url", "prometheus_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// probeStatus is what the scrape targets say about an endpoint's probe
+type probeStatus struct {
+	Target     string   `json:"target"`
+	Configured bool     `json:"configured"`
+	Job        string   `json:"job,omitempty"`
+	Module     string   `json:"module,omitempty"`
+	Health     string   `json:"health,omitempty"`
+	LastError  string   `json:"last_error,omitempty"`
+	Exporter   string   `json:"exporter,omitempty"`
+	ProbeJobs  []string `json:"probe_jobs"`
+}
+
+func (r *Registry) handleMonitorEndpoint(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target := strings.TrimSpace(getString(args, "url"))
+	promUID := getString(args, "prometheus_uid")
+	if target == "" || promUID == "" {
+		return errorResult("url and prometheus_uid are required"), nil
+	}
+	https := false
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			return errorResult(fmt.Sprintf("url %q is not a valid URL", target)), nil
+		}
+		https = u.Scheme == "https"
+	}
+	certDays := defaultCertDays
+	if _, ok := args["cert_expiry_days"]; ok {
+		certDays = getInt(args, "cert_expiry_days")
+	}
+	forDuration := getString(args, "for")
+	if forDuration == "" {
+		forDuration = defaultProbeFor
+	}
+	folderUID := getString(args, "folder_uid")
+	if folderUID == "" {
+		folderUID = probeUID
+	}
+	dashUID := getString(args, "dashboard_uid")
+	if dashUID == "" {
+		dashUID = probeUID
+	}
+
+	ds, err := r.client.GetDatasource(r.ctx, promUID)
+	if err != nil {
+		return apiErrorResult("Failed to get datasource", err), nil
+	}
+	if ds.Type != "prometheus" {
+		return errorResult(fmt.Sprintf("datasource %s is a %s datasource; blackbox probes are read from Prometheus", ds.Name, ds.Type)), nil
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: promUID, Type: ds.Type}); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	probe, err := r.findProbe(promUID, target, getString(args, "job"))
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if len(probe.ProbeJobs) == 0 {
+		return errorResult(fmt.Sprintf("no blackbox exporter found behind datasource %s: no scrape target uses %s and no probe_success series exist", ds.Name, blackboxProbeURL)), nil
+	}
+	var notes []string
+	if !probe.Configured {
+		notes = append(notes, fmt.Sprintf("%s is not probed yet; Prometheus scrape configuration is not managed through Grafana, so add it to the targets of scrape job %q. The dashboard and alert rule pick it up once it is scraped, and the rule reports NoData until then", target, probe.Job))
+	} else if probe.Health == "down" {
+		notes = append(notes, fmt.Sprintf("the probe scrape is failing: %s", probe.LastError))
+	}
+
+	labels := map[string]string{"probe": "blackbox", "target": target}
+	for k, v := range getStringMap(args, "labels") {
+		labels[k] = v
+	}
+	selector := probeSelector(probe.Job, target)
+	rules := []grafana.AlertRule{probeRule(folderUID, dashUID, promUID, "Endpoint down: "+target,
+		fmt.Sprintf("%s is failing its blackbox probe", target),
+		"probe_success"+selector, "lt", 1, forDuration, "critical", labels)}
+	if https && certDays > 0 {
+		cert := probeRule(folderUID, dashUID, promUID, "Certificate expiring: "+target,
+			fmt.Sprintf("The TLS certificate of %s expires in less than %d days", target, certDays),
+			fmt.Sprintf("(probe_ssl_earliest_cert_expiry%s - time()) / 86400", selector), "lt", float64(certDays), "10m", "warning", labels)
+		cert.NoDataState = "OK"
+		rules = append(rules, cert)
+	}
+
+	dryRun := getBool(args, "dry_run")
+	var steps []installStep
+	ok := true
+	add := func(s installStep) {
+		if s.Status == "failed" {
+			ok = false
+		}
+		steps = append(steps, s)
+	}
+	if dryRun {
+		s := installStep{Step: "folder", UID: folderUID, Status: "exists"}
+		if _, err := r.client.GetFolder(r.ctx, folderUID); isNotFound(err) {
+			s.Status = "created"
+		} else if err != nil {
+			s.Status, s.Detail = "failed", err.Error()
+		}
+		add(s)
+	} else {
+		add(r.ensureFolder(folderUID, probeTitle))
+		if !ok {
+			return r.monitorEndpointResult(probe, steps, notes, dryRun, false)
+		}
+	}
+	add(r.addProbeToDashboard(dashUID, folderUID, dashboard.Ref(ds.Type, ds.UID), target, dryRun))
+	for _, s := range r.upsertAlertRules(rules, dryRun) {
+		add(s)
+	}
+	return r.monitorEndpointResult(probe, steps, notes, dryRun, ok)
+}
+
+func (r *Registry) monitorEndpointResult(probe *probeStatus, steps []installStep, notes []string, dryRun, ok bool) (*mcp.CallToolResult, error) {
+	out := map[string]interface{}{
+		"probe": probe,
+		"steps": steps,
+	}
+	if dryRun {
+		out["dry_run"] = true
+	}
+	if len(notes) > 0 {
+		out["notes"] = notes
+	}
+	result, err := jsonResult(out)
+	if result != nil && !ok {
+		result.IsError = true
+	}
+	return result, err
+}
+
+// findProbe looks for target among the blackbox exporter scrape targets,
+// recognised by their /probe path. Where the targets API is unavailable,
+// as on Mimir, the probe_success series are used instead.
+func (r *Registry) findProbe(dsUID, target, job string) (*probeStatus, error) {
+	probe := &probeStatus{Target: target, Job: job, ProbeJobs: []string{}}
+	jobs := map[string]bool{}
+
+	targets, err := r.client.GetPrometheusTargets(r.ctx, dsUID, "active")
+	if err == nil {
+		for _, t := range targets.Active {
+			u, err := url.Parse(t.ScrapeURL)
+			if err != nil || u.Path != blackboxProbeURL {
+				continue
+			}
+			tJob := t.Labels["job"]
+			if tJob == "" {
+				tJob = t.ScrapePool
+			}
+			jobs[tJob] = true
+			if job != "" && tJob != job {
+				continue
+			}
+			if probe.Exporter == "" {
+				probe.Exporter = u.Host
+			}
+			q := u.Query()
+			if q.Get("target") == target || t.Labels["instance"] == target {
+				probe.Configured = true
+				probe.Job, probe.Module, probe.Exporter = tJob, q.Get("module"), u.Host
+				probe.Health, probe.LastError = t.Health, t.LastError
+			}
+		}
+	} else {
+		names, err := r.client.GetPrometheusLabelValues(r.ctx, dsUID, "job", []string{"probe_success"})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to look up probe jobs: %v", err)
+		}
+		for _, n := range names {
+			jobs[n] = true
+		}
+		found, err := r.client.GetPrometheusLabelValues(r.ctx, dsUID, "job", []string{"probe_success" + probeSelector(job, target)})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to look up probe: %v", err)
+		}
+		if len(found) > 0 {
+			probe.Configured, probe.Job = true, found[0]
+		}
+	}
+
+	for j := range jobs {
+		probe.ProbeJobs = append(probe.ProbeJobs, j)
+	}
+	sort.Strings(probe.ProbeJobs)
+	if probe.Job == "" && len(probe.ProbeJobs) > 0 {
+		probe.Job = probe.ProbeJobs[0]
+	}
+	return probe, nil
+}
+
+// probeSelector matches target's probe series, within job when known
+func probeSelector(job, target string) string {
+	if job == "" {
+		return fmt.Sprintf("{instance=%q}", target)
+	}
+	return fmt.Sprintf("{job=%q, instance=%q}", job, target)
+}
+
+func probeRule(folderUID, dashUID, dsUID, title, summary, expr, op string, threshold float64, forDuration, severity string, labels map[string]string) grafana.AlertRule {
+	ruleLabels := map[string]string{"severity": severity}
+	for k, v := range labels {
+		ruleLabels[k] = v
+	}
+	return grafana.AlertRule{
+		Title:        title,
+		FolderUID:    folderUID,
+		RuleGroup:    probeUID,
+		Condition:    "B",
+		For:          forDuration,
+		NoDataState:  "NoData",
+		ExecErrState: "Error",
+		Labels:       ruleLabels,
+		Annotations: map[string]string{
+			"summary":          summary,
+			"__dashboardUid__": dashUID,
+		},
+		Data: thresholdRuleData(dsUID, expr, op, threshold),
+	}
+}
+
+// upsertAlertRules creates rules, or updates those whose title already
+// exists in the rule's folder, keeping their pause state, notification
+// settings, and any labels added since
+func (r *Registry) upsertAlertRules(rules []grafana.AlertRule, dryRun bool) []installStep {
+	existing, err := r.client.GetAlertRules(r.ctx)
+	if err != nil {
+		return []installStep{{Step: "alert_rules", Status: "failed", Detail: err.Error()}}
+	}
+	steps := make([]installStep, 0, len(rules))
+	for _, rule := range rules {
+		s := installStep{Step: "alert_rule", Status: "created", Detail: rule.Title}
+		var current *grafana.AlertRule
+		for i, e := range existing {
+			if e.FolderUID == rule.FolderUID && e.Title == rule.Title {
+				current = &existing[i]
+			}
+		}
+		if current != nil {
+			s.Status, s.UID = "updated", current.UID
+			rule.UID, rule.IsPaused, rule.NotificationSettings = current.UID, current.IsPaused, current.NotificationSettings
+			for k, v := range current.Labels {
+				if _, ok := rule.Labels[k]; !ok {
+					rule.Labels[k] = v
+				}
+			}
+		}
+		if dryRun {
+			steps = append(steps, s)
+			continue
+		}
+		if current != nil {
+			_, err = r.client.UpdateAlertRuleKeepEditable(r.ctx, current.UID, rule)
+		} else {
+			var created *grafana.AlertRule
+			if created, err = r.client.CreateAlertRuleKeepEditable(r.ctx, rule); err == nil {
+				s.UID = created.UID
+			}
+		}
+		if err != nil {
+			s.Status, s.Detail = "failed", fmt.Sprintf("%s: %v", rule.Title, err)
+		}
+		steps = append(steps, s)
+	}
+	return steps
+}
+
+// addProbeToDashboard adds target to the endpoint variable of the probe
+// dashboard, creating the dashboard when it does not exist
+func (r *Registry) addProbeToDashboard(uid, folderUID string, ds map[string]interface{}, target string, dryRun bool) installStep {
+	s := installStep{Step: "dashboard", UID: uid}
+	current, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil && !isNotFound(err) {
+		s.Status, s.Detail = "failed", err.Error()
+		return s
+	}
+
+	var model map[string]interface{}
+	if current == nil {
+		s.Status, s.Detail = "created", probeTitle
+		model = probeDashboard(uid, ds, []string{target})
+	} else {
+		model = current.Dashboard
+		folderUID = current.Meta.FolderUID
+		v := probeTargetVariable(model)
+		if v == nil {
+			s.Status, s.Detail = "failed", fmt.Sprintf("dashboard %s has no custom %q variable to add the endpoint to; pass another dashboard_uid", uid, probeVariable)
+			return s
+		}
+		targets := splitCustomQuery(dashboard.String(v, "query"))
+		if contains(targets, target) {
+			s.Status, s.Detail = "exists", "endpoint already listed"
+			return s
+		}
+		s.Status, s.Detail = "updated", fmt.Sprintf("endpoint added to the %q variable", probeVariable)
+		v["query"] = joinCustomQuery(append(targets, target))
+		v["options"] = []interface{}{}
+	}
+	if dryRun {
+		return s
+	}
+	if _, err := r.client.SaveDashboardJSON(r.ctx, model, folderUID, "Added endpoint "+target+" via MCP", false); err != nil {
+		s.Status, s.Detail = "failed", err.Error()
+	}
+	return s
+}
+
+func probeTargetVariable(model map[string]interface{}) map[string]interface{} {
+	templating, _ := model["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, item := range list {
+		if v, ok := item.(map[string]interface{}); ok && dashboard.String(v, "name") == probeVariable && dashboard.String(v, "type") == "custom" {
+			return v
+		}
+	}
+	return nil
+}
+
+// splitCustomQuery splits the values of a custom variable, which are
+// separated by commas with literal commas escaped as \,
+func splitCustomQuery(query string) []string {
+	var values []string
+	for _, v := range strings.Split(strings.ReplaceAll(query, `\,`, "\x00"), ",") {
+		if v = strings.TrimSpace(strings.ReplaceAll(v, "\x00", ",")); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func joinCustomQuery(values []string) string {
+	sort.Strings(values)
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = strings.ReplaceAll(v, ",", `\,`)
+	}
+	return strings.Join(escaped, ",")
+}
+
+// probeDashboard builds the endpoint probes dashboard: every panel covers
+// the endpoints selected in the target variable
+func probeDashboard(uid string, ds map[string]interface{}, targets []string) map[string]interface{} {
+	sel := fmt.Sprintf(`{instance=~"$%s"}`, probeVariable)
+	target := func(expr string) map[string]interface{} {
+		return dashboard.Target("A", ds, expr, "{{instance}}")
+	}
+
+	status := dashboard.StatPanel("Status", ds, "", target("probe_success"+sel))
+	status["options"].(map[string]interface{})["textMode"] = "value_and_name"
+	status["options"].(map[string]interface{})["colorMode"] = "background"
+	status["fieldConfig"].(map[string]interface{})["defaults"].(map[string]interface{})["mappings"] = []interface{}{
+		map[string]interface{}{
+			"type": "value",
+			"options": map[string]interface{}{
+				"0": map[string]interface{}{"text": "DOWN", "color": "red"},
+				"1": map[string]interface{}{"text": "UP", "color": "green"},
+			},
+		},
+	}
+
+	b := dashboard.NewBuilder(probeTitle).
+		Set("uid", uid).
+		Set("tags", []string{"generated", "blackbox", "probes"}).
+		Set("refresh", "1m").
+		Variable(map[string]interface{}{
+			"type":       "custom",
+			"name":       probeVariable,
+			"label":      "Endpoint",
+			"query":      joinCustomQuery(targets),
+			"multi":      true,
+			"includeAll": true,
+			"current":    map[string]interface{}{"text": "All", "value": "$__all"},
+			"options":    []interface{}{},
+		})
+	b.Panel(status, 24, 5)
+	b.Panel(dashboard.StatPanel("Uptime", ds, "percentunit", target("avg_over_time(probe_success"+sel+"[$__range])")), 12, 8)
+	b.Panel(dashboard.StatPanel("Certificate expires in", ds, "s", target("probe_ssl_earliest_cert_expiry"+sel+" - time()")), 12, 8)
+	b.Panel(dashboard.TimeseriesPanel("Probe duration", ds, "s", target("probe_duration_seconds"+sel)), 12, 8)
+	b.Panel(dashboard.TimeseriesPanel("HTTP status code", ds, "none", target("probe_http_status_code"+sel)), 12, 8)
+	return b.Dashboard()
+}
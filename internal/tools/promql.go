@@ -0,0 +1,126 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/promql"
+)
+
+func (r *Registry) grafanaValidatePromQLTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_validate_promql",
+		Description: "Parse and lint a PromQL expression locally before it goes into a dashboard or alert rule. Reports syntax and type errors with line and column, and warns about counters used without rate(), histogram_quantile dropping le, rate over $__interval, and suspicious label matchers. Grafana template variables are allowed. Optionally dry-runs the query as an instant query against a Prometheus datasource",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"query":          {Type: "string", Description: "PromQL expression"},
+				"datasource_uid": {Type: "string", Description: "Prometheus datasource UID to dry-run the query against"},
+				"dry_run":        {Type: "boolean", Description: "Run the query as an instant query against datasource_uid (default true when datasource_uid is set)"},
+			},
+			Required: []string{"query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// templateVariable captures the name of a $var, ${var}, or [[var]] reference
+var templateVariable = regexp.MustCompile(`\$\{?(\w+)|\[\[(\w+)`)
+
+// serverSideVariables are the built-in macros the Prometheus datasource
+// expands itself, so queries using them can run through the query API
+var serverSideVariables = map[string]bool{
+	"__interval": true, "__interval_ms": true, "__rate_interval": true,
+	"__range": true, "__range_s": true, "__range_ms": true,
+}
+
+// hasDashboardVariables reports whether query references template
+// variables that only a dashboard can expand
+func hasDashboardVariables(query string) bool {
+	for _, m := range templateVariable.FindAllStringSubmatch(query, -1) {
+		if !serverSideVariables[m[1]+m[2]] {
+			return true
+		}
+	}
+	return false
+}
+
+type promQLDryRun struct {
+	Series int    `json:"series"`
+	Error  string `json:"error,omitempty"`
+	// Skipped explains why the query was not run
+	Skipped string `json:"skipped,omitempty"`
+}
+
+type promQLValidation struct {
+	Valid    bool             `json:"valid"`
+	Type     string           `json:"type,omitempty"`
+	Error    *promql.Error    `json:"error,omitempty"`
+	Warnings []promql.Warning `json:"warnings"`
+	DryRun   *promQLDryRun    `json:"dry_run,omitempty"`
+}
+
+func (r *Registry) handleValidatePromQL(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	query := getString(args, "query")
+	if query == "" {
+		return errorResult("query is required"), nil
+	}
+	dsUID := getString(args, "datasource_uid")
+	dryRun := dsUID != ""
+	if _, set := args["dry_run"]; set {
+		dryRun = getBool(args, "dry_run")
+	}
+	if dryRun && dsUID == "" {
+		return errorResult("datasource_uid is required for dry_run"), nil
+	}
+
+	out := promQLValidation{Warnings: []promql.Warning{}}
+	expr, err := promql.Parse(query)
+	if err != nil {
+		perr, _ := err.(*promql.Error)
+		out.Error = perr
+		return jsonResult(out)
+	}
+	out.Valid = true
+	out.Type = string(expr.Type())
+	if w := promql.Lint(query, expr); len(w) > 0 {
+		out.Warnings = w
+	}
+
+	if dryRun {
+		out.DryRun = r.dryRunPromQL(dsUID, query)
+	}
+	return jsonResult(out)
+}
+
+// dryRunPromQL evaluates query as an instant query at the current time
+func (r *Registry) dryRunPromQL(dsUID, query string) *promQLDryRun {
+	if hasDashboardVariables(query) {
+		return &promQLDryRun{Skipped: "query uses dashboard template variables"}
+	}
+	now := time.Now()
+	resp, err := r.query(grafana.QueryRequest{
+		From: fmt.Sprintf("%d", now.Add(-5*time.Minute).UnixMilli()),
+		To:   fmt.Sprintf("%d", now.UnixMilli()),
+		Queries: []grafana.QueryTarget{{
+			RefID:      "A",
+			Datasource: grafana.DatasourceRef{Type: "prometheus", UID: dsUID},
+			Query:      query,
+			Extra:      map[string]interface{}{"instant": true, "range": false},
+		}},
+	})
+	if err != nil {
+		return &promQLDryRun{Error: err.Error()}
+	}
+	res := resp.Results["A"]
+	if res.Error != "" {
+		return &promQLDryRun{Error: res.Error}
+	}
+	return &promQLDryRun{Series: len(res.Frames)}
+}
@@ -0,0 +1,307 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const defaultTargetLimit = 100
+
+// scrapeErrorURL and scrapeErrorAddr match the per-target parts of scrape
+// errors, so the same failure on many targets groups together
+var (
+	scrapeErrorURL  = regexp.MustCompile(`https?://[^\s"]+`)
+	scrapeErrorAddr = regexp.MustCompile(`\b[\w.-]+:\d{2,5}\b`)
+)
+
+func (r *Registry) grafanaPrometheusTargetsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_prometheus_targets",
+		Description: "Check scrape health behind a Prometheus datasource: up/down counts per job, down targets with their last scrape error, errors grouped across targets, and slow scrapes near their timeout. With metric, also lists the targets that report it, answering why a panel shows no data",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid":  {Type: "string", Description: "Prometheus datasource UID"},
+				"job":             {Type: "string", Description: "Only targets of this job (scrape pool)"},
+				"include_healthy": {Type: "boolean", Description: "List healthy targets too, not only down and unknown ones"},
+				"metric":          {Type: "string", Description: "Metric name to look up in the targets' metadata"},
+				"limit":           {Type: "integer", Description: "Maximum targets listed (default 100)"},
+			},
+			Required: []string{"datasource_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// scrapeTarget is a target listed in the scrape health report
+type scrapeTarget struct {
+	Job               string            `json:"job"`
+	Instance          string            `json:"instance"`
+	Health            string            `json:"health"`
+	ScrapeURL         string            `json:"scrape_url,omitempty"`
+	LastError         string            `json:"last_error,omitempty"`
+	LastScrape        string            `json:"last_scrape,omitempty"`
+	LastScrapeSeconds float64           `json:"last_scrape_seconds,omitempty"`
+	ScrapeInterval    string            `json:"scrape_interval,omitempty"`
+	ScrapeTimeout     string            `json:"scrape_timeout,omitempty"`
+	NearTimeout       bool              `json:"near_timeout,omitempty"`
+	Labels            map[string]string `json:"labels,omitempty"`
+}
+
+// jobHealth counts the targets of one job by health
+type jobHealth struct {
+	Job     string `json:"job"`
+	Total   int    `json:"total"`
+	Up      int    `json:"up"`
+	Down    int    `json:"down"`
+	Unknown int    `json:"unknown"`
+}
+
+// scrapeErrorGroup is one scrape error shared by several targets
+type scrapeErrorGroup struct {
+	Error     string   `json:"error"`
+	Targets   int      `json:"targets"`
+	Jobs      []string `json:"jobs"`
+	Example   string   `json:"example"`
+	Instances []string `json:"instances,omitempty"`
+}
+
+func (r *Registry) handlePrometheusTargets(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dsUID := getString(args, "datasource_uid")
+	if dsUID == "" {
+		return errorResult("datasource_uid is required"), nil
+	}
+	job := getString(args, "job")
+	metric := getString(args, "metric")
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = defaultTargetLimit
+	}
+
+	ds, err := r.client.GetDatasource(r.ctx, dsUID)
+	if err != nil {
+		return apiErrorResult("Failed to get datasource", err), nil
+	}
+	if ds.Type != "prometheus" {
+		return errorResult(fmt.Sprintf("datasource %s is a %s datasource; scrape targets are only available from Prometheus", ds.Name, ds.Type)), nil
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: dsUID, Type: ds.Type}); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	targets, err := r.client.GetPrometheusTargets(r.ctx, dsUID, "")
+	if err != nil {
+		if isNotFound(err) {
+			return errorResult(fmt.Sprintf("datasource %s has no /api/v1/targets endpoint; Mimir, Cortex, and Thanos Query do not scrape, so check the Prometheus or agent that sends them data", ds.Name)), nil
+		}
+		return apiErrorResult("Failed to list targets", err), nil
+	}
+
+	summary := map[string]int{"active": 0, "up": 0, "down": 0, "unknown": 0, "near_timeout": 0, "dropped": 0}
+	jobs := map[string]*jobHealth{}
+	errs := map[string]*scrapeErrorGroup{}
+	listed := []scrapeTarget{}
+	for _, t := range targets.Active {
+		st := newScrapeTarget(t)
+		if job != "" && st.Job != job && t.ScrapePool != job {
+			continue
+		}
+		summary["active"]++
+		jh := jobs[st.Job]
+		if jh == nil {
+			jh = &jobHealth{Job: st.Job}
+			jobs[st.Job] = jh
+		}
+		jh.Total++
+		switch st.Health {
+		case "up":
+			summary["up"]++
+			jh.Up++
+		case "down":
+			summary["down"]++
+			jh.Down++
+		default:
+			summary["unknown"]++
+			jh.Unknown++
+		}
+		if st.NearTimeout {
+			summary["near_timeout"]++
+		}
+		if st.LastError != "" {
+			key := scrapeErrorKey(st.LastError)
+			g := errs[key]
+			if g == nil {
+				g = &scrapeErrorGroup{Error: key, Example: st.LastError}
+				errs[key] = g
+			}
+			g.Targets++
+			if !contains(g.Jobs, st.Job) {
+				g.Jobs = append(g.Jobs, st.Job)
+			}
+			if len(g.Instances) < 10 {
+				g.Instances = append(g.Instances, st.Instance)
+			}
+		}
+		if st.Health != "up" || st.NearTimeout || getBool(args, "include_healthy") {
+			listed = append(listed, st)
+		}
+	}
+	for _, t := range targets.Dropped {
+		if job == "" || t.DiscoveredLabels["job"] == job {
+			summary["dropped"]++
+		}
+	}
+
+	sort.SliceStable(listed, func(i, j int) bool {
+		if hi, hj := healthRank(listed[i].Health), healthRank(listed[j].Health); hi != hj {
+			return hi < hj
+		}
+		if listed[i].Job != listed[j].Job {
+			return listed[i].Job < listed[j].Job
+		}
+		return listed[i].Instance < listed[j].Instance
+	})
+	total := len(listed)
+	if len(listed) > limit {
+		listed = listed[:limit]
+	}
+
+	jobList := make([]*jobHealth, 0, len(jobs))
+	for _, jh := range jobs {
+		jobList = append(jobList, jh)
+	}
+	sort.Slice(jobList, func(i, j int) bool {
+		if jobList[i].Down != jobList[j].Down {
+			return jobList[i].Down > jobList[j].Down
+		}
+		return jobList[i].Job < jobList[j].Job
+	})
+	errList := make([]*scrapeErrorGroup, 0, len(errs))
+	for _, g := range errs {
+		sort.Strings(g.Jobs)
+		errList = append(errList, g)
+	}
+	sort.Slice(errList, func(i, j int) bool {
+		if errList[i].Targets != errList[j].Targets {
+			return errList[i].Targets > errList[j].Targets
+		}
+		return errList[i].Error < errList[j].Error
+	})
+
+	out := map[string]interface{}{
+		"datasource":    map[string]string{"uid": ds.UID, "name": ds.Name},
+		"summary":       summary,
+		"jobs":          jobList,
+		"scrape_errors": errList,
+		"targets":       listed,
+		"total_listed":  total,
+	}
+	if job != "" {
+		out["job"] = job
+	}
+	if summary["active"] == 0 {
+		note := "no active targets"
+		if job != "" {
+			note = fmt.Sprintf("no active targets for job %q", job)
+		}
+		out["notes"] = []string{note}
+	}
+	if metric != "" {
+		out["metric"] = r.metricTargets(dsUID, job, metric, targets.Active)
+	}
+	return jsonResult(out)
+}
+
+func newScrapeTarget(t grafana.PrometheusTarget) scrapeTarget {
+	st := scrapeTarget{
+		Job:               t.Labels["job"],
+		Instance:          t.Labels["instance"],
+		Health:            t.Health,
+		ScrapeURL:         t.ScrapeURL,
+		LastError:         t.LastError,
+		LastScrape:        t.LastScrape,
+		LastScrapeSeconds: t.LastScrapeDuration,
+		ScrapeInterval:    t.ScrapeInterval,
+		ScrapeTimeout:     t.ScrapeTimeout,
+	}
+	if st.Job == "" {
+		st.Job = t.ScrapePool
+	}
+	if st.Health == "" {
+		st.Health = "unknown"
+	}
+	if timeout, err := time.ParseDuration(t.ScrapeTimeout); err == nil && timeout > 0 {
+		st.NearTimeout = t.LastScrapeDuration >= 0.8*timeout.Seconds()
+	}
+	for k, v := range t.Labels {
+		if k == "job" || k == "instance" {
+			continue
+		}
+		if st.Labels == nil {
+			st.Labels = map[string]string{}
+		}
+		st.Labels[k] = v
+	}
+	return st
+}
+
+// scrapeErrorKey replaces the URLs and addresses in a scrape error so the
+// same failure on different targets compares equal
+func scrapeErrorKey(msg string) string {
+	msg = scrapeErrorURL.ReplaceAllString(msg, "<url>")
+	return scrapeErrorAddr.ReplaceAllString(msg, "<addr>")
+}
+
+func healthRank(health string) int {
+	switch health {
+	case "down":
+		return 0
+	case "unknown":
+		return 1
+	}
+	return 2
+}
+
+// metricTargets reports which targets expose metric, according to the
+// metadata Prometheus keeps from their last scrape, and whether they are up
+func (r *Registry) metricTargets(dsUID, job, metric string, active []grafana.PrometheusTarget) map[string]interface{} {
+	out := map[string]interface{}{"name": metric}
+	match := ""
+	if job != "" {
+		match = fmt.Sprintf("{job=%q}", job)
+	}
+	meta, err := r.client.GetPrometheusTargetMetadata(r.ctx, dsUID, match, metric, 0)
+	if err != nil {
+		out["error"] = fmt.Sprintf("could not read target metadata: %v", err)
+		return out
+	}
+
+	health := map[string]string{}
+	for _, t := range active {
+		health[t.Labels["job"]+"/"+t.Labels["instance"]] = t.Health
+	}
+	reporting := []map[string]string{}
+	for _, m := range meta {
+		if out["type"] == nil {
+			out["type"], out["help"], out["unit"] = m.Type, m.Help, m.Unit
+		}
+		h := health[m.Target["job"]+"/"+m.Target["instance"]]
+		if h == "" {
+			h = "unknown"
+		}
+		reporting = append(reporting, map[string]string{"job": m.Target["job"], "instance": m.Target["instance"], "health": h})
+	}
+	out["targets"] = reporting
+	if len(reporting) == 0 {
+		out["note"] = "no target reports this metric; it may come from a recording rule or remote write, be misspelled, or its exporter may not be scraped"
+	}
+	return out
+}
@@ -0,0 +1,280 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/export"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// WithProvisioningPath sets where the provisioned dashboard tools read
+// dashboard files from. Path arguments must lie within it.
+func WithProvisioningPath(path string) Option {
+	return func(r *Registry) {
+		r.provisioningPath = path
+	}
+}
+
+func (r *Registry) grafanaListProvisionedDashboardsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_provisioned_dashboards",
+		Description: "List the dashboard files Grafana provisions from disk, read from the configured provisioning path (when the server runs next to Grafana), with the folder each lands in",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"path": {Type: "string", Description: "Directory of dashboard JSON files, or of provider YAML files, within provisioning.dashboards_path from the config (default: that path)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaDiffProvisionedDashboardsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_diff_provisioned_dashboards",
+		Description: "Compare file-provisioned dashboards with their live versions and flag drift: UI edits to the saved copy, a different folder, dashboards missing from Grafana, and live copies no longer marked provisioned",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"path":             {Type: "string", Description: "Directory of dashboard JSON files, or of provider YAML files, within provisioning.dashboards_path from the config (default: that path)"},
+				"uids":             {Type: "array", Description: "Only compare these dashboard UIDs"},
+				"only_drifted":     {Type: "boolean", Description: "Leave in-sync dashboards out of the result"},
+				"max_diff_entries": {Type: "integer", Description: "Maximum diff entries returned per dashboard (default 200)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleListProvisionedDashboards(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	path, dashboards, errResult := r.readProvisioned(args)
+	if errResult != nil {
+		return errResult, nil
+	}
+	return jsonResult(map[string]interface{}{
+		"path":       path,
+		"dashboards": dashboards,
+		"count":      len(dashboards),
+	})
+}
+
+// provisionedDrift is how a provisioned dashboard compares to Grafana
+type provisionedDrift struct {
+	Path     string `json:"path"`
+	UID      string `json:"uid,omitempty"`
+	Title    string `json:"title,omitempty"`
+	Provider string `json:"provider,omitempty"`
+	// Status is in_sync, drifted, missing, or invalid
+	Status      string             `json:"status"`
+	Issues      []string           `json:"issues,omitempty"`
+	LiveVersion int                `json:"live_version,omitempty"`
+	Updated     string             `json:"updated,omitempty"`
+	UpdatedBy   string             `json:"updated_by,omitempty"`
+	DiffEntries int                `json:"diff_entries,omitempty"`
+	Diff        []dashboard.Change `json:"diff,omitempty"`
+}
+
+func (r *Registry) handleDiffProvisionedDashboards(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	path, files, errResult := r.readProvisioned(args)
+	if errResult != nil {
+		return errResult, nil
+	}
+	maxDiff := getInt(args, "max_diff_entries")
+	if maxDiff <= 0 {
+		maxDiff = defaultMaxDiffEntries
+	}
+	onlyUIDs := getStringSlice(args, "uids")
+
+	summary := map[string]int{"in_sync": 0, "drifted": 0, "missing": 0, "invalid": 0}
+	results := []provisionedDrift{}
+	for _, f := range files {
+		if len(onlyUIDs) > 0 && !contains(onlyUIDs, f.UID) {
+			continue
+		}
+		d := r.compareProvisioned(f, maxDiff)
+		summary[d.Status]++
+		if d.Status == "in_sync" && getBool(args, "only_drifted") {
+			continue
+		}
+		results = append(results, d)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return driftRank(results[i].Status) < driftRank(results[j].Status)
+	})
+
+	return jsonResult(map[string]interface{}{
+		"path":       path,
+		"summary":    summary,
+		"dashboards": results,
+	})
+}
+
+// readProvisioned reads the dashboard files at the configured provisioning
+// path, or at the path argument within it
+func (r *Registry) readProvisioned(args map[string]interface{}) (string, []export.ProvisionedDashboard, *mcp.CallToolResult) {
+	if r.provisioningPath == "" {
+		return "", nil, errorResult("provisioning.dashboards_path is not configured")
+	}
+	path := r.provisioningPath
+	if arg := getString(args, "path"); arg != "" {
+		var err error
+		if path, err = confinePath(r.provisioningPath, arg); err != nil {
+			return "", nil, errorResultFor(err)
+		}
+	}
+	dashboards, err := export.ReadProvisioned(path)
+	if err != nil {
+		return path, nil, apiErrorResult("Failed to read provisioned dashboards", err)
+	}
+	if dashboards == nil {
+		dashboards = []export.ProvisionedDashboard{}
+	}
+	return path, dashboards, nil
+}
+
+// compareProvisioned compares one dashboard file with the live dashboard
+// it provisions
+func (r *Registry) compareProvisioned(f export.ProvisionedDashboard, maxDiff int) provisionedDrift {
+	d := provisionedDrift{Path: f.Path, UID: f.UID, Title: f.Title, Provider: f.Provider}
+	if f.Error != "" {
+		d.Status, d.Issues = "invalid", []string{f.Error}
+		return d
+	}
+
+	uid := f.UID
+	if uid == "" {
+		// Grafana generates a uid for files without one; find the
+		// dashboard by title instead
+		found, err := r.provisionedUIDByTitle(f)
+		if err != nil {
+			d.Status, d.Issues = "missing", []string{err.Error()}
+			return d
+		}
+		uid = found
+		d.UID = found
+		d.Issues = append(d.Issues, "file has no uid; matched the live dashboard by title")
+	}
+
+	live, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		d.Status = "missing"
+		if isNotFound(err) {
+			d.Issues = append(d.Issues, "not in Grafana; the provider may not have loaded it yet, or it failed validation")
+		} else {
+			d.Issues = append(d.Issues, fmt.Sprintf("could not fetch live dashboard: %v", err))
+		}
+		return d
+	}
+	d.LiveVersion = live.Meta.Version
+	d.Updated = live.Meta.Updated
+	d.UpdatedBy = live.Meta.UpdatedBy
+
+	if !live.Meta.Provisioned {
+		d.Issues = append(d.Issues, "live dashboard is not marked provisioned; it was created or overwritten outside provisioning, or Grafana does not read this file")
+	}
+	moved := false
+	switch {
+	case f.FolderUID != "":
+		moved = f.FolderUID != live.Meta.FolderUID
+	case f.Folder == "":
+		moved = live.Meta.FolderUID != ""
+	default:
+		moved = f.Folder != live.Meta.FolderTitle
+	}
+	if moved {
+		want := folderLabel(f.Folder)
+		if f.FolderUID != "" {
+			want = "uid " + f.FolderUID
+		}
+		d.Issues = append(d.Issues, fmt.Sprintf("in folder %s instead of %s", folderLabel(live.Meta.FolderTitle), want))
+	}
+
+	liveModel := comparableModel(live.Dashboard)
+	if f.UID == "" {
+		delete(liveModel, "uid")
+	}
+	diff := dashboard.Diff(comparableModel(f.Model), liveModel)
+	if len(diff) > 0 {
+		msg := fmt.Sprintf("live dashboard differs from the file in %d places", len(diff))
+		if live.Meta.UpdatedBy != "" {
+			msg += fmt.Sprintf(", last saved by %s", live.Meta.UpdatedBy)
+		}
+		if f.AllowUIUpdates {
+			msg += "; the provider allows UI updates, so the next file change overwrites them"
+		}
+		d.Issues = append(d.Issues, msg)
+		d.DiffEntries = len(diff)
+		if len(diff) > maxDiff {
+			diff = diff[:maxDiff]
+		}
+		d.Diff = diff
+	}
+
+	d.Status = "in_sync"
+	if len(diff) > 0 || !live.Meta.Provisioned || moved {
+		d.Status = "drifted"
+	}
+	return d
+}
+
+// provisionedUIDByTitle finds the live dashboard a file without a uid
+// provisions, by title and folder
+func (r *Registry) provisionedUIDByTitle(f export.ProvisionedDashboard) (string, error) {
+	hits, err := r.client.Search(r.ctx, grafana.SearchQuery{Type: "dash-db", Query: f.Title})
+	if err != nil {
+		return "", fmt.Errorf("could not search for %q: %v", f.Title, err)
+	}
+	var uids []string
+	for _, h := range hits {
+		if h.Title != f.Title {
+			continue
+		}
+		if f.FolderUID != "" && h.FolderUID != f.FolderUID {
+			continue
+		}
+		uids = append(uids, h.UID)
+	}
+	switch len(uids) {
+	case 0:
+		return "", fmt.Errorf("file has no uid and no dashboard titled %q exists", f.Title)
+	case 1:
+		return uids[0], nil
+	}
+	return "", fmt.Errorf("file has no uid and %d dashboards are titled %q; add a uid to the file", len(uids), f.Title)
+}
+
+// comparableModel drops the fields Grafana sets on every save, which always
+// differ from the file
+func comparableModel(model map[string]interface{}) map[string]interface{} {
+	out := export.CleanModel(model)
+	delete(out, "version")
+	return out
+}
+
+func folderLabel(title string) string {
+	if title == "" {
+		return "General"
+	}
+	return fmt.Sprintf("%q", title)
+}
+
+// driftRank orders results so the ones needing attention come first
+func driftRank(status string) int {
+	switch status {
+	case "drifted":
+		return 0
+	case "missing":
+		return 1
+	case "invalid":
+		return 2
+	}
+	return 3
+}
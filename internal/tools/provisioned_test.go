@@ -0,0 +1,42 @@
+package tools_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+func TestProvisionedPathStaysInConfiguredRoot(t *testing.T) {
+	root := t.TempDir()
+	team := filepath.Join(root, "team")
+	if err := os.MkdirAll(team, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(team, "checkout.json"), []byte(`{"uid":"checkout","title":"Checkout"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithProvisioningPath(root)))
+
+	var out struct {
+		Dashboards []struct {
+			UID string `json:"uid"`
+		} `json:"dashboards"`
+	}
+	h.Call("grafana_list_provisioned_dashboards", map[string]interface{}{"path": "team"}).OK().JSON(&out)
+	if len(out.Dashboards) != 1 || out.Dashboards[0].UID != "checkout" {
+		t.Fatalf("listed %+v, want the checkout dashboard", out.Dashboards)
+	}
+
+	for _, path := range []string{"..", "/etc", t.TempDir()} {
+		h.Call("grafana_list_provisioned_dashboards", map[string]interface{}{"path": path}).Error("outside")
+		h.Call("grafana_diff_provisioned_dashboards", map[string]interface{}{"path": path}).Error("outside")
+	}
+}
+
+func TestProvisionedNeedsConfiguredRoot(t *testing.T) {
+	h := testkit.New(t)
+	h.Call("grafana_list_provisioned_dashboards", map[string]interface{}{"path": t.TempDir()}).Error("provisioning.dashboards_path")
+}
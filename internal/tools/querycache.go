@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/cache"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// defaultQueryPoints is the number of points per series assumed when
+// deriving a step for requests that set neither interval nor max points
+const defaultQueryPoints = 1000
+
+// QueryCacheSettings tunes the cache of /api/ds/query results
+type QueryCacheSettings struct {
+	// TTL is how long a result is reused; 0 disables the cache
+	TTL time.Duration
+	// Size is the maximum number of cached results
+	Size int
+	// MinStep is the smallest bucket relative time ranges are quantized to
+	MinStep time.Duration
+}
+
+// DefaultQueryCacheSettings returns the settings used when none are configured
+func DefaultQueryCacheSettings() QueryCacheSettings {
+	return QueryCacheSettings{TTL: 30 * time.Second, Size: 256, MinStep: 10 * time.Second}
+}
+
+// WithQueryCache overrides the query result cache defaults. Zero Size or
+// MinStep keep their defaults.
+func WithQueryCache(s QueryCacheSettings) Option {
+	return func(r *Registry) {
+		r.queryCache = newQueryCache(s)
+	}
+}
+
+// queryCache reuses identical query results for a short time so that an
+// agent refining its reasoning over the same data does not re-run an
+// expensive backend query on every step
+type queryCache struct {
+	results *cache.Cache[[]byte]
+	minStep time.Duration
+}
+
+func newQueryCache(s QueryCacheSettings) *queryCache {
+	if s.TTL <= 0 {
+		return nil
+	}
+	def := DefaultQueryCacheSettings()
+	if s.Size <= 0 {
+		s.Size = def.Size
+	}
+	if s.MinStep <= 0 {
+		s.MinStep = def.MinStep
+	}
+	return &queryCache{results: cache.New[[]byte](s.Size, s.TTL), minStep: s.MinStep}
+}
+
+// query runs req through /api/ds/query after checking every target against
+// the datasource access rules, serving repeated requests from the cache.
+// Tools send queries through here rather than calling the client directly.
+func (r *Registry) query(req grafana.QueryRequest) (*grafana.QueryResponse, error) {
+	return r.runQuery(req, true)
+}
+
+// runQuery is query with the cache optional, for callers that must see
+// fresh data
+func (r *Registry) runQuery(req grafana.QueryRequest, useCache bool) (*grafana.QueryResponse, error) {
+	for _, q := range req.Queries {
+		if err := r.checkQueryAccess(q.Datasource, queryTexts(q)...); err != nil {
+			return nil, err
+		}
+	}
+	qc := r.queryCache
+	if qc == nil || !useCache {
+		return r.client.Query(r.ctx, req)
+	}
+
+	req = qc.quantize(req)
+	key, err := queryCacheKey(r.orgID(), req)
+	if err != nil {
+		return r.client.Query(r.ctx, req)
+	}
+	if data, ok := qc.results.Get(key); ok {
+		// Each hit decodes a fresh copy, since callers filter results in place
+		var resp grafana.QueryResponse
+		if err := json.Unmarshal(data, &resp); err == nil {
+			return &resp, nil
+		}
+	}
+
+	resp, err := r.client.Query(r.ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, res := range resp.Results {
+		if res.Error != "" {
+			return resp, nil
+		}
+	}
+	if data, err := json.Marshal(resp); err == nil {
+		qc.results.Set(key, data)
+	}
+	return resp, nil
+}
+
+// quantize aligns relative time ranges (now-1h) to the query step so that
+// repeated calls a few seconds apart produce the same request. Absolute
+// ranges are left as given and only match exactly.
+func (qc *queryCache) quantize(req grafana.QueryRequest) grafana.QueryRequest {
+	if !strings.HasPrefix(strings.TrimSpace(req.From), "now") && !strings.HasPrefix(strings.TrimSpace(req.To), "now") {
+		return req
+	}
+	start, end, err := parseTimeRange(req.From, req.To, "now-1h")
+	if err != nil {
+		return req
+	}
+
+	step := qc.minStep
+	for _, q := range req.Queries {
+		var s time.Duration
+		if q.IntervalMs > 0 {
+			s = time.Duration(q.IntervalMs) * time.Millisecond
+		} else {
+			points := q.MaxDataPoints
+			if points <= 0 {
+				points = defaultQueryPoints
+			}
+			s = end.Sub(start) / time.Duration(points)
+		}
+		if s > step {
+			step = s
+		}
+	}
+
+	start, end = start.Truncate(step), end.Truncate(step)
+	if !end.After(start) {
+		end = start.Add(step)
+	}
+	req.From = strconv.FormatInt(start.UnixMilli(), 10)
+	req.To = strconv.FormatInt(end.UnixMilli(), 10)
+	return req
+}
+
+// queryCacheKey identifies a request by everything sent to Grafana,
+// including the organization it goes to
+func queryCacheKey(orgID int64, req grafana.QueryRequest) (string, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(strconv.FormatInt(orgID, 10)+"|"), data...))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,132 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// maxMultiQueries caps the queries of one grafana_query_multi call
+const maxMultiQueries = 26
+
+func (r *Registry) grafanaQueryMultiTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_query_multi",
+		Description: "Run queries against several datasources in one request, as a panel using the mixed datasource does, e.g. Prometheus error rates next to Loki error log counts over the same range. Each query names its own datasource; items with an expression instead are server-side math over the other queries' refIds, such as $A / $B. Results are keyed by refId",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"queries": {
+					Type:        "array",
+					Description: "Queries to run together, each {ref_id, datasource_uid, datasource_type, query} or {ref_id, expression} for math over other refIds (e.g. \"$A / $B\"). ref_id defaults to A, B, C, ... by position",
+				},
+				"from":            {Type: "string", Description: "Start time (e.g., now-1h, 2024-01-01T00:00:00Z)"},
+				"to":              {Type: "string", Description: "End time (e.g., now)"},
+				"max_data_points": {Type: "integer", Description: "Maximum number of data points per query"},
+				"interval_ms":     {Type: "integer", Description: "Query interval in milliseconds"},
+				"no_cache":        {Type: "boolean", Description: "Bypass the short-lived result cache and run the queries again"},
+			},
+			Required: []string{"queries"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleQueryMulti(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	items := getMapSlice(args, "queries")
+	if len(items) == 0 {
+		return errorResult("queries is required"), nil
+	}
+	if len(items) > maxMultiQueries {
+		return errorResult(fmt.Sprintf("at most %d queries can run together, got %d", maxMultiQueries, len(items))), nil
+	}
+
+	from := getString(args, "from")
+	to := getString(args, "to")
+	if from == "" {
+		from = "now-1h"
+	}
+	if to == "" {
+		to = "now"
+	}
+
+	req := grafana.QueryRequest{From: from, To: to}
+	seen := map[string]bool{}
+	for i, item := range items {
+		q, err := multiQueryTarget(item, i)
+		if err != nil {
+			return errorResultFor(err), nil
+		}
+		if seen[q.RefID] {
+			return errorResult(fmt.Sprintf("ref_id %q is used by more than one query", q.RefID)), nil
+		}
+		seen[q.RefID] = true
+		q.MaxDataPoints = getInt(args, "max_data_points")
+		q.IntervalMs = getInt(args, "interval_ms")
+		req.Queries = append(req.Queries, q)
+	}
+
+	// Check access before the guardrails, whose estimates also reach the datasource
+	guarded := map[string]*queryEstimate{}
+	for _, q := range req.Queries {
+		if q.Datasource.UID == expressionDatasource {
+			continue
+		}
+		if err := r.checkQueryAccess(q.Datasource, q.Query); err != nil {
+			return errorResultFor(fmt.Errorf("query %s: %w", q.RefID, err)), nil
+		}
+		est := r.guardQuery(q.Datasource.UID, q.Datasource.Type, q.Query, from, to)
+		if est == nil || len(est.Violations) == 0 {
+			continue
+		}
+		if r.guardrails.Refuse {
+			return guardrailRefusal(est), nil
+		}
+		guarded[q.RefID] = est
+	}
+
+	result, err := r.runQuery(req, !getBool(args, "no_cache"))
+	if err != nil {
+		return apiErrorResult("Query failed", err), nil
+	}
+	if len(guarded) == 0 {
+		return jsonResult(result)
+	}
+	return jsonResult(map[string]interface{}{
+		"results":    result.Results,
+		"guardrails": guarded,
+	})
+}
+
+// multiQueryTarget builds the target of the i-th grafana_query_multi item
+func multiQueryTarget(item map[string]interface{}, i int) (grafana.QueryTarget, error) {
+	refID := strings.TrimSpace(getString(item, "ref_id"))
+	if refID == "" {
+		refID = string(rune('A' + i))
+	}
+	if expr := strings.TrimSpace(getString(item, "expression")); expr != "" {
+		return grafana.QueryTarget{
+			RefID:      refID,
+			Datasource: grafana.DatasourceRef{Type: expressionDatasource, UID: expressionDatasource},
+			Extra:      map[string]interface{}{"type": "math", "expression": expr},
+		}, nil
+	}
+	q := grafana.QueryTarget{
+		RefID:      refID,
+		Datasource: grafana.DatasourceRef{Type: getString(item, "datasource_type"), UID: getString(item, "datasource_uid")},
+		Query:      getString(item, "query"),
+	}
+	if q.Datasource.UID == "" || q.Datasource.Type == "" || q.Query == "" {
+		return q, fmt.Errorf("query %s: datasource_uid, datasource_type, and query are required, or expression for math", refID)
+	}
+	if q.Datasource.UID == dashboard.MixedUID {
+		return q, fmt.Errorf("query %s: name the datasource of each query rather than the mixed datasource", refID)
+	}
+	return q, nil
+}
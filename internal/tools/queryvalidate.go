@@ -0,0 +1,425 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/promql"
+)
+
+func (r *Registry) grafanaValidateLogQLTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_validate_logql",
+		Description: "Validate a LogQL query before it goes into a panel or alert rule. With datasource_uid, Loki's own parser checks the query and parse errors come back with line and column plus Loki's canonical formatting; without it, stream selectors, regexes, brackets, and strings are checked locally. Also warns about selectors without an equality matcher and regex filters that could be plain string filters",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"query":          {Type: "string", Description: "LogQL query"},
+				"datasource_uid": {Type: "string", Description: "Loki datasource UID to validate against"},
+			},
+			Required: []string{"query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaValidateTraceQLTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_validate_traceql",
+		Description: "Validate a TraceQL query before it goes into a panel. With datasource_uid, Tempo parses the query (via a one-result search over the last five minutes) and parse errors come back with line and column; without it, spanset braces, regexes, brackets, and strings are checked locally",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"query":          {Type: "string", Description: "TraceQL query"},
+				"datasource_uid": {Type: "string", Description: "Tempo datasource UID to validate against"},
+			},
+			Required: []string{"query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// queryError locates a problem in a query
+type queryError struct {
+	Message string `json:"message"`
+	Offset  int    `json:"offset"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+// queryWarning is a lint finding in a query that parses
+type queryWarning struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+}
+
+type queryValidation struct {
+	Valid bool `json:"valid"`
+	// CheckedBy is "loki" or "tempo" when the datasource parsed the query,
+	// or "local" when only local checks ran
+	CheckedBy string         `json:"checked_by"`
+	Error     *queryError    `json:"error,omitempty"`
+	Warnings  []queryWarning `json:"warnings"`
+	Formatted string         `json:"formatted,omitempty"`
+	Note      string         `json:"note,omitempty"`
+}
+
+func newQueryError(query string, offset int, format string, args ...interface{}) *queryError {
+	line, col := lineColumn(query, offset)
+	return &queryError{Message: fmt.Sprintf(format, args...), Offset: offset, Line: line, Column: col}
+}
+
+func newQueryWarning(query string, offset int, rule, format string, args ...interface{}) queryWarning {
+	line, col := lineColumn(query, offset)
+	return queryWarning{Rule: rule, Message: fmt.Sprintf(format, args...), Line: line, Column: col}
+}
+
+// lineColumn converts a byte offset into a 1-based line and column
+func lineColumn(s string, offset int) (int, int) {
+	if offset > len(s) {
+		offset = len(s)
+	}
+	line := 1 + strings.Count(s[:offset], "\n")
+	return line, offset - strings.LastIndex(s[:offset], "\n")
+}
+
+// offsetOf converts a 1-based line and column into a byte offset
+func offsetOf(s string, line, col int) int {
+	offset := 0
+	for i := 1; i < line; i++ {
+		nl := strings.IndexByte(s[offset:], '\n')
+		if nl < 0 {
+			return len(s)
+		}
+		offset += nl + 1
+	}
+	offset += col - 1
+	if offset < 0 {
+		return 0
+	}
+	if offset > len(s) {
+		return len(s)
+	}
+	return offset
+}
+
+// parseErrorPosition matches the position in Loki and Tempo parse errors,
+// e.g. "parse error at line 1, col 15: syntax error: unexpected IDENTIFIER"
+var parseErrorPosition = regexp.MustCompile(`line (\d+), col (\d+)`)
+
+// serverQueryError converts the body of a datasource's 400 response into a
+// queryError, locating it when the message includes a position
+func serverQueryError(query, msg string) *queryError {
+	qe := &queryError{Message: msg}
+	if m := parseErrorPosition.FindStringSubmatch(msg); m != nil {
+		qe.Line, _ = strconv.Atoi(m[1])
+		qe.Column, _ = strconv.Atoi(m[2])
+		qe.Offset = offsetOf(query, qe.Line, qe.Column)
+	}
+	return qe
+}
+
+// queryToken is a string literal, bracket, operator, or word of a LogQL or
+// TraceQL query
+type queryToken struct {
+	// text is the token as written, or the unquoted value of a string
+	text string
+	str  bool
+	pos  int
+}
+
+// scanQuery splits a LogQL or TraceQL query into tokens, checking that
+// strings are terminated and brackets balanced. LogQL allows # comments.
+func scanQuery(query string, comments bool) ([]queryToken, *queryError) {
+	var toks []queryToken
+	var open []queryToken
+	closers := map[byte]string{')': "(", ']': "[", '}': "{"}
+	const operators = "|!=~<>&+-*/%^,"
+
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#' && comments:
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '"' || c == '`':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(query) || (c == '"' && query[i] == '\n') {
+					return nil, newQueryError(query, start, "unterminated string")
+				}
+				if query[i] == c {
+					i++
+					break
+				}
+				if c == '"' && query[i] == '\\' && i+1 < len(query) {
+					b.WriteByte(query[i])
+					i++
+				}
+				b.WriteByte(query[i])
+				i++
+			}
+			value := b.String()
+			if c == '"' {
+				if unq, err := strconv.Unquote(query[start:i]); err == nil {
+					value = unq
+				}
+			}
+			toks = append(toks, queryToken{text: value, str: true, pos: start})
+		case c == '(' || c == '[' || c == '{':
+			t := queryToken{text: string(c), pos: i}
+			toks = append(toks, t)
+			open = append(open, t)
+			i++
+		case c == ')' || c == ']' || c == '}':
+			if len(open) == 0 {
+				return nil, newQueryError(query, i, "unexpected %q with nothing to close", string(c))
+			}
+			last := open[len(open)-1]
+			if last.text != closers[c] {
+				line, col := lineColumn(query, last.pos)
+				return nil, newQueryError(query, i, "unexpected %q; %q at line %d, column %d is still open",
+					string(c), last.text, line, col)
+			}
+			open = open[:len(open)-1]
+			toks = append(toks, queryToken{text: string(c), pos: i})
+			i++
+		case strings.IndexByte(operators, c) >= 0:
+			start := i
+			for i < len(query) && strings.IndexByte(operators, query[i]) >= 0 && i-start < 3 {
+				i++
+			}
+			toks = append(toks, queryToken{text: query[start:i], pos: start})
+		default:
+			start := i
+			for i++; i < len(query) && !strings.ContainsRune(" \t\r\n\"`()[]{}#"+operators, rune(query[i])); i++ {
+			}
+			toks = append(toks, queryToken{text: query[start:i], pos: start})
+		}
+	}
+	if len(open) > 0 {
+		last := open[len(open)-1]
+		return nil, newQueryError(query, last.pos, "unclosed %q", last.text)
+	}
+	return toks, nil
+}
+
+// checkRegexString validates a regex string literal following a regex
+// operator, warning when a plain string match would do
+func checkRegexString(query string, op, value queryToken, plainOp string, warnings *[]queryWarning) *queryError {
+	if promql.HasVariables(value.text) {
+		return nil
+	}
+	if _, err := regexp.Compile(value.text); err != nil {
+		return newQueryError(query, value.pos, "invalid regular expression %q: %v", value.text, err)
+	}
+	if value.text != "" && regexp.QuoteMeta(value.text) == value.text {
+		*warnings = append(*warnings, newQueryWarning(query, op.pos, "regex-without-metacharacters",
+			"%s %q has no regex metacharacters; %s %q is equivalent and cheaper", op.text, value.text, plainOp, value.text))
+	}
+	return nil
+}
+
+// checkLogQL validates stream selectors, line filter and regexp stage
+// regexes, strings, and brackets without contacting Loki
+func checkLogQL(query string) (*queryError, []queryWarning) {
+	warnings := []queryWarning{}
+	toks, qerr := scanQuery(query, true)
+	if qerr != nil {
+		return qerr, warnings
+	}
+
+	selectors := 0
+	for i := 0; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case !t.str && t.text == "{":
+			end := i + 1
+			for end < len(toks) && (toks[end].str || toks[end].text != "}") {
+				end++
+			}
+			selectors++
+			if qerr := checkStreamSelector(query, t.pos, toks[end].pos+1, &warnings); qerr != nil {
+				return qerr, warnings
+			}
+			i = end
+		case !t.str && (t.text == "|~" || t.text == "!~") && i+1 < len(toks) && toks[i+1].str:
+			plain := "|="
+			if t.text == "!~" {
+				plain = "!="
+			}
+			if qerr := checkRegexString(query, t, toks[i+1], plain, &warnings); qerr != nil {
+				return qerr, warnings
+			}
+		case !t.str && t.text == "regexp" && i > 0 && toks[i-1].text == "|" && i+1 < len(toks) && toks[i+1].str:
+			value := toks[i+1]
+			if !promql.HasVariables(value.text) {
+				if _, err := regexp.Compile(value.text); err != nil {
+					return newQueryError(query, value.pos, "invalid regular expression %q: %v", value.text, err), warnings
+				}
+			}
+		}
+	}
+	if selectors == 0 {
+		return newQueryError(query, 0, "LogQL queries need a stream selector, e.g. {job=\"app\"}"), warnings
+	}
+	return nil, warnings
+}
+
+// checkStreamSelector parses query[start:end], a {...} stream selector, as
+// a PromQL selector, which shares its matcher syntax
+func checkStreamSelector(query string, start, end int, warnings *[]queryWarning) *queryError {
+	sel := query[start:end]
+	expr, err := promql.Parse(sel)
+	if err != nil {
+		var perr *promql.Error
+		if errors.As(err, &perr) {
+			return newQueryError(query, start+perr.Offset, "stream selector: %s", perr.Msg)
+		}
+		return newQueryError(query, start, "stream selector: %v", err)
+	}
+	for _, w := range promql.Lint(sel, expr) {
+		*warnings = append(*warnings, newQueryWarning(query, start+offsetOf(sel, w.Line, w.Column), w.Rule, "%s", w.Message))
+	}
+	vs, ok := expr.(*promql.VectorSelector)
+	if !ok {
+		return nil
+	}
+	for _, mt := range vs.Matchers {
+		if mt.Op == "=" && mt.Value != "" {
+			return nil
+		}
+	}
+	*warnings = append(*warnings, newQueryWarning(query, start, "selector-without-equality",
+		"the stream selector has no = matcher, so Loki must scan every stream matching the regex or negative matchers; add an exact label such as job or namespace"))
+	return nil
+}
+
+// checkTraceQL validates spanset braces, regexes, strings, and brackets
+// without contacting Tempo
+func checkTraceQL(query string) (*queryError, []queryWarning) {
+	warnings := []queryWarning{}
+	toks, qerr := scanQuery(query, false)
+	if qerr != nil {
+		return qerr, warnings
+	}
+	spansets := 0
+	for i, t := range toks {
+		switch {
+		case !t.str && t.text == "{":
+			spansets++
+		case !t.str && (t.text == "=~" || t.text == "!~") && i+1 < len(toks) && toks[i+1].str:
+			plain := "="
+			if t.text == "!~" {
+				plain = "!="
+			}
+			if qerr := checkRegexString(query, t, toks[i+1], plain, &warnings); qerr != nil {
+				return qerr, warnings
+			}
+		}
+	}
+	if spansets == 0 {
+		return newQueryError(query, 0, "TraceQL queries start with a spanset filter, e.g. { resource.service.name = \"api\" }"), warnings
+	}
+	return nil, warnings
+}
+
+// localOnly reports a local check result, explaining why the datasource
+// was not asked
+func localOnly(out queryValidation, qerr *queryError, note string) queryValidation {
+	out.CheckedBy = "local"
+	out.Valid = qerr == nil
+	out.Error = qerr
+	out.Note = note
+	return out
+}
+
+func (r *Registry) handleValidateLogQL(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	query := getString(args, "query")
+	if query == "" {
+		return errorResult("query is required"), nil
+	}
+	dsUID := getString(args, "datasource_uid")
+
+	localErr, warnings := checkLogQL(query)
+	out := queryValidation{Warnings: warnings}
+	switch {
+	case dsUID == "":
+		return jsonResult(localOnly(out, localErr, "pass datasource_uid to validate the full query with Loki's parser"))
+	case templateVariable.MatchString(query):
+		return jsonResult(localOnly(out, localErr, "the query uses template variables, which Loki cannot parse until a dashboard expands them"))
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: dsUID, Type: "loki"}, query); err != nil {
+		return jsonResult(localOnly(out, localErr, err.Error()+"; only local checks ran"))
+	}
+
+	formatted, err := r.client.CheckLokiQuery(r.ctx, dsUID, query)
+	var apiErr *grafana.APIError
+	switch {
+	case err == nil:
+		out.Valid, out.CheckedBy = true, "loki"
+		if formatted != query {
+			out.Formatted = formatted
+		}
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest:
+		out.CheckedBy = "loki"
+		out.Error = serverQueryError(query, apiErr.Message)
+	default:
+		out = localOnly(out, localErr, fmt.Sprintf("Loki could not validate the query (%v); only local checks ran", err))
+	}
+	return jsonResult(out)
+}
+
+func (r *Registry) handleValidateTraceQL(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	query := getString(args, "query")
+	if query == "" {
+		return errorResult("query is required"), nil
+	}
+	dsUID := getString(args, "datasource_uid")
+
+	localErr, warnings := checkTraceQL(query)
+	out := queryValidation{Warnings: warnings}
+	switch {
+	case dsUID == "":
+		return jsonResult(localOnly(out, localErr, "pass datasource_uid to validate the full query with Tempo's parser"))
+	case templateVariable.MatchString(query):
+		return jsonResult(localOnly(out, localErr, "the query uses template variables, which Tempo cannot parse until a dashboard expands them"))
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: dsUID, Type: "tempo"}, query); err != nil {
+		return jsonResult(localOnly(out, localErr, err.Error()+"; only local checks ran"))
+	}
+
+	now := time.Now()
+	_, err := r.client.SearchTempo(r.ctx, dsUID, query, now.Add(-5*time.Minute), now, 1)
+	var apiErr *grafana.APIError
+	switch {
+	case err == nil:
+		out.Valid, out.CheckedBy = true, "tempo"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest:
+		out.CheckedBy = "tempo"
+		out.Error = serverQueryError(query, apiErr.Message)
+	default:
+		out = localOnly(out, localErr, fmt.Sprintf("Tempo could not validate the query (%v); only local checks ran", err))
+	}
+	return jsonResult(out)
+}
@@ -0,0 +1,306 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// Resource kinds carried in refs
+const (
+	refDashboard    = "dashboard"
+	refFolder       = "folder"
+	refDatasource   = "datasource"
+	refAlertRule    = "alert_rule"
+	refContactPoint = "contact_point"
+)
+
+// Ref is the canonical reference to a Grafana object. Tools attach one to
+// every object they return and accept one wherever a uid is expected, so
+// an output can be passed straight into the next call.
+type Ref struct {
+	Type string `json:"type"`
+	UID  string `json:"uid"`
+	URL  string `json:"url,omitempty"`
+}
+
+// refURL returns the Grafana UI path of an object
+func refURL(kind, uid string) string {
+	switch kind {
+	case refDashboard:
+		return "/d/" + uid
+	case refFolder:
+		return "/dashboards/f/" + uid
+	case refDatasource:
+		return "/connections/datasources/edit/" + uid
+	case refAlertRule:
+		return "/alerting/grafana/" + uid + "/view"
+	case refContactPoint:
+		return "/alerting/notifications/receivers/" + uid + "/edit"
+	}
+	return ""
+}
+
+// toolRefKind is the kind of object a tool is about, used for uid arguments
+// and returned objects whose shape does not say what they are
+func toolRefKind(tool string) string {
+	switch {
+	case strings.Contains(tool, "folder"):
+		return refFolder
+	case strings.Contains(tool, "datasource"):
+		return refDatasource
+	case strings.Contains(tool, "alert_rule"):
+		return refAlertRule
+	case strings.Contains(tool, "contact_point"):
+		return refContactPoint
+	case strings.Contains(tool, "dashboard"):
+		return refDashboard
+	}
+	return ""
+}
+
+// argRefKind is the kind of object a uid argument names
+func argRefKind(tool, key string) string {
+	switch {
+	case strings.HasPrefix(key, "folder_"):
+		return refFolder
+	case strings.HasPrefix(key, "dashboard_"):
+		return refDashboard
+	case strings.HasSuffix(key, "datasource_uid"), key == "prometheus_uid", key == "tempo_uid", key == "loki_uid":
+		return refDatasource
+	case strings.HasPrefix(key, "integration_"), strings.HasPrefix(key, "contact_point_"):
+		return refContactPoint
+	case key == "uid", key == "uids":
+		return toolRefKind(tool)
+	}
+	return ""
+}
+
+func isUIDArg(key string) bool {
+	return key == "uid" || key == "uids" || strings.HasSuffix(key, "_uid") || strings.HasSuffix(key, "_uids")
+}
+
+// resolveRefArgs replaces refs given for uid arguments with their uids. A
+// ref may be passed on its own or inside the object it was returned with.
+// Returned objects passed back whole, such as a dashboard to update, have
+// their ref dropped so it is not saved into Grafana.
+func resolveRefArgs(tool string, args map[string]interface{}) (map[string]interface{}, error) {
+	var out map[string]interface{}
+	for key, v := range args {
+		var resolved interface{}
+		var changed bool
+		if isUIDArg(key) {
+			var err error
+			if resolved, changed, err = resolveRefValue(argRefKind(tool, key), key, v); err != nil {
+				return nil, err
+			}
+		} else {
+			resolved, changed = stripRefs(v)
+		}
+		if !changed {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]interface{}, len(args))
+			for k, v := range args {
+				out[k] = v
+			}
+		}
+		out[key] = resolved
+	}
+	if out == nil {
+		return args, nil
+	}
+	return out, nil
+}
+
+func resolveRefValue(want, key string, v interface{}) (interface{}, bool, error) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ref, ok := refFromValue(v)
+		if !ok {
+			return nil, false, fmt.Errorf("%s must be a uid or a ref with type and uid", key)
+		}
+		if want != "" && ref.Type != want {
+			return nil, false, fmt.Errorf("%s expects a %s ref, got a %s ref (%s)", key, want, ref.Type, ref.UID)
+		}
+		return ref.UID, true, nil
+	case []interface{}:
+		changed := false
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved, c, err := resolveRefValue(want, key, item)
+			if err != nil {
+				return nil, false, err
+			}
+			if !c {
+				resolved = item
+			}
+			out[i] = resolved
+			changed = changed || c
+		}
+		return out, changed, nil
+	}
+	return nil, false, nil
+}
+
+// stripRefs returns v without the refs added to returned objects, at the top
+// level of an object or of each object in a list
+func stripRefs(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		ref, ok := v["ref"].(map[string]interface{})
+		if !ok {
+			return v, false
+		}
+		if _, ok := refFromValue(ref); !ok {
+			return v, false
+		}
+		out := make(map[string]interface{}, len(v))
+		for k, val := range v {
+			if k != "ref" {
+				out[k] = val
+			}
+		}
+		return out, true
+	case []interface{}:
+		changed := false
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			var c bool
+			out[i], c = stripRefs(item)
+			changed = changed || c
+		}
+		return out, changed
+	}
+	return v, false
+}
+
+// refFromValue reads a ref, or the ref of a returned object
+func refFromValue(v map[string]interface{}) (Ref, bool) {
+	if inner, ok := v["ref"].(map[string]interface{}); ok {
+		v = inner
+	}
+	typ, _ := v["type"].(string)
+	uid, _ := v["uid"].(string)
+	if uid == "" || refURL(typ, uid) == "" {
+		return Ref{}, false
+	}
+	url, _ := v["url"].(string)
+	return Ref{Type: typ, UID: uid, URL: url}, true
+}
+
+// addRefs attaches a ref to each Grafana object in a tool's JSON output.
+// Results that are not JSON, such as rendered images, are left alone.
+func (r *Registry) addRefs(tool string, result *mcp.CallToolResult) {
+	if result == nil || result.IsError {
+		return
+	}
+	for i, block := range result.Content {
+		text := strings.TrimSpace(block.Text)
+		if block.Type != "text" || !strings.HasPrefix(text, "{") && !strings.HasPrefix(text, "[") {
+			continue
+		}
+		// Numbers are kept as written so large IDs survive the round trip
+		dec := json.NewDecoder(strings.NewReader(block.Text))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			continue
+		}
+		if !r.walkRefs(toolRefKind(tool), v) {
+			continue
+		}
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			continue
+		}
+		result.Content[i].Text = string(data)
+		if i == 0 && result.StructuredContent != nil {
+			result.StructuredContent = v
+		}
+	}
+}
+
+// walkRefs adds refs below v and reports whether it added any
+func (r *Registry) walkRefs(fallback string, v interface{}) bool {
+	added := false
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			added = r.walkRefs(fallback, item) || added
+		}
+	case map[string]interface{}:
+		if _, ok := v["ref"]; !ok {
+			if ref, ok := r.objectRef(fallback, v); ok {
+				v["ref"] = ref
+				added = true
+			}
+		}
+		if isDashboardModel(v) {
+			// Panel datasource references are not objects of their own
+			return added
+		}
+		for key, child := range v {
+			switch key {
+			case "ref", "data", "model", "settings", "jsonData", "secureJsonData", "secureFields":
+				continue
+			}
+			added = r.walkRefs(fallback, child) || added
+		}
+	}
+	return added
+}
+
+// objectRef works out the ref of a returned object from its shape, falling
+// back to the kind the tool is about for objects that only carry a uid
+func (r *Registry) objectRef(fallback string, v map[string]interface{}) (Ref, bool) {
+	uid, _ := v["uid"].(string)
+	url, _ := v["url"].(string)
+	kind := ""
+	switch {
+	case v["dashboard"] != nil && v["meta"] != nil:
+		// GET /api/dashboards/uid response: the ref goes on the wrapper
+		dash, _ := v["dashboard"].(map[string]interface{})
+		meta, _ := v["meta"].(map[string]interface{})
+		uid, _ = dash["uid"].(string)
+		url, _ = meta["url"].(string)
+		kind = refDashboard
+	case v["type"] == "dash-db":
+		kind = refDashboard
+	case v["type"] == "dash-folder":
+		kind = refFolder
+	case v["ruleGroup"] != nil && v["folderUID"] != nil:
+		kind = refAlertRule
+	case v["access"] != nil && v["name"] != nil && v["type"] != nil:
+		kind = refDatasource
+	case v["settings"] != nil && v["name"] != nil && v["type"] != nil:
+		kind = refContactPoint
+	default:
+		// Typed objects left over are datasource references such as
+		// {type, uid} inside queries, not objects of the tool's kind
+		if _, typed := v["type"]; !typed {
+			kind = fallback
+		}
+	}
+	if uid == "" || kind == "" {
+		return Ref{}, false
+	}
+	if url == "" || kind == refDatasource || kind == refContactPoint {
+		// Datasource url fields hold the backend address, not a UI link
+		url = refURL(kind, uid)
+	}
+	if strings.HasPrefix(url, "/") {
+		url = r.client.BaseURL() + url
+	}
+	return Ref{Type: kind, UID: uid, URL: url}, true
+}
+
+// isDashboardModel reports whether v is dashboard JSON rather than metadata
+func isDashboardModel(v map[string]interface{}) bool {
+	_, panels := v["panels"]
+	_, schema := v["schemaVersion"]
+	return panels || schema
+}
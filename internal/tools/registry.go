@@ -1,12 +1,18 @@
 package tools
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/npcomplete777/grafana-mcp/internal/cache"
 	"github.com/npcomplete777/grafana-mcp/internal/grafana"
 	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/notify"
+	"github.com/npcomplete777/grafana-mcp/internal/scheduler"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
 )
 
 // Registry holds all tool definitions and handlers
@@ -14,94 +20,319 @@ type Registry struct {
 	client    *grafana.Client
 	tools     map[string]ToolHandler
 	isEnabled func(string) bool
-}
-
-// ToolHandler processes a tool call
-type ToolHandler func(args map[string]interface{}) (*mcp.CallToolResult, error)
+	readOnly  map[string]bool
+	limiter   *limiter
+	version   *grafana.Version
+	features  map[string]bool
+	renderer  *panelRenderer
+	scheduler *scheduler.Scheduler
+	notifier  *notify.Notifier
+	state     *state.Store
+	// destructive records tools annotated as destructive, for notifications
+	// and confirmation
+	destructive map[string]bool
+	// confirm, when set, holds destructive calls until they are confirmed
+	confirm *confirmations
+	// journal records the objects write calls change, for grafana_undo
+	journal *undoJournal
+	// guardrails, when set, sizes Prometheus and Loki queries before they run
+	guardrails *QueryGuardrails
+	// access, when set, restricts the datasources queries may target
+	access *datasourceAccess
+	// urlPolicy, when set, restricts the hosts URLs given to tools may
+	// point at
+	urlPolicy *URLPolicy
+	// queryCache, when set, reuses recent /api/ds/query results
+	queryCache *queryCache
+	// inventory, when set, answers listings from prefetched metadata
+	inventory *inventory
+	// tokens, when set, checks the token's expiry in the background
+	tokens *tokenMonitor
+	// firing polls firing alerts while clients are subscribed to them
+	firing *firingWatch
+	// deltas holds list snapshots for if_changed_since cursors
+	deltas *cache.Cache[listSnapshot]
+	// timeFormat renders epoch timestamps in output as readable times
+	timeFormat TimeFormat
+	// provisioningPath is where provisioned dashboard files are read from
+	provisioningPath string
+	// exportDir is the directory tools may write files into; "" forbids
+	// writing
+	exportDir string
+	// jsonnetDir is the directory Jsonnet programs may read; "" allows
+	// inline programs without imports only
+	jsonnetDir string
+	// jsonnetTimeout bounds a Jsonnet evaluation; 0 uses the default
+	jsonnetTimeout time.Duration
+	// mimir, when set, is the Mimir or Cortex cluster the ruler tools use
+	// without a datasource
+	mimir *grafana.Ruler
+	// dashboardIDs maps each tool's dashboard uid arguments to the
+	// arguments that accept legacy numeric ids instead
+	dashboardIDs map[string]map[string]string
+	// folderTitles lists each tool's folder uid arguments that also accept
+	// a folder title
+	folderTitles map[string][]string
+	// instanceName is what the instance argument of tools calls this
+	// registry's Grafana
+	instanceName string
+	// instances, when set, are the Grafana instances tools can address
+	instances *instanceSet
+	// ctx bounds the Grafana requests of the call a per-call copy serves;
+	// it is Background outside a call
+	ctx context.Context
+}
+
+// ToolHandler processes a tool call. It runs against a per-call copy of
+// the registry whose ctx is the call's context, which handlers pass to the
+// Grafana client.
+type ToolHandler func(r *Registry, args map[string]interface{}) (*mcp.CallToolResult, error)
 
 // NewRegistry creates a new tool registry. isEnabled gates individual tools;
 // pass nil to enable all tools unconditionally.
-func NewRegistry(client *grafana.Client, isEnabled func(string) bool) *Registry {
+func NewRegistry(client *grafana.Client, isEnabled func(string) bool, opts ...Option) *Registry {
 	if isEnabled == nil {
 		isEnabled = func(string) bool { return true }
 	}
 	r := &Registry{
-		client:    client,
-		tools:     make(map[string]ToolHandler),
-		isEnabled: isEnabled,
-	}
+		client:       client,
+		ctx:          context.Background(),
+		tools:        make(map[string]ToolHandler),
+		isEnabled:    isEnabled,
+		readOnly:     make(map[string]bool),
+		destructive:  make(map[string]bool),
+		renderer:     newPanelRenderer(DefaultRenderSettings()),
+		queryCache:   newQueryCache(DefaultQueryCacheSettings()),
+		deltas:       newDeltaSnapshots(),
+		firing:       newFiringWatch(),
+		timeFormat:   DefaultTimeFormat(),
+		dashboardIDs: make(map[string]map[string]string),
+		folderTitles: make(map[string][]string),
+		instanceName: defaultInstanceName,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	// The state store keeps as many renders as the in-memory cache
+	r.state.SetLimit(state.BucketRender, r.renderer.size)
+	if r.confirm != nil {
+		r.confirm.state = r.state
+	}
+	r.journal = newUndoJournal(r.state)
 	r.registerAll()
+	for _, t := range r.allTools() {
+		if twins := dashboardIDArgs(t); len(twins) > 0 {
+			r.dashboardIDs[t.Name] = twins
+		}
+		if keys := folderTitleArgs(t); len(keys) > 0 {
+			r.folderTitles[t.Name] = keys
+		}
+	}
+	for _, t := range r.GetTools() {
+		r.readOnly[t.Name] = t.Annotations != nil && t.Annotations.ReadOnlyHint
+		r.destructive[t.Name] = t.Annotations != nil && t.Annotations.DestructiveHint
+	}
 	return r
 }
 
-// GetTools returns all enabled tool definitions.
+// toolGroup is a category of tools, as the README groups them
+type toolGroup struct {
+	Name  string
+	Tools []mcp.Tool
+}
+
+// toolGroups returns every tool definition by category, whether enabled or
+// not
+func (r *Registry) toolGroups() []toolGroup {
+	return []toolGroup{
+		{"Health", []mcp.Tool{
+			r.grafanaHealthTool(),
+			r.grafanaGetInstanceInfoTool(),
+			r.grafanaCheckTokenAccessTool(),
+			r.grafanaCheckTokenExpiryTool(),
+			r.grafanaCapabilitiesTool(),
+			r.grafanaListInstancesTool(),
+			r.grafanaUseInstanceTool(),
+		}},
+		{"Dashboards", []mcp.Tool{
+			r.grafanaSearchDashboardsTool(),
+			r.grafanaSearchTool(),
+			r.grafanaGetDashboardTool(),
+			r.grafanaCreateDashboardTool(),
+			r.grafanaUpdateDashboardTool(),
+			r.grafanaDeleteDashboardTool(),
+			r.grafanaFindUnusedDashboardsTool(),
+			r.grafanaArchiveDashboardsTool(),
+			r.grafanaApplyJsonnetDashboardTool(),
+			r.grafanaImportDashboardTool(),
+			r.grafanaUpgradeDashboardSchemaTool(),
+			r.grafanaEnforceTimePolicyTool(),
+			r.grafanaTemplatizeDashboardTool(),
+			r.grafanaScoreDashboardTool(),
+			r.grafanaGenerateDashboardFromRulesTool(),
+			r.grafanaGenerateServiceDashboardTool(),
+			r.grafanaBootstrapServiceTool(),
+			r.grafanaMonitorEndpointTool(),
+			r.grafanaInstallKubernetesPackTool(),
+			r.grafanaListTemplatesTool(),
+			r.grafanaInstallTemplateTool(),
+			r.grafanaBulkTagTool(),
+		}},
+		{"Datasources", []mcp.Tool{
+			r.grafanaListDatasourcesTool(),
+			r.grafanaGetDatasourceTool(),
+			r.grafanaCreateDatasourceTool(),
+			r.grafanaUpdateDatasourceTool(),
+			r.grafanaDeleteDatasourceTool(),
+			r.grafanaListDatasourceTypesTool(),
+		}},
+		{"Folders", []mcp.Tool{
+			r.grafanaListFoldersTool(),
+			r.grafanaGetFolderTool(),
+			r.grafanaCreateFolderTool(),
+			r.grafanaUpdateFolderTool(),
+			r.grafanaDeleteFolderTool(),
+			r.grafanaFindFolderTool(),
+		}},
+		{"Alert Rules", []mcp.Tool{
+			r.grafanaListAlertRulesTool(),
+			r.grafanaGetAlertRuleTool(),
+			r.grafanaCreateAlertRuleTool(),
+			r.grafanaUpdateAlertRuleTool(),
+			r.grafanaDeleteAlertRuleTool(),
+			r.grafanaAlertNoiseReportTool(),
+			r.grafanaBulkEditAlertRulesTool(),
+			r.grafanaApplyAlertTemplateTool(),
+			r.grafanaLintAlertRulesTool(),
+			r.grafanaAlertOwnershipReportTool(),
+		}},
+		{"Contact Points", []mcp.Tool{
+			r.grafanaListContactPointsTool(),
+			r.grafanaGetContactPointTool(),
+			r.grafanaCreateContactPointTool(),
+			r.grafanaUpdateContactPointTool(),
+			r.grafanaDeleteContactPointTool(),
+			r.grafanaTestContactPointTool(),
+			r.grafanaPreviewRoutingTool(),
+			r.grafanaContactPointInventoryTool(),
+		}},
+		{"Mimir Ruler", []mcp.Tool{
+			r.grafanaMimirListRuleGroupsTool(),
+			r.grafanaMimirGetRuleGroupTool(),
+			r.grafanaMimirSetRuleGroupTool(),
+			r.grafanaMimirDeleteRuleGroupTool(),
+			r.grafanaMimirGetAlertmanagerConfigTool(),
+			r.grafanaMimirSetAlertmanagerConfigTool(),
+		}},
+		{"Annotations", []mcp.Tool{
+			r.grafanaListAnnotationsTool(),
+			r.grafanaCreateAnnotationTool(),
+			r.grafanaUpdateAnnotationTool(),
+			r.grafanaDeleteAnnotationTool(),
+			r.grafanaImportAnnotationsTool(),
+		}},
+		{"Query", []mcp.Tool{
+			r.grafanaQueryTool(),
+			r.grafanaQueryMultiTool(),
+			r.grafanaExploreLinkTool(),
+			r.grafanaValidatePromQLTool(),
+			r.grafanaValidateLogQLTool(),
+			r.grafanaValidateTraceQLTool(),
+			r.grafanaEstimateQueryCostTool(),
+			r.grafanaPrometheusTargetsTool(),
+			r.grafanaLokiStatsTool(),
+		}},
+		{"Render", []mcp.Tool{
+			r.grafanaRenderPanelTool(),
+			r.grafanaGenerateReportTool(),
+		}},
+		{"Live", []mcp.Tool{
+			r.grafanaLiveSubscribeTool(),
+			r.grafanaLokiTailTool(),
+		}},
+		{"Analysis", []mcp.Tool{
+			r.grafanaCorrelateChangesTool(),
+			r.grafanaLogPatternsTool(),
+			r.grafanaFindDashboardAnomaliesTool(),
+			r.grafanaExplainPanelErrorsTool(),
+			r.grafanaDependencyGraphTool(),
+			r.grafanaPermissionsReportTool(),
+			r.grafanaBurnRateTool(),
+			r.grafanaForecastTool(),
+		}},
+		{"Export", []mcp.Tool{
+			r.grafanaExportProvisioningTool(),
+			r.grafanaListProvisionedDashboardsTool(),
+			r.grafanaDiffProvisionedDashboardsTool(),
+			r.grafanaExportIaCTool(),
+			r.grafanaApplyManifestTool(),
+		}},
+		{"Scheduler", []mcp.Tool{
+			r.grafanaListScheduledJobsTool(),
+			r.grafanaGetJobHistoryTool(),
+		}},
+		{"Batch", []mcp.Tool{
+			r.grafanaBatchTool(),
+		}},
+		{"Undo", []mcp.Tool{
+			r.grafanaListUndoTool(),
+			r.grafanaUndoTool(),
+		}},
+		{"Organization", []mcp.Tool{
+			r.grafanaGetOrgTool(),
+			r.grafanaListOrgUsersTool(),
+			r.grafanaListOrgsTool(),
+			r.grafanaSwitchOrgTool(),
+		}},
+		{"User", []mcp.Tool{
+			r.grafanaGetCurrentUserTool(),
+			r.grafanaUserActivityTool(),
+		}},
+		{"Teams", []mcp.Tool{
+			r.grafanaListTeamsTool(),
+			r.grafanaGetTeamTool(),
+			r.grafanaCreateTeamTool(),
+			r.grafanaDeleteTeamTool(),
+			r.grafanaGrantTeamWorkspaceTool(),
+		}},
+	}
+}
+
+// allTools returns every tool definition, whether enabled or not.
+func (r *Registry) allTools() []mcp.Tool {
+	var all []mcp.Tool
+	for _, g := range r.toolGroups() {
+		all = append(all, g.Tools...)
+	}
+	return all
+}
+
+// GetTools returns all enabled tool definitions supported by the connected Grafana.
 func (r *Registry) GetTools() []mcp.Tool {
-	all := []mcp.Tool{
-		// Health
-		r.grafanaHealthTool(),
-
-		// Dashboard tools
-		r.grafanaSearchDashboardsTool(),
-		r.grafanaGetDashboardTool(),
-		r.grafanaCreateDashboardTool(),
-		r.grafanaUpdateDashboardTool(),
-		r.grafanaDeleteDashboardTool(),
-
-		// Datasource tools
-		r.grafanaListDatasourcesTool(),
-		r.grafanaGetDatasourceTool(),
-		r.grafanaCreateDatasourceTool(),
-		r.grafanaUpdateDatasourceTool(),
-		r.grafanaDeleteDatasourceTool(),
-
-		// Folder tools
-		r.grafanaListFoldersTool(),
-		r.grafanaGetFolderTool(),
-		r.grafanaCreateFolderTool(),
-		r.grafanaUpdateFolderTool(),
-		r.grafanaDeleteFolderTool(),
-
-		// Alert tools
-		r.grafanaListAlertRulesTool(),
-		r.grafanaGetAlertRuleTool(),
-		r.grafanaCreateAlertRuleTool(),
-		r.grafanaUpdateAlertRuleTool(),
-		r.grafanaDeleteAlertRuleTool(),
-
-		// Annotation tools
-		r.grafanaListAnnotationsTool(),
-		r.grafanaCreateAnnotationTool(),
-		r.grafanaUpdateAnnotationTool(),
-		r.grafanaDeleteAnnotationTool(),
-
-		// Query tools
-		r.grafanaQueryTool(),
-
-		// Organization tools
-		r.grafanaGetOrgTool(),
-		r.grafanaListOrgUsersTool(),
-
-		// User tools
-		r.grafanaGetCurrentUserTool(),
-
-		// Team tools
-		r.grafanaListTeamsTool(),
-		r.grafanaGetTeamTool(),
-		r.grafanaCreateTeamTool(),
-		r.grafanaDeleteTeamTool(),
-	}
-
+	all := r.allTools()
 	enabled := make([]mcp.Tool, 0, len(all))
 	for _, t := range all {
-		if r.isEnabled(t.Name) {
-			enabled = append(enabled, t)
+		if r.isEnabled(t.Name) && r.unsupportedReason(t.Name) == "" {
+			enabled = append(enabled, r.withConfirmArg(r.withInstanceArg(withFolderTitleArgs(withDashboardIDArgs(t)))))
 		}
 	}
 	return enabled
 }
 
-// CallTool executes a tool by name
-func (r *Registry) CallTool(name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+// CallTool executes a tool by name, waiting for any configured concurrency
+// limits. Grafana requests the tool makes are aborted once ctx is done. It
+// is safe to call from multiple goroutines.
+func (r *Registry) CallTool(ctx context.Context, name string, args map[string]interface{}) (*mcp.CallToolResult, error) {
+	target, args, err := r.instanceRegistry(ctx, args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if target != r {
+		return target.CallTool(withCallInstance(ctx, target.instanceName), name, args)
+	}
+	if reason := r.unsupportedReason(name); reason != "" {
+		return errorResult(reason), nil
+	}
 	handler, ok := r.tools[name]
 	if !ok {
 		return &mcp.CallToolResult{
@@ -109,7 +340,109 @@ func (r *Registry) CallTool(name string, args map[string]interface{}) (*mcp.Call
 			Content: []mcp.ContentBlock{{Type: "text", Text: fmt.Sprintf("Unknown tool: %s", name)}},
 		}, nil
 	}
-	return handler(args)
+	if r.confirm != nil && r.destructive[name] {
+		var ask *mcp.CallToolResult
+		if ctx, args, ask = r.confirm.check(ctx, r.instanceName, name, args); ask != nil {
+			return ask, nil
+		}
+	}
+	args, err = resolveRefArgs(name, args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if args, err = r.resolveDashboardIDArgs(name, args); err != nil {
+		return errorResultFor(err), nil
+	}
+	if args, err = r.resolveFolderTitleArgs(name, args); err != nil {
+		return errorResultFor(err), nil
+	}
+	call := func() (*mcp.CallToolResult, error) {
+		cr := r.forCall(ctx)
+		before := cr.undoSnapshots(name, args)
+		result, err := handler(cr, args)
+		if len(before) > 0 && err == nil && result != nil && !result.IsError {
+			cr.recordUndo(before)
+		}
+		r.addRefs(name, result)
+		return result, err
+	}
+	// A batch takes no limiter slot of its own; each step does, and holding
+	// one across the steps could deadlock a limit of one
+	if r.limiter != nil && name != batchToolName {
+		release, err := r.limiter.acquire(ctx, name, !r.readOnly[name], args)
+		if err != nil {
+			return errorResultFor(fmt.Errorf("waiting to run %s: %w", name, err)), nil
+		}
+		defer release()
+	}
+	if !r.readOnly[name] && name != batchToolName {
+		defer r.invalidateInventory()
+	}
+	if r.notifier == nil {
+		return call()
+	}
+
+	started := time.Now()
+	result, err := call()
+	ev := notify.Event{
+		Kind:        "tool",
+		Tool:        name,
+		Status:      "ok",
+		ReadOnly:    r.readOnly[name],
+		Destructive: r.destructive[name],
+		Args:        args,
+		Time:        started.UTC(),
+		Duration:    time.Since(started).Round(time.Millisecond).String(),
+	}
+	switch {
+	case err != nil:
+		ev.Status, ev.Error = "error", err.Error()
+	case result != nil && result.IsError:
+		ev.Status = "error"
+		if len(result.Content) > 0 {
+			ev.Error = result.Content[0].Text
+		}
+	}
+	r.notifier.Notify(ev)
+	return result, err
+}
+
+// forCall returns a copy of the registry for one tool call, whose Grafana
+// requests are aborted when ctx is done. Everything else is shared.
+func (r *Registry) forCall(ctx context.Context) *Registry {
+	rc := *r
+	rc.ctx = ctx
+	return &rc
+}
+
+// ReleaseIdle drops cached query results, responses, renders, and list
+// snapshots and closes idle Grafana connections, on every connected instance.
+// Transports call it when their last session ends, so an unused server holds
+// no per-client state.
+func (r *Registry) ReleaseIdle() {
+	r.releaseIdle()
+	r.otherInstances((*Registry).releaseIdle)
+}
+
+func (r *Registry) releaseIdle() {
+	if r.queryCache != nil {
+		r.queryCache.results.Purge()
+	}
+	if r.renderer != nil && r.renderer.cache != nil {
+		r.renderer.cache.Purge()
+	}
+	r.deltas.Purge()
+	r.client.InvalidateResponseCache()
+	r.client.CloseIdleConnections()
+}
+
+// readCtx returns the call's context, bypassing cached Grafana responses
+// when args ask for a refresh
+func (r *Registry) readCtx(args map[string]interface{}) context.Context {
+	if getBool(args, "refresh") {
+		return grafana.Uncached(r.ctx)
+	}
+	return r.ctx
 }
 
 func (r *Registry) registerAll() {
@@ -120,64 +453,161 @@ func (r *Registry) registerAll() {
 	}
 
 	// Health
-	reg("grafana_health", r.handleHealth)
+	reg("grafana_health", (*Registry).handleHealth)
+	reg("grafana_get_instance_info", (*Registry).handleGetInstanceInfo)
+	reg("grafana_check_token_access", (*Registry).handleCheckTokenAccess)
+	reg("grafana_check_token_expiry", (*Registry).handleCheckTokenExpiry)
+	reg("grafana_capabilities", (*Registry).handleCapabilities)
+	reg("grafana_list_instances", (*Registry).handleListInstances)
+	reg("grafana_use_instance", (*Registry).handleUseInstance)
 
 	// Dashboards
-	reg("grafana_search_dashboards", r.handleSearchDashboards)
-	reg("grafana_get_dashboard", r.handleGetDashboard)
-	reg("grafana_create_dashboard", r.handleCreateDashboard)
-	reg("grafana_update_dashboard", r.handleUpdateDashboard)
-	reg("grafana_delete_dashboard", r.handleDeleteDashboard)
+	reg("grafana_search_dashboards", (*Registry).handleSearchDashboards)
+	reg("grafana_search", (*Registry).handleSearch)
+	reg("grafana_get_dashboard", (*Registry).handleGetDashboard)
+	reg("grafana_create_dashboard", (*Registry).handleCreateDashboard)
+	reg("grafana_update_dashboard", (*Registry).handleUpdateDashboard)
+	reg("grafana_delete_dashboard", (*Registry).handleDeleteDashboard)
+	reg("grafana_find_unused_dashboards", (*Registry).handleFindUnusedDashboards)
+	reg("grafana_archive_dashboards", (*Registry).handleArchiveDashboards)
+	reg("grafana_apply_jsonnet_dashboard", (*Registry).handleApplyJsonnetDashboard)
+	reg("grafana_import_dashboard", (*Registry).handleImportDashboard)
+	reg("grafana_upgrade_dashboard_schema", (*Registry).handleUpgradeDashboardSchema)
+	reg("grafana_enforce_time_policy", (*Registry).handleEnforceTimePolicy)
+	reg("grafana_templatize_dashboard", (*Registry).handleTemplatizeDashboard)
+	reg("grafana_score_dashboard", (*Registry).handleScoreDashboard)
+	reg("grafana_generate_dashboard_from_rules", (*Registry).handleGenerateDashboardFromRules)
+	reg("grafana_generate_service_dashboard", (*Registry).handleGenerateServiceDashboard)
+	reg("grafana_bootstrap_service", (*Registry).handleBootstrapService)
+	reg("grafana_monitor_endpoint", (*Registry).handleMonitorEndpoint)
+	reg("grafana_install_kubernetes_pack", (*Registry).handleInstallKubernetesPack)
+	reg("grafana_list_templates", (*Registry).handleListTemplates)
+	reg("grafana_install_template", (*Registry).handleInstallTemplate)
+	reg("grafana_bulk_tag", (*Registry).handleBulkTag)
 
 	// Datasources
-	reg("grafana_list_datasources", r.handleListDatasources)
-	reg("grafana_get_datasource", r.handleGetDatasource)
-	reg("grafana_create_datasource", r.handleCreateDatasource)
-	reg("grafana_update_datasource", r.handleUpdateDatasource)
-	reg("grafana_delete_datasource", r.handleDeleteDatasource)
+	reg("grafana_list_datasources", (*Registry).handleListDatasources)
+	reg("grafana_get_datasource", (*Registry).handleGetDatasource)
+	reg("grafana_create_datasource", (*Registry).handleCreateDatasource)
+	reg("grafana_update_datasource", (*Registry).handleUpdateDatasource)
+	reg("grafana_delete_datasource", (*Registry).handleDeleteDatasource)
+	reg("grafana_list_datasource_types", (*Registry).handleListDatasourceTypes)
 
 	// Folders
-	reg("grafana_list_folders", r.handleListFolders)
-	reg("grafana_get_folder", r.handleGetFolder)
-	reg("grafana_create_folder", r.handleCreateFolder)
-	reg("grafana_update_folder", r.handleUpdateFolder)
-	reg("grafana_delete_folder", r.handleDeleteFolder)
+	reg("grafana_list_folders", (*Registry).handleListFolders)
+	reg("grafana_get_folder", (*Registry).handleGetFolder)
+	reg("grafana_create_folder", (*Registry).handleCreateFolder)
+	reg("grafana_update_folder", (*Registry).handleUpdateFolder)
+	reg("grafana_delete_folder", (*Registry).handleDeleteFolder)
+	reg("grafana_find_folder", (*Registry).handleFindFolder)
 
 	// Alerts
-	reg("grafana_list_alert_rules", r.handleListAlertRules)
-	reg("grafana_get_alert_rule", r.handleGetAlertRule)
-	reg("grafana_create_alert_rule", r.handleCreateAlertRule)
-	reg("grafana_update_alert_rule", r.handleUpdateAlertRule)
-	reg("grafana_delete_alert_rule", r.handleDeleteAlertRule)
+	reg("grafana_list_alert_rules", (*Registry).handleListAlertRules)
+	reg("grafana_get_alert_rule", (*Registry).handleGetAlertRule)
+	reg("grafana_create_alert_rule", (*Registry).handleCreateAlertRule)
+	reg("grafana_update_alert_rule", (*Registry).handleUpdateAlertRule)
+	reg("grafana_delete_alert_rule", (*Registry).handleDeleteAlertRule)
+	reg("grafana_alert_noise_report", (*Registry).handleAlertNoiseReport)
+	reg("grafana_bulk_edit_alert_rules", (*Registry).handleBulkEditAlertRules)
+	reg("grafana_apply_alert_template", (*Registry).handleApplyAlertTemplate)
+	reg("grafana_lint_alert_rules", (*Registry).handleLintAlertRules)
+	reg("grafana_alert_ownership_report", (*Registry).handleAlertOwnershipReport)
+
+	// Contact points
+	reg("grafana_list_contact_points", (*Registry).handleListContactPoints)
+	reg("grafana_get_contact_point", (*Registry).handleGetContactPoint)
+	reg("grafana_create_contact_point", (*Registry).handleCreateContactPoint)
+	reg("grafana_update_contact_point", (*Registry).handleUpdateContactPoint)
+	reg("grafana_delete_contact_point", (*Registry).handleDeleteContactPoint)
+	reg("grafana_test_contact_point", (*Registry).handleTestContactPoint)
+	reg("grafana_preview_alert_routing", (*Registry).handlePreviewRouting)
+	reg("grafana_contact_point_inventory", (*Registry).handleContactPointInventory)
+
+	// Mimir ruler
+	reg("grafana_mimir_list_rule_groups", (*Registry).handleMimirListRuleGroups)
+	reg("grafana_mimir_get_rule_group", (*Registry).handleMimirGetRuleGroup)
+	reg("grafana_mimir_set_rule_group", (*Registry).handleMimirSetRuleGroup)
+	reg("grafana_mimir_delete_rule_group", (*Registry).handleMimirDeleteRuleGroup)
+	reg("grafana_mimir_get_alertmanager_config", (*Registry).handleMimirGetAlertmanagerConfig)
+	reg("grafana_mimir_set_alertmanager_config", (*Registry).handleMimirSetAlertmanagerConfig)
 
 	// Annotations
-	reg("grafana_list_annotations", r.handleListAnnotations)
-	reg("grafana_create_annotation", r.handleCreateAnnotation)
-	reg("grafana_update_annotation", r.handleUpdateAnnotation)
-	reg("grafana_delete_annotation", r.handleDeleteAnnotation)
+	reg("grafana_list_annotations", (*Registry).handleListAnnotations)
+	reg("grafana_create_annotation", (*Registry).handleCreateAnnotation)
+	reg("grafana_update_annotation", (*Registry).handleUpdateAnnotation)
+	reg("grafana_delete_annotation", (*Registry).handleDeleteAnnotation)
+	reg("grafana_import_annotations", (*Registry).handleImportAnnotations)
 
 	// Query
-	reg("grafana_query", r.handleQuery)
+	reg("grafana_query", (*Registry).handleQuery)
+	reg("grafana_query_multi", (*Registry).handleQueryMulti)
+	reg("grafana_explore_link", (*Registry).handleExploreLink)
+	reg("grafana_validate_promql", (*Registry).handleValidatePromQL)
+	reg("grafana_validate_logql", (*Registry).handleValidateLogQL)
+	reg("grafana_validate_traceql", (*Registry).handleValidateTraceQL)
+	reg("grafana_estimate_query_cost", (*Registry).handleEstimateQueryCost)
+	reg("grafana_prometheus_targets", (*Registry).handlePrometheusTargets)
+	reg("grafana_loki_stats", (*Registry).handleLokiStats)
+
+	// Render
+	reg("grafana_render_panel", (*Registry).handleRenderPanel)
+	reg("grafana_generate_report", (*Registry).handleGenerateReport)
+
+	// Live
+	reg("grafana_live_subscribe", (*Registry).handleLiveSubscribe)
+	reg("grafana_loki_tail", (*Registry).handleLokiTail)
+
+	// Analysis
+	reg("grafana_correlate_changes", (*Registry).handleCorrelateChanges)
+	reg("grafana_log_patterns", (*Registry).handleLogPatterns)
+	reg("grafana_find_dashboard_anomalies", (*Registry).handleFindDashboardAnomalies)
+	reg("grafana_explain_panel_errors", (*Registry).handleExplainPanelErrors)
+	reg("grafana_dependency_graph", (*Registry).handleDependencyGraph)
+	reg("grafana_permissions_report", (*Registry).handlePermissionsReport)
+	reg("grafana_burn_rate", (*Registry).handleBurnRate)
+	reg("grafana_forecast", (*Registry).handleForecast)
+
+	// Export
+	reg("grafana_export_provisioning", (*Registry).handleExportProvisioning)
+	reg("grafana_list_provisioned_dashboards", (*Registry).handleListProvisionedDashboards)
+	reg("grafana_diff_provisioned_dashboards", (*Registry).handleDiffProvisionedDashboards)
+	reg("grafana_export_iac", (*Registry).handleExportIaC)
+	reg("grafana_apply_manifest", (*Registry).handleApplyManifest)
+
+	// Scheduler
+	reg("grafana_list_scheduled_jobs", (*Registry).handleListScheduledJobs)
+	reg("grafana_get_job_history", (*Registry).handleGetJobHistory)
+
+	// Batch
+	reg("grafana_batch", (*Registry).handleBatch)
+
+	// Undo
+	reg("grafana_list_undo", (*Registry).handleListUndo)
+	reg(undoToolName, (*Registry).handleUndo)
 
 	// Organization
-	reg("grafana_get_org", r.handleGetOrg)
-	reg("grafana_list_org_users", r.handleListOrgUsers)
+	reg("grafana_get_org", (*Registry).handleGetOrg)
+	reg("grafana_list_org_users", (*Registry).handleListOrgUsers)
+	reg("grafana_list_orgs", (*Registry).handleListOrgs)
+	reg("grafana_switch_org", (*Registry).handleSwitchOrg)
 
 	// User
-	reg("grafana_get_current_user", r.handleGetCurrentUser)
+	reg("grafana_get_current_user", (*Registry).handleGetCurrentUser)
+	reg("grafana_user_activity", (*Registry).handleUserActivity)
 
 	// Teams
-	reg("grafana_list_teams", r.handleListTeams)
-	reg("grafana_get_team", r.handleGetTeam)
-	reg("grafana_create_team", r.handleCreateTeam)
-	reg("grafana_delete_team", r.handleDeleteTeam)
+	reg("grafana_list_teams", (*Registry).handleListTeams)
+	reg("grafana_get_team", (*Registry).handleGetTeam)
+	reg("grafana_create_team", (*Registry).handleCreateTeam)
+	reg("grafana_delete_team", (*Registry).handleDeleteTeam)
+	reg("grafana_grant_team_workspace", (*Registry).handleGrantTeamWorkspace)
 }
 
 // Helper functions
 func jsonResult(v interface{}) (*mcp.CallToolResult, error) {
 	data, err := json.MarshalIndent(v, "", "  ")
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to marshal result: %v", err)), nil
+		return apiErrorResult("Failed to marshal result", err), nil
 	}
 	return &mcp.CallToolResult{
 		Content: []mcp.ContentBlock{{Type: "text", Text: string(data)}},
@@ -191,6 +621,40 @@ func errorResult(msg string) *mcp.CallToolResult {
 	}
 }
 
+// apiErrorResult reports err as a tool error prefixed by what failed. A
+// Grafana API error also comes back as structured content under "error",
+// so clients can tell a denied request from a missing object or a conflict.
+func apiErrorResult(what string, err error) *mcp.CallToolResult {
+	return withErrorData(errorResult(what+": "+err.Error()), err)
+}
+
+// errorResultFor is apiErrorResult for errors that already say what failed
+func errorResultFor(err error) *mcp.CallToolResult {
+	return withErrorData(errorResult(err.Error()), err)
+}
+
+func withErrorData(res *mcp.CallToolResult, err error) *mcp.CallToolResult {
+	if data := ErrorData(err); data.Status != 0 {
+		res.StructuredContent = map[string]interface{}{"error": data}
+	}
+	return res
+}
+
+// ErrorData describes err for a JSON-RPC error or a tool result, with the
+// status, messageId, and trace ID of a Grafana API error
+func ErrorData(err error) *mcp.ErrorData {
+	data := &mcp.ErrorData{Details: err.Error()}
+	var apiErr *grafana.APIError
+	if errors.As(err, &apiErr) {
+		data.Cause = apiErr.Message
+		data.Status = apiErr.StatusCode
+		data.Kind = apiErr.Kind()
+		data.MessageID = apiErr.MessageID
+		data.TraceID = apiErr.TraceID
+	}
+	return data
+}
+
 func getString(args map[string]interface{}, key string) string {
 	if v, ok := args[key]; ok {
 		if s, ok := v.(string); ok {
@@ -218,6 +682,20 @@ func getInt(args map[string]interface{}, key string) int {
 	return int(getInt64(args, key))
 }
 
+func getFloat(args map[string]interface{}, key string) float64 {
+	if v, ok := args[key]; ok {
+		switch n := v.(type) {
+		case float64:
+			return n
+		case int64:
+			return float64(n)
+		case int:
+			return float64(n)
+		}
+	}
+	return 0
+}
+
 func getBool(args map[string]interface{}, key string) bool {
 	if v, ok := args[key]; ok {
 		if b, ok := v.(bool); ok {
@@ -242,6 +720,49 @@ func getStringSlice(args map[string]interface{}, key string) []string {
 	return nil
 }
 
+func getInt64Slice(args map[string]interface{}, key string) []int64 {
+	if v, ok := args[key]; ok {
+		if arr, ok := v.([]interface{}); ok {
+			result := make([]int64, 0, len(arr))
+			for _, item := range arr {
+				if n, ok := item.(float64); ok {
+					result = append(result, int64(n))
+				}
+			}
+			return result
+		}
+	}
+	return nil
+}
+
+func getMapSlice(args map[string]interface{}, key string) []map[string]interface{} {
+	if v, ok := args[key]; ok {
+		if arr, ok := v.([]interface{}); ok {
+			result := make([]map[string]interface{}, 0, len(arr))
+			for _, item := range arr {
+				if m, ok := item.(map[string]interface{}); ok {
+					result = append(result, m)
+				}
+			}
+			return result
+		}
+	}
+	return nil
+}
+
+func getStringMap(args map[string]interface{}, key string) map[string]string {
+	if m, ok := args[key].(map[string]interface{}); ok {
+		result := make(map[string]string, len(m))
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				result[k] = s
+			}
+		}
+		return result
+	}
+	return nil
+}
+
 // ============== Tool Definitions ==============
 
 func (r *Registry) grafanaHealthTool() mcp.Tool {
@@ -265,12 +786,13 @@ func (r *Registry) grafanaSearchDashboardsTool() mcp.Tool {
 		Description: "Search for dashboards by query, tags, or folder",
 		InputSchema: mcp.InputSchema{
 			Type: "object",
-			Properties: map[string]mcp.Property{
-				"query": {Type: "string", Description: "Search query string"},
-				"tags":  {Type: "array", Description: "Filter by tags"},
-				"type":  {Type: "string", Description: "Filter by type: dash-db or dash-folder", Enum: []string{"dash-db", "dash-folder"}},
-				"limit": {Type: "integer", Description: "Maximum number of results (default 50)"},
-			},
+			Properties: deltaProperties(map[string]mcp.Property{
+				"query":   {Type: "string", Description: "Search query string"},
+				"tags":    {Type: "array", Description: "Filter by tags"},
+				"type":    {Type: "string", Description: "Filter by type: dash-db or dash-folder", Enum: []string{"dash-db", "dash-folder"}},
+				"limit":   {Type: "integer", Description: "Maximum number of results (default 50)"},
+				"refresh": {Type: "boolean", Description: "Search live instead of answering from the prefetched inventory or cached responses"},
+			}),
 		},
 		Annotations: &mcp.ToolAnnotations{
 			ReadOnlyHint:  true,
@@ -328,13 +850,14 @@ func (r *Registry) grafanaUpdateDashboardTool() mcp.Tool {
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
-				"uid":        {Type: "string", Description: "Dashboard UID to update"},
-				"title":      {Type: "string", Description: "New dashboard title"},
-				"tags":       {Type: "array", Description: "Dashboard tags"},
-				"panels":     {Type: "array", Description: "Array of panel configurations"},
-				"folder_uid": {Type: "string", Description: "Folder UID to move dashboard to"},
-				"message":    {Type: "string", Description: "Save message/commit description"},
-				"overwrite":  {Type: "boolean", Description: "Overwrite existing dashboard"},
+				"uid":               {Type: "string", Description: "Dashboard UID to update"},
+				"title":             {Type: "string", Description: "New dashboard title"},
+				"tags":              {Type: "array", Description: "Dashboard tags"},
+				"panels":            {Type: "array", Description: "Array of panel configurations"},
+				"folder_uid":        {Type: "string", Description: "Folder UID to move dashboard to"},
+				"message":           {Type: "string", Description: "Save message/commit description"},
+				"overwrite":         {Type: "boolean", Description: "Overwrite existing dashboard"},
+				"retry_on_conflict": {Type: "integer", Description: "If the dashboard changes before the save, refetch it, reapply the update, and retry up to this many times (default 0, max 5)"},
 			},
 			Required: []string{"uid"},
 		},
@@ -368,8 +891,10 @@ func (r *Registry) grafanaListDatasourcesTool() mcp.Tool {
 		Name:        "grafana_list_datasources",
 		Description: "List all configured datasources",
 		InputSchema: mcp.InputSchema{
-			Type:       "object",
-			Properties: map[string]mcp.Property{},
+			Type: "object",
+			Properties: deltaProperties(map[string]mcp.Property{
+				"refresh": {Type: "boolean", Description: "Fetch live instead of answering from the prefetched inventory or cached responses"},
+			}),
 		},
 		Annotations: &mcp.ToolAnnotations{
 			ReadOnlyHint:  true,
@@ -399,18 +924,23 @@ func (r *Registry) grafanaGetDatasourceTool() mcp.Tool {
 func (r *Registry) grafanaCreateDatasourceTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "grafana_create_datasource",
-		Description: "Create a new datasource",
+		Description: "Create a new datasource. Use grafana_list_datasource_types to see the settings a type expects",
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
-				"name":       {Type: "string", Description: "Datasource name"},
-				"type":       {Type: "string", Description: "Datasource type (e.g., prometheus, loki, elasticsearch)"},
-				"url":        {Type: "string", Description: "Datasource URL"},
-				"access":     {Type: "string", Description: "Access mode: proxy or direct", Enum: []string{"proxy", "direct"}},
-				"is_default": {Type: "boolean", Description: "Set as default datasource"},
-				"json_data":  {Type: "object", Description: "Additional JSON configuration"},
+				"name":             {Type: "string", Description: "Datasource name"},
+				"type":             {Type: "string", Description: "Datasource type (e.g., prometheus, loki, elasticsearch)"},
+				"url":              {Type: "string", Description: "Datasource URL (not needed for types such as cloudwatch)"},
+				"access":           {Type: "string", Description: "Access mode: proxy or direct", Enum: []string{"proxy", "direct"}},
+				"is_default":       {Type: "boolean", Description: "Set as default datasource"},
+				"json_data":        {Type: "object", Description: "Additional JSON configuration"},
+				"secure_json_data": {Type: "object", Description: "Secret settings such as password or token, stored encrypted (string values)"},
+				"user":             {Type: "string", Description: "Database user, for SQL datasources"},
+				"database":         {Type: "string", Description: "Database name; prefer json_data.database for SQL datasources"},
+				"basic_auth":       {Type: "boolean", Description: "Enable basic auth, with basic_auth_user and secure_json_data.basicAuthPassword"},
+				"basic_auth_user":  {Type: "string", Description: "Basic auth user"},
 			},
-			Required: []string{"name", "type", "url"},
+			Required: []string{"name", "type"},
 		},
 		Annotations: &mcp.ToolAnnotations{
 			DestructiveHint: false,
@@ -430,7 +960,7 @@ func (r *Registry) grafanaUpdateDatasourceTool() mcp.Tool {
 				"name":       {Type: "string", Description: "New datasource name"},
 				"url":        {Type: "string", Description: "New datasource URL"},
 				"is_default": {Type: "boolean", Description: "Set as default datasource"},
-				"json_data":  {Type: "object", Description: "Additional JSON configuration"},
+				"json_data":  {Type: "object", Description: "JSON configuration to change, merged into the current one; null removes a setting"},
 			},
 			Required: []string{"uid"},
 		},
@@ -464,8 +994,10 @@ func (r *Registry) grafanaListFoldersTool() mcp.Tool {
 		Name:        "grafana_list_folders",
 		Description: "List all folders",
 		InputSchema: mcp.InputSchema{
-			Type:       "object",
-			Properties: map[string]mcp.Property{},
+			Type: "object",
+			Properties: deltaProperties(map[string]mcp.Property{
+				"refresh": {Type: "boolean", Description: "Fetch live instead of answering from the prefetched inventory or cached responses"},
+			}),
 		},
 		Annotations: &mcp.ToolAnnotations{
 			ReadOnlyHint:  true,
@@ -518,9 +1050,10 @@ func (r *Registry) grafanaUpdateFolderTool() mcp.Tool {
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
-				"uid":     {Type: "string", Description: "Folder UID to update"},
-				"title":   {Type: "string", Description: "New folder title"},
-				"version": {Type: "integer", Description: "Current folder version for optimistic locking"},
+				"uid":               {Type: "string", Description: "Folder UID to update"},
+				"title":             {Type: "string", Description: "New folder title"},
+				"version":           {Type: "integer", Description: "Current folder version for optimistic locking"},
+				"retry_on_conflict": {Type: "integer", Description: "If the folder changes before the save, refetch its version and retry up to this many times (default 0, max 5)"},
 			},
 			Required: []string{"uid", "title", "version"},
 		},
@@ -555,7 +1088,7 @@ func (r *Registry) grafanaListAlertRulesTool() mcp.Tool {
 		Description: "List all alert rules",
 		InputSchema: mcp.InputSchema{
 			Type:       "object",
-			Properties: map[string]mcp.Property{},
+			Properties: deltaProperties(map[string]mcp.Property{}),
 		},
 		Annotations: &mcp.ToolAnnotations{
 			ReadOnlyHint:  true,
@@ -741,7 +1274,7 @@ func (r *Registry) grafanaDeleteAnnotationTool() mcp.Tool {
 func (r *Registry) grafanaQueryTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "grafana_query",
-		Description: "Execute a query against a datasource",
+		Description: "Execute a query against a datasource. With compare, the same query also runs over earlier windows (e.g. a day or a week before) and each series comes back with percentage deltas against them",
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
@@ -752,6 +1285,12 @@ func (r *Registry) grafanaQueryTool() mcp.Tool {
 				"to":              {Type: "string", Description: "End time (e.g., now)"},
 				"max_data_points": {Type: "integer", Description: "Maximum number of data points"},
 				"interval_ms":     {Type: "integer", Description: "Query interval in milliseconds"},
+				"top_k":           {Type: "integer", Description: "Return only the K most significant series; PromQL is wrapped in topk()/bottomk() and results are filtered to K"},
+				"top_k_by":        {Type: "string", Description: "How series are ranked for top_k (default max)", Enum: []string{"max", "avg", "last"}},
+				"bottom":          {Type: "boolean", Description: "With top_k, keep the lowest-ranked series instead (bottomk)"},
+				"no_cache":        {Type: "boolean", Description: "Bypass the short-lived result cache and run the query again"},
+				"compare":         {Type: "array", Description: "Offsets of earlier windows to compare with, e.g. [\"1d\", \"1w\"]; each window's results come back with per-series mean, max, and last deltas in percent"},
+				"quantiles":       {Type: "array", Description: "For a Prometheus histogram (le bucket series or native histograms), return these quantiles over time instead of the raw buckets, e.g. [0.5, 0.9, 0.99]"},
 			},
 			Required: []string{"datasource_uid", "datasource_type", "query"},
 		},
@@ -780,10 +1319,16 @@ func (r *Registry) grafanaGetOrgTool() mcp.Tool {
 func (r *Registry) grafanaListOrgUsersTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "grafana_list_org_users",
-		Description: "List users in the current organization",
+		Description: "List users in the current organization with their role and last activity, one page at a time with the total count, or every page with all=true",
 		InputSchema: mcp.InputSchema{
-			Type:       "object",
-			Properties: map[string]mcp.Property{},
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"query":       {Type: "string", Description: "Only list users whose login, email, or name matches"},
+				"page":        {Type: "integer", Description: "Page number, from 1"},
+				"per_page":    {Type: "integer", Description: "Users per page (Grafana's default 1000)"},
+				"all":         {Type: "boolean", Description: "Fetch every page, up to max_results"},
+				"max_results": {Type: "integer", Description: "With all, the most users to return (default 5000, max 50000)"},
+			},
 		},
 		Annotations: &mcp.ToolAnnotations{
 			ReadOnlyHint:  true,
@@ -810,13 +1355,15 @@ func (r *Registry) grafanaGetCurrentUserTool() mcp.Tool {
 func (r *Registry) grafanaListTeamsTool() mcp.Tool {
 	return mcp.Tool{
 		Name:        "grafana_list_teams",
-		Description: "List teams in the organization",
+		Description: "List teams in the organization, one page at a time with the total count, or every page with all=true",
 		InputSchema: mcp.InputSchema{
 			Type: "object",
 			Properties: map[string]mcp.Property{
-				"query":    {Type: "string", Description: "Search query"},
-				"page":     {Type: "integer", Description: "Page number"},
-				"per_page": {Type: "integer", Description: "Results per page"},
+				"query":       {Type: "string", Description: "Search query"},
+				"page":        {Type: "integer", Description: "Page number, from 1"},
+				"per_page":    {Type: "integer", Description: "Results per page (Grafana's default 1000)"},
+				"all":         {Type: "boolean", Description: "Fetch every page, up to max_results"},
+				"max_results": {Type: "integer", Description: "With all, the most teams to return (default 5000, max 50000)"},
 			},
 		},
 		Annotations: &mcp.ToolAnnotations{
@@ -884,9 +1431,9 @@ func (r *Registry) grafanaDeleteTeamTool() mcp.Tool {
 // ============== Handler Implementations ==============
 
 func (r *Registry) handleHealth(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	health, err := r.client.GetHealth()
+	health, err := r.client.GetHealth(r.ctx)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get health: %v", err)), nil
+		return apiErrorResult("Failed to get health", err), nil
 	}
 	return jsonResult(health)
 }
@@ -900,9 +1447,23 @@ func (r *Registry) handleSearchDashboards(args map[string]interface{}) (*mcp.Cal
 		limit = 50
 	}
 
-	results, err := r.client.SearchDashboards(query, tags, nil, dashType, limit)
+	delta := deltaRequested(args)
+	filter := fmt.Sprintf("%q|%q|%q|%d", query, tags, dashType, limit)
+	if !getBool(args, "refresh") {
+		if results, st, ok := r.searchPrefetched(query, tags, dashType, limit); ok {
+			if delta {
+				return deltaList(r, "grafana_search_dashboards", filter, args, results, searchResultKey, map[string]interface{}{"inventory": st})
+			}
+			return jsonResult(map[string]interface{}{"results": results, "inventory": st})
+		}
+	}
+
+	results, err := r.client.SearchDashboards(r.readCtx(args), query, tags, nil, dashType, limit)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Search failed: %v", err)), nil
+		return apiErrorResult("Search failed", err), nil
+	}
+	if delta {
+		return deltaList(r, "grafana_search_dashboards", filter, args, results, searchResultKey, nil)
 	}
 	return jsonResult(results)
 }
@@ -913,9 +1474,9 @@ func (r *Registry) handleGetDashboard(args map[string]interface{}) (*mcp.CallToo
 		return errorResult("uid is required"), nil
 	}
 
-	dashboard, err := r.client.GetDashboard(uid)
+	dashboard, err := r.client.GetDashboard(r.ctx, uid)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get dashboard: %v", err)), nil
+		return apiErrorResult("Failed to get dashboard", err), nil
 	}
 	return jsonResult(dashboard)
 }
@@ -960,9 +1521,9 @@ func (r *Registry) handleCreateDashboard(args map[string]interface{}) (*mcp.Call
 		Message:   "Created via MCP",
 	}
 
-	result, err := r.client.SaveDashboard(req)
+	result, err := r.client.SaveDashboard(r.ctx, req)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to create dashboard: %v", err)), nil
+		return apiErrorResult("Failed to create dashboard", err), nil
 	}
 	return jsonResult(result)
 }
@@ -973,30 +1534,34 @@ func (r *Registry) handleUpdateDashboard(args map[string]interface{}) (*mcp.Call
 		return errorResult("uid is required"), nil
 	}
 
-	// Get existing dashboard
-	existing, err := r.client.GetDashboard(uid)
-	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get dashboard: %v", err)), nil
-	}
+	var result *grafana.SaveDashboardResponse
+	_, err := retryOnConflict(r.ctx, conflictRetries(args), func() error {
+		// Get existing dashboard
+		existing, err := r.client.GetDashboard(r.ctx, uid)
+		if err != nil {
+			return fmt.Errorf("failed to get dashboard: %w", err)
+		}
 
-	// Update fields
-	if title := getString(args, "title"); title != "" {
-		existing.Title = title
-	}
-	if tags := getStringSlice(args, "tags"); len(tags) > 0 {
-		existing.Tags = tags
-	}
+		// Update fields
+		if title := getString(args, "title"); title != "" {
+			existing.Title = title
+		}
+		if tags := getStringSlice(args, "tags"); len(tags) > 0 {
+			existing.Tags = tags
+		}
 
-	req := grafana.SaveDashboardRequest{
-		Dashboard: *existing,
-		FolderUID: getString(args, "folder_uid"),
-		Message:   getString(args, "message"),
-		Overwrite: getBool(args, "overwrite"),
-	}
+		req := grafana.SaveDashboardRequest{
+			Dashboard: *existing,
+			FolderUID: getString(args, "folder_uid"),
+			Message:   getString(args, "message"),
+			Overwrite: getBool(args, "overwrite"),
+		}
 
-	result, err := r.client.SaveDashboard(req)
+		result, err = r.client.SaveDashboard(r.ctx, req)
+		return err
+	})
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to update dashboard: %v", err)), nil
+		return apiErrorResult("Failed to update dashboard", err), nil
 	}
 	return jsonResult(result)
 }
@@ -1007,16 +1572,28 @@ func (r *Registry) handleDeleteDashboard(args map[string]interface{}) (*mcp.Call
 		return errorResult("uid is required"), nil
 	}
 
-	if err := r.client.DeleteDashboard(uid); err != nil {
-		return errorResult(fmt.Sprintf("Failed to delete dashboard: %v", err)), nil
+	if err := r.client.DeleteDashboard(r.ctx, uid); err != nil {
+		return apiErrorResult("Failed to delete dashboard", err), nil
 	}
 	return jsonResult(map[string]string{"status": "deleted", "uid": uid})
 }
 
 func (r *Registry) handleListDatasources(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	datasources, err := r.client.GetDatasources()
+	delta := deltaRequested(args)
+	if !getBool(args, "refresh") {
+		if datasources, st, ok := r.prefetchedDatasources(); ok {
+			if delta {
+				return deltaList(r, "grafana_list_datasources", "", args, datasources, func(v grafana.Datasource) string { return v.UID }, map[string]interface{}{"inventory": st})
+			}
+			return jsonResult(map[string]interface{}{"datasources": datasources, "inventory": st})
+		}
+	}
+	datasources, err := r.client.GetDatasources(r.readCtx(args))
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to list datasources: %v", err)), nil
+		return apiErrorResult("Failed to list datasources", err), nil
+	}
+	if delta {
+		return deltaList(r, "grafana_list_datasources", "", args, datasources, func(v grafana.Datasource) string { return v.UID }, nil)
 	}
 	return jsonResult(datasources)
 }
@@ -1027,9 +1604,9 @@ func (r *Registry) handleGetDatasource(args map[string]interface{}) (*mcp.CallTo
 		return errorResult("uid is required"), nil
 	}
 
-	ds, err := r.client.GetDatasource(uid)
+	ds, err := r.client.GetDatasource(r.ctx, uid)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get datasource: %v", err)), nil
+		return apiErrorResult("Failed to get datasource", err), nil
 	}
 	return jsonResult(ds)
 }
@@ -1039,16 +1616,23 @@ func (r *Registry) handleCreateDatasource(args map[string]interface{}) (*mcp.Cal
 	dsType := getString(args, "type")
 	dsURL := getString(args, "url")
 
-	if name == "" || dsType == "" || dsURL == "" {
-		return errorResult("name, type, and url are required"), nil
+	if name == "" || dsType == "" {
+		return errorResult("name and type are required"), nil
+	}
+	if err := r.checkURL(dsURL); err != nil {
+		return errorResultFor(err), nil
 	}
 
 	ds := grafana.Datasource{
-		Name:      name,
-		Type:      dsType,
-		URL:       dsURL,
-		Access:    getString(args, "access"),
-		IsDefault: getBool(args, "is_default"),
+		Name:          name,
+		Type:          dsType,
+		URL:           dsURL,
+		Access:        getString(args, "access"),
+		IsDefault:     getBool(args, "is_default"),
+		User:          getString(args, "user"),
+		Database:      getString(args, "database"),
+		BasicAuth:     getBool(args, "basic_auth"),
+		BasicAuthUser: getString(args, "basic_auth_user"),
 	}
 
 	if ds.Access == "" {
@@ -1056,12 +1640,24 @@ func (r *Registry) handleCreateDatasource(args map[string]interface{}) (*mcp.Cal
 	}
 
 	if jsonData, ok := args["json_data"].(map[string]interface{}); ok {
+		if err := r.checkSettingURLs(jsonData); err != nil {
+			return errorResultFor(fmt.Errorf("json_data.%w", err)), nil
+		}
 		ds.JSONData = jsonData
 	}
+	ds.SecureJSONData = getStringMap(args, "secure_json_data")
+	ds.JSONData = withRequiredDefaults(dsType, ds.JSONData)
 
-	result, err := r.client.CreateDatasource(ds)
+	if _, known := lookupDatasourceSchema(dsType); !known && dsURL == "" {
+		return errorResult("url is required"), nil
+	}
+	if missing := missingDatasourceSettings(dsType, dsURL, ds.JSONData, ds.Database); len(missing) > 0 {
+		return errorResult(describeMissingSettings(dsType, missing)), nil
+	}
+
+	result, err := r.client.CreateDatasource(r.ctx, ds)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to create datasource: %v", err)), nil
+		return apiErrorResult("Failed to create datasource", err), nil
 	}
 	return jsonResult(result)
 }
@@ -1072,24 +1668,42 @@ func (r *Registry) handleUpdateDatasource(args map[string]interface{}) (*mcp.Cal
 		return errorResult("uid is required"), nil
 	}
 
-	existing, err := r.client.GetDatasource(uid)
+	existing, err := r.client.GetDatasource(r.ctx, uid)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get datasource: %v", err)), nil
+		return apiErrorResult("Failed to get datasource", err), nil
 	}
 
 	if name := getString(args, "name"); name != "" {
 		existing.Name = name
 	}
 	if dsURL := getString(args, "url"); dsURL != "" {
+		if err := r.checkURL(dsURL); err != nil {
+			return errorResultFor(err), nil
+		}
 		existing.URL = dsURL
 	}
 	if _, ok := args["is_default"]; ok {
 		existing.IsDefault = getBool(args, "is_default")
 	}
+	if jsonData, ok := args["json_data"].(map[string]interface{}); ok {
+		if err := r.checkSettingURLs(jsonData); err != nil {
+			return errorResultFor(fmt.Errorf("json_data.%w", err)), nil
+		}
+		if existing.JSONData == nil {
+			existing.JSONData = map[string]interface{}{}
+		}
+		for k, v := range jsonData {
+			if v == nil {
+				delete(existing.JSONData, k)
+				continue
+			}
+			existing.JSONData[k] = v
+		}
+	}
 
-	result, err := r.client.UpdateDatasource(uid, *existing)
+	result, err := r.client.UpdateDatasource(r.ctx, uid, *existing)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to update datasource: %v", err)), nil
+		return apiErrorResult("Failed to update datasource", err), nil
 	}
 	return jsonResult(result)
 }
@@ -1100,16 +1714,28 @@ func (r *Registry) handleDeleteDatasource(args map[string]interface{}) (*mcp.Cal
 		return errorResult("uid is required"), nil
 	}
 
-	if err := r.client.DeleteDatasource(uid); err != nil {
-		return errorResult(fmt.Sprintf("Failed to delete datasource: %v", err)), nil
+	if err := r.client.DeleteDatasource(r.ctx, uid); err != nil {
+		return apiErrorResult("Failed to delete datasource", err), nil
 	}
 	return jsonResult(map[string]string{"status": "deleted", "uid": uid})
 }
 
 func (r *Registry) handleListFolders(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	folders, err := r.client.GetFolders()
+	delta := deltaRequested(args)
+	if !getBool(args, "refresh") {
+		if folders, st, ok := r.prefetchedFolders(); ok {
+			if delta {
+				return deltaList(r, "grafana_list_folders", "", args, folders, func(v grafana.Folder) string { return v.UID }, map[string]interface{}{"inventory": st})
+			}
+			return jsonResult(map[string]interface{}{"folders": folders, "inventory": st})
+		}
+	}
+	folders, err := r.client.GetFolders(r.readCtx(args))
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to list folders: %v", err)), nil
+		return apiErrorResult("Failed to list folders", err), nil
+	}
+	if delta {
+		return deltaList(r, "grafana_list_folders", "", args, folders, func(v grafana.Folder) string { return v.UID }, nil)
 	}
 	return jsonResult(folders)
 }
@@ -1120,9 +1746,9 @@ func (r *Registry) handleGetFolder(args map[string]interface{}) (*mcp.CallToolRe
 		return errorResult("uid is required"), nil
 	}
 
-	folder, err := r.client.GetFolder(uid)
+	folder, err := r.client.GetFolder(r.ctx, uid)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get folder: %v", err)), nil
+		return apiErrorResult("Failed to get folder", err), nil
 	}
 	return jsonResult(folder)
 }
@@ -1133,9 +1759,9 @@ func (r *Registry) handleCreateFolder(args map[string]interface{}) (*mcp.CallToo
 		return errorResult("title is required"), nil
 	}
 
-	folder, err := r.client.CreateFolder(title, getString(args, "uid"))
+	folder, err := r.client.CreateFolder(r.ctx, title, getString(args, "uid"))
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to create folder: %v", err)), nil
+		return apiErrorResult("Failed to create folder", err), nil
 	}
 	return jsonResult(folder)
 }
@@ -1149,9 +1775,21 @@ func (r *Registry) handleUpdateFolder(args map[string]interface{}) (*mcp.CallToo
 		return errorResult("uid, title, and version are required"), nil
 	}
 
-	folder, err := r.client.UpdateFolder(uid, title, version)
+	var folder *grafana.Folder
+	retries := conflictRetries(args)
+	_, err := retryOnConflict(r.ctx, retries, func() error {
+		var err error
+		folder, err = r.client.UpdateFolder(r.ctx, uid, title, version)
+		if retries > 0 && grafana.IsVersionConflict(err) {
+			// Pick up the current version for the next attempt
+			if current, getErr := r.client.GetFolder(r.ctx, uid); getErr == nil {
+				version = current.Version
+			}
+		}
+		return err
+	})
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to update folder: %v", err)), nil
+		return apiErrorResult("Failed to update folder", err), nil
 	}
 	return jsonResult(folder)
 }
@@ -1162,16 +1800,19 @@ func (r *Registry) handleDeleteFolder(args map[string]interface{}) (*mcp.CallToo
 		return errorResult("uid is required"), nil
 	}
 
-	if err := r.client.DeleteFolder(uid); err != nil {
-		return errorResult(fmt.Sprintf("Failed to delete folder: %v", err)), nil
+	if err := r.client.DeleteFolder(r.ctx, uid); err != nil {
+		return apiErrorResult("Failed to delete folder", err), nil
 	}
 	return jsonResult(map[string]string{"status": "deleted", "uid": uid})
 }
 
 func (r *Registry) handleListAlertRules(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	rules, err := r.client.GetAlertRules()
+	rules, err := r.client.GetAlertRules(r.ctx)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to list alert rules: %v", err)), nil
+		return apiErrorResult("Failed to list alert rules", err), nil
+	}
+	if deltaRequested(args) {
+		return deltaList(r, "grafana_list_alert_rules", "", args, rules, func(v grafana.AlertRule) string { return v.UID }, nil)
 	}
 	return jsonResult(rules)
 }
@@ -1182,9 +1823,9 @@ func (r *Registry) handleGetAlertRule(args map[string]interface{}) (*mcp.CallToo
 		return errorResult("uid is required"), nil
 	}
 
-	rule, err := r.client.GetAlertRule(uid)
+	rule, err := r.client.GetAlertRule(r.ctx, uid)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get alert rule: %v", err)), nil
+		return apiErrorResult("Failed to get alert rule", err), nil
 	}
 	return jsonResult(rule)
 }
@@ -1227,9 +1868,9 @@ func (r *Registry) handleCreateAlertRule(args map[string]interface{}) (*mcp.Call
 		}
 	}
 
-	result, err := r.client.CreateAlertRule(rule)
+	result, err := r.client.CreateAlertRule(r.ctx, rule)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to create alert rule: %v", err)), nil
+		return apiErrorResult("Failed to create alert rule", err), nil
 	}
 	return jsonResult(result)
 }
@@ -1240,9 +1881,9 @@ func (r *Registry) handleUpdateAlertRule(args map[string]interface{}) (*mcp.Call
 		return errorResult("uid is required"), nil
 	}
 
-	existing, err := r.client.GetAlertRule(uid)
+	existing, err := r.client.GetAlertRule(r.ctx, uid)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get alert rule: %v", err)), nil
+		return apiErrorResult("Failed to get alert rule", err), nil
 	}
 
 	if title := getString(args, "title"); title != "" {
@@ -1261,9 +1902,9 @@ func (r *Registry) handleUpdateAlertRule(args map[string]interface{}) (*mcp.Call
 		existing.IsPaused = getBool(args, "is_paused")
 	}
 
-	result, err := r.client.UpdateAlertRule(uid, *existing)
+	result, err := r.client.UpdateAlertRule(r.ctx, uid, *existing)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to update alert rule: %v", err)), nil
+		return apiErrorResult("Failed to update alert rule", err), nil
 	}
 	return jsonResult(result)
 }
@@ -1274,8 +1915,8 @@ func (r *Registry) handleDeleteAlertRule(args map[string]interface{}) (*mcp.Call
 		return errorResult("uid is required"), nil
 	}
 
-	if err := r.client.DeleteAlertRule(uid); err != nil {
-		return errorResult(fmt.Sprintf("Failed to delete alert rule: %v", err)), nil
+	if err := r.client.DeleteAlertRule(r.ctx, uid); err != nil {
+		return apiErrorResult("Failed to delete alert rule", err), nil
 	}
 	return jsonResult(map[string]string{"status": "deleted", "uid": uid})
 }
@@ -1288,11 +1929,11 @@ func (r *Registry) handleListAnnotations(args map[string]interface{}) (*mcp.Call
 	tags := getStringSlice(args, "tags")
 	limit := getInt(args, "limit")
 
-	annotations, err := r.client.GetAnnotations(from, to, dashboardUID, panelID, tags, limit)
+	annotations, err := r.client.GetAnnotations(r.ctx, from, to, dashboardUID, panelID, tags, limit)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to list annotations: %v", err)), nil
+		return apiErrorResult("Failed to list annotations", err), nil
 	}
-	return jsonResult(annotations)
+	return jsonResult(r.annotationOutputs(annotations))
 }
 
 func (r *Registry) handleCreateAnnotation(args map[string]interface{}) (*mcp.CallToolResult, error) {
@@ -1314,9 +1955,9 @@ func (r *Registry) handleCreateAnnotation(args map[string]interface{}) (*mcp.Cal
 		ann.Time = time.Now().UnixMilli()
 	}
 
-	result, err := r.client.CreateAnnotation(ann)
+	result, err := r.client.CreateAnnotation(r.ctx, ann)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to create annotation: %v", err)), nil
+		return apiErrorResult("Failed to create annotation", err), nil
 	}
 	return jsonResult(result)
 }
@@ -1334,8 +1975,8 @@ func (r *Registry) handleUpdateAnnotation(args map[string]interface{}) (*mcp.Cal
 		Tags:    getStringSlice(args, "tags"),
 	}
 
-	if err := r.client.UpdateAnnotation(id, ann); err != nil {
-		return errorResult(fmt.Sprintf("Failed to update annotation: %v", err)), nil
+	if err := r.client.UpdateAnnotation(r.ctx, id, ann); err != nil {
+		return apiErrorResult("Failed to update annotation", err), nil
 	}
 	return jsonResult(map[string]interface{}{"status": "updated", "id": id})
 }
@@ -1346,8 +1987,8 @@ func (r *Registry) handleDeleteAnnotation(args map[string]interface{}) (*mcp.Cal
 		return errorResult("id is required"), nil
 	}
 
-	if err := r.client.DeleteAnnotation(id); err != nil {
-		return errorResult(fmt.Sprintf("Failed to delete annotation: %v", err)), nil
+	if err := r.client.DeleteAnnotation(r.ctx, id); err != nil {
+		return apiErrorResult("Failed to delete annotation", err), nil
 	}
 	return jsonResult(map[string]interface{}{"status": "deleted", "id": id})
 }
@@ -1370,6 +2011,29 @@ func (r *Registry) handleQuery(args map[string]interface{}) (*mcp.CallToolResult
 		to = "now"
 	}
 
+	quantiles, err := getQuantiles(args, "quantiles")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if len(quantiles) > 0 && (getInt(args, "top_k") > 0 || len(getStringSlice(args, "compare")) > 0) {
+		return errorResult("quantiles cannot be combined with top_k or compare"), nil
+	}
+
+	// Compared windows are shifted copies of this one, so pin it to
+	// absolute times that every window's query shares
+	var offsets []time.Duration
+	var start, end time.Time
+	compare := getStringSlice(args, "compare")
+	if len(compare) > 0 {
+		if offsets, err = parseCompareOffsets(compare); err != nil {
+			return errorResultFor(err), nil
+		}
+		if start, end, err = parseTimeRange(from, to, from); err != nil {
+			return errorResultFor(err), nil
+		}
+		from, to = fmt.Sprintf("%d", start.UnixMilli()), fmt.Sprintf("%d", end.UnixMilli())
+	}
+
 	req := grafana.QueryRequest{
 		From: from,
 		To:   to,
@@ -1384,47 +2048,140 @@ func (r *Registry) handleQuery(args map[string]interface{}) (*mcp.CallToolResult
 		},
 	}
 
-	result, err := r.client.Query(req)
+	topK := topKOptions{K: getInt(args, "top_k"), By: getString(args, "top_k_by"), Bottom: getBool(args, "bottom")}
+	if topK.By == "" {
+		topK.By = "max"
+	}
+	wrapped := false
+	if topK.K > 0 && promQLTypes[dsType] {
+		req.Queries[0].Query, wrapped = topK.wrapTopK(query)
+	}
+
+	// Check access before the guardrails, whose estimates also reach the datasource
+	if err := r.checkQueryAccess(req.Queries[0].Datasource, query); err != nil {
+		return errorResultFor(err), nil
+	}
+	est := r.guardQuery(dsUID, dsType, query, from, to)
+	if est != nil && len(est.Violations) > 0 && r.guardrails.Refuse {
+		return guardrailRefusal(est), nil
+	}
+
+	useCache := !getBool(args, "no_cache")
+	result, err := r.runQuery(req, useCache)
+	if wrapped && (err != nil || result.Results["A"].Error != "") {
+		// Not every expression can be ranked (e.g. scalars); filter locally instead
+		req.Queries[0].Query, wrapped = query, false
+		result, err = r.runQuery(req, useCache)
+	}
 	if err != nil {
-		return errorResult(fmt.Sprintf("Query failed: %v", err)), nil
+		return apiErrorResult("Query failed", err), nil
 	}
-	return jsonResult(result)
+	warn := est != nil && len(est.Violations) > 0
+	if topK.K <= 0 && !warn && len(offsets) == 0 && len(quantiles) == 0 {
+		return jsonResult(result)
+	}
+
+	out := map[string]interface{}{"results": result.Results}
+	if len(quantiles) > 0 {
+		if hs, ok := summarizeHistograms(result.Results["A"], quantiles); ok {
+			delete(out, "results")
+			out["histograms"] = hs
+		} else {
+			out["histogram_note"] = "the result holds no histogram: expected series with an le label, e.g. from rate(x_bucket[5m]), or native histogram frames"
+		}
+	}
+	if topK.K > 0 {
+		totals := topK.filter(result)
+		out["top_k"] = map[string]interface{}{
+			"k":                    topK.K,
+			"by":                   topK.By,
+			"bottom":               topK.Bottom,
+			"server_side":          wrapped,
+			"series_before_filter": totals["A"],
+		}
+	}
+	if warn {
+		out["guardrails"] = est
+	}
+	if len(offsets) > 0 {
+		out["from"], out["to"] = r.formatMillis(start.UnixMilli()), r.formatMillis(end.UnixMilli())
+		out["compare"] = r.compareWindows(req, result, compare, offsets, topK, useCache, start, end)
+	}
+	return jsonResult(out)
 }
 
 func (r *Registry) handleGetOrg(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	org, err := r.client.GetCurrentOrg()
+	org, err := r.client.GetCurrentOrg(r.ctx)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get organization: %v", err)), nil
+		return apiErrorResult("Failed to get organization", err), nil
 	}
 	return jsonResult(org)
 }
 
 func (r *Registry) handleListOrgUsers(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	users, err := r.client.GetOrgUsers()
+	paging, err := listPagingFromArgs(args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	query := getString(args, "query")
+
+	if paging.all {
+		users, total, err := fetchPages(listPageSize, paging.max, func(page, perPage int) ([]grafana.User, int64, error) {
+			res, err := r.client.SearchOrgUsers(r.ctx, query, page, perPage)
+			if err != nil {
+				return nil, 0, err
+			}
+			return res.OrgUsers, res.TotalCount, nil
+		})
+		if err != nil {
+			return apiErrorResult("Failed to list org users", err), nil
+		}
+		return jsonResult(pagedResult("users", users, len(users), total, paging))
+	}
+
+	res, err := r.client.SearchOrgUsers(r.ctx, query, paging.page, paging.perPage)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to list org users: %v", err)), nil
+		return apiErrorResult("Failed to list org users", err), nil
 	}
-	return jsonResult(users)
+	paging.page, paging.perPage = res.Page, res.PerPage
+	return jsonResult(pagedResult("users", res.OrgUsers, len(res.OrgUsers), res.TotalCount, paging))
 }
 
 func (r *Registry) handleGetCurrentUser(args map[string]interface{}) (*mcp.CallToolResult, error) {
-	user, err := r.client.GetCurrentUser()
+	user, err := r.client.GetCurrentUser(r.ctx)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get current user: %v", err)), nil
+		return apiErrorResult("Failed to get current user", err), nil
 	}
 	return jsonResult(user)
 }
 
 func (r *Registry) handleListTeams(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	paging, err := listPagingFromArgs(args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
 	query := getString(args, "query")
-	page := getInt(args, "page")
-	perPage := getInt(args, "per_page")
 
-	teams, err := r.client.GetTeams(query, page, perPage)
+	if paging.all {
+		teams, total, err := fetchPages(listPageSize, paging.max, func(page, perPage int) ([]grafana.Team, int64, error) {
+			res, err := r.client.SearchTeams(r.ctx, query, page, perPage)
+			if err != nil {
+				return nil, 0, err
+			}
+			return res.Teams, res.TotalCount, nil
+		})
+		if err != nil {
+			return apiErrorResult("Failed to list teams", err), nil
+		}
+		return jsonResult(pagedResult("teams", teams, len(teams), total, paging))
+	}
+
+	res, err := r.client.SearchTeams(r.ctx, query, paging.page, paging.perPage)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to list teams: %v", err)), nil
+		return apiErrorResult("Failed to list teams", err), nil
 	}
-	return jsonResult(teams)
+	paging.page, paging.perPage = res.Page, res.PerPage
+	return jsonResult(pagedResult("teams", res.Teams, len(res.Teams), res.TotalCount, paging))
 }
 
 func (r *Registry) handleGetTeam(args map[string]interface{}) (*mcp.CallToolResult, error) {
@@ -1433,9 +2190,9 @@ func (r *Registry) handleGetTeam(args map[string]interface{}) (*mcp.CallToolResu
 		return errorResult("id is required"), nil
 	}
 
-	team, err := r.client.GetTeam(id)
+	team, err := r.client.GetTeam(r.ctx, id)
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to get team: %v", err)), nil
+		return apiErrorResult("Failed to get team", err), nil
 	}
 	return jsonResult(team)
 }
@@ -1446,9 +2203,9 @@ func (r *Registry) handleCreateTeam(args map[string]interface{}) (*mcp.CallToolR
 		return errorResult("name is required"), nil
 	}
 
-	team, err := r.client.CreateTeam(name, getString(args, "email"))
+	team, err := r.client.CreateTeam(r.ctx, name, getString(args, "email"))
 	if err != nil {
-		return errorResult(fmt.Sprintf("Failed to create team: %v", err)), nil
+		return apiErrorResult("Failed to create team", err), nil
 	}
 	return jsonResult(team)
 }
@@ -1459,8 +2216,8 @@ func (r *Registry) handleDeleteTeam(args map[string]interface{}) (*mcp.CallToolR
 		return errorResult("id is required"), nil
 	}
 
-	if err := r.client.DeleteTeam(id); err != nil {
-		return errorResult(fmt.Sprintf("Failed to delete team: %v", err)), nil
+	if err := r.client.DeleteTeam(r.ctx, id); err != nil {
+		return apiErrorResult("Failed to delete team", err), nil
 	}
 	return jsonResult(map[string]interface{}{"status": "deleted", "id": id})
 }
@@ -0,0 +1,401 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/cache"
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+)
+
+const (
+	defaultRenderConcurrency = 2
+	maxRenderConcurrency     = 8
+)
+
+// RenderSettings tunes the render cache and the load placed on the image renderer
+type RenderSettings struct {
+	// CacheTTL is how long a rendered image is reused; 0 disables the cache
+	CacheTTL time.Duration
+	// CacheSize is the maximum number of cached images
+	CacheSize int
+	// MaxConcurrent caps renders in flight across all calls
+	MaxConcurrent int
+}
+
+// DefaultRenderSettings returns the settings used when none are configured
+func DefaultRenderSettings() RenderSettings {
+	return RenderSettings{CacheTTL: 5 * time.Minute, CacheSize: 64, MaxConcurrent: 4}
+}
+
+// WithRenderSettings overrides the render cache and concurrency defaults.
+// Zero CacheSize or MaxConcurrent keep their defaults.
+func WithRenderSettings(s RenderSettings) Option {
+	return func(r *Registry) {
+		r.renderer = newPanelRenderer(s)
+	}
+}
+
+// WithStateStore persists state that should survive a restart, such as
+// rendered images, in st
+func WithStateStore(st *state.Store) Option {
+	return func(r *Registry) {
+		r.state = st
+	}
+}
+
+// panelRenderer caches rendered images and bounds renderer load across calls
+type panelRenderer struct {
+	cache *cache.Cache[[]byte]
+	ttl   time.Duration
+	// size is the most images cached, in memory and in the state store
+	size int
+	sem  chan struct{}
+}
+
+func newPanelRenderer(s RenderSettings) *panelRenderer {
+	def := DefaultRenderSettings()
+	if s.CacheSize <= 0 {
+		s.CacheSize = def.CacheSize
+	}
+	if s.MaxConcurrent <= 0 {
+		s.MaxConcurrent = def.MaxConcurrent
+	}
+	pr := &panelRenderer{ttl: s.CacheTTL, size: s.CacheSize, sem: make(chan struct{}, s.MaxConcurrent)}
+	if s.CacheTTL > 0 {
+		pr.cache = cache.New[[]byte](s.CacheSize, s.CacheTTL)
+	}
+	return pr
+}
+
+// renderCacheKey identifies a render by everything that affects the image,
+// including the organization the dashboard belongs to
+func renderCacheKey(orgID int64, o grafana.RenderOptions) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d|%s|%d|%s|%s|%dx%d|%s|%s", orgID, o.DashboardUID, o.PanelID, o.From, o.To, o.Width, o.Height, o.Theme, o.Timezone)
+	names := make([]string, 0, len(o.Vars))
+	for name := range o.Vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "|%s=%s", name, o.Vars[name])
+	}
+	return b.String()
+}
+
+func (r *Registry) grafanaRenderPanelTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_render_panel",
+		Description: "Render one or more dashboard panels to PNG using the Grafana image renderer. Renders a single panel, a list of panels, or every panel of a dashboard. Recent renders are cached; without an image renderer, returns panel and Explore links instead",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"dashboard_uid": {Type: "string", Description: "Dashboard UID"},
+				"panel_id":      {Type: "integer", Description: "Single panel ID to render"},
+				"panel_ids":     {Type: "array", Description: "List of panel IDs to render"},
+				"all_panels":    {Type: "boolean", Description: "Render every panel of the dashboard as a separate image"},
+				"width":         {Type: "integer", Description: "Image width in pixels (default 1000)"},
+				"height":        {Type: "integer", Description: "Image height in pixels (default 500)"},
+				"theme":         {Type: "string", Description: "Render theme", Enum: []string{"light", "dark"}},
+				"timezone":      {Type: "string", Description: "Timezone for the rendered time axis (e.g., UTC, Europe/Berlin)"},
+				"from":          {Type: "string", Description: "Time range from (default now-6h)"},
+				"to":            {Type: "string", Description: "Time range to (default now)"},
+				"vars":          {Type: "object", Description: "Template variable values, e.g. {\"env\": \"prod\"}"},
+				"no_cache":      {Type: "boolean", Description: "Render fresh images instead of reusing cached ones"},
+				"concurrency":   {Type: "integer", Description: "Maximum parallel render requests (default 2, max 8)"},
+				"output":        {Type: "string", Description: "Return images inline or write them to disk", Enum: []string{"image", "file"}},
+				"output_dir":    {Type: "string", Description: "Directory for rendered files when output is file, relative to the export directory, or to grafana-mcp-renders in the system temp dir when none is configured"},
+			},
+			Required: []string{"dashboard_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// renderedPanel is the per-panel outcome of a render call
+type renderedPanel struct {
+	PanelID    int64  `json:"panel_id"`
+	Title      string `json:"title,omitempty"`
+	File       string `json:"file,omitempty"`
+	Bytes      int    `json:"bytes,omitempty"`
+	Cached     bool   `json:"cached,omitempty"`
+	PanelURL   string `json:"panel_url,omitempty"`
+	ExploreURL string `json:"explore_url,omitempty"`
+	Error      string `json:"error,omitempty"`
+
+	data            []byte
+	rendererMissing bool
+}
+
+func (r *Registry) handleRenderPanel(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "dashboard_uid")
+	if uid == "" {
+		return errorResult("dashboard_uid is required"), nil
+	}
+
+	output := getString(args, "output")
+	if output == "" {
+		output = "image"
+	}
+	if output != "image" && output != "file" {
+		return errorResult("output must be image or file"), nil
+	}
+	// Rendered files stay within the export directory, or a directory of
+	// their own in the temp dir when none is configured
+	var dir string
+	if output == "file" {
+		root := r.exportDir
+		if root == "" {
+			root = filepath.Join(os.TempDir(), "grafana-mcp-renders")
+		}
+		var err error
+		if dir, err = confinePath(root, getString(args, "output_dir")); err != nil {
+			return errorResultFor(err), nil
+		}
+	}
+
+	dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		return apiErrorResult("Failed to get dashboard", err), nil
+	}
+
+	var targets []renderedPanel
+	switch {
+	case getBool(args, "all_panels"):
+		for _, p := range dashboard.Panels(dash.Dashboard) {
+			if dashboard.IsRow(p) {
+				continue
+			}
+			targets = append(targets, renderedPanel{PanelID: dashboard.PanelID(p), Title: dashboard.String(p, "title")})
+		}
+	case len(getInt64Slice(args, "panel_ids")) > 0:
+		for _, id := range getInt64Slice(args, "panel_ids") {
+			targets = append(targets, panelTarget(dash.Dashboard, id))
+		}
+	case getInt64(args, "panel_id") > 0:
+		targets = append(targets, panelTarget(dash.Dashboard, getInt64(args, "panel_id")))
+	default:
+		return errorResult("one of panel_id, panel_ids, or all_panels is required"), nil
+	}
+	if len(targets) == 0 {
+		return errorResult("dashboard has no renderable panels"), nil
+	}
+
+	opts := grafana.RenderOptions{
+		DashboardUID: uid,
+		Width:        getInt(args, "width"),
+		Height:       getInt(args, "height"),
+		Theme:        getString(args, "theme"),
+		Timezone:     getString(args, "timezone"),
+		From:         getString(args, "from"),
+		To:           getString(args, "to"),
+		Vars:         getStringMap(args, "vars"),
+	}
+	if opts.Width == 0 {
+		opts.Width = 1000
+	}
+	if opts.Height == 0 {
+		opts.Height = 500
+	}
+	if opts.From == "" {
+		opts.From = "now-6h"
+	}
+	if opts.To == "" {
+		opts.To = "now"
+	}
+
+	concurrency := getInt(args, "concurrency")
+	if concurrency <= 0 {
+		concurrency = defaultRenderConcurrency
+	}
+	if concurrency > maxRenderConcurrency {
+		concurrency = maxRenderConcurrency
+	}
+
+	if available, known := r.features["rendererAvailable"]; known && !available {
+		return r.renderFallback(dash.Dashboard, opts, targets, "no image renderer is installed")
+	}
+	r.renderPanels(opts, targets, concurrency, getBool(args, "no_cache"))
+	for _, t := range targets {
+		if t.rendererMissing {
+			return r.renderFallback(dash.Dashboard, opts, targets, "the image renderer is unavailable")
+		}
+	}
+
+	if output == "file" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return apiErrorResult("Failed to create output directory", err), nil
+		}
+		stamp := time.Now().Format("20060102-150405")
+		for i := range targets {
+			if targets[i].data == nil {
+				continue
+			}
+			name := fmt.Sprintf("%s-panel-%d-%s.png", uid, targets[i].PanelID, stamp)
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, targets[i].data, 0o644); err != nil {
+				targets[i].Error = fmt.Sprintf("failed to write file: %v", err)
+				continue
+			}
+			targets[i].File = path
+		}
+	}
+
+	return renderResult(targets, output == "image")
+}
+
+// renderPanels renders every target, running at most concurrency requests
+// at once for this call and at most the registry-wide limit overall. Cached
+// images, in memory or in the state store, are reused unless noCache is set.
+func (r *Registry) renderPanels(opts grafana.RenderOptions, targets []renderedPanel, concurrency int, noCache bool) {
+	pr := r.renderer
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i := range targets {
+		if targets[i].Error != "" {
+			continue
+		}
+		wg.Add(1)
+		go func(t *renderedPanel) {
+			defer wg.Done()
+			o := opts
+			o.PanelID = t.PanelID
+			key := renderCacheKey(r.orgID(), o)
+			if pr.cache != nil && !noCache {
+				if data, ok := pr.cache.Get(key); ok {
+					t.data, t.Bytes, t.Cached = data, len(data), true
+					return
+				}
+				if data, ok, _ := r.state.GetBlob(state.BucketRender, key); ok {
+					pr.cache.Set(key, data)
+					t.data, t.Bytes, t.Cached = data, len(data), true
+					return
+				}
+			}
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			pr.sem <- struct{}{}
+			defer func() { <-pr.sem }()
+
+			data, err := r.client.RenderPanel(r.ctx, o)
+			if err != nil {
+				t.Error = err.Error()
+				t.rendererMissing = isRendererMissing(err)
+				return
+			}
+			t.data = data
+			t.Bytes = len(data)
+			if pr.cache != nil {
+				pr.cache.Set(key, data)
+				// Best effort: the in-memory cache already holds the image
+				_ = r.state.PutBlob(state.BucketRender, key, data, pr.ttl)
+			}
+		}(&targets[i])
+	}
+	wg.Wait()
+}
+
+// isRendererMissing reports whether a render failed because no image
+// renderer plugin or service is configured
+func isRendererMissing(err error) bool {
+	var apiErr *grafana.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	body := strings.ToLower(apiErr.Body)
+	return strings.Contains(body, "no image renderer") || strings.Contains(body, "renderer not available") ||
+		strings.Contains(body, "image renderer plugin") || strings.Contains(body, "rendering plugin")
+}
+
+// renderFallback returns links to view each panel when images cannot be
+// rendered: the panel in view mode and, where the panel has a concrete
+// datasource, its queries in Explore.
+func (r *Registry) renderFallback(dash map[string]interface{}, opts grafana.RenderOptions, targets []renderedPanel, reason string) (*mcp.CallToolResult, error) {
+	legacy := !r.supports("10.0.0")
+	links := make([]renderedPanel, 0, len(targets))
+	for _, t := range targets {
+		out := renderedPanel{PanelID: t.PanelID, Title: t.Title}
+		p := dashboard.FindPanel(dash, t.PanelID)
+		if p == nil {
+			out.Error = t.Error
+			links = append(links, out)
+			continue
+		}
+		out.PanelURL = r.client.PanelURL(opts.DashboardUID, t.PanelID, opts.From, opts.To, opts.Vars)
+		if ds, ok := p["datasource"].(map[string]interface{}); ok {
+			uid, _ := ds["uid"].(string)
+			typ, _ := ds["type"].(string)
+			queries, _ := p["targets"].([]interface{})
+			if uid != "" && typ != "" && !strings.HasPrefix(uid, "$") && len(queries) > 0 {
+				pane := grafana.ExplorePane{DatasourceUID: uid, DatasourceType: typ, From: opts.From, To: opts.To}
+				for _, q := range queries {
+					if m, ok := q.(map[string]interface{}); ok {
+						pane.Queries = append(pane.Queries, m)
+					}
+				}
+				if link, err := r.client.ExploreURL([]grafana.ExplorePane{pane}, legacy); err == nil {
+					out.ExploreURL = link
+				}
+			}
+		}
+		links = append(links, out)
+	}
+	return jsonResult(map[string]interface{}{
+		"rendered": false,
+		"reason":   reason + "; open the links below to view the panels",
+		"panels":   links,
+	})
+}
+
+func panelTarget(dash map[string]interface{}, id int64) renderedPanel {
+	p := dashboard.FindPanel(dash, id)
+	if p == nil {
+		return renderedPanel{PanelID: id, Error: "panel not found in dashboard"}
+	}
+	return renderedPanel{PanelID: id, Title: dashboard.String(p, "title")}
+}
+
+// renderResult builds a summary text block followed by one image block per
+// successful render when inline images are requested.
+func renderResult(targets []renderedPanel, inline bool) (*mcp.CallToolResult, error) {
+	summary, err := json.MarshalIndent(targets, "", "  ")
+	if err != nil {
+		return apiErrorResult("Failed to marshal result", err), nil
+	}
+
+	result := &mcp.CallToolResult{
+		Content: []mcp.ContentBlock{{Type: "text", Text: string(summary)}},
+	}
+	failed := 0
+	for _, t := range targets {
+		if t.data == nil {
+			failed++
+			continue
+		}
+		if inline {
+			result.Content = append(result.Content, mcp.ContentBlock{
+				Type:     "image",
+				Data:     base64.StdEncoding.EncodeToString(t.data),
+				MimeType: "image/png",
+			})
+		}
+	}
+	result.IsError = failed == len(targets)
+	return result, nil
+}
@@ -0,0 +1,17 @@
+package tools
+
+import (
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+func TestRenderCacheKeyIncludesOrg(t *testing.T) {
+	o := grafana.RenderOptions{DashboardUID: "abc", PanelID: 2, From: "now-1h", To: "now", Width: 1000, Height: 500}
+	if renderCacheKey(1, o) == renderCacheKey(2, o) {
+		t.Fatal("renders of the same dashboard UID in two organizations share a cache key")
+	}
+	if renderCacheKey(1, o) != renderCacheKey(1, o) {
+		t.Fatal("render cache key is not stable")
+	}
+}
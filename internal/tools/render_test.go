@@ -0,0 +1,47 @@
+package tools_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+func TestRenderFilesStayInExportDir(t *testing.T) {
+	root := t.TempDir()
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithExportDir(root)))
+	h.AddDashboard(map[string]interface{}{
+		"uid":    "checkout",
+		"title":  "Checkout",
+		"panels": []interface{}{map[string]interface{}{"id": 2, "type": "timeseries", "title": "Latency"}},
+	}, "")
+	args := func(dir string) map[string]interface{} {
+		return map[string]interface{}{"dashboard_uid": "checkout", "panel_id": 2, "output": "file", "output_dir": dir}
+	}
+
+	var panels []struct {
+		File  string `json:"file"`
+		Error string `json:"error"`
+	}
+	h.Call("grafana_render_panel", args("renders")).OK().JSON(&panels)
+	if len(panels) != 1 || panels[0].File == "" {
+		t.Fatalf("no file rendered: %+v", panels)
+	}
+	if dir := filepath.Dir(panels[0].File); dir != filepath.Join(resolved(t, root), "renders") {
+		t.Fatalf("rendered into %s, want %s/renders", dir, root)
+	}
+
+	h.Call("grafana_render_panel", args("../outside")).Error("outside")
+	h.Call("grafana_render_panel", args("/etc")).Error("outside")
+}
+
+// resolved follows the symlinks of dir, e.g. of a temp dir on macOS
+func resolved(t *testing.T, dir string) string {
+	t.Helper()
+	real, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return real
+}
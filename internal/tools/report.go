@@ -0,0 +1,363 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const reportSeriesPerPanel = 5
+
+func (r *Registry) grafanaGenerateReportTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_generate_report",
+		Description: "Build a Markdown or HTML report of a dashboard: renders the selected panels, adds per-series summaries (last, min, max, mean) from the panel queries, writes the report to disk, and optionally publishes it as a Grafana snapshot",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"dashboard_uid":    {Type: "string", Description: "UID of the dashboard to report on"},
+				"panel_ids":        {Type: "array", Description: "Panels to include (default: every panel)"},
+				"title":            {Type: "string", Description: "Report title (default: dashboard title)"},
+				"from":             {Type: "string", Description: "Time range from (default now-24h)"},
+				"to":               {Type: "string", Description: "Time range to (default now)"},
+				"vars":             {Type: "object", Description: "Template variable values, e.g. {\"env\": \"prod\"}"},
+				"format":           {Type: "string", Description: "Report format (default markdown); HTML embeds images", Enum: []string{"markdown", "html"}},
+				"output_dir":       {Type: "string", Description: "Directory for the report (default: a new directory under the system temp dir)"},
+				"images":           {Type: "boolean", Description: "Render panel images (default true)"},
+				"snapshot":         {Type: "boolean", Description: "Also publish the report text as a Grafana snapshot"},
+				"snapshot_expires": {Type: "integer", Description: "Snapshot lifetime in seconds (default: never expires)"},
+			},
+			Required: []string{"dashboard_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// reportPanel is one section of a report
+type reportPanel struct {
+	ID       int64
+	Title    string
+	URL      string
+	Image    string
+	ImageURI template.URL
+	Note     string
+	Series   []reportSeries
+}
+
+// reportSeries summarizes one series of a panel over the report range
+type reportSeries struct {
+	Name string
+	analysis.Stats
+}
+
+// report is the data the Markdown and HTML templates render
+type report struct {
+	Title        string
+	Dashboard    string
+	DashboardURL string
+	From, To     string
+	Generated    string
+	Panels       []reportPanel
+}
+
+func (r *Registry) handleGenerateReport(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "dashboard_uid")
+	if uid == "" {
+		return errorResult("dashboard_uid is required"), nil
+	}
+	format := getString(args, "format")
+	if format == "" {
+		format = "markdown"
+	}
+	if format != "markdown" && format != "html" {
+		return errorResult("format must be markdown or html"), nil
+	}
+	withImages := true
+	if _, ok := args["images"]; ok {
+		withImages = getBool(args, "images")
+	}
+	from, to := getString(args, "from"), getString(args, "to")
+	if from == "" {
+		from = "now-24h"
+	}
+	if to == "" {
+		to = "now"
+	}
+	start, end, err := parseTimeRange(from, to, "now-24h")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		return apiErrorResult("Failed to get dashboard", err), nil
+	}
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to list datasources", err), nil
+	}
+
+	var panels []map[string]interface{}
+	if ids := getInt64Slice(args, "panel_ids"); len(ids) > 0 {
+		for _, id := range ids {
+			p := dashboard.FindPanel(dash.Dashboard, id)
+			if p == nil {
+				return errorResult(fmt.Sprintf("panel %d not found", id)), nil
+			}
+			panels = append(panels, p)
+		}
+	} else {
+		for _, p := range dashboard.Panels(dash.Dashboard) {
+			if !dashboard.IsRow(p) {
+				panels = append(panels, p)
+			}
+		}
+	}
+	if len(panels) == 0 {
+		return errorResult("dashboard has no panels to report on"), nil
+	}
+
+	dir := getString(args, "output_dir")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "grafana-mcp-reports", fmt.Sprintf("%s-%s", uid, time.Now().Format("20060102-150405")))
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return apiErrorResult("Failed to create output directory", err), nil
+	}
+
+	userVars := getStringMap(args, "vars")
+	vars := dashboard.CurrentValues(dash.Dashboard)
+	for name, v := range userVars {
+		vars[name] = []string{v}
+	}
+
+	rep := report{
+		Title:        getString(args, "title"),
+		Dashboard:    dashboard.String(dash.Dashboard, "title"),
+		DashboardURL: r.client.BaseURL() + dash.Meta.URL,
+		From:         start.UTC().Format(time.RFC3339),
+		To:           end.UTC().Format(time.RFC3339),
+		Generated:    time.Now().UTC().Format(time.RFC3339),
+		Panels:       make([]reportPanel, len(panels)),
+	}
+	if rep.Title == "" {
+		rep.Title = rep.Dashboard + " report"
+	}
+	targets := make([]renderedPanel, len(panels))
+	for i, p := range panels {
+		id := dashboard.PanelID(p)
+		rep.Panels[i] = reportPanel{ID: id, Title: dashboard.String(p, "title"), URL: r.client.PanelURL(uid, id, from, to, userVars)}
+		targets[i] = renderedPanel{PanelID: id, Title: rep.Panels[i].Title}
+	}
+
+	// Images and query summaries are independent, so fetch them side by side
+	var wg sync.WaitGroup
+	if withImages {
+		if available, known := r.features["rendererAvailable"]; known && !available {
+			for i := range targets {
+				targets[i].Error = "no image renderer is installed"
+			}
+		} else {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				r.renderPanels(grafana.RenderOptions{
+					DashboardUID: uid, Width: 1000, Height: 500, From: from, To: to, Vars: userVars,
+				}, targets, defaultRenderConcurrency, false)
+			}()
+		}
+	}
+	resolver := newDatasourceResolver(datasources, vars)
+	for i, p := range panels {
+		wg.Add(1)
+		go func(rp *reportPanel, p map[string]interface{}) {
+			defer wg.Done()
+			rp.Series, rp.Note = r.summarizePanel(p, vars, resolver, start, end)
+		}(&rep.Panels[i], p)
+	}
+	wg.Wait()
+
+	images := 0
+	for i, t := range targets {
+		rp := &rep.Panels[i]
+		if !withImages {
+			continue
+		}
+		if t.data == nil {
+			rp.Note = strings.TrimPrefix(rp.Note+"; image unavailable: "+t.Error, "; ")
+			continue
+		}
+		name := fmt.Sprintf("panel-%d.png", t.PanelID)
+		if format == "html" {
+			rp.ImageURI = template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(t.data))
+		} else if err := os.WriteFile(filepath.Join(dir, name), t.data, 0o644); err != nil {
+			rp.Note = strings.TrimPrefix(rp.Note+"; failed to write image: "+err.Error(), "; ")
+			continue
+		}
+		rp.Image = name
+		images++
+	}
+
+	var buf bytes.Buffer
+	file := filepath.Join(dir, "report.md")
+	if format == "html" {
+		file = filepath.Join(dir, "report.html")
+		err = htmlReport.Execute(&buf, rep)
+	} else {
+		writeMarkdownReport(&buf, rep, true)
+	}
+	if err != nil {
+		return apiErrorResult("Failed to build report", err), nil
+	}
+	if err := os.WriteFile(file, buf.Bytes(), 0o644); err != nil {
+		return apiErrorResult("Failed to write report", err), nil
+	}
+
+	result := map[string]interface{}{
+		"file":   file,
+		"format": format,
+		"panels": len(rep.Panels),
+		"images": images,
+	}
+	if getBool(args, "snapshot") {
+		var md bytes.Buffer
+		writeMarkdownReport(&md, rep, false)
+		snap, err := r.client.CreateSnapshot(r.ctx, grafana.SnapshotRequest{
+			Name:    rep.Title,
+			Expires: getInt64(args, "snapshot_expires"),
+			Dashboard: map[string]interface{}{
+				"title": rep.Title,
+				"time":  map[string]interface{}{"from": rep.From, "to": rep.To},
+				"panels": []interface{}{map[string]interface{}{
+					"id":      1,
+					"type":    "text",
+					"title":   "",
+					"gridPos": map[string]interface{}{"x": 0, "y": 0, "w": 24, "h": 40},
+					"options": map[string]interface{}{"mode": "markdown", "content": md.String()},
+				}},
+			},
+		})
+		if err != nil {
+			result["snapshot_error"] = fmt.Sprintf("Failed to create snapshot: %v", err)
+		} else {
+			result["snapshot_url"] = snap.URL
+			result["snapshot_delete_url"] = snap.DeleteURL
+		}
+	}
+	return jsonResult(result)
+}
+
+// summarizePanel runs a panel's queries over the report range and returns
+// statistics for its series, largest last value first, or a note explaining
+// why there are none
+func (r *Registry) summarizePanel(p map[string]interface{}, vars map[string][]string, resolver *datasourceResolver, start, end time.Time) ([]reportSeries, string) {
+	queries, err := panelQueries(p, vars, resolver, 500)
+	if err != nil {
+		return nil, err.Error()
+	}
+	if len(queries) == 0 {
+		return nil, ""
+	}
+	resp, err := r.query(grafana.QueryRequest{
+		From:    fmt.Sprintf("%d", start.UnixMilli()),
+		To:      fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: queries,
+	})
+	if err != nil {
+		return nil, fmt.Sprintf("query failed: %v", err)
+	}
+	var frames []grafana.DataFrame
+	for _, q := range queries {
+		frames = append(frames, resp.Results[q.RefID].Frames...)
+	}
+	var out []reportSeries
+	for _, s := range analysis.FramesToSeries(frames) {
+		out = append(out, reportSeries{Name: s.Name, Stats: analysis.Summarize(s.Values)})
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Last > out[j].Last })
+	note := ""
+	if len(out) > reportSeriesPerPanel {
+		note = fmt.Sprintf("showing %d of %d series", reportSeriesPerPanel, len(out))
+		out = out[:reportSeriesPerPanel]
+	}
+	return out, note
+}
+
+// writeMarkdownReport renders the report as Markdown. Images are linked as
+// files next to the report when withImages is set.
+func writeMarkdownReport(buf *bytes.Buffer, rep report, withImages bool) {
+	fmt.Fprintf(buf, "# %s\n\n", rep.Title)
+	fmt.Fprintf(buf, "Dashboard: [%s](%s)  \nRange: %s to %s  \nGenerated: %s\n", rep.Dashboard, rep.DashboardURL, rep.From, rep.To, rep.Generated)
+	for _, p := range rep.Panels {
+		fmt.Fprintf(buf, "\n## %s\n\n", markdownText(p.Title, fmt.Sprintf("Panel %d", p.ID)))
+		if withImages && p.Image != "" {
+			fmt.Fprintf(buf, "![%s](%s)\n\n", markdownText(p.Title, "panel"), p.Image)
+		}
+		fmt.Fprintf(buf, "[Open panel](%s)\n", p.URL)
+		if p.Note != "" {
+			fmt.Fprintf(buf, "\n_%s_\n", p.Note)
+		}
+		if len(p.Series) == 0 {
+			continue
+		}
+		buf.WriteString("\n| Series | Last | Min | Max | Mean |\n|---|---:|---:|---:|---:|\n")
+		for _, s := range p.Series {
+			fmt.Fprintf(buf, "| %s | %.4g | %.4g | %.4g | %.4g |\n", strings.ReplaceAll(s.Name, "|", "\\|"), s.Last, s.Min, s.Max, s.Mean)
+		}
+	}
+}
+
+func markdownText(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return strings.NewReplacer("[", "\\[", "]", "\\]").Replace(s)
+}
+
+var htmlReport = template.Must(template.New("report").Funcs(template.FuncMap{
+	"num": func(v float64) string { return fmt.Sprintf("%.4g", v) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 1040px; color: #222; }
+img { max-width: 100%; border: 1px solid #ddd; }
+table { border-collapse: collapse; margin: 1em 0; }
+th, td { border: 1px solid #ddd; padding: 4px 8px; }
+td.num { text-align: right; }
+.note { color: #666; font-style: italic; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Dashboard: <a href="{{.DashboardURL}}">{{.Dashboard}}</a><br>Range: {{.From}} to {{.To}}<br>Generated: {{.Generated}}</p>
+{{range .Panels}}
+<h2>{{if .Title}}{{.Title}}{{else}}Panel {{.ID}}{{end}}</h2>
+{{if .ImageURI}}<img src="{{.ImageURI}}" alt="{{.Title}}">{{end}}
+<p><a href="{{.URL}}">Open panel</a></p>
+{{if .Note}}<p class="note">{{.Note}}</p>{{end}}
+{{if .Series}}<table>
+<tr><th>Series</th><th>Last</th><th>Min</th><th>Max</th><th>Mean</th></tr>
+{{range .Series}}<tr><td>{{.Name}}</td><td class="num">{{num .Last}}</td><td class="num">{{num .Min}}</td><td class="num">{{num .Max}}</td><td class="num">{{num .Mean}}</td></tr>
+{{end}}</table>{{end}}
+{{end}}
+</body>
+</html>
+`))
@@ -0,0 +1,264 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// resourceListLimit caps the dashboards resources/list enumerates. Any
+// dashboard can still be read through its grafana://dashboards/{uid} URI.
+const resourceListLimit = 500
+
+// ErrResourceNotFound is returned by ReadResource for a URI the registry
+// does not serve
+var ErrResourceNotFound = errors.New("resource not found")
+
+// resourceDef is a resource exposed over resources/list and resources/read.
+// Each mirrors a tool and is only served while that tool is enabled, so a
+// profile that hides the tool hides the resource too.
+type resourceDef struct {
+	resource mcp.Resource
+	tool     string
+	read     func() (interface{}, error)
+}
+
+// allResources returns every resource definition, whether enabled or not
+func (r *Registry) allResources() []resourceDef {
+	return []resourceDef{
+		{
+			resource: mcp.Resource{
+				URI:         recentDashboardsURI,
+				Name:        "Starred and recent dashboards",
+				Description: "The authenticated user's starred and recently viewed dashboards, read fresh from Grafana on every read",
+				MimeType:    "application/json",
+			},
+			tool: "grafana_search_dashboards",
+			read: r.readRecentDashboards,
+		},
+		{
+			resource: mcp.Resource{
+				URI:         firingAlertsURI,
+				Name:        "Firing alerts",
+				Description: "Alerts firing now, longest firing first, without silenced or inhibited ones. Subscribe to be notified when alerts start or stop firing",
+				MimeType:    "application/json",
+			},
+			tool: "grafana_list_alert_rules",
+			read: r.readFiringAlerts,
+		},
+	}
+}
+
+// objectResource is a family of resources, one per Grafana object of a
+// kind, addressed as prefix + uid. Like resourceDef, it is served only
+// while its tool is enabled.
+type objectResource struct {
+	template mcp.ResourceTemplate
+	prefix   string
+	tool     string
+	list     func() ([]mcp.Resource, error)
+	read     func(uid string) (interface{}, error)
+}
+
+// allObjectResources returns every object resource family
+func (r *Registry) allObjectResources() []objectResource {
+	return []objectResource{
+		{
+			template: mcp.ResourceTemplate{
+				URITemplate: "grafana://dashboards/{uid}",
+				Name:        "Dashboard",
+				Description: "A dashboard's JSON model and metadata",
+				MimeType:    "application/json",
+			},
+			prefix: "grafana://dashboards/",
+			tool:   "grafana_get_dashboard",
+			list:   r.listDashboardResources,
+			read:   r.readDashboardResource,
+		},
+		{
+			template: mcp.ResourceTemplate{
+				URITemplate: "grafana://folders/{uid}",
+				Name:        "Folder",
+				Description: "A folder and the dashboards in it",
+				MimeType:    "application/json",
+			},
+			prefix: "grafana://folders/",
+			tool:   "grafana_get_folder",
+			list:   r.listFolderResources,
+			read:   r.readFolderResource,
+		},
+		{
+			template: mcp.ResourceTemplate{
+				URITemplate: "grafana://datasources/{uid}",
+				Name:        "Datasource",
+				Description: "A datasource's settings, without secrets",
+				MimeType:    "application/json",
+			},
+			prefix: "grafana://datasources/",
+			tool:   "grafana_get_datasource",
+			list:   r.listDatasourceResources,
+			read:   r.readDatasourceResource,
+		},
+	}
+}
+
+// GetResources returns the resources whose tools are enabled: the fixed
+// ones, then every folder, datasource, and dashboard up to
+// resourceListLimit dashboards. Listing is abandoned when ctx is done.
+func (r *Registry) GetResources(ctx context.Context) ([]mcp.Resource, error) {
+	r = r.forCall(ctx)
+	out := []mcp.Resource{}
+	for _, def := range r.allResources() {
+		if r.resourceEnabled(def) {
+			out = append(out, def.resource)
+		}
+	}
+	for _, obj := range r.allObjectResources() {
+		if !r.objectResourceEnabled(obj) {
+			continue
+		}
+		listed, err := obj.list()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, listed...)
+	}
+	return out, nil
+}
+
+// GetResourceTemplates returns the URI templates of the object resources
+// whose tools are enabled
+func (r *Registry) GetResourceTemplates() []mcp.ResourceTemplate {
+	out := []mcp.ResourceTemplate{}
+	for _, obj := range r.allObjectResources() {
+		if r.objectResourceEnabled(obj) {
+			out = append(out, obj.template)
+		}
+	}
+	return out
+}
+
+// ReadResource reads the resource at uri as JSON text, abandoning it when
+// ctx is done. It is safe to call from multiple goroutines.
+func (r *Registry) ReadResource(ctx context.Context, uri string) (*mcp.ReadResourceResult, error) {
+	r = r.forCall(ctx)
+	for _, def := range r.allResources() {
+		if def.resource.URI != uri || !r.resourceEnabled(def) {
+			continue
+		}
+		v, err := def.read()
+		if err != nil {
+			return nil, err
+		}
+		return resourceResult(uri, def.resource.MimeType, v)
+	}
+	for _, obj := range r.allObjectResources() {
+		uid, ok := strings.CutPrefix(uri, obj.prefix)
+		if !ok || uid == "" || strings.Contains(uid, "/") || !r.objectResourceEnabled(obj) {
+			continue
+		}
+		v, err := obj.read(uid)
+		if isNotFound(err) {
+			return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return resourceResult(uri, obj.template.MimeType, v)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrResourceNotFound, uri)
+}
+
+func resourceResult(uri, mimeType string, v interface{}) (*mcp.ReadResourceResult, error) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource: %w", err)
+	}
+	return &mcp.ReadResourceResult{
+		Contents: []mcp.ResourceContents{{URI: uri, MimeType: mimeType, Text: string(data)}},
+	}, nil
+}
+
+func (r *Registry) resourceEnabled(def resourceDef) bool {
+	return r.isEnabled(def.tool) && r.unsupportedReason(def.tool) == ""
+}
+
+func (r *Registry) objectResourceEnabled(obj objectResource) bool {
+	return r.isEnabled(obj.tool) && r.unsupportedReason(obj.tool) == ""
+}
+
+func (r *Registry) listDashboardResources() ([]mcp.Resource, error) {
+	hits, err := r.client.Search(r.ctx, grafana.SearchQuery{Type: "dash-db", Limit: resourceListLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dashboards: %w", err)
+	}
+	out := make([]mcp.Resource, 0, len(hits))
+	for _, h := range hits {
+		res := mcp.Resource{URI: "grafana://dashboards/" + h.UID, Name: h.Title, MimeType: "application/json"}
+		if h.FolderTitle != "" {
+			res.Description = "Dashboard in " + h.FolderTitle
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
+
+func (r *Registry) listFolderResources() ([]mcp.Resource, error) {
+	folders, err := r.client.GetFolders(r.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list folders: %w", err)
+	}
+	out := make([]mcp.Resource, 0, len(folders))
+	for _, f := range folders {
+		out = append(out, mcp.Resource{URI: "grafana://folders/" + f.UID, Name: f.Title, Description: "Folder", MimeType: "application/json"})
+	}
+	return out, nil
+}
+
+func (r *Registry) listDatasourceResources() ([]mcp.Resource, error) {
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list datasources: %w", err)
+	}
+	out := make([]mcp.Resource, 0, len(datasources))
+	for _, ds := range datasources {
+		out = append(out, mcp.Resource{URI: "grafana://datasources/" + ds.UID, Name: ds.Name, Description: ds.Type + " datasource", MimeType: "application/json"})
+	}
+	return out, nil
+}
+
+func (r *Registry) readDashboardResource(uid string) (interface{}, error) {
+	return r.client.GetDashboardJSON(r.ctx, uid)
+}
+
+// readFolderResource returns a folder with its dashboards, each as a
+// resource URI so a client can follow it
+func (r *Registry) readFolderResource(uid string) (interface{}, error) {
+	folder, err := r.client.GetFolder(r.ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	hits, err := r.client.Search(r.ctx, grafana.SearchQuery{Type: "dash-db", FolderUIDs: []string{uid}, Limit: resourceListLimit})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list dashboards in folder: %w", err)
+	}
+	dashboards := make([]map[string]string, 0, len(hits))
+	for _, h := range hits {
+		dashboards = append(dashboards, map[string]string{"uid": h.UID, "title": h.Title, "uri": "grafana://dashboards/" + h.UID})
+	}
+	return map[string]interface{}{"folder": folder, "dashboards": dashboards}, nil
+}
+
+func (r *Registry) readDatasourceResource(uid string) (interface{}, error) {
+	ds, err := r.client.GetDatasource(r.ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	ds.SecureJSONData = nil
+	return ds, nil
+}
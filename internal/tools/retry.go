@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+const (
+	maxConflictRetries = 5
+	conflictBackoff    = 200 * time.Millisecond
+)
+
+// conflictRetries reads the retry_on_conflict argument, clamped to maxConflictRetries
+func conflictRetries(args map[string]interface{}) int {
+	n := getInt(args, "retry_on_conflict")
+	if n < 0 {
+		return 0
+	}
+	if n > maxConflictRetries {
+		return maxConflictRetries
+	}
+	return n
+}
+
+// retryOnConflict runs attempt, which must refetch the resource and reapply
+// the intended change, retrying up to retries times while Grafana reports a
+// version conflict. It returns the number of attempts made, and stops
+// waiting for the next attempt when ctx is done.
+func retryOnConflict(ctx context.Context, retries int, attempt func() error) (int, error) {
+	for i := 0; ; i++ {
+		err := attempt()
+		if err == nil || i >= retries || !grafana.IsVersionConflict(err) {
+			return i + 1, err
+		}
+		select {
+		case <-ctx.Done():
+			return i + 1, ctx.Err()
+		case <-time.After(conflictBackoff * time.Duration(i+1)):
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+func TestRetryOnConflictStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	conflict := &grafana.APIError{StatusCode: 412, Body: `{"status":"version-mismatch"}`}
+	start := time.Now()
+	attempts, err := retryOnConflict(ctx, maxConflictRetries, func() error {
+		cancel()
+		return conflict
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if attempts != 1 {
+		t.Fatalf("%d attempts, want 1", attempts)
+	}
+	if elapsed := time.Since(start); elapsed >= conflictBackoff {
+		t.Fatalf("returned after %v, want before the first backoff", elapsed)
+	}
+}
+
+func TestRetryOnConflictRetriesConflicts(t *testing.T) {
+	conflict := &grafana.APIError{StatusCode: 412, Body: `{"status":"version-mismatch"}`}
+	calls := 0
+	attempts, err := retryOnConflict(context.Background(), 2, func() error {
+		calls++
+		if calls < 2 {
+			return conflict
+		}
+		return nil
+	})
+	if err != nil || attempts != 2 {
+		t.Fatalf("retryOnConflict = %d, %v; want 2 attempts and no error", attempts, err)
+	}
+}
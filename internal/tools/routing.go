@@ -0,0 +1,417 @@
+package tools
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// Alertmanager defaults applied at the root of the policy tree
+const (
+	defaultGroupWait      = "30s"
+	defaultGroupInterval  = "5m"
+	defaultRepeatInterval = "4h"
+)
+
+func (r *Registry) grafanaPreviewRoutingTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_preview_alert_routing",
+		Description: "Walk the notification policy tree for a set of alert labels and report which policies match, which contact points would be notified, the effective grouping and timing, and whether mute or active time intervals silence the alert at a given time. Nothing is sent",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"labels": {Type: "object", Description: "Alert labels, e.g. {\"team\": \"payments\", \"severity\": \"critical\"}. Grafana also adds alertname and grafana_folder to rule alerts"},
+				"at":     {Type: "string", Description: "Time to evaluate mute timings at (default now; accepts now-1h, RFC 3339, epoch ms)"},
+			},
+			Required: []string{"labels"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// routeOpts are the notification settings a policy inherits from its parent
+type routeOpts struct {
+	Receiver            string
+	GroupBy             []string
+	GroupWait           string
+	GroupInterval       string
+	RepeatInterval      string
+	MuteTimeIntervals   []string
+	ActiveTimeIntervals []string
+}
+
+// inherit applies the route's own settings on top of the parent's. As in
+// Alertmanager, time intervals are not inherited.
+func (o routeOpts) inherit(route *grafana.Route) routeOpts {
+	if route.Receiver != "" {
+		o.Receiver = route.Receiver
+	}
+	if route.GroupBy != nil {
+		o.GroupBy = route.GroupBy
+	}
+	if route.GroupWait != "" {
+		o.GroupWait = route.GroupWait
+	}
+	if route.GroupInterval != "" {
+		o.GroupInterval = route.GroupInterval
+	}
+	if route.RepeatInterval != "" {
+		o.RepeatInterval = route.RepeatInterval
+	}
+	o.MuteTimeIntervals = route.MuteTimeIntervals
+	o.ActiveTimeIntervals = route.ActiveTimeIntervals
+	return o
+}
+
+// matchedRoute is a leaf policy that an alert would be delivered by
+type matchedRoute struct {
+	Path []string
+	routeOpts
+}
+
+// routeMatchers collects the object matchers and legacy match/match_re/matchers fields of a policy
+func routeMatchers(route *grafana.Route) ([]labelMatcher, error) {
+	var out []labelMatcher
+	for _, om := range route.ObjectMatchers {
+		if len(om) != 3 {
+			return nil, fmt.Errorf("invalid object matcher %v", om)
+		}
+		m, err := newLabelMatcher(om[0], om[1], om[2])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	legacy, err := parseLabelMatchers(route.Matchers)
+	if err != nil {
+		return nil, err
+	}
+	out = append(out, legacy...)
+	for _, k := range sortedStringKeys(route.Match) {
+		m, _ := newLabelMatcher(k, "=", route.Match[k])
+		out = append(out, m)
+	}
+	for _, k := range sortedStringKeys(route.MatchRE) {
+		m, err := newLabelMatcher(k, "=~", route.MatchRE[k])
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, nil
+}
+
+// matchRoutes mirrors Alertmanager's Route.Match: children are tried in
+// order, stopping at the first match unless it sets continue, and the route
+// itself is returned when no child matches.
+func matchRoutes(route *grafana.Route, parent routeOpts, labels map[string]string, path []string) ([]matchedRoute, error) {
+	matchers, err := routeMatchers(route)
+	if err != nil {
+		return nil, err
+	}
+	if !matchAll(matchers, labels) {
+		return nil, nil
+	}
+
+	opts := parent.inherit(route)
+	var names []string
+	for _, m := range matchers {
+		names = append(names, m.String())
+	}
+	step := "root"
+	if len(path) > 0 {
+		step = strings.Join(names, ", ")
+		if step == "" {
+			step = "(match all)"
+		}
+	}
+	path = append(append([]string{}, path...), step)
+
+	var out []matchedRoute
+	for _, child := range route.Routes {
+		matched, err := matchRoutes(child, opts, labels, path)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, matched...)
+		if len(matched) > 0 && !child.Continue {
+			break
+		}
+	}
+	if len(out) == 0 {
+		out = append(out, matchedRoute{Path: path, routeOpts: opts})
+	}
+	return out, nil
+}
+
+func (r *Registry) handlePreviewRouting(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if _, ok := args["labels"].(map[string]interface{}); !ok {
+		return errorResult("labels is required"), nil
+	}
+	labels := getStringMap(args, "labels")
+	at, err := parseTime(getString(args, "at"), time.Now())
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	tree, err := r.client.GetNotificationPolicyTree(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get notification policies", err), nil
+	}
+	timings, err := r.client.GetMuteTimings(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get mute timings", err), nil
+	}
+	receivers, err := r.client.GetReceivers(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get contact points", err), nil
+	}
+
+	byName := make(map[string]grafana.MuteTiming, len(timings))
+	for _, t := range timings {
+		byName[t.Name] = t
+	}
+	integrations := make(map[string][]string, len(receivers))
+	for _, rc := range receivers {
+		for _, in := range rc.Integrations {
+			integrations[rc.Name] = append(integrations[rc.Name], in.Type)
+		}
+	}
+
+	root := routeOpts{GroupWait: defaultGroupWait, GroupInterval: defaultGroupInterval, RepeatInterval: defaultRepeatInterval}
+	matched, err := matchRoutes(tree, root, labels, nil)
+	if err != nil {
+		return apiErrorResult("Failed to evaluate notification policies", err), nil
+	}
+
+	var results []map[string]interface{}
+	var notified []string
+	for _, m := range matched {
+		entry := map[string]interface{}{
+			"policy_path":     m.Path,
+			"contact_point":   m.Receiver,
+			"integrations":    integrations[m.Receiver],
+			"group_by":        m.GroupBy,
+			"group_wait":      m.GroupWait,
+			"group_interval":  m.GroupInterval,
+			"repeat_interval": m.RepeatInterval,
+		}
+
+		var mutedBy, inactive, unknown []string
+		for _, name := range m.MuteTimeIntervals {
+			t, ok := byName[name]
+			if !ok {
+				unknown = append(unknown, name)
+				continue
+			}
+			in, err := muteTimingContains(t, at)
+			if err != nil {
+				return apiErrorResult(fmt.Sprintf("Failed to evaluate mute timing %q", name), err), nil
+			}
+			if in {
+				mutedBy = append(mutedBy, name)
+			}
+		}
+		if len(m.ActiveTimeIntervals) > 0 {
+			active := false
+			for _, name := range m.ActiveTimeIntervals {
+				t, ok := byName[name]
+				if !ok {
+					unknown = append(unknown, name)
+					continue
+				}
+				in, err := muteTimingContains(t, at)
+				if err != nil {
+					return apiErrorResult(fmt.Sprintf("Failed to evaluate mute timing %q", name), err), nil
+				}
+				active = active || in
+			}
+			if !active {
+				inactive = m.ActiveTimeIntervals
+			}
+		}
+		if len(m.MuteTimeIntervals) > 0 {
+			entry["mute_time_intervals"] = m.MuteTimeIntervals
+		}
+		if len(m.ActiveTimeIntervals) > 0 {
+			entry["active_time_intervals"] = m.ActiveTimeIntervals
+		}
+		if len(unknown) > 0 {
+			entry["unknown_time_intervals"] = unknown
+		}
+		muted := len(mutedBy) > 0 || len(inactive) > 0
+		entry["muted"] = muted
+		if len(mutedBy) > 0 {
+			entry["muted_by"] = mutedBy
+		}
+		if len(inactive) > 0 {
+			entry["outside_active_intervals"] = inactive
+		}
+		if !muted {
+			notified = append(notified, m.Receiver)
+		}
+		results = append(results, entry)
+	}
+
+	return jsonResult(map[string]interface{}{
+		"labels":         labels,
+		"evaluated_at":   at.UTC().Format(time.RFC3339),
+		"policies":       results,
+		"contact_points": notified,
+		"note":           "Silences and rules using simplified routing (contact point set on the rule) are not evaluated",
+	})
+}
+
+// muteTimingContains reports whether t falls within any of the timing's intervals
+func muteTimingContains(timing grafana.MuteTiming, t time.Time) (bool, error) {
+	for _, ti := range timing.TimeIntervals {
+		in, err := timeIntervalContains(ti, t)
+		if err != nil || in {
+			return in, err
+		}
+	}
+	return false, nil
+}
+
+var (
+	weekdayNames = map[string]int{"sunday": 0, "monday": 1, "tuesday": 2, "wednesday": 3, "thursday": 4, "friday": 5, "saturday": 6}
+	monthNames   = map[string]int{"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6, "july": 7, "august": 8, "september": 9, "october": 10, "november": 11, "december": 12}
+)
+
+// timeIntervalContains evaluates an Alertmanager time interval. Every
+// populated field must match; empty fields match any time.
+func timeIntervalContains(ti grafana.TimeInterval, t time.Time) (bool, error) {
+	if ti.Location != "" {
+		loc, err := time.LoadLocation(ti.Location)
+		if err != nil {
+			return false, err
+		}
+		t = t.In(loc)
+	} else {
+		t = t.UTC()
+	}
+
+	if len(ti.Times) > 0 {
+		minute := t.Hour()*60 + t.Minute()
+		in := false
+		for _, tr := range ti.Times {
+			start, err := clockMinutes(tr.StartTime)
+			if err != nil {
+				return false, err
+			}
+			end, err := clockMinutes(tr.EndTime)
+			if err != nil {
+				return false, err
+			}
+			if minute >= start && minute < end {
+				in = true
+				break
+			}
+		}
+		if !in {
+			return false, nil
+		}
+	}
+
+	checks := []struct {
+		ranges []string
+		value  int
+		parse  func(string) (int, error)
+	}{
+		{ti.Weekdays, int(t.Weekday()), namedNumber(weekdayNames)},
+		{ti.Months, int(t.Month()), namedNumber(monthNames)},
+		{ti.Years, t.Year(), strconv.Atoi},
+	}
+	for _, c := range checks {
+		if len(c.ranges) == 0 {
+			continue
+		}
+		in, err := inRanges(c.ranges, c.value, c.parse)
+		if err != nil || !in {
+			return false, err
+		}
+	}
+
+	if len(ti.DaysOfMonth) > 0 {
+		days := time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+		in, err := inRanges(ti.DaysOfMonth, t.Day(), func(s string) (int, error) {
+			d, err := strconv.Atoi(s)
+			if d < 0 {
+				d = days + d + 1
+			}
+			return d, err
+		})
+		if err != nil || !in {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// inRanges reports whether v is within any "n" or "start:end" range (inclusive)
+func inRanges(ranges []string, v int, parse func(string) (int, error)) (bool, error) {
+	for _, r := range ranges {
+		lo, hi := r, r
+		if i := strings.Index(r, ":"); i >= 0 {
+			lo, hi = r[:i], r[i+1:]
+		}
+		start, err := parse(strings.TrimSpace(lo))
+		if err != nil {
+			return false, fmt.Errorf("invalid range %q: %w", r, err)
+		}
+		end, err := parse(strings.TrimSpace(hi))
+		if err != nil {
+			return false, fmt.Errorf("invalid range %q: %w", r, err)
+		}
+		if start > end {
+			return false, fmt.Errorf("invalid range %q: start is after end", r)
+		}
+		if v >= start && v <= end {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func namedNumber(names map[string]int) func(string) (int, error) {
+	return func(s string) (int, error) {
+		if n, ok := names[strings.ToLower(s)]; ok {
+			return n, nil
+		}
+		return strconv.Atoi(s)
+	}
+}
+
+// clockMinutes parses HH:MM (up to 24:00) into minutes since midnight
+func clockMinutes(s string) (int, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	h, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	m, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid time of day %q", s)
+	}
+	return h*60 + m, nil
+}
+
+func sortedStringKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
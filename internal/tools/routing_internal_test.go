@@ -0,0 +1,196 @@
+package tools
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// testPolicyTree routes payments alerts to their team and, through a
+// continue route, to an audit log; critical payments alerts page
+func testPolicyTree() *grafana.Route {
+	return &grafana.Route{
+		Receiver: "default",
+		GroupBy:  []string{"grafana_folder", "alertname"},
+		Routes: []*grafana.Route{
+			{
+				Receiver:       "audit",
+				ObjectMatchers: [][]string{{"team", "=~", "pay.*"}},
+				Continue:       true,
+				GroupBy:        []string{},
+			},
+			{
+				Receiver:       "payments",
+				ObjectMatchers: [][]string{{"team", "=", "payments"}},
+				GroupBy:        []string{"alertname", "cluster"},
+				RepeatInterval: "1h",
+				Routes: []*grafana.Route{
+					{Receiver: "pager", Matchers: []string{"severity=critical"}, GroupWait: "0s", MuteTimeIntervals: []string{"weekends"}},
+					{Match: map[string]string{"severity": "info"}, MuteTimeIntervals: []string{"always"}},
+				},
+				MuteTimeIntervals: []string{"maintenance"},
+			},
+			{Receiver: "ops", MatchRE: map[string]string{"team": "ops|sre"}},
+			{Receiver: "never", ObjectMatchers: [][]string{{"team", "!=", ""}}},
+		},
+	}
+}
+
+func TestMatchRoutes(t *testing.T) {
+	root := routeOpts{GroupWait: defaultGroupWait, GroupInterval: defaultGroupInterval, RepeatInterval: defaultRepeatInterval}
+	rootGroupBy := []string{"grafana_folder", "alertname"}
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []matchedRoute
+	}{
+		{
+			name:   "no child matches: the root",
+			labels: map[string]string{"alertname": "Disk"},
+			want: []matchedRoute{{Path: []string{"root"}, routeOpts: routeOpts{
+				Receiver: "default", GroupBy: rootGroupBy, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+			}}},
+		},
+		{
+			name:   "continue route and the deepest match below the next sibling",
+			labels: map[string]string{"team": "payments", "severity": "critical"},
+			want: []matchedRoute{
+				{Path: []string{"root", `team=~"pay.*"`}, routeOpts: routeOpts{
+					Receiver: "audit", GroupBy: []string{}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+				}},
+				{Path: []string{"root", `team="payments"`, `severity="critical"`}, routeOpts: routeOpts{
+					Receiver: "pager", GroupBy: []string{"alertname", "cluster"}, GroupWait: "0s", GroupInterval: "5m", RepeatInterval: "1h",
+					MuteTimeIntervals: []string{"weekends"},
+				}},
+			},
+		},
+		{
+			name:   "child without a receiver inherits its parent's",
+			labels: map[string]string{"team": "payments", "severity": "info"},
+			want: []matchedRoute{
+				{Path: []string{"root", `team=~"pay.*"`}, routeOpts: routeOpts{
+					Receiver: "audit", GroupBy: []string{}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+				}},
+				{Path: []string{"root", `team="payments"`, `severity="info"`}, routeOpts: routeOpts{
+					Receiver: "payments", GroupBy: []string{"alertname", "cluster"}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "1h",
+					MuteTimeIntervals: []string{"always"},
+				}},
+			},
+		},
+		{
+			name:   "no grandchild matches: the parent, with its own time intervals",
+			labels: map[string]string{"team": "payments", "severity": "warning"},
+			want: []matchedRoute{
+				{Path: []string{"root", `team=~"pay.*"`}, routeOpts: routeOpts{
+					Receiver: "audit", GroupBy: []string{}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+				}},
+				{Path: []string{"root", `team="payments"`}, routeOpts: routeOpts{
+					Receiver: "payments", GroupBy: []string{"alertname", "cluster"}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "1h",
+					MuteTimeIntervals: []string{"maintenance"},
+				}},
+			},
+		},
+		{
+			name:   "continue route matches alone, so siblings are still tried",
+			labels: map[string]string{"team": "payroll"},
+			want: []matchedRoute{
+				{Path: []string{"root", `team=~"pay.*"`}, routeOpts: routeOpts{
+					Receiver: "audit", GroupBy: []string{}, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+				}},
+				{Path: []string{"root", `team!=""`}, routeOpts: routeOpts{
+					Receiver: "never", GroupBy: rootGroupBy, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+				}},
+			},
+		},
+		{
+			name:   "first matching sibling stops the walk",
+			labels: map[string]string{"team": "sre"},
+			want: []matchedRoute{{Path: []string{"root", `team=~"ops|sre"`}, routeOpts: routeOpts{
+				Receiver: "ops", GroupBy: rootGroupBy, GroupWait: "30s", GroupInterval: "5m", RepeatInterval: "4h",
+			}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := matchRoutes(testPolicyTree(), root, tt.labels, nil)
+			if err != nil {
+				t.Fatalf("matchRoutes: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("matched\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchRoutesRejectsInvalidMatchers(t *testing.T) {
+	tests := []struct {
+		name  string
+		route *grafana.Route
+		want  string
+	}{
+		{"short object matcher", &grafana.Route{ObjectMatchers: [][]string{{"team", "="}}}, "invalid object matcher"},
+		{"bad operator", &grafana.Route{ObjectMatchers: [][]string{{"team", "==", "x"}}}, "invalid matcher operator"},
+		{"bad legacy matcher", &grafana.Route{Matchers: []string{"team"}}, "invalid matcher"},
+		{"bad match_re", &grafana.Route{MatchRE: map[string]string{"team": "("}}, "invalid regex"},
+	}
+	for _, tt := range tests {
+		tree := &grafana.Route{Receiver: "default", Routes: []*grafana.Route{tt.route}}
+		if _, err := matchRoutes(tree, routeOpts{}, map[string]string{}, nil); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestTimeIntervalContains(t *testing.T) {
+	// A Saturday evening in UTC, Sunday morning in Tokyo
+	at := time.Date(2024, time.June, 29, 22, 30, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		ti   grafana.TimeInterval
+		want bool
+	}{
+		{"empty matches any time", grafana.TimeInterval{}, true},
+		{"inside times", grafana.TimeInterval{Times: []grafana.TimeOfDayRange{{StartTime: "22:00", EndTime: "24:00"}}}, true},
+		{"end is exclusive", grafana.TimeInterval{Times: []grafana.TimeOfDayRange{{StartTime: "09:00", EndTime: "22:30"}}}, false},
+		{"weekend by name", grafana.TimeInterval{Weekdays: []string{"sunday", "saturday"}}, true},
+		{"weekdays", grafana.TimeInterval{Weekdays: []string{"Monday:Friday"}}, false},
+		{"month by name and number", grafana.TimeInterval{Months: []string{"january", "6"}}, true},
+		{"last day of month", grafana.TimeInterval{DaysOfMonth: []string{"-2:-1"}}, true},
+		{"first days of month", grafana.TimeInterval{DaysOfMonth: []string{"1:7"}}, false},
+		{"years", grafana.TimeInterval{Years: []string{"2020:2023"}}, false},
+		{"all fields must match", grafana.TimeInterval{Weekdays: []string{"saturday"}, Years: []string{"2025"}}, false},
+		{"location", grafana.TimeInterval{Location: "Asia/Tokyo", Weekdays: []string{"sunday"}, Times: []grafana.TimeOfDayRange{{StartTime: "07:00", EndTime: "08:00"}}}, true},
+	}
+	for _, tt := range tests {
+		got, err := timeIntervalContains(tt.ti, at)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: contains = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTimeIntervalContainsRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		ti   grafana.TimeInterval
+	}{
+		{"bad location", grafana.TimeInterval{Location: "Mars/Olympus"}},
+		{"bad time", grafana.TimeInterval{Times: []grafana.TimeOfDayRange{{StartTime: "9am", EndTime: "17:00"}}}},
+		{"bad weekday", grafana.TimeInterval{Weekdays: []string{"funday"}}},
+		{"reversed range", grafana.TimeInterval{Years: []string{"2025:2020"}}},
+		{"weekend wrapping past saturday", grafana.TimeInterval{Weekdays: []string{"saturday:sunday"}}},
+	}
+	for _, tt := range tests {
+		if _, err := timeIntervalContains(tt.ti, time.Now()); err == nil {
+			t.Errorf("%s: evaluated, want an error", tt.name)
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/promql"
+)
+
+// WithMimir sets the Mimir or Cortex cluster the ruler tools use when no
+// datasource_uid is given
+func WithMimir(ruler *grafana.Ruler) Option {
+	return func(r *Registry) {
+		r.mimir = ruler
+	}
+}
+
+const rulerSourceDescription = "Prometheus or Loki datasource whose Mimir, Cortex, or Loki ruler holds the rules (default: the mimir cluster from the config)"
+
+func (r *Registry) grafanaMimirListRuleGroupsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_mimir_list_rule_groups",
+		Description: "List the rule groups stored in a Mimir, Cortex, or Loki ruler (rules managed outside Grafana alerting), by namespace, with rule counts; full includes the rules",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: rulerSourceDescription},
+				"namespace":      {Type: "string", Description: "Only this namespace"},
+				"full":           {Type: "boolean", Description: "Include each group's rules"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaMimirGetRuleGroupTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_mimir_get_rule_group",
+		Description: "Get one rule group from a Mimir, Cortex, or Loki ruler",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: rulerSourceDescription},
+				"namespace":      {Type: "string", Description: "Namespace of the group"},
+				"group":          {Type: "string", Description: "Rule group name"},
+			},
+			Required: []string{"namespace", "group"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaMimirSetRuleGroupTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_mimir_set_rule_group",
+		Description: "Create or replace a rule group in a Mimir, Cortex, or Loki ruler. The group is given in Prometheus rule file format, as an object or YAML; PromQL expressions are checked before anything is sent, and the result lists the rules added, changed, and removed",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: rulerSourceDescription},
+				"namespace":      {Type: "string", Description: "Namespace to store the group in"},
+				"group":          {Type: "object", Description: "Rule group: {name, interval, rules: [{alert|record, expr, for, labels, annotations}]}, or the same as a YAML string"},
+				"dry_run":        {Type: "boolean", Description: "Validate and compare with the stored group without saving"},
+			},
+			Required: []string{"namespace", "group"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) grafanaMimirDeleteRuleGroupTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_mimir_delete_rule_group",
+		Description: "Delete a rule group from a Mimir, Cortex, or Loki ruler",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: rulerSourceDescription},
+				"namespace":      {Type: "string", Description: "Namespace of the group"},
+				"group":          {Type: "string", Description: "Rule group name"},
+			},
+			Required: []string{"namespace", "group"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+const alertmanagerSourceDescription = "Alertmanager datasource backed by Mimir or Cortex (default: the mimir cluster from the config)"
+
+func (r *Registry) grafanaMimirGetAlertmanagerConfigTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_mimir_get_alertmanager_config",
+		Description: "Get the Alertmanager configuration (routes, receivers, inhibit rules) and notification templates of a Mimir or Cortex tenant",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: alertmanagerSourceDescription},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaMimirSetAlertmanagerConfigTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_mimir_set_alertmanager_config",
+		Description: "Replace the Alertmanager configuration of a Mimir or Cortex tenant. Checks that the route tree only names defined receivers before saving; read the current config first and edit it, as the whole configuration is replaced",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"datasource_uid": {Type: "string", Description: alertmanagerSourceDescription},
+				"config":         {Type: "object", Description: "Alertmanager configuration: {global, route, receivers, inhibit_rules, ...}, or the same as a YAML string"},
+				"template_files": {Type: "object", Description: "Notification templates by file name (default: keep the current templates)"},
+				"dry_run":        {Type: "boolean", Description: "Validate without saving"},
+			},
+			Required: []string{"config"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// rulerFor returns the ruler a call targets: Grafana's proxy for the
+// datasource_uid argument, which must be one of types, or the configured
+// Mimir cluster. It also returns the datasource type, "" for the cluster.
+func (r *Registry) rulerFor(args map[string]interface{}, types ...string) (*grafana.Ruler, string, error) {
+	dsUID := getString(args, "datasource_uid")
+	if dsUID == "" {
+		if r.mimir == nil {
+			return nil, "", fmt.Errorf("datasource_uid is required when mimir.url is not configured")
+		}
+		return r.mimir, "", nil
+	}
+	ds, err := r.client.GetDatasource(r.ctx, dsUID)
+	if err != nil {
+		return nil, "", fmt.Errorf("Failed to get datasource: %v", err)
+	}
+	if !contains(types, ds.Type) {
+		return nil, "", fmt.Errorf("datasource %s is a %s datasource; expected %s", ds.Name, ds.Type, strings.Join(types, " or "))
+	}
+	if err := r.checkQueryAccess(grafana.DatasourceRef{UID: ds.UID, Type: ds.Type}); err != nil {
+		return nil, "", err
+	}
+	return r.client.DatasourceRuler(dsUID), ds.Type, nil
+}
+
+// rulerGroupSummary is a rule group in the list result
+type rulerGroupSummary struct {
+	Name      string              `json:"name"`
+	Interval  string              `json:"interval,omitempty"`
+	Rules     int                 `json:"rules"`
+	Alerting  int                 `json:"alerting"`
+	Recording int                 `json:"recording"`
+	Detail    []grafana.RulerRule `json:"rule_list,omitempty"`
+}
+
+func (r *Registry) handleMimirListRuleGroups(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	ruler, _, err := r.rulerFor(args, "prometheus", "loki")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	byNamespace, err := ruler.ListRuleGroups(r.ctx, getString(args, "namespace"))
+	if err != nil {
+		return apiErrorResult("Failed to list rule groups", err), nil
+	}
+
+	full := getBool(args, "full")
+	namespaces := make([]string, 0, len(byNamespace))
+	for ns := range byNamespace {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	out := make([]map[string]interface{}, 0, len(namespaces))
+	totalGroups, totalRules := 0, 0
+	for _, ns := range namespaces {
+		groups := make([]rulerGroupSummary, 0, len(byNamespace[ns]))
+		for _, g := range byNamespace[ns] {
+			s := rulerGroupSummary{Name: g.Name, Interval: g.Interval, Rules: len(g.Rules)}
+			for _, rule := range g.Rules {
+				if rule.Alert != "" {
+					s.Alerting++
+				} else {
+					s.Recording++
+				}
+			}
+			if full {
+				s.Detail = g.Rules
+			}
+			groups = append(groups, s)
+			totalRules += len(g.Rules)
+		}
+		totalGroups += len(groups)
+		out = append(out, map[string]interface{}{"namespace": ns, "groups": groups})
+	}
+	return jsonResult(map[string]interface{}{
+		"namespaces": out,
+		"groups":     totalGroups,
+		"rules":      totalRules,
+	})
+}
+
+func (r *Registry) handleMimirGetRuleGroup(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	namespace, name := getString(args, "namespace"), getString(args, "group")
+	if namespace == "" || name == "" {
+		return errorResult("namespace and group are required"), nil
+	}
+	ruler, _, err := r.rulerFor(args, "prometheus", "loki")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	group, err := ruler.GetRuleGroup(r.ctx, namespace, name)
+	if err != nil {
+		return apiErrorResult("Failed to get rule group", err), nil
+	}
+	return jsonResult(map[string]interface{}{"namespace": namespace, "group": group})
+}
+
+func (r *Registry) handleMimirSetRuleGroup(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	namespace := getString(args, "namespace")
+	if namespace == "" {
+		return errorResult("namespace is required"), nil
+	}
+	var group grafana.RulerRuleGroup
+	if err := decodeYAMLArg(args, "group", &group); err != nil {
+		return errorResultFor(err), nil
+	}
+	ruler, dsType, err := r.rulerFor(args, "prometheus", "loki")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if problems := checkRuleGroup(group, dsType != "loki"); len(problems) > 0 {
+		return errorResult("invalid rule group:\n- " + strings.Join(problems, "\n- ")), nil
+	}
+
+	status := "created"
+	var added, changed, removed []string
+	current, err := ruler.GetRuleGroup(r.ctx, namespace, group.Name)
+	switch {
+	case err == nil:
+		status = "updated"
+		added, changed, removed = compareRuleGroups(current.Rules, group.Rules)
+	case !isNotFound(err):
+		return apiErrorResult("Failed to read the stored rule group", err), nil
+	}
+	if status == "created" {
+		for _, rule := range group.Rules {
+			added = append(added, ruleName(rule))
+		}
+	}
+	if status == "updated" && len(added)+len(changed)+len(removed) == 0 && current.Interval == group.Interval {
+		status = "unchanged"
+	}
+
+	dryRun := getBool(args, "dry_run")
+	if !dryRun && status != "unchanged" {
+		if err := ruler.SetRuleGroup(r.ctx, namespace, group); err != nil {
+			return apiErrorResult("Failed to save rule group", err), nil
+		}
+	}
+	out := map[string]interface{}{
+		"namespace": namespace,
+		"group":     group.Name,
+		"status":    status,
+		"added":     nonNil(added),
+		"changed":   nonNil(changed),
+		"removed":   nonNil(removed),
+	}
+	if dryRun {
+		out["dry_run"] = true
+	}
+	return jsonResult(out)
+}
+
+func (r *Registry) handleMimirDeleteRuleGroup(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	namespace, name := getString(args, "namespace"), getString(args, "group")
+	if namespace == "" || name == "" {
+		return errorResult("namespace and group are required"), nil
+	}
+	ruler, _, err := r.rulerFor(args, "prometheus", "loki")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if err := ruler.DeleteRuleGroup(r.ctx, namespace, name); err != nil {
+		return apiErrorResult("Failed to delete rule group", err), nil
+	}
+	return jsonResult(map[string]string{"status": "deleted", "namespace": namespace, "group": name})
+}
+
+func (r *Registry) handleMimirGetAlertmanagerConfig(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	ruler, _, err := r.rulerFor(args, "alertmanager")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	cfg, err := ruler.GetAlertmanagerConfig(r.ctx)
+	if err != nil {
+		return apiErrorResult("Failed to get Alertmanager config", err), nil
+	}
+	return jsonResult(cfg)
+}
+
+func (r *Registry) handleMimirSetAlertmanagerConfig(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	var config map[string]interface{}
+	if err := decodeYAMLArg(args, "config", &config); err != nil {
+		return errorResultFor(err), nil
+	}
+	if problems := checkAlertmanagerConfig(config); len(problems) > 0 {
+		return errorResult("invalid Alertmanager config:\n- " + strings.Join(problems, "\n- ")), nil
+	}
+	if err := r.checkSettingURLs(config); err != nil {
+		return errorResultFor(fmt.Errorf("config.%w", err)), nil
+	}
+	ruler, _, err := r.rulerFor(args, "alertmanager")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	cfg := grafana.RulerAlertmanagerConfig{AlertmanagerConfig: config}
+	if templates, ok := args["template_files"].(map[string]interface{}); ok {
+		cfg.TemplateFiles = map[string]string{}
+		for name, v := range templates {
+			if s, ok := v.(string); ok {
+				cfg.TemplateFiles[name] = s
+			}
+		}
+	} else {
+		current, err := ruler.GetAlertmanagerConfig(r.ctx)
+		if err != nil && !isNotFound(err) {
+			return apiErrorResult("Failed to read the current templates", err), nil
+		}
+		if current != nil {
+			cfg.TemplateFiles = current.TemplateFiles
+		}
+	}
+
+	if getBool(args, "dry_run") {
+		return jsonResult(map[string]interface{}{"dry_run": true, "valid": true, "template_files": len(cfg.TemplateFiles)})
+	}
+	if err := ruler.SetAlertmanagerConfig(r.ctx, cfg); err != nil {
+		return apiErrorResult("Failed to save Alertmanager config", err), nil
+	}
+	return jsonResult(map[string]interface{}{"status": "replaced", "template_files": len(cfg.TemplateFiles)})
+}
+
+// decodeYAMLArg decodes an argument given as an object or as a YAML (or
+// JSON) string into v
+func decodeYAMLArg(args map[string]interface{}, key string, v interface{}) error {
+	var data []byte
+	switch a := args[key].(type) {
+	case nil:
+		return fmt.Errorf("%s is required", key)
+	case string:
+		data = []byte(a)
+	default:
+		var err error
+		if data, err = json.Marshal(a); err != nil {
+			return fmt.Errorf("%s: %v", key, err)
+		}
+	}
+	if err := yaml.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s is not valid YAML: %v", key, err)
+	}
+	return nil
+}
+
+// checkRuleGroup returns what is wrong with a rule group, checking the
+// expressions as PromQL when promQL is set
+func checkRuleGroup(g grafana.RulerRuleGroup, promQL bool) []string {
+	var problems []string
+	if g.Name == "" {
+		problems = append(problems, "group name is required")
+	}
+	if len(g.Rules) == 0 {
+		problems = append(problems, "group has no rules")
+	}
+	seen := map[string]bool{}
+	for i, rule := range g.Rules {
+		at := fmt.Sprintf("rules[%d]", i)
+		switch {
+		case rule.Alert != "" && rule.Record != "":
+			problems = append(problems, at+": set alert or record, not both")
+		case rule.Alert == "" && rule.Record == "":
+			problems = append(problems, at+": alert or record is required")
+		case rule.Record != "" && (rule.For != "" || len(rule.Annotations) > 0):
+			problems = append(problems, at+": recording rules take no for or annotations")
+		}
+		if rule.Alert != "" {
+			if key := ruleName(rule); seen[key] {
+				problems = append(problems, fmt.Sprintf("%s: duplicate alert %q with the same labels", at, rule.Alert))
+			} else {
+				seen[key] = true
+			}
+		}
+		if strings.TrimSpace(rule.Expr) == "" {
+			problems = append(problems, at+": expr is required")
+			continue
+		}
+		if promQL {
+			if _, err := promql.Parse(rule.Expr); err != nil {
+				problems = append(problems, fmt.Sprintf("%s (%s): %v", at, ruleName(rule), err))
+			}
+		}
+	}
+	return problems
+}
+
+// compareRuleGroups lists the rules added, changed, and removed between
+// the stored and the new rules, by name
+func compareRuleGroups(before, after []grafana.RulerRule) (added, changed, removed []string) {
+	old := map[string]grafana.RulerRule{}
+	for _, rule := range before {
+		old[ruleName(rule)] = rule
+	}
+	for _, rule := range after {
+		name := ruleName(rule)
+		prev, ok := old[name]
+		if !ok {
+			added = append(added, name)
+			continue
+		}
+		delete(old, name)
+		a, _ := json.Marshal(prev)
+		b, _ := json.Marshal(rule)
+		if string(a) != string(b) {
+			changed = append(changed, name)
+		}
+	}
+	for name := range old {
+		removed = append(removed, name)
+	}
+	sort.Strings(removed)
+	return added, changed, removed
+}
+
+// ruleName identifies a rule by its alert or record name, with its labels
+// when it has any, since one alert name may be reused with other labels
+func ruleName(rule grafana.RulerRule) string {
+	name := rule.Alert
+	if name == "" {
+		name = rule.Record
+	}
+	if len(rule.Labels) > 0 {
+		name += labelSet(rule.Labels)
+	}
+	return name
+}
+
+// checkAlertmanagerConfig returns what is wrong with an Alertmanager
+// configuration: a missing route or receivers, and route receivers that
+// are not defined
+func checkAlertmanagerConfig(cfg map[string]interface{}) []string {
+	var problems []string
+	defined := map[string]bool{}
+	receivers, _ := cfg["receivers"].([]interface{})
+	for _, rv := range receivers {
+		if m, ok := rv.(map[string]interface{}); ok {
+			if name, _ := m["name"].(string); name != "" {
+				defined[name] = true
+			}
+		}
+	}
+	if len(defined) == 0 {
+		problems = append(problems, "receivers must define at least one named receiver")
+	}
+	route, ok := cfg["route"].(map[string]interface{})
+	if !ok {
+		return append(problems, "route is required")
+	}
+	if name, _ := route["receiver"].(string); name == "" {
+		problems = append(problems, "route.receiver is required")
+	}
+	var walk func(route map[string]interface{}, path string)
+	walk = func(route map[string]interface{}, path string) {
+		if name, _ := route["receiver"].(string); name != "" && !defined[name] {
+			problems = append(problems, fmt.Sprintf("%s.receiver %q is not defined in receivers", path, name))
+		}
+		children, _ := route["routes"].([]interface{})
+		for i, c := range children {
+			if child, ok := c.(map[string]interface{}); ok {
+				walk(child, fmt.Sprintf("%s.routes[%d]", path, i))
+			}
+		}
+	}
+	walk(route, "route")
+	return problems
+}
+
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
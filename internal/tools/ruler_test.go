@@ -0,0 +1,82 @@
+package tools_test
+
+import (
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+const latencyGroup = `
+name: latency
+interval: 1m
+rules:
+  - alert: HighLatency
+    expr: histogram_quantile(0.99, sum by (le) (rate(http_request_duration_seconds_bucket[5m]))) > 0.5
+    for: 10m
+    labels:
+      severity: page
+`
+
+type ruleGroupResult struct {
+	Status  string   `json:"status"`
+	Added   []string `json:"added"`
+	Changed []string `json:"changed"`
+	Removed []string `json:"removed"`
+}
+
+func TestMimirRuleGroupLifecycle(t *testing.T) {
+	h := testkit.New(t)
+	ds := h.AddDatasource(grafana.Datasource{Name: "Mimir", Type: "prometheus"})
+	set := func(group string) ruleGroupResult {
+		t.Helper()
+		var res ruleGroupResult
+		h.Call("grafana_mimir_set_rule_group", map[string]interface{}{
+			"datasource_uid": ds, "namespace": "checkout", "group": group,
+		}).OK().JSON(&res)
+		return res
+	}
+
+	if res := set(latencyGroup); res.Status != "created" || len(res.Added) != 1 {
+		t.Fatalf("first save = %+v, want created with one rule added", res)
+	}
+	if res := set(latencyGroup); res.Status != "unchanged" {
+		t.Fatalf("saving the same group = %+v, want unchanged", res)
+	}
+	if n := len(h.RequestsTo("POST", "/api/ruler/*/api/v1/rules/*")); n != 1 {
+		t.Fatalf("%d saves sent, want the unchanged group not sent again", n)
+	}
+	if res := set(latencyGroup + "  - record: job:http_requests:rate5m\n    expr: sum by (job) (rate(http_requests_total[5m]))\n"); res.Status != "updated" || len(res.Added) != 1 || len(res.Changed) != 0 {
+		t.Fatalf("adding a rule = %+v, want updated with one rule added", res)
+	}
+
+	var listed struct {
+		Groups int `json:"groups"`
+		Rules  int `json:"rules"`
+	}
+	h.Call("grafana_mimir_list_rule_groups", map[string]interface{}{"datasource_uid": ds}).OK().JSON(&listed)
+	if listed.Groups != 1 || listed.Rules != 2 {
+		t.Fatalf("listed %+v, want 1 group of 2 rules", listed)
+	}
+
+	h.Call("grafana_mimir_delete_rule_group", map[string]interface{}{
+		"datasource_uid": ds, "namespace": "checkout", "group": "latency",
+	}).OK()
+	h.Call("grafana_mimir_get_rule_group", map[string]interface{}{
+		"datasource_uid": ds, "namespace": "checkout", "group": "latency",
+	}).Error("Failed to get rule group")
+}
+
+func TestMimirSetRuleGroupChecksPromQL(t *testing.T) {
+	h := testkit.New(t)
+	ds := h.AddDatasource(grafana.Datasource{Name: "Mimir", Type: "prometheus"})
+
+	h.Call("grafana_mimir_set_rule_group", map[string]interface{}{
+		"datasource_uid": ds,
+		"namespace":      "checkout",
+		"group":          "name: broken\nrules:\n  - alert: Broken\n    expr: sum(rate(http_requests_total[5m])\n",
+	}).Error("invalid rule group")
+	if reqs := h.RequestsTo("POST", "/api/ruler/*/api/v1/rules/*"); len(reqs) != 0 {
+		t.Fatalf("an invalid group was sent to the ruler")
+	}
+}
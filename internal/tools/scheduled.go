@@ -0,0 +1,95 @@
+package tools
+
+import (
+	"context"
+	"errors"
+
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/notify"
+	"github.com/npcomplete777/grafana-mcp/internal/scheduler"
+)
+
+// WithScheduler exposes the jobs and run history of s through the
+// scheduled job tools
+func WithScheduler(s *scheduler.Scheduler) Option {
+	return func(r *Registry) {
+		r.scheduler = s
+	}
+}
+
+// RunTool calls a tool the way a scheduled job does and returns its text
+// output. Tool errors, including unknown or disabled tools, are returned as errors.
+func (r *Registry) RunTool(name string, args map[string]interface{}) (string, error) {
+	res, err := r.CallTool(context.Background(), name, args)
+	if err != nil {
+		return "", err
+	}
+	text := resultText(res)
+	if res.IsError {
+		return text, errors.New(text)
+	}
+	return text, nil
+}
+
+// WithNotifier sends webhook notifications for completed tool calls
+func WithNotifier(n *notify.Notifier) Option {
+	return func(r *Registry) {
+		r.notifier = n
+	}
+}
+
+func (r *Registry) grafanaListScheduledJobsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_scheduled_jobs",
+		Description: "List the jobs configured for the built-in scheduler with their cron schedule, tool, next run, and last outcome",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaGetJobHistoryTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_get_job_history",
+		Description: "Get recent runs of scheduled jobs, newest first, with status, duration, and truncated output",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"job":   {Type: "string", Description: "Only show runs of this job"},
+				"limit": {Type: "integer", Description: "Maximum runs to return (default 20)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleListScheduledJobs(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if r.scheduler == nil {
+		return jsonResult(map[string]interface{}{
+			"enabled": false,
+			"jobs":    []scheduler.JobStatus{},
+			"note":    "the scheduler is disabled; set scheduler.enabled in the config file",
+		})
+	}
+	return jsonResult(map[string]interface{}{
+		"enabled": true,
+		"jobs":    r.scheduler.Jobs(),
+	})
+}
+
+func (r *Registry) handleGetJobHistory(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	if r.scheduler == nil {
+		return errorResult("the scheduler is disabled; set scheduler.enabled in the config file"), nil
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = 20
+	}
+	return jsonResult(r.scheduler.History(getString(args, "job"), limit))
+}
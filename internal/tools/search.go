@@ -0,0 +1,191 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// Object kinds grafana_search covers, in the order results are grouped
+var searchKinds = []string{"dashboards", "folders", "alert_rules", "datasources", "teams", "users"}
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 200
+)
+
+// searchGroup is the result of one object kind of grafana_search
+type searchGroup struct {
+	Results interface{} `json:"results"`
+	// Total counts every match, including those past the limit
+	Total int    `json:"total"`
+	Error string `json:"error,omitempty"`
+}
+
+func (r *Registry) grafanaSearchTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_search",
+		Description: "Find anything by name in one call: dashboards and folders by title, alert rules by title, datasources by name or UID, teams by name, and users by login, email, or name, searched in parallel and grouped by kind. Matching is a case-insensitive substring match. Kinds that cannot be searched, e.g. for lack of permission, report an error without failing the others",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"query": {Type: "string", Description: "Text to look for, e.g. checkout"},
+				"kinds": {Type: "array", Description: "Object kinds to search (default all): dashboards, folders, alert_rules, datasources, teams, users"},
+				"limit": {Type: "integer", Description: "Most results per kind (default 20, max 200)"},
+			},
+			Required: []string{"query"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleSearch(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	query := strings.TrimSpace(getString(args, "query"))
+	if query == "" {
+		return errorResult("query is required"), nil
+	}
+	kinds := getStringSlice(args, "kinds")
+	if len(kinds) == 0 {
+		kinds = searchKinds
+	}
+	for _, k := range kinds {
+		if !contains(searchKinds, k) {
+			return errorResult(fmt.Sprintf("unknown kind %q: use %s", k, strings.Join(searchKinds, ", "))), nil
+		}
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+
+	groups := make(map[string]*searchGroup, len(kinds))
+	for _, k := range kinds {
+		groups[k] = &searchGroup{}
+	}
+	var wg sync.WaitGroup
+	run := func(kind string, f func(g *searchGroup) error) {
+		g, ok := groups[kind]
+		if !ok {
+			return
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := f(g); err != nil {
+				g.Error = err.Error()
+			}
+		}()
+	}
+
+	// One /api/search call answers both dashboards and folders
+	if groups["dashboards"] != nil || groups["folders"] != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := r.client.Search(r.ctx, grafana.SearchQuery{Query: query, Limit: 5000})
+			dashboards, folders := []grafana.SearchDashboardsResponse{}, []grafana.SearchDashboardsResponse{}
+			for _, f := range found {
+				switch f.Type {
+				case "dash-db":
+					dashboards = append(dashboards, f)
+				case "dash-folder":
+					folders = append(folders, f)
+				}
+			}
+			for kind, items := range map[string][]grafana.SearchDashboardsResponse{"dashboards": dashboards, "folders": folders} {
+				g, ok := groups[kind]
+				if !ok {
+					continue
+				}
+				if err != nil {
+					g.Error = err.Error()
+					continue
+				}
+				g.Total = len(items)
+				g.Results = items[:min(len(items), limit)]
+			}
+		}()
+	}
+	run("alert_rules", func(g *searchGroup) error {
+		rules, err := r.client.GetAlertRules(r.ctx)
+		if err != nil {
+			return err
+		}
+		matches := []map[string]interface{}{}
+		for _, rule := range rules {
+			if !containsFold(rule.Title, query) {
+				continue
+			}
+			matches = append(matches, map[string]interface{}{
+				"uid":       rule.UID,
+				"title":     rule.Title,
+				"folderUID": rule.FolderUID,
+				"ruleGroup": rule.RuleGroup,
+				"labels":    rule.Labels,
+				"isPaused":  rule.IsPaused,
+			})
+		}
+		g.Total = len(matches)
+		g.Results = matches[:min(len(matches), limit)]
+		return nil
+	})
+	run("datasources", func(g *searchGroup) error {
+		datasources, err := r.client.GetDatasources(r.ctx)
+		if err != nil {
+			return err
+		}
+		matches := []grafana.Datasource{}
+		for _, ds := range datasources {
+			if containsFold(ds.Name, query) || containsFold(ds.UID, query) {
+				matches = append(matches, ds)
+			}
+		}
+		g.Total = len(matches)
+		g.Results = matches[:min(len(matches), limit)]
+		return nil
+	})
+	run("teams", func(g *searchGroup) error {
+		res, err := r.client.SearchTeams(r.ctx, query, 1, limit)
+		if err != nil {
+			return err
+		}
+		g.Total, g.Results = max(int(res.TotalCount), len(res.Teams)), res.Teams
+		return nil
+	})
+	run("users", func(g *searchGroup) error {
+		res, err := r.client.SearchOrgUsers(r.ctx, query, 1, limit)
+		if err != nil {
+			return err
+		}
+		g.Total, g.Results = max(int(res.TotalCount), len(res.OrgUsers)), res.OrgUsers
+		return nil
+	})
+	wg.Wait()
+
+	total := 0
+	for _, g := range groups {
+		total += g.Total
+		if g.Results == nil {
+			g.Results = []interface{}{}
+		}
+	}
+	return jsonResult(map[string]interface{}{
+		"query":   query,
+		"total":   total,
+		"results": groups,
+	})
+}
+
+// containsFold reports whether s contains substr, ignoring case
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,347 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// redProfile describes a family of request metrics that can drive the rate,
+// errors and duration panels
+type redProfile struct {
+	name      string
+	histogram string // histogram base name, without _bucket
+	unit      string
+	errors    string // matcher selecting failed requests
+}
+
+// redProfiles are tried in order; the first whose histogram exists for the
+// service is used
+var redProfiles = []redProfile{
+	{"OTel HTTP server (stable semconv)", "http_server_request_duration_seconds", "s", `http_response_status_code=~"5.."`},
+	{"OTel HTTP server (legacy semconv)", "http_server_duration_milliseconds", "ms", `http_status_code=~"5.."`},
+	{"OTel HTTP server (legacy semconv)", "http_server_duration_seconds", "s", `http_status_code=~"5.."`},
+	{"OTel RPC server", "rpc_server_duration_milliseconds", "ms", `rpc_grpc_status_code!="0"`},
+	{"Span metrics connector", "traces_span_metrics_duration_seconds", "s", `status_code="STATUS_CODE_ERROR"`},
+	{"Span metrics connector", "duration_milliseconds", "ms", `status_code="STATUS_CODE_ERROR"`},
+	{"Tempo metrics generator", "traces_spanmetrics_latency", "s", `status_code="STATUS_CODE_ERROR"`},
+}
+
+// usePanel is a resource panel with candidate metrics in preference order;
+// %s in expr is replaced with the metric and service selector
+type usePanel struct {
+	title      string
+	unit       string
+	candidates []useCandidate
+}
+
+type useCandidate struct {
+	metric string
+	expr   string
+}
+
+var usePanels = []usePanel{
+	{"CPU", "percentunit", []useCandidate{
+		{"process_cpu_utilization_ratio", "avg(%s)"},
+		{"process_cpu_time_seconds_total", "sum(rate(%s[$__rate_interval]))"},
+		{"jvm_cpu_recent_utilization_ratio", "avg(%s)"},
+		{"process_runtime_jvm_cpu_utilization_ratio", "avg(%s)"},
+		{"process_cpu_seconds_total", "sum(rate(%s[$__rate_interval]))"},
+	}},
+	{"Memory", "bytes", []useCandidate{
+		{"process_memory_usage_bytes", "sum(%s)"},
+		{"jvm_memory_used_bytes", "sum(%s)"},
+		{"process_runtime_jvm_memory_usage_bytes", "sum(%s)"},
+		{"process_runtime_go_mem_heap_alloc_bytes", "sum(%s)"},
+		{"process_resident_memory_bytes", "sum(%s)"},
+	}},
+	{"Threads / goroutines", "short", []useCandidate{
+		{"jvm_thread_count", "sum(%s)"},
+		{"process_runtime_go_goroutines", "sum(%s)"},
+		{"process_thread_count", "sum(%s)"},
+		{"go_goroutines", "sum(%s)"},
+	}},
+	{"GC time", "s", []useCandidate{
+		{"jvm_gc_duration_seconds_sum", "sum(rate(%s[$__rate_interval]))"},
+		{"process_runtime_go_gc_pause_ns_sum", "sum(rate(%s[$__rate_interval])) / 1e9"},
+		{"go_gc_duration_seconds_sum", "sum(rate(%s[$__rate_interval]))"},
+	}},
+	{"Active requests", "short", []useCandidate{
+		{"http_server_active_requests", "sum(%s)"},
+	}},
+}
+
+func (r *Registry) grafanaGenerateServiceDashboardTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_generate_service_dashboard",
+		Description: "Generate a RED (rate, errors, duration) and USE (resource) dashboard for a service from OpenTelemetry semantic-convention metric names, detecting which metric families exist in Prometheus. Optionally adds Tempo trace panels, or TraceQL metrics when no request metrics are found, and Loki log panels",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"service":        {Type: "string", Description: "Service name (OTel service.name)"},
+				"prometheus_uid": {Type: "string", Description: "Prometheus-compatible datasource UID"},
+				"tempo_uid":      {Type: "string", Description: "Tempo datasource UID (optional)"},
+				"loki_uid":       {Type: "string", Description: "Loki datasource UID for a logs row, with error requests and error log lines in one mixed-datasource panel (optional)"},
+				"title":          {Type: "string", Description: "Dashboard title (default 'Service: <service>')"},
+				"folder_uid":     {Type: "string", Description: "Folder to save the dashboard in"},
+				"dry_run":        {Type: "boolean", Description: "Return the generated dashboard and detected metrics without saving"},
+			},
+			Required: []string{"service", "prometheus_uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleGenerateServiceDashboard(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	service := getString(args, "service")
+	if service == "" {
+		return errorResult("service is required"), nil
+	}
+	promUID := getString(args, "prometheus_uid")
+	if promUID == "" {
+		return errorResult("prometheus_uid is required"), nil
+	}
+
+	title := getString(args, "title")
+	if title == "" {
+		title = "Service: " + service
+	}
+	b, det, err := r.serviceDashboard(service, promUID, getString(args, "tempo_uid"), title)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	if err := r.serviceLogPanels(b, service, getString(args, "loki_uid"), det); err != nil {
+		return errorResultFor(err), nil
+	}
+
+	model := b.Dashboard()
+	if getBool(args, "dry_run") {
+		return jsonResult(map[string]interface{}{
+			"dry_run":   true,
+			"detected":  det.detected,
+			"dashboard": model,
+		})
+	}
+
+	saved, err := r.client.SaveDashboardJSON(r.ctx, model, getString(args, "folder_uid"), "Generated service dashboard via MCP", false)
+	if err != nil {
+		return apiErrorResult("Failed to save dashboard", err), nil
+	}
+	return jsonResult(map[string]interface{}{
+		"detected":  det.detected,
+		"dashboard": saved,
+	})
+}
+
+// serviceDetection records which metric families a service dashboard was
+// built from
+type serviceDetection struct {
+	detected map[string]interface{}
+	// red is the request metric family, nil when RED panels come from
+	// TraceQL metrics or are missing
+	red      *redProfile
+	selector string
+	promRef  map[string]interface{}
+}
+
+// serviceDashboard builds the RED/USE dashboard for a service, detecting
+// which OTel metric families exist in Prometheus. The builder is returned
+// so callers can add panels before rendering the model.
+func (r *Registry) serviceDashboard(service, promUID, tempoUID, title string) (*dashboard.Builder, *serviceDetection, error) {
+	prom, err := r.client.GetDatasource(r.ctx, promUID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to get datasource: %v", err)
+	}
+	promRef := dashboard.Ref(prom.Type, prom.UID)
+	var tempoRef map[string]interface{}
+	if tempoUID != "" {
+		tempo, err := r.client.GetDatasource(r.ctx, tempoUID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("Failed to get datasource: %v", err)
+		}
+		tempoRef = dashboard.Ref(tempo.Type, tempo.UID)
+	}
+
+	metrics, err := r.serviceMetrics(promUID, service)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to discover metrics: %v", err)
+	}
+
+	b := dashboard.NewBuilder(title).Set("tags", []string{"generated", "service", "otel"})
+	det := &serviceDetection{detected: map[string]interface{}{}, promRef: promRef}
+
+	for i := range redProfiles {
+		if _, ok := metrics[redProfiles[i].histogram+"_bucket"]; ok {
+			det.red = &redProfiles[i]
+			break
+		}
+	}
+	switch {
+	case det.red != nil:
+		det.selector = metrics[det.red.histogram+"_bucket"]
+		det.detected["red"] = det.red.histogram
+		det.detected["service_selector"] = det.selector
+		redPanels(b, det.red, det.selector, promRef)
+	case tempoRef != nil:
+		det.detected["red"] = "traceql_metrics"
+		traceQLRedPanels(b, service, tempoRef)
+	}
+
+	var use []string
+	for _, up := range usePanels {
+		for _, c := range up.candidates {
+			sel, ok := metrics[c.metric]
+			if !ok {
+				continue
+			}
+			if len(use) == 0 {
+				b.Row("Resources (USE)")
+			}
+			expr := fmt.Sprintf(c.expr, c.metric+sel)
+			b.Panel(dashboard.TimeseriesPanel(up.title, promRef, up.unit, dashboard.Target("A", promRef, expr, "")), 8, 8)
+			use = append(use, c.metric)
+			break
+		}
+	}
+	det.detected["use"] = use
+
+	if tempoRef != nil {
+		tracePanels(b, service, tempoRef)
+	}
+
+	if b.Len() == 0 {
+		return nil, nil, fmt.Errorf("no OpenTelemetry request or resource metrics found for service %q; pass tempo_uid to use trace data instead", service)
+	}
+	return b, det, nil
+}
+
+// serviceMetrics returns the metric names that have series for the service,
+// mapped to the label selector that matched them. OTel metrics identify the
+// service as service_name (resource attributes promoted to labels), job
+// ("<namespace>/<name>"), or service (span metrics).
+func (r *Registry) serviceMetrics(uid, service string) (map[string]string, error) {
+	quoted := regexp.QuoteMeta(service)
+	selectors := []string{
+		fmt.Sprintf(`service_name=%q`, service),
+		fmt.Sprintf(`service=%q`, service),
+		fmt.Sprintf(`job=~%q`, "(.+/)?"+quoted),
+	}
+	metrics := map[string]string{}
+	var lastErr error
+	for _, s := range selectors {
+		names, err := r.client.GetPrometheusLabelValues(r.ctx, uid, "__name__", []string{"{" + s + "}"})
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		for _, n := range names {
+			if _, ok := metrics[n]; !ok {
+				metrics[n] = "{" + s + "}"
+			}
+		}
+	}
+	if len(metrics) == 0 && lastErr != nil {
+		return nil, lastErr
+	}
+	return metrics, nil
+}
+
+func redPanels(b *dashboard.Builder, p *redProfile, sel string, ds map[string]interface{}) {
+	inner := strings.TrimSuffix(strings.TrimPrefix(sel, "{"), "}")
+	count := p.histogram + "_count"
+	bucket := p.histogram + "_bucket"
+
+	b.Row("RED: " + p.name)
+	b.Panel(dashboard.TimeseriesPanel("Request rate", ds, "reqps",
+		dashboard.Target("A", ds, fmt.Sprintf("sum(rate(%s%s[$__rate_interval]))", count, sel), "requests")), 8, 8)
+	b.Panel(dashboard.TimeseriesPanel("Error ratio", ds, "percentunit",
+		dashboard.Target("A", ds, fmt.Sprintf("sum(rate(%s{%s, %s}[$__rate_interval])) / sum(rate(%s%s[$__rate_interval]))",
+			count, inner, p.errors, count, sel), "errors")), 8, 8)
+
+	var targets []map[string]interface{}
+	for i, q := range []string{"0.5", "0.95", "0.99"} {
+		targets = append(targets, dashboard.Target(string(rune('A'+i)), ds,
+			fmt.Sprintf("histogram_quantile(%s, sum by (le) (rate(%s%s[$__rate_interval])))", q, bucket, sel), "p"+strings.TrimPrefix(q, "0.")))
+	}
+	b.Panel(dashboard.TimeseriesPanel("Duration", ds, p.unit, targets...), 8, 8)
+}
+
+// serviceLogPanels adds a logs row for the service when lokiUID is set. With
+// request metrics, a mixed-datasource panel plots error requests from
+// Prometheus against error log lines from Loki.
+func (r *Registry) serviceLogPanels(b *dashboard.Builder, service, lokiUID string, det *serviceDetection) error {
+	if lokiUID == "" {
+		return nil
+	}
+	loki, err := r.client.GetDatasource(r.ctx, lokiUID)
+	if err != nil {
+		return fmt.Errorf("Failed to get datasource: %v", err)
+	}
+	ref := dashboard.Ref(loki.Type, loki.UID)
+	stream := fmt.Sprintf(`{service_name=%q}`, service)
+
+	b.Row("Logs")
+	if p := det.red; p != nil {
+		inner := strings.TrimSuffix(strings.TrimPrefix(det.selector, "{"), "}")
+		b.Panel(dashboard.TimeseriesPanel("Errors: requests vs log lines", nil, "short",
+			dashboard.Target("A", det.promRef, fmt.Sprintf("sum(rate(%s_count{%s, %s}[$__rate_interval]))", p.histogram, inner, p.errors), "error requests/s"),
+			dashboard.Target("B", ref, fmt.Sprintf(`sum(rate(%s |~ "(?i)error" [$__interval]))`, stream), "error log lines/s"),
+		), 24, 8)
+	}
+	b.Panel(map[string]interface{}{
+		"type":       "logs",
+		"title":      "Logs",
+		"datasource": ref,
+		"targets":    []interface{}{dashboard.Target("A", ref, stream, "")},
+	}, 24, 10)
+	return nil
+}
+
+// traceQLRedPanels derives RED metrics from spans with TraceQL metrics
+// queries, for services that do not export request metrics
+func traceQLRedPanels(b *dashboard.Builder, service string, ds map[string]interface{}) {
+	sel := fmt.Sprintf(`resource.service.name=%q`, service)
+	panel := func(title, unit, query string) map[string]interface{} {
+		return dashboard.TimeseriesPanel(title, ds, unit, tempoTarget("A", ds, query, "range"))
+	}
+	b.Row("RED: TraceQL metrics")
+	b.Panel(panel("Request rate", "reqps", "{"+sel+" && kind=server} | rate()"), 8, 8)
+	b.Panel(panel("Error rate", "reqps", "{"+sel+" && kind=server && status=error} | rate()"), 8, 8)
+	b.Panel(panel("Duration p95", "s", "{"+sel+" && kind=server} | quantile_over_time(duration, .95)"), 8, 8)
+}
+
+func tracePanels(b *dashboard.Builder, service string, ds map[string]interface{}) {
+	sel := fmt.Sprintf(`resource.service.name=%q`, service)
+	table := func(title, query string) map[string]interface{} {
+		return map[string]interface{}{
+			"type":       "table",
+			"title":      title,
+			"datasource": ds,
+			"targets":    []interface{}{tempoTarget("A", ds, query, "")},
+		}
+	}
+	b.Row("Traces")
+	b.Panel(table("Recent errors", "{"+sel+" && status=error}"), 12, 10)
+	b.Panel(table("Slow requests", "{"+sel+" && kind=server && duration > 1s}"), 12, 10)
+}
+
+func tempoTarget(refID string, ds map[string]interface{}, query, metricsType string) map[string]interface{} {
+	t := map[string]interface{}{
+		"refId":      refID,
+		"datasource": ds,
+		"queryType":  "traceql",
+		"query":      query,
+		"limit":      20,
+		"tableType":  "traces",
+	}
+	if metricsType != "" {
+		t["metricsQueryType"] = metricsType
+	}
+	return t
+}
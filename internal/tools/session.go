@@ -0,0 +1,21 @@
+package tools
+
+import "context"
+
+type sessionKey struct{}
+
+// WithSession returns ctx carrying the id of the MCP session a call comes
+// from. State a session sets for its later calls, such as the instance
+// chosen with grafana_use_instance, is kept under this id. Calls without a
+// session, such as scheduled jobs, have no such state.
+func WithSession(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, sessionKey{}, id)
+}
+
+func sessionFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(sessionKey{}).(string)
+	return id
+}
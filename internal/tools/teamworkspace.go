@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaGrantTeamWorkspaceTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_grant_team_workspace",
+		Description: "Onboard a team in one call: find or create a folder by title, grant the team Edit (or another permission) on it, and optionally seed a starter dashboard. Existing objects are left alone, so the call can be re-run.",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"team":            {Type: "string", Description: "Team name"},
+				"folder_title":    {Type: "string", Description: "Folder title; an existing folder with this title is reused"},
+				"folder_uid":      {Type: "string", Description: "UID for the folder when it is created (default: generated by Grafana)"},
+				"permission":      {Type: "string", Description: "Permission granted to the team (default Edit)", Enum: []string{"View", "Edit", "Admin"}},
+				"seed_dashboard":  {Type: "boolean", Description: "Create a starter dashboard in the folder"},
+				"dashboard_title": {Type: "string", Description: "Starter dashboard title (default: \"<team> Home\")"},
+				"dry_run":         {Type: "boolean", Description: "Report what would be done without changing anything"},
+			},
+			Required: []string{"team", "folder_title"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleGrantTeamWorkspace(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	teamName := strings.TrimSpace(getString(args, "team"))
+	if teamName == "" {
+		return errorResult("team is required"), nil
+	}
+	title := strings.TrimSpace(getString(args, "folder_title"))
+	if title == "" {
+		return errorResult("folder_title is required"), nil
+	}
+	permission := getString(args, "permission")
+	if permission == "" {
+		permission = "Edit"
+	}
+	if !contains([]string{"View", "Edit", "Admin"}, permission) {
+		return errorResult("permission must be View, Edit, or Admin"), nil
+	}
+
+	team, err := r.teamByName(teamName)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	folders, err := r.folderList(true)
+	if err != nil {
+		return apiErrorResult("Failed to list folders", err), nil
+	}
+	var existing []folderMatch
+	for _, m := range matchFolders(folders, title) {
+		if m.Match == "exact" || m.Match == "case_insensitive" {
+			existing = append(existing, m)
+		}
+	}
+	if len(existing) > 1 {
+		return errorResult(fmt.Sprintf("folder_title %q matches %d folders (%s); rename one so the title is unique", title, len(existing), describeFolderMatches(existing))), nil
+	}
+
+	var model map[string]interface{}
+	if getBool(args, "seed_dashboard") {
+		model = starterDashboard(team.Name, getString(args, "dashboard_title"))
+	}
+
+	if getBool(args, "dry_run") {
+		plan := map[string]interface{}{
+			"team":       team.Name,
+			"team_id":    team.ID,
+			"permission": permission,
+		}
+		if len(existing) == 1 {
+			plan["folder"] = map[string]string{"status": "exists", "uid": existing[0].UID, "title": existing[0].Title}
+		} else {
+			plan["folder"] = map[string]string{"status": "create", "uid": getString(args, "folder_uid"), "title": title}
+		}
+		if model != nil {
+			plan["dashboard"] = model
+		}
+		return jsonResult(map[string]interface{}{"dry_run": true, "plan": plan})
+	}
+
+	var steps []installStep
+	folderUID := ""
+	if len(existing) == 1 {
+		folderUID = existing[0].UID
+		steps = append(steps, installStep{Step: "folder", Status: "exists", UID: folderUID, Detail: existing[0].Title})
+	} else {
+		created, err := r.client.CreateFolder(r.ctx, title, getString(args, "folder_uid"))
+		if err != nil {
+			steps = append(steps, installStep{Step: "folder", Status: "failed", Detail: err.Error()})
+			return workspaceResult(team, steps, false)
+		}
+		folderUID = created.UID
+		steps = append(steps, installStep{Step: "folder", Status: "created", UID: folderUID, Detail: title})
+	}
+
+	ok := true
+	grant := installStep{Step: "team_permission", Status: "updated", UID: folderUID, Detail: fmt.Sprintf("team %s has %s on the folder", team.Name, permission)}
+	if err := r.client.SetFolderTeamPermission(r.ctx, folderUID, team.ID, permission); err != nil {
+		grant.Status, grant.Detail, ok = "failed", err.Error(), false
+	}
+	steps = append(steps, grant)
+
+	if model != nil {
+		dash := r.installDashboard(model, folderUID, "Seeded team workspace via MCP", false)
+		ok = ok && dash.Status != "failed"
+		steps = append(steps, dash)
+	}
+	return workspaceResult(team, steps, ok)
+}
+
+func workspaceResult(team *grafana.Team, steps []installStep, ok bool) (*mcp.CallToolResult, error) {
+	result, err := jsonResult(map[string]interface{}{
+		"team":    team.Name,
+		"team_id": team.ID,
+		"steps":   steps,
+	})
+	if result != nil && !ok {
+		result.IsError = true
+	}
+	return result, err
+}
+
+// teamByName looks up a team by name, ignoring case
+func (r *Registry) teamByName(name string) (*grafana.Team, error) {
+	teams, err := r.client.GetTeams(r.ctx, name, 1, 100)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to look up team: %v", err)
+	}
+	for i := range teams {
+		if strings.EqualFold(teams[i].Name, name) {
+			return &teams[i], nil
+		}
+	}
+	return nil, fmt.Errorf("team %q not found", name)
+}
+
+// starterDashboard is the dashboard a new team workspace is seeded with: a
+// note on where the team's dashboards and alerts belong, ready to extend
+func starterDashboard(team, title string) map[string]interface{} {
+	if title == "" {
+		title = team + " Home"
+	}
+	slug := serviceSlug(team)
+	b := dashboard.NewBuilder(title)
+	b.Panel(map[string]interface{}{
+		"type":  "text",
+		"title": "Welcome",
+		"options": map[string]interface{}{
+			"mode": "markdown",
+			"content": fmt.Sprintf("## %s\n\nThis folder is the %s team's workspace. "+
+				"Keep the team's dashboards here, and label its alert rules `team=%s`.", title, team, team),
+		},
+	}, 24, 6)
+	b.Set("tags", []string{"team:" + slug, "starter"})
+	if slug != "" {
+		b.Set("uid", "team-"+slug+"-home")
+	}
+	return b.Dashboard()
+}
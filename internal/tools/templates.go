@@ -0,0 +1,276 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/packs"
+)
+
+const (
+	templateFolderUID   = "mcp-templates"
+	templateFolderTitle = "Templates"
+)
+
+func (r *Registry) grafanaListTemplatesTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_templates",
+		Description: "List the dashboard and alert rule templates shipped with the server (Node, PostgreSQL, Redis, NGINX, JVM, Kafka) with the exporter each expects, the datasource inputs to map, and the alert rules it creates",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaInstallTemplateTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_install_template",
+		Description: "Install a template from grafana_list_templates: its dashboard and recommended alert rules, with each datasource input mapped to a datasource. Inputs left unmapped use datasource_uid, or the only datasource of the input's type.",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"name":           {Type: "string", Description: "Template name, e.g. node, postgres, redis, nginx, jvm, kafka"},
+				"datasources":    {Type: "object", Description: "Datasource UID per template input, e.g. {\"DS_PROMETHEUS\": \"prom-uid\"}"},
+				"datasource_uid": {Type: "string", Description: "Datasource for inputs not given in datasources"},
+				"job":            {Type: "string", Description: "Scrape job to scope alert rules to and preselect on the dashboard (default: all jobs)"},
+				"folder_uid":     {Type: "string", Description: "Folder to install into (default: a 'Templates' folder, created if needed)"},
+				"alert_rules":    {Type: "boolean", Description: "Also create the recommended alert rules (default true)"},
+				"overwrite":      {Type: "boolean", Description: "Replace the template dashboard if it already exists (default: leave it unchanged)"},
+				"dry_run":        {Type: "boolean", Description: "Report the mapped dashboard and rules without saving"},
+			},
+			Required: []string{"name"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleListTemplates(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	templates, err := packs.Templates()
+	if err != nil {
+		return apiErrorResult("Failed to load templates", err), nil
+	}
+	out := make([]map[string]interface{}, 0, len(templates))
+	for _, t := range templates {
+		var dashboards, alerts []string
+		for _, d := range t.Dashboards {
+			if title, ok := d.Model["title"].(string); ok {
+				dashboards = append(dashboards, title)
+			}
+		}
+		for _, a := range t.Alerts {
+			alerts = append(alerts, a.Title)
+		}
+		out = append(out, map[string]interface{}{
+			"name":        t.Name,
+			"title":       t.Title,
+			"description": t.Description,
+			"exporter":    t.Exporter,
+			"inputs":      t.Inputs,
+			"dashboards":  dashboards,
+			"alert_rules": alerts,
+		})
+	}
+	return jsonResult(out)
+}
+
+func (r *Registry) handleInstallTemplate(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	name := getString(args, "name")
+	if name == "" {
+		return errorResult("name is required"), nil
+	}
+	meta, err := templateByName(name)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+
+	mapping, err := r.mapTemplateInputs(meta.Inputs, getStringMap(args, "datasources"), getString(args, "datasource_uid"))
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	uids := make(map[string]string, len(mapping))
+	for input, ds := range mapping {
+		uids[input] = ds.UID
+	}
+	job := getString(args, "job")
+	params := packs.TemplateParams{Datasources: uids}
+	if job != "" {
+		params.Selector = fmt.Sprintf("job=%q", job)
+	}
+	t, err := packs.LoadTemplate(name, params)
+	if err != nil {
+		return apiErrorResult("Failed to load template", err), nil
+	}
+
+	folderUID := getString(args, "folder_uid")
+	defaultFolder := folderUID == ""
+	if defaultFolder {
+		folderUID = templateFolderUID
+	}
+	withAlerts := true
+	if _, ok := args["alert_rules"]; ok {
+		withAlerts = getBool(args, "alert_rules")
+	}
+
+	var dashboards []map[string]interface{}
+	for _, d := range t.Dashboards {
+		if job != "" {
+			setVariableCurrent(d.Model, "job", job)
+		}
+		dashboards = append(dashboards, d.Model)
+	}
+	var rules []grafana.AlertRule
+	if withAlerts {
+		for _, a := range t.Alerts {
+			rules = append(rules, templateAlertRule(t.Name, a, uids[a.Datasource], folderUID, job))
+		}
+	}
+
+	out := map[string]interface{}{
+		"template":    t.Name,
+		"datasources": uids,
+	}
+	if getBool(args, "dry_run") {
+		out["dry_run"] = true
+		out["dashboards"] = dashboards
+		out["alert_rules"] = rules
+		return jsonResult(out)
+	}
+
+	var steps []installStep
+	if defaultFolder {
+		folder := r.ensureFolder(folderUID, templateFolderTitle)
+		steps = append(steps, folder)
+		if folder.Status == "failed" {
+			out["steps"] = steps
+			res, err := jsonResult(out)
+			if res != nil {
+				res.IsError = true
+			}
+			return res, err
+		}
+	}
+	for _, d := range dashboards {
+		steps = append(steps, r.installDashboard(d, folderUID, "Installed "+t.Name+" template via MCP", getBool(args, "overwrite")))
+	}
+	steps = append(steps, r.installAlertRules(rules)...)
+	out["steps"] = steps
+	return jsonResult(out)
+}
+
+func templateByName(name string) (*packs.Template, error) {
+	templates, err := packs.Templates()
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load templates: %v", err)
+	}
+	var names []string
+	for i := range templates {
+		if templates[i].Name == name {
+			return &templates[i], nil
+		}
+		names = append(names, templates[i].Name)
+	}
+	return nil, fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(names, ", "))
+}
+
+// mapTemplateInputs resolves a datasource for each template input: the one
+// mapped explicitly, then the fallback, then the only datasource of the
+// input's type. Each must exist and be of the input's type.
+func (r *Registry) mapTemplateInputs(inputs []packs.Input, explicit map[string]string, fallback string) (map[string]*grafana.Datasource, error) {
+	for input := range explicit {
+		if !templateHasInput(inputs, input) {
+			return nil, fmt.Errorf("template has no input %q", input)
+		}
+	}
+	var all []grafana.Datasource
+	out := make(map[string]*grafana.Datasource, len(inputs))
+	for _, in := range inputs {
+		uid := explicit[in.Name]
+		if uid == "" {
+			uid = fallback
+		}
+		if uid != "" {
+			ds, err := r.client.GetDatasource(r.ctx, uid)
+			if err != nil {
+				return nil, fmt.Errorf("Failed to get datasource for %s: %v", in.Name, err)
+			}
+			if ds.Type != in.Type {
+				return nil, fmt.Errorf("input %s needs a %s datasource, %s is %s", in.Name, in.Type, ds.Name, ds.Type)
+			}
+			out[in.Name] = ds
+			continue
+		}
+		if all == nil {
+			var err error
+			if all, err = r.client.GetDatasources(r.ctx); err != nil {
+				return nil, fmt.Errorf("Failed to list datasources: %v", err)
+			}
+		}
+		var candidates []grafana.Datasource
+		for _, ds := range all {
+			if ds.Type == in.Type {
+				candidates = append(candidates, ds)
+			}
+		}
+		switch len(candidates) {
+		case 0:
+			return nil, fmt.Errorf("input %s needs a %s datasource and none exists", in.Name, in.Type)
+		case 1:
+			out[in.Name] = &candidates[0]
+		default:
+			var names []string
+			for _, ds := range candidates {
+				names = append(names, fmt.Sprintf("%s (%s)", ds.Name, ds.UID))
+			}
+			return nil, fmt.Errorf("input %s matches several %s datasources, map it in datasources: %s", in.Name, in.Type, strings.Join(names, ", "))
+		}
+	}
+	return out, nil
+}
+
+func templateHasInput(inputs []packs.Input, name string) bool {
+	for _, in := range inputs {
+		if in.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// setVariableCurrent preselects value in a dashboard's template variable
+func setVariableCurrent(model map[string]interface{}, name, value string) {
+	templating, _ := model["templating"].(map[string]interface{})
+	list, _ := templating["list"].([]interface{})
+	for _, v := range list {
+		if m, ok := v.(map[string]interface{}); ok && m["name"] == name {
+			m["current"] = map[string]interface{}{"text": value, "value": value}
+		}
+	}
+}
+
+func templateAlertRule(template string, a packs.Alert, dsUID, folderUID, job string) grafana.AlertRule {
+	title := a.Title
+	if job != "" {
+		title += " (" + job + ")"
+	}
+	return grafana.AlertRule{
+		Title:        title,
+		FolderUID:    folderUID,
+		RuleGroup:    template,
+		Condition:    "B",
+		For:          a.For,
+		NoDataState:  "OK",
+		ExecErrState: "Error",
+		Labels:       map[string]string{"severity": a.Severity, "template": template},
+		Annotations:  map[string]string{"summary": a.Summary},
+		Data:         thresholdRuleData(dsUID, a.Expr, a.Op, a.Threshold),
+	}
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaTemplatizeDashboardTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_templatize_dashboard",
+		Description: "Generalize a single-service dashboard into a reusable template: hard-coded label values in queries (namespace, cluster, job, ...) become query-backed template variables, optionally with a datasource variable. Saves as a new dashboard unless update_in_place is set",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uid":                 {Type: "string", Description: "Source dashboard UID"},
+				"labels":              {Type: "array", Description: "Label names to extract, in variable dependency order (default cluster, environment, env, namespace, job, service, app)"},
+				"datasource_variable": {Type: "boolean", Description: "Add a datasource variable for the dominant datasource (default true)"},
+				"update_in_place":     {Type: "boolean", Description: "Overwrite the source dashboard instead of creating a new one"},
+				"new_title":           {Type: "string", Description: "Title for the new template dashboard (default '<title> (template)')"},
+				"new_uid":             {Type: "string", Description: "UID for the new template dashboard (default generated)"},
+				"folder_uid":          {Type: "string", Description: "Folder for the new dashboard (default the source folder)"},
+				"dry_run":             {Type: "boolean", Description: "Return the generated dashboard without saving"},
+			},
+			Required: []string{"uid"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleTemplatizeDashboard(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uid := getString(args, "uid")
+	if uid == "" {
+		return errorResult("uid is required"), nil
+	}
+	dsVariable := true
+	if v, ok := args["datasource_variable"].(bool); ok {
+		dsVariable = v
+	}
+	inPlace := getBool(args, "update_in_place")
+
+	dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+	if err != nil {
+		return apiErrorResult("Failed to get dashboard", err), nil
+	}
+	model := dash.Dashboard
+
+	res := dashboard.Templatize(model, dashboard.TemplatizeOptions{
+		Labels:             getStringSlice(args, "labels"),
+		DatasourceVariable: dsVariable,
+	})
+	if len(res.Variables) == 0 && len(res.Values) == 0 {
+		return errorResult("no hard-coded label matchers found for the requested labels"), nil
+	}
+
+	folderUID := dash.Meta.FolderUID
+	if !inPlace {
+		title := getString(args, "new_title")
+		if title == "" {
+			title = dashboard.String(model, "title") + " (template)"
+		}
+		model["title"] = title
+		delete(model, "id")
+		delete(model, "version")
+		model["uid"] = getString(args, "new_uid")
+		if f := getString(args, "folder_uid"); f != "" {
+			folderUID = f
+		}
+	}
+
+	if getBool(args, "dry_run") {
+		return jsonResult(map[string]interface{}{
+			"dry_run":    true,
+			"templatize": res,
+			"dashboard":  model,
+		})
+	}
+
+	saved, err := r.client.SaveDashboardJSON(r.ctx, model, folderUID, "Templatized via MCP", false)
+	if err != nil {
+		return apiErrorResult("Failed to save dashboard", err), nil
+	}
+
+	return jsonResult(map[string]interface{}{
+		"templatize": res,
+		"dashboard":  saved,
+	})
+}
@@ -0,0 +1,69 @@
+package tools
+
+import (
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// TimeFormat controls how epoch-millisecond timestamps are rendered next to
+// their raw values in tool output
+type TimeFormat struct {
+	// Location is the timezone times are shown in
+	Location *time.Location
+	// Layout is a Go time layout, e.g. time.RFC3339
+	Layout string
+}
+
+// DefaultTimeFormat returns the format used when none is configured: RFC 3339
+// in the server's local timezone
+func DefaultTimeFormat() TimeFormat {
+	return TimeFormat{Location: time.Local, Layout: time.RFC3339}
+}
+
+// WithTimeFormat overrides the timezone and layout of rendered timestamps.
+// A nil Location or empty Layout keeps its default.
+func WithTimeFormat(f TimeFormat) Option {
+	return func(r *Registry) {
+		def := DefaultTimeFormat()
+		if f.Location == nil {
+			f.Location = def.Location
+		}
+		if f.Layout == "" {
+			f.Layout = def.Layout
+		}
+		r.timeFormat = f
+	}
+}
+
+// formatMillis renders an epoch-millisecond timestamp, or "" for 0
+func (r *Registry) formatMillis(ms int64) string {
+	if ms == 0 {
+		return ""
+	}
+	return time.UnixMilli(ms).In(r.timeFormat.Location).Format(r.timeFormat.Layout)
+}
+
+// annotationOutput is an annotation with its timestamps also rendered as
+// readable times
+type annotationOutput struct {
+	grafana.Annotation
+	TimeLocal    string `json:"timeLocal,omitempty"`
+	TimeEndLocal string `json:"timeEndLocal,omitempty"`
+	CreatedLocal string `json:"createdLocal,omitempty"`
+	UpdatedLocal string `json:"updatedLocal,omitempty"`
+}
+
+func (r *Registry) annotationOutputs(annotations []grafana.Annotation) []annotationOutput {
+	out := make([]annotationOutput, len(annotations))
+	for i, a := range annotations {
+		out[i] = annotationOutput{
+			Annotation:   a,
+			TimeLocal:    r.formatMillis(a.Time),
+			TimeEndLocal: r.formatMillis(a.TimeEnd),
+			CreatedLocal: r.formatMillis(a.Created),
+			UpdatedLocal: r.formatMillis(a.Updated),
+		}
+	}
+	return out
+}
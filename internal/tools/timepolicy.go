@@ -0,0 +1,241 @@
+package tools
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// Default org policy for dashboard time settings
+const (
+	defaultPolicyMinRefresh = "30s"
+	defaultPolicyMaxRange   = "7d"
+	defaultPolicyTimezone   = "browser"
+)
+
+// timePolicy is the time and refresh policy dashboards are checked against
+type timePolicy struct {
+	minRefresh    time.Duration
+	minRefreshRaw string
+	maxRange      time.Duration
+	maxRangeRaw   string
+	timezone      string
+}
+
+// timePolicyViolation is one setting of a dashboard that breaks the policy
+type timePolicyViolation struct {
+	Rule  string      `json:"rule"`
+	Path  string      `json:"path"`
+	Value interface{} `json:"value"`
+	Limit string      `json:"limit,omitempty"`
+	// Fix is the value the setting is changed to, absent when it cannot be
+	// fixed automatically
+	Fix interface{} `json:"fix,omitempty"`
+}
+
+func (r *Registry) grafanaEnforceTimePolicyTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_enforce_time_policy",
+		Description: "Check dashboards against the org's time and refresh policy: auto-refresh faster than min_refresh (default 30s), including faster choices in the refresh picker, a default time range or panel relative time longer than max_range (default 7d), and a missing timezone. Reports violations per dashboard; with fix=true, saves the dashboards with refresh raised to min_refresh, ranges shortened to max_range, and the timezone set",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uids":              {Type: "array", Description: "Dashboard UIDs to check (default: all matching the filters)"},
+				"folder_uid":        {Type: "string", Description: "Only check dashboards in this folder"},
+				"query":             {Type: "string", Description: "Only check dashboards matching this search query"},
+				"tags":              {Type: "array", Description: "Only check dashboards with these tags"},
+				"min_refresh":       {Type: "string", Description: "Fastest allowed auto-refresh interval (default 30s)"},
+				"max_range":         {Type: "string", Description: "Longest allowed default time range (default 7d)"},
+				"timezone":          {Type: "string", Description: "Timezone set on dashboards without one when fixing: browser, utc, or an IANA name (default browser)"},
+				"fix":               {Type: "boolean", Description: "Save the dashboards with their violations fixed (default false: report only)"},
+				"retry_on_conflict": {Type: "integer", Description: "If a dashboard changes before its save, refetch it, reapply the fixes, and retry up to this many times (default 0, max 5)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleEnforceTimePolicy(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	policy, err := timePolicyFromArgs(args)
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	fix := getBool(args, "fix")
+	retries := conflictRetries(args)
+
+	dashboards, failed, err := r.collectDashboards(args)
+	if err != nil {
+		return apiErrorResult("Failed to collect dashboards", err), nil
+	}
+
+	result := newBulkResult(!fix)
+	result.Meta = map[string]interface{}{
+		"min_refresh": policy.minRefreshRaw,
+		"max_range":   policy.maxRangeRaw,
+		"timezone":    policy.timezone,
+	}
+	result.FailAll(failed)
+	now := time.Now()
+	for _, d := range dashboards {
+		violations := policy.check(d.Model, now, false)
+		if len(violations) == 0 {
+			result.Skip(d.UID, d.Title, "compliant", nil)
+			continue
+		}
+		detail := map[string]interface{}{"violations": violations}
+		if !fix {
+			result.Succeed(d.UID, d.Title, "violates", detail)
+			continue
+		}
+		if !fixable(violations) {
+			result.Skip(d.UID, d.Title, "violations cannot be fixed automatically", detail)
+			continue
+		}
+
+		model := d.Model
+		policy.check(model, now, true)
+		var saved *grafana.SaveDashboardResponse
+		attempts, err := retryOnConflict(r.ctx, retries, func() error {
+			var err error
+			saved, err = r.client.SaveDashboardJSON(r.ctx, model, d.FolderUID, "Applied time policy via MCP", false)
+			if retries > 0 && grafana.IsVersionConflict(err) {
+				if latest, getErr := r.client.GetDashboardJSON(r.ctx, d.UID); getErr == nil {
+					model = latest.Dashboard
+					policy.check(model, time.Now(), true)
+				}
+			}
+			return err
+		})
+		if attempts > 1 {
+			detail["attempts"] = attempts
+		}
+		if err != nil {
+			result.Fail(d.UID, d.Title, err.Error(), detail)
+			continue
+		}
+		detail["version"] = saved.Version
+		result.Succeed(d.UID, d.Title, "fixed", detail)
+	}
+	return bulkResult(result)
+}
+
+func timePolicyFromArgs(args map[string]interface{}) (*timePolicy, error) {
+	p := &timePolicy{
+		minRefreshRaw: getString(args, "min_refresh"),
+		maxRangeRaw:   getString(args, "max_range"),
+		timezone:      getString(args, "timezone"),
+	}
+	if p.minRefreshRaw == "" {
+		p.minRefreshRaw = defaultPolicyMinRefresh
+	}
+	if p.maxRangeRaw == "" {
+		p.maxRangeRaw = defaultPolicyMaxRange
+	}
+	if p.timezone == "" {
+		p.timezone = defaultPolicyTimezone
+	}
+	var err error
+	if p.minRefresh, err = parseGrafanaDuration(p.minRefreshRaw); err != nil || p.minRefresh <= 0 {
+		return nil, fmt.Errorf("invalid min_refresh %q: expected a positive duration such as 30s", p.minRefreshRaw)
+	}
+	if p.maxRange, err = parseGrafanaDuration(p.maxRangeRaw); err != nil || p.maxRange <= 0 {
+		return nil, fmt.Errorf("invalid max_range %q: expected a positive duration such as 7d", p.maxRangeRaw)
+	}
+	return p, nil
+}
+
+// check returns the policy violations of a dashboard model, changing the
+// model to fix each one that can be fixed when apply is set
+func (p *timePolicy) check(model map[string]interface{}, now time.Time, apply bool) []timePolicyViolation {
+	var out []timePolicyViolation
+	add := func(v timePolicyViolation, set func()) {
+		if apply && v.Fix != nil {
+			set()
+		}
+		out = append(out, v)
+	}
+
+	// A refresh of "" or false means auto-refresh is off
+	if refresh, ok := model["refresh"].(string); ok && refresh != "" {
+		if d, err := parseGrafanaDuration(refresh); err == nil && d < p.minRefresh {
+			add(timePolicyViolation{Rule: "min_refresh", Path: "refresh", Value: refresh, Limit: p.minRefreshRaw, Fix: p.minRefreshRaw},
+				func() { model["refresh"] = p.minRefreshRaw })
+		}
+	}
+
+	if picker, ok := model["timepicker"].(map[string]interface{}); ok {
+		if intervals, ok := picker["refresh_intervals"].([]interface{}); ok {
+			// The faster choices are replaced by min_refresh
+			kept, faster := []interface{}{p.minRefreshRaw}, []interface{}{}
+			for _, iv := range intervals {
+				s, _ := iv.(string)
+				if d, err := parseGrafanaDuration(strings.TrimSpace(s)); err == nil && d < p.minRefresh {
+					faster = append(faster, iv)
+					continue
+				}
+				if iv != p.minRefreshRaw {
+					kept = append(kept, iv)
+				}
+			}
+			if len(faster) > 0 {
+				add(timePolicyViolation{Rule: "min_refresh", Path: "timepicker.refresh_intervals", Value: faster, Limit: p.minRefreshRaw, Fix: kept},
+					func() { picker["refresh_intervals"] = kept })
+			}
+		}
+	}
+
+	if tr, ok := model["time"].(map[string]interface{}); ok {
+		from, _ := tr["from"].(string)
+		to, _ := tr["to"].(string)
+		start, errFrom := parseTime(from, now)
+		end, errTo := parseTime(to, now)
+		if errFrom == nil && errTo == nil && end.Sub(start) > p.maxRange {
+			v := timePolicyViolation{Rule: "max_range", Path: "time", Value: map[string]interface{}{"from": from, "to": to}, Limit: p.maxRangeRaw}
+			// Only ranges ending now keep their meaning when shortened
+			if strings.TrimSpace(to) == "now" || to == "" {
+				v.Fix = map[string]interface{}{"from": "now-" + p.maxRangeRaw, "to": "now"}
+			}
+			add(v, func() {
+				tr["from"] = "now-" + p.maxRangeRaw
+				tr["to"] = "now"
+			})
+		}
+	}
+
+	for _, panel := range dashboard.Panels(model) {
+		timeFrom := strings.TrimSpace(dashboard.String(panel, "timeFrom"))
+		if timeFrom == "" {
+			continue
+		}
+		if d, err := parseGrafanaDuration(strings.TrimPrefix(timeFrom, "now-")); err == nil && d > p.maxRange {
+			panel := panel
+			path := fmt.Sprintf("panels[id=%d].timeFrom", dashboard.PanelID(panel))
+			add(timePolicyViolation{Rule: "max_range", Path: path, Value: timeFrom, Limit: p.maxRangeRaw, Fix: p.maxRangeRaw},
+				func() { panel["timeFrom"] = p.maxRangeRaw })
+		}
+	}
+
+	if tz, _ := model["timezone"].(string); strings.TrimSpace(tz) == "" {
+		add(timePolicyViolation{Rule: "timezone", Path: "timezone", Value: model["timezone"], Fix: p.timezone},
+			func() { model["timezone"] = p.timezone })
+	}
+	return out
+}
+
+// fixable reports whether fixing would change anything
+func fixable(violations []timePolicyViolation) bool {
+	for _, v := range violations {
+		if v.Fix != nil {
+			return true
+		}
+	}
+	return false
+}
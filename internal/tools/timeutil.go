@@ -0,0 +1,148 @@
+package tools
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// parseTime resolves a Grafana-style time expression (now, now-6h, now-7d/d),
+// an RFC 3339 timestamp, or epoch milliseconds relative to now.
+func parseTime(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "now" {
+		return now, nil
+	}
+
+	if strings.HasPrefix(s, "now") {
+		expr := s[3:]
+		round := ""
+		if i := strings.Index(expr, "/"); i >= 0 {
+			round = expr[i+1:]
+			expr = expr[:i]
+		}
+		t := now
+		if expr != "" {
+			d, err := parseGrafanaDuration(strings.TrimPrefix(expr, "+"))
+			if err != nil {
+				return time.Time{}, fmt.Errorf("invalid time %q: %w", s, err)
+			}
+			t = t.Add(d)
+		}
+		if round != "" {
+			t = truncateTo(t, round)
+		}
+		return t, nil
+	}
+
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms), nil
+	}
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q: expected now-<duration>, RFC 3339, or epoch milliseconds", s)
+}
+
+// parseTimeRange resolves from/to expressions with the supplied defaults.
+func parseTimeRange(from, to, defaultFrom string) (time.Time, time.Time, error) {
+	if from == "" {
+		from = defaultFrom
+	}
+	if to == "" {
+		to = "now"
+	}
+	now := time.Now()
+	start, err := parseTime(from, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := parseTime(to, now)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("time range from %q must be before to %q", from, to)
+	}
+	return start, end, nil
+}
+
+// parseGrafanaDuration parses durations with Grafana's units, including d, w,
+// M (30 days), and y (365 days), which time.ParseDuration does not accept.
+func parseGrafanaDuration(s string) (time.Duration, error) {
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		if i == 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, _ := strconv.Atoi(s[:i])
+		s = s[i:]
+
+		j := 0
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		unit := s[:j]
+		s = s[j:]
+
+		var u time.Duration
+		switch unit {
+		case "ms":
+			u = time.Millisecond
+		case "s":
+			u = time.Second
+		case "m":
+			u = time.Minute
+		case "h":
+			u = time.Hour
+		case "d":
+			u = 24 * time.Hour
+		case "w":
+			u = 7 * 24 * time.Hour
+		case "M":
+			u = 30 * 24 * time.Hour
+		case "y":
+			u = 365 * 24 * time.Hour
+		default:
+			return 0, fmt.Errorf("unknown duration unit %q", unit)
+		}
+		total += time.Duration(n) * u
+	}
+	if neg {
+		total = -total
+	}
+	return total, nil
+}
+
+func truncateTo(t time.Time, unit string) time.Time {
+	switch unit {
+	case "m":
+		return t.Truncate(time.Minute)
+	case "h":
+		return t.Truncate(time.Hour)
+	case "d":
+		y, m, d := t.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+	case "w":
+		y, m, d := t.Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		return day.AddDate(0, 0, -int(day.Weekday()))
+	case "M":
+		y, m, _ := t.Date()
+		return time.Date(y, m, 1, 0, 0, 0, 0, t.Location())
+	case "y":
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
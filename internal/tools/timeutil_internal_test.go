@@ -0,0 +1,64 @@
+package tools
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	// A Wednesday afternoon
+	now := time.Date(2024, time.May, 15, 14, 37, 12, 0, time.UTC)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", now},
+		{"now", now},
+		{" now-6h ", now.Add(-6 * time.Hour)},
+		{"now+30m", now.Add(30 * time.Minute)},
+		{"now-1h30m", now.Add(-90 * time.Minute)},
+		{"now-7d", now.AddDate(0, 0, -7)},
+		{"now-1w", now.AddDate(0, 0, -7)},
+		{"now-1M", now.Add(-30 * 24 * time.Hour)},
+		{"now-1y", now.Add(-365 * 24 * time.Hour)},
+		{"now-500ms", now.Add(-500 * time.Millisecond)},
+		{"now/h", time.Date(2024, time.May, 15, 14, 0, 0, 0, time.UTC)},
+		{"now-1d/d", time.Date(2024, time.May, 14, 0, 0, 0, 0, time.UTC)},
+		{"now/w", time.Date(2024, time.May, 12, 0, 0, 0, 0, time.UTC)},
+		{"now/M", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)},
+		{"now/y", time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"1715783832000", time.UnixMilli(1715783832000)},
+		{"2024-05-01T00:00:00Z", time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := parseTime(tt.in, now)
+		if err != nil {
+			t.Errorf("parseTime(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseTime(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseTimeRejectsInvalid(t *testing.T) {
+	for _, in := range []string{"now-", "now-6", "now-6x", "now-h", "yesterday", "2024-05-01"} {
+		if _, err := parseTime(in, time.Now()); err == nil {
+			t.Errorf("parseTime(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestParseTimeRange(t *testing.T) {
+	start, end, err := parseTimeRange("", "", "now-6h")
+	if err != nil {
+		t.Fatalf("parseTimeRange with defaults: %v", err)
+	}
+	if end.Sub(start) != 6*time.Hour {
+		t.Fatalf("default range = %s, want 6h", end.Sub(start))
+	}
+	if _, _, err := parseTimeRange("now", "now-1h", "now-6h"); err == nil {
+		t.Fatal("parseTimeRange accepted a range ending before it starts")
+	}
+}
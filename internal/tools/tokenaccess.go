@@ -0,0 +1,340 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+func (r *Registry) grafanaCheckTokenAccessTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_check_token_access",
+		Description: "Check which Grafana API families (dashboards, folders, datasources, queries, alerting, annotations, teams, organization, server admin) the server's credentials can read and write, against what the enabled tools need. Lists tools that will fail for lack of access, access no enabled tool uses, the least basic role that covers the enabled tools, and a config snippet disabling unusable tools",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// accessFamily is a group of Grafana APIs granted together. Read access is
+// probed with a GET where one exists; write access can only be read from
+// the RBAC permissions of the identity, as probing it would change data.
+type accessFamily struct {
+	name  string
+	probe string
+	// read and write are RBAC actions, any one of which grants the access
+	read  []string
+	write []string
+	// role is the least basic role that usually holds write access
+	role       string
+	readTools  []string
+	writeTools []string
+}
+
+var accessFamilies = []accessFamily{
+	{
+		name:  "dashboards",
+		probe: "/api/search?type=dash-db&limit=1",
+		read:  []string{"dashboards:read"},
+		write: []string{"dashboards:write", "dashboards:create"},
+		role:  "Editor",
+		readTools: []string{
+			"grafana_search_dashboards", "grafana_search", "grafana_get_dashboard", "grafana_find_unused_dashboards",
+			"grafana_score_dashboard", "grafana_find_dashboard_anomalies", "grafana_explain_panel_errors",
+			"grafana_dependency_graph", "grafana_permissions_report", "grafana_render_panel",
+			"grafana_generate_report", "grafana_export_provisioning", "grafana_diff_provisioned_dashboards",
+			"grafana_export_iac", "grafana_user_activity",
+		},
+		writeTools: []string{
+			"grafana_create_dashboard", "grafana_update_dashboard", "grafana_delete_dashboard",
+			"grafana_archive_dashboards", "grafana_apply_jsonnet_dashboard", "grafana_import_dashboard",
+			"grafana_upgrade_dashboard_schema", "grafana_templatize_dashboard", "grafana_generate_dashboard_from_rules",
+			"grafana_generate_service_dashboard", "grafana_bootstrap_service", "grafana_monitor_endpoint",
+			"grafana_install_kubernetes_pack", "grafana_install_template", "grafana_bulk_tag", "grafana_apply_manifest",
+		},
+	},
+	{
+		name:       "folders",
+		probe:      "/api/folders?limit=1",
+		read:       []string{"folders:read"},
+		write:      []string{"folders:write", "folders:create"},
+		role:       "Editor",
+		readTools:  []string{"grafana_list_folders", "grafana_get_folder", "grafana_find_folder", "grafana_permissions_report"},
+		writeTools: []string{"grafana_create_folder", "grafana_update_folder", "grafana_delete_folder", "grafana_archive_dashboards", "grafana_bootstrap_service", "grafana_grant_team_workspace", "grafana_apply_manifest"},
+	},
+	{
+		name:       "permissions",
+		read:       []string{"folders.permissions:read", "dashboards.permissions:read"},
+		write:      []string{"folders.permissions:write"},
+		role:       "Admin",
+		readTools:  []string{"grafana_permissions_report"},
+		writeTools: []string{"grafana_bootstrap_service", "grafana_grant_team_workspace"},
+	},
+	{
+		name:       "datasources",
+		probe:      "/api/datasources",
+		read:       []string{"datasources:read"},
+		write:      []string{"datasources:write", "datasources:create"},
+		role:       "Admin",
+		readTools:  []string{"grafana_list_datasources", "grafana_get_datasource", "grafana_export_iac"},
+		writeTools: []string{"grafana_create_datasource", "grafana_update_datasource", "grafana_delete_datasource", "grafana_apply_manifest"},
+	},
+	{
+		name: "queries",
+		read: []string{"datasources:query"},
+		readTools: []string{
+			"grafana_query", "grafana_estimate_query_cost", "grafana_prometheus_targets", "grafana_loki_stats",
+			"grafana_loki_tail", "grafana_correlate_changes", "grafana_log_patterns", "grafana_find_dashboard_anomalies",
+			"grafana_explain_panel_errors", "grafana_burn_rate", "grafana_forecast",
+		},
+	},
+	{
+		name:  "alert_rules",
+		probe: "/api/v1/provisioning/alert-rules",
+		read:  []string{"alert.provisioning:read", "alert.rules:read"},
+		write: []string{"alert.provisioning:write", "alert.rules:write"},
+		role:  "Editor",
+		readTools: []string{
+			"grafana_list_alert_rules", "grafana_get_alert_rule", "grafana_alert_noise_report", "grafana_lint_alert_rules",
+			"grafana_alert_ownership_report", "grafana_dependency_graph", "grafana_generate_dashboard_from_rules", "grafana_export_iac",
+		},
+		writeTools: []string{
+			"grafana_create_alert_rule", "grafana_update_alert_rule", "grafana_delete_alert_rule", "grafana_bulk_edit_alert_rules",
+			"grafana_burn_rate", "grafana_monitor_endpoint", "grafana_apply_manifest",
+		},
+	},
+	{
+		name:      "alert_notifications",
+		probe:     "/api/v1/provisioning/policies",
+		read:      []string{"alert.provisioning:read", "alert.notifications:read"},
+		write:     []string{"alert.provisioning:write", "alert.notifications:write"},
+		role:      "Editor",
+		readTools: []string{"grafana_list_contact_points", "grafana_get_contact_point", "grafana_preview_alert_routing", "grafana_contact_point_inventory"},
+		writeTools: []string{
+			"grafana_create_contact_point", "grafana_update_contact_point", "grafana_delete_contact_point",
+			"grafana_test_contact_point", "grafana_bootstrap_service",
+		},
+	},
+	{
+		name:       "annotations",
+		probe:      "/api/annotations?limit=1",
+		read:       []string{"annotations:read"},
+		write:      []string{"annotations:write", "annotations:create"},
+		role:       "Editor",
+		readTools:  []string{"grafana_list_annotations", "grafana_correlate_changes", "grafana_alert_noise_report"},
+		writeTools: []string{"grafana_create_annotation", "grafana_update_annotation", "grafana_delete_annotation", "grafana_import_annotations", "grafana_forecast"},
+	},
+	{
+		name:       "teams",
+		probe:      "/api/teams/search?perpage=1",
+		read:       []string{"teams:read"},
+		write:      []string{"teams:create", "teams:delete", "teams:write"},
+		role:       "Admin",
+		readTools:  []string{"grafana_list_teams", "grafana_get_team", "grafana_alert_ownership_report"},
+		writeTools: []string{"grafana_create_team", "grafana_delete_team", "grafana_grant_team_workspace", "grafana_bootstrap_service"},
+	},
+	{
+		name:      "organization",
+		probe:     "/api/org/users",
+		read:      []string{"org.users:read"},
+		readTools: []string{"grafana_get_org", "grafana_list_org_users", "grafana_user_activity", "grafana_list_orgs", "grafana_switch_org"},
+	},
+	{
+		name:  "server_admin",
+		probe: "/api/admin/stats",
+		read:  []string{"server.stats:read"},
+	},
+}
+
+// familyAccess is what the credentials may do in one API family. Read and
+// Write are nil when they could not be determined.
+type familyAccess struct {
+	Family       string   `json:"family"`
+	Read         *bool    `json:"read"`
+	Write        *bool    `json:"write"`
+	Source       string   `json:"source"`
+	ProbeError   string   `json:"probe_error,omitempty"`
+	ToolsReading []string `json:"enabled_tools_reading,omitempty"`
+	ToolsWriting []string `json:"enabled_tools_writing,omitempty"`
+}
+
+func (r *Registry) handleCheckTokenAccess(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	perms, permErr := r.client.GetUserPermissions(r.ctx)
+	rbac := permErr == nil && len(perms) > 0
+
+	probes := make([]error, len(accessFamilies))
+	var wg sync.WaitGroup
+	for i, f := range accessFamilies {
+		if f.probe == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			probes[i] = r.client.CheckAccess(r.ctx, path)
+		}(i, f.probe)
+	}
+	wg.Wait()
+
+	enabled := map[string]bool{}
+	for _, t := range r.allTools() {
+		if r.isEnabled(t.Name) && r.unsupportedReason(t.Name) == "" {
+			enabled[t.Name] = true
+		}
+	}
+
+	families := make([]familyAccess, 0, len(accessFamilies))
+	unusable := map[string][]string{}
+	unused := []string{}
+	role := "Viewer"
+	for i, f := range accessFamilies {
+		fa := familyAccess{Family: f.name, Source: "unknown", ToolsReading: enabledOf(f.readTools, enabled), ToolsWriting: enabledOf(f.writeTools, enabled)}
+		if rbac {
+			fa.Source = "rbac"
+			fa.Read = boolPtr(hasAction(perms, f.read))
+			if len(f.write) > 0 {
+				fa.Write = boolPtr(hasAction(perms, f.write))
+			}
+		}
+		if f.probe != "" {
+			// A probe shows what the API actually allows, so it wins over
+			// RBAC for read access
+			if ok, known := probeOutcome(probes[i]); known {
+				fa.Read = boolPtr(ok)
+				if !rbac {
+					fa.Source = "probe"
+				}
+			} else if probes[i] != nil {
+				fa.ProbeError = probes[i].Error()
+			}
+		}
+		families = append(families, fa)
+
+		if fa.Read != nil && !*fa.Read {
+			for _, t := range fa.ToolsReading {
+				unusable[t] = append(unusable[t], f.name+" read")
+			}
+		}
+		if fa.Write != nil && !*fa.Write {
+			for _, t := range fa.ToolsWriting {
+				unusable[t] = append(unusable[t], f.name+" write")
+			}
+		}
+		switch {
+		case fa.Write != nil && *fa.Write && len(fa.ToolsWriting) == 0 && len(f.write) > 0:
+			unused = append(unused, fmt.Sprintf("%s: the credentials can write (%s) but no enabled tool does", f.name, strings.Join(f.write, ", ")))
+		case fa.Read != nil && *fa.Read && len(fa.ToolsReading) == 0 && len(fa.ToolsWriting) == 0:
+			unused = append(unused, fmt.Sprintf("%s: the credentials can read (%s) but no enabled tool does", f.name, strings.Join(f.read, ", ")))
+		}
+		if len(fa.ToolsWriting) > 0 && roleRank(f.role) > roleRank(role) {
+			role = f.role
+		}
+	}
+
+	unusableNames := make([]string, 0, len(unusable))
+	for name := range unusable {
+		unusableNames = append(unusableNames, name)
+	}
+	sort.Strings(unusableNames)
+	unusableTools := make([]map[string]interface{}, 0, len(unusableNames))
+	for _, name := range unusableNames {
+		unusableTools = append(unusableTools, map[string]interface{}{"tool": name, "missing": unusable[name]})
+	}
+
+	var suggestions []string
+	if len(unusableNames) > 0 {
+		suggestions = append(suggestions, fmt.Sprintf("%d enabled tools will fail with these credentials: grant the missing access, or disable them with disable_config", len(unusableNames)))
+	}
+	if len(unused) > 0 {
+		suggestions = append(suggestions, fmt.Sprintf("the credentials hold access no enabled tool uses; a token with the %s role (or a custom role with only the actions the enabled tools need) is enough", role))
+	}
+	if len(suggestions) == 0 {
+		suggestions = append(suggestions, "the credentials match the enabled tools")
+	}
+
+	out := map[string]interface{}{
+		"rbac":                 rbac,
+		"families":             families,
+		"unusable_tools":       unusableTools,
+		"unused_access":        unused,
+		"least_privilege_role": role,
+		"suggestions":          suggestions,
+	}
+	if !rbac {
+		note := "role-based access control permissions are unavailable, so write access is unknown and read access comes from probes"
+		if permErr != nil {
+			note += fmt.Sprintf(" (%v)", permErr)
+		}
+		out["note"] = note
+	}
+	if user, err := r.client.GetCurrentUser(r.ctx); err == nil {
+		out["identity"] = map[string]interface{}{"login": user.Login, "is_grafana_admin": user.IsGrafanaAdmin}
+	}
+	if len(unusableNames) > 0 {
+		var b strings.Builder
+		b.WriteString("tools:\n")
+		for _, name := range unusableNames {
+			fmt.Fprintf(&b, "  %s:\n    enabled: false\n", name)
+		}
+		out["disable_config"] = b.String()
+	}
+	return jsonResult(out)
+}
+
+// probeOutcome reads a probe's error: ok with known set when it shows
+// whether access is granted, and known unset when it shows neither, such
+// as an API this Grafana lacks
+func probeOutcome(err error) (ok, known bool) {
+	if err == nil {
+		return true, true
+	}
+	var apiErr *grafana.APIError
+	if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+		return false, true
+	}
+	return false, false
+}
+
+func hasAction(perms map[string][]string, actions []string) bool {
+	for _, a := range actions {
+		if _, ok := perms[a]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func enabledOf(names []string, enabled map[string]bool) []string {
+	var out []string
+	for _, n := range names {
+		if enabled[n] {
+			out = append(out, n)
+		}
+	}
+	return out
+}
+
+func roleRank(role string) int {
+	switch role {
+	case "Admin":
+		return 2
+	case "Editor":
+		return 1
+	}
+	return 0
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,334 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+// Token expiry statuses
+const (
+	tokenOK       = "ok"
+	tokenExpiring = "expiring"
+	tokenExpired  = "expired"
+	tokenNoExpiry = "no_expiry"
+	// tokenNotApplicable is reported when the credentials are not a service
+	// account token, such as basic auth
+	tokenNotApplicable = "not_applicable"
+	tokenUnknown       = "unknown"
+)
+
+// TokenExpirySettings controls the check of the service account token's
+// expiry and its rotation
+type TokenExpirySettings struct {
+	// Warning is how long before expiry the token is reported as expiring
+	Warning time.Duration
+	// Interval is how often the token is checked in the background; 0
+	// checks it once at startup
+	Interval time.Duration
+	// Rotate replaces an expiring token with a new one
+	Rotate bool
+	// RotationTTL is the lifetime of rotated tokens; 0 never expires them
+	RotationTTL time.Duration
+	// Admin, when set, reads and rotates the service account's tokens in
+	// place of the token itself, which often lacks serviceaccounts:read
+	Admin *grafana.Client
+	// Save stores a rotated token where the server reads it at startup.
+	// Rotation is skipped without it, as a restart would use a revoked token.
+	Save func(key string) error
+}
+
+// DefaultTokenExpirySettings returns the settings used when none are
+// configured
+func DefaultTokenExpirySettings() TokenExpirySettings {
+	return TokenExpirySettings{
+		Warning:     14 * 24 * time.Hour,
+		Interval:    12 * time.Hour,
+		RotationTTL: 90 * 24 * time.Hour,
+	}
+}
+
+// WithTokenExpiry checks the token's expiry in the background once
+// StartTokenMonitor has run, logging a warning as it nears
+func WithTokenExpiry(s TokenExpirySettings) Option {
+	return func(r *Registry) {
+		r.tokens = &tokenMonitor{settings: s}
+	}
+}
+
+// tokenMonitor holds the latest token check and rotation
+type tokenMonitor struct {
+	settings TokenExpirySettings
+
+	mu   sync.Mutex
+	last *tokenStatus
+	// tokenID is the token the server rotated to, known to be its own
+	tokenID      int64
+	lastRotation *tokenRotation
+	stop, done   chan struct{}
+}
+
+// tokenStatus is the outcome of one check
+type tokenStatus struct {
+	Status           string                       `json:"status"`
+	ServiceAccount   string                       `json:"service_account,omitempty"`
+	ServiceAccountID int64                        `json:"service_account_id,omitempty"`
+	Token            *grafana.ServiceAccountToken `json:"token,omitempty"`
+	ExpiresIn        string                       `json:"expires_in,omitempty"`
+	// IdentifiedBy says how the token was told apart from the service
+	// account's others: only_token, rotation, or most_recently_used
+	IdentifiedBy string    `json:"identified_by,omitempty"`
+	OtherTokens  int       `json:"other_tokens,omitempty"`
+	Reason       string    `json:"reason,omitempty"`
+	Checked      time.Time `json:"checked"`
+}
+
+// tokenRotation records a rotation attempt
+type tokenRotation struct {
+	Time    time.Time `json:"time"`
+	TokenID int64     `json:"token_id,omitempty"`
+	Name    string    `json:"name,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func (r *Registry) grafanaCheckTokenExpiryTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_check_token_expiry",
+		Description: "Check when the service account token the server uses expires, whether that is within the warning window, and the state of automatic rotation",
+		InputSchema: mcp.InputSchema{
+			Type:       "object",
+			Properties: map[string]mcp.Property{},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) handleCheckTokenExpiry(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	m := r.tokens
+	if m == nil {
+		m = &tokenMonitor{settings: DefaultTokenExpirySettings()}
+	}
+	st := r.checkToken(r.ctx, m)
+	m.mu.Lock()
+	m.last = st
+	rotation := map[string]interface{}{"enabled": m.settings.Rotate && m.settings.Save != nil}
+	if m.settings.Rotate && m.settings.Save == nil {
+		rotation["reason"] = "the token is not stored where a rotated one could be saved"
+	}
+	if m.lastRotation != nil {
+		rotation["last"] = m.lastRotation
+	}
+	m.mu.Unlock()
+
+	return jsonResult(map[string]interface{}{
+		"token":          st,
+		"warning_window": m.settings.Warning.String(),
+		"rotation":       rotation,
+	})
+}
+
+// StartTokenMonitor checks the token now and then every interval, rotating
+// it when configured to. It does nothing unless WithTokenExpiry was given.
+func (r *Registry) StartTokenMonitor() {
+	m := r.tokens
+	if m == nil || m.stop != nil {
+		return
+	}
+	m.stop, m.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(m.done)
+		var tick <-chan time.Time
+		if m.settings.Interval > 0 {
+			t := time.NewTicker(m.settings.Interval)
+			defer t.Stop()
+			tick = t.C
+		}
+		for {
+			r.monitorToken(m)
+			select {
+			case <-m.stop:
+				return
+			case <-tick:
+			}
+		}
+	}()
+}
+
+// StopTokenMonitor stops background checks and waits for one in flight
+func (r *Registry) StopTokenMonitor() {
+	m := r.tokens
+	if m == nil || m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+}
+
+// monitorToken runs one background check, logging what changed
+func (r *Registry) monitorToken(m *tokenMonitor) {
+	ctx := context.Background()
+	st := r.checkToken(ctx, m)
+	m.mu.Lock()
+	prev := m.last
+	m.last = st
+	m.mu.Unlock()
+
+	switch st.Status {
+	case tokenExpiring, tokenExpired:
+		log.Printf("Warning: the Grafana token %q of service account %s expires in %s (%s)", st.Token.Name, st.ServiceAccount, st.ExpiresIn, st.Token.Expiration.Format(time.RFC3339))
+	case tokenUnknown:
+		if prev == nil || prev.Status != tokenUnknown {
+			log.Printf("Warning: could not check the Grafana token's expiry: %s", st.Reason)
+		}
+	}
+
+	if st.Status != tokenExpiring || !m.settings.Rotate {
+		return
+	}
+	if m.settings.Save == nil {
+		log.Println("Warning: not rotating the Grafana token: it was not stored with `grafana-mcp login`, so a new token could not be saved")
+		return
+	}
+	rot := r.rotateToken(ctx, m, st)
+	m.mu.Lock()
+	m.lastRotation = rot
+	m.mu.Unlock()
+	switch {
+	case rot.TokenID == 0:
+		log.Printf("Warning: rotating the Grafana token failed: %s", rot.Error)
+	case rot.Error != "":
+		log.Printf("Rotated the Grafana token of service account %s to %q; warning: %s", st.ServiceAccount, rot.Name, rot.Error)
+	default:
+		log.Printf("Rotated the Grafana token of service account %s to %q", st.ServiceAccount, rot.Name)
+	}
+}
+
+// checkToken finds the token the server authenticates with among its
+// service account's tokens and reports how close it is to expiry
+func (r *Registry) checkToken(ctx context.Context, m *tokenMonitor) *tokenStatus {
+	st := &tokenStatus{Status: tokenUnknown, Checked: time.Now().UTC()}
+	user, err := r.client.GetCurrentUser(ctx)
+	if err != nil {
+		st.Reason = fmt.Sprintf("could not identify the credentials: %v", err)
+		return st
+	}
+	st.ServiceAccount, st.ServiceAccountID = user.Login, user.ID
+
+	lookup := r.client
+	if m.settings.Admin != nil {
+		lookup = m.settings.Admin
+	}
+	tokens, err := lookup.GetServiceAccountTokens(ctx, user.ID)
+	if err != nil {
+		var apiErr *grafana.APIError
+		switch {
+		case errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound:
+			st.Status, st.ServiceAccount, st.ServiceAccountID = tokenNotApplicable, "", 0
+			st.Reason = fmt.Sprintf("the credentials belong to %s, which is not a service account", user.Login)
+		case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden):
+			st.Reason = "the credentials cannot read their service account's tokens, which needs serviceaccounts:read; configure token.admin_token"
+		default:
+			st.Reason = fmt.Sprintf("could not list the service account's tokens: %v", err)
+		}
+		return st
+	}
+
+	var live []grafana.ServiceAccountToken
+	for _, t := range tokens {
+		if !t.HasExpired && !t.IsRevoked {
+			live = append(live, t)
+		}
+	}
+	m.mu.Lock()
+	rotated := m.tokenID
+	m.mu.Unlock()
+
+	var current *grafana.ServiceAccountToken
+	for i := range live {
+		if rotated != 0 && live[i].ID == rotated {
+			current, st.IdentifiedBy = &live[i], "rotation"
+		}
+	}
+	if current == nil && len(live) == 1 {
+		current, st.IdentifiedBy = &live[0], "only_token"
+	}
+	if current == nil {
+		// Grafana does not say which token authenticated a request; the
+		// one just used is the best guess
+		for i := range live {
+			if live[i].LastUsedAt != nil && (current == nil || current.LastUsedAt.Before(*live[i].LastUsedAt)) {
+				current, st.IdentifiedBy = &live[i], "most_recently_used"
+			}
+		}
+	}
+	if current == nil {
+		st.Reason = fmt.Sprintf("could not tell which of the %d tokens of %s the server uses", len(live), user.Login)
+		return st
+	}
+	st.Token, st.OtherTokens = current, len(live)-1
+
+	if current.Expiration == nil {
+		st.Status = tokenNoExpiry
+		return st
+	}
+	left := time.Until(*current.Expiration)
+	st.ExpiresIn = left.Round(time.Minute).String()
+	switch {
+	case left <= 0:
+		st.Status = tokenExpired
+	case left <= m.settings.Warning:
+		st.Status = tokenExpiring
+	default:
+		st.Status = tokenOK
+	}
+	return st
+}
+
+// rotateToken creates a token for the service account, saves it, switches
+// the client to it, and revokes the old token when it is known to be the
+// server's own
+func (r *Registry) rotateToken(ctx context.Context, m *tokenMonitor, st *tokenStatus) *tokenRotation {
+	rot := &tokenRotation{Time: time.Now().UTC()}
+	lookup := r.client
+	if m.settings.Admin != nil {
+		lookup = m.settings.Admin
+	}
+
+	name := "grafana-mcp-" + rot.Time.Format("20060102-150405")
+	created, err := lookup.CreateServiceAccountToken(ctx, st.ServiceAccountID, name, m.settings.RotationTTL)
+	if err != nil {
+		rot.Error = fmt.Sprintf("creating a token: %v", err)
+		return rot
+	}
+	if err := m.settings.Save(created.Key); err != nil {
+		rot.Error = fmt.Sprintf("saving the new token: %v", err)
+		if err := lookup.DeleteServiceAccountToken(ctx, st.ServiceAccountID, created.ID); err != nil {
+			rot.Error += fmt.Sprintf("; revoking it again also failed, revoke token %q by hand: %v", name, err)
+		}
+		return rot
+	}
+	r.client.SetAPIKey(created.Key)
+	rot.TokenID, rot.Name = created.ID, created.Name
+	m.mu.Lock()
+	m.tokenID = created.ID
+	m.mu.Unlock()
+
+	if st.IdentifiedBy == "most_recently_used" {
+		log.Printf("Warning: not revoking the previous Grafana token %q, as it is not certain to be the one replaced", st.Token.Name)
+		return rot
+	}
+	if err := lookup.DeleteServiceAccountToken(ctx, st.ServiceAccountID, st.Token.ID); err != nil {
+		rot.Error = fmt.Sprintf("the new token is in use, but revoking the old token %q failed: %v", st.Token.Name, err)
+	}
+	return rot
+}
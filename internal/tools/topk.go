@@ -0,0 +1,51 @@
+package tools
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// promQLTypes are datasource types that accept PromQL and so support
+// server-side topk()/bottomk()
+var promQLTypes = map[string]bool{
+	"prometheus":                          true,
+	"grafana-amazonprometheus-datasource": true,
+	"grafana-azureprometheus-datasource":  true,
+}
+
+var alreadyRanked = regexp.MustCompile(`^\s*(topk|bottomk)\s*\(`)
+
+// topKOptions limits a query to its most significant series
+type topKOptions struct {
+	K      int
+	By     string
+	Bottom bool
+}
+
+// wrapTopK wraps a PromQL expression in topk() or bottomk() so the server
+// returns fewer series. Expressions that already rank are left alone.
+func (o topKOptions) wrapTopK(query string) (string, bool) {
+	if alreadyRanked.MatchString(query) {
+		return query, false
+	}
+	fn := "topk"
+	if o.Bottom {
+		fn = "bottomk"
+	}
+	return fmt.Sprintf("%s(%d, %s)", fn, o.K, query), true
+}
+
+// filter keeps the K most significant series of every result. topk()
+// selects per step, so a range query can still return more than K series
+// in total; filtering afterwards enforces the limit either way.
+func (o topKOptions) filter(resp *grafana.QueryResponse) map[string]int {
+	totals := make(map[string]int, len(resp.Results))
+	for refID, res := range resp.Results {
+		res.Frames, totals[refID] = analysis.TopKFrames(res.Frames, o.K, o.By, o.Bottom)
+		resp.Results[refID] = res
+	}
+	return totals
+}
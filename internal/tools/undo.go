@@ -0,0 +1,384 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+)
+
+// undoJournalSize is the most changes the undo journal keeps
+const undoJournalSize = 100
+
+// undoMessage is the version message of dashboards grafana_undo restores
+const undoMessage = "Restored by grafana_undo"
+
+// undoEntry is an object as it was before a tool call changed or deleted it
+type undoEntry struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Session  string    `json:"session,omitempty"`
+	Instance string    `json:"instance"`
+	Tool     string    `json:"tool"`
+	Kind     string    `json:"kind"`
+	UID      string    `json:"uid"`
+	Title    string    `json:"title,omitempty"`
+	// Deleted is set when the call removed the object
+	Deleted bool `json:"deleted,omitempty"`
+	// Snapshot is the object as Grafana returned it before the call
+	Snapshot json.RawMessage `json:"snapshot"`
+}
+
+// undoJournal records the objects write calls change, so grafana_undo can
+// put them back. Entries are also kept in the state store, so a change
+// made before a restart can be undone after it.
+type undoJournal struct {
+	state *state.Store
+	mu    sync.Mutex
+	// entries are oldest first; nil until loaded from the state store
+	entries []undoEntry
+}
+
+func newUndoJournal(st *state.Store) *undoJournal {
+	st.SetLimit(state.BucketUndo, undoJournalSize)
+	return &undoJournal{state: st}
+}
+
+// load reads the entries from the state store on first use; callers hold
+// j.mu
+func (j *undoJournal) load() {
+	if j.entries != nil {
+		return
+	}
+	j.entries = []undoEntry{}
+	ids, err := j.state.Keys(state.BucketUndo)
+	if err != nil {
+		log.Printf("Warning: could not restore the undo journal: %v", err)
+		return
+	}
+	for _, id := range ids {
+		var e undoEntry
+		if ok, err := j.state.Get(state.BucketUndo, id, &e); err != nil {
+			log.Printf("Warning: could not restore undo entry %s: %v", id, err)
+		} else if ok {
+			j.entries = append(j.entries, e)
+		}
+	}
+	sort.SliceStable(j.entries, func(a, b int) bool { return j.entries[a].Time.Before(j.entries[b].Time) })
+}
+
+func (j *undoJournal) add(e undoEntry) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+	j.entries = append(j.entries, e)
+	if len(j.entries) > undoJournalSize {
+		j.entries = j.entries[len(j.entries)-undoJournalSize:]
+	}
+	if err := j.state.Put(state.BucketUndo, e.ID, e, 0); err != nil {
+		log.Printf("Warning: could not save undo entry: %v", err)
+	}
+}
+
+// list returns the entries, newest first
+func (j *undoJournal) list() []undoEntry {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+	out := make([]undoEntry, len(j.entries))
+	for i, e := range j.entries {
+		out[len(out)-1-i] = e
+	}
+	return out
+}
+
+func (j *undoJournal) remove(id string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.load()
+	for i, e := range j.entries {
+		if e.ID == id {
+			j.entries = append(j.entries[:i:i], j.entries[i+1:]...)
+			break
+		}
+	}
+	if err := j.state.Delete(state.BucketUndo, id); err != nil {
+		log.Printf("Warning: could not remove undo entry %s: %v", id, err)
+	}
+}
+
+// undoTargets returns the kind and uid of each object a write call names
+// in its uid or uids argument that the journal can restore: dashboards,
+// folders, and alert rules. Datasources are left out because Grafana does
+// not return their secrets, so a snapshot could not restore them.
+func undoTargets(tool string, args map[string]interface{}) [][2]string {
+	kind := toolRefKind(tool)
+	if kind == "" {
+		kind = refDashboard
+	}
+	if kind != refDashboard && kind != refFolder && kind != refAlertRule {
+		return nil
+	}
+	uids := getStringSlice(args, "uids")
+	if uid := getString(args, "uid"); uid != "" {
+		uids = append(uids, uid)
+	}
+	var out [][2]string
+	seen := map[string]bool{}
+	for _, uid := range uids {
+		if uid != "" && !seen[uid] {
+			seen[uid] = true
+			out = append(out, [2]string{kind, uid})
+		}
+	}
+	return out
+}
+
+// undoSnapshots returns the objects a write call is about to change, as
+// they are before it runs. Objects that do not exist yet are skipped.
+func (r *Registry) undoSnapshots(tool string, args map[string]interface{}) []undoEntry {
+	if r.readOnly[tool] || tool == batchToolName || tool == undoToolName {
+		return nil
+	}
+	var out []undoEntry
+	for _, t := range undoTargets(tool, args) {
+		snap, title, err := r.undoSnapshot(t[0], t[1])
+		if err != nil {
+			if !isNotFound(err) {
+				log.Printf("Warning: could not snapshot %s %s for undo: %v", t[0], t[1], err)
+			}
+			continue
+		}
+		out = append(out, undoEntry{
+			Session:  sessionFrom(r.ctx),
+			Instance: r.instanceName,
+			Tool:     tool,
+			Kind:     t[0],
+			UID:      t[1],
+			Title:    title,
+			Snapshot: snap,
+		})
+	}
+	return out
+}
+
+// recordUndo journals the snapshots of objects the call changed or deleted
+func (r *Registry) recordUndo(before []undoEntry) {
+	for _, e := range before {
+		after, _, err := r.undoSnapshot(e.Kind, e.UID)
+		switch {
+		case isNotFound(err):
+			e.Deleted = true
+		case err == nil && bytes.Equal(after, e.Snapshot):
+			continue
+		}
+		e.ID = newCursor()
+		e.Time = time.Now().UTC()
+		r.journal.add(e)
+	}
+}
+
+// undoSnapshot returns an object as Grafana has it, with its title
+func (r *Registry) undoSnapshot(kind, uid string) (json.RawMessage, string, error) {
+	var v interface{}
+	var title string
+	switch kind {
+	case refDashboard:
+		d, err := r.client.GetDashboardJSON(r.ctx, uid)
+		if err != nil {
+			return nil, "", err
+		}
+		v, title = d, fmt.Sprint(d.Dashboard["title"])
+	case refFolder:
+		f, err := r.client.GetFolder(r.ctx, uid)
+		if err != nil {
+			return nil, "", err
+		}
+		v, title = f, f.Title
+	case refAlertRule:
+		rule, err := r.client.GetAlertRule(r.ctx, uid)
+		if err != nil {
+			return nil, "", err
+		}
+		v, title = rule, rule.Title
+	default:
+		return nil, "", fmt.Errorf("cannot snapshot a %s", kind)
+	}
+	data, err := json.Marshal(v)
+	return data, title, err
+}
+
+// restore puts an object back as the entry's snapshot has it, recreating
+// it if it was deleted
+func (r *Registry) restore(e undoEntry) error {
+	switch e.Kind {
+	case refDashboard:
+		var d grafana.DashboardJSON
+		if err := json.Unmarshal(e.Snapshot, &d); err != nil {
+			return err
+		}
+		// The id of a deleted dashboard is gone; Grafana matches on the uid
+		delete(d.Dashboard, "id")
+		_, err := r.client.SaveDashboardJSON(r.ctx, d.Dashboard, d.Meta.FolderUID, undoMessage, true)
+		return err
+	case refFolder:
+		var f grafana.Folder
+		if err := json.Unmarshal(e.Snapshot, &f); err != nil {
+			return err
+		}
+		current, err := r.client.GetFolder(r.ctx, f.UID)
+		if isNotFound(err) {
+			_, err = r.client.CreateFolder(r.ctx, f.Title, f.UID)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		_, err = r.client.UpdateFolder(r.ctx, f.UID, f.Title, current.Version)
+		return err
+	case refAlertRule:
+		var rule grafana.AlertRule
+		if err := json.Unmarshal(e.Snapshot, &rule); err != nil {
+			return err
+		}
+		_, err := r.client.GetAlertRule(r.ctx, rule.UID)
+		if isNotFound(err) {
+			rule.ID = 0
+			_, err = r.client.CreateAlertRule(r.ctx, rule)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		_, err = r.client.UpdateAlertRule(r.ctx, rule.UID, rule)
+		return err
+	}
+	return fmt.Errorf("cannot restore a %s", e.Kind)
+}
+
+// undoToolName is the tool that restores journaled objects; its own writes
+// are not journaled
+const undoToolName = "grafana_undo"
+
+func (r *Registry) grafanaListUndoTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_list_undo",
+		Description: "List the changes grafana_undo can revert, newest first: dashboards, folders, and alert rules that tool calls updated or deleted, with the tool, time, and session that changed them",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"limit": {Type: "integer", Description: "Maximum entries to return (default 20)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaUndoTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        undoToolName,
+		Description: "Revert a change a tool call made to a dashboard, folder, or alert rule, putting the object back as it was before the call and recreating it if the call deleted it. Without an id, reverts this session's most recent change. Restoring a deleted folder does not bring back its contents",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"id": {Type: "string", Description: "Entry to revert, from grafana_list_undo (default: this session's latest)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// undoSummary is an undo entry without its snapshot
+type undoSummary struct {
+	ID       string    `json:"id"`
+	Time     time.Time `json:"time"`
+	Instance string    `json:"instance"`
+	Tool     string    `json:"tool"`
+	Kind     string    `json:"kind"`
+	UID      string    `json:"uid"`
+	Title    string    `json:"title,omitempty"`
+	Deleted  bool      `json:"deleted,omitempty"`
+	// Mine is set for changes made by the calling session
+	Mine bool `json:"this_session,omitempty"`
+}
+
+func summarizeUndo(e undoEntry, session string) undoSummary {
+	return undoSummary{
+		ID:       e.ID,
+		Time:     e.Time,
+		Instance: e.Instance,
+		Tool:     e.Tool,
+		Kind:     e.Kind,
+		UID:      e.UID,
+		Title:    e.Title,
+		Deleted:  e.Deleted,
+		Mine:     session != "" && e.Session == session,
+	}
+}
+
+func (r *Registry) handleListUndo(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	limit := int(getInt64(args, "limit"))
+	if limit <= 0 {
+		limit = 20
+	}
+	entries := r.journal.list()
+	if len(entries) > limit {
+		entries = entries[:limit]
+	}
+	session := sessionFrom(r.ctx)
+	out := make([]undoSummary, len(entries))
+	for i, e := range entries {
+		out[i] = summarizeUndo(e, session)
+	}
+	return jsonResult(map[string]interface{}{"changes": out})
+}
+
+func (r *Registry) handleUndo(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	id := getString(args, "id")
+	session := sessionFrom(r.ctx)
+	var entry *undoEntry
+	for _, e := range r.journal.list() {
+		if (id != "" && e.ID == id) || (id == "" && e.Session == session) {
+			entry = &e
+			break
+		}
+	}
+	if entry == nil {
+		if id != "" {
+			return errorResult(fmt.Sprintf("undo entry %q not found; see grafana_list_undo", id)), nil
+		}
+		return errorResult("this session has no changes to undo; pass an id from grafana_list_undo"), nil
+	}
+
+	target := r
+	if entry.Instance != r.instanceName {
+		reg, ok := r.instances.lookup(entry.Instance)
+		if !ok {
+			return errorResult(fmt.Sprintf("instance %q of undo entry %s is no longer configured", entry.Instance, entry.ID)), nil
+		}
+		target = reg.forCall(r.ctx)
+	}
+	if err := target.restore(*entry); err != nil {
+		return apiErrorResult(fmt.Sprintf("Failed to restore %s %s", entry.Kind, entry.UID), err), nil
+	}
+	if target != r {
+		target.invalidateInventory()
+	}
+	r.journal.remove(entry.ID)
+	return jsonResult(map[string]interface{}{
+		"status":   "restored",
+		"restored": summarizeUndo(*entry, session),
+	})
+}
@@ -0,0 +1,114 @@
+package tools_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/state"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+type undoList struct {
+	Changes []struct {
+		ID      string `json:"id"`
+		Tool    string `json:"tool"`
+		Kind    string `json:"kind"`
+		UID     string `json:"uid"`
+		Title   string `json:"title"`
+		Deleted bool   `json:"deleted"`
+		Mine    bool   `json:"this_session"`
+	} `json:"changes"`
+}
+
+func TestUndoRevertsUpdatesAndDeletes(t *testing.T) {
+	h := testkit.New(t)
+	folder := h.AddFolder(grafana.Folder{Title: "Team"})
+	dash := h.AddDashboard(map[string]interface{}{
+		"title":  "Before",
+		"panels": []interface{}{map[string]interface{}{"type": "timeseries", "targets": []interface{}{map[string]interface{}{"expr": `up{job="api"}`}}}},
+	}, folder)
+	rule := h.AddAlertRule(grafana.AlertRule{Title: "High latency", FolderUID: folder, RuleGroup: "api"})
+
+	h.Call("grafana_update_dashboard", map[string]interface{}{"uid": dash, "title": "After"}).OK()
+	h.Call("grafana_delete_alert_rule", map[string]interface{}{"uid": rule}).OK()
+	// Writes that change nothing, or fail, are not journaled
+	h.Call("grafana_templatize_dashboard", map[string]interface{}{"uid": dash, "dry_run": true}).OK()
+	h.Call("grafana_update_dashboard", map[string]interface{}{"uid": "missing", "title": "After"}).Error("")
+
+	var list undoList
+	h.Call("grafana_list_undo", nil).OK().JSON(&list)
+	if len(list.Changes) != 2 {
+		t.Fatalf("journal = %+v, want the rule delete and the dashboard update", list.Changes)
+	}
+	latest := list.Changes[0]
+	if latest.Kind != "alert_rule" || latest.UID != rule || !latest.Deleted || !latest.Mine {
+		t.Fatalf("latest change = %+v, want this session's delete of rule %s", latest, rule)
+	}
+	h.NewSession().Call("grafana_undo", nil).Error("no changes to undo")
+
+	// Undo works newest first: the rule comes back, then the dashboard title
+	h.Call("grafana_undo", nil).OK()
+	if rules := h.AlertRules(); len(rules) != 1 || rules[0].UID != rule || rules[0].Title != "High latency" {
+		t.Fatalf("rules after undo = %+v, want %s restored", rules, rule)
+	}
+	h.Call("grafana_undo", nil).OK()
+	model, folderUID, ok := h.Dashboard(dash)
+	if !ok || model["title"] != "Before" || folderUID != folder {
+		t.Fatalf("dashboard after undo = %v in folder %q, want title Before in %s", model, folderUID, folder)
+	}
+	h.Call("grafana_undo", nil).Error("no changes to undo")
+}
+
+func TestUndoRecreatesDeletedFolder(t *testing.T) {
+	h := testkit.New(t)
+	folder := h.AddFolder(grafana.Folder{Title: "Team"})
+	h.Call("grafana_delete_folder", map[string]interface{}{"uid": folder}).OK()
+
+	var list undoList
+	h.Call("grafana_list_undo", nil).OK().JSON(&list)
+	if len(list.Changes) != 1 {
+		t.Fatalf("journal = %+v, want the folder delete", list.Changes)
+	}
+	h.NewSession().Call("grafana_undo", map[string]interface{}{"id": list.Changes[0].ID}).OK()
+	if f, ok := h.Folder(folder); !ok || f.Title != "Team" {
+		t.Fatalf("folder after undo = %+v (found %v), want Team", f, ok)
+	}
+}
+
+func TestUndoJournalSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	st, err := state.Open(dir)
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithStateStore(st)))
+	dash := h.AddDashboard(map[string]interface{}{"title": "Before"}, "")
+	h.Call("grafana_delete_dashboard", map[string]interface{}{"uid": dash}).OK()
+
+	reopened, err := state.Open(dir)
+	if err != nil {
+		t.Fatalf("state.Open: %v", err)
+	}
+	after := tools.NewRegistry(h.Client(), nil, tools.WithStateStore(reopened))
+	res, err := after.CallTool(tools.WithSession(context.Background(), "s1"), "grafana_list_undo", map[string]interface{}{})
+	if err != nil || res.IsError {
+		t.Fatalf("list undo after restart: %v %+v", err, res)
+	}
+	var list undoList
+	if err := json.Unmarshal([]byte(res.Content[0].Text), &list); err != nil {
+		t.Fatalf("decoding %s: %v", res.Content[0].Text, err)
+	}
+	if len(list.Changes) != 1 || list.Changes[0].UID != dash {
+		t.Fatalf("journal after restart = %+v, want the delete of %s", list.Changes, dash)
+	}
+	res, err = after.CallTool(context.Background(), "grafana_undo", map[string]interface{}{"id": list.Changes[0].ID})
+	if err != nil || res.IsError {
+		t.Fatalf("undo after restart: %v %+v", err, res)
+	}
+	if model, _, ok := h.Dashboard(dash); !ok || model["title"] != "Before" {
+		t.Fatalf("dashboard after undo = %v (found %v), want Before", model, ok)
+	}
+}
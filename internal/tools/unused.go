@@ -0,0 +1,297 @@
+package tools
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	defaultArchiveFolder = "Archive"
+	defaultArchiveTag    = "archived"
+	unusedScanLimit      = 5000
+	unusedScanWorkers    = 4
+)
+
+func (r *Registry) grafanaFindUnusedDashboardsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_find_unused_dashboards",
+		Description: "List dashboards not viewed (Enterprise usage insights) or not updated (OSS metadata) in the last N days, as candidates for archiving",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"days":       {Type: "integer", Description: "Inactivity threshold in days (default 90)"},
+				"folder_uid": {Type: "string", Description: "Only scan dashboards in this folder"},
+				"limit":      {Type: "integer", Description: "Maximum number of dashboards to return (default 100)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+func (r *Registry) grafanaArchiveDashboardsTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_archive_dashboards",
+		Description: "Move dashboards into an archive folder and tag them, as a reversible alternative to deletion. The folder is created if it does not exist",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uids":         {Type: "array", Description: "Dashboard UIDs to archive"},
+				"folder_title": {Type: "string", Description: "Archive folder title (default Archive)"},
+				"tag":          {Type: "string", Description: "Tag added to archived dashboards (default archived)"},
+				"dry_run":      {Type: "boolean", Description: "Report what would change without saving"},
+			},
+			Required: []string{"uids"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: false,
+			IdempotentHint:  true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+// unusedDashboard is a dashboard whose last activity is older than the cutoff
+type unusedDashboard struct {
+	UID          string `json:"uid"`
+	Title        string `json:"title"`
+	FolderTitle  string `json:"folder_title,omitempty"`
+	URL          string `json:"url"`
+	LastActivity string `json:"last_activity,omitempty"`
+	Source       string `json:"source"`
+	InactiveDays int    `json:"inactive_days"`
+}
+
+func (r *Registry) handleFindUnusedDashboards(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	days := getInt(args, "days")
+	if days <= 0 {
+		days = 90
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = 100
+	}
+	cutoff := time.Now().AddDate(0, 0, -days)
+
+	q := grafana.SearchQuery{Type: "dash-db", Limit: unusedScanLimit}
+	if folderUID := getString(args, "folder_uid"); folderUID != "" {
+		q.FolderUIDs = []string{folderUID}
+	}
+
+	// Oldest views first so the stale dashboards are not cut off by the limit
+	q.Sort = r.viewSortOption(true)
+	hits, err := r.client.Search(r.ctx, q)
+	if err != nil {
+		return apiErrorResult("Search failed", err), nil
+	}
+
+	var unused []unusedDashboard
+	source := "last_updated"
+	if q.Sort != "" {
+		source = "usage_insights"
+		unused = unusedByViews(hits, cutoff)
+	} else {
+		unused = r.unusedByUpdated(hits, cutoff)
+	}
+
+	sort.SliceStable(unused, func(i, j int) bool { return unused[i].InactiveDays > unused[j].InactiveDays })
+	total := len(unused)
+	if len(unused) > limit {
+		unused = unused[:limit]
+	}
+
+	return jsonResult(map[string]interface{}{
+		"days":       days,
+		"source":     source,
+		"total":      total,
+		"dashboards": unused,
+	})
+}
+
+// viewSortOption returns the Enterprise "last viewed" search sort if the
+// instance offers one, or "" on OSS. The order asked for is preferred when
+// the instance offers both.
+func (r *Registry) viewSortOption(oldestFirst bool) string {
+	options, err := r.client.GetSearchSorting(r.ctx)
+	if err != nil {
+		return ""
+	}
+	want := "-desc"
+	if oldestFirst {
+		want = "-asc"
+	}
+	match := ""
+	for _, o := range options {
+		if !strings.Contains(o.Name, "viewed-recently") && !strings.Contains(o.Name, "views-recent") {
+			continue
+		}
+		if strings.HasSuffix(o.Name, want) {
+			return o.Name
+		}
+		match = o.Name
+	}
+	return match
+}
+
+func unusedByViews(hits []grafana.SearchDashboardsResponse, cutoff time.Time) []unusedDashboard {
+	var out []unusedDashboard
+	for _, h := range hits {
+		last := time.Unix(h.SortMeta, 0)
+		if h.SortMeta > 0 && last.After(cutoff) {
+			continue
+		}
+		u := unusedDashboard{
+			UID:          h.UID,
+			Title:        h.Title,
+			FolderTitle:  h.FolderTitle,
+			URL:          h.URL,
+			Source:       "usage_insights",
+			InactiveDays: int(time.Since(last).Hours() / 24),
+		}
+		if h.SortMeta > 0 {
+			u.LastActivity = last.UTC().Format(time.RFC3339)
+		}
+		out = append(out, u)
+	}
+	return out
+}
+
+// unusedByUpdated fetches each dashboard's metadata and compares its last
+// update time against the cutoff.
+func (r *Registry) unusedByUpdated(hits []grafana.SearchDashboardsResponse, cutoff time.Time) []unusedDashboard {
+	var (
+		mu  sync.Mutex
+		out []unusedDashboard
+		wg  sync.WaitGroup
+	)
+	sem := make(chan struct{}, unusedScanWorkers)
+	for _, h := range hits {
+		wg.Add(1)
+		go func(h grafana.SearchDashboardsResponse) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			dash, err := r.client.GetDashboardJSON(r.ctx, h.UID)
+			if err != nil {
+				return
+			}
+			updated, err := time.Parse(time.RFC3339, dash.Meta.Updated)
+			if err != nil || updated.After(cutoff) {
+				return
+			}
+			mu.Lock()
+			out = append(out, unusedDashboard{
+				UID:          h.UID,
+				Title:        h.Title,
+				FolderTitle:  h.FolderTitle,
+				URL:          h.URL,
+				LastActivity: updated.UTC().Format(time.RFC3339),
+				Source:       "last_updated",
+				InactiveDays: int(time.Since(updated).Hours() / 24),
+			})
+			mu.Unlock()
+		}(h)
+	}
+	wg.Wait()
+	return out
+}
+
+func (r *Registry) handleArchiveDashboards(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	uids := getStringSlice(args, "uids")
+	if len(uids) == 0 {
+		return errorResult("uids is required"), nil
+	}
+	folderTitle := getString(args, "folder_title")
+	if folderTitle == "" {
+		folderTitle = defaultArchiveFolder
+	}
+	tag := getString(args, "tag")
+	if tag == "" {
+		tag = defaultArchiveTag
+	}
+	dryRun := getBool(args, "dry_run")
+
+	folder, err := r.findFolderByTitle(folderTitle)
+	if err != nil {
+		return apiErrorResult("Failed to list folders", err), nil
+	}
+	if folder == nil && !dryRun {
+		folder, err = r.client.CreateFolder(r.ctx, folderTitle, "")
+		if err != nil {
+			return apiErrorResult("Failed to create archive folder", err), nil
+		}
+	}
+
+	result := newBulkResult(dryRun)
+	result.Meta = map[string]interface{}{"folder": folderTitle, "tag": tag}
+	for _, uid := range uids {
+		dash, err := r.client.GetDashboardJSON(r.ctx, uid)
+		if err != nil {
+			result.Fail(uid, "", err.Error(), nil)
+			continue
+		}
+		title, _ := dash.Dashboard["title"].(string)
+		detail := map[string]interface{}{"from_folder": dash.Meta.FolderTitle}
+		if folder != nil && dash.Meta.FolderUID == folder.UID && hasTag(dash.Dashboard, tag) {
+			result.Skip(uid, title, "already archived", detail)
+			continue
+		}
+		if dryRun {
+			result.Succeed(uid, title, "would_archive", detail)
+			continue
+		}
+
+		addTag(dash.Dashboard, tag)
+		saved, err := r.client.SaveDashboardJSON(r.ctx, dash.Dashboard, folder.UID, "Archived via MCP", false)
+		if err != nil {
+			result.Fail(uid, title, err.Error(), detail)
+			continue
+		}
+		detail["version"] = saved.Version
+		result.Succeed(uid, title, "archived", detail)
+	}
+
+	return bulkResult(result)
+}
+
+// findFolderByTitle returns the folder with an exact (case-insensitive)
+// title match, or nil if there is none.
+func (r *Registry) findFolderByTitle(title string) (*grafana.Folder, error) {
+	folders, err := r.client.GetFolders(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range folders {
+		if strings.EqualFold(folders[i].Title, title) {
+			return &folders[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func hasTag(dash map[string]interface{}, tag string) bool {
+	tags, _ := dash["tags"].([]interface{})
+	for _, t := range tags {
+		if s, ok := t.(string); ok && s == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func addTag(dash map[string]interface{}, tag string) bool {
+	if hasTag(dash, tag) {
+		return false
+	}
+	tags, _ := dash["tags"].([]interface{})
+	dash["tags"] = append(tags, tag)
+	return true
+}
@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"github.com/npcomplete777/grafana-mcp/internal/dashboard"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const defaultMaxDiffEntries = 200
+
+// builtinDatasources are the pseudo datasources that are not returned by the
+// datasource API but appear by name in legacy dashboards
+var builtinDatasources = map[string][2]string{
+	"-- Grafana --":   {"grafana", "datasource"},
+	"-- Mixed --":     {"-- Mixed --", "datasource"},
+	"-- Dashboard --": {"-- Dashboard --", "datasource"},
+}
+
+func (r *Registry) grafanaUpgradeDashboardSchemaTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_upgrade_dashboard_schema",
+		Description: "Upgrade legacy dashboards to the current schemaVersion: converts graph to timeseries, singlestat to stat/gauge, table-old to table, legacy thresholds/legends, and datasource names to references. Dry run by default, returning a per-dashboard diff",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"uids":             {Type: "array", Description: "Dashboard UIDs to upgrade (default: all matching the filters)"},
+				"folder_uid":       {Type: "string", Description: "Only upgrade dashboards in this folder"},
+				"query":            {Type: "string", Description: "Only upgrade dashboards matching this search query"},
+				"tags":             {Type: "array", Description: "Only upgrade dashboards with these tags"},
+				"dry_run":          {Type: "boolean", Description: "Report changes and diffs without saving (default true)"},
+				"max_diff_entries": {Type: "integer", Description: "Maximum diff entries returned per dashboard in dry run (default 200)"},
+			},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			DestructiveHint: true,
+			OpenWorldHint:   true,
+		},
+	}
+}
+
+func (r *Registry) handleUpgradeDashboardSchema(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	dryRun := true
+	if v, ok := args["dry_run"].(bool); ok {
+		dryRun = v
+	}
+	maxDiff := getInt(args, "max_diff_entries")
+	if maxDiff <= 0 {
+		maxDiff = defaultMaxDiffEntries
+	}
+
+	dashboards, failed, err := r.collectDashboards(args)
+	if err != nil {
+		return apiErrorResult("Failed to collect dashboards", err), nil
+	}
+
+	resolve, err := r.datasourceResolver()
+	if err != nil {
+		return apiErrorResult("Failed to list datasources", err), nil
+	}
+
+	result := newBulkResult(dryRun)
+	result.Meta = map[string]interface{}{"target_version": dashboard.TargetSchemaVersion}
+	result.FailAll(failed)
+	for _, d := range dashboards {
+		before := dashboard.Clone(d.Model)
+		res := dashboard.Upgrade(d.Model, resolve)
+		detail := map[string]interface{}{"upgrade": res}
+		if !res.Changed() {
+			result.Skip(d.UID, d.Title, "up to date", nil)
+			continue
+		}
+
+		if dryRun {
+			diff := dashboard.Diff(before, d.Model)
+			detail["diff_entries"] = len(diff)
+			if len(diff) > maxDiff {
+				diff = diff[:maxDiff]
+			}
+			detail["diff"] = diff
+			result.Succeed(d.UID, d.Title, "would_upgrade", detail)
+			continue
+		}
+
+		saved, err := r.client.SaveDashboardJSON(r.ctx, d.Model, d.FolderUID, "Upgraded dashboard schema via MCP", false)
+		if err != nil {
+			result.Fail(d.UID, d.Title, err.Error(), detail)
+			continue
+		}
+		detail["version"] = saved.Version
+		result.Succeed(d.UID, d.Title, "upgraded", detail)
+	}
+
+	return bulkResult(result)
+}
+
+// datasourceResolver resolves legacy datasource names (or UIDs stored as
+// plain strings) to references using the instance's datasource list.
+func (r *Registry) datasourceResolver() (dashboard.DatasourceResolver, error) {
+	datasources, err := r.client.GetDatasources(r.ctx)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string][2]string, len(datasources)*2)
+	for _, ds := range datasources {
+		byName[ds.UID] = [2]string{ds.UID, ds.Type}
+	}
+	for _, ds := range datasources {
+		byName[ds.Name] = [2]string{ds.UID, ds.Type}
+	}
+	return func(name string) (string, string, bool) {
+		if ref, ok := builtinDatasources[name]; ok {
+			return ref[0], ref[1], true
+		}
+		ref, ok := byName[name]
+		return ref[0], ref[1], ok
+	}, nil
+}
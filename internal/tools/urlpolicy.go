@@ -0,0 +1,221 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// urlLookupTimeout bounds the DNS lookup of a URL's host
+const urlLookupTimeout = 5 * time.Second
+
+// URLPolicy restricts the hosts that URLs given to tools may point at, so
+// an agent cannot make Grafana reach internal endpoints through a
+// datasource or a contact point test. Deny rules win over allow rules;
+// without AllowHosts every host not denied is allowed.
+type URLPolicy struct {
+	// AllowHosts and DenyHosts are host names, with * matching any run of
+	// characters, e.g. "*.example.com". With AllowHosts set, other hosts
+	// are allowed only when they resolve into AllowCIDRs.
+	AllowHosts []string
+	DenyHosts  []string
+	// DenyCIDRs are refused address ranges. AllowCIDRs are exempt from
+	// DenyPrivate.
+	AllowCIDRs []*net.IPNet
+	DenyCIDRs  []*net.IPNet
+	// DenyPrivate refuses loopback, private, link-local (including cloud
+	// metadata endpoints), and unspecified addresses
+	DenyPrivate bool
+}
+
+// WithURLPolicy checks the URLs tools pass on to Grafana
+func WithURLPolicy(p URLPolicy) Option {
+	return func(r *Registry) {
+		r.urlPolicy = &p
+	}
+}
+
+// needsAddresses reports whether the deny rules depend on resolved
+// addresses
+func (p *URLPolicy) needsAddresses() bool {
+	return p.DenyPrivate || len(p.DenyCIDRs) > 0
+}
+
+// checkURL returns an error when the configured URL policy forbids raw,
+// a URL or a host:port
+func (r *Registry) checkURL(raw string) error {
+	return r.urlPolicy.Check(r.ctx, raw)
+}
+
+// Check returns an error when the policy forbids raw, a URL or a
+// host:port; a nil policy allows everything. Host names are resolved here,
+// which may differ from what Grafana resolves; a name that does not resolve
+// is refused when address rules apply.
+func (p *URLPolicy) Check(ctx context.Context, raw string) error {
+	raw = strings.TrimSpace(raw)
+	if p == nil || raw == "" {
+		return nil
+	}
+	host := urlHost(raw)
+	if host == "" {
+		return fmt.Errorf("url policy: cannot find the host in %q", raw)
+	}
+
+	for _, pattern := range p.DenyHosts {
+		if hostMatches(pattern, host) {
+			return fmt.Errorf("url policy: host %s is denied by configuration", host)
+		}
+	}
+
+	var addrs []net.IP
+	if p.needsAddresses() {
+		var err error
+		if addrs, err = lookupHost(ctx, host); err != nil {
+			return err
+		}
+	}
+	for _, ip := range addrs {
+		target := host
+		if !ip.Equal(net.ParseIP(host)) {
+			target = fmt.Sprintf("%s (%s)", host, ip)
+		}
+		switch {
+		case inCIDRs(p.DenyCIDRs, ip):
+			return fmt.Errorf("url policy: address %s is denied by configuration", target)
+		case p.DenyPrivate && isPrivateAddress(ip) && !inCIDRs(p.AllowCIDRs, ip):
+			return fmt.Errorf("url policy: %s is an internal address, denied by configuration", target)
+		}
+	}
+
+	if len(p.AllowHosts) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowHosts {
+		if hostMatches(pattern, host) {
+			return nil
+		}
+	}
+	if len(p.AllowCIDRs) > 0 && addrs == nil {
+		var err error
+		if addrs, err = lookupHost(ctx, host); err != nil {
+			return err
+		}
+	}
+	if len(p.AllowCIDRs) > 0 && len(addrs) > 0 {
+		allowed := true
+		for _, ip := range addrs {
+			allowed = allowed && inCIDRs(p.AllowCIDRs, ip)
+		}
+		if allowed {
+			return nil
+		}
+	}
+	return fmt.Errorf("url policy: host %s is not in the allowed list (%s)", host, allowedTargets(p))
+}
+
+// lookupHost returns the addresses of a host name, or the address itself
+func lookupHost(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, urlLookupTimeout)
+	defer cancel()
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, fmt.Errorf("url policy: cannot resolve %s to check its addresses: %v", host, err)
+	}
+	addrs := make([]net.IP, len(ips))
+	for i, ip := range ips {
+		addrs[i] = ip.IP
+	}
+	return addrs, nil
+}
+
+// checkSettingURLs checks every string in settings, at any depth and in
+// lists, that is an http or https URL
+func (r *Registry) checkSettingURLs(settings map[string]interface{}) error {
+	if r.urlPolicy == nil {
+		return nil
+	}
+	keys := make([]string, 0, len(settings))
+	for k := range settings {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := r.checkValueURLs(k, settings[k]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkValueURLs checks v, found at name, as checkSettingURLs does
+func (r *Registry) checkValueURLs(name string, v interface{}) error {
+	switch v := v.(type) {
+	case string:
+		if lower := strings.ToLower(strings.TrimSpace(v)); strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") {
+			if err := r.checkURL(v); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	case map[string]interface{}:
+		if err := r.checkSettingURLs(v); err != nil {
+			return fmt.Errorf("%s.%w", name, err)
+		}
+	case []interface{}:
+		for i, item := range v {
+			if err := r.checkValueURLs(fmt.Sprintf("%s[%d]", name, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// urlHost returns the lower-cased host name or address of a URL or a
+// host:port
+func urlHost(raw string) string {
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+}
+
+func hostMatches(pattern, host string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), host)
+	return ok
+}
+
+func inCIDRs(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateAddress reports whether ip is reachable only from inside a
+// network or host
+func isPrivateAddress(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsUnspecified()
+}
+
+func allowedTargets(p *URLPolicy) string {
+	targets := append([]string{}, p.AllowHosts...)
+	for _, n := range p.AllowCIDRs {
+		targets = append(targets, n.String())
+	}
+	return strings.Join(targets, ", ")
+}
@@ -0,0 +1,90 @@
+package tools
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+)
+
+func mustCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	var out []*net.IPNet
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, n)
+	}
+	return out
+}
+
+// TestCheckURL covers the policy rules with address literals and host
+// rules alone, so no case depends on DNS
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  URLPolicy
+		url     string
+		wantErr string
+	}{
+		{"empty URL", URLPolicy{DenyPrivate: true}, "  ", ""},
+		{"no host", URLPolicy{DenyPrivate: true}, "http://", "cannot find the host"},
+		{"public address", URLPolicy{DenyPrivate: true}, "https://203.0.113.7/hook", ""},
+		{"loopback", URLPolicy{DenyPrivate: true}, "http://127.0.0.1:9090", "internal address"},
+		{"IPv6 loopback", URLPolicy{DenyPrivate: true}, "http://[::1]:9090/", "internal address"},
+		{"metadata endpoint", URLPolicy{DenyPrivate: true}, "http://169.254.169.254/latest/meta-data/", "internal address"},
+		{"RFC 1918", URLPolicy{DenyPrivate: true}, "10.1.2.3:5432", "internal address"},
+		{"unspecified", URLPolicy{DenyPrivate: true}, "http://0.0.0.0/", "internal address"},
+		{"allowed private range", URLPolicy{DenyPrivate: true, AllowCIDRs: mustCIDRs(t, "10.0.0.0/8")}, "http://10.1.2.3:9090", ""},
+		{"denied range wins over allowed", URLPolicy{AllowCIDRs: mustCIDRs(t, "10.0.0.0/8"), DenyCIDRs: mustCIDRs(t, "10.1.0.0/16")}, "http://10.1.2.3", "denied by configuration"},
+		{"denied host", URLPolicy{DenyHosts: []string{"*.internal"}}, "https://grafana.internal/api", "denied by configuration"},
+		{"denied host is case-insensitive", URLPolicy{DenyHosts: []string{"*.Internal"}}, "https://GRAFANA.INTERNAL./api", "denied by configuration"},
+		{"denied host wins over allowed", URLPolicy{AllowHosts: []string{"*"}, DenyHosts: []string{"metadata.google.internal"}}, "http://metadata.google.internal/", "denied by configuration"},
+		{"allowed host", URLPolicy{AllowHosts: []string{"*.slack.com"}}, "https://hooks.slack.com/services/T0/B0/x", ""},
+		{"host not allowed", URLPolicy{AllowHosts: []string{"*.slack.com"}}, "https://example.com/hook", "not in the allowed list"},
+		{"suffix is not a subdomain", URLPolicy{AllowHosts: []string{"*.slack.com"}}, "https://slack.com.example.net/", "not in the allowed list"},
+		{"address in allowed range", URLPolicy{AllowHosts: []string{"*.slack.com"}, AllowCIDRs: mustCIDRs(t, "203.0.113.0/24")}, "https://203.0.113.7", ""},
+		{"address outside allowed range", URLPolicy{AllowHosts: []string{"*.slack.com"}, AllowCIDRs: mustCIDRs(t, "203.0.113.0/24")}, "https://198.51.100.7", "not in the allowed list"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := tt.policy
+			r := &Registry{urlPolicy: &policy, ctx: context.Background()}
+			err := r.checkURL(tt.url)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("checkURL(%q) = %v, want nil", tt.url, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("checkURL(%q) = %v, want an error containing %q", tt.url, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckURLWithoutPolicy(t *testing.T) {
+	r := &Registry{ctx: context.Background()}
+	if err := r.checkURL("http://169.254.169.254/"); err != nil {
+		t.Fatalf("checkURL without a policy = %v, want nil", err)
+	}
+}
+
+func TestCheckSettingURLs(t *testing.T) {
+	r := &Registry{urlPolicy: &URLPolicy{DenyPrivate: true}, ctx: context.Background()}
+	settings := map[string]interface{}{
+		"title":   "http is not a URL here",
+		"ignored": "127.0.0.1:9090",
+		"receivers": []interface{}{
+			map[string]interface{}{"name": "ok", "url": "https://203.0.113.7"},
+			map[string]interface{}{"name": "bad", "webhook": map[string]interface{}{"url": "HTTP://127.0.0.1/"}},
+		},
+	}
+	err := r.checkSettingURLs(settings)
+	if err == nil || !strings.HasPrefix(err.Error(), "receivers[1].webhook.url: url policy") {
+		t.Fatalf("checkSettingURLs = %v, want receivers[1].webhook.url refused", err)
+	}
+}
@@ -0,0 +1,77 @@
+package tools_test
+
+import (
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+const metadataURL = "http://169.254.169.254/latest/meta-data/"
+
+func TestURLPolicyCoversWritePaths(t *testing.T) {
+	h := testkit.New(t, testkit.WithToolOptions(tools.WithURLPolicy(tools.URLPolicy{DenyPrivate: true})))
+	ds := h.AddDatasource(grafana.Datasource{Name: "Tempo", Type: "tempo", URL: "https://tempo.example.com"})
+	am := h.AddDatasource(grafana.Datasource{Name: "Mimir Alertmanager", Type: "alertmanager", URL: "https://mimir.example.com"})
+
+	tests := []struct {
+		name string
+		tool string
+		args map[string]interface{}
+		want string
+	}{
+		{
+			"datasource jsonData on create", "grafana_create_datasource",
+			map[string]interface{}{
+				"name": "Jaeger", "type": "jaeger", "url": "https://203.0.113.10:16686",
+				"json_data": map[string]interface{}{"tracesToLogsV2": map[string]interface{}{"customQuery": false}, "oauthTokenUrl": metadataURL},
+			},
+			"json_data.oauthTokenUrl: url policy",
+		},
+		{
+			"datasource jsonData on update", "grafana_update_datasource",
+			map[string]interface{}{"uid": ds, "json_data": map[string]interface{}{"serviceMap": map[string]interface{}{"url": metadataURL}}},
+			"json_data.serviceMap.url: url policy",
+		},
+		{
+			"alertmanager webhook", "grafana_mimir_set_alertmanager_config",
+			map[string]interface{}{
+				"datasource_uid": am,
+				"config":         "route:\n  receiver: hook\nreceivers:\n  - name: hook\n    webhook_configs:\n      - url: " + metadataURL + "\n",
+			},
+			"config.receivers[0].webhook_configs[0].url: url policy",
+		},
+		{
+			"contact point settings", "grafana_create_contact_point",
+			map[string]interface{}{"name": "hook", "type": "webhook", "settings": map[string]interface{}{"url": metadataURL}},
+			"url: url policy",
+		},
+	}
+	for _, tt := range tests {
+		h.ResetRequests()
+		h.Call(tt.tool, tt.args).Error(tt.want)
+		for _, req := range h.Requests() {
+			if req.Method != "GET" {
+				t.Fatalf("%s: %s %s sent despite the URL policy", tt.name, req.Method, req.Path)
+			}
+		}
+	}
+}
+
+func TestUpdateDatasourceMergesJSONData(t *testing.T) {
+	h := testkit.New(t)
+	uid := h.AddDatasource(grafana.Datasource{Name: "Prometheus", Type: "prometheus", URL: "https://prom.example.com", JSONData: map[string]interface{}{
+		"httpMethod":   "POST",
+		"timeInterval": "30s",
+	}})
+
+	h.Call("grafana_update_datasource", map[string]interface{}{
+		"uid":       uid,
+		"json_data": map[string]interface{}{"timeInterval": nil, "manageAlerts": true},
+	}).OK()
+	ds, _ := h.Datasource(uid)
+	if _, ok := ds.JSONData["timeInterval"]; ok || ds.JSONData["httpMethod"] != "POST" || ds.JSONData["manageAlerts"] != true {
+		t.Fatalf("jsonData %v, want timeInterval removed, httpMethod kept, and manageAlerts set", ds.JSONData)
+	}
+}
@@ -0,0 +1,318 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/analysis"
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+)
+
+const (
+	// activityDashboardLimit caps the dashboards whose histories are scanned
+	activityDashboardLimit = 200
+	// activityVersionLimit is how many recent revisions are read per dashboard
+	activityVersionLimit = 50
+	activityScanWorkers  = 4
+	defaultActivityLimit = 200
+)
+
+func (r *Registry) grafanaUserActivityTool() mcp.Tool {
+	return mcp.Tool{
+		Name:        "grafana_user_activity",
+		Description: "Answer \"what has this user changed lately?\" in one call: dashboard revisions they saved, annotations they created, and, when Grafana audit logs are shipped to Loki, their audit entries, merged into one timeline",
+		InputSchema: mcp.InputSchema{
+			Type: "object",
+			Properties: map[string]mcp.Property{
+				"user":                 {Type: "string", Description: "Login, email, or name of the user"},
+				"from":                 {Type: "string", Description: "Start time (default now-7d)"},
+				"to":                   {Type: "string", Description: "End time (default now)"},
+				"folder_uid":           {Type: "string", Description: "Only scan dashboards in this folder"},
+				"max_dashboards":       {Type: "integer", Description: "Maximum dashboards whose version history is scanned (default 200)"},
+				"limit":                {Type: "integer", Description: "Maximum events returned (default 200)"},
+				"audit_datasource_uid": {Type: "string", Description: "Loki datasource holding Grafana audit logs (optional)"},
+				"audit_selector":       {Type: "string", Description: "LogQL stream selector for the audit logs, e.g. {job=\"grafana-audit\"}; required with audit_datasource_uid"},
+			},
+			Required: []string{"user"},
+		},
+		Annotations: &mcp.ToolAnnotations{
+			ReadOnlyHint:  true,
+			OpenWorldHint: true,
+		},
+	}
+}
+
+// activityEvent is one change attributed to the user
+type activityEvent struct {
+	Time      string `json:"time"`
+	Source    string `json:"source"` // dashboard_version, annotation, or audit
+	Action    string `json:"action"`
+	UID       string `json:"uid,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Version   int    `json:"version,omitempty"`
+	Message   string `json:"message,omitempty"`
+	URL       string `json:"url,omitempty"`
+	timestamp time.Time
+}
+
+func (r *Registry) handleUserActivity(args map[string]interface{}) (*mcp.CallToolResult, error) {
+	who := strings.TrimSpace(getString(args, "user"))
+	if who == "" {
+		return errorResult("user is required"), nil
+	}
+	auditUID, auditSelector := getString(args, "audit_datasource_uid"), getString(args, "audit_selector")
+	if auditUID != "" && auditSelector == "" {
+		return errorResult("audit_selector is required with audit_datasource_uid"), nil
+	}
+	start, end, err := parseTimeRange(getString(args, "from"), getString(args, "to"), "now-7d")
+	if err != nil {
+		return errorResultFor(err), nil
+	}
+	maxDashboards := getInt(args, "max_dashboards")
+	if maxDashboards <= 0 {
+		maxDashboards = activityDashboardLimit
+	}
+	limit := getInt(args, "limit")
+	if limit <= 0 {
+		limit = defaultActivityLimit
+	}
+
+	var notes []string
+	user := r.lookupUser(who)
+	if user == nil {
+		user = &grafana.User{Login: who}
+		notes = append(notes, fmt.Sprintf("no user %q in the organization; matching %q as a login only", who, who))
+	}
+	matches := userMatcher(user)
+
+	var events []activityEvent
+	sources := map[string]int{}
+
+	q := grafana.SearchQuery{Type: "dash-db", Limit: unusedScanLimit}
+	if folderUID := getString(args, "folder_uid"); folderUID != "" {
+		q.FolderUIDs = []string{folderUID}
+	}
+	hits, err := r.client.Search(r.ctx, q)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("dashboard versions skipped: search failed: %v", err))
+	} else {
+		if len(hits) > maxDashboards {
+			notes = append(notes, fmt.Sprintf("scanned the version history of %d of %d dashboards; narrow with folder_uid or raise max_dashboards", maxDashboards, len(hits)))
+			hits = hits[:maxDashboards]
+		}
+		versionEvents, failed := r.dashboardVersionEvents(hits, matches, start, end)
+		if failed > 0 {
+			notes = append(notes, fmt.Sprintf("could not read the version history of %d dashboards", failed))
+		}
+		events = append(events, versionEvents...)
+		sources["dashboard_versions"] = len(versionEvents)
+	}
+
+	annotationEvents, err := r.annotationEvents(user, matches, start, end)
+	if err != nil {
+		notes = append(notes, fmt.Sprintf("annotations skipped: %v", err))
+	} else {
+		events = append(events, annotationEvents...)
+		sources["annotations"] = len(annotationEvents)
+	}
+
+	if auditUID != "" {
+		auditEvents, err := r.auditEvents(auditUID, auditSelector, user, start, end, limit)
+		if err != nil {
+			notes = append(notes, fmt.Sprintf("audit logs skipped: %v", err))
+		} else {
+			events = append(events, auditEvents...)
+			sources["audit"] = len(auditEvents)
+		}
+	} else {
+		notes = append(notes, "audit entries are not exposed by Grafana's API; pass audit_datasource_uid and audit_selector if audit logs are shipped to Loki")
+	}
+
+	sort.SliceStable(events, func(i, j int) bool { return events[i].timestamp.After(events[j].timestamp) })
+	total := len(events)
+	if len(events) > limit {
+		events = events[:limit]
+	}
+	if events == nil {
+		events = []activityEvent{}
+	}
+
+	out := map[string]interface{}{
+		"user":    map[string]interface{}{"id": user.ID, "login": user.Login, "email": user.Email, "name": user.Name},
+		"from":    r.formatMillis(start.UnixMilli()),
+		"to":      r.formatMillis(end.UnixMilli()),
+		"total":   total,
+		"sources": sources,
+		"events":  events,
+	}
+	if len(notes) > 0 {
+		out["notes"] = notes
+	}
+	return jsonResult(out)
+}
+
+// lookupUser finds an organization user by login, email, or name
+func (r *Registry) lookupUser(who string) *grafana.User {
+	// The search matches login, email, and name by substring, so the exact
+	// match is picked below
+	res, err := r.client.SearchOrgUsers(r.ctx, who, 1, listPageSize)
+	if err != nil {
+		return nil
+	}
+	users := res.OrgUsers
+	for i := range users {
+		u := &users[i]
+		if strings.EqualFold(u.Login, who) || strings.EqualFold(u.Email, who) || strings.EqualFold(u.Name, who) {
+			if u.ID == 0 {
+				u.ID = u.UserID
+			}
+			return u
+		}
+	}
+	return nil
+}
+
+// userMatcher reports whether an author name recorded by Grafana, which is
+// usually the login, refers to user
+func userMatcher(user *grafana.User) func(string) bool {
+	return func(author string) bool {
+		if author == "" {
+			return false
+		}
+		for _, v := range []string{user.Login, user.Email, user.Name} {
+			if v != "" && strings.EqualFold(v, author) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// dashboardVersionEvents returns the revisions of dashboards saved by the
+// user within the window, and how many histories could not be read
+func (r *Registry) dashboardVersionEvents(hits []grafana.SearchDashboardsResponse, matches func(string) bool, start, end time.Time) ([]activityEvent, int) {
+	var (
+		mu     sync.Mutex
+		out    []activityEvent
+		failed int
+		wg     sync.WaitGroup
+	)
+	sem := make(chan struct{}, activityScanWorkers)
+	for _, h := range hits {
+		wg.Add(1)
+		go func(h grafana.SearchDashboardsResponse) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			versions, err := r.client.GetDashboardVersions(r.ctx, h.UID, activityVersionLimit)
+			if err != nil {
+				mu.Lock()
+				failed++
+				mu.Unlock()
+				return
+			}
+			var found []activityEvent
+			for _, v := range versions {
+				created, err := time.Parse(time.RFC3339, v.Created)
+				if err != nil || created.Before(start) || created.After(end) || !matches(v.CreatedBy) {
+					continue
+				}
+				action := "saved"
+				if v.Version == 1 {
+					action = "created"
+				}
+				found = append(found, activityEvent{
+					Time:      r.formatMillis(created.UnixMilli()),
+					Source:    "dashboard_version",
+					Action:    action,
+					UID:       h.UID,
+					Title:     h.Title,
+					Version:   v.Version,
+					Message:   v.Message,
+					URL:       h.URL,
+					timestamp: created,
+				})
+			}
+			mu.Lock()
+			out = append(out, found...)
+			mu.Unlock()
+		}(h)
+	}
+	wg.Wait()
+	return out, failed
+}
+
+// annotationEvents returns annotations the user created within the window
+func (r *Registry) annotationEvents(user *grafana.User, matches func(string) bool, start, end time.Time) ([]activityEvent, error) {
+	q := grafana.AnnotationsQuery{From: start.UnixMilli(), To: end.UnixMilli(), Type: "annotation", UserID: user.ID, Limit: 1000}
+	annotations, err := r.client.QueryAnnotations(r.ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	var out []activityEvent
+	for _, a := range annotations {
+		if user.ID > 0 && a.UserID != user.ID {
+			continue
+		}
+		if user.ID == 0 && !matches(a.UserEmail) && !matches(a.UserName) {
+			continue
+		}
+		at := time.UnixMilli(a.Time)
+		if a.Created > 0 {
+			at = time.UnixMilli(a.Created)
+		}
+		text := a.Text
+		if len(text) > 200 {
+			text = text[:200] + "…"
+		}
+		out = append(out, activityEvent{
+			Time:      r.formatMillis(at.UnixMilli()),
+			Source:    "annotation",
+			Action:    "annotated",
+			UID:       a.DashboardUID,
+			Message:   text,
+			timestamp: at,
+		})
+	}
+	return out, nil
+}
+
+// auditEvents returns the Loki audit log lines mentioning the user's login
+func (r *Registry) auditEvents(dsUID, selector string, user *grafana.User, start, end time.Time, limit int) ([]activityEvent, error) {
+	resp, err := r.query(grafana.QueryRequest{
+		From: fmt.Sprintf("%d", start.UnixMilli()),
+		To:   fmt.Sprintf("%d", end.UnixMilli()),
+		Queries: []grafana.QueryTarget{{
+			RefID:      "A",
+			Datasource: grafana.DatasourceRef{Type: "loki", UID: dsUID},
+			Query:      fmt.Sprintf("%s |= %q", selector, user.Login),
+			QueryType:  "range",
+			MaxLines:   limit,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	res := resp.Results["A"]
+	if res.Error != "" {
+		return nil, errors.New(res.Error)
+	}
+	var out []activityEvent
+	for _, l := range analysis.FramesToLogLines(res.Frames) {
+		at := time.UnixMilli(l.Time)
+		out = append(out, activityEvent{
+			Time:      r.formatMillis(l.Time),
+			Source:    "audit",
+			Action:    "audit",
+			Message:   l.Line,
+			timestamp: at,
+		})
+	}
+	return out, nil
+}
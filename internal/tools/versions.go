@@ -0,0 +1,67 @@
+package tools
+
+import (
+	"fmt"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// Minimum Grafana versions for the APIs some tools depend on
+const (
+	// alertingProvisioningVersion introduced /api/v1/provisioning for alert
+	// rules, notification policies, and mute timings
+	alertingProvisioningVersion = "9.1.0"
+	// liveVersion introduced Grafana Live channels
+	liveVersion = "8.0.0"
+)
+
+// toolRequirements maps tools to the oldest Grafana version they work with.
+// Tools not listed work with any supported version.
+var toolRequirements = map[string]string{
+	"grafana_list_alert_rules":        alertingProvisioningVersion,
+	"grafana_get_alert_rule":          alertingProvisioningVersion,
+	"grafana_create_alert_rule":       alertingProvisioningVersion,
+	"grafana_update_alert_rule":       alertingProvisioningVersion,
+	"grafana_delete_alert_rule":       alertingProvisioningVersion,
+	"grafana_alert_noise_report":      alertingProvisioningVersion,
+	"grafana_bulk_edit_alert_rules":   alertingProvisioningVersion,
+	"grafana_list_contact_points":     alertingProvisioningVersion,
+	"grafana_get_contact_point":       alertingProvisioningVersion,
+	"grafana_create_contact_point":    alertingProvisioningVersion,
+	"grafana_update_contact_point":    alertingProvisioningVersion,
+	"grafana_delete_contact_point":    alertingProvisioningVersion,
+	"grafana_preview_alert_routing":   alertingProvisioningVersion,
+	"grafana_contact_point_inventory": alertingProvisioningVersion,
+	"grafana_live_subscribe":          liveVersion,
+}
+
+// WithGrafanaVersion hides tools the connected Grafana version does not
+// support and makes calls to them fail with a "requires Grafana >= X" error.
+// Without it, no tools are gated.
+func WithGrafanaVersion(v grafana.Version) Option {
+	return func(r *Registry) {
+		r.version = &v
+	}
+}
+
+// supports reports whether the connected Grafana is at least min. An
+// unknown version supports everything.
+func (r *Registry) supports(min string) bool {
+	if r.version == nil {
+		return true
+	}
+	want, err := grafana.ParseVersion(min)
+	if err != nil {
+		return true
+	}
+	return r.version.AtLeast(want)
+}
+
+// unsupportedReason explains why a tool is unavailable on the connected
+// Grafana, by version or feature, or returns "" if it is supported
+func (r *Registry) unsupportedReason(name string) string {
+	if min, ok := toolRequirements[name]; ok && !r.supports(min) {
+		return fmt.Sprintf("%s requires Grafana >= %s (connected to %s)", name, min, r.version)
+	}
+	return r.missingFeature(name)
+}
@@ -0,0 +1,414 @@
+// Package websocket is a minimal RFC 6455 implementation used for Grafana Live,
+// Loki tailing, and the WebSocket MCP transport. It supports text/binary
+// messages, fragmentation, and ping/pong/close control frames; extensions
+// such as permessage-deflate are not negotiated.
+package websocket
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Message opcodes
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+const (
+	acceptGUID     = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	maxMessageSize = 64 << 20
+	// maxControlPayload is the largest close, ping, or pong payload
+	// (RFC 6455 section 5.5)
+	maxControlPayload = 125
+)
+
+// ErrClosed is returned by ReadMessage after the peer sent a close frame.
+var ErrClosed = errors.New("websocket: connection closed")
+
+// Conn is a WebSocket connection. Reads must come from a single goroutine;
+// writes are serialized internally.
+type Conn struct {
+	conn     net.Conn
+	br       *bufio.Reader
+	isClient bool
+	onPong   func()
+
+	writeMu      sync.Mutex
+	writeTimeout time.Duration
+}
+
+// Dial opens a client connection to a ws:// or wss:// URL, sending header
+// with the handshake request.
+func Dial(rawURL string, header http.Header, tlsConfig *tls.Config, timeout time.Duration) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket URL: %w", err)
+	}
+
+	host := u.Host
+	useTLS := false
+	switch u.Scheme {
+	case "ws":
+		if u.Port() == "" {
+			host += ":80"
+		}
+	case "wss":
+		useTLS = true
+		if u.Port() == "" {
+			host += ":443"
+		}
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme %q", u.Scheme)
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	var nc net.Conn
+	if useTLS {
+		cfg := &tls.Config{}
+		if tlsConfig != nil {
+			cfg = tlsConfig.Clone()
+		}
+		if cfg.ServerName == "" {
+			cfg.ServerName = u.Hostname()
+		}
+		nc, err = tls.DialWithDialer(dialer, "tcp", host, cfg)
+	} else {
+		nc, err = dialer.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     "GET",
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     make(http.Header),
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	if timeout > 0 {
+		nc.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := req.Write(nc); err != nil {
+		nc.Close()
+		return nil, err
+	}
+
+	br := bufio.NewReader(nc)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		nc.Close()
+		return nil, fmt.Errorf("websocket handshake failed (status %d): %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		nc.Close()
+		return nil, errors.New("websocket handshake failed: invalid Sec-WebSocket-Accept")
+	}
+	nc.SetDeadline(time.Time{})
+
+	return &Conn{conn: nc, br: br, isClient: true}, nil
+}
+
+// Upgrade completes the server side of the handshake for r and takes over
+// its connection. header is added to the 101 response. On failure an HTTP
+// error has already been written to w.
+func Upgrade(w http.ResponseWriter, r *http.Request, header http.Header) (*Conn, error) {
+	fail := func(status int, msg string) (*Conn, error) {
+		http.Error(w, msg, status)
+		return nil, errors.New("websocket handshake failed: " + msg)
+	}
+	if r.Method != http.MethodGet {
+		return fail(http.StatusMethodNotAllowed, "method must be GET")
+	}
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return fail(http.StatusBadRequest, "not a websocket upgrade request")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		return fail(http.StatusUpgradeRequired, "unsupported websocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return fail(http.StatusBadRequest, "missing Sec-WebSocket-Key")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		return fail(http.StatusInternalServerError, "connection cannot be hijacked")
+	}
+	nc, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n"
+	for k, vs := range header {
+		for _, v := range vs {
+			resp += k + ": " + v + "\r\n"
+		}
+	}
+	resp += "\r\n"
+	if _, err := nc.Write([]byte(resp)); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	nc.SetDeadline(time.Time{})
+
+	return &Conn{conn: nc, br: rw.Reader, isClient: false}, nil
+}
+
+// headerContains reports whether a comma-separated header has token, ignoring case
+func headerContains(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func acceptKey(key string) string {
+	h := sha1.New()
+	h.Write([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(h.Sum(nil))
+}
+
+// SetReadDeadline sets the deadline for future ReadMessage calls.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+// SetWriteTimeout bounds every frame written from now on, including pongs
+// and close frames sent by ReadMessage, so a peer that stops reading cannot
+// block writers indefinitely. Zero means no timeout.
+func (c *Conn) SetWriteTimeout(d time.Duration) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	c.writeTimeout = d
+}
+
+// SetPongHandler sets a function called from ReadMessage for every pong
+// received. Call it before reading starts.
+func (c *Conn) SetPongHandler(h func()) {
+	c.onPong = h
+}
+
+// Ping sends a ping control frame.
+func (c *Conn) Ping() error {
+	return c.WriteMessage(PingMessage, nil)
+}
+
+// Close closes the underlying network connection without a close handshake.
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// CloseGracefully sends a normal-closure close frame and closes the connection.
+func (c *Conn) CloseGracefully() error {
+	c.WriteMessage(CloseMessage, []byte{0x03, 0xE8})
+	return c.conn.Close()
+}
+
+// ReadMessage returns the next complete data message. Pings are answered
+// automatically and pongs are passed to the pong handler, if any.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		opcode  int
+		message []byte
+	)
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch op {
+		case PingMessage:
+			if err := c.WriteMessage(PongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case PongMessage:
+			if c.onPong != nil {
+				c.onPong()
+			}
+			continue
+		case CloseMessage:
+			c.WriteMessage(CloseMessage, payload)
+			return 0, nil, ErrClosed
+		case 0:
+			if opcode == 0 {
+				return 0, nil, errors.New("websocket: unexpected continuation frame")
+			}
+		default:
+			if opcode != 0 {
+				return 0, nil, errors.New("websocket: new message before previous finished")
+			}
+			opcode = op
+		}
+
+		message = append(message, payload...)
+		if len(message) > maxMessageSize {
+			return 0, nil, errors.New("websocket: message too large")
+		}
+		if fin {
+			return opcode, message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(c.br, header[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	op := int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, errors.New("websocket: frame too large")
+	}
+	if isControl(op) {
+		if length > maxControlPayload {
+			return false, 0, nil, errors.New("websocket: control frame payload exceeds 125 bytes")
+		}
+		if !fin {
+			return false, 0, nil, errors.New("websocket: fragmented control frame")
+		}
+	}
+	if !c.isClient && !masked {
+		return false, 0, nil, errors.New("websocket: client frame not masked")
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, op, payload, nil
+}
+
+// isControl reports whether op is a close, ping, or pong opcode
+func isControl(op int) bool {
+	return op&0x8 != 0
+}
+
+// WriteMessage sends a single unfragmented frame. Client frames are masked.
+func (c *Conn) WriteMessage(opcode int, data []byte) error {
+	if isControl(opcode) && len(data) > maxControlPayload {
+		return errors.New("websocket: control frame payload exceeds 125 bytes")
+	}
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	frame := make([]byte, 0, len(data)+14)
+	frame = append(frame, 0x80|byte(opcode))
+
+	maskBit := byte(0)
+	if c.isClient {
+		maskBit = 0x80
+	}
+	switch n := len(data); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(frame, maskBit|127)
+		frame = append(frame, ext[:]...)
+	}
+
+	if c.isClient {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, data...)
+		for i := range data {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, data...)
+	}
+
+	if c.writeTimeout > 0 {
+		c.conn.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	}
+	_, err := c.conn.Write(frame)
+	return err
+}
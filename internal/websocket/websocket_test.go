@@ -0,0 +1,162 @@
+package websocket
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clientFrame encodes a masked frame as a client sends it
+func clientFrame(fin bool, op int, payload []byte) []byte {
+	b0 := byte(op)
+	if fin {
+		b0 |= 0x80
+	}
+	frame := []byte{b0}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(append(frame, 0x80|127), ext[:]...)
+	}
+	mask := [4]byte{1, 2, 3, 4}
+	frame = append(frame, mask[:]...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	return frame
+}
+
+// serverConn returns a server connection that reads input and the bytes it
+// writes back, available once the test ends the connection
+func serverConn(t *testing.T, input []byte) (*Conn, func() []byte) {
+	local, remote := net.Pipe()
+	written := make(chan []byte)
+	go func() {
+		b, _ := io.ReadAll(remote)
+		written <- b
+	}()
+	t.Cleanup(func() { local.Close() })
+	c := &Conn{conn: local, br: bufio.NewReader(bytes.NewReader(input))}
+	return c, func() []byte {
+		local.Close()
+		return <-written
+	}
+}
+
+func TestReadMessageControlFrames(t *testing.T) {
+	payload := func(n int) []byte { return bytes.Repeat([]byte("x"), n) }
+	frames := func(fs ...[]byte) []byte { return bytes.Join(fs, nil) }
+	tests := []struct {
+		name      string
+		input     []byte
+		wantMsg   string
+		wantErr   string
+		wantReply []byte
+	}{
+		{
+			name:      "ping at the limit is answered",
+			input:     frames(clientFrame(true, PingMessage, payload(125)), clientFrame(true, TextMessage, []byte("hi"))),
+			wantMsg:   "hi",
+			wantReply: append([]byte{0x80 | PongMessage, 125}, payload(125)...),
+		},
+		{
+			name:    "ping over the limit",
+			input:   clientFrame(true, PingMessage, payload(126)),
+			wantErr: "control frame payload exceeds 125 bytes",
+		},
+		{
+			name:    "pong over the limit",
+			input:   clientFrame(true, PongMessage, payload(200)),
+			wantErr: "control frame payload exceeds 125 bytes",
+		},
+		{
+			name:    "close over the limit",
+			input:   clientFrame(true, CloseMessage, payload(70000)),
+			wantErr: "control frame payload exceeds 125 bytes",
+		},
+		{
+			name:    "fragmented ping",
+			input:   clientFrame(false, PingMessage, nil),
+			wantErr: "fragmented control frame",
+		},
+		{
+			name:      "ping between fragments",
+			input:     frames(clientFrame(false, TextMessage, []byte("hel")), clientFrame(true, PingMessage, []byte("p")), clientFrame(true, 0, []byte("lo"))),
+			wantMsg:   "hello",
+			wantReply: []byte{0x80 | PongMessage, 1, 'p'},
+		},
+		{
+			name:      "close is echoed",
+			input:     clientFrame(true, CloseMessage, []byte{0x03, 0xE8}),
+			wantErr:   "connection closed",
+			wantReply: []byte{0x80 | CloseMessage, 2, 0x03, 0xE8},
+		},
+		{
+			name:    "unmasked client frame",
+			input:   []byte{0x80 | TextMessage, 2, 'h', 'i'},
+			wantErr: "not masked",
+		},
+		{
+			name:    "continuation without a message",
+			input:   clientFrame(true, 0, []byte("lo")),
+			wantErr: "unexpected continuation frame",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, written := serverConn(t, tt.input)
+			_, msg, err := c.ReadMessage()
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("ReadMessage = %q, %v, want an error containing %q", msg, err, tt.wantErr)
+				}
+			} else if err != nil || string(msg) != tt.wantMsg {
+				t.Fatalf("ReadMessage = %q, %v, want %q", msg, err, tt.wantMsg)
+			}
+			if got := written(); !bytes.Equal(got, tt.wantReply) {
+				t.Fatalf("wrote % x, want % x", got, tt.wantReply)
+			}
+		})
+	}
+}
+
+func TestWriteMessageRejectsLargeControlFrames(t *testing.T) {
+	c, written := serverConn(t, nil)
+	for _, op := range []int{CloseMessage, PingMessage, PongMessage} {
+		if err := c.WriteMessage(op, make([]byte, 126)); err == nil {
+			t.Errorf("WriteMessage(%d) with 126 bytes succeeded", op)
+		}
+	}
+	if got := written(); len(got) != 0 {
+		t.Fatalf("wrote % x, want nothing", got)
+	}
+}
+
+func TestWriteTimeout(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+	c := &Conn{conn: local, br: bufio.NewReader(local)}
+	c.SetWriteTimeout(20 * time.Millisecond)
+
+	// Nothing reads remote, so the write can never complete
+	done := make(chan error, 1)
+	go func() { done <- c.WriteMessage(TextMessage, []byte("hello")) }()
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatal("write to a peer that never reads succeeded")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("write blocked past its timeout")
+	}
+}
@@ -0,0 +1,95 @@
+package testkit
+
+import (
+	"embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"sort"
+	"strings"
+)
+
+//go:embed fixtures/*.json
+var fixtureFiles embed.FS
+
+// Fixture is a canned response for one endpoint, stored as a JSON file:
+//
+//	{"method": "GET", "path": "/api/health", "status": 200, "body": {...}}
+//
+// A * in the path matches one segment. Bodies are written as JSON unless
+// content_type is set; with encoding "base64" the body is a base64 string
+// of raw bytes, such as a rendered PNG.
+type Fixture struct {
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type,omitempty"`
+	Encoding    string          `json:"encoding,omitempty"`
+	Body        json.RawMessage `json:"body"`
+}
+
+func (f Fixture) write(w http.ResponseWriter) {
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if f.ContentType == "" {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(f.Body)
+		return
+	}
+	body := []byte(f.Body)
+	var s string
+	if json.Unmarshal(f.Body, &s) == nil {
+		body = []byte(s)
+		if f.Encoding == "base64" {
+			if raw, err := base64.StdEncoding.DecodeString(s); err == nil {
+				body = raw
+			}
+		}
+	}
+	w.Header().Set("Content-Type", f.ContentType)
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+func builtinFixtures() ([]Fixture, error) {
+	return readFixtures(fixtureFiles, "fixtures")
+}
+
+func loadFixtureDir(dir string) ([]Fixture, error) {
+	return readFixtures(os.DirFS(dir), ".")
+}
+
+// readFixtures reads every .json file in dir, in name order
+func readFixtures(fsys fs.FS, dir string) ([]Fixture, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	var out []Fixture
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var f Fixture
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("fixture %s: %w", e.Name(), err)
+		}
+		if f.Method == "" || f.Path == "" {
+			return nil, fmt.Errorf("fixture %s: method and path are required", e.Name())
+		}
+		out = append(out, f)
+	}
+	return out, nil
+}
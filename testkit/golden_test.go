@@ -0,0 +1,36 @@
+package testkit_test
+
+import (
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+// TestGoldenResponses pins the output of tools over seeded objects and the
+// fixtures in testdata/fixtures to testdata/*.golden. Run with
+// TESTKIT_UPDATE=1 after an intended change in a response.
+func TestGoldenResponses(t *testing.T) {
+	h := testkit.New(t, testkit.WithFixtures("testdata/fixtures"))
+	folder := h.AddFolder(grafana.Folder{UID: "ops", Title: "Ops"})
+	h.AddDatasource(grafana.Datasource{UID: "prom", Name: "Prometheus", Type: "prometheus", URL: "http://prometheus:9090", IsDefault: true})
+	h.AddDatasource(grafana.Datasource{UID: "loki", Name: "Loki", Type: "loki", URL: "http://loki:3100"})
+	h.AddAlertRule(grafana.AlertRule{UID: "latency", Title: "High latency", FolderUID: folder, RuleGroup: "checkout", Labels: map[string]string{"team": "sre"}})
+
+	tests := []struct {
+		golden string
+		tool   string
+		args   map[string]interface{}
+	}{
+		{"list_folders", "grafana_list_folders", nil},
+		{"list_datasources", "grafana_list_datasources", nil},
+		{"get_alert_rule", "grafana_get_alert_rule", map[string]interface{}{"uid": "latency"}},
+		{"health", "grafana_health", nil},
+		{"prometheus_targets", "grafana_prometheus_targets", map[string]interface{}{"datasource_uid": "prom"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			h.Call(tt.tool, tt.args).OK().Golden(tt.golden)
+		})
+	}
+}
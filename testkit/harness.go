@@ -0,0 +1,191 @@
+package testkit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/mcp"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+)
+
+// UpdateEnv names the environment variable that makes Golden rewrite golden
+// files instead of comparing against them
+const UpdateEnv = "TESTKIT_UPDATE"
+
+// normalizedURL replaces the fake server's random address in tool output
+// compared against golden files
+const normalizedURL = "http://grafana.test"
+
+// Harness is a fake Grafana with a tool registry connected to it
+type Harness struct {
+	*Server
+	Registry *tools.Registry
+	tb       testing.TB
+	// orgs and session make the harness's calls one MCP session
+	orgs    *grafana.SessionOrgs
+	session string
+}
+
+// sessions numbers the MCP sessions of harnesses
+var sessions atomic.Int64
+
+func newSessionID() string {
+	return fmt.Sprintf("testkit-%d", sessions.Add(1))
+}
+
+// WithToolOptions passes options to the tool registry a Harness creates
+func WithToolOptions(opts ...tools.Option) Option {
+	return func(s *Server) error {
+		s.toolOpts = append(s.toolOpts, opts...)
+		return nil
+	}
+}
+
+// WithEnabledTools limits the Harness registry to the named tools
+func WithEnabledTools(names ...string) Option {
+	return func(s *Server) error {
+		s.enabled = func(name string) bool { return containsString(names, name) }
+		return nil
+	}
+}
+
+// New starts a fake Grafana and a registry with every tool enabled
+func New(tb testing.TB, opts ...Option) *Harness {
+	tb.Helper()
+	s := NewServer(tb, opts...)
+	return &Harness{
+		Server:   s,
+		Registry: tools.NewRegistry(s.Client(), s.enabled, s.toolOpts...),
+		tb:       tb,
+		orgs:     grafana.NewSessionOrgs(),
+		session:  newSessionID(),
+	}
+}
+
+// Call runs a tool through the registry, as an MCP tools/call would. The
+// test fails if the call returns a protocol error; tool errors are reported
+// in the Result.
+func (h *Harness) Call(name string, args map[string]interface{}) *Result {
+	h.tb.Helper()
+	if args == nil {
+		args = map[string]interface{}{}
+	}
+	ctx := tools.WithSession(grafana.WithSessionOrgs(context.Background(), h.orgs), h.session)
+	res, err := h.Registry.CallTool(ctx, name, args)
+	if err != nil {
+		h.tb.Fatalf("%s: %v", name, err)
+	}
+	if res == nil {
+		h.tb.Fatalf("%s: no result", name)
+	}
+	return &Result{CallToolResult: res, name: name, h: h}
+}
+
+// NewSession returns a harness for a second MCP session sharing the fake
+// Grafana and the registry, e.g. to check what one session does stays out
+// of another
+func (h *Harness) NewSession() *Harness {
+	return &Harness{Server: h.Server, Registry: h.Registry, tb: h.tb, orgs: grafana.NewSessionOrgs(), session: newSessionID()}
+}
+
+// Result is the outcome of a tool call
+type Result struct {
+	*mcp.CallToolResult
+	name string
+	h    *Harness
+}
+
+// Text returns the text of the first content block
+func (r *Result) Text() string {
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			return c.Text
+		}
+	}
+	return ""
+}
+
+// OK fails the test if the tool reported an error, and returns r
+func (r *Result) OK() *Result {
+	r.h.tb.Helper()
+	if r.IsError {
+		r.h.tb.Fatalf("%s failed: %s", r.name, r.Text())
+	}
+	return r
+}
+
+// Error fails the test unless the tool reported an error containing want
+func (r *Result) Error(want string) {
+	r.h.tb.Helper()
+	if !r.IsError {
+		r.h.tb.Fatalf("%s succeeded, want error containing %q: %s", r.name, want, r.Text())
+	}
+	if !strings.Contains(r.Text(), want) {
+		r.h.tb.Fatalf("%s error %q does not contain %q", r.name, r.Text(), want)
+	}
+}
+
+// JSON decodes the result text into v
+func (r *Result) JSON(v interface{}) {
+	r.h.tb.Helper()
+	if err := json.Unmarshal([]byte(r.Text()), v); err != nil {
+		r.h.tb.Fatalf("%s: result is not JSON: %v\n%s", r.name, err, r.Text())
+	}
+}
+
+// Golden compares the result text with testdata/<name>.golden, after
+// replacing the fake server's address with a fixed one
+func (r *Result) Golden(name string) {
+	r.h.tb.Helper()
+	text := strings.ReplaceAll(r.Text(), r.h.URL(), normalizedURL)
+	Golden(r.h.tb, name, []byte(text))
+}
+
+// Golden compares got with the file testdata/<name>.golden. Run the tests
+// with TESTKIT_UPDATE=1 to write the current output as the new golden file.
+// JSON is compared after re-indenting, so formatting changes do not count.
+func Golden(tb testing.TB, name string, got []byte) {
+	tb.Helper()
+	got = canonicalJSON(got)
+	path := filepath.Join("testdata", name+".golden")
+	if os.Getenv(UpdateEnv) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			tb.Fatalf("golden %s: %v", path, err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			tb.Fatalf("golden %s: %v", path, err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		tb.Fatalf("golden %s: %v (run with %s=1 to create it)", path, err, UpdateEnv)
+	}
+	if !bytes.Equal(canonicalJSON(want), got) {
+		tb.Errorf("%s does not match the output (run with %s=1 to update)\n--- want\n%s\n--- got\n%s", path, UpdateEnv, want, got)
+	}
+}
+
+// canonicalJSON re-indents JSON with sorted keys and leaves anything else
+// as is
+func canonicalJSON(data []byte) []byte {
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return data
+	}
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return data
+	}
+	return append(out, '\n')
+}
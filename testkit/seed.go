@@ -0,0 +1,210 @@
+package testkit
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// AddFolder stores a folder and returns its uid, generating one if unset
+func (s *Server) AddFolder(f grafana.Folder) string {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if f.UID == "" {
+		f.UID = st.uid("folder-")
+	}
+	f.ID, f.URL, f.Version = st.id(), "/dashboards/f/"+f.UID, 1
+	st.folders[f.UID] = &f
+	return f.UID
+}
+
+// AddDashboard stores a dashboard model in a folder ("" for General) and
+// returns its uid, generating one if the model has none
+func (s *Server) AddDashboard(model map[string]interface{}, folderUID string) string {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	model = cloneJSON(model)
+	uid, _ := model["uid"].(string)
+	if uid == "" {
+		uid = st.uid("dash-")
+	}
+	d := &storedDashboard{id: st.id(), version: 1, folderUID: folderUID, updated: time.Now(), model: model}
+	model["uid"], model["id"], model["version"] = uid, d.id, d.version
+	d.history = []grafana.DashboardVersion{{ID: st.id(), Version: 1, Created: d.updated.Format(time.RFC3339), CreatedBy: "admin"}}
+	st.dashboards[uid] = d
+	return uid
+}
+
+// StarDashboard stars a stored dashboard for the authenticated user
+func (s *Server) StarDashboard(uid string) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	if d, ok := s.store.dashboards[uid]; ok {
+		d.starred = true
+	}
+}
+
+// AddDatasource stores a datasource and returns its uid, generating one if
+// unset
+func (s *Server) AddDatasource(ds grafana.Datasource) string {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.addDatasource(ds).UID
+}
+
+// AddAlertRule stores an alert rule and returns its uid, generating one if
+// unset. The rule's folder need not exist.
+func (s *Server) AddAlertRule(rule grafana.AlertRule) string {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if rule.UID == "" {
+		rule.UID = st.uid("rule-")
+	}
+	rule.ID, rule.OrgID = st.id(), 1
+	st.rules[rule.UID] = &rule
+	return rule.UID
+}
+
+// AddAnnotation stores an annotation and returns its id
+func (s *Server) AddAnnotation(a grafana.Annotation) int64 {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.addAnnotation(a).ID
+}
+
+// AddTeam stores a team and returns its id
+func (s *Server) AddTeam(t grafana.Team) int64 {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	return st.addTeam(t).ID
+}
+
+// AddOrg adds an organization the authenticated user belongs to with role,
+// and returns its id. Requests with X-Grafana-Org-Id set to it are answered
+// for it by /api/org.
+func (s *Server) AddOrg(name, role string) int64 {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	id := int64(len(st.orgs) + 1)
+	st.orgs[id] = &grafana.UserOrg{OrgID: id, Name: name, Role: role}
+	return id
+}
+
+// SetNotificationPolicies replaces the notification policy tree
+func (s *Server) SetNotificationPolicies(root grafana.Route) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	s.store.policies = root
+}
+
+// AddContactPoint stores a contact point integration and returns its uid,
+// generating one if unset
+func (s *Server) AddContactPoint(cp grafana.ContactPoint) string {
+	st := s.store
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if cp.UID == "" {
+		cp.UID = st.uid("cp-")
+	}
+	st.contactPoints[cp.UID] = &cp
+	return cp.UID
+}
+
+// Dashboard returns a stored dashboard model and its folder uid
+func (s *Server) Dashboard(uid string) (map[string]interface{}, string, bool) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	d, ok := s.store.dashboards[uid]
+	if !ok {
+		return nil, "", false
+	}
+	return cloneJSON(d.model), d.folderUID, true
+}
+
+// Dashboards returns the uids of the stored dashboards
+func (s *Server) Dashboards() []string {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	return sortedKeys(s.store.dashboards)
+}
+
+// Folder returns a stored folder
+func (s *Server) Folder(uid string) (grafana.Folder, bool) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	f, ok := s.store.folders[uid]
+	if !ok {
+		return grafana.Folder{}, false
+	}
+	return *f, true
+}
+
+// Datasource returns a stored datasource
+func (s *Server) Datasource(uid string) (grafana.Datasource, bool) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	ds, ok := s.store.datasources[uid]
+	if !ok {
+		return grafana.Datasource{}, false
+	}
+	return *ds, true
+}
+
+// AlertRules returns the stored alert rules, ordered by uid
+func (s *Server) AlertRules() []grafana.AlertRule {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	out := make([]grafana.AlertRule, 0, len(s.store.rules))
+	for _, uid := range sortedKeys(s.store.rules) {
+		out = append(out, *s.store.rules[uid])
+	}
+	return out
+}
+
+// Annotations returns the stored annotations, ordered by id
+func (s *Server) Annotations() []grafana.Annotation {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	out := make([]grafana.Annotation, 0, len(s.store.annotations))
+	for _, id := range sortedIDs(s.store.annotations) {
+		out = append(out, *s.store.annotations[id])
+	}
+	return out
+}
+
+// NotificationPolicies returns the notification policy tree
+func (s *Server) NotificationPolicies() grafana.Route {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	return s.store.policies
+}
+
+// ContactPoint returns a stored contact point integration, secrets included
+func (s *Server) ContactPoint(uid string) (grafana.ContactPoint, bool) {
+	s.store.mu.Lock()
+	defer s.store.mu.Unlock()
+	cp, ok := s.store.contactPoints[uid]
+	if !ok {
+		return grafana.ContactPoint{}, false
+	}
+	return *cp, true
+}
+
+// cloneJSON deep-copies a JSON object so callers cannot alias stored state
+func cloneJSON(v map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return map[string]interface{}{}
+	}
+	var out map[string]interface{}
+	json.Unmarshal(data, &out)
+	return out
+}
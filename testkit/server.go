@@ -0,0 +1,211 @@
+// Package testkit runs tool handlers end to end against a fake Grafana, so
+// tools can be tested without a live instance.
+//
+// The fake keeps folders, dashboards, datasources, alert rules, annotations,
+// teams, snapshots, organizations, contact points, the notification policy
+// tree, service account tokens, and the rule groups and Alertmanager
+// configuration of Mimir datasources in memory, and serves every other
+// endpoint the client uses from JSON response fixtures. Tests seed state with the Add methods, override any
+// endpoint with a fixture directory or Handle, and inspect the calls a tool
+// made with Requests.
+// Grafana Live and Loki tail use WebSockets and are not faked.
+package testkit
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/internal/tools"
+)
+
+// APIKey is the token the fake server expects
+const APIKey = "testkit-key"
+
+// Request is a call the fake server received
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+	Body   []byte
+}
+
+// JSON decodes the request body into v
+func (r Request) JSON(v interface{}) error {
+	return json.Unmarshal(r.Body, v)
+}
+
+// Server is a fake Grafana HTTP API
+type Server struct {
+	srv *httptest.Server
+
+	mu       sync.Mutex
+	requests []Request
+	handlers []route
+	fixtures []Fixture
+	store    *store
+
+	// Registry settings used by New
+	toolOpts []tools.Option
+	enabled  func(string) bool
+}
+
+type route struct {
+	method, pattern string
+	handler         http.HandlerFunc
+}
+
+// Option configures a Server
+type Option func(*Server) error
+
+// WithFixtures loads response fixtures from dir. They take precedence over
+// the built-in fixtures and the in-memory state.
+func WithFixtures(dir string) Option {
+	return func(s *Server) error {
+		fixtures, err := loadFixtureDir(dir)
+		if err != nil {
+			return err
+		}
+		s.fixtures = append(fixtures, s.fixtures...)
+		return nil
+	}
+}
+
+// NewServer starts a fake Grafana that is closed when the test ends
+func NewServer(tb testing.TB, opts ...Option) *Server {
+	tb.Helper()
+	defaults, err := builtinFixtures()
+	if err != nil {
+		tb.Fatalf("testkit: %v", err)
+	}
+	s := &Server{fixtures: defaults, store: newStore()}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			tb.Fatalf("testkit: %v", err)
+		}
+	}
+	s.srv = httptest.NewServer(http.HandlerFunc(s.serve))
+	tb.Cleanup(s.srv.Close)
+	return s
+}
+
+// URL is the base URL of the fake Grafana
+func (s *Server) URL() string {
+	return s.srv.URL
+}
+
+// Client returns a Grafana client for the fake server
+func (s *Server) Client() *grafana.Client {
+	return grafana.NewClient(s.srv.URL, APIKey)
+}
+
+// Handle overrides an endpoint. The pattern is a path in which * matches
+// one segment; later handlers take precedence.
+func (s *Server) Handle(method, pattern string, h http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers = append([]route{{method: method, pattern: pattern, handler: h}}, s.handlers...)
+}
+
+// Reply overrides an endpoint with a fixed status and JSON body
+func (s *Server) Reply(method, pattern string, status int, body interface{}) {
+	s.Handle(method, pattern, func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(w, status, body)
+	})
+}
+
+// Requests returns the calls received so far, oldest first
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// RequestsTo returns the calls received for a method and path pattern
+func (s *Server) RequestsTo(method, pattern string) []Request {
+	var out []Request
+	for _, r := range s.Requests() {
+		if r.Method == method && matchPath(pattern, r.Path) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// ResetRequests forgets the calls received so far
+func (s *Server) ResetRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+	handlers := s.handlers
+	fixtures := s.fixtures
+	s.mu.Unlock()
+
+	if req.Header.Get("Authorization") != "Bearer "+APIKey && req.URL.Path != "/api/health" {
+		writeError(w, http.StatusUnauthorized, "invalid API key")
+		return
+	}
+	for _, h := range handlers {
+		if h.method == req.Method && matchPath(h.pattern, req.URL.Path) {
+			h.handler(w, req)
+			return
+		}
+	}
+	for _, f := range fixtures {
+		if f.Method == req.Method && matchPath(f.Path, req.URL.Path) {
+			f.write(w)
+			return
+		}
+	}
+	if s.store.serve(w, req, body) {
+		return
+	}
+	writeError(w, http.StatusNotFound, "Not found")
+}
+
+// matchPath reports whether path matches pattern, where * matches one segment
+func matchPath(pattern, path string) bool {
+	want := strings.Split(strings.Trim(pattern, "/"), "/")
+	got := strings.Split(strings.Trim(path, "/"), "/")
+	if len(want) != len(got) {
+		return false
+	}
+	for i := range want {
+		if want[i] != "*" && want[i] != got[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"message": message})
+}
@@ -0,0 +1,157 @@
+package testkit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+	"github.com/npcomplete777/grafana-mcp/testkit"
+)
+
+// TestServerCoversClient calls every HTTP endpoint of the client against
+// the fake, so an endpoint added to the client without a route or fixture
+// fails here rather than in a downstream tool test
+func TestServerCoversClient(t *testing.T) {
+	s := testkit.NewServer(t)
+	c := s.Client()
+	ctx := context.Background()
+
+	folder := s.AddFolder(grafana.Folder{Title: "Ops"})
+	dash := s.AddDashboard(map[string]interface{}{"title": "Checkout", "panels": []interface{}{}}, folder)
+	ds := s.AddDatasource(grafana.Datasource{Name: "Prometheus", Type: "prometheus"})
+	loki := s.AddDatasource(grafana.Datasource{Name: "Loki", Type: "loki"})
+	tempo := s.AddDatasource(grafana.Datasource{Name: "Tempo", Type: "tempo"})
+	rule := s.AddAlertRule(grafana.AlertRule{Title: "High latency", FolderUID: folder, RuleGroup: "latency"})
+	team := s.AddTeam(grafana.Team{Name: "SRE"})
+	annotation := s.AddAnnotation(grafana.Annotation{Text: "deploy"})
+	cp := s.AddContactPoint(grafana.ContactPoint{Name: "oncall", Type: "email", Settings: map[string]interface{}{"addresses": "oncall@example.com"}})
+	now := time.Now()
+	ruler := c.DatasourceRuler(ds)
+
+	calls := []struct {
+		name string
+		call func() error
+	}{
+		{"GetUserPermissions", func() error { _, err := c.GetUserPermissions(ctx); return err }},
+		{"Search", func() error { _, err := c.Search(ctx, grafana.SearchQuery{Query: "checkout"}); return err }},
+		{"GetSearchSorting", func() error { _, err := c.GetSearchSorting(ctx); return err }},
+		{"GetDashboard", func() error { _, err := c.GetDashboard(ctx, dash); return err }},
+		{"GetDashboardVersions", func() error {
+			_, err := c.GetDashboardVersions(ctx, dash, 10)
+			return err
+		}},
+		{"GetDashboardPermissions", func() error { _, err := c.GetDashboardPermissions(ctx, dash); return err }},
+		{"GetPublicDashboards", func() error { _, err := c.GetPublicDashboards(ctx); return err }},
+		{"GetDatasources", func() error { _, err := c.GetDatasources(ctx); return err }},
+		{"GetDatasource", func() error { _, err := c.GetDatasource(ctx, ds); return err }},
+		{"GetFolders", func() error { _, err := c.GetFolders(ctx); return err }},
+		{"GetFolder", func() error { _, err := c.GetFolder(ctx, folder); return err }},
+		{"GetFolderPermissions", func() error { _, err := c.GetFolderPermissions(ctx, folder); return err }},
+		{"GetAlertRules", func() error { _, err := c.GetAlertRules(ctx); return err }},
+		{"GetAlertRule", func() error { _, err := c.GetAlertRule(ctx, rule); return err }},
+		{"GetRuleGroup", func() error { _, err := c.GetRuleGroup(ctx, folder, "latency"); return err }},
+		{"QueryAnnotations", func() error { _, err := c.QueryAnnotations(ctx, grafana.AnnotationsQuery{}); return err }},
+		{"UpdateAnnotation", func() error {
+			return c.UpdateAnnotation(ctx, annotation, grafana.Annotation{Text: "deploy v2"})
+		}},
+		{"GetCurrentOrg", func() error { _, err := c.GetCurrentOrg(ctx); return err }},
+		{"GetUserOrgs", func() error { _, err := c.GetUserOrgs(ctx); return err }},
+		{"GetOrgs", func() error { _, err := c.GetOrgs(ctx); return err }},
+		{"GetCurrentUser", func() error { _, err := c.GetCurrentUser(ctx); return err }},
+		{"GetOrgUsers", func() error { _, err := c.GetOrgUsers(ctx); return err }},
+		{"SearchOrgUsers", func() error { _, err := c.SearchOrgUsers(ctx, "", 1, 10); return err }},
+		{"Query", func() error {
+			_, err := c.Query(ctx, grafana.QueryRequest{From: "now-1h", To: "now", Queries: []grafana.QueryTarget{{RefID: "A"}}})
+			return err
+		}},
+		{"GetHealth", func() error { _, err := c.GetHealth(ctx); return err }},
+		{"GetVersion", func() error { _, err := c.GetVersion(ctx); return err }},
+		{"SearchTeams", func() error { _, err := c.SearchTeams(ctx, "", 1, 10); return err }},
+		{"GetTeam", func() error { _, err := c.GetTeam(ctx, team); return err }},
+		{"GetReceivers", func() error { _, err := c.GetReceivers(ctx); return err }},
+		{"GetPolicyTree", func() error { _, err := c.GetNotificationPolicyTree(ctx); return err }},
+		{"GetMuteTimings", func() error { _, err := c.GetMuteTimings(ctx); return err }},
+		{"GetContactPoints", func() error { _, err := c.GetContactPoints(ctx, "oncall"); return err }},
+		{"UpdateContactPoint", func() error {
+			return c.UpdateContactPoint(ctx, cp, grafana.ContactPoint{Name: "oncall", Type: "email"})
+		}},
+		{"GetFiringAlerts", func() error { _, err := c.GetFiringAlerts(ctx); return err }},
+		{"ListPlugins", func() error { _, err := c.ListPlugins(ctx, ""); return err }},
+		{"GetFrontendSettings", func() error {
+			_, err := c.GetFrontendSettings(ctx)
+			return err
+		}},
+		{"GetPrometheusRules", func() error { _, err := c.GetPrometheusRules(ctx, ds); return err }},
+		{"GetPrometheusLabelValues", func() error {
+			_, err := c.GetPrometheusLabelValues(ctx, ds, "job", nil)
+			return err
+		}},
+		{"CountPrometheusSeries", func() error {
+			_, err := c.CountPrometheusSeries(ctx, ds, `{job="api"}`, now.Add(-time.Hour), now, 100)
+			return err
+		}},
+		{"GetPrometheusTargets", func() error { _, err := c.GetPrometheusTargets(ctx, ds, "active"); return err }},
+		{"GetPrometheusTargetMetadata", func() error {
+			_, err := c.GetPrometheusTargetMetadata(ctx, ds, "", "up", 10)
+			return err
+		}},
+		{"CheckLokiQuery", func() error { _, err := c.CheckLokiQuery(ctx, loki, `{job="api"}`); return err }},
+		{"GetLokiIndexStats", func() error {
+			_, err := c.GetLokiIndexStats(ctx, loki, `{job="api"}`, now.Add(-time.Hour), now)
+			return err
+		}},
+		{"GetLokiVolume", func() error {
+			_, err := c.GetLokiVolume(ctx, loki, `{job="api"}`, now.Add(-time.Hour), now, nil, 10)
+			return err
+		}},
+		{"GetLokiSeries", func() error {
+			_, err := c.GetLokiSeries(ctx, loki, `{job="api"}`, now.Add(-time.Hour), now)
+			return err
+		}},
+		{"SearchTempo", func() error {
+			_, err := c.SearchTempo(ctx, tempo, `{}`, now.Add(-time.Hour), now, 10)
+			return err
+		}},
+		{"RenderPanel", func() error {
+			_, err := c.RenderPanel(ctx, grafana.RenderOptions{DashboardUID: dash, PanelID: 1})
+			return err
+		}},
+		{"GetServiceAccountTokens", func() error { _, err := c.GetServiceAccountTokens(ctx, 1); return err }},
+		{"CreateServiceAccountToken", func() error {
+			token, err := c.CreateServiceAccountToken(ctx, 1, "ci", time.Hour)
+			if err != nil {
+				return err
+			}
+			return c.DeleteServiceAccountToken(ctx, 1, token.ID)
+		}},
+		{"TestReceivers", func() error {
+			_, err := c.TestReceivers(ctx, []grafana.Receiver{{Name: "oncall"}}, nil)
+			return err
+		}},
+		{"CreateSnapshot", func() error {
+			_, err := c.CreateSnapshot(ctx, grafana.SnapshotRequest{Dashboard: map[string]interface{}{"title": "Checkout"}})
+			return err
+		}},
+		{"ListRuleGroups", func() error { _, err := ruler.ListRuleGroups(ctx, ""); return err }},
+		{"SetRuleGroup", func() error {
+			return ruler.SetRuleGroup(ctx, "ops", grafana.RulerRuleGroup{Name: "latency"})
+		}},
+		{"GetRulerRuleGroup", func() error { _, err := ruler.GetRuleGroup(ctx, "ops", "latency"); return err }},
+		{"DeleteRuleGroup", func() error { return ruler.DeleteRuleGroup(ctx, "ops", "latency") }},
+		{"GetAlertmanagerConfig", func() error {
+			cfg, err := ruler.GetAlertmanagerConfig(ctx)
+			if err != nil {
+				return err
+			}
+			return ruler.SetAlertmanagerConfig(ctx, *cfg)
+		}},
+	}
+	for _, tc := range calls {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.call(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
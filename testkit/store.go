@@ -0,0 +1,947 @@
+package testkit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/npcomplete777/grafana-mcp/internal/grafana"
+)
+
+// store is the in-memory state behind the fake's CRUD endpoints
+type store struct {
+	mu          sync.Mutex
+	nextID      int64
+	folders     map[string]*grafana.Folder
+	dashboards  map[string]*storedDashboard
+	datasources map[string]*grafana.Datasource
+	rules       map[string]*grafana.AlertRule
+	annotations map[int64]*grafana.Annotation
+	teams       map[int64]*grafana.Team
+	policies    grafana.Route
+	// contactPoints are the contact point integrations, by uid
+	contactPoints map[string]*grafana.ContactPoint
+	// rulerGroups are the rule groups of Mimir or Cortex datasources, by
+	// datasource uid and namespace
+	rulerGroups map[string]map[string][]grafana.RulerRuleGroup
+	// amConfigs are the Alertmanager configurations of Mimir or Cortex
+	// datasources, by datasource uid
+	amConfigs map[string]*grafana.RulerAlertmanagerConfig
+	// saTokens are the tokens of service accounts, by service account id
+	saTokens map[int64][]grafana.ServiceAccountToken
+	// orgs are the organizations the authenticated user belongs to, by ID
+	orgs map[int64]*grafana.UserOrg
+}
+
+type storedDashboard struct {
+	model     map[string]interface{}
+	folderUID string
+	id        int64
+	version   int
+	updated   time.Time
+	history   []grafana.DashboardVersion
+	starred   bool
+}
+
+func newStore() *store {
+	return &store{
+		nextID:      1,
+		folders:     map[string]*grafana.Folder{},
+		dashboards:  map[string]*storedDashboard{},
+		datasources: map[string]*grafana.Datasource{},
+		rules:       map[string]*grafana.AlertRule{},
+		annotations: map[int64]*grafana.Annotation{},
+		teams:       map[int64]*grafana.Team{},
+		policies:    grafana.Route{Receiver: "grafana-default-email", GroupBy: []string{"grafana_folder", "alertname"}},
+		orgs:        map[int64]*grafana.UserOrg{1: {OrgID: 1, Name: "Main Org.", Role: "Admin"}},
+
+		contactPoints: map[string]*grafana.ContactPoint{},
+		rulerGroups:   map[string]map[string][]grafana.RulerRuleGroup{},
+		amConfigs:     map[string]*grafana.RulerAlertmanagerConfig{},
+		saTokens:      map[int64][]grafana.ServiceAccountToken{},
+	}
+}
+
+func (st *store) id() int64 {
+	id := st.nextID
+	st.nextID++
+	return id
+}
+
+func (st *store) uid(prefix string) string {
+	return fmt.Sprintf("%s%d", prefix, st.id())
+}
+
+// serve handles req from the in-memory state, reporting false for
+// endpoints the store does not cover
+func (st *store) serve(w http.ResponseWriter, req *http.Request, body []byte) bool {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	p := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	at := func(pattern string) bool { return matchPath(pattern, req.URL.Path) }
+	method := req.Method
+	switch {
+	case method == "GET" && at("/api/search"):
+		writeJSON(w, 200, st.search(req))
+
+	case method == "GET" && at("/api/dashboards/uid/*"):
+		d, ok := st.dashboards[p[3]]
+		if !ok {
+			writeError(w, 404, "Dashboard not found")
+			break
+		}
+		writeJSON(w, 200, grafana.DashboardJSON{Dashboard: d.model, Meta: st.dashboardMeta(d)})
+	case method == "GET" && at("/api/dashboards/uid/*/versions"):
+		d, ok := st.dashboards[p[3]]
+		if !ok {
+			writeError(w, 404, "Dashboard not found")
+			break
+		}
+		versions := make([]grafana.DashboardVersion, 0, len(d.history))
+		for i := len(d.history) - 1; i >= 0; i-- {
+			versions = append(versions, d.history[i])
+		}
+		writeJSON(w, 200, versions)
+	case method == "DELETE" && at("/api/dashboards/uid/*"):
+		d, ok := st.dashboards[p[3]]
+		if !ok {
+			writeError(w, 404, "Dashboard not found")
+			break
+		}
+		delete(st.dashboards, p[3])
+		writeJSON(w, 200, map[string]interface{}{"id": d.id, "title": d.model["title"], "message": "Dashboard deleted"})
+	case method == "POST" && at("/api/dashboards/db"):
+		st.saveDashboard(w, body)
+
+	case method == "GET" && at("/api/folders"):
+		writeJSON(w, 200, st.folderList())
+	case method == "GET" && at("/api/folders/*"):
+		f, ok := st.folders[p[2]]
+		if !ok {
+			writeError(w, 404, "folder not found")
+			break
+		}
+		writeJSON(w, 200, f)
+	case method == "POST" && at("/api/folders"):
+		var in grafana.Folder
+		if !decode(w, body, &in) {
+			break
+		}
+		if in.UID == "" {
+			in.UID = st.uid("folder-")
+		}
+		if _, ok := st.folders[in.UID]; ok {
+			writeError(w, 409, "a folder with the same uid already exists")
+			break
+		}
+		f := &grafana.Folder{ID: st.id(), UID: in.UID, Title: in.Title, URL: "/dashboards/f/" + in.UID, Version: 1, CanSave: true, CanEdit: true, CanAdmin: true}
+		st.folders[in.UID] = f
+		writeJSON(w, 200, f)
+	case method == "PUT" && at("/api/folders/*"):
+		f, ok := st.folders[p[2]]
+		if !ok {
+			writeError(w, 404, "folder not found")
+			break
+		}
+		var in struct {
+			Title     string `json:"title"`
+			Version   int    `json:"version"`
+			Overwrite bool   `json:"overwrite"`
+		}
+		if !decode(w, body, &in) {
+			break
+		}
+		if !in.Overwrite && in.Version != f.Version {
+			writeError(w, 409, "the folder has been changed by someone else")
+			break
+		}
+		f.Title = in.Title
+		f.Version++
+		writeJSON(w, 200, f)
+	case method == "DELETE" && at("/api/folders/*"):
+		if _, ok := st.folders[p[2]]; !ok {
+			writeError(w, 404, "folder not found")
+			break
+		}
+		delete(st.folders, p[2])
+		for uid, d := range st.dashboards {
+			if d.folderUID == p[2] {
+				delete(st.dashboards, uid)
+			}
+		}
+		writeJSON(w, 200, map[string]string{"message": "Folder deleted"})
+	case method == "POST" && at("/api/access-control/folders/*/teams/*"):
+		if _, ok := st.folders[p[3]]; !ok {
+			writeError(w, 404, "folder not found")
+			break
+		}
+		writeJSON(w, 200, map[string]string{"message": "Permission updated"})
+
+	case method == "GET" && at("/api/datasources"):
+		list := make([]grafana.Datasource, 0, len(st.datasources))
+		for _, uid := range sortedKeys(st.datasources) {
+			list = append(list, *st.datasources[uid])
+		}
+		writeJSON(w, 200, list)
+	case method == "GET" && at("/api/datasources/uid/*"):
+		ds, ok := st.datasources[p[3]]
+		if !ok {
+			writeError(w, 404, "Data source not found")
+			break
+		}
+		writeJSON(w, 200, ds)
+	case method == "POST" && at("/api/datasources"):
+		var in grafana.Datasource
+		if !decode(w, body, &in) {
+			break
+		}
+		for _, ds := range st.datasources {
+			if ds.Name == in.Name {
+				writeError(w, 409, "data source with the same name already exists")
+				return true
+			}
+		}
+		ds := st.addDatasource(in)
+		writeJSON(w, 200, map[string]interface{}{"datasource": ds, "id": ds.ID, "message": "Datasource added", "name": ds.Name})
+	case method == "PUT" && at("/api/datasources/uid/*"):
+		cur, ok := st.datasources[p[3]]
+		if !ok {
+			writeError(w, 404, "Data source not found")
+			break
+		}
+		var in grafana.Datasource
+		if !decode(w, body, &in) {
+			break
+		}
+		in.ID, in.UID, in.OrgID, in.SecureJSONData = cur.ID, cur.UID, cur.OrgID, nil
+		*cur = in
+		writeJSON(w, 200, map[string]interface{}{"datasource": cur, "id": cur.ID, "message": "Datasource updated", "name": cur.Name})
+	case method == "DELETE" && at("/api/datasources/uid/*"):
+		if _, ok := st.datasources[p[3]]; !ok {
+			writeError(w, 404, "Data source not found")
+			break
+		}
+		delete(st.datasources, p[3])
+		writeJSON(w, 200, map[string]string{"message": "Data source deleted"})
+	case method == "GET" && at("/api/datasources/proxy/uid/*/loki/api/v1/format_query"):
+		writeJSON(w, 200, map[string]string{"status": "success", "data": req.URL.Query().Get("query")})
+
+	case method == "GET" && at("/api/v1/provisioning/alert-rules"):
+		list := make([]grafana.AlertRule, 0, len(st.rules))
+		for _, uid := range sortedKeys(st.rules) {
+			list = append(list, *st.rules[uid])
+		}
+		writeJSON(w, 200, list)
+	case method == "GET" && at("/api/v1/provisioning/alert-rules/*"):
+		rule, ok := st.rules[p[4]]
+		if !ok {
+			writeError(w, 404, "rule not found")
+			break
+		}
+		writeJSON(w, 200, rule)
+	case method == "POST" && at("/api/v1/provisioning/alert-rules"):
+		var in grafana.AlertRule
+		if !decode(w, body, &in) {
+			break
+		}
+		if _, ok := st.folders[in.FolderUID]; !ok {
+			writeError(w, 400, "folder does not exist")
+			break
+		}
+		if in.UID == "" {
+			in.UID = st.uid("rule-")
+		} else if _, ok := st.rules[in.UID]; ok {
+			writeError(w, 409, "a rule with the same uid already exists")
+			break
+		}
+		in.ID, in.OrgID, in.Provenance = st.id(), 1, provenance(req)
+		st.rules[in.UID] = &in
+		writeJSON(w, 201, in)
+	case method == "PUT" && at("/api/v1/provisioning/alert-rules/*"):
+		cur, ok := st.rules[p[4]]
+		if !ok {
+			writeError(w, 404, "rule not found")
+			break
+		}
+		var in grafana.AlertRule
+		if !decode(w, body, &in) {
+			break
+		}
+		in.ID, in.UID, in.OrgID, in.Provenance = cur.ID, cur.UID, cur.OrgID, provenance(req)
+		*cur = in
+		writeJSON(w, 200, cur)
+	case method == "DELETE" && at("/api/v1/provisioning/alert-rules/*"):
+		delete(st.rules, p[4])
+		w.WriteHeader(204)
+	case method == "GET" && at("/api/v1/provisioning/folder/*/rule-groups/*"):
+		group := grafana.RuleGroup{Title: p[6], FolderUID: p[4], Interval: 60}
+		for _, uid := range sortedKeys(st.rules) {
+			if r := st.rules[uid]; r.FolderUID == p[4] && r.RuleGroup == p[6] {
+				group.Rules = append(group.Rules, *r)
+			}
+		}
+		if len(group.Rules) == 0 {
+			writeError(w, 404, "rule group not found")
+			break
+		}
+		writeJSON(w, 200, group)
+
+	case method == "GET" && at("/api/v1/provisioning/policies"):
+		writeJSON(w, 200, st.policies)
+	case method == "PUT" && at("/api/v1/provisioning/policies"):
+		var in grafana.Route
+		if !decode(w, body, &in) {
+			break
+		}
+		in.Provenance = provenance(req)
+		st.policies = in
+		writeJSON(w, 202, map[string]string{"message": "policies updated"})
+
+	case method == "GET" && at("/api/v1/provisioning/contact-points"):
+		writeJSON(w, 200, st.contactPointList(req.URL.Query().Get("name")))
+	case method == "POST" && at("/api/v1/provisioning/contact-points"):
+		var in grafana.ContactPoint
+		if !decode(w, body, &in) {
+			break
+		}
+		if in.Name == "" || in.Type == "" {
+			writeError(w, 400, "contact point name and type are required")
+			break
+		}
+		if in.UID == "" {
+			in.UID = st.uid("cp-")
+		} else if _, ok := st.contactPoints[in.UID]; ok {
+			writeError(w, 400, "contact point with the same uid already exists")
+			break
+		}
+		in.Provenance = provenance(req)
+		st.contactPoints[in.UID] = &in
+		writeJSON(w, 202, redactContactPoint(in))
+	case method == "PUT" && at("/api/v1/provisioning/contact-points/*"):
+		cur, ok := st.contactPoints[p[4]]
+		if !ok {
+			writeError(w, 404, "contact point not found")
+			break
+		}
+		var in grafana.ContactPoint
+		if !decode(w, body, &in) {
+			break
+		}
+		for k, v := range in.Settings {
+			if v == redacted {
+				in.Settings[k] = cur.Settings[k]
+			}
+		}
+		in.UID, in.Provenance = cur.UID, provenance(req)
+		*cur = in
+		writeJSON(w, 202, map[string]string{"message": "contactpoint updated"})
+	case method == "DELETE" && at("/api/v1/provisioning/contact-points/*"):
+		cur, ok := st.contactPoints[p[4]]
+		if !ok {
+			writeError(w, 404, "contact point not found")
+			break
+		}
+		if len(st.contactPointList(cur.Name)) == 1 && st.receiverInUse(cur.Name) {
+			writeError(w, 409, "contact point '"+cur.Name+"' is currently used by a notification policy or alert rule")
+			break
+		}
+		delete(st.contactPoints, p[4])
+		writeJSON(w, 202, map[string]string{"message": "contactpoint deleted"})
+
+	case method == "GET" && at("/api/ruler/*/api/v1/rules"):
+		if len(st.rulerGroups[p[2]]) == 0 {
+			writeError(w, 404, "no rule groups found")
+			break
+		}
+		writeJSON(w, 200, st.rulerGroups[p[2]])
+	case method == "GET" && at("/api/ruler/*/api/v1/rules/*"):
+		groups, ok := st.rulerGroups[p[2]][p[6]]
+		if !ok {
+			writeError(w, 404, "no rule groups found")
+			break
+		}
+		writeJSON(w, 200, map[string][]grafana.RulerRuleGroup{p[6]: groups})
+	case method == "GET" && at("/api/ruler/*/api/v1/rules/*/*"):
+		i := rulerGroupIndex(st.rulerGroups[p[2]][p[6]], p[7])
+		if i < 0 {
+			writeError(w, 404, "group does not exist")
+			break
+		}
+		writeJSON(w, 200, st.rulerGroups[p[2]][p[6]][i])
+	case method == "POST" && at("/api/ruler/*/api/v1/rules/*"):
+		var in grafana.RulerRuleGroup
+		if !decode(w, body, &in) {
+			break
+		}
+		if in.Name == "" {
+			writeError(w, 400, "invalid rules config: rule group name must not be empty")
+			break
+		}
+		if st.rulerGroups[p[2]] == nil {
+			st.rulerGroups[p[2]] = map[string][]grafana.RulerRuleGroup{}
+		}
+		groups := st.rulerGroups[p[2]][p[6]]
+		if i := rulerGroupIndex(groups, in.Name); i >= 0 {
+			groups[i] = in
+		} else {
+			st.rulerGroups[p[2]][p[6]] = append(groups, in)
+		}
+		writeJSON(w, 202, map[string]string{"status": "success"})
+	case method == "DELETE" && at("/api/ruler/*/api/v1/rules/*/*"):
+		groups := st.rulerGroups[p[2]][p[6]]
+		i := rulerGroupIndex(groups, p[7])
+		if i < 0 {
+			writeError(w, 404, "group does not exist")
+			break
+		}
+		st.rulerGroups[p[2]][p[6]] = append(groups[:i], groups[i+1:]...)
+		if len(st.rulerGroups[p[2]][p[6]]) == 0 {
+			delete(st.rulerGroups[p[2]], p[6])
+		}
+		writeJSON(w, 202, map[string]string{"status": "success"})
+	case method == "GET" && at("/api/alertmanager/*/config/api/v1/alerts"):
+		cfg, ok := st.amConfigs[p[2]]
+		if !ok {
+			// Mimir serves its fallback configuration to tenants that have
+			// not set one
+			cfg = &grafana.RulerAlertmanagerConfig{AlertmanagerConfig: map[string]interface{}{
+				"route":     map[string]interface{}{"receiver": "empty-receiver"},
+				"receivers": []interface{}{map[string]interface{}{"name": "empty-receiver"}},
+			}}
+		}
+		writeJSON(w, 200, cfg)
+	case method == "POST" && at("/api/alertmanager/*/config/api/v1/alerts"):
+		var in grafana.RulerAlertmanagerConfig
+		if !decode(w, body, &in) {
+			break
+		}
+		st.amConfigs[p[2]] = &in
+		writeJSON(w, 202, map[string]string{"message": "configuration created"})
+
+	case method == "GET" && at("/api/serviceaccounts/*/tokens"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		writeJSON(w, 200, append([]grafana.ServiceAccountToken{}, st.saTokens[id]...))
+	case method == "POST" && at("/api/serviceaccounts/*/tokens"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		var in struct {
+			Name          string `json:"name"`
+			SecondsToLive int64  `json:"secondsToLive"`
+		}
+		if !decode(w, body, &in) {
+			break
+		}
+		now := time.Now().UTC()
+		token := grafana.ServiceAccountToken{ID: st.id(), Name: in.Name, Created: &now}
+		if in.SecondsToLive > 0 {
+			expires := now.Add(time.Duration(in.SecondsToLive) * time.Second)
+			token.Expiration = &expires
+		}
+		st.saTokens[id] = append(st.saTokens[id], token)
+		writeJSON(w, 200, grafana.NewServiceAccountToken{ID: token.ID, Name: token.Name, Key: fmt.Sprintf("glsa_testkit_%d", token.ID)})
+	case method == "DELETE" && at("/api/serviceaccounts/*/tokens/*"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		tokenID, _ := strconv.ParseInt(p[4], 10, 64)
+		tokens := st.saTokens[id]
+		for i := range tokens {
+			if tokens[i].ID == tokenID {
+				st.saTokens[id] = append(tokens[:i], tokens[i+1:]...)
+				writeJSON(w, 200, map[string]string{"message": "Service account token deleted"})
+				return true
+			}
+		}
+		writeError(w, 404, "service account token not found")
+
+	case method == "GET" && at("/api/annotations"):
+		writeJSON(w, 200, st.findAnnotations(req))
+	case method == "POST" && at("/api/annotations"):
+		var in grafana.Annotation
+		if !decode(w, body, &in) {
+			break
+		}
+		a := st.addAnnotation(in)
+		writeJSON(w, 200, map[string]interface{}{"id": a.ID, "message": "Annotation added"})
+	case method == "PUT" && at("/api/annotations/*"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		cur, ok := st.annotations[id]
+		if !ok {
+			writeError(w, 404, "Annotation not found")
+			break
+		}
+		var in grafana.Annotation
+		if !decode(w, body, &in) {
+			break
+		}
+		in.ID, in.Created, in.Updated = cur.ID, cur.Created, time.Now().UnixMilli()
+		*cur = in
+		writeJSON(w, 200, map[string]string{"message": "Annotation updated"})
+	case method == "DELETE" && at("/api/annotations/*"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		delete(st.annotations, id)
+		writeJSON(w, 200, map[string]string{"message": "Annotation deleted"})
+
+	case method == "GET" && at("/api/teams/search"):
+		query := strings.ToLower(req.URL.Query().Get("query"))
+		teams := []grafana.Team{}
+		for _, id := range sortedIDs(st.teams) {
+			if t := st.teams[id]; strings.Contains(strings.ToLower(t.Name), query) {
+				teams = append(teams, *t)
+			}
+		}
+		page, perPage := pageArgs(req, 1000)
+		total := len(teams)
+		teams = teams[min(total, (page-1)*perPage):min(total, page*perPage)]
+		writeJSON(w, 200, map[string]interface{}{"totalCount": total, "teams": teams, "page": page, "perPage": perPage})
+	case method == "GET" && at("/api/teams/*"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		t, ok := st.teams[id]
+		if !ok {
+			writeError(w, 404, "Team not found")
+			break
+		}
+		writeJSON(w, 200, t)
+	case method == "POST" && at("/api/teams"):
+		var in grafana.Team
+		if !decode(w, body, &in) {
+			break
+		}
+		t := st.addTeam(in)
+		writeJSON(w, 200, map[string]interface{}{"teamId": t.ID, "message": "Team created"})
+	case method == "DELETE" && at("/api/teams/*"):
+		id, _ := strconv.ParseInt(p[2], 10, 64)
+		if _, ok := st.teams[id]; !ok {
+			writeError(w, 404, "Team not found")
+			break
+		}
+		delete(st.teams, id)
+		writeJSON(w, 200, map[string]string{"message": "Team deleted"})
+
+	case method == "GET" && at("/api/org"):
+		id := int64(1)
+		if h := req.Header.Get("X-Grafana-Org-Id"); h != "" {
+			id, _ = strconv.ParseInt(h, 10, 64)
+		}
+		o, ok := st.orgs[id]
+		if !ok {
+			writeError(w, 401, "User does not belong to the organization")
+			break
+		}
+		writeJSON(w, 200, grafana.Organization{ID: o.OrgID, Name: o.Name})
+	case method == "GET" && at("/api/user/orgs"):
+		out := []grafana.UserOrg{}
+		for _, id := range sortedIDs(st.orgs) {
+			out = append(out, *st.orgs[id])
+		}
+		writeJSON(w, 200, out)
+	case method == "GET" && at("/api/orgs"):
+		out := []grafana.Organization{}
+		for _, id := range sortedIDs(st.orgs) {
+			out = append(out, grafana.Organization{ID: id, Name: st.orgs[id].Name})
+		}
+		writeJSON(w, 200, out)
+
+	case method == "POST" && at("/api/snapshots"):
+		id := st.id()
+		key := fmt.Sprintf("snapshot-%d", id)
+		writeJSON(w, 200, grafana.Snapshot{
+			ID:        id,
+			Key:       key,
+			DeleteKey: "delete-" + key,
+			URL:       "http://localhost:3000/dashboard/snapshot/" + key,
+			DeleteURL: "http://localhost:3000/api/snapshots-delete/delete-" + key,
+		})
+
+	default:
+		return false
+	}
+	return true
+}
+
+func (st *store) saveDashboard(w http.ResponseWriter, body []byte) {
+	var in struct {
+		Dashboard map[string]interface{} `json:"dashboard"`
+		FolderUID string                 `json:"folderUid"`
+		Overwrite bool                   `json:"overwrite"`
+		Message   string                 `json:"message"`
+	}
+	if !decode(w, body, &in) {
+		return
+	}
+	if in.Dashboard == nil {
+		writeError(w, 400, "dashboard is required")
+		return
+	}
+	if title, _ := in.Dashboard["title"].(string); title == "" {
+		writeError(w, 400, "Dashboard title cannot be empty")
+		return
+	}
+	if in.FolderUID != "" {
+		if _, ok := st.folders[in.FolderUID]; !ok {
+			writeError(w, 400, "folder not found")
+			return
+		}
+	}
+	uid, _ := in.Dashboard["uid"].(string)
+	if uid == "" {
+		uid = st.uid("dash-")
+	}
+	d, exists := st.dashboards[uid]
+	if exists && !in.Overwrite {
+		version, _ := in.Dashboard["version"].(float64)
+		if in.Dashboard["id"] == nil {
+			writeJSON(w, 412, map[string]string{"status": "name-exists", "message": "A dashboard with the same uid already exists"})
+			return
+		}
+		if int(version) != d.version {
+			writeJSON(w, 412, map[string]string{"status": "version-mismatch", "message": "The dashboard has been changed by someone else"})
+			return
+		}
+	}
+	if !exists {
+		d = &storedDashboard{id: st.id()}
+		st.dashboards[uid] = d
+	}
+	d.version++
+	d.folderUID = in.FolderUID
+	d.updated = time.Now()
+	d.model = in.Dashboard
+	d.model["uid"] = uid
+	d.model["id"] = d.id
+	d.model["version"] = d.version
+	d.history = append(d.history, grafana.DashboardVersion{
+		ID:            st.id(),
+		Version:       d.version,
+		ParentVersion: d.version - 1,
+		Created:       d.updated.Format(time.RFC3339),
+		CreatedBy:     "admin",
+		Message:       in.Message,
+	})
+	writeJSON(w, 200, map[string]interface{}{
+		"id":      d.id,
+		"uid":     uid,
+		"url":     "/d/" + uid,
+		"status":  "success",
+		"version": d.version,
+		"slug":    slug(d.model),
+	})
+}
+
+func (st *store) dashboardMeta(d *storedDashboard) grafana.DashboardMeta {
+	uid, _ := d.model["uid"].(string)
+	meta := grafana.DashboardMeta{
+		Slug:      slug(d.model),
+		URL:       "/d/" + uid,
+		FolderUID: d.folderUID,
+		Created:   d.updated.Format(time.RFC3339),
+		Updated:   d.updated.Format(time.RFC3339),
+		CreatedBy: "admin",
+		UpdatedBy: "admin",
+		Version:   d.version,
+	}
+	if f, ok := st.folders[d.folderUID]; ok {
+		meta.FolderID, meta.FolderTitle = f.ID, f.Title
+	}
+	return meta
+}
+
+func (st *store) folderList() []grafana.Folder {
+	list := make([]grafana.Folder, 0, len(st.folders))
+	for _, uid := range sortedKeys(st.folders) {
+		f := st.folders[uid]
+		list = append(list, grafana.Folder{ID: f.ID, UID: f.UID, Title: f.Title})
+	}
+	return list
+}
+
+// search implements the filters of /api/search over folders and dashboards
+func (st *store) search(req *http.Request) []grafana.SearchDashboardsResponse {
+	q := req.URL.Query()
+	query := strings.ToLower(q.Get("query"))
+	typ := q.Get("type")
+	tags := q["tag"]
+	folderUIDs := q["folderUIDs"]
+	uids := q["dashboardUIDs"]
+	ids := q["dashboardIds"]
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	starred := q.Get("starred") == "true"
+
+	hits := []grafana.SearchDashboardsResponse{}
+	if (typ == "" || typ == "dash-folder") && !starred {
+		for _, uid := range sortedKeys(st.folders) {
+			f := st.folders[uid]
+			if len(tags) > 0 || len(folderUIDs) > 0 || len(uids) > 0 || len(ids) > 0 || !strings.Contains(strings.ToLower(f.Title), query) {
+				continue
+			}
+			hits = append(hits, grafana.SearchDashboardsResponse{ID: f.ID, UID: f.UID, Title: f.Title, URL: f.URL, Type: "dash-folder", Tags: []string{}})
+		}
+	}
+	if typ == "" || typ == "dash-db" {
+		for _, uid := range sortedKeys(st.dashboards) {
+			d := st.dashboards[uid]
+			title, _ := d.model["title"].(string)
+			dashTags := stringList(d.model["tags"])
+			if !strings.Contains(strings.ToLower(title), query) ||
+				len(folderUIDs) > 0 && !containsString(folderUIDs, d.folderUID) ||
+				len(uids) > 0 && !containsString(uids, uid) ||
+				len(ids) > 0 && !containsString(ids, strconv.FormatInt(d.id, 10)) ||
+				!containsAll(dashTags, tags) ||
+				starred && !d.starred {
+				continue
+			}
+			meta := st.dashboardMeta(d)
+			hits = append(hits, grafana.SearchDashboardsResponse{
+				ID:          d.id,
+				UID:         uid,
+				Title:       title,
+				URI:         "db/" + meta.Slug,
+				URL:         meta.URL,
+				Type:        "dash-db",
+				Tags:        dashTags,
+				IsStarred:   d.starred,
+				FolderID:    meta.FolderID,
+				FolderUID:   meta.FolderUID,
+				FolderTitle: meta.FolderTitle,
+			})
+		}
+	}
+	if limit > 0 {
+		page, _ := strconv.Atoi(q.Get("page"))
+		start := (max(page, 1) - 1) * limit
+		hits = hits[min(start, len(hits)):min(start+limit, len(hits))]
+	}
+	return hits
+}
+
+func (st *store) findAnnotations(req *http.Request) []grafana.Annotation {
+	q := req.URL.Query()
+	from, _ := strconv.ParseInt(q.Get("from"), 10, 64)
+	to, _ := strconv.ParseInt(q.Get("to"), 10, 64)
+	panelID, _ := strconv.ParseInt(q.Get("panelId"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	dashUID := q.Get("dashboardUID")
+	tags := q["tags"]
+
+	out := []grafana.Annotation{}
+	for _, id := range sortedIDs(st.annotations) {
+		a := st.annotations[id]
+		end := a.TimeEnd
+		if end == 0 {
+			end = a.Time
+		}
+		if from > 0 && end < from || to > 0 && a.Time > to ||
+			dashUID != "" && a.DashboardUID != dashUID ||
+			panelID > 0 && a.PanelID != panelID ||
+			!containsAll(a.Tags, tags) {
+			continue
+		}
+		out = append(out, *a)
+	}
+	// Newest first, as Grafana returns them
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Time > out[j].Time })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+func (st *store) addDatasource(ds grafana.Datasource) *grafana.Datasource {
+	ds.ID, ds.OrgID, ds.SecureJSONData = st.id(), 1, nil
+	if ds.UID == "" {
+		ds.UID = st.uid("ds-")
+	}
+	if ds.Access == "" {
+		ds.Access = "proxy"
+	}
+	st.datasources[ds.UID] = &ds
+	return &ds
+}
+
+func (st *store) addAnnotation(a grafana.Annotation) *grafana.Annotation {
+	now := time.Now().UnixMilli()
+	a.ID, a.Created, a.Updated = st.id(), now, now
+	if a.Time == 0 {
+		a.Time = now
+	}
+	if a.Type == "" {
+		a.Type = "annotation"
+	}
+	st.annotations[a.ID] = &a
+	return &a
+}
+
+func (st *store) addTeam(t grafana.Team) *grafana.Team {
+	t.ID, t.OrgID = st.id(), 1
+	st.teams[t.ID] = &t
+	return &t
+}
+
+// rulerGroupIndex returns the index of the group named name, or -1
+func rulerGroupIndex(groups []grafana.RulerRuleGroup, name string) int {
+	for i, g := range groups {
+		if g.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// redacted stands in for a secure setting in contact points read back
+const redacted = "[REDACTED]"
+
+// secureSettings are the settings Grafana stores encrypted and redacts, by
+// integration type
+var secureSettings = map[string][]string{
+	"slack":     {"url", "token"},
+	"pagerduty": {"integrationKey"},
+	"opsgenie":  {"apiKey"},
+	"telegram":  {"bottoken"},
+	"webhook":   {"password", "authorization_credentials"},
+}
+
+// contactPointList returns the integrations named name, or all of them
+// when name is "", by name and then uid
+func (st *store) contactPointList(name string) []grafana.ContactPoint {
+	list := []grafana.ContactPoint{}
+	for _, uid := range sortedKeys(st.contactPoints) {
+		if cp := st.contactPoints[uid]; name == "" || cp.Name == name {
+			list = append(list, redactContactPoint(*cp))
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func redactContactPoint(cp grafana.ContactPoint) grafana.ContactPoint {
+	settings := make(map[string]interface{}, len(cp.Settings))
+	for k, v := range cp.Settings {
+		settings[k] = v
+	}
+	for _, k := range secureSettings[cp.Type] {
+		if _, ok := settings[k]; ok {
+			settings[k] = redacted
+		}
+	}
+	cp.Settings = settings
+	return cp
+}
+
+// receiverInUse reports whether a notification policy or an alert rule's
+// simplified routing sends to the contact point name
+func (st *store) receiverInUse(name string) bool {
+	var routed func(r *grafana.Route) bool
+	routed = func(r *grafana.Route) bool {
+		if r.Receiver == name {
+			return true
+		}
+		for _, child := range r.Routes {
+			if routed(child) {
+				return true
+			}
+		}
+		return false
+	}
+	if routed(&st.policies) {
+		return true
+	}
+	for _, rule := range st.rules {
+		if receiver, _ := rule.NotificationSettings["receiver"].(string); receiver == name {
+			return true
+		}
+	}
+	return false
+}
+
+// provenance is what Grafana records for a provisioning API write
+func provenance(req *http.Request) string {
+	if req.Header.Get("X-Disable-Provenance") != "" {
+		return ""
+	}
+	return "api"
+}
+
+func decode(w http.ResponseWriter, body []byte, v interface{}) bool {
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, 400, "bad request data: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func slug(model map[string]interface{}) string {
+	title, _ := model["title"].(string)
+	return strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, title), "-")
+}
+
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+	out := []string{}
+	for _, item := range list {
+		if s, ok := item.(string); ok {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(have, want []string) bool {
+	for _, w := range want {
+		if !containsString(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedIDs[T any](m map[int64]T) []int64 {
+	ids := make([]int64, 0, len(m))
+	for id := range m {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// pageArgs reads the page and perpage parameters of a search, as Grafana
+// defaults them
+func pageArgs(req *http.Request, defaultPerPage int) (page, perPage int) {
+	page, _ = strconv.Atoi(req.URL.Query().Get("page"))
+	perPage, _ = strconv.Atoi(req.URL.Query().Get("perpage"))
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 {
+		perPage = defaultPerPage
+	}
+	return page, perPage
+}